@@ -1,16 +1,50 @@
 package clientdto
 
+import (
+	"src/domain/money"
+)
+
 type AccountDto struct {
 	ID            int     `json:"id"`
 	ClientID      int     `json:"client_id"` // From Keycloak
 	AccountNumber string  `json:"account_number"`
-	Balance       float64 `json:"balance"`
+	Currency      string  `json:"currency"` // ISO 4217
+	Product       string  `json:"product"` // STANDARD, BUSINESS, SAVINGS...
+	Status        string  `json:"status"` // ACTIVE, FROZEN, DORMANT, CLOSED
+	StatusReason  *string `json:"status_reason,omitempty"`
+	Balance       money.Money `json:"balance"`
+	AvailableBalance money.Money `json:"available_balance"` // Balance minus the funds reserved by holds
+	OverdraftLimit   money.Money `json:"overdraft_limit"` // Arranged overdraft in force today
+	OverdraftHeadroom money.Money `json:"overdraft_headroom"` // Part of the overdraft not used yet
 	CreatedDate   string  `json:"created_date" binding:"required,datetime=2006-01-02 15:04:05"` // ISO 8601 date (YYYY-MM-DD HH:mm:ss)
 	UpdatedDate   string  `json:"updated_date" binding:"required,datetime=2006-01-02 15:04:05"` // ISO 8601 date (YYYY-MM-DD HH:mm:ss)
 }
 
 type CreateAccountRequest struct {
 	ClientID int `json:"client_id"`
+	Currency string `json:"currency,omitempty"` // ISO 4217, EUR when empty
+	Product  string `json:"product,omitempty"`  // Decides the fees, STANDARD when empty
+}
+
+// Freeze, unfreeze, dormant and reactivate an account
+type AccountStatusRequest struct {
+	Reason string `json:"reason" binding:"required,max=255"`
+}
+
+// Close an account. The settlement IBAN receives the balance, it is required unless the balance is zero
+type CloseAccountRequest struct {
+	Reason         string  `json:"reason" binding:"required,max=255"`
+	SettlementIban *string `json:"settlement_iban,omitempty"`
+}
+
+type AccountStatusChangeDto struct {
+	ID                      int     `json:"id"`
+	AccountID               int     `json:"account_id"`
+	FromStatus              string  `json:"from_status"`
+	ToStatus                string  `json:"to_status"`
+	Reason                  string  `json:"reason"`
+	SettlementTransactionID *int    `json:"settlement_transaction_id,omitempty"`
+	CreatedDate             string  `json:"created_date"` // YYYY-MM-DD HH:mm:ss
 }
 
 // Complete Client Registration - Open New Account
@@ -0,0 +1,19 @@
+package clientdto
+
+import (
+	"src/domain/money"
+	"time"
+)
+
+type ActivityEntryDto struct {
+    EntryID          int         `json:"entry_id"`
+    TransactionID    int         `json:"transaction_id"`
+    TransactionType  string      `json:"transaction_type"` // ADD, WITHDRAWAL, TRANSFER, REVERSAL
+    Direction        string      `json:"direction"` // IN, OUT
+    Kind             string      `json:"kind"` // PRINCIPAL, FEE: the fee of the transaction, or its refund
+    Amount           money.Money `json:"amount"`
+    BalanceAfter     money.Money `json:"balance_after"` // Balance of the account after the entry
+    CounterpartyIban *string     `json:"counterparty_iban"` // Empty for cash movements and fees
+    CounterpartyName *string     `json:"counterparty_name"`
+    CreatedAt        time.Time   `json:"created_at"`
+}
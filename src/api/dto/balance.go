@@ -0,0 +1,18 @@
+package clientdto
+
+import (
+	"src/domain/money"
+	"time"
+)
+
+type BalanceAtDto struct {
+    AccountID int         `json:"account_id"`
+    At        time.Time   `json:"at"` // Entries created before this instant
+    Balance   money.Money `json:"balance"`
+}
+
+// A point of the balance history: the balance at the end of the day
+type BalancePointDto struct {
+    Date    string      `json:"date"` // YYYY-MM-DD
+    Balance money.Money `json:"balance"`
+}
@@ -0,0 +1,34 @@
+package clientdto
+
+import (
+    "src/domain/money"
+    "time"
+)
+
+type CreateBeneficiaryDto struct {
+    Nickname  string  `json:"nickname" binding:"required"` // At most 70 characters
+    Iban      string  `json:"iban" binding:"required"` // Printed or electronic
+    Name      *string `json:"name,omitempty"` // Holder of the IBAN, the creditor of the transfers to other banks. At most 70 characters
+    Reference *string `json:"reference,omitempty"` // Default concept of the transfers, at most 140 characters
+}
+
+// Only the fields sent are changed, an empty name or reference removes it. The IBAN cannot be changed
+type UpdateBeneficiaryDto struct {
+    Nickname  *string `json:"nickname,omitempty"`
+    Name      *string `json:"name,omitempty"`
+    Reference *string `json:"reference,omitempty"`
+}
+
+type BeneficiaryDto struct {
+    ID              int         `json:"id"`
+    ClientID        int         `json:"client_id"`
+    Nickname        string      `json:"nickname"`
+    Iban            string      `json:"iban"` // Electronic format
+    Name            *string     `json:"name,omitempty"`
+    Reference       *string     `json:"reference,omitempty"`
+    CoolingOff      bool        `json:"cooling_off"` // The transfers to the beneficiary are still capped
+    CoolingOffUntil time.Time   `json:"cooling_off_until"`
+    CoolingOffLimit money.Money `json:"cooling_off_limit"` // Total that can be sent to the beneficiary until cooling_off_until
+    CreatedAt       time.Time   `json:"created_at"`
+    UpdatedAt       time.Time   `json:"updated_at"`
+}
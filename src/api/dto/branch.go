@@ -0,0 +1,24 @@
+package clientdto
+
+import "time"
+
+// Branch with the account numbers it has handed out
+type BranchDto struct {
+    Code      string    `json:"code"`
+    Name      string    `json:"name"`
+    Accounts  int       `json:"accounts"` // Client accounts whose IBAN has the bank and branch code
+    Allocated int64     `json:"allocated"` // Account numbers handed out by the sequence of the branch
+    Capacity  int64     `json:"capacity"`
+    Available int64     `json:"available"`
+    Usage     float64   `json:"usage"` // allocated / capacity, from 0 to 1
+    CreatedAt time.Time `json:"created_at"`
+    UpdatedAt time.Time `json:"updated_at"`
+}
+
+type SetBranchDto struct {
+    Name string `json:"name" binding:"required"`
+}
+
+type SetClientBranchDto struct {
+    BranchCode string `json:"branch_code" binding:"required"`
+}
@@ -22,6 +22,7 @@ type CreateClientRequest struct {
     TaxID          string `json:"tax_id"` // e.g., SSN, TIN
     Telephone      string `json:"telephone" binding:"required"` // Basic phone validation can be added
     ZipCode        string `json:"zip_code" binding:"required"`
+    BranchCode     string `json:"branch_code"` // Optional, the branch where the client's accounts are opened
 }
 
 type ClientResponse struct {
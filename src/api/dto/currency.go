@@ -0,0 +1,11 @@
+package clientdto
+
+type ExchangeRateDto struct {
+    Base  string `json:"base" binding:"required"` // ISO 4217
+    Quote string `json:"quote" binding:"required"` // ISO 4217
+    Rate  string `json:"rate" binding:"required"` // Units of quote per unit of base, i.e. "1.0850"
+}
+
+type UpdateExchangeRatesDto struct {
+    Rates []ExchangeRateDto `json:"rates" binding:"required,dive"`
+}
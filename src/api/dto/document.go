@@ -0,0 +1,19 @@
+package clientdto
+
+import (
+	"time"
+)
+
+// What the public verification of a document shows. The IBAN is masked: whoever checks the
+// code has the document already.
+type DocumentVerificationDto struct {
+    Valid            bool      `json:"valid"`
+    VerificationCode string    `json:"verification_code"`
+    Type             string    `json:"type"` // STATEMENT, OWNERSHIP_CERTIFICATE
+    Iban             string    `json:"iban"` // ES91****************7890
+    HolderName       string    `json:"holder_name"`
+    PeriodFrom       *string   `json:"period_from,omitempty"` // YYYY-MM-DD, statements only
+    PeriodTo         *string   `json:"period_to,omitempty"`
+    Sha256           string    `json:"sha256"` // Digest of the genuine PDF
+    IssuedAt         time.Time `json:"issued_at"`
+}
@@ -0,0 +1,41 @@
+package clientdto
+
+import (
+	"src/domain/money"
+	"time"
+)
+
+type ProductDto struct {
+    Code string `json:"code"`
+    Name string `json:"name"`
+}
+
+// A component of the fee charged on a transaction
+type FeeDto struct {
+    Kind   string      `json:"kind"` // FIXED, PERCENTAGE
+    Amount money.Money `json:"amount"`
+}
+
+// Replaces the fee of a transaction type, product and currency
+type SetFeeScheduleDto struct {
+    TransactionType     string       `json:"transaction_type" binding:"required"` // ADD, WITHDRAWAL, TRANSFER
+    Product             *string      `json:"product,omitempty"` // Every product without its own schedule when empty
+    Currency            string       `json:"currency" binding:"required"` // ISO 4217
+    FixedAmount         money.Money  `json:"fixed_amount"` // "0.50"
+    Percentage          string       `json:"percentage"` // "0.0025" is 0.25%. No percentage when empty
+    MinPercentageAmount *money.Money `json:"min_percentage_amount,omitempty"`
+    MaxPercentageAmount *money.Money `json:"max_percentage_amount,omitempty"`
+}
+
+type FeeScheduleDto struct {
+    ID                  int          `json:"id"`
+    TransactionType     string       `json:"transaction_type"`
+    Product             *string      `json:"product"`
+    Currency            string       `json:"currency"`
+    FixedAmount         money.Money  `json:"fixed_amount"`
+    Percentage          string       `json:"percentage"`
+    MinPercentageAmount *money.Money `json:"min_percentage_amount"`
+    MaxPercentageAmount *money.Money `json:"max_percentage_amount"`
+    CreatedAt           time.Time    `json:"created_at"`
+    UpdatedAt           time.Time    `json:"updated_at"`
+}
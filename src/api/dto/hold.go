@@ -0,0 +1,30 @@
+package clientdto
+
+import (
+	"src/domain/money"
+	"time"
+)
+
+type AuthorizeHoldDto struct {
+    AccountID       int         `json:"account_id" binding:"required"`
+    Amount          money.Money `json:"amount"` // "12.50", at most two decimals
+    ToAccountNumber *string     `json:"to_account_number,omitempty"` // Merchant account. The capture is a WITHDRAWAL when empty
+    Reference       *string     `json:"reference,omitempty"`
+}
+
+type CaptureHoldDto struct {
+    Amount *money.Money `json:"amount,omitempty"` // Partial capture. The whole hold when empty
+}
+
+type HoldDto struct {
+    ID              int         `json:"id"`
+    AccountID       int         `json:"account_id"`
+    ToAccountID     *int        `json:"to_account_id,omitempty"`
+    Amount          money.Money `json:"amount"`
+    CapturedAmount  money.Money `json:"captured_amount"`
+    Status          string      `json:"status"` // AUTHORIZED, CAPTURED, VOIDED, EXPIRED
+    Reference       *string     `json:"reference,omitempty"`
+    ExpiresAt       time.Time   `json:"expires_at"`
+    CreatedAt       time.Time   `json:"created_at"`
+    UpdatedAt       time.Time   `json:"updated_at"`
+}
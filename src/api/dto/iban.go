@@ -0,0 +1,16 @@
+package clientdto
+
+// Validation of an IBAN, with the bank of the directory when it is listed
+type IbanDto struct {
+    Valid            bool    `json:"valid"`
+    Reason           *string `json:"reason,omitempty"` // UNSUPPORTED_COUNTRY, LENGTH, FORMAT, CHECK_DIGITS, NATIONAL_CHECK_DIGITS
+    Message          *string `json:"message,omitempty"`
+    ElectronicFormat string  `json:"electronic_format"` // ES9121000418450200051332
+    PrintFormat      string  `json:"print_format"` // ES91 2100 0418 4502 0005 1332
+    CountryCode      string  `json:"country_code,omitempty"`
+    BankCode         string  `json:"bank_code,omitempty"`
+    BranchCode       string  `json:"branch_code,omitempty"`
+    AccountNumber    string  `json:"account_number,omitempty"`
+    Bic              *string `json:"bic,omitempty"`
+    BankName         *string `json:"bank_name,omitempty"`
+}
@@ -0,0 +1,33 @@
+package clientdto
+
+import (
+	"src/domain/money"
+	"time"
+)
+
+// Sets the credit interest of a product and currency from a day
+type SetInterestRateDto struct {
+    Product       string `json:"product" binding:"required"` // STANDARD, BUSINESS, SAVINGS...
+    Currency      string `json:"currency" binding:"required"` // ISO 4217
+    Rate          string `json:"rate" binding:"required"` // Yearly rate, "0.015" is 1.5%. "0" stops the interest
+    DayCount      string `json:"day_count,omitempty"` // ACT/365, ACT/360. ACT/365 when empty
+    EffectiveFrom string `json:"effective_from" binding:"required,datetime=2006-01-02"` // YYYY-MM-DD, may be in the middle of a month
+}
+
+type InterestRateDto struct {
+    ID            int       `json:"id"`
+    Product       string    `json:"product"`
+    Currency      string    `json:"currency"`
+    Rate          string    `json:"rate"`
+    DayCount      string    `json:"day_count"`
+    EffectiveFrom string    `json:"effective_from"` // YYYY-MM-DD
+    CreatedAt     time.Time `json:"created_at"`
+}
+
+type InterestAccrualDto struct {
+    AccrualDate  string      `json:"accrual_date"` // YYYY-MM-DD
+    Balance      money.Money `json:"balance"` // End of day balance
+    InterestRate string      `json:"interest_rate"`
+    DayCount     string      `json:"day_count"`
+    Amount       string      `json:"amount"` // Not rounded, 10 decimals
+}
@@ -1,6 +1,7 @@
 package clientdto
 
 import (
+	"src/domain/money"
 	"time"
 )
 
@@ -10,6 +11,6 @@ type LedgerEntryDto struct {
     TransactionID int      `json:"transaction_id"`
     AccountID    int       `json:"account_id"`
     Type         string    `json:"type"` // credit, debit
-    Amount       float64   `json:"amount"`
+    Amount       money.Money `json:"amount"`
     CreatedAt    time.Time `json:"created_at"`
 }
\ No newline at end of file
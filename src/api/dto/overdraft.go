@@ -0,0 +1,24 @@
+package clientdto
+
+import (
+	"src/domain/money"
+	"time"
+)
+
+// Replaces the overdraft of the account
+type SetOverdraftDto struct {
+    Limit        money.Money `json:"limit"` // "500.00", zero to stop new drawings
+    InterestRate string      `json:"interest_rate"` // Yearly debit interest, "0.095" is 9.5%. No interest when empty
+    ValidFrom    string      `json:"valid_from" binding:"required,datetime=2006-01-02"` // YYYY-MM-DD
+    ValidTo      *string     `json:"valid_to,omitempty" binding:"omitempty,datetime=2006-01-02"` // YYYY-MM-DD, inclusive. Open ended when empty
+}
+
+type OverdraftDto struct {
+    AccountID    int         `json:"account_id"`
+    Limit        money.Money `json:"limit"`
+    InterestRate string      `json:"interest_rate"`
+    ValidFrom    string      `json:"valid_from"` // YYYY-MM-DD
+    ValidTo      *string     `json:"valid_to"` // YYYY-MM-DD
+    CreatedAt    time.Time   `json:"created_at"`
+    UpdatedAt    time.Time   `json:"updated_at"`
+}
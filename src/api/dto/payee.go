@@ -0,0 +1,14 @@
+package clientdto
+
+// Name typed by the payer for the account of a transfer
+type VerifyPayeeDto struct {
+    AccountNumber string `json:"account_number" binding:"required"` // IBAN, printed or electronic
+    Name          string `json:"name" binding:"required"`
+}
+
+// Result of a Confirmation of Payee check
+type PayeeVerificationDto struct {
+    AccountNumber string  `json:"account_number"` // Electronic format
+    Result        string  `json:"result"` // MATCH, CLOSE_MATCH, NO_MATCH, NOT_AVAILABLE
+    SuggestedName *string `json:"suggested_name,omitempty"` // Masked name of the holder, only for CLOSE_MATCH
+}
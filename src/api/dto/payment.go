@@ -0,0 +1,58 @@
+package clientdto
+
+import (
+	"src/domain/money"
+	"time"
+)
+
+// SEPA credit transfer to an IBAN of another bank
+type OutboundPaymentDto struct {
+    ID                    int         `json:"id"`
+    AccountID             int         `json:"account_id"`
+    TransactionID         int         `json:"transaction_id"` // Debit of the account
+    RefundTransactionID   *int        `json:"refund_transaction_id,omitempty"` // Once rejected
+    EndToEndID            string      `json:"end_to_end_id"`
+    CreditorIban          string      `json:"creditor_iban"`
+    CreditorName          string      `json:"creditor_name"`
+    Amount                money.Money `json:"amount"`
+    Currency              string      `json:"currency"`
+    RemittanceInformation *string     `json:"remittance_information,omitempty"`
+    Status                string      `json:"status"` // PENDING, SUBMITTED, SETTLED, REJECTED
+    StatusReason          *string     `json:"status_reason,omitempty"` // ISO reason code of a rejection, i.e. AC01
+    CreatedAt             time.Time   `json:"created_at"`
+    UpdatedAt             time.Time   `json:"updated_at"`
+}
+
+// SEPA credit transfer received from another bank
+type InboundPaymentDto struct {
+    ID                      int         `json:"id"`
+    MessageID               string      `json:"message_id"`
+    MessageType             string      `json:"message_type"` // pacs.008 or camt.054
+    EndToEndID              string      `json:"end_to_end_id"`
+    DebtorIban              *string     `json:"debtor_iban,omitempty"`
+    DebtorName              *string     `json:"debtor_name,omitempty"`
+    CreditorIban            string      `json:"creditor_iban"`
+    CreditorName            *string     `json:"creditor_name,omitempty"`
+    Amount                  money.Money `json:"amount"`
+    Currency                string      `json:"currency"`
+    RemittanceInformation   *string     `json:"remittance_information,omitempty"`
+    Status                  string      `json:"status"` // CREDITED, EXCEPTION, RESOLVED, RETURNED
+    ExceptionReason         *string     `json:"exception_reason,omitempty"` // ACCOUNT_NOT_FOUND, ACCOUNT_NOT_ACTIVE
+    AccountID               *int        `json:"account_id,omitempty"` // Account credited, once known
+    TransactionID           int         `json:"transaction_id"` // Credit from the settlement account
+    ResolutionTransactionID *int        `json:"resolution_transaction_id,omitempty"` // Out of suspense, once resolved or returned
+    ReturnReason            *string     `json:"return_reason,omitempty"` // ISO reason code, once returned
+    CreatedAt               time.Time   `json:"created_at"`
+    UpdatedAt               time.Time   `json:"updated_at"`
+}
+
+// Credit an inbound payment held in suspense to a client account
+type ResolveInboundPaymentDto struct {
+    AccountNumber string `json:"account_number" binding:"required"`
+}
+
+// Send an inbound payment held in suspense back to the bank of the debtor. Without a reason code AC01 is
+// sent, or AC04 when the account is closed
+type ReturnInboundPaymentDto struct {
+    ReasonCode string `json:"reason_code" binding:"omitempty,len=4"`
+}
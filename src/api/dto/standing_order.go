@@ -0,0 +1,47 @@
+package clientdto
+
+import (
+	"src/domain/money"
+	"time"
+)
+
+type CreateStandingOrderDto struct {
+    ToAccountNumber string      `json:"to_account_number" binding:"required"`
+    Amount          money.Money `json:"amount"` // "12.50", at most two decimals
+    Frequency       string      `json:"frequency" binding:"required"` // DAILY, WEEKLY, MONTHLY, END_OF_MONTH
+    StartDate       string      `json:"start_date" binding:"required,datetime=2006-01-02"` // YYYY-MM-DD
+    EndDate         *string     `json:"end_date,omitempty" binding:"omitempty,datetime=2006-01-02"` // YYYY-MM-DD. No end when empty
+    Reference       *string     `json:"reference,omitempty"`
+}
+
+// Only the fields sent are changed
+type UpdateStandingOrderDto struct {
+    Amount    *money.Money `json:"amount,omitempty"`
+    EndDate   *string      `json:"end_date,omitempty" binding:"omitempty,datetime=2006-01-02"` // YYYY-MM-DD
+    Reference *string      `json:"reference,omitempty"`
+}
+
+type StandingOrderDto struct {
+    ID              int         `json:"id"`
+    AccountID       int         `json:"account_id"`
+    ToAccountNumber string      `json:"to_account_number"`
+    Amount          money.Money `json:"amount"`
+    Frequency       string      `json:"frequency"`
+    StartDate       string      `json:"start_date"` // YYYY-MM-DD
+    EndDate         *string     `json:"end_date"` // YYYY-MM-DD
+    NextRunDate     *string     `json:"next_run_date"` // YYYY-MM-DD. Empty once finished or cancelled
+    Status          string      `json:"status"` // ACTIVE, FINISHED, CANCELLED
+    Reference       *string     `json:"reference,omitempty"`
+    Runs            []StandingOrderRunDto `json:"runs,omitempty"`
+    CreatedAt       time.Time   `json:"created_at"`
+    UpdatedAt       time.Time   `json:"updated_at"`
+}
+
+type StandingOrderRunDto struct {
+    ID            int       `json:"id"`
+    RunDate       string    `json:"run_date"` // YYYY-MM-DD
+    Status        string    `json:"status"` // SUCCEEDED, FAILED
+    FailureReason *string   `json:"failure_reason,omitempty"`
+    TransactionID *int      `json:"transaction_id,omitempty"`
+    CreatedAt     time.Time `json:"created_at"`
+}
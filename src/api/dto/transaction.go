@@ -1,6 +1,7 @@
 package clientdto
 
 import (
+	"src/domain/money"
 	"time"
 )
 
@@ -9,16 +10,31 @@ import (
 type PerformTransactionDto struct {
     AccountID   int       `json:"account_id"`
     Type        string    `json:"type"` // ADD, WITHDRAWAL, TRANSFER
-    Amount      float64   `json:"amount"`
+    Amount      money.Money `json:"amount"` // "12.50", at most two decimals
     ToAccountNumber *string       `json:"to_account_number,omitempty"` // For transfers
+    ToName      *string   `json:"to_name,omitempty"` // Creditor of a transfer to another bank (SEPA)
+    RemittanceInformation *string `json:"remittance_information,omitempty"` // Concept of a transfer to another bank, at most 140 characters
+    BeneficiaryID *int `json:"beneficiary_id,omitempty"` // Saved beneficiary of a transfer, instead of to_account_number
 }
 
 type TransactionDto struct {
     ID          int       `json:"id"`
     AccountID   int       `json:"account_id"`
     Type        string    `json:"type"` // ADD, WITHDRAWAL, TRANSFER
-    Amount      float64   `json:"amount"`
+    Amount      money.Money `json:"amount"`
     ToAccountNumber *string `json:"to_account_number"` // For transfers
+    ReversalOf  *int      `json:"reversal_of,omitempty"` // For reversals
+    ReasonCode  *string   `json:"reason_code,omitempty"` // For reversals
+    HoldID      *int      `json:"hold_id,omitempty"` // For captured holds
+    FxRate      *string   `json:"fx_rate,omitempty"` // For transfers between currencies
+    ToAmount    *money.Money `json:"to_amount,omitempty"` // For transfers between currencies, in the destination currency
+    FeeAmount   money.Money `json:"fee_amount"` // Paid by the source account on top of the amount
+    Fees        []FeeDto  `json:"fees,omitempty"` // Components of the fee, when the transaction is performed
     CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type ReverseTransactionDto struct {
+    Amount      *money.Money `json:"amount,omitempty"` // Partial reversal. Everything left to reverse when empty
+    ReasonCode  string       `json:"reason_code" binding:"required"` // DUPLICATE, FRAUD, CUSTOMER_REQUEST, TECHNICAL_ERROR, WRONG_AMOUNT
 }
\ No newline at end of file
@@ -40,7 +40,8 @@ type IAccountHandler struct {
 // @Failure 400 {object} map[string]string "Bad Request"
 // @Router /accounts/:client_id [get]
 func (h *IAccountHandler) FetchAccounts(c *gin.Context) {
-	clientIDStr := c.Param("client_id")
+	// the route wildcard is :id, shared with the /accounts/:id/... routes of a single account
+	clientIDStr := c.Param("id")
 
 	clientID, err := strconv.Atoi(clientIDStr)
 	if err != nil {
@@ -60,14 +61,12 @@ func (h *IAccountHandler) FetchAccounts(c *gin.Context) {
 	}
 	var accounts []dto.AccountDto
 	for _, accountEntity := range accEntities {
-		accountDto := mappers.ToAccountDTO(accountEntity, nil)
-
-		balance, error := h.TransactionRepository.FetchAccountBalance(c, nil, accountDto.ID)
+		balance, error := h.TransactionRepository.FetchAccountBalance(c, nil, accountEntity.ID)
 		if error != nil {
 			error.JsonError(c)
 			return
 		}
-		accountDto.Balance = *balance
+		accountDto := mappers.ToAccountDTO(accountEntity, balance)
 		accounts = append(accounts, accountDto)
 	}
 
@@ -85,7 +84,7 @@ func (h *IAccountHandler) CreateAccount(c *gin.Context) {
 		return
 	}
 
-	account, err := h.AccountService.CreateAccount(createAccountReq.ClientID)
+	account, err := h.AccountService.CreateAccount(createAccountReq.ClientID, createAccountReq.Currency, createAccountReq.Product)
 
 	if err != nil {
 		err.JsonError(c)
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"net/http"
+	dto "src/api/dto"
+	accountentity "src/domain/account"
+	mappers "src/mappers"
+	repositories "src/repositories"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AccountStatusHandler interface {
+	FreezeAccount(c *gin.Context)
+	UnfreezeAccount(c *gin.Context)
+	MarkAccountDormant(c *gin.Context)
+	ReactivateAccount(c *gin.Context)
+	CloseAccount(c *gin.Context)
+	FetchStatusChanges(c *gin.Context)
+}
+
+type IAccountStatusHandler struct {
+	AccountStatusRepository repositories.AccountStatusRepository
+	TransactionRepository   repositories.TransactionRepository
+}
+
+// POST /admin/accounts/:id/freeze
+//
+// Blocks all the money in and out of the account. Bank staff only.
+func (h *IAccountStatusHandler) FreezeAccount(c *gin.Context) {
+	h.changeStatus(c, "", accountentity.StatusFrozen)
+}
+
+// POST /admin/accounts/:id/unfreeze
+//
+// Activates a frozen account again. Bank staff only.
+func (h *IAccountStatusHandler) UnfreezeAccount(c *gin.Context) {
+	h.changeStatus(c, accountentity.StatusFrozen, accountentity.StatusActive)
+}
+
+// POST /admin/accounts/:id/dormant
+//
+// Marks an unused account as dormant: it can still receive money, not send it. Bank staff only.
+func (h *IAccountStatusHandler) MarkAccountDormant(c *gin.Context) {
+	h.changeStatus(c, "", accountentity.StatusDormant)
+}
+
+// POST /admin/accounts/:id/reactivate
+//
+// Activates a dormant account again. Bank staff only.
+func (h *IAccountStatusHandler) ReactivateAccount(c *gin.Context) {
+	h.changeStatus(c, accountentity.StatusDormant, accountentity.StatusActive)
+}
+
+func (h *IAccountStatusHandler) changeStatus(c *gin.Context, fromStatus, toStatus string) {
+	accountID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid identifier"})
+		return
+	}
+	var accountStatusRequest dto.AccountStatusRequest
+	if err := c.ShouldBindJSON(&accountStatusRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	account, appErr := h.AccountStatusRepository.ChangeStatusTx(c.Request.Context(), accountID, fromStatus, toStatus, accountStatusRequest.Reason)
+	if appErr != nil {
+		appErr.JsonError(c)
+		return
+	}
+	h.respondAccount(c, account, nil)
+}
+
+// POST /admin/accounts/:id/close
+//
+// Closes the account for good. A positive balance is first transferred to the settlement IBAN.
+// Bank staff only.
+func (h *IAccountStatusHandler) CloseAccount(c *gin.Context) {
+	accountID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid identifier"})
+		return
+	}
+	var closeAccountRequest dto.CloseAccountRequest
+	if err := c.ShouldBindJSON(&closeAccountRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	account, settlement, appErr := h.AccountStatusRepository.CloseTx(c.Request.Context(), accountID, closeAccountRequest.Reason, closeAccountRequest.SettlementIban)
+	if appErr != nil {
+		appErr.JsonError(c)
+		return
+	}
+	if settlement == nil {
+		h.respondAccount(c, account, nil)
+		return
+	}
+	transactionDto, err := mappers.ToTransactionDto(*settlement)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+	h.respondAccount(c, account, &transactionDto)
+}
+
+func (h *IAccountStatusHandler) respondAccount(c *gin.Context, account accountentity.AccountEntity, settlement *dto.TransactionDto) {
+	balance, appErr := h.TransactionRepository.FetchAccountBalance(c.Request.Context(), nil, account.ID)
+	if appErr != nil {
+		appErr.JsonError(c)
+		return
+	}
+	response := gin.H{"account": mappers.ToAccountDTO(account, balance)}
+	if settlement != nil {
+		response["settlement_transaction"] = settlement
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GET /admin/accounts/:id/status-changes
+//
+// Audit trail of the status changes of the account. Bank staff only.
+func (h *IAccountStatusHandler) FetchStatusChanges(c *gin.Context) {
+	accountID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid identifier"})
+		return
+	}
+	changes, appErr := h.AccountStatusRepository.FetchStatusChanges(c.Request.Context(), accountID)
+	if appErr != nil {
+		appErr.JsonError(c)
+		return
+	}
+	changeDtos := make([]dto.AccountStatusChangeDto, 0, len(changes))
+	for _, change := range changes {
+		changeDtos = append(changeDtos, mappers.ToAccountStatusChangeDto(change))
+	}
+	c.JSON(http.StatusOK, gin.H{"status_changes": changeDtos})
+}
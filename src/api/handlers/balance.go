@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	dto "src/api/dto"
+	accountentity "src/domain/account"
+	scheduledtransferentity "src/domain/scheduled_transfer"
+	mappers "src/mappers"
+	repositories "src/repositories"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BalanceHandler interface {
+	FetchBalanceAt(c *gin.Context)
+	FetchBalanceHistory(c *gin.Context)
+}
+
+type IBalanceHandler struct {
+	BalanceRepository repositories.BalanceRepository
+}
+
+// GET /accounts/:id/balance?at=2026-03-31T23:59:59Z
+//
+// Returns the balance of the account at an instant, now when at is empty. A YYYY-MM-DD date
+// is the end of that day, so at=2026-03-31 is the balance on 31 March.
+func (h *IBalanceHandler) FetchBalanceAt(c *gin.Context) {
+	accountID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid identifier"})
+		return
+	}
+	at := time.Now().UTC()
+	if value := c.Query("at"); value != "" {
+		at, err = time.Parse(time.RFC3339, value)
+		if err != nil {
+			var day time.Time
+			day, err = time.Parse(time.DateOnly, value)
+			at = day.AddDate(0, 0, 1)
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "at must be a RFC 3339 date-time or YYYY-MM-DD"})
+			return
+		}
+		at = at.UTC()
+	}
+
+	balance, appErr := h.BalanceRepository.FetchBalanceAt(c.Request.Context(), nil, accountID, at)
+	if appErr != nil {
+		appErr.JsonError(c)
+		return
+	}
+	c.JSON(http.StatusOK, dto.BalanceAtDto{AccountID: accountID, At: at, Balance: balance})
+}
+
+// GET /accounts/:id/balance-history?from=2026-03-01&to=2026-03-31
+//
+// Returns the end of day balances of the account, both days included. The last 30 days up to
+// yesterday by default, at most a year. Today is not returned until the day is over.
+func (h *IBalanceHandler) FetchBalanceHistory(c *gin.Context) {
+	accountID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid identifier"})
+		return
+	}
+	to := scheduledtransferentity.Today().AddDate(0, 0, -1)
+	if value := c.Query("to"); value != "" {
+		if to, err = time.Parse(time.DateOnly, value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to must be YYYY-MM-DD"})
+			return
+		}
+	}
+	from := to.AddDate(0, 0, -29)
+	if value := c.Query("from"); value != "" {
+		if from, err = time.Parse(time.DateOnly, value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must be YYYY-MM-DD"})
+			return
+		}
+	}
+	if to.Before(from) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to cannot be before from"})
+		return
+	}
+	if to.Sub(from) >= accountentity.BalanceHistoryMaxDays*24*time.Hour {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("the history cannot be longer than %d days", accountentity.BalanceHistoryMaxDays)})
+		return
+	}
+
+	snapshots, appErr := h.BalanceRepository.FetchBalanceHistory(c.Request.Context(), accountID, from, to)
+	if appErr != nil {
+		appErr.JsonError(c)
+		return
+	}
+	balances := make([]dto.BalancePointDto, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		balances = append(balances, mappers.ToBalancePointDto(snapshot))
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"account_id": accountID,
+		"from":       from.Format(time.DateOnly),
+		"to":         to.Format(time.DateOnly),
+		"balances":   balances,
+	})
+}
@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"os"
+	dto "src/api/dto"
+	beneficiaryentity "src/domain/beneficiary"
+	"src/domain/money"
+	mappers "src/mappers"
+	repositories "src/repositories"
+	"src/utils"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BeneficiaryHandler interface {
+	FetchBeneficiaries(c *gin.Context)
+	FetchBeneficiary(c *gin.Context)
+	CreateBeneficiary(c *gin.Context)
+	UpdateBeneficiary(c *gin.Context)
+	DeleteBeneficiary(c *gin.Context)
+}
+
+type IBeneficiaryHandler struct {
+	BeneficiaryRepository repositories.BeneficiaryRepository
+	CoolingOff            time.Duration // How long the transfers to a new beneficiary are capped
+	CoolingOffLimit       money.Money   // Total that can be sent to a new beneficiary during its cooling-off
+}
+
+// BeneficiaryCoolingOffFromEnv reads BENEFICIARY_COOLING_OFF (i.e. 24h) and BENEFICIARY_COOLING_OFF_LIMIT
+// (i.e. 300.00). Default to 24 hours and 300.00. The method is supposed to be used after the .env is loaded
+func BeneficiaryCoolingOffFromEnv() (time.Duration, money.Money) {
+	coolingOff, err := time.ParseDuration(os.Getenv("BENEFICIARY_COOLING_OFF"))
+	if err != nil || coolingOff < 0 {
+		coolingOff = beneficiaryentity.DefaultCoolingOff
+	}
+	limit, err := money.Parse(os.Getenv("BENEFICIARY_COOLING_OFF_LIMIT"))
+	if err != nil || limit.IsNegative() {
+		limit = beneficiaryentity.DefaultCoolingOffLimit
+	}
+	return coolingOff, limit
+}
+
+// :identification is the client id, see the routes
+const beneficiaryClientParam = "identification"
+
+// GET /clients/:id/beneficiaries
+func (h *IBeneficiaryHandler) FetchBeneficiaries(c *gin.Context) {
+	clientID, err := strconv.Atoi(c.Param(beneficiaryClientParam))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid identifier"})
+		return
+	}
+	entities, appErr := h.BeneficiaryRepository.FetchBeneficiariesByClient(c.Request.Context(), clientID)
+	if appErr != nil {
+		appErr.JsonError(c)
+		return
+	}
+	beneficiaries := make([]dto.BeneficiaryDto, 0, len(entities))
+	for _, entity := range entities {
+		beneficiaries = append(beneficiaries, mappers.ToBeneficiaryDto(entity))
+	}
+	c.JSON(http.StatusOK, gin.H{"beneficiaries": beneficiaries})
+}
+
+// GET /clients/:id/beneficiaries/:beneficiary_id
+func (h *IBeneficiaryHandler) FetchBeneficiary(c *gin.Context) {
+	entity, ok := h.fetchClientBeneficiary(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"beneficiary": mappers.ToBeneficiaryDto(entity)})
+}
+
+// POST /clients/:id/beneficiaries
+//
+// Saves an IBAN to send transfers to with beneficiary_id. Until the cooling-off is over, the transfers to the
+// new beneficiary cannot add up to more than the cooling-off limit.
+func (h *IBeneficiaryHandler) CreateBeneficiary(c *gin.Context) {
+	clientID, err := strconv.Atoi(c.Param(beneficiaryClientParam))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid identifier"})
+		return
+	}
+	var createBeneficiaryDto dto.CreateBeneficiaryDto
+	if err := c.ShouldBindJSON(&createBeneficiaryDto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	iban := utils.NormalizeIban(createBeneficiaryDto.Iban)
+	if _, err := (&utils.IbanHandler{}).Parse(iban); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "iban is not valid: " + err.Error()})
+		return
+	}
+	entity := beneficiaryentity.BeneficiaryEntity{
+		ClientID:        clientID,
+		Iban:            iban,
+		CoolingOffUntil: time.Now().Add(h.CoolingOff),
+		CoolingOffLimit: h.CoolingOffLimit,
+	}
+	if !applyBeneficiaryFields(c, &entity, &createBeneficiaryDto.Nickname, createBeneficiaryDto.Name, createBeneficiaryDto.Reference) {
+		return
+	}
+	if appErr := h.BeneficiaryRepository.InsertBeneficiary(c.Request.Context(), &entity); appErr != nil {
+		appErr.JsonError(c)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"beneficiary": mappers.ToBeneficiaryDto(entity)})
+}
+
+// PATCH /clients/:id/beneficiaries/:beneficiary_id
+//
+// Changes the nickname, the name or the reference. A new IBAN is a new beneficiary, with its own cooling-off.
+func (h *IBeneficiaryHandler) UpdateBeneficiary(c *gin.Context) {
+	entity, ok := h.fetchClientBeneficiary(c)
+	if !ok {
+		return
+	}
+	var updateBeneficiaryDto dto.UpdateBeneficiaryDto
+	if err := c.ShouldBindJSON(&updateBeneficiaryDto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !applyBeneficiaryFields(c, &entity, updateBeneficiaryDto.Nickname, updateBeneficiaryDto.Name, updateBeneficiaryDto.Reference) {
+		return
+	}
+	if appErr := h.BeneficiaryRepository.UpdateBeneficiary(c.Request.Context(), &entity); appErr != nil {
+		appErr.JsonError(c)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"beneficiary": mappers.ToBeneficiaryDto(entity)})
+}
+
+// DELETE /clients/:id/beneficiaries/:beneficiary_id
+//
+// Removes the beneficiary. The transfers sent to it are kept. 409 during its cooling-off.
+func (h *IBeneficiaryHandler) DeleteBeneficiary(c *gin.Context) {
+	entity, ok := h.fetchClientBeneficiary(c)
+	if !ok {
+		return
+	}
+	if appErr := h.BeneficiaryRepository.DeleteBeneficiary(c.Request.Context(), entity.ID); appErr != nil {
+		appErr.JsonError(c)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// applyBeneficiaryFields validates and sets the fields sent, nil ones are left as they are. When they are not
+// valid, the error response has already been written and false is returned.
+func applyBeneficiaryFields(c *gin.Context, entity *beneficiaryentity.BeneficiaryEntity, nickname, name, reference *string) bool {
+	if nickname != nil {
+		value := strings.TrimSpace(*nickname)
+		if value == "" || utf8.RuneCountInString(value) > beneficiaryentity.MaxNicknameLength {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("nickname must have between 1 and %d characters", beneficiaryentity.MaxNicknameLength)})
+			return false
+		}
+		entity.Nickname = value
+	}
+	if name != nil {
+		value := strings.TrimSpace(*name)
+		if utf8.RuneCountInString(value) > beneficiaryentity.MaxNameLength {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("name cannot be longer than %d characters", beneficiaryentity.MaxNameLength)})
+			return false
+		}
+		entity.Name = sql.NullString{String: value, Valid: value != ""}
+	}
+	if reference != nil {
+		value := strings.TrimSpace(*reference)
+		if utf8.RuneCountInString(value) > beneficiaryentity.MaxReferenceLength {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("reference cannot be longer than %d characters", beneficiaryentity.MaxReferenceLength)})
+			return false
+		}
+		entity.Reference = sql.NullString{String: value, Valid: value != ""}
+	}
+	return true
+}
+
+// fetchClientBeneficiary reads the :beneficiary_id beneficiary, which must belong to the :id client.
+// When it fails, the error response has already been written and false is returned.
+func (h *IBeneficiaryHandler) fetchClientBeneficiary(c *gin.Context) (beneficiaryentity.BeneficiaryEntity, bool) {
+	clientID, err := strconv.Atoi(c.Param(beneficiaryClientParam))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid identifier"})
+		return beneficiaryentity.BeneficiaryEntity{}, false
+	}
+	beneficiaryID, err := strconv.Atoi(c.Param("beneficiary_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid identifier"})
+		return beneficiaryentity.BeneficiaryEntity{}, false
+	}
+	entity, appErr := h.BeneficiaryRepository.FetchBeneficiaryById(c.Request.Context(), beneficiaryID)
+	if appErr != nil {
+		appErr.JsonError(c)
+		return beneficiaryentity.BeneficiaryEntity{}, false
+	}
+	if entity.ClientID != clientID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Beneficiary not found"})
+		return beneficiaryentity.BeneficiaryEntity{}, false
+	}
+	return entity, true
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"net/http"
+	dto "src/api/dto"
+	services "src/api/service"
+	branchentity "src/domain/branch"
+	mappers "src/mappers"
+	repositories "src/repositories"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BranchHandler interface {
+	FetchBranches(c *gin.Context)
+	SetBranch(c *gin.Context)
+	SetClientBranch(c *gin.Context)
+}
+
+type IBranchHandler struct {
+	BranchRepository repositories.BranchRepository
+}
+
+// GET /admin/branches
+//
+// Lists the branches with their accounts and how much of their account numbers have been handed out.
+// Bank staff only.
+func (h *IBranchHandler) FetchBranches(c *gin.Context) {
+	bankCode, _, err := services.BankCodes()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	branches, appErr := h.BranchRepository.FetchBranches(c.Request.Context(), bankCode)
+	if appErr != nil {
+		appErr.JsonError(c)
+		return
+	}
+	branchDtos := make([]dto.BranchDto, 0, len(branches))
+	for _, branch := range branches {
+		branchDtos = append(branchDtos, mappers.ToBranchDto(branch))
+	}
+	c.JSON(http.StatusOK, gin.H{"branches": branchDtos})
+}
+
+// PUT /admin/branches/:code
+//
+// Opens the branch, or renames it. Bank staff only.
+func (h *IBranchHandler) SetBranch(c *gin.Context) {
+	code := c.Param("code")
+	if !branchentity.IsValidCode(code) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": branchentity.ErrInvalidCode.Error()})
+		return
+	}
+	var setBranchDto dto.SetBranchDto
+	if err := c.ShouldBindJSON(&setBranchDto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	branch := branchentity.BranchEntity{Code: code, Name: setBranchDto.Name}
+	if appErr := h.BranchRepository.UpsertBranch(c.Request.Context(), &branch); appErr != nil {
+		appErr.JsonError(c)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"branch": mappers.ToBranchDto(branchentity.BranchUsage{BranchEntity: branch})})
+}
+
+// PUT /admin/clients/:id/branch
+//
+// Moves the client to the branch: the accounts opened from now on get its branch code, the ones
+// opened before keep their IBAN. Bank staff only.
+func (h *IBranchHandler) SetClientBranch(c *gin.Context) {
+	clientID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid identifier"})
+		return
+	}
+	var setClientBranchDto dto.SetClientBranchDto
+	if err := c.ShouldBindJSON(&setClientBranchDto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if appErr := h.BranchRepository.SetClientBranch(c.Request.Context(), clientID, setClientBranchDto.BranchCode); appErr != nil {
+		appErr.JsonError(c)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"client_id": clientID, "branch_code": setClientBranchDto.BranchCode})
+}
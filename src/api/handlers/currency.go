@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"net/http"
+	dto "src/api/dto"
+	services "src/api/service"
+	currencyentity "src/domain/currency"
+	repositories "src/repositories"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CurrencyHandler interface {
+	FetchCurrencies(c *gin.Context)
+	FetchExchangeRates(c *gin.Context)
+	UpdateExchangeRates(c *gin.Context)
+}
+
+type ICurrencyHandler struct {
+	CurrencyRepository  repositories.CurrencyRepository
+	ExchangeRateService services.ExchangeRateService
+}
+
+// GET /currencies
+func (h *ICurrencyHandler) FetchCurrencies(c *gin.Context) {
+	currencies, appErr := h.CurrencyRepository.FetchCurrencies(c.Request.Context())
+	if appErr != nil {
+		appErr.JsonError(c)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"currencies": currencies})
+}
+
+// GET /exchange-rates
+func (h *ICurrencyHandler) FetchExchangeRates(c *gin.Context) {
+	rates, appErr := h.ExchangeRateService.FetchExchangeRates(c.Request.Context())
+	if appErr != nil {
+		appErr.JsonError(c)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"exchange_rates": rates})
+}
+
+// PUT /admin/exchange-rates
+//
+// Stores the rates of a JSON body, or of a text/csv body with the format of EXCHANGE_RATES_FILE.
+// Pairs not included keep their rate. Bank staff only.
+func (h *ICurrencyHandler) UpdateExchangeRates(c *gin.Context) {
+	var rates []currencyentity.ExchangeRateEntity
+	if strings.HasPrefix(c.ContentType(), "text/csv") {
+		parsed, err := services.ParseExchangeRatesCSV(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		rates = parsed
+	} else {
+		var request dto.UpdateExchangeRatesDto
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		for _, rate := range request.Rates {
+			rates = append(rates, currencyentity.ExchangeRateEntity{Base: rate.Base, Quote: rate.Quote, Rate: rate.Rate})
+		}
+	}
+
+	if appErr := h.ExchangeRateService.UpdateExchangeRates(c.Request.Context(), rates); appErr != nil {
+		appErr.JsonError(c)
+		return
+	}
+	h.FetchExchangeRates(c)
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	services "src/api/service"
+	documententity "src/domain/document"
+	mappers "src/mappers"
+	repositories "src/repositories"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DocumentHandler interface {
+	FetchStatementPdf(c *gin.Context)
+	FetchOwnershipCertificate(c *gin.Context)
+	VerifyDocument(c *gin.Context)
+}
+
+type IDocumentHandler struct {
+	DocumentService    services.DocumentService
+	DocumentRepository repositories.DocumentRepository
+}
+
+// GET /accounts/:id/documents/statement?month=2026-03
+//
+// Issues the PDF statement of a calendar month, the previous one by default. Every call issues
+// a new document with its own verification code.
+func (h *IDocumentHandler) FetchStatementPdf(c *gin.Context) {
+	accountID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid identifier"})
+		return
+	}
+	now := time.Now().UTC()
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -1, 0)
+	if value := c.Query("month"); value != "" {
+		if month, err = time.Parse("2006-01", value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "month must be YYYY-MM"})
+			return
+		}
+	}
+
+	content, document, appErr := h.DocumentService.IssueStatement(c.Request.Context(), accountID, month)
+	if appErr != nil {
+		appErr.JsonError(c)
+		return
+	}
+	filename := fmt.Sprintf("statement-%s-%s.pdf", document.Iban, month.Format("200601"))
+	sendPdf(c, filename, document.VerificationCode, content)
+}
+
+// GET /accounts/:id/documents/ownership-certificate
+//
+// Issues the certificate of account ownership, with the holder and the IBAN
+func (h *IDocumentHandler) FetchOwnershipCertificate(c *gin.Context) {
+	accountID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid identifier"})
+		return
+	}
+	content, document, appErr := h.DocumentService.IssueOwnershipCertificate(c.Request.Context(), accountID)
+	if appErr != nil {
+		appErr.JsonError(c)
+		return
+	}
+	filename := fmt.Sprintf("ownership-certificate-%s.pdf", document.Iban)
+	sendPdf(c, filename, document.VerificationCode, content)
+}
+
+// GET /documents/verify/:code
+//
+// Public. Tells whether a document was issued by the bank, and what it was about. The sha256 of
+// the genuine PDF is returned so a copy can be checked byte for byte.
+func (h *IDocumentHandler) VerifyDocument(c *gin.Context) {
+	code := documententity.NormalizeVerificationCode(c.Param("code"))
+	document, appErr := h.DocumentRepository.FetchDocumentByCode(c.Request.Context(), code)
+	if appErr != nil {
+		appErr.JsonError(c)
+		return
+	}
+	c.JSON(http.StatusOK, mappers.ToDocumentVerificationDto(document))
+}
+
+func sendPdf(c *gin.Context, filename string, verificationCode string, content []byte) {
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Header("X-Verification-Code", verificationCode)
+	c.Data(http.StatusOK, "application/pdf", content)
+}
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"slices"
+	dto "src/api/dto"
+	currencyentity "src/domain/currency"
+	feeentity "src/domain/fee"
+	"src/domain/money"
+	app_errors "src/errors"
+	mappers "src/mappers"
+	repositories "src/repositories"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FeeHandler interface {
+	FetchProducts(c *gin.Context)
+	FetchFeeSchedules(c *gin.Context)
+	SetFeeSchedule(c *gin.Context)
+	DeleteFeeSchedule(c *gin.Context)
+}
+
+type IFeeHandler struct {
+	FeeRepository      repositories.FeeRepository
+	CurrencyRepository repositories.CurrencyRepository
+}
+
+// GET /products
+func (h *IFeeHandler) FetchProducts(c *gin.Context) {
+	products, appErr := h.FeeRepository.FetchProducts(c.Request.Context())
+	if appErr != nil {
+		appErr.JsonError(c)
+		return
+	}
+	productDtos := make([]dto.ProductDto, 0, len(products))
+	for _, product := range products {
+		productDtos = append(productDtos, mappers.ToProductDto(product))
+	}
+	c.JSON(http.StatusOK, gin.H{"products": productDtos})
+}
+
+// GET /fee-schedules
+func (h *IFeeHandler) FetchFeeSchedules(c *gin.Context) {
+	schedules, appErr := h.FeeRepository.FetchFeeSchedules(c.Request.Context())
+	if appErr != nil {
+		appErr.JsonError(c)
+		return
+	}
+	scheduleDtos := make([]dto.FeeScheduleDto, 0, len(schedules))
+	for _, schedule := range schedules {
+		scheduleDtos = append(scheduleDtos, mappers.ToFeeScheduleDto(schedule))
+	}
+	c.JSON(http.StatusOK, gin.H{"fee_schedules": scheduleDtos})
+}
+
+// PUT /admin/fee-schedules
+//
+// Sets the fee of a transaction type, product and currency, replacing the previous one. Bank staff only.
+func (h *IFeeHandler) SetFeeSchedule(c *gin.Context) {
+	var setFeeScheduleDto dto.SetFeeScheduleDto
+	if err := c.ShouldBindJSON(&setFeeScheduleDto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	schedule := feeentity.FeeScheduleEntity{
+		TransactionType: strings.ToUpper(setFeeScheduleDto.TransactionType),
+		FixedAmount:     setFeeScheduleDto.FixedAmount,
+		Percentage:      setFeeScheduleDto.Percentage,
+	}
+	if !slices.Contains(feeentity.TransactionTypes, schedule.TransactionType) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "transaction_type must be one of " + strings.Join(feeentity.TransactionTypes, ", ")})
+		return
+	}
+	currency, err := currencyentity.NormalizeCode(setFeeScheduleDto.Currency)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	currencyEntity, appErr := h.CurrencyRepository.FetchCurrency(c.Request.Context(), currency)
+	if appErr != nil {
+		if _, notFound := appErr.(*app_errors.ErrNotFound); notFound {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "currency " + currency + " is not supported"})
+			return
+		}
+		appErr.JsonError(c)
+		return
+	}
+	schedule.Currency = currency
+	if setFeeScheduleDto.Product != nil {
+		product := strings.ToUpper(strings.TrimSpace(*setFeeScheduleDto.Product))
+		if _, appErr := h.FeeRepository.FetchProduct(c.Request.Context(), product); appErr != nil {
+			if _, notFound := appErr.(*app_errors.ErrNotFound); notFound {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "product " + product + " is not supported"})
+				return
+			}
+			appErr.JsonError(c)
+			return
+		}
+		schedule.Product = sql.NullString{String: product, Valid: true}
+	}
+
+	if schedule.Percentage == "" {
+		schedule.Percentage = "0"
+	}
+	if _, err := feeentity.ParsePercentage(schedule.Percentage); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	amounts := []*money.Money{&schedule.FixedAmount, setFeeScheduleDto.MinPercentageAmount, setFeeScheduleDto.MaxPercentageAmount}
+	for _, amount := range amounts {
+		if amount == nil {
+			continue
+		}
+		if amount.IsNegative() || !currencyEntity.HasScale(*amount) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "fee amounts must be non negative " + currency + " amounts with at most " + strconv.Itoa(currencyEntity.MinorUnit) + " decimals."})
+			return
+		}
+	}
+	if setFeeScheduleDto.MinPercentageAmount != nil {
+		schedule.MinPercentageAmount = money.NullMoney{Money: *setFeeScheduleDto.MinPercentageAmount, Valid: true}
+	}
+	if setFeeScheduleDto.MaxPercentageAmount != nil {
+		schedule.MaxPercentageAmount = money.NullMoney{Money: *setFeeScheduleDto.MaxPercentageAmount, Valid: true}
+	}
+	if schedule.MinPercentageAmount.Valid && schedule.MaxPercentageAmount.Valid &&
+		schedule.MaxPercentageAmount.Money.LessThan(schedule.MinPercentageAmount.Money) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "max_percentage_amount cannot be lower than min_percentage_amount."})
+		return
+	}
+
+	if appErr := h.FeeRepository.UpsertFeeSchedule(c.Request.Context(), &schedule); appErr != nil {
+		appErr.JsonError(c)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"fee_schedule": mappers.ToFeeScheduleDto(schedule)})
+}
+
+// DELETE /admin/fee-schedules/:id
+//
+// Stops charging the fee. Bank staff only.
+func (h *IFeeHandler) DeleteFeeSchedule(c *gin.Context) {
+	scheduleID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid identifier"})
+		return
+	}
+	if appErr := h.FeeRepository.DeleteFeeSchedule(c.Request.Context(), scheduleID); appErr != nil {
+		appErr.JsonError(c)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"os"
+	dto "src/api/dto"
+	holdentity "src/domain/hold"
+	mappers "src/mappers"
+	repositories "src/repositories"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const defaultHoldTTL = 7 * 24 * time.Hour
+
+type HoldHandler interface {
+	AuthorizeHold(c *gin.Context)
+	CaptureHold(c *gin.Context)
+	VoidHold(c *gin.Context)
+}
+
+type IHoldHandler struct {
+	HoldRepository    repositories.HoldRepository
+	AccountRepository repositories.AccountRepository
+	TTL               time.Duration // How long an authorization reserves the funds
+}
+
+// HoldTTLFromEnv reads HOLD_TTL (i.e. 168h, 30m). Defaults to 7 days.
+// The method is supposed to be used after the .env is loaded
+func HoldTTLFromEnv() time.Duration {
+	ttl, err := time.ParseDuration(os.Getenv("HOLD_TTL"))
+	if err != nil || ttl <= 0 {
+		return defaultHoldTTL
+	}
+	return ttl
+}
+
+// POST /holds
+//
+// Reserves funds of the account. The available balance decreases, the ledger balance does not.
+func (h *IHoldHandler) AuthorizeHold(c *gin.Context) {
+	authorizeHoldDtoCtx, exists := c.Get("authorize_hold_dto")
+	if !exists {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad request"})
+		return
+	}
+	authorizeHoldDto, ok := authorizeHoldDtoCtx.(dto.AuthorizeHoldDto)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad request"})
+		return
+	}
+	if !authorizeHoldDto.Amount.IsPositive() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "amount must be positive."})
+		return
+	}
+
+	hold := holdentity.HoldEntity{
+		AccountID: authorizeHoldDto.AccountID,
+		Amount:    authorizeHoldDto.Amount,
+		ExpiresAt: time.Now().Add(h.TTL),
+	}
+	if authorizeHoldDto.ToAccountNumber != nil {
+		id, err := h.AccountRepository.FetchAccountIdByAccountNumber(c, *authorizeHoldDto.ToAccountNumber)
+		if err != nil {
+			err.JsonError(c)
+			return
+		}
+		if id == nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+			return
+		}
+		if *id == hold.AccountID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to_account_number cannot be the held account"})
+			return
+		}
+		hold.ToAccountID = sql.NullInt32{Int32: int32(*id), Valid: true}
+	}
+	if authorizeHoldDto.Reference != nil {
+		hold.Reference = sql.NullString{String: *authorizeHoldDto.Reference, Valid: true}
+	}
+
+	if err := h.HoldRepository.AuthorizeTx(c.Request.Context(), &hold); err != nil {
+		err.JsonError(c)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"hold": mappers.ToHoldDto(hold)})
+}
+
+// POST /holds/:id/capture
+//
+// Posts the held funds (all of them or part of them) as a transaction. The rest is released.
+func (h *IHoldHandler) CaptureHold(c *gin.Context) {
+	holdID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid identifier"})
+		return
+	}
+	var captureHoldDto dto.CaptureHoldDto
+	// the body is optional: an empty one captures the whole hold
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&captureHoldDto); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if captureHoldDto.Amount != nil && !captureHoldDto.Amount.IsPositive() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "amount must be positive."})
+		return
+	}
+
+	hold, transaction, appErr := h.HoldRepository.CaptureTx(c.Request.Context(), holdID, captureHoldDto.Amount)
+	if appErr != nil {
+		appErr.JsonError(c)
+		return
+	}
+	transactionDto, err := mappers.ToTransactionDto(transaction)
+	if err != nil {
+		c.AbortWithError(500, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"hold": mappers.ToHoldDto(hold), "transaction": transactionDto})
+}
+
+// POST /holds/:id/void
+//
+// Cancels the hold and releases the funds.
+func (h *IHoldHandler) VoidHold(c *gin.Context) {
+	holdID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid identifier"})
+		return
+	}
+	hold, appErr := h.HoldRepository.VoidTx(c.Request.Context(), holdID)
+	if appErr != nil {
+		appErr.JsonError(c)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"hold": mappers.ToHoldDto(hold)})
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"net/http"
+	"os"
+	services "src/api/service"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type IbanHandler interface {
+	LookupIban(c *gin.Context)
+	UpdateBankDirectory(c *gin.Context)
+	ReloadBankDirectory(c *gin.Context)
+}
+
+type IIbanHandler struct {
+	BankDirectoryService services.BankDirectoryService
+}
+
+// GET /iban/:iban
+//
+// Validates an IBAN, printed or electronic, and returns its formats, its components and the BIC and name
+// of its bank when the directory has it. An invalid IBAN is not an error: valid is false and reason says why.
+func (h *IIbanHandler) LookupIban(c *gin.Context) {
+	result, appErr := h.BankDirectoryService.LookupIban(c.Request.Context(), c.Param("iban"))
+	if appErr != nil {
+		appErr.JsonError(c)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// PUT /admin/bank-directory
+//
+// Replaces the bank directory with a text/csv body with the format of BANK_DIRECTORY_FILE. Bank staff only.
+func (h *IIbanHandler) UpdateBankDirectory(c *gin.Context) {
+	if !strings.HasPrefix(c.ContentType(), "text/csv") {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "the bank directory must be sent as text/csv"})
+		return
+	}
+	banks, err := services.ParseBankDirectoryCSV(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	count, appErr := h.BankDirectoryService.ReplaceBankDirectory(c.Request.Context(), banks)
+	if appErr != nil {
+		appErr.JsonError(c)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"banks": count})
+}
+
+// POST /admin/bank-directory/reload
+//
+// Reads BANK_DIRECTORY_FILE again and replaces the bank directory with it. Bank staff only.
+func (h *IIbanHandler) ReloadBankDirectory(c *gin.Context) {
+	path := os.Getenv("BANK_DIRECTORY_FILE")
+	if path == "" {
+		c.JSON(http.StatusConflict, gin.H{"error": "BANK_DIRECTORY_FILE is not set"})
+		return
+	}
+	count, appErr := h.BankDirectoryService.LoadBankDirectoryFile(c.Request.Context(), path)
+	if appErr != nil {
+		appErr.JsonError(c)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"banks": count})
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"net/http"
+	dto "src/api/dto"
+	paymententity "src/domain/payment"
+	mappers "src/mappers"
+	repositories "src/repositories"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type InboundPaymentHandler interface {
+	FetchInboundPayments(c *gin.Context)
+	ResolveInboundPayment(c *gin.Context)
+	ReturnInboundPayment(c *gin.Context)
+}
+
+type IInboundPaymentHandler struct {
+	InboundPaymentRepository repositories.InboundPaymentRepository
+}
+
+// GET /admin/inbound-payments?status=EXCEPTION
+//
+// SEPA credits received from other banks, oldest first. The exceptions are the ones held in suspense.
+// Bank staff only.
+func (h *IInboundPaymentHandler) FetchInboundPayments(c *gin.Context) {
+	status := strings.ToUpper(c.Query("status"))
+	switch status {
+	case "", paymententity.InboundStatusCredited, paymententity.InboundStatusException,
+		paymententity.InboundStatusResolved, paymententity.InboundStatusReturned:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be CREDITED, EXCEPTION, RESOLVED or RETURNED"})
+		return
+	}
+	payments, appErr := h.InboundPaymentRepository.FetchInboundPayments(c.Request.Context(), status)
+	if appErr != nil {
+		appErr.JsonError(c)
+		return
+	}
+	paymentDtos := make([]dto.InboundPaymentDto, 0, len(payments))
+	for _, payment := range payments {
+		paymentDtos = append(paymentDtos, mappers.ToInboundPaymentDto(payment))
+	}
+	c.JSON(http.StatusOK, gin.H{"payments": paymentDtos})
+}
+
+// POST /admin/inbound-payments/:id/resolve
+//
+// Moves an exception out of suspense into the client account given. Bank staff only.
+func (h *IInboundPaymentHandler) ResolveInboundPayment(c *gin.Context) {
+	paymentID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid identifier"})
+		return
+	}
+	var resolveRequest dto.ResolveInboundPaymentDto
+	if err := c.ShouldBindJSON(&resolveRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	payment, appErr := h.InboundPaymentRepository.ResolveInboundPayment(c.Request.Context(), paymentID, resolveRequest.AccountNumber)
+	if appErr != nil {
+		appErr.JsonError(c)
+		return
+	}
+	c.JSON(http.StatusOK, mappers.ToInboundPaymentDto(payment))
+}
+
+// POST /admin/inbound-payments/:id/return
+//
+// Sends an exception back to the bank of the debtor. The amount leaves suspense now, the pacs.004 goes
+// out with the next clearing run. Bank staff only.
+func (h *IInboundPaymentHandler) ReturnInboundPayment(c *gin.Context) {
+	paymentID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid identifier"})
+		return
+	}
+	var returnRequest dto.ReturnInboundPaymentDto
+	// the body is optional
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&returnRequest); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	payment, appErr := h.InboundPaymentRepository.ReturnInboundPayment(c.Request.Context(), paymentID, strings.ToUpper(returnRequest.ReasonCode))
+	if appErr != nil {
+		appErr.JsonError(c)
+		return
+	}
+	c.JSON(http.StatusOK, mappers.ToInboundPaymentDto(payment))
+}
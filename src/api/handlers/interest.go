@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"math/big"
+	"net/http"
+	"slices"
+	dto "src/api/dto"
+	accountentity "src/domain/account"
+	currencyentity "src/domain/currency"
+	interestentity "src/domain/interest"
+	scheduledtransferentity "src/domain/scheduled_transfer"
+	app_errors "src/errors"
+	mappers "src/mappers"
+	repositories "src/repositories"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type InterestHandler interface {
+	FetchInterestRates(c *gin.Context)
+	SetInterestRate(c *gin.Context)
+	DeleteInterestRate(c *gin.Context)
+	FetchPendingAccruals(c *gin.Context)
+}
+
+type IInterestHandler struct {
+	InterestRepository repositories.InterestRepository
+	FeeRepository      repositories.FeeRepository
+	CurrencyRepository repositories.CurrencyRepository
+}
+
+// GET /interest-rates
+func (h *IInterestHandler) FetchInterestRates(c *gin.Context) {
+	rates, appErr := h.InterestRepository.FetchInterestRates(c.Request.Context())
+	if appErr != nil {
+		appErr.JsonError(c)
+		return
+	}
+	rateDtos := make([]dto.InterestRateDto, 0, len(rates))
+	for _, rate := range rates {
+		rateDtos = append(rateDtos, mappers.ToInterestRateDto(rate))
+	}
+	c.JSON(http.StatusOK, gin.H{"interest_rates": rateDtos})
+}
+
+// PUT /admin/interest-rates
+//
+// Sets the credit interest of a product and currency from effective_from on. Bank staff only.
+func (h *IInterestHandler) SetInterestRate(c *gin.Context) {
+	var setInterestRateDto dto.SetInterestRateDto
+	if err := c.ShouldBindJSON(&setInterestRateDto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	rate := interestentity.InterestRateEntity{
+		Product:  strings.ToUpper(strings.TrimSpace(setInterestRateDto.Product)),
+		Rate:     setInterestRateDto.Rate,
+		DayCount: strings.ToUpper(setInterestRateDto.DayCount),
+	}
+	if rate.DayCount == "" {
+		rate.DayCount = interestentity.DayCountACT365
+	}
+	if !slices.Contains(interestentity.DayCounts, rate.DayCount) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": interestentity.ErrInvalidDayCount.Error()})
+		return
+	}
+	if _, err := accountentity.ParseInterestRate(rate.Rate); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if _, appErr := h.FeeRepository.FetchProduct(c.Request.Context(), rate.Product); appErr != nil {
+		if _, notFound := appErr.(*app_errors.ErrNotFound); notFound {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "product " + rate.Product + " is not supported"})
+			return
+		}
+		appErr.JsonError(c)
+		return
+	}
+	currency, err := currencyentity.NormalizeCode(setInterestRateDto.Currency)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if _, appErr := h.CurrencyRepository.FetchCurrency(c.Request.Context(), currency); appErr != nil {
+		if _, notFound := appErr.(*app_errors.ErrNotFound); notFound {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "currency " + currency + " is not supported"})
+			return
+		}
+		appErr.JsonError(c)
+		return
+	}
+	rate.Currency = currency
+	rate.EffectiveFrom, _ = time.Parse(time.DateOnly, setInterestRateDto.EffectiveFrom)
+
+	if appErr := h.InterestRepository.UpsertInterestRate(c.Request.Context(), &rate); appErr != nil {
+		appErr.JsonError(c)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"interest_rate": mappers.ToInterestRateDto(rate)})
+}
+
+// DELETE /admin/interest-rates/:id
+//
+// Deletes a rate that is not in force yet. Bank staff only.
+func (h *IInterestHandler) DeleteInterestRate(c *gin.Context) {
+	rateID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid identifier"})
+		return
+	}
+	if appErr := h.InterestRepository.DeleteInterestRate(c.Request.Context(), rateID, scheduledtransferentity.Today()); appErr != nil {
+		appErr.JsonError(c)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GET /accounts/:id/interest-accruals
+//
+// Returns the interest accrued by the account and not paid yet, with its daily accruals.
+func (h *IInterestHandler) FetchPendingAccruals(c *gin.Context) {
+	accountID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid identifier"})
+		return
+	}
+	accruals, appErr := h.InterestRepository.FetchPendingAccruals(c.Request.Context(), accountID)
+	if appErr != nil {
+		appErr.JsonError(c)
+		return
+	}
+	total := new(big.Rat)
+	accrualDtos := make([]dto.InterestAccrualDto, 0, len(accruals))
+	for _, accrual := range accruals {
+		if amount, ok := new(big.Rat).SetString(accrual.Amount); ok {
+			total.Add(total, amount)
+		}
+		accrualDtos = append(accrualDtos, mappers.ToInterestAccrualDto(accrual))
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"accrued_interest": total.FloatString(interestentity.AccrualScale),
+		"accruals":         accrualDtos,
+	})
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	dto "src/api/dto"
+	accountentity "src/domain/account"
+	mappers "src/mappers"
+	repositories "src/repositories"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OverdraftHandler interface {
+	FetchOverdraft(c *gin.Context)
+	SetOverdraft(c *gin.Context)
+	DeleteOverdraft(c *gin.Context)
+}
+
+type IOverdraftHandler struct {
+	OverdraftRepository repositories.OverdraftRepository
+}
+
+// GET /admin/accounts/:id/overdraft
+func (h *IOverdraftHandler) FetchOverdraft(c *gin.Context) {
+	accountID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid identifier"})
+		return
+	}
+	overdraft, appErr := h.OverdraftRepository.FetchOverdraft(c.Request.Context(), accountID)
+	if appErr != nil {
+		appErr.JsonError(c)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"overdraft": mappers.ToOverdraftDto(overdraft)})
+}
+
+// PUT /admin/accounts/:id/overdraft
+//
+// Arranges the overdraft of the account, replacing the previous one. Bank staff only.
+func (h *IOverdraftHandler) SetOverdraft(c *gin.Context) {
+	accountID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid identifier"})
+		return
+	}
+	var setOverdraftDto dto.SetOverdraftDto
+	if err := c.ShouldBindJSON(&setOverdraftDto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if setOverdraftDto.Limit.IsNegative() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit cannot be negative."})
+		return
+	}
+	if setOverdraftDto.InterestRate == "" {
+		setOverdraftDto.InterestRate = "0"
+	}
+	if _, err := accountentity.ParseInterestRate(setOverdraftDto.InterestRate); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	validFrom, _ := time.Parse(time.DateOnly, setOverdraftDto.ValidFrom)
+	overdraft := accountentity.OverdraftEntity{
+		AccountID:    accountID,
+		Limit:        setOverdraftDto.Limit,
+		InterestRate: setOverdraftDto.InterestRate,
+		ValidFrom:    validFrom,
+	}
+	if setOverdraftDto.ValidTo != nil {
+		validTo, _ := time.Parse(time.DateOnly, *setOverdraftDto.ValidTo)
+		if validTo.Before(validFrom) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "valid_to cannot be before valid_from."})
+			return
+		}
+		overdraft.ValidTo = sql.NullTime{Time: validTo, Valid: true}
+	}
+
+	if appErr := h.OverdraftRepository.UpsertOverdraft(c.Request.Context(), &overdraft); appErr != nil {
+		appErr.JsonError(c)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"overdraft": mappers.ToOverdraftDto(overdraft)})
+}
+
+// DELETE /admin/accounts/:id/overdraft
+//
+// Cancels the overdraft of the account. Bank staff only.
+func (h *IOverdraftHandler) DeleteOverdraft(c *gin.Context) {
+	accountID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid identifier"})
+		return
+	}
+	if appErr := h.OverdraftRepository.DeleteOverdraft(c.Request.Context(), accountID); appErr != nil {
+		appErr.JsonError(c)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"net/http"
+	dto "src/api/dto"
+	services "src/api/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PayeeHandler interface {
+	VerifyPayee(c *gin.Context)
+}
+
+type IPayeeHandler struct {
+	PayeeService services.PayeeService
+}
+
+// POST /payee-verifications
+//
+// Confirmation of Payee: tells whether the name typed for a transfer is the holder of the account before it is
+// sent. A close match returns the masked name of the holder. Clients only, with a limit of checks per hour and
+// per day; over them the answer is a 429 with Retry-After.
+func (h *IPayeeHandler) VerifyPayee(c *gin.Context) {
+	clientID := c.GetInt("client_id")
+	if clientID == 0 {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only clients can verify a payee"})
+		return
+	}
+	var verifyPayeeDto dto.VerifyPayeeDto
+	if err := c.ShouldBindJSON(&verifyPayeeDto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	result, appErr := h.PayeeService.VerifyPayee(c.Request.Context(), clientID, verifyPayeeDto.AccountNumber, verifyPayeeDto.Name)
+	if appErr != nil {
+		appErr.JsonError(c)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"net/http"
+	dto "src/api/dto"
+	mappers "src/mappers"
+	repositories "src/repositories"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PaymentHandler interface {
+	FetchOutboundPayments(c *gin.Context)
+}
+
+type IPaymentHandler struct {
+	PaymentRepository repositories.PaymentRepository
+}
+
+// GET /accounts/:id/payments
+//
+// SEPA transfers of the account to other banks, newest first, with their clearing status
+func (h *IPaymentHandler) FetchOutboundPayments(c *gin.Context) {
+	accountID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid identifier"})
+		return
+	}
+	payments, appErr := h.PaymentRepository.FetchOutboundPaymentsByAccount(c.Request.Context(), accountID)
+	if appErr != nil {
+		appErr.JsonError(c)
+		return
+	}
+	paymentDtos := make([]dto.OutboundPaymentDto, 0, len(payments))
+	for _, payment := range payments {
+		paymentDtos = append(paymentDtos, mappers.ToOutboundPaymentDto(payment))
+	}
+	c.JSON(http.StatusOK, gin.H{"payments": paymentDtos})
+}
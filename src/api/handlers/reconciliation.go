@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	services "src/api/service"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReconciliationHandler interface {
+	Reconcile(c *gin.Context)
+}
+
+type IReconciliationHandler struct {
+	ReconciliationService services.ReconciliationService
+}
+
+// POST /admin/reconciliation?format=json|csv&refresh_mv=true
+//
+// Runs the ledger reconciliation and returns the discrepancy report. Bank staff only.
+func (h *IReconciliationHandler) Reconcile(c *gin.Context) {
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json or csv"})
+		return
+	}
+	refreshMaterializedView := false
+	if value := c.Query("refresh_mv"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "refresh_mv must be a boolean"})
+			return
+		}
+		refreshMaterializedView = parsed
+	}
+
+	report, appErr := h.ReconciliationService.Reconcile(c.Request.Context(), refreshMaterializedView)
+	if appErr != nil {
+		appErr.JsonError(c)
+		return
+	}
+	if format == "json" {
+		c.JSON(http.StatusOK, report)
+		return
+	}
+	var body bytes.Buffer
+	if err := services.WriteReconciliationCSV(&body, report); err != nil {
+		c.AbortWithError(500, err)
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="reconciliation-%s.csv"`, report.GeneratedAt.Format("20060102T150405Z")))
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", body.Bytes())
+}
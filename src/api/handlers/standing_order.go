@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	dto "src/api/dto"
+	scheduledtransferentity "src/domain/scheduled_transfer"
+	mappers "src/mappers"
+	repositories "src/repositories"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StandingOrderHandler interface {
+	FetchStandingOrders(c *gin.Context)
+	FetchStandingOrder(c *gin.Context)
+	CreateStandingOrder(c *gin.Context)
+	UpdateStandingOrder(c *gin.Context)
+	CancelStandingOrder(c *gin.Context)
+}
+
+type IStandingOrderHandler struct {
+	ScheduledTransferRepository repositories.ScheduledTransferRepository
+	AccountRepository           repositories.AccountRepository
+}
+
+// GET /accounts/:id/standing-orders
+func (h *IStandingOrderHandler) FetchStandingOrders(c *gin.Context) {
+	accountID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid identifier"})
+		return
+	}
+	entities, appErr := h.ScheduledTransferRepository.FetchScheduledTransfersByAccount(c.Request.Context(), accountID)
+	if appErr != nil {
+		appErr.JsonError(c)
+		return
+	}
+	standingOrders := make([]dto.StandingOrderDto, 0, len(entities))
+	for _, entity := range entities {
+		standingOrders = append(standingOrders, mappers.ToStandingOrderDto(entity))
+	}
+	c.JSON(http.StatusOK, gin.H{"standing_orders": standingOrders})
+}
+
+// GET /accounts/:id/standing-orders/:order_id
+//
+// The standing order with the outcome of its runs, newest first.
+func (h *IStandingOrderHandler) FetchStandingOrder(c *gin.Context) {
+	entity, ok := h.fetchAccountStandingOrder(c)
+	if !ok {
+		return
+	}
+	runs, appErr := h.ScheduledTransferRepository.FetchRuns(c.Request.Context(), entity.ID)
+	if appErr != nil {
+		appErr.JsonError(c)
+		return
+	}
+	standingOrder := mappers.ToStandingOrderDto(entity)
+	standingOrder.Runs = make([]dto.StandingOrderRunDto, 0, len(runs))
+	for _, run := range runs {
+		standingOrder.Runs = append(standingOrder.Runs, mappers.ToStandingOrderRunDto(run))
+	}
+	c.JSON(http.StatusOK, gin.H{"standing_order": standingOrder})
+}
+
+// POST /accounts/:id/standing-orders
+func (h *IStandingOrderHandler) CreateStandingOrder(c *gin.Context) {
+	accountID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid identifier"})
+		return
+	}
+	var createStandingOrderDto dto.CreateStandingOrderDto
+	if err := c.ShouldBindJSON(&createStandingOrderDto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !scheduledtransferentity.IsValidFrequency(createStandingOrderDto.Frequency) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "frequency is not valid", "frequencies": scheduledtransferentity.Frequencies})
+		return
+	}
+	if !createStandingOrderDto.Amount.IsPositive() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "amount must be positive."})
+		return
+	}
+	startDate, _ := time.Parse(time.DateOnly, createStandingOrderDto.StartDate)
+	if startDate.Before(scheduledtransferentity.Today()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "start_date cannot be in the past"})
+		return
+	}
+
+	toAccountID, appErr := h.AccountRepository.FetchAccountIdByAccountNumber(c, createStandingOrderDto.ToAccountNumber)
+	if appErr != nil {
+		appErr.JsonError(c)
+		return
+	}
+	if toAccountID == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+	if *toAccountID == accountID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to_account_number cannot be the same account"})
+		return
+	}
+
+	entity := scheduledtransferentity.ScheduledTransferEntity{
+		AccountID:       accountID,
+		ToAccountID:     *toAccountID,
+		ToAccountNumber: createStandingOrderDto.ToAccountNumber,
+		Amount:          createStandingOrderDto.Amount,
+		Frequency:       createStandingOrderDto.Frequency,
+		StartDate:       startDate,
+		Status:          scheduledtransferentity.StatusActive,
+	}
+	if createStandingOrderDto.EndDate != nil {
+		endDate, _ := time.Parse(time.DateOnly, *createStandingOrderDto.EndDate)
+		entity.EndDate = sql.NullTime{Time: endDate, Valid: true}
+	}
+	if createStandingOrderDto.Reference != nil {
+		entity.Reference = sql.NullString{String: *createStandingOrderDto.Reference, Valid: true}
+	}
+	firstRunDate := entity.FirstRunDate()
+	if !entity.IsWithinEndDate(firstRunDate) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end_date is before the first run date " + firstRunDate.Format(time.DateOnly)})
+		return
+	}
+	entity.NextRunDate = sql.NullTime{Time: firstRunDate, Valid: true}
+
+	if appErr := h.ScheduledTransferRepository.InsertScheduledTransfer(c.Request.Context(), &entity); appErr != nil {
+		appErr.JsonError(c)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"standing_order": mappers.ToStandingOrderDto(entity)})
+}
+
+// PATCH /accounts/:id/standing-orders/:order_id
+//
+// Changes the amount, the end date or the reference of an active standing order.
+// The frequency and the beneficiary cannot be changed: the order has to be cancelled and created again.
+func (h *IStandingOrderHandler) UpdateStandingOrder(c *gin.Context) {
+	entity, ok := h.fetchAccountStandingOrder(c)
+	if !ok {
+		return
+	}
+	var updateStandingOrderDto dto.UpdateStandingOrderDto
+	if err := c.ShouldBindJSON(&updateStandingOrderDto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if updateStandingOrderDto.Amount != nil {
+		if !updateStandingOrderDto.Amount.IsPositive() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "amount must be positive."})
+			return
+		}
+		entity.Amount = *updateStandingOrderDto.Amount
+	}
+	if updateStandingOrderDto.Reference != nil {
+		entity.Reference = sql.NullString{String: *updateStandingOrderDto.Reference, Valid: true}
+	}
+	if updateStandingOrderDto.EndDate != nil {
+		endDate, _ := time.Parse(time.DateOnly, *updateStandingOrderDto.EndDate)
+		if endDate.Before(scheduledtransferentity.Date(entity.StartDate)) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "end_date cannot be before start_date"})
+			return
+		}
+		entity.EndDate = sql.NullTime{Time: endDate, Valid: true}
+		// no run left before the new end date
+		if entity.NextRunDate.Valid && !entity.IsWithinEndDate(entity.NextRunDate.Time) {
+			entity.NextRunDate = sql.NullTime{}
+			entity.Status = scheduledtransferentity.StatusFinished
+		}
+	}
+
+	if appErr := h.ScheduledTransferRepository.UpdateScheduledTransfer(c.Request.Context(), &entity); appErr != nil {
+		appErr.JsonError(c)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"standing_order": mappers.ToStandingOrderDto(entity)})
+}
+
+// DELETE /accounts/:id/standing-orders/:order_id
+//
+// Cancels the standing order. Its runs are kept.
+func (h *IStandingOrderHandler) CancelStandingOrder(c *gin.Context) {
+	entity, ok := h.fetchAccountStandingOrder(c)
+	if !ok {
+		return
+	}
+	cancelled, appErr := h.ScheduledTransferRepository.CancelScheduledTransfer(c.Request.Context(), entity.ID)
+	if appErr != nil {
+		appErr.JsonError(c)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"standing_order": mappers.ToStandingOrderDto(cancelled)})
+}
+
+// fetchAccountStandingOrder reads the :order_id standing order, which must belong to the :id account.
+// When it fails, the error response has already been written and false is returned.
+func (h *IStandingOrderHandler) fetchAccountStandingOrder(c *gin.Context) (scheduledtransferentity.ScheduledTransferEntity, bool) {
+	accountID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid identifier"})
+		return scheduledtransferentity.ScheduledTransferEntity{}, false
+	}
+	orderID, err := strconv.Atoi(c.Param("order_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid identifier"})
+		return scheduledtransferentity.ScheduledTransferEntity{}, false
+	}
+	entity, appErr := h.ScheduledTransferRepository.FetchScheduledTransferById(c.Request.Context(), orderID)
+	if appErr != nil {
+		appErr.JsonError(c)
+		return scheduledtransferentity.ScheduledTransferEntity{}, false
+	}
+	if entity.AccountID != accountID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Standing order not found"})
+		return scheduledtransferentity.ScheduledTransferEntity{}, false
+	}
+	return entity, true
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	services "src/api/service"
+	statemententity "src/domain/statement"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type StatementHandler interface {
+	FetchStatement(c *gin.Context)
+}
+
+type IStatementHandler struct {
+	StatementService services.StatementService
+	Logger           *zap.Logger
+}
+
+// GET /accounts/:id/statements?from=2026-03-01&to=2026-03-31&format=camt053
+//
+// Downloads the statement of the account in csv (default), ofx or camt053. from and to are RFC 3339
+// date-times or YYYY-MM-DD dates, both days included: to=2026-03-31 ends at the end of 31 March.
+// The current month up to now by default. The statement is streamed as the entries are read.
+func (h *IStatementHandler) FetchStatement(c *gin.Context) {
+	accountID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid identifier"})
+		return
+	}
+	format := strings.ToLower(c.DefaultQuery("format", statemententity.FormatCSV))
+	if !slices.Contains(statemententity.Formats, format) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be one of " + strings.Join(statemententity.Formats, ", ")})
+		return
+	}
+
+	now := time.Now().UTC()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if value := c.Query("from"); value != "" {
+		if from, err = parseStatementTime(value, false); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must be a RFC 3339 date-time or YYYY-MM-DD"})
+			return
+		}
+	}
+	to := now
+	if value := c.Query("to"); value != "" {
+		if to, err = parseStatementTime(value, true); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to must be a RFC 3339 date-time or YYYY-MM-DD"})
+			return
+		}
+		if to.After(now) {
+			to = now
+		}
+	}
+	if !from.Before(to) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must be before to"})
+		return
+	}
+
+	appErr := h.StatementService.WriteStatement(c.Request.Context(), accountID, from, to,
+		func(statement statemententity.StatementEntity) (services.StatementWriter, error) {
+			c.Header("Content-Type", services.StatementContentType(format))
+			c.Header("Content-Disposition", `attachment; filename="`+services.StatementFileName(statement, format)+`"`)
+			c.Status(http.StatusOK)
+			return services.NewStatementWriter(format, c.Writer)
+		})
+	if appErr != nil {
+		if c.Writer.Written() {
+			// The status is already sent, the statement is cut short
+			h.Logger.Error("Error occurred while writing statement of account " + strconv.Itoa(accountID) + ": " + appErr.Error())
+			c.Abort()
+			return
+		}
+		c.Writer.Header().Del("Content-Type")
+		c.Writer.Header().Del("Content-Disposition")
+		appErr.JsonError(c)
+	}
+}
+
+// parseStatementTime parses a RFC 3339 date-time or a YYYY-MM-DD date, which is the start of the day,
+// or its end when endOfDay is set
+func parseStatementTime(value string, endOfDay bool) (time.Time, error) {
+	if at, err := time.Parse(time.RFC3339, value); err == nil {
+		return at.UTC(), nil
+	}
+	day, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		day = day.AddDate(0, 0, 1)
+	}
+	return day, nil
+}
@@ -3,42 +3,113 @@ package handlers
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	dto "src/api/dto"
+	services "src/api/service"
+	"src/domain/money"
+	paginationentity "src/domain/pagination"
+	paymententity "src/domain/payment"
 	trasnactionentity "src/domain/transaction"
+	app_errors "src/errors"
 	mappers "src/mappers"
 	repositories "src/repositories"
+	"src/utils"
 	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 )
 
 type TransactionHandler interface {
 	PerformTransaction(c *gin.Context)
 	GetTransactions(c *gin.Context)
+	GetAccountActivity(c *gin.Context)
+	ReverseTransaction(c *gin.Context)
 }
 
 type ITransactionHandler struct {
 	TransactionRepository repositories.TransactionRepository
 	AccountRepository     repositories.AccountRepository
+	IdempotencyService    services.IdempotencyService
+	PaymentRepository     repositories.PaymentRepository
+	BeneficiaryRepository repositories.BeneficiaryRepository
+	Logger                *zap.Logger
 }
 
 // POST
+//
+// Retries are safe when the Idempotency-Key header is sent: a replay of the same
+// request returns the original response and a replay with another body gets a 409.
 func (h *ITransactionHandler) PerformTransaction(c *gin.Context) {
 	fmt.Println("Entering PerformTransaction Endpoint")
 	performTransactionDtoCtx, exists := c.Get("perform_transaction_dto")
 	if !exists {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "bad request"})
+		return
 	}
 	performnTransactionDto, ok := performTransactionDtoCtx.(dto.PerformTransactionDto)
 
 	if !ok {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "bad request"})
+		return
 	}
 
 	fmt.Println("PerformnTrasnactionDto has been binded")
 
+	if idempotencyKey := c.GetHeader(services.IdempotencyKeyHeader); idempotencyKey != "" {
+		ctx := c.Request.Context()
+		keyEntity, storedResponse, err := h.IdempotencyService.Begin(ctx, c.GetInt("client_id"), idempotencyKey, performnTransactionDto)
+		if err != nil {
+			err.JsonError(c)
+			return
+		}
+		if storedResponse != nil {
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(storedResponse.Status, "application/json; charset=utf-8", []byte(storedResponse.Body))
+			return
+		}
+		completed := false
+		// the key is freed when the transaction is not performed, so the client can retry it
+		defer func() {
+			if !completed {
+				h.IdempotencyService.Release(context.Background(), keyEntity)
+			}
+		}()
+		response, ok := h.performTransaction(c, performnTransactionDto)
+		if !ok {
+			return
+		}
+		// the transaction is committed: the key must never be released from now on
+		completed = true
+		body, jsonErr := json.Marshal(response)
+		if jsonErr != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+			return
+		}
+		if err := h.IdempotencyService.Complete(ctx, keyEntity, http.StatusOK, body); err != nil {
+			// the transaction is performed anyway, replays get a 409 until the key expires
+			h.Logger.Error("Error storing the response of the idempotency key "+idempotencyKey, zap.Error(err))
+		}
+		c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+		return
+	}
+
+	response, ok := h.performTransaction(c, performnTransactionDto)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// performTransaction validates and inserts the transaction. When it fails, the error
+// response has already been written and false is returned.
+func (h *ITransactionHandler) performTransaction(c *gin.Context, performnTransactionDto dto.PerformTransactionDto) (gin.H, bool) {
+
 	// pre-validation
 	//
 	// Is AccountID from a trusted source?
@@ -59,17 +130,23 @@ func (h *ITransactionHandler) PerformTransaction(c *gin.Context) {
 		fmt.Println("Transaction type is not valid")
 
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Transaction type is not valid"})
-		return
+		return nil, false
+	}
+	// 2. A saved beneficiary gives the IBAN, and the creditor name and concept when they are not sent
+	if performnTransactionDto.BeneficiaryID != nil {
+		if !h.applyBeneficiary(c, &performnTransactionDto) {
+			return nil, false
+		}
 	}
-	// 2. Transfer with Non empty ToAccountId
+	// 3. Transfer with Non empty ToAccountId
 	if performnTransactionDto.ToAccountNumber == nil && performnTransactionDto.Type == "TRANSFER" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Transaction type is not valid", "reason": "TRANSFER type needs to set to_account_id"})
-		return
+		return nil, false
 	}
-	// 3. Negative money
-	if performnTransactionDto.Amount <= 0 {
+	// 4. Negative money
+	if !performnTransactionDto.Amount.IsPositive() {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "amount cannot be negative."})
-		return
+		return nil, false
 	}
 	toAccountIdSql := sql.NullInt32{}
 	if performnTransactionDto.ToAccountNumber != nil {
@@ -77,15 +154,19 @@ func (h *ITransactionHandler) PerformTransaction(c *gin.Context) {
 		accNr := *performnTransactionDto.ToAccountNumber
 		fmt.Println("ACCOUNT NUMBER ", accNr)
 		id, err := h.AccountRepository.FetchAccountIdByAccountNumber(c, accNr)
+		if _, notFound := err.(*app_errors.ErrNotFound); notFound && performnTransactionDto.Type == "TRANSFER" {
+			// not an account of this bank: SEPA transfer through the clearing house
+			return h.performSepaTransfer(c, performnTransactionDto)
+		}
 		if err != nil {
 			fmt.Println("Error fetching accountId by Account Number")
 
 			err.JsonError(c)
-			return
+			return nil, false
 		}
 		if id == nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
-			return
+			return nil, false
 		}
 		toAccountIdSql.Valid = true
 		toAccountIdSql.Int32 = int32(*id)
@@ -103,29 +184,113 @@ func (h *ITransactionHandler) PerformTransaction(c *gin.Context) {
 		Type:        performnTransactionDto.Type,
 		Amount:      performnTransactionDto.Amount,
 	}
+	if performnTransactionDto.BeneficiaryID != nil {
+		transactionEntity.BeneficiaryID = sql.NullInt32{Int32: int32(*performnTransactionDto.BeneficiaryID), Valid: true}
+	}
 
 	err := h.TransactionRepository.InsertTransactionLedgerTx(c, &transactionEntity)
 	if err != nil {
 		fmt.Println("Error al insertar TransactionLedgerTx")
 
 		err.JsonError(c)
-		return
+		return nil, false
+	}
+	transactionDto, mapErr := mappers.ToTransactionDto(transactionEntity)
+	if mapErr != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return nil, false
+	}
+	transactionDto.ToAccountNumber = performnTransactionDto.ToAccountNumber
+	return gin.H{"transaction": transactionDto}, true
+}
+
+// applyBeneficiary fills the transfer with the beneficiary of the client that owns the account. When it fails,
+// the error response has already been written and false is returned.
+func (h *ITransactionHandler) applyBeneficiary(c *gin.Context, performnTransactionDto *dto.PerformTransactionDto) bool {
+	if performnTransactionDto.Type != "TRANSFER" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "beneficiary_id is only allowed in transfers"})
+		return false
+	}
+	if performnTransactionDto.ToAccountNumber != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "send either to_account_number or beneficiary_id"})
+		return false
+	}
+	beneficiary, err := h.BeneficiaryRepository.FetchBeneficiaryById(c.Request.Context(), *performnTransactionDto.BeneficiaryID)
+	if err != nil {
+		err.JsonError(c)
+		return false
+	}
+	// the middleware checked the account belongs to the client of the token
+	if beneficiary.ClientID != c.GetInt("client_id") {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Beneficiary not found"})
+		return false
+	}
+	performnTransactionDto.ToAccountNumber = &beneficiary.Iban
+	if performnTransactionDto.ToName == nil && beneficiary.Name.Valid {
+		performnTransactionDto.ToName = &beneficiary.Name.String
+	}
+	if performnTransactionDto.RemittanceInformation == nil && beneficiary.Reference.Valid {
+		performnTransactionDto.RemittanceInformation = &beneficiary.Reference.String
+	}
+	return true
+}
+
+// performSepaTransfer debits the account and queues the payment to the IBAN of another bank.
+// The amount waits in the SEPA clearing account until the clearing house settles or rejects it.
+func (h *ITransactionHandler) performSepaTransfer(c *gin.Context, performnTransactionDto dto.PerformTransactionDto) (gin.H, bool) {
+	creditorIban := utils.NormalizeIban(*performnTransactionDto.ToAccountNumber)
+	if _, err := (&utils.IbanHandler{}).Parse(creditorIban); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to_account_number is not a valid IBAN: " + err.Error()})
+		return nil, false
+	}
+	if performnTransactionDto.ToName == nil || strings.TrimSpace(*performnTransactionDto.ToName) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to_name is required for transfers to other banks"})
+		return nil, false
+	}
+	creditorName := strings.TrimSpace(*performnTransactionDto.ToName)
+	if utf8.RuneCountInString(creditorName) > paymententity.MaxCreditorNameLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("to_name cannot be longer than %d characters", paymententity.MaxCreditorNameLength)})
+		return nil, false
+	}
+	payment := paymententity.OutboundPaymentEntity{
+		AccountID:    performnTransactionDto.AccountID,
+		CreditorIban: creditorIban,
+		CreditorName: creditorName,
+		Amount:       performnTransactionDto.Amount,
+	}
+	if performnTransactionDto.RemittanceInformation != nil && strings.TrimSpace(*performnTransactionDto.RemittanceInformation) != "" {
+		remittance := strings.TrimSpace(*performnTransactionDto.RemittanceInformation)
+		if utf8.RuneCountInString(remittance) > paymententity.MaxRemittanceLength {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("remittance_information cannot be longer than %d characters", paymententity.MaxRemittanceLength)})
+			return nil, false
+		}
+		payment.RemittanceInformation = sql.NullString{String: remittance, Valid: true}
+	}
+
+	var transactionEntity trasnactionentity.TransactionEntity
+	if performnTransactionDto.BeneficiaryID != nil {
+		transactionEntity.BeneficiaryID = sql.NullInt32{Int32: int32(*performnTransactionDto.BeneficiaryID), Valid: true}
+	}
+	if err := h.PaymentRepository.InsertOutboundPayment(c.Request.Context(), &payment, &transactionEntity); err != nil {
+		err.JsonError(c)
+		return nil, false
 	}
-	transactionDto := dto.TransactionDto{
-		ID:              transactionEntity.ID,
-		AccountID:       transactionEntity.AccountID,
-		Type:            transactionEntity.Type,
-		Amount:          transactionEntity.Amount,
-		ToAccountNumber: performnTransactionDto.ToAccountNumber,
-		CreatedAt:       transactionEntity.CreatedAt,
-		UpdatedAt:       transactionEntity.UpdatedAt,
+	transactionDto, mapErr := mappers.ToTransactionDto(transactionEntity)
+	if mapErr != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return nil, false
 	}
-	c.JSON(http.StatusOK, gin.H{"transaction": transactionDto})
+	return gin.H{"transaction": transactionDto, "payment": mappers.ToOutboundPaymentDto(payment)}, true
 }
 
+// GET /transactions/:account_id
+//
+// With page, the transactions are paged with an offset as before. Otherwise they are paged with the
+// cursor returned in next_cursor/prev_cursor. Filters: from, to (RFC 3339 or YYYY-MM-DD), type,
+// min_amount, max_amount, counterparty_iban and sort (asc, desc).
 func (h *ITransactionHandler) GetTransactions(c *gin.Context) {
 	accountId := c.Param("account_id")
-	countStr := c.Query("count")
+	countStr := c.DefaultQuery("count", "20")
 	pageStr := c.Query("page")
 
 	// token validation, account id validation
@@ -139,18 +304,34 @@ func (h *ITransactionHandler) GetTransactions(c *gin.Context) {
 		c.AbortWithError(400, err)
 		return
 	}
-	pageInt, err := strconv.ParseInt(pageStr, 0, 32)
-	if err != nil {
-		c.AbortWithError(400, err)
+	filter, ok := transactionFilterFromQuery(c)
+	if !ok {
 		return
 	}
 
-	// Pre-validation
-	// account_id belongs to clientId ?
-
-	pagination, error := h.TransactionRepository.GetTransactions(context.Background(), int(accountIdInt), int(pageInt), int(countInt))
-	if error != nil {
-		error.JsonError(c)
+	var pagination paginationentity.Pagination[trasnactionentity.TransactionEntity]
+	var appErr app_errors.AppError
+	if pageStr != "" {
+		pageInt, err := strconv.ParseInt(pageStr, 0, 32)
+		if err != nil {
+			c.AbortWithError(400, err)
+			return
+		}
+		pagination, appErr = h.TransactionRepository.GetTransactions(c.Request.Context(), int(accountIdInt), filter, int(pageInt), int(countInt))
+	} else {
+		var cursor *paginationentity.Cursor
+		if token := c.Query("cursor"); token != "" {
+			decoded, err := paginationentity.DecodeCursor(token)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+				return
+			}
+			cursor = &decoded
+		}
+		pagination, appErr = h.TransactionRepository.ListTransactions(c.Request.Context(), int(accountIdInt), filter, cursor, int(countInt))
+	}
+	if appErr != nil {
+		appErr.JsonError(c)
 		return
 	}
 	paginationDto, err := mappers.ToPaginationTransactionDto(pagination)
@@ -159,6 +340,126 @@ func (h *ITransactionHandler) GetTransactions(c *gin.Context) {
 		return
 	}
 	c.JSON(http.StatusOK, paginationDto)
+}
+
+// transactionFilterFromQuery reads the filters of the transaction history. When they are not valid,
+// the error response has already been written and false is returned.
+func transactionFilterFromQuery(c *gin.Context) (trasnactionentity.TransactionFilter, bool) {
+	var filter trasnactionentity.TransactionFilter
+	for _, param := range []struct {
+		name  string
+		value **time.Time
+	}{{"from", &filter.From}, {"to", &filter.To}} {
+		value := c.Query(param.name)
+		if value == "" {
+			continue
+		}
+		date, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			date, err = time.Parse(time.DateOnly, value)
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s must be a RFC 3339 date-time or YYYY-MM-DD", param.name)})
+			return filter, false
+		}
+		date = date.UTC()
+		*param.value = &date
+	}
+	for _, param := range []struct {
+		name  string
+		value **money.Money
+	}{{"min_amount", &filter.MinAmount}, {"max_amount", &filter.MaxAmount}} {
+		value := c.Query(param.name)
+		if value == "" {
+			continue
+		}
+		amount, err := money.Parse(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s is not a valid amount", param.name)})
+			return filter, false
+		}
+		*param.value = &amount
+	}
+	filter.Type = strings.ToUpper(c.Query("type"))
+	if filter.Type != "" && !trasnactionentity.IsValidType(filter.Type) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Transaction type is not valid"})
+		return filter, false
+	}
+	filter.CounterpartyIban = strings.ReplaceAll(strings.ToUpper(c.Query("counterparty_iban")), " ", "")
+	filter.Sort = strings.ToLower(c.DefaultQuery("sort", trasnactionentity.SortDesc))
+	if filter.Sort != trasnactionentity.SortAsc && filter.Sort != trasnactionentity.SortDesc {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sort must be asc or desc"})
+		return filter, false
+	}
+	return filter, true
+}
+
+// GET /accounts/:id/activity?page=1&count=20
+//
+// Ledger entries of the account, newest first, including the incoming transfers.
+func (h *ITransactionHandler) GetAccountActivity(c *gin.Context) {
+	accountID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid identifier"})
+		return
+	}
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid page"})
+		return
+	}
+	count, err := strconv.Atoi(c.DefaultQuery("count", "20"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid count"})
+		return
+	}
+
+	activity, appErr := h.TransactionRepository.GetAccountActivity(c.Request.Context(), accountID, page, count)
+	if appErr != nil {
+		appErr.JsonError(c)
+		return
+	}
+	c.JSON(http.StatusOK, mappers.ToPaginationActivityEntryDto(activity))
+}
+
+// POST /transactions/:id/reversal
+//
+// Posts a REVERSAL transaction linked to the original one, with mirrored ledger entries.
+// Bank staff only.
+func (h *ITransactionHandler) ReverseTransaction(c *gin.Context) {
+	transactionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid identifier"})
+		return
+	}
+	var reverseTransactionDto dto.ReverseTransactionDto
+	if err := c.ShouldBindJSON(&reverseTransactionDto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !trasnactionentity.IsValidReversalReasonCode(reverseTransactionDto.ReasonCode) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "reason_code is not valid", "reason_codes": trasnactionentity.ReversalReasonCodes})
+		return
+	}
+	if reverseTransactionDto.Amount != nil && !reverseTransactionDto.Amount.IsPositive() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "amount must be positive."})
+		return
+	}
 
-	// fetchFromRepository
+	reversal, appErr := h.TransactionRepository.InsertReversalLedgerTx(
+		c.Request.Context(),
+		transactionID,
+		reverseTransactionDto.Amount,
+		reverseTransactionDto.ReasonCode,
+	)
+	if appErr != nil {
+		appErr.JsonError(c)
+		return
+	}
+	transactionDto, err := mappers.ToTransactionDto(reversal)
+	if err != nil {
+		c.AbortWithError(500, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"transaction": transactionDto})
 }
@@ -88,12 +88,47 @@ func AuthorizationMiddleware(c *gin.Context) {
 		c.AbortWithStatusJSON(500, gin.H{"error": "Internal Server Error"})
 		return
 	}
-	clientId := claims["client_id"].(float64)
-	clientIdInt := int(clientId)
-	c.Set("client_id", clientIdInt)
+	// bank staff users do not have a client_id
+	if clientId, ok := claims["client_id"].(float64); ok {
+		c.Set("client_id", int(clientId))
+	}
 	c.Next()
 }
 
+// Realm role of the bank staff. It is required by the administration endpoints
+const AdminRealmRole string = "ledger-admin"
+
+// AuthorizeRealmRoleHandler must be placed after the AuthorizationMiddleware.
+// It checks the role in the realm_access.roles claim of the Keycloak token
+func AuthorizeRealmRoleHandler(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenCtx, exists := c.Get("token")
+		if !exists {
+			c.AbortWithStatusJSON(401, gin.H{"error": "Unauthorized"})
+			return
+		}
+		token, ok := tokenCtx.(*jwt.Token)
+		if !ok {
+			c.AbortWithStatus(500)
+			return
+		}
+		claims, ok := token.Claims.(jwt.MapClaims)
+		if !ok {
+			c.AbortWithStatus(500)
+			return
+		}
+		realmAccess, _ := claims["realm_access"].(map[string]interface{})
+		roles, _ := realmAccess["roles"].([]interface{})
+		for _, element := range roles {
+			if element == role {
+				c.Next()
+				return
+			}
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+	}
+}
+
 func AuthenticateUserByClientIdMiddleware(c *gin.Context, clientId int) {
 	claimsClientId := c.GetInt("client_id")
 	if claimsClientId != clientId {
@@ -104,8 +139,13 @@ func AuthenticateUserByClientIdMiddleware(c *gin.Context, clientId int) {
 }
 
 func AuthenticationByClientIdHandler() gin.HandlerFunc {
+	return AuthenticationByClientIdParamHandler("client_id")
+}
+
+// AuthenticationByClientIdParamHandler reads the client id from the given url parameter
+func AuthenticationByClientIdParamHandler(param string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		clientIDStr := c.Param("client_id")
+		clientIDStr := c.Param(param)
 		clientID, err := strconv.Atoi(clientIDStr)
 		if err != nil {
 			c.AbortWithStatusJSON(400, gin.H{"error": "Invalid identifier"})
@@ -179,12 +219,17 @@ func AuthenticateUserByAccountIdMiddleware(c *gin.Context, accountId int) {
 }
 
 func AuthenticateByAccountIdHandler() gin.HandlerFunc {
+	return AuthenticateByAccountIdParamHandler("account_id")
+}
+
+// AuthenticateByAccountIdParamHandler reads the account id from the given url parameter
+func AuthenticateByAccountIdParamHandler(param string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		accountIdStr := c.Param("account_id")
-		fmt.Printf(":account_id param => %s\n", accountIdStr)
+		accountIdStr := c.Param(param)
+		fmt.Printf(":%s param => %s\n", param, accountIdStr)
 
 		accountID, err := strconv.ParseInt(accountIdStr, 0, 32)
-		fmt.Printf(":account_id param parsed => %d\n", accountID)
+		fmt.Printf(":%s param parsed => %d\n", param, accountID)
 
 		if err != nil {
 			fmt.Println("Error al parsear account id como parámetro de la url")
@@ -247,3 +292,56 @@ func AppMiddlewares() []func() gin.HandlerFunc {
 	return middlewares
 
 }
+
+// AuthenticateAuthorizeHoldHandler binds the hold request and checks the held account belongs to the client
+func AuthenticateAuthorizeHoldHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var authorizeHoldDto dto.AuthorizeHoldDto
+		if error := c.ShouldBindJSON(&authorizeHoldDto); error != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": error.Error()})
+			return
+		}
+		// the body stream is consumed, the handler reads the dto from the context
+		c.Set("authorize_hold_dto", authorizeHoldDto)
+		AuthenticateUserByAccountIdMiddleware(c, authorizeHoldDto.AccountID)
+	}
+}
+
+// AuthenticateByHoldIdHandler lets through the owner of the held account and the owner of the merchant account
+func AuthenticateByHoldIdHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		holdID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid identifier"})
+			return
+		}
+		claimsClientId := c.GetInt("client_id")
+		repositoryWrapper, exists := c.Get("repository_wrapper")
+		if !exists {
+			c.AbortWithStatus(500)
+			return
+		}
+		repositories, ok := repositoryWrapper.(*repositories.RepositoryWrapper)
+		if !ok {
+			c.AbortWithStatus(500)
+			return
+		}
+		hold, appErr := repositories.HoldRepository.FetchHoldById(c.Request.Context(), holdID)
+		if appErr != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		accountIDs := []int{hold.AccountID}
+		if hold.ToAccountID.Valid {
+			accountIDs = append(accountIDs, int(hold.ToAccountID.Int32))
+		}
+		for _, accountID := range accountIDs {
+			account, err := repositories.AccountRepository.FetchAccountById(c.Request.Context(), accountID)
+			if err == nil && account.ClientID == claimsClientId {
+				c.Next()
+				return
+			}
+		}
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+	}
+}
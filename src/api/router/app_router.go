@@ -44,7 +44,7 @@ func (appRouter AppRouter) BuildRoutes(router *gin.Engine) {
 	clientHandler := handlers.IClientHandler{
 		ClientRepository:             appRouter.RepositoryWrapper.ClientRepository,
 		RegistryAccountOtpRepository: appRouter.RepositoryWrapper.RegistryAccountOtpRepository,
-		ClientService:                services.NewClientService(appRouter.RepositoryWrapper.ClientRepository, appRouter.RepositoryWrapper.RegistryAccountOtpRepository),
+		ClientService:                services.NewClientService(appRouter.RepositoryWrapper.ClientRepository, appRouter.RepositoryWrapper.RegistryAccountOtpRepository, appRouter.RepositoryWrapper.BranchRepository),
 	}
 	accountHandler := handlers.IAccountHandler{
 		KeycloakClient:               *appRouter.KeycloakClient,
@@ -58,6 +58,96 @@ func (appRouter AppRouter) BuildRoutes(router *gin.Engine) {
 	transactionHandler := handlers.ITransactionHandler{
 		AccountRepository:     appRouter.RepositoryWrapper.AccountRepository,
 		TransactionRepository: appRouter.RepositoryWrapper.TransactionRepository,
+		IdempotencyService: services.NewIdempotencyService(
+			appRouter.RepositoryWrapper.IdempotencyRepository,
+			appRouter.RedisClient,
+			services.IdempotencyKeyTTLFromEnv(),
+			appRouter.ZapLogger,
+		),
+		PaymentRepository:     appRouter.RepositoryWrapper.PaymentRepository,
+		BeneficiaryRepository: appRouter.RepositoryWrapper.BeneficiaryRepository,
+		Logger:                appRouter.ZapLogger,
+	}
+
+	coolingOff, coolingOffLimit := handlers.BeneficiaryCoolingOffFromEnv()
+	beneficiaryHandler := handlers.IBeneficiaryHandler{
+		BeneficiaryRepository: appRouter.RepositoryWrapper.BeneficiaryRepository,
+		CoolingOff:            coolingOff,
+		CoolingOffLimit:       coolingOffLimit,
+	}
+
+	holdHandler := handlers.IHoldHandler{
+		HoldRepository:    appRouter.RepositoryWrapper.HoldRepository,
+		AccountRepository: appRouter.RepositoryWrapper.AccountRepository,
+		TTL:               handlers.HoldTTLFromEnv(),
+	}
+
+	standingOrderHandler := handlers.IStandingOrderHandler{
+		ScheduledTransferRepository: appRouter.RepositoryWrapper.ScheduledTransferRepository,
+		AccountRepository:           appRouter.RepositoryWrapper.AccountRepository,
+	}
+
+	reconciliationHandler := handlers.IReconciliationHandler{
+		ReconciliationService: services.NewReconciliationService(appRouter.RepositoryWrapper.ReconciliationRepository, appRouter.ZapLogger),
+	}
+
+	currencyHandler := handlers.ICurrencyHandler{
+		CurrencyRepository:  appRouter.RepositoryWrapper.CurrencyRepository,
+		ExchangeRateService: services.NewExchangeRateService(appRouter.RepositoryWrapper.CurrencyRepository, appRouter.ZapLogger),
+	}
+
+	accountStatusHandler := handlers.IAccountStatusHandler{
+		AccountStatusRepository: appRouter.RepositoryWrapper.AccountStatusRepository,
+		TransactionRepository:   appRouter.RepositoryWrapper.TransactionRepository,
+	}
+
+	overdraftHandler := handlers.IOverdraftHandler{
+		OverdraftRepository: appRouter.RepositoryWrapper.OverdraftRepository,
+	}
+
+	feeHandler := handlers.IFeeHandler{
+		FeeRepository:      appRouter.RepositoryWrapper.FeeRepository,
+		CurrencyRepository: appRouter.RepositoryWrapper.CurrencyRepository,
+	}
+
+	balanceHandler := handlers.IBalanceHandler{
+		BalanceRepository: appRouter.RepositoryWrapper.BalanceRepository,
+	}
+
+	statementHandler := handlers.IStatementHandler{
+		StatementService: services.NewStatementService(*appRouter.RepositoryWrapper, appRouter.ZapLogger),
+		Logger:           appRouter.ZapLogger,
+	}
+
+	documentHandler := handlers.IDocumentHandler{
+		DocumentService: services.NewDocumentService(
+			*appRouter.RepositoryWrapper,
+			statementHandler.StatementService,
+			appRouter.ZapLogger,
+		),
+		DocumentRepository: appRouter.RepositoryWrapper.DocumentRepository,
+	}
+
+	paymentHandler := handlers.IPaymentHandler{
+		PaymentRepository: appRouter.RepositoryWrapper.PaymentRepository,
+	}
+	ibanHandler := handlers.IIbanHandler{
+		BankDirectoryService: services.NewBankDirectoryService(appRouter.RepositoryWrapper.BankDirectoryRepository, appRouter.ZapLogger),
+	}
+	payeeHandler := handlers.IPayeeHandler{
+		PayeeService: services.NewPayeeService(*appRouter.RepositoryWrapper, services.PayeeVerificationLimitsFromEnv(), appRouter.ZapLogger),
+	}
+	branchHandler := handlers.IBranchHandler{
+		BranchRepository: appRouter.RepositoryWrapper.BranchRepository,
+	}
+	inboundPaymentHandler := handlers.IInboundPaymentHandler{
+		InboundPaymentRepository: appRouter.RepositoryWrapper.InboundPaymentRepository,
+	}
+
+	interestHandler := handlers.IInterestHandler{
+		InterestRepository: appRouter.RepositoryWrapper.InterestRepository,
+		FeeRepository:      appRouter.RepositoryWrapper.FeeRepository,
+		CurrencyRepository: appRouter.RepositoryWrapper.CurrencyRepository,
 	}
 
 	authHandler := handlers.IAuthorizationHandler{
@@ -80,12 +170,86 @@ func (appRouter AppRouter) BuildRoutes(router *gin.Engine) {
 		accounts.POST("", logger,authHandlerMiddleware(), accountHandler.CreateAccount)
 		accounts.POST("/completeNewUserRegistration", logger,accountHandler.CompleteNewUserRegistration)
 		// Verificar el client ID en el middleware de auth
+		// :id es el client_id. Gin no admite otro nombre de parámetro en las rutas /accounts/:id/...
 		accounts.GET(
-			"/:client_id",
+			"/:id",
 			authHandlerMiddleware(),
-			middleware.AuthenticationByClientIdHandler(),
+			middleware.AuthenticationByClientIdParamHandler("id"),
 			accountHandler.FetchAccounts,
 		)
+		// verificar que la cuenta corresponda al cliente
+		accounts.GET(
+			"/:id/activity",
+			logger,
+			authHandlerMiddleware(),
+			middleware.AuthenticateByAccountIdParamHandler("id"),
+			transactionHandler.GetAccountActivity,
+		)
+		// verificar que la cuenta corresponda al cliente
+		accounts.GET(
+			"/:id/payments",
+			logger,
+			authHandlerMiddleware(),
+			middleware.AuthenticateByAccountIdParamHandler("id"),
+			paymentHandler.FetchOutboundPayments,
+		)
+		// verificar que la cuenta corresponda al cliente
+		accounts.GET(
+			"/:id/interest-accruals",
+			logger,
+			authHandlerMiddleware(),
+			middleware.AuthenticateByAccountIdParamHandler("id"),
+			interestHandler.FetchPendingAccruals,
+		)
+		// verificar que la cuenta corresponda al cliente
+		accounts.GET(
+			"/:id/balance",
+			logger,
+			authHandlerMiddleware(),
+			middleware.AuthenticateByAccountIdParamHandler("id"),
+			balanceHandler.FetchBalanceAt,
+		)
+		// verificar que la cuenta corresponda al cliente
+		accounts.GET(
+			"/:id/balance-history",
+			logger,
+			authHandlerMiddleware(),
+			middleware.AuthenticateByAccountIdParamHandler("id"),
+			balanceHandler.FetchBalanceHistory,
+		)
+		// verificar que la cuenta corresponda al cliente
+		accounts.GET(
+			"/:id/statements",
+			logger,
+			authHandlerMiddleware(),
+			middleware.AuthenticateByAccountIdParamHandler("id"),
+			statementHandler.FetchStatement,
+		)
+		// verificar que la cuenta corresponda al cliente
+		accounts.GET(
+			"/:id/documents/statement",
+			logger,
+			authHandlerMiddleware(),
+			middleware.AuthenticateByAccountIdParamHandler("id"),
+			documentHandler.FetchStatementPdf,
+		)
+		// verificar que la cuenta corresponda al cliente
+		accounts.GET(
+			"/:id/documents/ownership-certificate",
+			logger,
+			authHandlerMiddleware(),
+			middleware.AuthenticateByAccountIdParamHandler("id"),
+			documentHandler.FetchOwnershipCertificate,
+		)
+		// verificar que la cuenta corresponda al cliente
+		standingOrders := accounts.Group("/:id/standing-orders", logger, authHandlerMiddleware(), middleware.AuthenticateByAccountIdParamHandler("id"))
+		{
+			standingOrders.GET("", standingOrderHandler.FetchStandingOrders)
+			standingOrders.POST("", standingOrderHandler.CreateStandingOrder)
+			standingOrders.GET("/:order_id", standingOrderHandler.FetchStandingOrder)
+			standingOrders.PATCH("/:order_id", standingOrderHandler.UpdateStandingOrder)
+			standingOrders.DELETE("/:order_id", standingOrderHandler.CancelStandingOrder)
+		}
 	}
 	clients := router.Group("/clients")
 	{
@@ -98,6 +262,16 @@ func (appRouter AppRouter) BuildRoutes(router *gin.Engine) {
 		)
 		// Este endpoint debe recibir algún token especial para la autorización
 		clients.POST("",logger, clientHandler.CreateClient)
+		// beneficiarios guardados por el cliente para sus transferencias
+		// :identification es el client_id. Gin no admite otro nombre de parámetro en las rutas /clients/:identification/...
+		beneficiaries := clients.Group("/:identification/beneficiaries", logger, authHandlerMiddleware(), middleware.AuthenticationByClientIdParamHandler("identification"))
+		{
+			beneficiaries.GET("", beneficiaryHandler.FetchBeneficiaries)
+			beneficiaries.POST("", beneficiaryHandler.CreateBeneficiary)
+			beneficiaries.GET("/:beneficiary_id", beneficiaryHandler.FetchBeneficiary)
+			beneficiaries.PATCH("/:beneficiary_id", beneficiaryHandler.UpdateBeneficiary)
+			beneficiaries.DELETE("/:beneficiary_id", beneficiaryHandler.DeleteBeneficiary)
+		}
 
 	}
 	transactions := router.Group("/transactions",logger, authHandlerMiddleware())
@@ -114,5 +288,75 @@ func (appRouter AppRouter) BuildRoutes(router *gin.Engine) {
 		    middleware.AuthenticatePerformTransactionHandler(),
 			transactionHandler.PerformTransaction,
 		)
+		// solo personal del banco
+		transactions.POST(
+			"/:id/reversal",
+			middleware.AuthorizeRealmRoleHandler(middleware.AdminRealmRole),
+			transactionHandler.ReverseTransaction,
+		)
+	}
+	// solo personal del banco
+	admin := router.Group("/admin", logger, authHandlerMiddleware(), middleware.AuthorizeRealmRoleHandler(middleware.AdminRealmRole))
+	{
+		admin.POST("/reconciliation", reconciliationHandler.Reconcile)
+		admin.PUT("/exchange-rates", currencyHandler.UpdateExchangeRates)
+		// ciclo de vida de las cuentas: congelar, descongelar, inactivar, reactivar y cancelar
+		admin.POST("/accounts/:id/freeze", accountStatusHandler.FreezeAccount)
+		admin.POST("/accounts/:id/unfreeze", accountStatusHandler.UnfreezeAccount)
+		admin.POST("/accounts/:id/dormant", accountStatusHandler.MarkAccountDormant)
+		admin.POST("/accounts/:id/reactivate", accountStatusHandler.ReactivateAccount)
+		admin.POST("/accounts/:id/close", accountStatusHandler.CloseAccount)
+		admin.GET("/accounts/:id/status-changes", accountStatusHandler.FetchStatusChanges)
+		// descubierto autorizado de la cuenta
+		admin.GET("/accounts/:id/overdraft", overdraftHandler.FetchOverdraft)
+		admin.PUT("/accounts/:id/overdraft", overdraftHandler.SetOverdraft)
+		admin.DELETE("/accounts/:id/overdraft", overdraftHandler.DeleteOverdraft)
+		// comisiones por tipo de operación, producto y divisa
+		admin.PUT("/fee-schedules", feeHandler.SetFeeSchedule)
+		admin.DELETE("/fee-schedules/:id", feeHandler.DeleteFeeSchedule)
+		// tipos de interés por producto y divisa, con fecha de entrada en vigor
+		admin.PUT("/interest-rates", interestHandler.SetInterestRate)
+		admin.DELETE("/interest-rates/:id", interestHandler.DeleteInterestRate)
+		// transferencias SEPA recibidas: las que no tienen cuenta quedan en la cuenta de suspenso
+		admin.GET("/inbound-payments", inboundPaymentHandler.FetchInboundPayments)
+		admin.POST("/inbound-payments/:id/resolve", inboundPaymentHandler.ResolveInboundPayment)
+		admin.POST("/inbound-payments/:id/return", inboundPaymentHandler.ReturnInboundPayment)
+		// directorio de bancos para el BIC y el nombre del banco de un IBAN
+		admin.PUT("/bank-directory", ibanHandler.UpdateBankDirectory)
+		admin.POST("/bank-directory/reload", ibanHandler.ReloadBankDirectory)
+		// oficinas: las cuentas se abren en la oficina del cliente con el siguiente número de su secuencia
+		admin.GET("/branches", branchHandler.FetchBranches)
+		admin.PUT("/branches/:code", branchHandler.SetBranch)
+		admin.PUT("/clients/:id/branch", branchHandler.SetClientBranch)
+	}
+	router.GET("/currencies", logger, authHandlerMiddleware(), currencyHandler.FetchCurrencies)
+	router.GET("/exchange-rates", logger, authHandlerMiddleware(), currencyHandler.FetchExchangeRates)
+	router.GET("/products", logger, authHandlerMiddleware(), feeHandler.FetchProducts)
+	router.GET("/fee-schedules", logger, authHandlerMiddleware(), feeHandler.FetchFeeSchedules)
+	router.GET("/interest-rates", logger, authHandlerMiddleware(), interestHandler.FetchInterestRates)
+	router.GET("/iban/:iban", logger, authHandlerMiddleware(), ibanHandler.LookupIban)
+	// Confirmation of Payee: el titular de la cuenta antes de una transferencia, con límite de consultas por cliente
+	router.POST("/payee-verifications", logger, authHandlerMiddleware(), payeeHandler.VerifyPayee)
+	// Público: quien recibe un documento comprueba el código sin tener cuenta en el banco
+	router.GET("/documents/verify/:code", logger, documentHandler.VerifyDocument)
+	holds := router.Group("/holds", logger, authHandlerMiddleware())
+	{
+		// verificar que la cuenta retenida corresponda al cliente
+		holds.POST(
+			"",
+			middleware.AuthenticateAuthorizeHoldHandler(),
+			holdHandler.AuthorizeHold,
+		)
+		// verificar que la retención sea de una cuenta del cliente (pagador o comercio)
+		holds.POST(
+			"/:id/capture",
+			middleware.AuthenticateByHoldIdHandler(),
+			holdHandler.CaptureHold,
+		)
+		holds.POST(
+			"/:id/void",
+			middleware.AuthenticateByHoldIdHandler(),
+			holdHandler.VoidHold,
+		)
 	}
 }
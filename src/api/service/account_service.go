@@ -3,17 +3,22 @@ package services
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"os"
 	dto "src/api/dto"
 	accountentity "src/domain/account"
+	branchentity "src/domain/branch"
 	cliententity "src/domain/client"
+	currencyentity "src/domain/currency"
 	app_errors "src/errors"
 	"src/mappers"
 	"src/repositories"
 	"src/utils"
+	"strings"
 )
 
 type AccountService interface {
-	CreateAccount(clientId int) (dto.AccountDto, app_errors.AppError)
+	CreateAccount(clientId int, currency string, product string) (dto.AccountDto, app_errors.AppError)
 	CreateAccountTx(context context.Context, tx *sql.Tx, clientId int) (dto.AccountDto, app_errors.AppError)
 	CompleteClientRegistrationBankAccount(
 		req dto.CompleteClientRegistrationBankAccountRequest,
@@ -33,81 +38,143 @@ func NewAccountService(
 	}
 }
 
-func (h *accountService) CreateAccount(clientId int) (dto.AccountDto, app_errors.AppError) {
-
-	const spainCode string = "ES"
-	const bankDigits string = "0182"
-	const branchDigits string = "0600"
-	handler := utils.IbanHandler{}
-	accNumber := handler.GenerateAccountNumber(10)
-	cc := handler.DomesticCheckDigits(bankDigits, branchDigits, accNumber)
-
-	bban := utils.Bban{
-		BankCode:            bankDigits,
-		BranchCode:          branchDigits,
-		DomesticCheckDigits: cc,
-		AccountNumber:       accNumber,
+// CreateAccount opens an account in the given currency and product, EUR and STANDARD when they are empty
+func (h *accountService) CreateAccount(clientId int, currency string, product string) (dto.AccountDto, app_errors.AppError) {
+	context := context.Background()
+	if currency == "" {
+		currency = currencyentity.Default
 	}
-	iban, err := handler.ComputeIban(bban, spainCode)
-	if err != nil {
-		return dto.AccountDto{}, nil
-
+	currency, codeErr := currencyentity.NormalizeCode(currency)
+	if codeErr != nil {
+		return dto.AccountDto{}, &app_errors.ErrBadRequest{Message: codeErr.Error()}
+	}
+	if _, err := h.RepositoryWrapper.CurrencyRepository.FetchCurrency(context, currency); err != nil {
+		if _, notFound := err.(*app_errors.ErrNotFound); notFound {
+			return dto.AccountDto{}, &app_errors.ErrBadRequest{Message: "currency " + currency + " is not supported"}
+		}
+		return dto.AccountDto{}, err
+	}
+	product = strings.ToUpper(strings.TrimSpace(product))
+	if product == "" {
+		product = accountentity.DefaultProduct
+	}
+	if _, err := h.RepositoryWrapper.FeeRepository.FetchProduct(context, product); err != nil {
+		if _, notFound := err.(*app_errors.ErrNotFound); notFound {
+			return dto.AccountDto{}, &app_errors.ErrBadRequest{Message: "product " + product + " is not supported"}
+		}
+		return dto.AccountDto{}, err
 	}
 
 	accountEntity := accountentity.AccountEntity{
-		ClientID:      clientId,
-		AccountNumber: iban,
+		ClientID: clientId,
+		Currency: currency,
+		Product:  product,
 	}
-	context := context.Background()
-	error := h.RepositoryWrapper.AccountRepository.InsertAccount(context, &accountEntity)
-	if error != nil {
-
-		return dto.AccountDto{}, nil
+	appErr := h.RepositoryWrapper.TransactionRepository.RunInTx(context, func(tx *sql.Tx) app_errors.AppError {
+		accountNumber, err := h.allocateAccountNumber(context, tx, clientId)
+		if err != nil {
+			return err
+		}
+		accountEntity.AccountNumber = accountNumber
+		return h.RepositoryWrapper.AccountRepository.InsertAccountTx(context, tx, &accountEntity)
+	})
+	if appErr != nil {
+		return dto.AccountDto{}, appErr
 	}
-	balance := 0.0
-	var balancePtr *float64 = &balance
+	balancePtr := &accountentity.AccountBalance{AccountID: accountEntity.ID}
 
 	return mappers.ToAccountDTO(accountEntity, balancePtr), nil
 
 }
 
 func (h *accountService) CreateAccountTx(context context.Context, tx *sql.Tx, clientId int) (dto.AccountDto, app_errors.AppError) {
-	const spainCode string = "ES"
-	const bankDigits string = "0182"
-	const branchDigits string = "0600"
-	handler := utils.IbanHandler{}
-	accNumber := handler.GenerateAccountNumber(10)
-	cc := handler.DomesticCheckDigits(bankDigits, branchDigits, accNumber)
-
-	bban := utils.Bban{
-		BankCode:            bankDigits,
-		BranchCode:          branchDigits,
-		DomesticCheckDigits: cc,
-		AccountNumber:       accNumber,
-	}
-	iban, err := handler.ComputeIban(bban, spainCode)
+	accountNumber, err := h.allocateAccountNumber(context, tx, clientId)
 	if err != nil {
-		return dto.AccountDto{}, nil
-
+		return dto.AccountDto{}, err
 	}
-
 	accountEntity := accountentity.AccountEntity{
 		ClientID:      clientId,
-		AccountNumber: iban,
+		AccountNumber: accountNumber,
 	}
 
-	error := h.RepositoryWrapper.AccountRepository.InsertAccountTx(context, tx, &accountEntity)
-	if error != nil {
-
-		return dto.AccountDto{}, nil
+	err = h.RepositoryWrapper.AccountRepository.InsertAccountTx(context, tx, &accountEntity)
+	if err != nil {
+		return dto.AccountDto{}, err
 	}
-	balance := 0.0
-	var balancePtr *float64 = &balance
+	balancePtr := &accountentity.AccountBalance{AccountID: accountEntity.ID}
 
 	return mappers.ToAccountDTO(accountEntity, balancePtr), nil
 
 }
 
+// maxAllocationAttempts bounds the numbers skipped in a row because they are already taken, i.e. by the
+// accounts opened with random numbers before the branches had a sequence
+const maxAllocationAttempts = 100
+
+// BankCodes returns the bank code of the IBANs and the branch of the clients without one,
+// set by BANK_CODE and BANK_BRANCH_CODE
+func BankCodes() (string, string, error) {
+	bankCode := os.Getenv("BANK_CODE")
+	if bankCode == "" {
+		bankCode = branchentity.DefaultBankCode
+	}
+	branchCode := os.Getenv("BANK_BRANCH_CODE")
+	if branchCode == "" {
+		branchCode = branchentity.DefaultBranchCode
+	}
+	if !branchentity.IsValidCode(bankCode) {
+		return "", "", fmt.Errorf("BANK_CODE %q: %w", bankCode, branchentity.ErrInvalidCode)
+	}
+	if !branchentity.IsValidCode(branchCode) {
+		return "", "", fmt.Errorf("BANK_BRANCH_CODE %q: %w", branchCode, branchentity.ErrInvalidCode)
+	}
+	return bankCode, branchCode, nil
+}
+
+// allocateAccountNumber takes the next number of the branch of the client and returns its IBAN.
+// The numbers already in use are skipped.
+func (h *accountService) allocateAccountNumber(ctx context.Context, tx *sql.Tx, clientId int) (string, app_errors.AppError) {
+	bankCode, branchCode, err := BankCodes()
+	if err != nil {
+		return "", &app_errors.ErrInternalServer{Reason: err}
+	}
+	clientBranch, appErr := h.RepositoryWrapper.BranchRepository.FetchClientBranch(ctx, tx, clientId)
+	if appErr != nil {
+		return "", appErr
+	}
+	if clientBranch.Valid {
+		branchCode = clientBranch.String
+	}
+	handler := utils.IbanHandler{}
+	for attempt := 0; attempt < maxAllocationAttempts; attempt++ {
+		sequence, appErr := h.RepositoryWrapper.BranchRepository.NextAccountSequence(ctx, tx, branchCode)
+		if appErr != nil {
+			if _, notFound := appErr.(*app_errors.ErrNotFound); notFound {
+				return "", &app_errors.ErrInternalServer{Message: "branch " + branchCode + " does not exist", Reason: appErr}
+			}
+			return "", appErr
+		}
+		accNumber := fmt.Sprintf("%0*d", branchentity.AccountNumberDigits, sequence)
+		iban, err := handler.ComputeIban(utils.Bban{
+			BankCode:            bankCode,
+			BranchCode:          branchCode,
+			DomesticCheckDigits: handler.DomesticCheckDigits(bankCode, branchCode, accNumber),
+			AccountNumber:       accNumber,
+		}, "ES")
+		if err != nil {
+			return "", &app_errors.ErrInternalServer{Reason: err}
+		}
+		exists, appErr := h.RepositoryWrapper.BranchRepository.AccountNumberExists(ctx, tx, iban)
+		if appErr != nil {
+			return "", appErr
+		}
+		if !exists {
+			return iban, nil
+		}
+	}
+	return "", &app_errors.ErrInternalServer{Message: fmt.Sprintf("no free account number found in branch %s after %d attempts", branchCode, maxAllocationAttempts)}
+}
+
 func (s *accountService) CompleteClientRegistrationBankAccount(
 	req dto.CompleteClientRegistrationBankAccountRequest,
 	clientEntity cliententity.ClientEntity,
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	dto "src/api/dto"
+	bankentity "src/domain/bank"
+	app_errors "src/errors"
+	"src/repositories"
+	"src/utils"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+type BankDirectoryService interface {
+	LookupIban(ctx context.Context, iban string) (dto.IbanDto, app_errors.AppError)
+	ReplaceBankDirectory(ctx context.Context, banks []bankentity.BankEntity) (int, app_errors.AppError)
+	LoadBankDirectoryFile(ctx context.Context, path string) (int, app_errors.AppError)
+}
+
+type bankDirectoryService struct {
+	BankDirectoryRepository repositories.BankDirectoryRepository
+	Logger                  *zap.Logger
+}
+
+func NewBankDirectoryService(bankDirectoryRepository repositories.BankDirectoryRepository, logger *zap.Logger) BankDirectoryService {
+	return &bankDirectoryService{
+		BankDirectoryRepository: bankDirectoryRepository,
+		Logger:                  logger,
+	}
+}
+
+// LookupIban validates the IBAN and, when it is valid, finds its bank in the directory. An invalid IBAN is
+// not an error: the result tells why it is not valid.
+func (s *bankDirectoryService) LookupIban(ctx context.Context, iban string) (dto.IbanDto, app_errors.AppError) {
+	result := dto.IbanDto{
+		ElectronicFormat: utils.NormalizeIban(iban),
+		PrintFormat:      utils.PrintIban(iban),
+	}
+	parsed, err := (&utils.IbanHandler{}).Parse(iban)
+	if err != nil {
+		reason := utils.IbanReasonFormat
+		if ibanErr, ok := err.(*utils.IbanError); ok {
+			reason = ibanErr.Reason
+		}
+		message := err.Error()
+		result.Reason, result.Message = &reason, &message
+		return result, nil
+	}
+	result.Valid = true
+	result.CountryCode = parsed.CountryCode
+	result.BankCode = parsed.BankCode
+	result.BranchCode = parsed.BranchCode
+	result.AccountNumber = parsed.AccountNumber
+
+	bank, appErr := s.BankDirectoryRepository.FetchBank(ctx, parsed.CountryCode, parsed.BankCode+parsed.BranchCode, parsed.BankCode)
+	if _, notFound := appErr.(*app_errors.ErrNotFound); notFound {
+		return result, nil
+	}
+	if appErr != nil {
+		return dto.IbanDto{}, appErr
+	}
+	result.Bic, result.BankName = &bank.Bic, &bank.Name
+	return result, nil
+}
+
+// ReplaceBankDirectory checks the banks and stores them in place of the whole directory. Returns how many were stored.
+func (s *bankDirectoryService) ReplaceBankDirectory(ctx context.Context, banks []bankentity.BankEntity) (int, app_errors.AppError) {
+	if len(banks) == 0 {
+		return 0, &app_errors.ErrBadRequest{Message: "no banks"}
+	}
+	for i := range banks {
+		if err := banks[i].Normalize(); err != nil {
+			return 0, &app_errors.ErrBadRequest{Message: fmt.Sprintf("bank %d (%s %s): %s", i+1, banks[i].CountryCode, banks[i].BankCode, err.Error())}
+		}
+	}
+	if appErr := s.BankDirectoryRepository.ReplaceBankDirectory(ctx, banks); appErr != nil {
+		return 0, appErr
+	}
+	return len(banks), nil
+}
+
+// LoadBankDirectoryFile replaces the directory with the banks of a CSV file (see ParseBankDirectoryCSV) and
+// returns how many were loaded
+func (s *bankDirectoryService) LoadBankDirectoryFile(ctx context.Context, path string) (int, app_errors.AppError) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, &app_errors.ErrInternalServer{Reason: err}
+	}
+	defer file.Close()
+	banks, err := ParseBankDirectoryCSV(file)
+	if err != nil {
+		return 0, &app_errors.ErrBadRequest{Message: fmt.Sprintf("%s: %s", path, err.Error())}
+	}
+	count, appErr := s.ReplaceBankDirectory(ctx, banks)
+	if appErr != nil {
+		return 0, appErr
+	}
+	s.Logger.Info(fmt.Sprintf("Loaded %d banks from %s", count, path))
+	return count, nil
+}
+
+// ParseBankDirectoryCSV reads one bank per line:
+//
+//	country,bank_code,bic,name
+//	ES,2100,CAIXESBBXXX,CaixaBank
+//
+// The header line is optional. Lines starting with # are ignored.
+func ParseBankDirectoryCSV(r io.Reader) ([]bankentity.BankEntity, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = 4
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	banks := make([]bankentity.BankEntity, 0, len(records))
+	for i, record := range records {
+		if i == 0 && strings.EqualFold(record[0], "country") {
+			continue
+		}
+		banks = append(banks, bankentity.BankEntity{CountryCode: record[0], BankCode: record[1], Bic: record[2], Name: record[3]})
+	}
+	return banks, nil
+}
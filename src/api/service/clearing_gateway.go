@@ -0,0 +1,404 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	paymententity "src/domain/payment"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ClearingGateway sends the outbound SEPA payments to the clearing house and reads back what became of them
+type ClearingGateway interface {
+	// SubmitBatch sends the batch as one pain.001 message. An error means it was not sent.
+	SubmitBatch(ctx context.Context, batch paymententity.PaymentBatch) error
+	// FetchStatusReports returns the pain.002 status reports received and not acknowledged yet
+	FetchStatusReports(ctx context.Context) ([]paymententity.StatusReport, error)
+	// AcknowledgeStatusReport marks the report as applied, so it is not returned again
+	AcknowledgeStatusReport(ctx context.Context, report paymententity.StatusReport) error
+	// FetchInboundFiles returns the credit transfers received from other banks (pacs.008, camt.054) and not acknowledged yet
+	FetchInboundFiles(ctx context.Context) ([]paymententity.InboundFile, error)
+	// AcknowledgeInboundFile marks the file as posted, so it is not returned again
+	AcknowledgeInboundFile(ctx context.Context, file paymententity.InboundFile) error
+	// SubmitReturns sends the batch as one pacs.004 message. An error means it was not sent.
+	SubmitReturns(ctx context.Context, batch paymententity.ReturnBatch) error
+}
+
+/**
+* FileClearingGateway stands in for the clearing house with two directories: every batch is written
+* to OutboxDir as pain001-<MsgId>.xml (pacs004-<MsgId>.xml for returns), and the pain.002 status reports
+* and the pacs.008/camt.054 credits are read from the *.xml files of InboxDir. Acknowledged files are
+* moved to InboxDir/processed, and the files that cannot be parsed to InboxDir/failed.
+ */
+type FileClearingGateway struct {
+	OutboxDir       string
+	InboxDir        string
+	InitiatingParty string // Name of the bank, InitgPty of the pain.001
+	Bic             string // BIC of the bank, DbtrAgt of the pain.001. NOTPROVIDED when empty
+}
+
+func NewFileClearingGateway(outboxDir string, inboxDir string, initiatingParty string, bic string) (*FileClearingGateway, error) {
+	for _, dir := range []string{outboxDir, inboxDir} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, err
+		}
+	}
+	return &FileClearingGateway{OutboxDir: outboxDir, InboxDir: inboxDir, InitiatingParty: initiatingParty, Bic: bic}, nil
+}
+
+const pain001Namespace = "urn:iso:std:iso:20022:tech:xsd:pain.001.001.03"
+
+// SubmitBatch writes the pain.001 to a temporary file first, so a half written batch is never picked up
+func (g *FileClearingGateway) SubmitBatch(ctx context.Context, batch paymententity.PaymentBatch) error {
+	if len(batch.Payments) == 0 {
+		return errors.New("empty batch")
+	}
+	return g.writeOutbox("pain001-"+batch.MessageID+".xml", func(w io.Writer) error {
+		return WritePain001(w, batch, g.InitiatingParty, g.Bic)
+	})
+}
+
+func (g *FileClearingGateway) SubmitReturns(ctx context.Context, batch paymententity.ReturnBatch) error {
+	if len(batch.Payments) == 0 {
+		return errors.New("empty batch")
+	}
+	return g.writeOutbox("pacs004-"+batch.MessageID+".xml", func(w io.Writer) error {
+		return WritePacs004(w, batch, g.Bic)
+	})
+}
+
+// writeOutbox writes the file under a temporary name and renames it once complete
+func (g *FileClearingGateway) writeOutbox(name string, write func(w io.Writer) error) error {
+	file, err := os.CreateTemp(g.OutboxDir, ".outbox-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	if err := write(file); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), filepath.Join(g.OutboxDir, name))
+}
+
+/**
+* WritePain001 writes the batch as an ISO 20022 pain.001.001.03 customer credit transfer initiation.
+* The payments of the same debtor account share a payment information block (PmtInf), in the order
+* they come in the batch. Every transfer is SEPA (SvcLvl) with shared charges (SLEV).
+ */
+func WritePain001(w io.Writer, batch paymententity.PaymentBatch, initiatingParty string, bic string) error {
+	groups := [][]paymententity.OutboundPaymentEntity{}
+	byDebtor := map[string]int{}
+	for _, payment := range batch.Payments {
+		i, ok := byDebtor[payment.DebtorIban]
+		if !ok {
+			i = len(groups)
+			byDebtor[payment.DebtorIban] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], payment)
+	}
+
+	x := newXmlStream(w)
+	x.token(xml.ProcInst{Target: "xml", Inst: []byte(`version="1.0" encoding="UTF-8"`)})
+	x.start("Document", xml.Attr{Name: xml.Name{Local: "xmlns"}, Value: pain001Namespace})
+	x.start("CstmrCdtTrfInitn")
+	x.start("GrpHdr")
+	x.leaf("MsgId", batch.MessageID)
+	x.leaf("CreDtTm", camtDateTime(batch.CreatedAt))
+	x.leaf("NbOfTxs", strconv.Itoa(len(batch.Payments)))
+	x.leaf("CtrlSum", batch.Total().String())
+	x.start("InitgPty")
+	x.leaf("Nm", truncateText(initiatingParty, paymententity.MaxCreditorNameLength))
+	x.end("InitgPty")
+	x.end("GrpHdr")
+
+	for i, payments := range groups {
+		group := paymententity.PaymentBatch{Payments: payments}
+		debtor := payments[0]
+		x.start("PmtInf")
+		x.leaf("PmtInfId", truncateText(fmt.Sprintf("%s-%d", batch.MessageID, i+1), paymententity.MaxIdentifierLength))
+		x.leaf("PmtMtd", "TRF")
+		x.leaf("NbOfTxs", strconv.Itoa(len(payments)))
+		x.leaf("CtrlSum", group.Total().String())
+		x.start("PmtTpInf")
+		x.start("SvcLvl")
+		x.leaf("Cd", "SEPA")
+		x.end("SvcLvl")
+		x.end("PmtTpInf")
+		x.leaf("ReqdExctnDt", batch.CreatedAt.UTC().Format(time.DateOnly))
+		x.start("Dbtr")
+		x.leaf("Nm", truncateText(debtor.DebtorName, paymententity.MaxCreditorNameLength))
+		x.end("Dbtr")
+		x.start("DbtrAcct")
+		x.start("Id")
+		x.leaf("IBAN", debtor.DebtorIban)
+		x.end("Id")
+		x.leaf("Ccy", paymententity.SepaCurrency)
+		x.end("DbtrAcct")
+		x.start("DbtrAgt")
+		x.start("FinInstnId")
+		if bic != "" {
+			x.leaf("BIC", bic)
+		} else {
+			x.start("Othr")
+			x.leaf("Id", "NOTPROVIDED")
+			x.end("Othr")
+		}
+		x.end("FinInstnId")
+		x.end("DbtrAgt")
+		x.leaf("ChrgBr", "SLEV")
+		for _, payment := range payments {
+			x.start("CdtTrfTxInf")
+			x.start("PmtId")
+			x.leaf("EndToEndId", payment.EndToEndID)
+			x.end("PmtId")
+			x.start("Amt")
+			x.leaf("InstdAmt", payment.Amount.String(), xml.Attr{Name: xml.Name{Local: "Ccy"}, Value: payment.Currency})
+			x.end("Amt")
+			x.start("Cdtr")
+			x.leaf("Nm", truncateText(payment.CreditorName, paymententity.MaxCreditorNameLength))
+			x.end("Cdtr")
+			x.start("CdtrAcct")
+			x.start("Id")
+			x.leaf("IBAN", payment.CreditorIban)
+			x.end("Id")
+			x.end("CdtrAcct")
+			if payment.RemittanceInformation.Valid {
+				x.start("RmtInf")
+				x.leaf("Ustrd", truncateText(payment.RemittanceInformation.String, paymententity.MaxRemittanceLength))
+				x.end("RmtInf")
+			}
+			x.end("CdtTrfTxInf")
+		}
+		x.end("PmtInf")
+	}
+	x.end("CstmrCdtTrfInitn")
+	x.end("Document")
+	return x.flush()
+}
+
+func truncateText(text string, length int) string {
+	runes := []rune(strings.TrimSpace(text))
+	if len(runes) > length {
+		runes = runes[:length]
+	}
+	return string(runes)
+}
+
+// FetchStatusReports reads the pain.002 reports of the inbox, oldest file name first. The files that are
+// no known message, or cannot be parsed, are moved to the failed directory and reported in the error, the
+// others are still returned.
+func (g *FileClearingGateway) FetchStatusReports(ctx context.Context) ([]paymententity.StatusReport, error) {
+	reports := []paymententity.StatusReport{}
+	err := g.readInbox(pain002Message, func(name string, data []byte) error {
+		report, err := ParsePain002(data)
+		if err != nil {
+			return err
+		}
+		report.Name = name
+		reports = append(reports, report)
+		return nil
+	})
+	return reports, err
+}
+
+// readInbox calls read with the inbox files of the given message (see inboxMessage), oldest file name first
+func (g *FileClearingGateway) readInbox(message string, read func(name string, data []byte) error) error {
+	paths, err := filepath.Glob(filepath.Join(g.InboxDir, "*.xml"))
+	if err != nil {
+		return err
+	}
+	failures := []error{}
+	for _, path := range paths {
+		name := filepath.Base(path)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			failures = append(failures, err)
+			continue
+		}
+		kind := inboxMessage(data)
+		if kind != "" && kind != message {
+			continue
+		}
+		if kind == "" {
+			err = errors.New("not a pain.002, pacs.008 nor camt.054 message")
+		} else {
+			err = read(name, data)
+		}
+		if err != nil {
+			failures = append(failures, fmt.Errorf("%s: %w", name, err))
+			if err := g.moveInboxFile(name, "failed"); err != nil {
+				failures = append(failures, err)
+			}
+		}
+	}
+	return errors.Join(failures...)
+}
+
+// Root elements of the messages read from the inbox
+const (
+	pain002Message = "CstmrPmtStsRpt"
+	pacs008Message = "FIToFICstmrCdtTrf"
+	camt054Message = "BkToCstmrDbtCdtNtfctn"
+)
+
+// inboxMessage returns the root element of the message inside the Document, when it is one of the known ones
+func inboxMessage(data []byte) string {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	depth := 0
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return ""
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			depth++
+			if depth == 2 {
+				switch start.Name.Local {
+				case pain002Message, pacs008Message, camt054Message:
+					return start.Name.Local
+				}
+				return ""
+			}
+		}
+	}
+}
+
+func (g *FileClearingGateway) AcknowledgeStatusReport(ctx context.Context, report paymententity.StatusReport) error {
+	return g.moveInboxFile(report.Name, "processed")
+}
+
+// FetchInboundFiles reads the pacs.008 and camt.054 files of the inbox, oldest file name first. As with the
+// status reports, the files that cannot be parsed are moved to the failed directory and reported in the error.
+func (g *FileClearingGateway) FetchInboundFiles(ctx context.Context) ([]paymententity.InboundFile, error) {
+	files := []paymententity.InboundFile{}
+	read := func(parse func(data []byte) (paymententity.InboundFile, error)) func(name string, data []byte) error {
+		return func(name string, data []byte) error {
+			file, err := parse(data)
+			if err != nil {
+				return err
+			}
+			file.Name = name
+			files = append(files, file)
+			return nil
+		}
+	}
+	err := errors.Join(
+		g.readInbox(pacs008Message, read(ParsePacs008)),
+		g.readInbox(camt054Message, read(ParseCamt054)),
+	)
+	slices.SortFunc(files, func(a, b paymententity.InboundFile) int { return strings.Compare(a.Name, b.Name) })
+	return files, err
+}
+
+func (g *FileClearingGateway) AcknowledgeInboundFile(ctx context.Context, file paymententity.InboundFile) error {
+	return g.moveInboxFile(file.Name, "processed")
+}
+
+func (g *FileClearingGateway) moveInboxFile(name string, dir string) error {
+	if err := os.MkdirAll(filepath.Join(g.InboxDir, dir), 0o755); err != nil {
+		return err
+	}
+	return os.Rename(filepath.Join(g.InboxDir, name), filepath.Join(g.InboxDir, dir, name))
+}
+
+// pain.002.001.03, only the elements used
+type pain002Document struct {
+	XMLName xml.Name `xml:"Document"`
+	Report  struct {
+		GroupHeader struct {
+			MessageID string `xml:"MsgId"`
+		} `xml:"GrpHdr"`
+		Original struct {
+			MessageID   string          `xml:"OrgnlMsgId"`
+			GroupStatus string          `xml:"GrpSts"`
+			Reasons     []pain002Reason `xml:"StsRsnInf"`
+		} `xml:"OrgnlGrpInfAndSts"`
+		PaymentInformation []struct {
+			Status       string          `xml:"PmtInfSts"`
+			Reasons      []pain002Reason `xml:"StsRsnInf"`
+			Transactions []struct {
+				EndToEndID string          `xml:"OrgnlEndToEndId"`
+				Status     string          `xml:"TxSts"`
+				Reasons    []pain002Reason `xml:"StsRsnInf"`
+			} `xml:"TxInfAndSts"`
+		} `xml:"OrgnlPmtInfAndSts"`
+	} `xml:"CstmrPmtStsRpt"`
+}
+
+type pain002Reason struct {
+	Code        string `xml:"Rsn>Cd"`
+	Proprietary string `xml:"Rsn>Prtry"`
+}
+
+func reasonCode(reasons []pain002Reason) string {
+	for _, reason := range reasons {
+		if reason.Code != "" {
+			return reason.Code
+		}
+		if reason.Proprietary != "" {
+			return reason.Proprietary
+		}
+	}
+	return ""
+}
+
+/**
+* ParsePain002 reads a pain.002 customer payment status report. A transaction without a status (TxSts)
+* takes the one of its payment information block (PmtInfSts), and without one either the one of the
+* group (GrpSts). The same goes for the rejection reason.
+ */
+func ParsePain002(data []byte) (paymententity.StatusReport, error) {
+	var document pain002Document
+	if err := xml.Unmarshal(data, &document); err != nil {
+		return paymententity.StatusReport{}, err
+	}
+	original := document.Report.Original
+	if original.MessageID == "" {
+		return paymententity.StatusReport{}, errors.New("not a pain.002 status report: OrgnlMsgId is missing")
+	}
+	report := paymententity.StatusReport{
+		MessageID:         document.Report.GroupHeader.MessageID,
+		OriginalMessageID: original.MessageID,
+		GroupStatus:       strings.TrimSpace(original.GroupStatus),
+		GroupReason:       reasonCode(original.Reasons),
+		Payments:          []paymententity.PaymentStatus{},
+	}
+	for _, information := range document.Report.PaymentInformation {
+		informationStatus := strings.TrimSpace(information.Status)
+		informationReason := reasonCode(information.Reasons)
+		if informationStatus == "" {
+			informationStatus, informationReason = report.GroupStatus, report.GroupReason
+		}
+		for _, transaction := range information.Transactions {
+			status := paymententity.PaymentStatus{
+				EndToEndID: strings.TrimSpace(transaction.EndToEndID),
+				Status:     strings.TrimSpace(transaction.Status),
+				Reason:     reasonCode(transaction.Reasons),
+			}
+			if status.EndToEndID == "" {
+				return paymententity.StatusReport{}, errors.New("transaction status without OrgnlEndToEndId")
+			}
+			if status.Status == "" {
+				status.Status = informationStatus
+			}
+			if status.Reason == "" && status.IsRejected() {
+				status.Reason = informationReason
+			}
+			report.Payments = append(report.Payments, status)
+		}
+	}
+	return report, nil
+}
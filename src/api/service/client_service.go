@@ -17,15 +17,18 @@ type ClientService interface {
 type clientService struct {
 	ClientRepository             repositories.ClientRepository
 	RegistryAccountOtpRepository repositories.RegistryAccountOtpRepository
+	BranchRepository             repositories.BranchRepository
 }
 
 func NewClientService(
 	clientRepository repositories.ClientRepository,
 	registryAccountOtpRepository repositories.RegistryAccountOtpRepository,
+	branchRepository repositories.BranchRepository,
 ) ClientService {
 	return &clientService{
 		ClientRepository:             clientRepository,
 		RegistryAccountOtpRepository: registryAccountOtpRepository,
+		BranchRepository:             branchRepository,
 	}
 }
 
@@ -35,6 +38,14 @@ func (s *clientService) CreateClient(req clientdto.CreateClientRequest) (clientd
 		return clientdto.ClientResponse{}, &app_errors.ErrBadRequest{Reason: err}
 	}
 	context := context.Background()
+	if clientEntity.BranchCode.Valid {
+		if _, appError := s.BranchRepository.FetchBranch(context, clientEntity.BranchCode.String); appError != nil {
+			if _, ok := appError.(*app_errors.ErrNotFound); ok {
+				return clientdto.ClientResponse{}, &app_errors.ErrBadRequest{Message: "branch_code " + clientEntity.BranchCode.String + " does not exist"}
+			}
+			return clientdto.ClientResponse{}, appError
+		}
+	}
 	tx, txError := s.ClientRepository.GetTx()
 
 	if txError != nil {
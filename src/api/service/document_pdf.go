@@ -0,0 +1,143 @@
+package services
+
+import (
+	accountentity "src/domain/account"
+	cliententity "src/domain/client"
+	documententity "src/domain/document"
+	ledgerentity "src/domain/ledger"
+	statemententity "src/domain/statement"
+	"src/utils/pdf"
+	"strings"
+	"time"
+)
+
+const (
+	pdfTitleSize = 16.0
+	pdfTextSize  = 10.0
+	pdfTableSize = 8.5
+	pdfDate      = "02/01/2006"
+	pdfDateTime  = "02/01/2006 15:04 MST"
+)
+
+// Columns of the statement table
+var pdfStatementColumns = []pdf.Cell{
+	{X: 0, Width: 60, Text: "Date"},
+	{X: 62, Width: 78, Text: "Type"},
+	{X: 142, Width: 178, Text: "Counterparty"},
+	{X: 322, Width: 80, Text: "Amount", AlignRight: true},
+	{X: 407, Width: 88, Text: "Balance", AlignRight: true},
+}
+
+// newDocumentPdf starts a document of the bank with the verification code in the footer of every page
+func newDocumentPdf(title string, issuer string, code string) *pdf.Document {
+	document := pdf.New(title)
+	document.Author = issuer
+	document.Footer = issuer + " - verification code " + code + ", check it at /documents/verify/" + code
+	document.Text(pdfTitleSize, true, title)
+	document.Text(pdfTextSize, false, issuer)
+	document.Space(pdfTextSize)
+	return document
+}
+
+// pdfStatementWriter lays out a statement in a PDF document. Unlike the other statement writers it
+// writes nothing until the document is complete, the PDF cross reference table needs every page.
+type pdfStatementWriter struct {
+	document  *pdf.Document
+	statement statemententity.StatementEntity
+}
+
+func newPdfStatementWriter(document *pdf.Document) *pdfStatementWriter {
+	return &pdfStatementWriter{document: document}
+}
+
+func (s *pdfStatementWriter) WriteHeader(statement statemententity.StatementEntity) error {
+	s.statement = statement
+	d := s.document
+	d.Text(pdfTextSize, false, "Holder: "+statement.OwnerName)
+	d.Text(pdfTextSize, false, "IBAN: "+documententity.FormatIban(statement.Iban))
+	d.Text(pdfTextSize, false, "Currency: "+statement.Currency)
+	d.Text(pdfTextSize, false, "Period: "+statement.From.Format(pdfDate)+" - "+statement.To.Add(-time.Nanosecond).Format(pdfDate))
+	d.Space(pdfTextSize)
+	d.Row(pdfTextSize, pdf.Cell{Text: "Opening balance", Bold: true}, s.balanceCell(statement.OpeningBalance.String()))
+	d.Space(pdfTextSize / 2)
+	s.tableHeader(d)
+	d.OnNewPage = s.tableHeader
+	return nil
+}
+
+func (s *pdfStatementWriter) tableHeader(d *pdf.Document) {
+	cells := make([]pdf.Cell, len(pdfStatementColumns))
+	for i, column := range pdfStatementColumns {
+		column.Bold = true
+		cells[i] = column
+	}
+	d.Row(pdfTableSize, cells...)
+	d.Rule()
+}
+
+func (s *pdfStatementWriter) balanceCell(value string) pdf.Cell {
+	column := pdfStatementColumns[len(pdfStatementColumns)-1]
+	column.Text = value + " " + s.statement.Currency
+	column.Bold = true
+	return column
+}
+
+func (s *pdfStatementWriter) WriteEntry(entry ledgerentity.ActivityEntryEntity) error {
+	counterparty := entry.CounterpartyName.String
+	if entry.CounterpartyIban.Valid {
+		counterparty = strings.TrimSpace(counterparty + " " + entry.CounterpartyIban.String)
+	}
+	values := []string{
+		entry.CreatedAt.UTC().Format(pdfDate),
+		entryDescription(entry),
+		counterparty,
+		signedAmount(entry).String(),
+		entry.BalanceAfter.String(),
+	}
+	cells := make([]pdf.Cell, len(pdfStatementColumns))
+	for i, column := range pdfStatementColumns {
+		column.Text = values[i]
+		cells[i] = column
+	}
+	s.document.Row(pdfTableSize, cells...)
+	return nil
+}
+
+func (s *pdfStatementWriter) Close() error {
+	s.document.OnNewPage = nil
+	s.document.Rule()
+	s.document.Row(pdfTextSize, pdf.Cell{Text: "Closing balance", Bold: true}, s.balanceCell(s.statement.ClosingBalance.String()))
+	return nil
+}
+
+// renderOwnershipCertificate lays out the certificate of account ownership
+func renderOwnershipCertificate(
+	document *pdf.Document,
+	issuer string,
+	client cliententity.ClientEntity,
+	account accountentity.AccountEntity,
+	issuedAt time.Time,
+) {
+	holder := clientFullName(client)
+	document.Text(pdfTextSize, false, issuer+" certifies that "+holder+", with identification document "+client.Identification+
+		", is the holder of the account below, according to the records of the bank on "+issuedAt.Format(pdfDateTime)+".")
+	document.Space(pdfTextSize)
+	for _, line := range [][2]string{
+		{"Holder", holder},
+		{"Identification", client.Identification},
+		{"IBAN", documententity.FormatIban(account.AccountNumber)},
+		{"Currency", account.Currency},
+		{"Product", account.Product},
+		{"Status", account.Status},
+		{"Opened on", account.CreatedAt.UTC().Format(pdfDate)},
+	} {
+		document.Row(pdfTextSize, pdf.Cell{Text: line[0], Bold: true}, pdf.Cell{X: 110, Text: line[1]})
+	}
+	document.Space(pdfTextSize)
+	document.Text(pdfTextSize, false, "This certificate is issued at the request of the holder, for the purposes they deem appropriate.")
+}
+
+// clientFullName is the name followed by the surnames
+func clientFullName(client cliententity.ClientEntity) string {
+	return strings.TrimSpace(client.Name + " " + client.Surname1 + " " + client.Surname2.String)
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"os"
+	accountentity "src/domain/account"
+	documententity "src/domain/document"
+	statemententity "src/domain/statement"
+	app_errors "src/errors"
+	"src/repositories"
+	"src/utils/pdf"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type DocumentService interface {
+	IssueStatement(ctx context.Context, accountID int, month time.Time) ([]byte, documententity.DocumentEntity, app_errors.AppError)
+	IssueOwnershipCertificate(ctx context.Context, accountID int) ([]byte, documententity.DocumentEntity, app_errors.AppError)
+}
+
+type documentService struct {
+	AccountRepository  repositories.AccountRepository
+	ClientRepository   repositories.ClientRepository
+	DocumentRepository repositories.DocumentRepository
+	StatementService   StatementService
+	Issuer             string
+	Logger             *zap.Logger
+}
+
+// The documents are issued in the name of BANK_NAME
+func NewDocumentService(wrapper repositories.RepositoryWrapper, statementService StatementService, logger *zap.Logger) DocumentService {
+	issuer := os.Getenv("BANK_NAME")
+	if issuer == "" {
+		issuer = documententity.DefaultIssuer
+	}
+	return &documentService{
+		AccountRepository:  wrapper.AccountRepository,
+		ClientRepository:   wrapper.ClientRepository,
+		DocumentRepository: wrapper.DocumentRepository,
+		StatementService:   statementService,
+		Issuer:             issuer,
+		Logger:             logger,
+	}
+}
+
+// IssueStatement renders the PDF statement of the calendar month of the account, up to now for the
+// current month, and records it with a new verification code
+func (s *documentService) IssueStatement(ctx context.Context, accountID int, month time.Time) ([]byte, documententity.DocumentEntity, app_errors.AppError) {
+	from := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	now := time.Now().UTC()
+	if !from.Before(now) {
+		return nil, documententity.DocumentEntity{}, &app_errors.ErrBadRequest{Message: "the month has not started yet"}
+	}
+	if to.After(now) {
+		to = now
+	}
+	code, err := documententity.NewVerificationCode()
+	if err != nil {
+		return nil, documententity.DocumentEntity{}, &app_errors.ErrInternalServer{Reason: err}
+	}
+
+	document := documententity.DocumentEntity{
+		VerificationCode: code,
+		Type:             documententity.TypeStatement,
+		AccountID:        accountID,
+		PeriodFrom:       sql.NullTime{Time: from, Valid: true},
+		PeriodTo:         sql.NullTime{Time: to.Add(-time.Nanosecond), Valid: true},
+	}
+	statementPdf := newDocumentPdf("Account statement "+from.Format("January 2006"), s.Issuer, code)
+	appErr := s.StatementService.WriteStatement(ctx, accountID, from, to, func(statement statemententity.StatementEntity) (StatementWriter, error) {
+		document.Iban = statement.Iban
+		document.HolderName = statement.OwnerName
+		return newPdfStatementWriter(statementPdf), nil
+	})
+	if appErr != nil {
+		return nil, documententity.DocumentEntity{}, appErr
+	}
+	return s.record(ctx, statementPdf, document)
+}
+
+// IssueOwnershipCertificate renders the certificate of account ownership of a client account that is not
+// closed, and records it with a new verification code
+func (s *documentService) IssueOwnershipCertificate(ctx context.Context, accountID int) ([]byte, documententity.DocumentEntity, app_errors.AppError) {
+	account, appErr := s.AccountRepository.FetchAccountById(ctx, accountID)
+	if appErr != nil {
+		return nil, documententity.DocumentEntity{}, appErr
+	}
+	if account.Status == accountentity.StatusClosed {
+		return nil, documententity.DocumentEntity{}, &app_errors.ErrConflict{Message: "the account is closed"}
+	}
+	client, appErr := s.ClientRepository.FetchClientById(ctx, account.ClientID)
+	if appErr != nil {
+		return nil, documententity.DocumentEntity{}, appErr
+	}
+	code, err := documententity.NewVerificationCode()
+	if err != nil {
+		return nil, documententity.DocumentEntity{}, &app_errors.ErrInternalServer{Reason: err}
+	}
+
+	certificatePdf := newDocumentPdf("Certificate of account ownership", s.Issuer, code)
+	renderOwnershipCertificate(certificatePdf, s.Issuer, client, account, certificatePdf.CreatedAt)
+	return s.record(ctx, certificatePdf, documententity.DocumentEntity{
+		VerificationCode: code,
+		Type:             documententity.TypeOwnershipCertificate,
+		AccountID:        account.ID,
+		Iban:             account.AccountNumber,
+		HolderName:       clientFullName(client),
+	})
+}
+
+// record stores the digest of the rendered document, so a copy can be told apart from a forged one
+func (s *documentService) record(ctx context.Context, rendered *pdf.Document, document documententity.DocumentEntity) ([]byte, documententity.DocumentEntity, app_errors.AppError) {
+	content := rendered.Bytes()
+	digest := sha256.Sum256(content)
+	document.Sha256 = hex.EncodeToString(digest[:])
+	if appErr := s.DocumentRepository.InsertDocument(ctx, &document); appErr != nil {
+		return nil, documententity.DocumentEntity{}, appErr
+	}
+	return content, document, nil
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	currencyentity "src/domain/currency"
+	app_errors "src/errors"
+	"src/repositories"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+type ExchangeRateService interface {
+	FetchExchangeRates(ctx context.Context) ([]currencyentity.ExchangeRateEntity, app_errors.AppError)
+	UpdateExchangeRates(ctx context.Context, rates []currencyentity.ExchangeRateEntity) app_errors.AppError
+	LoadExchangeRatesFile(ctx context.Context, path string) (int, app_errors.AppError)
+}
+
+type exchangeRateService struct {
+	CurrencyRepository repositories.CurrencyRepository
+	Logger             *zap.Logger
+}
+
+func NewExchangeRateService(currencyRepository repositories.CurrencyRepository, logger *zap.Logger) ExchangeRateService {
+	return &exchangeRateService{
+		CurrencyRepository: currencyRepository,
+		Logger:             logger,
+	}
+}
+
+func (s *exchangeRateService) FetchExchangeRates(ctx context.Context) ([]currencyentity.ExchangeRateEntity, app_errors.AppError) {
+	return s.CurrencyRepository.FetchExchangeRates(ctx)
+}
+
+// UpdateExchangeRates normalizes and stores the rates. Pairs not included keep their rate.
+func (s *exchangeRateService) UpdateExchangeRates(ctx context.Context, rates []currencyentity.ExchangeRateEntity) app_errors.AppError {
+	if len(rates) == 0 {
+		return &app_errors.ErrBadRequest{Message: "no exchange rates"}
+	}
+	for i := range rates {
+		var err error
+		if rates[i].Base, err = currencyentity.NormalizeCode(rates[i].Base); err != nil {
+			return &app_errors.ErrBadRequest{Message: err.Error()}
+		}
+		if rates[i].Quote, err = currencyentity.NormalizeCode(rates[i].Quote); err != nil {
+			return &app_errors.ErrBadRequest{Message: err.Error()}
+		}
+		rates[i].Rate = strings.TrimSpace(rates[i].Rate)
+	}
+	return s.CurrencyRepository.UpsertExchangeRates(ctx, rates)
+}
+
+// LoadExchangeRatesFile stores the rates of a CSV file (see ParseExchangeRatesCSV) and returns how many were loaded
+func (s *exchangeRateService) LoadExchangeRatesFile(ctx context.Context, path string) (int, app_errors.AppError) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, &app_errors.ErrInternalServer{Reason: err}
+	}
+	defer file.Close()
+	rates, err := ParseExchangeRatesCSV(file)
+	if err != nil {
+		return 0, &app_errors.ErrBadRequest{Message: fmt.Sprintf("%s: %s", path, err.Error())}
+	}
+	if appErr := s.UpdateExchangeRates(ctx, rates); appErr != nil {
+		return 0, appErr
+	}
+	s.Logger.Info(fmt.Sprintf("Loaded %d exchange rates from %s", len(rates), path))
+	return len(rates), nil
+}
+
+// ParseExchangeRatesCSV reads one rate per line:
+//
+//	base,quote,rate
+//	EUR,USD,1.0850
+//
+// The header line is optional. Lines starting with # are ignored.
+func ParseExchangeRatesCSV(r io.Reader) ([]currencyentity.ExchangeRateEntity, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = 3
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	rates := make([]currencyentity.ExchangeRateEntity, 0, len(records))
+	for i, record := range records {
+		if i == 0 && strings.EqualFold(record[0], "base") {
+			continue
+		}
+		rates = append(rates, currencyentity.ExchangeRateEntity{Base: record[0], Quote: record[1], Rate: record[2]})
+	}
+	return rates, nil
+}
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	appRedis "src/db/redis"
+	idempotency_entity "src/domain/idempotency"
+	app_errors "src/errors"
+	"src/repositories"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+const IdempotencyKeyHeader string = "Idempotency-Key"
+
+const defaultIdempotencyKeyTTL = 24 * time.Hour
+
+// Storing the response of a key is retried a few times, waiting a bit longer after every attempt
+const (
+	completeAttempts   = 3
+	completeRetryDelay = 100 * time.Millisecond
+)
+
+// IdempotentResponse is the response stored for an already processed idempotency key
+type IdempotentResponse struct {
+	Status int
+	Body   string
+}
+
+type IdempotencyService interface {
+	Begin(ctx context.Context, clientId int, key string, request any) (idempotency_entity.IdempotencyKeyEntity, *IdempotentResponse, app_errors.AppError)
+	Complete(ctx context.Context, entity idempotency_entity.IdempotencyKeyEntity, status int, body []byte) app_errors.AppError
+	Release(ctx context.Context, entity idempotency_entity.IdempotencyKeyEntity) app_errors.AppError
+}
+
+type idempotencyService struct {
+	IdempotencyRepository repositories.IdempotencyRepository
+	RedisClient           *redis.Client
+	TTL                   time.Duration
+	Logger                *zap.Logger
+}
+
+// The redis client is optional, it is only used as a fast path for completed keys
+func NewIdempotencyService(
+	idempotencyRepository repositories.IdempotencyRepository,
+	redisClient *redis.Client,
+	ttl time.Duration,
+	logger *zap.Logger,
+) IdempotencyService {
+	return &idempotencyService{
+		IdempotencyRepository: idempotencyRepository,
+		RedisClient:           redisClient,
+		TTL:                   ttl,
+		Logger:                logger,
+	}
+}
+
+// IdempotencyKeyTTLFromEnv reads IDEMPOTENCY_KEY_TTL (i.e. 24h, 30m). Defaults to 24 hours.
+// The method is supposed to be used after the .env is loaded
+func IdempotencyKeyTTLFromEnv() time.Duration {
+	ttl, err := time.ParseDuration(os.Getenv("IDEMPOTENCY_KEY_TTL"))
+	if err != nil || ttl <= 0 {
+		return defaultIdempotencyKeyTTL
+	}
+	return ttl
+}
+
+// RequestFingerprint is the sha256 of the JSON representation of the request
+func RequestFingerprint(request any) (string, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(body)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// Begin reserves the idempotency key for the request.
+//
+//   - The key is new: the key is reserved and no response is returned.
+//   - The key was used with the same request: the stored response is returned.
+//   - The key was used with another request or it is still in progress: ErrConflict.
+func (s *idempotencyService) Begin(
+	ctx context.Context,
+	clientId int,
+	key string,
+	request any,
+) (idempotency_entity.IdempotencyKeyEntity, *IdempotentResponse, app_errors.AppError) {
+	requestHash, err := RequestFingerprint(request)
+	if err != nil {
+		return idempotency_entity.IdempotencyKeyEntity{}, nil, &app_errors.ErrBadRequest{Reason: err}
+	}
+	// 1. Fast path: completed keys are cached in redis
+	if s.RedisClient != nil {
+		record, err := appRedis.GetIdempotencyRecord(ctx, s.RedisClient, clientId, key)
+		if err != nil {
+			s.Logger.Warn(err.Error())
+		}
+		if record != nil {
+			if record.RequestHash != requestHash {
+				return idempotency_entity.IdempotencyKeyEntity{}, nil, &app_errors.ErrConflict{Message: "idempotency key already used with a different request"}
+			}
+			return idempotency_entity.IdempotencyKeyEntity{}, &IdempotentResponse{Status: record.ResponseStatus, Body: record.ResponseBody}, nil
+		}
+	}
+	// 2. Reserve the key in the database
+	entity := idempotency_entity.IdempotencyKeyEntity{
+		ClientID:       clientId,
+		IdempotencyKey: key,
+		RequestHash:    requestHash,
+		ExpiresAt:      time.Now().Add(s.TTL),
+	}
+	reserved, appErr := s.IdempotencyRepository.Reserve(ctx, &entity)
+	if appErr != nil {
+		return idempotency_entity.IdempotencyKeyEntity{}, nil, appErr
+	}
+	if reserved {
+		return entity, nil, nil
+	}
+	// 3. The key already exists
+	stored, appErr := s.IdempotencyRepository.FetchByKey(ctx, clientId, key)
+	if appErr != nil {
+		return idempotency_entity.IdempotencyKeyEntity{}, nil, appErr
+	}
+	if stored.RequestHash != requestHash {
+		return idempotency_entity.IdempotencyKeyEntity{}, nil, &app_errors.ErrConflict{Message: "idempotency key already used with a different request"}
+	}
+	if !stored.IsCompleted() {
+		return idempotency_entity.IdempotencyKeyEntity{}, nil, &app_errors.ErrConflict{Message: "a request with this idempotency key is still in progress"}
+	}
+	return stored, &IdempotentResponse{Status: int(stored.ResponseStatus.Int32), Body: stored.ResponseBody.String}, nil
+}
+
+// Complete stores the response of the request so that replays get the same one.
+// The request has already been performed, so the response is stored even when the client is gone
+// and failed attempts are retried: otherwise the key would stay in progress until it expires.
+func (s *idempotencyService) Complete(ctx context.Context, entity idempotency_entity.IdempotencyKeyEntity, status int, body []byte) app_errors.AppError {
+	ctx = context.WithoutCancel(ctx)
+	var appErr app_errors.AppError
+	for attempt := 1; attempt <= completeAttempts; attempt++ {
+		if appErr = s.IdempotencyRepository.Complete(ctx, entity.ID, status, string(body)); appErr == nil {
+			break
+		}
+		s.Logger.Warn(fmt.Sprintf("Idempotency key %s could not be completed (attempt %d of %d)", entity.IdempotencyKey, attempt, completeAttempts))
+		if attempt < completeAttempts {
+			time.Sleep(time.Duration(attempt) * completeRetryDelay)
+		}
+	}
+	if appErr != nil {
+		return appErr
+	}
+	if s.RedisClient != nil {
+		record := appRedis.IdempotencyRecord{
+			RequestHash:    entity.RequestHash,
+			ResponseStatus: status,
+			ResponseBody:   string(body),
+		}
+		err := appRedis.SetIdempotencyRecord(ctx, s.RedisClient, entity.ClientID, entity.IdempotencyKey, record, time.Until(entity.ExpiresAt))
+		if err != nil {
+			s.Logger.Warn(fmt.Sprintf("Idempotency key %s could not be cached: %s", entity.IdempotencyKey, err.Error()))
+		}
+	}
+	return nil
+}
+
+// Release frees a reserved key when the request did not succeed, so the client can retry it
+func (s *idempotencyService) Release(ctx context.Context, entity idempotency_entity.IdempotencyKeyEntity) app_errors.AppError {
+	return s.IdempotencyRepository.Release(ctx, entity.ID)
+}
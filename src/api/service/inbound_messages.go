@@ -0,0 +1,265 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"src/domain/money"
+	paymententity "src/domain/payment"
+	"src/utils"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Length of the debtor and creditor names of the inbound payments
+const maxPartyNameLength = 140
+
+// End to end id of the credits sent without one
+const notProvided = "NOTPROVIDED"
+
+type isoAmount struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    string `xml:",chardata"`
+}
+
+// pacs.008.001.02, only the elements used
+type pacs008Document struct {
+	Transfer struct {
+		MessageID    string `xml:"GrpHdr>MsgId"`
+		Transactions []struct {
+			EndToEndID    string    `xml:"PmtId>EndToEndId"`
+			TransactionID string    `xml:"PmtId>TxId"`
+			Amount        isoAmount `xml:"IntrBkSttlmAmt"`
+			DebtorName    string    `xml:"Dbtr>Nm"`
+			DebtorIban    string    `xml:"DbtrAcct>Id>IBAN"`
+			CreditorName  string    `xml:"Cdtr>Nm"`
+			CreditorIban  string    `xml:"CdtrAcct>Id>IBAN"`
+			Unstructured  []string  `xml:"RmtInf>Ustrd"`
+		} `xml:"CdtTrfTxInf"`
+	} `xml:"FIToFICstmrCdtTrf"`
+}
+
+// camt.054.001.02, only the elements used. The amount of a transaction is Amt in the later versions
+type camt054Document struct {
+	Notification struct {
+		MessageID     string `xml:"GrpHdr>MsgId"`
+		Notifications []struct {
+			AccountIban string `xml:"Acct>Id>IBAN"`
+			Entries     []struct {
+				Amount       isoAmount `xml:"Amt"`
+				Indicator    string    `xml:"CdtDbtInd"`
+				Reference    string    `xml:"AcctSvcrRef"`
+				Transactions []struct {
+					EndToEndID    string    `xml:"Refs>EndToEndId"`
+					Reference     string    `xml:"Refs>AcctSvcrRef"`
+					TransactionID string    `xml:"Refs>TxId"`
+					Amount        isoAmount `xml:"Amt"`
+					TxAmount      isoAmount `xml:"AmtDtls>TxAmt>Amt"`
+					DebtorName    string    `xml:"RltdPties>Dbtr>Nm"`
+					DebtorIban    string    `xml:"RltdPties>DbtrAcct>Id>IBAN"`
+					CreditorName  string    `xml:"RltdPties>Cdtr>Nm"`
+					CreditorIban  string    `xml:"RltdPties>CdtrAcct>Id>IBAN"`
+					Unstructured  []string  `xml:"RmtInf>Ustrd"`
+				} `xml:"NtryDtls>TxDtls"`
+			} `xml:"Ntry"`
+		} `xml:"Ntfctn"`
+	} `xml:"BkToCstmrDbtCdtNtfctn"`
+}
+
+func optionalText(text string, length int) sql.NullString {
+	text = truncateText(text, length)
+	return sql.NullString{String: text, Valid: text != ""}
+}
+
+// inboundPayment validates a credit read from a file and fills the fields shared by both messages
+func inboundPayment(file paymententity.InboundFile, amount isoAmount, endToEndID string, reference string,
+	debtorName string, debtorIban string, creditorName string, creditorIban string, remittance []string,
+) (paymententity.InboundPaymentEntity, error) {
+	sequence := len(file.Payments) + 1
+	value, err := money.Parse(strings.TrimSpace(amount.Value))
+	if err != nil || !value.IsPositive() {
+		return paymententity.InboundPaymentEntity{}, fmt.Errorf("credit %d: invalid amount %q", sequence, amount.Value)
+	}
+	if amount.Currency != paymententity.SepaCurrency {
+		return paymententity.InboundPaymentEntity{}, fmt.Errorf("credit %d: SEPA credits are in euros, not %q", sequence, amount.Currency)
+	}
+	creditorIban = utils.NormalizeIban(creditorIban)
+	if creditorIban == "" {
+		return paymententity.InboundPaymentEntity{}, fmt.Errorf("credit %d: the creditor IBAN is missing", sequence)
+	}
+	endToEndID = truncateText(endToEndID, paymententity.MaxIdentifierLength)
+	if endToEndID == "" {
+		endToEndID = notProvided
+	}
+	return paymententity.InboundPaymentEntity{
+		MessageID:             file.MessageID,
+		MessageType:           file.MessageType,
+		Sequence:              sequence,
+		EndToEndID:            endToEndID,
+		Reference:             optionalText(reference, paymententity.MaxIdentifierLength),
+		DebtorIban:            optionalText(utils.NormalizeIban(debtorIban), 34),
+		DebtorName:            optionalText(debtorName, maxPartyNameLength),
+		CreditorIban:          creditorIban,
+		CreditorName:          optionalText(creditorName, maxPartyNameLength),
+		Amount:                value,
+		Currency:              amount.Currency,
+		RemittanceInformation: optionalText(strings.Join(remittance, " "), paymententity.MaxRemittanceLength),
+	}, nil
+}
+
+// ParsePacs008 reads the credit transfers of a pacs.008 FI to FI customer credit transfer, one per CdtTrfTxInf
+func ParsePacs008(data []byte) (paymententity.InboundFile, error) {
+	var document pacs008Document
+	if err := xml.Unmarshal(data, &document); err != nil {
+		return paymententity.InboundFile{}, err
+	}
+	file := paymententity.InboundFile{
+		MessageID:   truncateText(document.Transfer.MessageID, paymententity.MaxIdentifierLength),
+		MessageType: paymententity.MessagePacs008,
+		Payments:    []paymententity.InboundPaymentEntity{},
+	}
+	if file.MessageID == "" {
+		return paymententity.InboundFile{}, errors.New("not a pacs.008 message: MsgId is missing")
+	}
+	for _, transaction := range document.Transfer.Transactions {
+		payment, err := inboundPayment(file, transaction.Amount, transaction.EndToEndID, transaction.TransactionID,
+			transaction.DebtorName, transaction.DebtorIban, transaction.CreditorName, transaction.CreditorIban, transaction.Unstructured)
+		if err != nil {
+			return paymententity.InboundFile{}, err
+		}
+		file.Payments = append(file.Payments, payment)
+	}
+	return file, nil
+}
+
+/**
+* ParseCamt054 reads the credits (CRDT entries) of a camt.054 notification, one per transaction detail (TxDtls).
+* An entry without details is a single credit. The creditor is the account of the notification unless the
+* transaction names another one, and a single transaction without an amount of its own takes the one of the
+* entry. Debit entries are ignored.
+ */
+func ParseCamt054(data []byte) (paymententity.InboundFile, error) {
+	var document camt054Document
+	if err := xml.Unmarshal(data, &document); err != nil {
+		return paymententity.InboundFile{}, err
+	}
+	file := paymententity.InboundFile{
+		MessageID:   truncateText(document.Notification.MessageID, paymententity.MaxIdentifierLength),
+		MessageType: paymententity.MessageCamt054,
+		Payments:    []paymententity.InboundPaymentEntity{},
+	}
+	if file.MessageID == "" {
+		return paymententity.InboundFile{}, errors.New("not a camt.054 message: MsgId is missing")
+	}
+	for _, notification := range document.Notification.Notifications {
+		for _, entry := range notification.Entries {
+			if strings.TrimSpace(entry.Indicator) != "CRDT" {
+				continue
+			}
+			if len(entry.Transactions) == 0 {
+				payment, err := inboundPayment(file, entry.Amount, "", entry.Reference, "", "", "", notification.AccountIban, nil)
+				if err != nil {
+					return paymententity.InboundFile{}, err
+				}
+				file.Payments = append(file.Payments, payment)
+				continue
+			}
+			for _, transaction := range entry.Transactions {
+				amount := transaction.Amount
+				if amount.Value == "" {
+					amount = transaction.TxAmount
+				}
+				if amount.Value == "" && len(entry.Transactions) == 1 {
+					amount = entry.Amount
+				}
+				creditorIban := transaction.CreditorIban
+				if creditorIban == "" {
+					creditorIban = notification.AccountIban
+				}
+				reference := transaction.Reference
+				if reference == "" {
+					reference = transaction.TransactionID
+				}
+				payment, err := inboundPayment(file, amount, transaction.EndToEndID, reference,
+					transaction.DebtorName, transaction.DebtorIban, transaction.CreditorName, creditorIban, transaction.Unstructured)
+				if err != nil {
+					return paymententity.InboundFile{}, err
+				}
+				file.Payments = append(file.Payments, payment)
+			}
+		}
+	}
+	return file, nil
+}
+
+const pacs004Namespace = "urn:iso:std:iso:20022:tech:xsd:pacs.004.001.02"
+
+// WritePacs004 writes the batch as an ISO 20022 pacs.004.001.02 payment return, one TxInf per returned
+// payment with its original message, end to end id and the reason of the return
+func WritePacs004(w io.Writer, batch paymententity.ReturnBatch, bic string) error {
+	x := newXmlStream(w)
+	x.token(xml.ProcInst{Target: "xml", Inst: []byte(`version="1.0" encoding="UTF-8"`)})
+	x.start("Document", xml.Attr{Name: xml.Name{Local: "xmlns"}, Value: pacs004Namespace})
+	x.start("PmtRtr")
+	x.start("GrpHdr")
+	x.leaf("MsgId", batch.MessageID)
+	x.leaf("CreDtTm", camtDateTime(batch.CreatedAt))
+	x.leaf("NbOfTxs", strconv.Itoa(len(batch.Payments)))
+	x.leaf("TtlRtrdIntrBkSttlmAmt", batch.Total().String(), xml.Attr{Name: xml.Name{Local: "Ccy"}, Value: paymententity.SepaCurrency})
+	x.leaf("IntrBkSttlmDt", batch.CreatedAt.UTC().Format(time.DateOnly))
+	x.start("SttlmInf")
+	x.leaf("SttlmMtd", "CLRG")
+	x.end("SttlmInf")
+	if bic != "" {
+		x.start("InstgAgt")
+		x.start("FinInstnId")
+		x.leaf("BIC", bic)
+		x.end("FinInstnId")
+		x.end("InstgAgt")
+	}
+	x.end("GrpHdr")
+	for _, payment := range batch.Payments {
+		x.start("TxInf")
+		x.leaf("RtrId", truncateText(fmt.Sprintf("%s-%d", batch.MessageID, payment.ID), paymententity.MaxIdentifierLength))
+		x.start("OrgnlGrpInf")
+		x.leaf("OrgnlMsgId", payment.MessageID)
+		x.leaf("OrgnlMsgNmId", payment.MessageType)
+		x.end("OrgnlGrpInf")
+		x.leaf("OrgnlEndToEndId", payment.EndToEndID)
+		if payment.Reference.Valid {
+			x.leaf("OrgnlTxId", payment.Reference.String)
+		}
+		x.leaf("RtrdIntrBkSttlmAmt", payment.Amount.String(), xml.Attr{Name: xml.Name{Local: "Ccy"}, Value: payment.Currency})
+		x.start("RtrRsnInf")
+		x.start("Rsn")
+		x.leaf("Cd", payment.ReturnReason.String)
+		x.end("Rsn")
+		x.end("RtrRsnInf")
+		x.start("OrgnlTxRef")
+		if payment.DebtorName.Valid {
+			x.start("Dbtr")
+			x.leaf("Nm", truncateText(payment.DebtorName.String, paymententity.MaxCreditorNameLength))
+			x.end("Dbtr")
+		}
+		if payment.DebtorIban.Valid {
+			x.start("DbtrAcct")
+			x.start("Id")
+			x.leaf("IBAN", payment.DebtorIban.String)
+			x.end("Id")
+			x.end("DbtrAcct")
+		}
+		x.start("CdtrAcct")
+		x.start("Id")
+		x.leaf("IBAN", payment.CreditorIban)
+		x.end("Id")
+		x.end("CdtrAcct")
+		x.end("OrgnlTxRef")
+		x.end("TxInf")
+	}
+	x.end("PmtRtr")
+	x.end("Document")
+	return x.flush()
+}
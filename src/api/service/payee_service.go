@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"os"
+	dto "src/api/dto"
+	payeeentity "src/domain/payee"
+	app_errors "src/errors"
+	"src/repositories"
+	"src/utils"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type PayeeService interface {
+	VerifyPayee(ctx context.Context, clientID int, accountNumber string, name string) (dto.PayeeVerificationDto, app_errors.AppError)
+}
+
+type payeeService struct {
+	RepositoryWrapper repositories.RepositoryWrapper
+	Limits            []payeeentity.RateLimit
+	Logger            *zap.Logger
+}
+
+func NewPayeeService(wrapper repositories.RepositoryWrapper, limits []payeeentity.RateLimit, logger *zap.Logger) PayeeService {
+	return &payeeService{
+		RepositoryWrapper: wrapper,
+		Limits:            limits,
+		Logger:            logger,
+	}
+}
+
+// PayeeVerificationLimitsFromEnv reads PAYEE_VERIFICATIONS_PER_HOUR and PAYEE_VERIFICATIONS_PER_DAY.
+// The method is supposed to be used after the .env is loaded
+func PayeeVerificationLimitsFromEnv() []payeeentity.RateLimit {
+	return []payeeentity.RateLimit{
+		{Max: positiveIntFromEnv("PAYEE_VERIFICATIONS_PER_HOUR", payeeentity.DefaultVerificationsPerHour), Window: time.Hour},
+		{Max: positiveIntFromEnv("PAYEE_VERIFICATIONS_PER_DAY", payeeentity.DefaultVerificationsPerDay), Window: 24 * time.Hour},
+	}
+}
+
+func positiveIntFromEnv(name string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(name))
+	if err != nil || value <= 0 {
+		return defaultValue
+	}
+	return value
+}
+
+// VerifyPayee compares the name with the holder of the account. Every check counts towards the limits of the
+// client, whatever its result, and is refused once they are reached. An invalid IBAN is not counted.
+func (s *payeeService) VerifyPayee(ctx context.Context, clientID int, accountNumber string, name string) (dto.PayeeVerificationDto, app_errors.AppError) {
+	iban := utils.NormalizeIban(accountNumber)
+	if _, err := (&utils.IbanHandler{}).Parse(iban); err != nil {
+		return dto.PayeeVerificationDto{}, &app_errors.ErrBadRequest{Message: "account_number is not a valid IBAN: " + err.Error()}
+	}
+	name = strings.Join(strings.Fields(name), " ")
+	if name == "" {
+		return dto.PayeeVerificationDto{}, &app_errors.ErrBadRequest{Message: "name cannot be empty"}
+	}
+
+	verification := payeeentity.PayeeVerificationEntity{
+		ClientID:      clientID,
+		AccountNumber: iban,
+		Name:          name,
+		Result:        payeeentity.ResultNotAvailable,
+	}
+	var holder payeeentity.Holder
+	accountID, appErr := s.RepositoryWrapper.AccountRepository.FetchAccountIdByAccountNumber(ctx, iban)
+	if _, notFound := appErr.(*app_errors.ErrNotFound); appErr != nil && !notFound {
+		return dto.PayeeVerificationDto{}, appErr
+	}
+	if appErr == nil {
+		account, appErr := s.RepositoryWrapper.AccountRepository.FetchAccountById(ctx, *accountID)
+		if appErr != nil {
+			return dto.PayeeVerificationDto{}, appErr
+		}
+		client, appErr := s.RepositoryWrapper.ClientRepository.FetchClientById(ctx, account.ClientID)
+		if appErr != nil {
+			return dto.PayeeVerificationDto{}, appErr
+		}
+		holder = payeeentity.Holder{Name: client.Name, Surname1: client.Surname1, Surname2: client.Surname2.String}
+		verification.Result = payeeentity.MatchName(name, holder)
+	}
+
+	if appErr := s.RepositoryWrapper.PayeeVerificationRepository.InsertVerification(ctx, &verification, s.Limits); appErr != nil {
+		return dto.PayeeVerificationDto{}, appErr
+	}
+	result := dto.PayeeVerificationDto{
+		AccountNumber: iban,
+		Result:        verification.Result,
+	}
+	if verification.Result == payeeentity.ResultCloseMatch {
+		suggestedName := payeeentity.MaskName(holder)
+		result.SuggestedName = &suggestedName
+	}
+	return result, nil
+}
@@ -0,0 +1,203 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	paymententity "src/domain/payment"
+	app_errors "src/errors"
+	"src/repositories"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Payments per pain.001 message
+const paymentBatchSize = 500
+
+type PaymentService interface {
+	SubmitPendingPayments(ctx context.Context) (int, app_errors.AppError)
+	ProcessStatusReports(ctx context.Context) (int, app_errors.AppError)
+	ProcessInboundFiles(ctx context.Context) (int, app_errors.AppError)
+	SubmitPendingReturns(ctx context.Context) (int, app_errors.AppError)
+}
+
+type paymentService struct {
+	PaymentRepository        repositories.PaymentRepository
+	InboundPaymentRepository repositories.InboundPaymentRepository
+	Gateway                  ClearingGateway
+	Logger                   *zap.Logger
+}
+
+func NewPaymentService(wrapper repositories.RepositoryWrapper, gateway ClearingGateway, logger *zap.Logger) PaymentService {
+	return &paymentService{
+		PaymentRepository:        wrapper.PaymentRepository,
+		InboundPaymentRepository: wrapper.InboundPaymentRepository,
+		Gateway:                  gateway,
+		Logger:                   logger,
+	}
+}
+
+// SubmitPendingPayments sends the pending payments to the clearing house, in batches, and returns how many
+// were sent. The payments of a batch the gateway does not take stay pending, so they go in the next run.
+func (s *paymentService) SubmitPendingPayments(ctx context.Context) (int, app_errors.AppError) {
+	submitted := 0
+	for {
+		batch := paymententity.PaymentBatch{
+			MessageID: strings.ReplaceAll(uuid.NewString(), "-", ""),
+			CreatedAt: time.Now().UTC(),
+		}
+		payments, appErr := s.PaymentRepository.ClaimPendingPayments(ctx, batch.MessageID, paymentBatchSize, func(payments []paymententity.OutboundPaymentEntity) error {
+			batch.Payments = payments
+			return s.Gateway.SubmitBatch(ctx, batch)
+		})
+		if appErr != nil {
+			return submitted, appErr
+		}
+		if len(payments) == 0 {
+			return submitted, nil
+		}
+		submitted += len(payments)
+		s.Logger.Info(fmt.Sprintf("Batch %s submitted with %d payments", batch.MessageID, len(payments)))
+		if len(payments) < paymentBatchSize {
+			return submitted, nil
+		}
+	}
+}
+
+/**
+* ProcessStatusReports applies the pain.002 status reports received and returns how many payments were
+* settled or rejected. The group status of a report applies to the payments of its batch not listed on
+* their own. A report is acknowledged once all its payments are applied; otherwise it is read again in
+* the next run, and the payments already applied are left as they are.
+ */
+func (s *paymentService) ProcessStatusReports(ctx context.Context) (int, app_errors.AppError) {
+	reports, err := s.Gateway.FetchStatusReports(ctx)
+	if err != nil {
+		s.Logger.Error("Status reports could not be read: " + err.Error())
+	}
+	applied := 0
+	for _, report := range reports {
+		count, appErr := s.applyStatusReport(ctx, report)
+		applied += count
+		if appErr != nil {
+			s.Logger.Error(fmt.Sprintf("Status report %s could not be applied: %s", report.Name, appErr.Error()))
+			continue
+		}
+		if err := s.Gateway.AcknowledgeStatusReport(ctx, report); err != nil {
+			s.Logger.Error(fmt.Sprintf("Status report %s could not be acknowledged: %s", report.Name, err.Error()))
+		}
+	}
+	if err != nil {
+		return applied, &app_errors.ErrInternalServer{Reason: err}
+	}
+	return applied, nil
+}
+
+func (s *paymentService) applyStatusReport(ctx context.Context, report paymententity.StatusReport) (int, app_errors.AppError) {
+	statuses := report.Payments
+	group := paymententity.PaymentStatus{Status: report.GroupStatus, Reason: report.GroupReason}
+	if group.IsSettled() || group.IsRejected() {
+		listed := map[string]bool{}
+		for _, status := range report.Payments {
+			listed[status.EndToEndID] = true
+		}
+		payments, appErr := s.PaymentRepository.FetchOutboundPaymentsByBatch(ctx, report.OriginalMessageID)
+		if appErr != nil {
+			return 0, appErr
+		}
+		for _, payment := range payments {
+			if !listed[payment.EndToEndID] {
+				statuses = append(statuses, paymententity.PaymentStatus{EndToEndID: payment.EndToEndID, Status: group.Status, Reason: group.Reason})
+			}
+		}
+	}
+
+	applied := 0
+	for _, status := range statuses {
+		final, appErr := s.PaymentRepository.ApplyPaymentStatus(ctx, status)
+		if _, unknown := appErr.(*app_errors.ErrNotFound); unknown {
+			// not one of ours: reading the report again would not change that
+			s.Logger.Warn(fmt.Sprintf("Status report %s refers to unknown payment %s", report.Name, status.EndToEndID))
+			continue
+		}
+		if appErr != nil {
+			return applied, appErr
+		}
+		if final {
+			applied++
+		}
+	}
+	return applied, nil
+}
+
+// ProcessInboundFiles posts the credits of the pacs.008 and camt.054 files received and returns how many were
+// posted. A file is acknowledged once all its credits are posted; otherwise it is read again in the next run,
+// and the credits already posted are skipped.
+func (s *paymentService) ProcessInboundFiles(ctx context.Context) (int, app_errors.AppError) {
+	files, err := s.Gateway.FetchInboundFiles(ctx)
+	if err != nil {
+		s.Logger.Error("Inbound files could not be read: " + err.Error())
+	}
+	posted := 0
+	for _, file := range files {
+		failed := false
+		for i := range file.Payments {
+			created, appErr := s.InboundPaymentRepository.InsertInboundPayment(ctx, &file.Payments[i])
+			if appErr != nil {
+				s.Logger.Error(fmt.Sprintf("Credit %d of %s could not be posted: %s", file.Payments[i].Sequence, file.Name, appErr.Error()))
+				failed = true
+				break
+			}
+			if created {
+				posted++
+			}
+			if created && file.Payments[i].Status == paymententity.InboundStatusException {
+				s.Logger.Warn(fmt.Sprintf("Credit %d of %s to %s went to suspense: %s", file.Payments[i].Sequence, file.Name, file.Payments[i].CreditorIban, file.Payments[i].ExceptionReason.String))
+			}
+		}
+		if failed {
+			continue
+		}
+		if err := s.Gateway.AcknowledgeInboundFile(ctx, file); err != nil {
+			s.Logger.Error(fmt.Sprintf("Inbound file %s could not be acknowledged: %s", file.Name, err.Error()))
+		}
+	}
+	if err != nil {
+		return posted, &app_errors.ErrInternalServer{Reason: err}
+	}
+	return posted, nil
+}
+
+// SubmitPendingReturns sends the inbound payments returned by bank staff back to the clearing house, in
+// pacs.004 batches, and returns how many were sent. A batch the gateway does not take is released.
+func (s *paymentService) SubmitPendingReturns(ctx context.Context) (int, app_errors.AppError) {
+	submitted := 0
+	for {
+		batch := paymententity.ReturnBatch{
+			MessageID: strings.ReplaceAll(uuid.NewString(), "-", ""),
+			CreatedAt: time.Now().UTC(),
+		}
+		payments, appErr := s.InboundPaymentRepository.ClaimPendingReturns(ctx, batch.MessageID, paymentBatchSize)
+		if appErr != nil {
+			return submitted, appErr
+		}
+		if len(payments) == 0 {
+			return submitted, nil
+		}
+		batch.Payments = payments
+		if err := s.Gateway.SubmitReturns(ctx, batch); err != nil {
+			s.Logger.Error(fmt.Sprintf("Returns %s could not be submitted: %s", batch.MessageID, err.Error()))
+			if appErr := s.InboundPaymentRepository.ReleaseReturns(ctx, batch.MessageID); appErr != nil {
+				return submitted, appErr
+			}
+			return submitted, &app_errors.ErrInternalServer{Reason: err}
+		}
+		submitted += len(payments)
+		s.Logger.Info(fmt.Sprintf("Returns %s submitted with %d payments", batch.MessageID, len(payments)))
+		if len(payments) < paymentBatchSize {
+			return submitted, nil
+		}
+	}
+}
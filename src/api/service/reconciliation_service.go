@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"io"
+	"src/domain/money"
+	reconciliationentity "src/domain/reconciliation"
+	app_errors "src/errors"
+	"src/repositories"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type ReconciliationService interface {
+	Reconcile(ctx context.Context, refreshMaterializedView bool) (reconciliationentity.Report, app_errors.AppError)
+}
+
+type reconciliationService struct {
+	ReconciliationRepository repositories.ReconciliationRepository
+	Logger                   *zap.Logger
+}
+
+func NewReconciliationService(reconciliationRepository repositories.ReconciliationRepository, logger *zap.Logger) ReconciliationService {
+	return &reconciliationService{
+		ReconciliationRepository: reconciliationRepository,
+		Logger:                   logger,
+	}
+}
+
+// Reconcile checks the ledger integrity:
+//
+//   - the whole ledger nets to zero
+//   - account_balances against the balances recomputed from ledger_entries
+//   - account_balances.held_amount against the authorized holds
+//   - the debits and credits of every transaction
+//
+// account_balances_mv is refreshed afterwards when refreshMaterializedView is set.
+func (s *reconciliationService) Reconcile(ctx context.Context, refreshMaterializedView bool) (reconciliationentity.Report, app_errors.AppError) {
+	report := reconciliationentity.Report{GeneratedAt: time.Now().UTC()}
+	appErr := s.ReconciliationRepository.RunInSnapshot(ctx, func(tx *sql.Tx) app_errors.AppError {
+		var err app_errors.AppError
+		if report.AccountsChecked, err = s.ReconciliationRepository.CountAccounts(ctx, tx); err != nil {
+			return err
+		}
+		if report.TransactionsChecked, err = s.ReconciliationRepository.CountTransactions(ctx, tx); err != nil {
+			return err
+		}
+		if report.LedgerTotal, err = s.ReconciliationRepository.FetchLedgerTotal(ctx, tx); err != nil {
+			return err
+		}
+		if report.InternalAccounts, err = s.ReconciliationRepository.FetchInternalAccountBalances(ctx, tx); err != nil {
+			return err
+		}
+		if report.BalanceDiscrepancies, err = s.ReconciliationRepository.FetchBalanceDiscrepancies(ctx, tx); err != nil {
+			return err
+		}
+		if report.HeldAmountDiscrepancies, err = s.ReconciliationRepository.FetchHeldAmountDiscrepancies(ctx, tx); err != nil {
+			return err
+		}
+		report.UnbalancedTransactions, err = s.ReconciliationRepository.FetchUnbalancedTransactions(ctx, tx)
+		return err
+	})
+	if appErr != nil {
+		return reconciliationentity.Report{}, appErr
+	}
+	if !report.IsClean() {
+		s.Logger.Sugar().Warnf(
+			"Reconciliation found a ledger total of %s, %d balance discrepancies, %d held amount discrepancies and %d unbalanced transactions",
+			report.LedgerTotal,
+			len(report.BalanceDiscrepancies),
+			len(report.HeldAmountDiscrepancies),
+			len(report.UnbalancedTransactions),
+		)
+	}
+
+	if refreshMaterializedView {
+		if appErr := s.ReconciliationRepository.RefreshBalancesMaterializedView(ctx); appErr != nil {
+			return reconciliationentity.Report{}, appErr
+		}
+		report.MaterializedViewRefreshed = true
+	}
+	return report, nil
+}
+
+// WriteReconciliationCSV writes one row per discrepancy:
+//
+//	kind,id,type,expected,actual,difference
+//
+// For balances the expected figure is the one recomputed from the ledger and the actual one is the stored one.
+// For transactions they are the debits and the credits.
+func WriteReconciliationCSV(w io.Writer, report reconciliationentity.Report) error {
+	writer := csv.NewWriter(w)
+	records := [][]string{{"kind", "id", "type", "expected", "actual", "difference"}}
+	if !report.LedgerTotal.IsZero() {
+		records = append(records, []string{"LEDGER_TOTAL", "", "", money.Zero.String(), report.LedgerTotal.String(), report.LedgerTotal.String()})
+	}
+	for _, discrepancy := range report.BalanceDiscrepancies {
+		records = append(records, []string{
+			"BALANCE",
+			strconv.Itoa(discrepancy.AccountID),
+			"",
+			discrepancy.LedgerBalance.String(),
+			discrepancy.StoredBalance.String(),
+			discrepancy.Difference.String(),
+		})
+	}
+	for _, discrepancy := range report.HeldAmountDiscrepancies {
+		records = append(records, []string{
+			"HELD_AMOUNT",
+			strconv.Itoa(discrepancy.AccountID),
+			"",
+			discrepancy.AuthorizedHolds.String(),
+			discrepancy.StoredHeldAmount.String(),
+			discrepancy.StoredHeldAmount.Sub(discrepancy.AuthorizedHolds).String(),
+		})
+	}
+	for _, transaction := range report.UnbalancedTransactions {
+		records = append(records, []string{
+			"UNBALANCED_TRANSACTION",
+			strconv.Itoa(transaction.TransactionID),
+			transaction.Type,
+			transaction.Debits.String(),
+			transaction.Credits.String(),
+			transaction.Credits.Sub(transaction.Debits).String(),
+		})
+	}
+	return writer.WriteAll(records)
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	statemententity "src/domain/statement"
+	app_errors "src/errors"
+	"src/repositories"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type StatementService interface {
+	WriteStatement(
+		ctx context.Context,
+		accountID int,
+		from, to time.Time,
+		open func(statement statemententity.StatementEntity) (StatementWriter, error),
+	) app_errors.AppError
+}
+
+type statementService struct {
+	AccountRepository     repositories.AccountRepository
+	ClientRepository      repositories.ClientRepository
+	BalanceRepository     repositories.BalanceRepository
+	TransactionRepository repositories.TransactionRepository
+	Logger                *zap.Logger
+}
+
+func NewStatementService(wrapper repositories.RepositoryWrapper, logger *zap.Logger) StatementService {
+	return &statementService{
+		AccountRepository:     wrapper.AccountRepository,
+		ClientRepository:      wrapper.ClientRepository,
+		BalanceRepository:     wrapper.BalanceRepository,
+		TransactionRepository: wrapper.TransactionRepository,
+		Logger:                logger,
+	}
+}
+
+// WriteStatement writes the statement of the account between from (included) and to (excluded). The balances
+// and the entries are read in the same snapshot, so the closing balance is always the opening balance plus
+// the entries. open is called once the header is known and returns the writer of the format; the errors
+// after it happen in the middle of the output.
+func (s *statementService) WriteStatement(
+	ctx context.Context,
+	accountID int,
+	from, to time.Time,
+	open func(statement statemententity.StatementEntity) (StatementWriter, error),
+) app_errors.AppError {
+	account, appErr := s.AccountRepository.FetchAccountById(ctx, accountID)
+	if appErr != nil {
+		return appErr
+	}
+	statement := statemententity.StatementEntity{
+		AccountID: account.ID,
+		Iban:      account.AccountNumber,
+		Currency:  account.Currency,
+		From:      from,
+		To:        to,
+		CreatedAt: time.Now().UTC(),
+	}
+	if account.ClientID != 0 {
+		client, appErr := s.ClientRepository.FetchClientById(ctx, account.ClientID)
+		if appErr != nil {
+			return appErr
+		}
+		statement.OwnerName = clientFullName(client)
+	}
+
+	return s.BalanceRepository.RunInSnapshot(ctx, func(tx *sql.Tx) app_errors.AppError {
+		var appErr app_errors.AppError
+		if statement.OpeningBalance, appErr = s.BalanceRepository.FetchBalanceAt(ctx, tx, accountID, from); appErr != nil {
+			return appErr
+		}
+		if statement.ClosingBalance, appErr = s.BalanceRepository.FetchBalanceAt(ctx, tx, accountID, to); appErr != nil {
+			return appErr
+		}
+
+		writer, err := open(statement)
+		if err != nil {
+			return &app_errors.ErrInternalServer{Reason: err}
+		}
+		if err := writer.WriteHeader(statement); err != nil {
+			return &app_errors.ErrInternalServer{Reason: err}
+		}
+		appErr = s.TransactionRepository.StreamAccountActivity(ctx, tx, accountID, from, to, statement.OpeningBalance, writer.WriteEntry)
+		if appErr != nil {
+			return appErr
+		}
+		if err := writer.Close(); err != nil {
+			return &app_errors.ErrInternalServer{Reason: err}
+		}
+		return nil
+	})
+}
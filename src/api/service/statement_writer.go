@@ -0,0 +1,435 @@
+package services
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	ledgerentity "src/domain/ledger"
+	"src/domain/money"
+	statemententity "src/domain/statement"
+	transaction_entity "src/domain/transaction"
+	"strconv"
+	"time"
+)
+
+// StatementWriter writes a statement as its entries are read: the header first, then every entry
+// and, on Close, the trailer. Nothing but the current entry is kept in memory.
+type StatementWriter interface {
+	WriteHeader(statement statemententity.StatementEntity) error
+	WriteEntry(entry ledgerentity.ActivityEntryEntity) error
+	Close() error
+}
+
+// NewStatementWriter returns the writer of the format (csv, ofx, camt053)
+func NewStatementWriter(format string, w io.Writer) (StatementWriter, error) {
+	switch format {
+	case statemententity.FormatCSV:
+		return &csvStatementWriter{csv: csv.NewWriter(w)}, nil
+	case statemententity.FormatOFX:
+		return &ofxStatementWriter{xml: newXmlStream(w)}, nil
+	case statemententity.FormatCamt053:
+		return &camt053StatementWriter{xml: newXmlStream(w)}, nil
+	}
+	return nil, fmt.Errorf("statement format %q is not supported", format)
+}
+
+// StatementContentType is the media type of the format
+func StatementContentType(format string) string {
+	if format == statemententity.FormatCSV {
+		return "text/csv; charset=utf-8"
+	}
+	return "application/xml; charset=utf-8"
+}
+
+// StatementFileName is the name of the downloaded statement: statement-<iban>-<first day>-<last day>.<ext>
+func StatementFileName(statement statemententity.StatementEntity, format string) string {
+	extension := "xml"
+	switch format {
+	case statemententity.FormatCSV:
+		extension = "csv"
+	case statemententity.FormatOFX:
+		extension = "ofx"
+	}
+	return fmt.Sprintf("statement-%s-%s-%s.%s",
+		statement.Iban,
+		statement.From.Format("20060102"),
+		statement.To.Add(-time.Nanosecond).Format("20060102"),
+		extension,
+	)
+}
+
+// signedAmount is the amount of the entry, negative when it leaves the account
+func signedAmount(entry ledgerentity.ActivityEntryEntity) money.Money {
+	if entry.Direction == ledgerentity.DirectionOut {
+		return entry.Amount.Neg()
+	}
+	return entry.Amount
+}
+
+// entryDescription is the transaction type of the entry, followed by FEE for the fee legs
+func entryDescription(entry ledgerentity.ActivityEntryEntity) string {
+	if entry.IsFee() {
+		return entry.TransactionType + " " + ledgerentity.KindFee
+	}
+	return entry.TransactionType
+}
+
+// ibanBankCode returns the bank code of a Spanish IBAN, the 4 digits after the check digits
+func ibanBankCode(iban string) string {
+	if len(iban) < 8 {
+		return ""
+	}
+	return iban[4:8]
+}
+
+/**
+* CSV: one row per entry, between an OPENING_BALANCE row and a CLOSING_BALANCE row.
+* Amounts are signed, negative for the money leaving the account.
+ */
+type csvStatementWriter struct {
+	csv       *csv.Writer
+	statement statemententity.StatementEntity
+}
+
+var csvStatementColumns = []string{
+	"date", "entry_id", "transaction_id", "type", "kind", "direction", "amount", "currency",
+	"counterparty_iban", "counterparty_name", "balance_after",
+}
+
+func (s *csvStatementWriter) WriteHeader(statement statemententity.StatementEntity) error {
+	s.statement = statement
+	if err := s.csv.Write(csvStatementColumns); err != nil {
+		return err
+	}
+	return s.balanceRow("OPENING_BALANCE", statement.From, statement.OpeningBalance)
+}
+
+func (s *csvStatementWriter) WriteEntry(entry ledgerentity.ActivityEntryEntity) error {
+	return s.csv.Write([]string{
+		entry.CreatedAt.UTC().Format(time.RFC3339),
+		strconv.Itoa(entry.EntryID),
+		strconv.Itoa(entry.TransactionID),
+		entry.TransactionType,
+		entry.Kind,
+		entry.Direction,
+		signedAmount(entry).String(),
+		s.statement.Currency,
+		entry.CounterpartyIban.String,
+		entry.CounterpartyName.String,
+		entry.BalanceAfter.String(),
+	})
+}
+
+func (s *csvStatementWriter) Close() error {
+	if err := s.balanceRow("CLOSING_BALANCE", s.statement.To, s.statement.ClosingBalance); err != nil {
+		return err
+	}
+	s.csv.Flush()
+	return s.csv.Error()
+}
+
+func (s *csvStatementWriter) balanceRow(balanceType string, at time.Time, balance money.Money) error {
+	return s.csv.Write([]string{
+		at.UTC().Format(time.RFC3339), "", "", balanceType, "", "", "", s.statement.Currency, "", "", balance.String(),
+	})
+}
+
+// xmlStream writes XML tokens keeping the first error, so a document is written without checking every element
+type xmlStream struct {
+	encoder *xml.Encoder
+	err     error
+}
+
+func newXmlStream(w io.Writer) *xmlStream {
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	return &xmlStream{encoder: encoder}
+}
+
+func (x *xmlStream) token(token xml.Token) {
+	if x.err == nil {
+		x.err = x.encoder.EncodeToken(token)
+	}
+}
+
+func (x *xmlStream) start(name string, attrs ...xml.Attr) {
+	x.token(xml.StartElement{Name: xml.Name{Local: name}, Attr: attrs})
+}
+
+func (x *xmlStream) end(name string) {
+	x.token(xml.EndElement{Name: xml.Name{Local: name}})
+}
+
+// leaf writes an element with text only
+func (x *xmlStream) leaf(name string, value string, attrs ...xml.Attr) {
+	x.start(name, attrs...)
+	x.token(xml.CharData(value))
+	x.end(name)
+}
+
+func (x *xmlStream) flush() error {
+	if x.err == nil {
+		x.err = x.encoder.Flush()
+	}
+	return x.err
+}
+
+/**
+* OFX 2.2: a bank statement response (STMTRS) with the entries in BANKTRANLIST, the closing
+* balance in LEDGERBAL and the opening balance in BALLIST.
+ */
+type ofxStatementWriter struct {
+	xml       *xmlStream
+	statement statemententity.StatementEntity
+}
+
+// ofxDate formats an OFX datetime in UTC: YYYYMMDDHHMMSS.XXX[0:GMT]
+func ofxDate(t time.Time) string {
+	return t.UTC().Format("20060102150405.000") + "[0:GMT]"
+}
+
+// ofxTransactionType maps the transaction types to the OFX TRNTYPE. Fees, and their refunds, are FEE
+func ofxTransactionType(entry ledgerentity.ActivityEntryEntity) string {
+	if entry.IsFee() {
+		return "FEE"
+	}
+	switch entry.TransactionType {
+	case "ADD":
+		return "DEP"
+	case "WITHDRAWAL":
+		return "CASH"
+	case "TRANSFER", transaction_entity.SepaTransferType:
+		return "XFER"
+	case transaction_entity.DebitInterestType, transaction_entity.CreditInterestType:
+		return "INT"
+	}
+	if entry.Direction == ledgerentity.DirectionIn {
+		return "CREDIT"
+	}
+	return "DEBIT"
+}
+
+func (s *ofxStatementWriter) WriteHeader(statement statemententity.StatementEntity) error {
+	s.statement = statement
+	x := s.xml
+	x.token(xml.ProcInst{Target: "xml", Inst: []byte(`version="1.0" encoding="UTF-8" standalone="no"`)})
+	x.token(xml.ProcInst{Target: "OFX", Inst: []byte(`OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"`)})
+	x.start("OFX")
+	x.start("SIGNONMSGSRSV1")
+	x.start("SONRS")
+	s.status()
+	x.leaf("DTSERVER", ofxDate(statement.CreatedAt))
+	x.leaf("LANGUAGE", "ENG")
+	x.end("SONRS")
+	x.end("SIGNONMSGSRSV1")
+	x.start("BANKMSGSRSV1")
+	x.start("STMTTRNRS")
+	x.leaf("TRNUID", "0")
+	s.status()
+	x.start("STMTRS")
+	x.leaf("CURDEF", statement.Currency)
+	x.start("BANKACCTFROM")
+	x.leaf("BANKID", ibanBankCode(statement.Iban))
+	x.leaf("ACCTID", statement.Iban)
+	x.leaf("ACCTTYPE", "CHECKING")
+	x.end("BANKACCTFROM")
+	x.start("BANKTRANLIST")
+	x.leaf("DTSTART", ofxDate(statement.From))
+	x.leaf("DTEND", ofxDate(statement.To))
+	return x.err
+}
+
+func (s *ofxStatementWriter) status() {
+	s.xml.start("STATUS")
+	s.xml.leaf("CODE", "0")
+	s.xml.leaf("SEVERITY", "INFO")
+	s.xml.end("STATUS")
+}
+
+func (s *ofxStatementWriter) WriteEntry(entry ledgerentity.ActivityEntryEntity) error {
+	x := s.xml
+	x.start("STMTTRN")
+	x.leaf("TRNTYPE", ofxTransactionType(entry))
+	x.leaf("DTPOSTED", ofxDate(entry.CreatedAt))
+	x.leaf("TRNAMT", signedAmount(entry).String())
+	x.leaf("FITID", strconv.Itoa(entry.EntryID))
+	if entry.CounterpartyName.Valid {
+		name := []rune(entry.CounterpartyName.String)
+		if len(name) > 32 {
+			name = name[:32]
+		}
+		x.leaf("NAME", string(name))
+	}
+	if entry.CounterpartyIban.Valid && entry.Direction == ledgerentity.DirectionOut {
+		x.start("BANKACCTTO")
+		x.leaf("BANKID", ibanBankCode(entry.CounterpartyIban.String))
+		x.leaf("ACCTID", entry.CounterpartyIban.String)
+		x.leaf("ACCTTYPE", "CHECKING")
+		x.end("BANKACCTTO")
+	}
+	memo := entryDescription(entry)
+	if entry.CounterpartyIban.Valid {
+		memo += " " + entry.CounterpartyIban.String
+	}
+	x.leaf("MEMO", memo)
+	x.end("STMTTRN")
+	return x.err
+}
+
+func (s *ofxStatementWriter) Close() error {
+	x := s.xml
+	x.end("BANKTRANLIST")
+	x.start("LEDGERBAL")
+	x.leaf("BALAMT", s.statement.ClosingBalance.String())
+	x.leaf("DTASOF", ofxDate(s.statement.To))
+	x.end("LEDGERBAL")
+	x.start("BALLIST")
+	x.start("BAL")
+	x.leaf("NAME", "Opening balance")
+	x.leaf("DESC", "Balance at the start of the statement")
+	x.leaf("BALTYPE", "DOLLAR")
+	x.leaf("VALUE", s.statement.OpeningBalance.String())
+	x.leaf("DTASOF", ofxDate(s.statement.From))
+	x.end("BAL")
+	x.end("BALLIST")
+	x.end("STMTRS")
+	x.end("STMTTRNRS")
+	x.end("BANKMSGSRSV1")
+	x.end("OFX")
+	return x.flush()
+}
+
+/**
+* ISO 20022 camt.053.001.02: one statement (Stmt) with the opening (OPBD) and closing (CLBD)
+* booked balances, and one booked entry (Ntry) per ledger entry. The counterparty is the
+* creditor of the debits and the debtor of the credits.
+ */
+type camt053StatementWriter struct {
+	xml       *xmlStream
+	statement statemententity.StatementEntity
+}
+
+const camt053Namespace = "urn:iso:std:iso:20022:tech:xsd:camt.053.001.02"
+
+func camtDateTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05Z")
+}
+
+func (s *camt053StatementWriter) WriteHeader(statement statemententity.StatementEntity) error {
+	s.statement = statement
+	x := s.xml
+	id := fmt.Sprintf("STMT-%d-%s", statement.AccountID, statement.CreatedAt.UTC().Format("20060102150405"))
+	x.token(xml.ProcInst{Target: "xml", Inst: []byte(`version="1.0" encoding="UTF-8"`)})
+	x.start("Document", xml.Attr{Name: xml.Name{Local: "xmlns"}, Value: camt053Namespace})
+	x.start("BkToCstmrStmt")
+	x.start("GrpHdr")
+	x.leaf("MsgId", id)
+	x.leaf("CreDtTm", camtDateTime(statement.CreatedAt))
+	x.end("GrpHdr")
+	x.start("Stmt")
+	x.leaf("Id", id)
+	x.leaf("CreDtTm", camtDateTime(statement.CreatedAt))
+	x.start("FrToDt")
+	x.leaf("FrDtTm", camtDateTime(statement.From))
+	x.leaf("ToDtTm", camtDateTime(statement.To))
+	x.end("FrToDt")
+	x.start("Acct")
+	x.start("Id")
+	x.leaf("IBAN", statement.Iban)
+	x.end("Id")
+	x.leaf("Ccy", statement.Currency)
+	if statement.OwnerName != "" {
+		x.start("Ownr")
+		x.leaf("Nm", statement.OwnerName)
+		x.end("Ownr")
+	}
+	x.end("Acct")
+	s.balance("OPBD", statement.OpeningBalance, statement.From)
+	s.balance("CLBD", statement.ClosingBalance, statement.To)
+	return x.err
+}
+
+func (s *camt053StatementWriter) amount(value money.Money) {
+	s.xml.leaf("Amt", value.Abs().String(), xml.Attr{Name: xml.Name{Local: "Ccy"}, Value: s.statement.Currency})
+}
+
+func (s *camt053StatementWriter) balance(code string, value money.Money, at time.Time) {
+	x := s.xml
+	x.start("Bal")
+	x.start("Tp")
+	x.start("CdOrPrtry")
+	x.leaf("Cd", code)
+	x.end("CdOrPrtry")
+	x.end("Tp")
+	s.amount(value)
+	indicator := "CRDT"
+	if value.IsNegative() {
+		indicator = "DBIT"
+	}
+	x.leaf("CdtDbtInd", indicator)
+	x.start("Dt")
+	x.leaf("DtTm", camtDateTime(at))
+	x.end("Dt")
+	x.end("Bal")
+}
+
+func (s *camt053StatementWriter) WriteEntry(entry ledgerentity.ActivityEntryEntity) error {
+	x := s.xml
+	x.start("Ntry")
+	x.leaf("NtryRef", strconv.Itoa(entry.EntryID))
+	s.amount(entry.Amount)
+	if entry.Direction == ledgerentity.DirectionIn {
+		x.leaf("CdtDbtInd", "CRDT")
+	} else {
+		x.leaf("CdtDbtInd", "DBIT")
+	}
+	if entry.TransactionType == transaction_entity.ReversalType {
+		x.leaf("RvslInd", "true")
+	}
+	x.leaf("Sts", "BOOK")
+	x.start("BookgDt")
+	x.leaf("DtTm", camtDateTime(entry.CreatedAt))
+	x.end("BookgDt")
+	x.start("ValDt")
+	x.leaf("DtTm", camtDateTime(entry.CreatedAt))
+	x.end("ValDt")
+	x.leaf("AcctSvcrRef", strconv.Itoa(entry.TransactionID))
+	x.start("BkTxCd")
+	x.start("Prtry")
+	x.leaf("Cd", entryDescription(entry))
+	x.end("Prtry")
+	x.end("BkTxCd")
+	if entry.CounterpartyIban.Valid {
+		party, account := "Dbtr", "DbtrAcct"
+		if entry.Direction == ledgerentity.DirectionOut {
+			party, account = "Cdtr", "CdtrAcct"
+		}
+		x.start("NtryDtls")
+		x.start("TxDtls")
+		x.start("RltdPties")
+		if entry.CounterpartyName.Valid {
+			x.start(party)
+			x.leaf("Nm", entry.CounterpartyName.String)
+			x.end(party)
+		}
+		x.start(account)
+		x.start("Id")
+		x.leaf("IBAN", entry.CounterpartyIban.String)
+		x.end("Id")
+		x.end(account)
+		x.end("RltdPties")
+		x.end("TxDtls")
+		x.end("NtryDtls")
+	}
+	x.leaf("AddtlNtryInf", entryDescription(entry))
+	x.end("Ntry")
+	return x.err
+}
+
+func (s *camt053StatementWriter) Close() error {
+	s.xml.end("Stmt")
+	s.xml.end("BkToCstmrStmt")
+	s.xml.end("Document")
+	return s.xml.flush()
+}
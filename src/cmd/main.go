@@ -4,10 +4,17 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"math/big"
 	"os"
+	"time"
 	api_keycloak "src/api/keycloak"
 	app_router "src/api/router"
+	services "src/api/service"
 	appRedis "src/db/redis"
+	accountentity "src/domain/account"
+	documententity "src/domain/document"
+	interestentity "src/domain/interest"
+	scheduledtransferentity "src/domain/scheduled_transfer"
 	logger "src/logger"
 	"src/repositories"
 
@@ -26,13 +33,249 @@ func LoadRepositoryWrapper() {
 	accountRepository := repositories.NewAccountRepository(db.DB, zlogger)
 	clientRepository := repositories.NewClientRepository(db.DB, zlogger)
 	registryAccountOtpRepository := repositories.NewRegistryAccountOtpRepository(db.DB, zlogger)
+	idempotencyRepository := repositories.NewIdempotencyRepository(db.DB, zlogger)
+	holdRepository := repositories.NewHoldRepository(db.DB, zlogger, transactionRepository)
+	scheduledTransferRepository := repositories.NewScheduledTransferRepository(db.DB, zlogger, transactionRepository)
+	reconciliationRepository := repositories.NewReconciliationRepository(db.DB, zlogger)
+	currencyRepository := repositories.NewCurrencyRepository(db.DB, zlogger)
+	overdraftRepository := repositories.NewOverdraftRepository(db.DB, zlogger, transactionRepository)
+	feeRepository := repositories.NewFeeRepository(db.DB, zlogger)
+	interestRepository := repositories.NewInterestRepository(db.DB, zlogger, transactionRepository)
+	accountStatusRepository := repositories.NewAccountStatusRepository(db.DB, zlogger, transactionRepository, interestRepository)
+	balanceRepository := repositories.NewBalanceRepository(db.DB, zlogger)
+	documentRepository := repositories.NewDocumentRepository(db.DB, zlogger)
+	paymentRepository := repositories.NewPaymentRepository(db.DB, zlogger, transactionRepository)
+	inboundPaymentRepository := repositories.NewInboundPaymentRepository(db.DB, zlogger, transactionRepository)
+	bankDirectoryRepository := repositories.NewBankDirectoryRepository(db.DB, zlogger)
+	branchRepository := repositories.NewBranchRepository(db.DB, zlogger)
+	payeeVerificationRepository := repositories.NewPayeeVerificationRepository(db.DB, zlogger)
+	beneficiaryRepository := repositories.NewBeneficiaryRepository(db.DB, zlogger)
 	repositoryWrapper = &repositories.RepositoryWrapper{
 		ClientRepository:             clientRepository,
 		AccountRepository:            accountRepository,
 		TransactionRepository:        transactionRepository,
 		RegistryAccountOtpRepository: registryAccountOtpRepository,
+		IdempotencyRepository:        idempotencyRepository,
+		HoldRepository:               holdRepository,
+		ScheduledTransferRepository:  scheduledTransferRepository,
+		ReconciliationRepository:     reconciliationRepository,
+		CurrencyRepository:           currencyRepository,
+		AccountStatusRepository:      accountStatusRepository,
+		OverdraftRepository:          overdraftRepository,
+		FeeRepository:                feeRepository,
+		InterestRepository:           interestRepository,
+		BalanceRepository:            balanceRepository,
+		DocumentRepository:           documentRepository,
+		PaymentRepository:            paymentRepository,
+		InboundPaymentRepository:     inboundPaymentRepository,
+		BankDirectoryRepository:      bankDirectoryRepository,
+		BranchRepository:             branchRepository,
+		PayeeVerificationRepository:  payeeVerificationRepository,
+		BeneficiaryRepository:        beneficiaryRepository,
 	}
 }
+// Expired keys are already taken over when reused, this only keeps the table small
+func purgeExpiredIdempotencyKeys(interval time.Duration) {
+	for range time.Tick(interval) {
+		deleted, err := repositoryWrapper.IdempotencyRepository.DeleteExpired(context.Background())
+		if err != nil {
+			continue
+		}
+		zlogger.Sugar().Infof("%d expired idempotency keys deleted", deleted)
+	}
+}
+
+// Expired holds cannot be captured anyway, this gives their funds back to the available balance
+func expireHolds(interval time.Duration) {
+	for range time.Tick(interval) {
+		expired, err := repositoryWrapper.HoldRepository.ExpireHolds(context.Background())
+		if err != nil {
+			continue
+		}
+		if expired > 0 {
+			zlogger.Sugar().Infof("%d expired holds released", expired)
+		}
+	}
+}
+
+// Executes the standing orders due today. A restart never executes a run twice: the run
+// and its transfer are committed together, so the next tick only sees what is still due.
+func runStandingOrders(interval time.Duration) {
+	ticker := time.Tick(interval)
+	for {
+		executed, err := repositoryWrapper.ScheduledTransferRepository.ExecuteDue(context.Background(), scheduledtransferentity.Today())
+		if err != nil {
+			zlogger.Error("Standing orders could not be executed: " + err.Error())
+		}
+		if executed > 0 {
+			zlogger.Sugar().Infof("%d standing order runs executed", executed)
+		}
+		<-ticker
+	}
+}
+
+// unarrangedOverdraftRate reads OVERDRAFT_UNARRANGED_RATE, the yearly debit interest of the accounts
+// below zero without an overdraft in force (i.e. 0.12). Defaults to 0.
+func unarrangedOverdraftRate() *big.Rat {
+	rate, err := accountentity.ParseInterestRate(os.Getenv("OVERDRAFT_UNARRANGED_RATE"))
+	if err != nil {
+		return new(big.Rat)
+	}
+	return rate
+}
+
+// Charges the debit interest on the negative balances of the days after the last one completed, up to yesterday.
+// The first tick catches up with the days missed while the service was down, a month at most per tick. A day
+// with an account that could not be charged is not completed, so the next tick charges the accounts it missed.
+func chargeDebitInterest(interval time.Duration) {
+	ticker := time.Tick(interval)
+	rate := unarrangedOverdraftRate()
+	for {
+		yesterday := scheduledtransferentity.Today().AddDate(0, 0, -1)
+		charged, err := repositoryWrapper.OverdraftRepository.ChargeDebitInterestUntil(context.Background(), yesterday, rate)
+		if err != nil {
+			zlogger.Error("Debit interest could not be charged: " + err.Error())
+		}
+		if charged > 0 {
+			zlogger.Sugar().Infof("%d debit interest charges posted", charged)
+		}
+		<-ticker
+	}
+}
+
+// Accrues the credit interest of the days after the last one completed, up to yesterday, and, once the month is over,
+// pays the accruals of the month before. The first tick catches up with the days missed while the service was down,
+// a month at most per tick. A day with an account that could not accrue is not completed, so the next tick accrues
+// the accounts it missed; the capitalisation waits for a tick whose accruals all succeeded.
+// The accruals of the last day of a month are recorded before the month is capitalised.
+func accrueInterest(interval time.Duration) {
+	ticker := time.Tick(interval)
+	for {
+		today := scheduledtransferentity.Today()
+		accrued, err := repositoryWrapper.InterestRepository.AccrueInterestUntil(context.Background(), today.AddDate(0, 0, -1))
+		if err != nil {
+			zlogger.Error("Interest could not be accrued: " + err.Error())
+		}
+		if accrued > 0 {
+			zlogger.Sugar().Infof("%d interest accruals recorded", accrued)
+		}
+		if err == nil {
+			lastMonth := interestentity.MonthStart(today).AddDate(0, -1, 0)
+			capitalised, err := repositoryWrapper.InterestRepository.CapitaliseInterest(context.Background(), lastMonth)
+			if err != nil {
+				zlogger.Error("Interest could not be capitalised: " + err.Error())
+			}
+			if capitalised > 0 {
+				zlogger.Sugar().Infof("Interest paid to %d accounts", capitalised)
+			}
+		}
+		<-ticker
+	}
+}
+
+// A day is snapshotted once it has been over for this long, so the transactions still running
+// at midnight, whose entries are timestamped when they started, are already committed
+const balanceSnapshotDelay = 10 * time.Minute
+
+// Takes the end of day balance snapshots up to yesterday. The days already taken are skipped,
+// so the first tick catches up with the days missed while the service was down.
+func snapshotBalances(interval time.Duration) {
+	ticker := time.Tick(interval)
+	for {
+		until := scheduledtransferentity.Date(time.Now().UTC().Add(-balanceSnapshotDelay)).AddDate(0, 0, -1)
+		taken, err := repositoryWrapper.BalanceRepository.SnapshotBalances(context.Background(), until)
+		if err != nil {
+			zlogger.Error("Balance snapshots could not be taken: " + err.Error())
+		}
+		if taken > 0 {
+			zlogger.Sugar().Infof("Balance snapshots taken for %d days", taken)
+		}
+		<-ticker
+	}
+}
+
+// Sends the pending SEPA transfers to the clearing house and applies its status reports: settled payments
+// leave the clearing account, rejected ones are refunded. Posts the inbound credits received and sends back
+// the ones returned by bank staff. Skipped unless SEPA_OUTBOX_DIR and SEPA_INBOX_DIR are set.
+func clearSepaPayments(interval time.Duration) {
+	outboxDir, inboxDir := os.Getenv("SEPA_OUTBOX_DIR"), os.Getenv("SEPA_INBOX_DIR")
+	if outboxDir == "" || inboxDir == "" {
+		return
+	}
+	issuer := os.Getenv("BANK_NAME")
+	if issuer == "" {
+		issuer = documententity.DefaultIssuer
+	}
+	gateway, err := services.NewFileClearingGateway(outboxDir, inboxDir, issuer, os.Getenv("BANK_BIC"))
+	if err != nil {
+		zlogger.Error("SEPA clearing directories could not be created: " + err.Error())
+		return
+	}
+	service := services.NewPaymentService(*repositoryWrapper, gateway, zlogger)
+	ticker := time.Tick(interval)
+	for {
+		if applied, err := service.ProcessStatusReports(context.Background()); err != nil {
+			zlogger.Error("SEPA status reports could not be processed: " + err.Error())
+		} else if applied > 0 {
+			zlogger.Sugar().Infof("%d SEPA payments settled or rejected", applied)
+		}
+		if submitted, err := service.SubmitPendingPayments(context.Background()); err != nil {
+			zlogger.Error("SEPA payments could not be submitted: " + err.Error())
+		} else if submitted > 0 {
+			zlogger.Sugar().Infof("%d SEPA payments submitted", submitted)
+		}
+		if posted, err := service.ProcessInboundFiles(context.Background()); err != nil {
+			zlogger.Error("SEPA inbound files could not be processed: " + err.Error())
+		} else if posted > 0 {
+			zlogger.Sugar().Infof("%d SEPA credits received", posted)
+		}
+		if returned, err := service.SubmitPendingReturns(context.Background()); err != nil {
+			zlogger.Error("SEPA returns could not be submitted: " + err.Error())
+		} else if returned > 0 {
+			zlogger.Sugar().Infof("%d SEPA credits returned", returned)
+		}
+		<-ticker
+	}
+}
+
+// loadExchangeRatesFile stores the rates of EXCHANGE_RATES_FILE, if set. A file that cannot be
+// loaded is logged, the rates stored before are kept.
+func loadExchangeRatesFile() {
+	path := os.Getenv("EXCHANGE_RATES_FILE")
+	if path == "" {
+		return
+	}
+	service := services.NewExchangeRateService(repositoryWrapper.CurrencyRepository, zlogger)
+	if _, err := service.LoadExchangeRatesFile(context.Background(), path); err != nil {
+		zlogger.Error("Error loading the exchange rates of " + path + ": " + err.Error())
+	}
+}
+
+// loadBankDirectoryFile replaces the bank directory with BANK_DIRECTORY_FILE, if set. A file that cannot be
+// loaded is logged, the directory stored before is kept.
+func loadBankDirectoryFile() {
+	path := os.Getenv("BANK_DIRECTORY_FILE")
+	if path == "" {
+		return
+	}
+	service := services.NewBankDirectoryService(repositoryWrapper.BankDirectoryRepository, zlogger)
+	if _, err := service.LoadBankDirectoryFile(context.Background(), path); err != nil {
+		zlogger.Error("Error loading the bank directory of " + path + ": " + err.Error())
+	}
+}
+
+// checkBankCodes stops the startup when BANK_CODE or BANK_BRANCH_CODE are not valid, no account could be opened.
+// A default branch missing from the branches table is only logged: it can still be created by an admin.
+func checkBankCodes() {
+	_, branchCode, err := services.BankCodes()
+	if err != nil {
+		panic(err.Error())
+	}
+	if _, appErr := repositoryWrapper.BranchRepository.FetchBranch(context.Background(), branchCode); appErr != nil {
+		zlogger.Error("Error fetching the default branch " + branchCode + ": " + appErr.Error())
+	}
+}
+
 func initializer() {
 	zlogger = logger.GetLogger()
 	err := godotenv.Load()
@@ -71,8 +314,30 @@ func initializer() {
 // @BasePath /
 func main() {
 	initializer()
+	// subcommands: main reconcile [flags]
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "reconcile":
+			code := reconcileCommand(os.Args[2:])
+			db.Close()
+			os.Exit(code)
+		default:
+			fmt.Fprintln(os.Stderr, "unknown command "+os.Args[1])
+			os.Exit(2)
+		}
+	}
+	checkBankCodes()
 	redisClient := appRedis.Get()
 	appRedis.CreateAllIndexes(context.Background(),redisClient,zlogger)
+	go purgeExpiredIdempotencyKeys(time.Hour)
+	go expireHolds(time.Minute)
+	go runStandingOrders(time.Minute)
+	go chargeDebitInterest(time.Hour)
+	go accrueInterest(time.Hour)
+	go snapshotBalances(time.Hour)
+	go clearSepaPayments(time.Minute)
+	loadExchangeRatesFile()
+	loadBankDirectoryFile()
 	
 
 	keycloakClient := api_keycloak.BuildKeycloakClientFromEnv()
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	services "src/api/service"
+)
+
+// reconcileCommand runs the ledger reconciliation from the command line:
+//
+//	go run ./cmd reconcile [-format json|csv] [-refresh-mv] [-output report.csv]
+//
+// The exit code is 0 when the ledger is consistent, 1 when discrepancies are found and 2 on errors.
+func reconcileCommand(args []string) int {
+	flags := flag.NewFlagSet("reconcile", flag.ContinueOnError)
+	format := flags.String("format", "json", "report format: json or csv")
+	refreshMaterializedView := flags.Bool("refresh-mv", false, "refresh account_balances_mv concurrently after the checks")
+	output := flags.String("output", "", "report file. Standard output when empty")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *format != "json" && *format != "csv" {
+		fmt.Fprintln(os.Stderr, "format must be json or csv")
+		return 2
+	}
+
+	reconciliationService := services.NewReconciliationService(repositoryWrapper.ReconciliationRepository, zlogger)
+	report, appErr := reconciliationService.Reconcile(context.Background(), *refreshMaterializedView)
+	if appErr != nil {
+		fmt.Fprintln(os.Stderr, "reconciliation failed: "+appErr.Error())
+		return 2
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			return 2
+		}
+		defer file.Close()
+		w = file
+	}
+	var err error
+	if *format == "csv" {
+		err = services.WriteReconciliationCSV(w, report)
+	} else {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(report)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 2
+	}
+	if !report.IsClean() {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,5 @@
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS reversal_of INTEGER REFERENCES transactions(id);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS reason_code VARCHAR(50);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_reversal_of ON transactions (reversal_of);
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_transaction_id ON ledger_entries (transaction_id);
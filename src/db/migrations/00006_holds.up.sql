@@ -0,0 +1,21 @@
+-- Funds reserved by authorized holds. The available balance is balance - held_amount
+ALTER TABLE account_balances ADD COLUMN IF NOT EXISTS held_amount DECIMAL(15,2) NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS holds (
+    id SERIAL PRIMARY KEY,
+    account_id INTEGER REFERENCES accounts(id) NOT NULL,
+    to_account_id INTEGER REFERENCES accounts(id), -- Merchant account the hold is captured to
+    amount DECIMAL(15,2) NOT NULL CHECK (amount > 0),
+    captured_amount DECIMAL(15,2) NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL DEFAULT 'AUTHORIZED' CHECK (status IN ('AUTHORIZED', 'CAPTURED', 'VOIDED', 'EXPIRED')),
+    reference VARCHAR(255),
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_holds_account_id ON holds (account_id);
+CREATE INDEX IF NOT EXISTS idx_holds_authorized_expires_at ON holds (expires_at) WHERE status = 'AUTHORIZED';
+
+-- Transaction posted by the capture of a hold
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS hold_id INTEGER REFERENCES holds(id);
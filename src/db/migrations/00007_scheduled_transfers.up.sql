@@ -0,0 +1,31 @@
+-- Standing orders: recurring transfers executed by the scheduler
+CREATE TABLE IF NOT EXISTS scheduled_transfers (
+    id SERIAL PRIMARY KEY,
+    account_id INTEGER REFERENCES accounts(id) NOT NULL,
+    to_account_id INTEGER REFERENCES accounts(id) NOT NULL,
+    to_account_number VARCHAR(34) NOT NULL,
+    amount DECIMAL(15,2) NOT NULL CHECK (amount > 0),
+    frequency VARCHAR(20) NOT NULL CHECK (frequency IN ('DAILY', 'WEEKLY', 'MONTHLY', 'END_OF_MONTH')),
+    start_date DATE NOT NULL,
+    end_date DATE CHECK (end_date IS NULL OR end_date >= start_date),
+    next_run_date DATE, -- NULL once the order is finished or cancelled
+    status VARCHAR(20) NOT NULL DEFAULT 'ACTIVE' CHECK (status IN ('ACTIVE', 'FINISHED', 'CANCELLED')),
+    reference VARCHAR(255),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_scheduled_transfers_account_id ON scheduled_transfers (account_id);
+CREATE INDEX IF NOT EXISTS idx_scheduled_transfers_due ON scheduled_transfers (next_run_date) WHERE status = 'ACTIVE';
+
+-- One row per execution. The unique run date makes a restart unable to execute an occurrence twice
+CREATE TABLE IF NOT EXISTS scheduled_transfer_runs (
+    id SERIAL PRIMARY KEY,
+    scheduled_transfer_id INTEGER REFERENCES scheduled_transfers(id) NOT NULL,
+    run_date DATE NOT NULL,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('SUCCEEDED', 'FAILED')),
+    failure_reason VARCHAR(255),
+    transaction_id INTEGER REFERENCES transactions(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (scheduled_transfer_id, run_date)
+);
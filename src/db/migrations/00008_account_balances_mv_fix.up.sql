@@ -0,0 +1,18 @@
+-- Ledger entries are written as 'CREDIT' / 'DEBIT', the view compared against 'credit' and
+-- counted every credit as a debit. The unique index allows REFRESH MATERIALIZED VIEW CONCURRENTLY
+DROP MATERIALIZED VIEW IF EXISTS account_balances_mv;
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS account_balances_mv AS
+SELECT
+    account_id,
+    COALESCE(
+        SUM(CASE WHEN UPPER(type) = 'CREDIT' THEN amount ELSE -amount END),
+        0.0
+    ) AS balance,
+    MAX(created_at) AS created_at,
+    MAX(updated_at) AS updated_at
+FROM ledger_entries
+GROUP BY account_id
+WITH DATA;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_account_balances_mv_account_id ON account_balances_mv (account_id);
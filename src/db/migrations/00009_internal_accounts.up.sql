@@ -0,0 +1,37 @@
+-- Bank owned accounts. Client accounts have no internal code
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS internal_code VARCHAR(50) UNIQUE;
+
+INSERT INTO accounts (account_number, internal_code) VALUES
+    ('INTERNAL-CASH-IN-VAULT', 'CASH_IN_VAULT'), -- Counterpart of ADD and WITHDRAWAL
+    ('INTERNAL-SUSPENSE', 'SUSPENSE'),           -- Funds waiting to be assigned to an account
+    ('INTERNAL-FEE-INCOME', 'FEE_INCOME')        -- Fees charged to the clients
+ON CONFLICT (account_number) DO NOTHING;
+
+INSERT INTO account_balances (account_id, balance)
+SELECT a.id, 0 FROM accounts a
+WHERE a.internal_code IS NOT NULL
+  AND NOT EXISTS (SELECT 1 FROM account_balances ab WHERE ab.account_id = a.id);
+
+-- Existing ADD and WITHDRAWAL transactions (and their reversals) were posted with a single entry.
+-- The missing leg is posted against the cash in vault account, so the whole ledger nets to zero
+INSERT INTO ledger_entries (transaction_id, account_id, type, amount, created_at, updated_at)
+SELECT
+    le.transaction_id,
+    vault.id,
+    CASE WHEN UPPER(le.type) = 'CREDIT' THEN 'DEBIT' ELSE 'CREDIT' END,
+    le.amount,
+    le.created_at,
+    le.updated_at
+FROM ledger_entries le
+JOIN transactions t ON t.id = le.transaction_id
+LEFT JOIN transactions original ON original.id = t.reversal_of
+CROSS JOIN (SELECT id FROM accounts WHERE internal_code = 'CASH_IN_VAULT') vault
+WHERE COALESCE(original.type, t.type) IN ('ADD', 'WITHDRAWAL')
+  AND (SELECT count(*) FROM ledger_entries other WHERE other.transaction_id = le.transaction_id) = 1;
+
+UPDATE account_balances ab SET balance = (
+    SELECT COALESCE(SUM(CASE WHEN UPPER(le.type) = 'CREDIT' THEN le.amount ELSE -le.amount END), 0)
+    FROM ledger_entries le
+    WHERE le.account_id = ab.account_id
+)
+WHERE ab.account_id = (SELECT id FROM accounts WHERE internal_code = 'CASH_IN_VAULT');
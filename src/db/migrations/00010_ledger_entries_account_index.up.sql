@@ -0,0 +1,2 @@
+-- The account activity feed reads the ledger entries of an account in posting order
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_account_id ON ledger_entries (account_id, id);
@@ -0,0 +1,2 @@
+-- Keyset pagination of the transaction history on (created_at, id)
+CREATE INDEX IF NOT EXISTS idx_transactions_account_created_at_id ON transactions (account_id, created_at, id);
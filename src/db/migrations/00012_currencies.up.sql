@@ -0,0 +1,53 @@
+-- ISO 4217 currencies. Amounts are stored as DECIMAL(15,2), so currencies with three decimals are not supported
+CREATE TABLE IF NOT EXISTS currencies (
+    code CHAR(3) PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    minor_unit SMALLINT NOT NULL CHECK (minor_unit BETWEEN 0 AND 2)
+);
+
+INSERT INTO currencies (code, name, minor_unit) VALUES
+    ('EUR', 'Euro', 2),
+    ('USD', 'US Dollar', 2),
+    ('GBP', 'Pound Sterling', 2),
+    ('CHF', 'Swiss Franc', 2),
+    ('JPY', 'Yen', 0),
+    ('SEK', 'Swedish Krona', 2),
+    ('NOK', 'Norwegian Krone', 2),
+    ('DKK', 'Danish Krone', 2),
+    ('PLN', 'Zloty', 2),
+    ('CZK', 'Czech Koruna', 2),
+    ('HUF', 'Forint', 2),
+    ('RON', 'Romanian Leu', 2)
+ON CONFLICT (code) DO NOTHING;
+
+-- Every account opened so far is in euros
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'EUR' REFERENCES currencies(code);
+
+-- Latest rate of each pair: one unit of base_currency buys rate units of quote_currency
+CREATE TABLE IF NOT EXISTS exchange_rates (
+    base_currency CHAR(3) NOT NULL REFERENCES currencies(code),
+    quote_currency CHAR(3) NOT NULL REFERENCES currencies(code),
+    rate NUMERIC(20,10) NOT NULL CHECK (rate > 0),
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (base_currency, quote_currency),
+    CHECK (base_currency <> quote_currency)
+);
+
+-- Transfers between currencies: the applied rate and the amount credited in the destination currency
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS fx_rate NUMERIC(20,10);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS to_amount DECIMAL(15,2);
+
+-- Internal accounts are kept per currency, so every currency nets to zero on its own
+ALTER TABLE accounts DROP CONSTRAINT IF EXISTS accounts_internal_code_key;
+ALTER TABLE accounts ADD CONSTRAINT accounts_internal_code_currency_key UNIQUE (internal_code, currency);
+
+INSERT INTO accounts (account_number, internal_code, currency)
+SELECT 'INTERNAL-FX-POSITION-' || code, 'FX_POSITION', code FROM currencies
+UNION ALL
+SELECT 'INTERNAL-CASH-IN-VAULT-' || code, 'CASH_IN_VAULT', code FROM currencies WHERE code <> 'EUR'
+ON CONFLICT (account_number) DO NOTHING;
+
+INSERT INTO account_balances (account_id, balance)
+SELECT a.id, 0 FROM accounts a
+WHERE a.internal_code IS NOT NULL
+  AND NOT EXISTS (SELECT 1 FROM account_balances ab WHERE ab.account_id = a.id);
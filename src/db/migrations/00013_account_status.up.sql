@@ -0,0 +1,18 @@
+-- Lifecycle of the accounts: ACTIVE, FROZEN, DORMANT, CLOSED
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'ACTIVE'
+    CHECK (status IN ('ACTIVE', 'FROZEN', 'DORMANT', 'CLOSED'));
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS status_reason VARCHAR(255);
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS status_changed_at TIMESTAMP;
+
+-- Audit trail of the status changes
+CREATE TABLE IF NOT EXISTS account_status_changes (
+    id SERIAL PRIMARY KEY,
+    account_id INTEGER NOT NULL REFERENCES accounts(id),
+    from_status VARCHAR(20) NOT NULL,
+    to_status VARCHAR(20) NOT NULL,
+    reason VARCHAR(255) NOT NULL,
+    settlement_transaction_id INTEGER REFERENCES transactions(id), -- Final transfer of the balance of a closed account
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_account_status_changes_account_id ON account_status_changes (account_id);
@@ -0,0 +1,35 @@
+-- Arranged overdraft of an account: how far below zero it can go, between two dates
+CREATE TABLE IF NOT EXISTS overdraft_facilities (
+    id SERIAL PRIMARY KEY,
+    account_id INTEGER NOT NULL UNIQUE REFERENCES accounts(id),
+    limit_amount DECIMAL(15,2) NOT NULL CHECK (limit_amount >= 0),
+    interest_rate NUMERIC(9,6) NOT NULL DEFAULT 0 CHECK (interest_rate >= 0), -- Yearly debit interest, 0.095 is 9.5%
+    valid_from DATE NOT NULL,
+    valid_to DATE, -- Inclusive. Open ended when null
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK (valid_to IS NULL OR valid_to >= valid_from)
+);
+
+-- Debit interest charged on the end of day balance. One row per account and day, so a day is never charged twice
+CREATE TABLE IF NOT EXISTS overdraft_interest_charges (
+    id SERIAL PRIMARY KEY,
+    account_id INTEGER NOT NULL REFERENCES accounts(id),
+    charge_date DATE NOT NULL,
+    balance DECIMAL(15,2) NOT NULL,
+    interest_rate NUMERIC(9,6) NOT NULL,
+    amount DECIMAL(15,2) NOT NULL,
+    transaction_id INTEGER REFERENCES transactions(id), -- Null when the interest rounds to zero
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (account_id, charge_date)
+);
+
+-- Counterpart of the debit interest, one per currency
+INSERT INTO accounts (account_number, internal_code, currency)
+SELECT 'INTERNAL-INTEREST-INCOME-' || code, 'INTEREST_INCOME', code FROM currencies
+ON CONFLICT (account_number) DO NOTHING;
+
+INSERT INTO account_balances (account_id, balance)
+SELECT a.id, 0 FROM accounts a
+WHERE a.internal_code IS NOT NULL
+  AND NOT EXISTS (SELECT 1 FROM account_balances ab WHERE ab.account_id = a.id);
@@ -0,0 +1,55 @@
+-- Kinds of account. The fees charged to an account depend on its product
+CREATE TABLE IF NOT EXISTS products (
+    code VARCHAR(30) PRIMARY KEY,
+    name VARCHAR(100) NOT NULL
+);
+
+INSERT INTO products (code, name) VALUES
+    ('STANDARD', 'Standard current account'),
+    ('BUSINESS', 'Business current account'),
+    ('SAVINGS', 'Savings account')
+ON CONFLICT (code) DO NOTHING;
+
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS product VARCHAR(30) NOT NULL DEFAULT 'STANDARD' REFERENCES products(code);
+
+-- Fee of a transaction type for the accounts of a product, in the currency of the source account.
+-- A schedule without product applies to the products without their own one
+CREATE TABLE IF NOT EXISTS fee_schedules (
+    id SERIAL PRIMARY KEY,
+    transaction_type VARCHAR(50) NOT NULL CHECK (transaction_type IN ('ADD', 'WITHDRAWAL', 'TRANSFER')),
+    product VARCHAR(30) REFERENCES products(code),
+    currency VARCHAR(3) NOT NULL REFERENCES currencies(code),
+    fixed_amount DECIMAL(15,2) NOT NULL DEFAULT 0 CHECK (fixed_amount >= 0),
+    percentage NUMERIC(7,6) NOT NULL DEFAULT 0 CHECK (percentage >= 0 AND percentage <= 1), -- 0.01 is 1%
+    min_percentage_amount DECIMAL(15,2) CHECK (min_percentage_amount >= 0),
+    max_percentage_amount DECIMAL(15,2) CHECK (max_percentage_amount >= 0),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK (min_percentage_amount IS NULL OR max_percentage_amount IS NULL OR min_percentage_amount <= max_percentage_amount)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_fee_schedules_scope ON fee_schedules (transaction_type, COALESCE(product, ''), currency);
+
+-- Total fee charged on a transaction, on top of its amount, and its components
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS fee_amount DECIMAL(15,2) NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS transaction_fees (
+    id SERIAL PRIMARY KEY,
+    transaction_id INTEGER NOT NULL REFERENCES transactions(id),
+    fee_schedule_id INTEGER REFERENCES fee_schedules(id) ON DELETE SET NULL,
+    kind VARCHAR(20) NOT NULL, -- FIXED, PERCENTAGE
+    amount DECIMAL(15,2) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_transaction_fees_transaction_id ON transaction_fees (transaction_id);
+
+-- Counterpart of the fees, one per currency (INTERNAL-FEE-INCOME is the euro one)
+INSERT INTO accounts (account_number, internal_code, currency)
+SELECT 'INTERNAL-FEE-INCOME-' || code, 'FEE_INCOME', code FROM currencies WHERE code <> 'EUR'
+ON CONFLICT (account_number) DO NOTHING;
+
+INSERT INTO account_balances (account_id, balance)
+SELECT a.id, 0 FROM accounts a
+WHERE a.internal_code IS NOT NULL
+  AND NOT EXISTS (SELECT 1 FROM account_balances ab WHERE ab.account_id = a.id);
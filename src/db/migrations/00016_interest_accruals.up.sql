@@ -0,0 +1,50 @@
+-- Yearly credit interest of the accounts of a product in a currency, in force from effective_from
+-- until the next rate of the same product and currency
+CREATE TABLE IF NOT EXISTS interest_rates (
+    id SERIAL PRIMARY KEY,
+    product VARCHAR(30) NOT NULL REFERENCES products(code),
+    currency VARCHAR(3) NOT NULL REFERENCES currencies(code),
+    rate NUMERIC(9,6) NOT NULL CHECK (rate >= 0), -- 0.015 is 1.5%. Zero stops the interest
+    day_count VARCHAR(10) NOT NULL DEFAULT 'ACT/365' CHECK (day_count IN ('ACT/365', 'ACT/360')),
+    effective_from DATE NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (product, currency, effective_from)
+);
+
+-- Interest paid at the end of a month: the sum of its accruals, rounded. One row per account and month
+CREATE TABLE IF NOT EXISTS interest_capitalisations (
+    id SERIAL PRIMARY KEY,
+    account_id INTEGER NOT NULL REFERENCES accounts(id),
+    period_start DATE NOT NULL, -- First day of the month
+    amount DECIMAL(15,2) NOT NULL DEFAULT 0,
+    transaction_id INTEGER REFERENCES transactions(id), -- Null when the interest rounds to zero
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (account_id, period_start)
+);
+
+-- Interest earned on the end of day balance. One row per account and day, so a day never accrues twice.
+-- The amount is not rounded until it is capitalised
+CREATE TABLE IF NOT EXISTS interest_accruals (
+    id SERIAL PRIMARY KEY,
+    account_id INTEGER NOT NULL REFERENCES accounts(id),
+    accrual_date DATE NOT NULL,
+    balance DECIMAL(15,2) NOT NULL,
+    interest_rate NUMERIC(9,6) NOT NULL,
+    day_count VARCHAR(10) NOT NULL,
+    amount NUMERIC(20,10) NOT NULL,
+    capitalisation_id INTEGER REFERENCES interest_capitalisations(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (account_id, accrual_date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_interest_accruals_pending ON interest_accruals (account_id) WHERE capitalisation_id IS NULL;
+
+-- Counterpart of the credit interest, one per currency
+INSERT INTO accounts (account_number, internal_code, currency)
+SELECT 'INTERNAL-INTEREST-EXPENSE-' || code, 'INTEREST_EXPENSE', code FROM currencies
+ON CONFLICT (account_number) DO NOTHING;
+
+INSERT INTO account_balances (account_id, balance)
+SELECT a.id, 0 FROM accounts a
+WHERE a.internal_code IS NOT NULL
+  AND NOT EXISTS (SELECT 1 FROM account_balances ab WHERE ab.account_id = a.id);
@@ -0,0 +1,12 @@
+-- End of day balance of every account, filled by a background job from the first day of the ledger on.
+-- Point in time balances start from the last snapshot and only add the entries after it
+CREATE TABLE IF NOT EXISTS balance_snapshots (
+    account_id INTEGER NOT NULL REFERENCES accounts(id),
+    snapshot_date DATE NOT NULL,
+    balance DECIMAL(15,2) NOT NULL, -- Credits minus debits of the entries created before snapshot_date + 1
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (account_id, snapshot_date)
+);
+
+-- Entries of an account between two dates, for the snapshots and the point in time balances
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_account_created_at ON ledger_entries (account_id, created_at);
@@ -0,0 +1,16 @@
+-- PDF documents handed to the clients: statements and certificates of account ownership. Only what
+-- proves a copy genuine is kept: who and what it is about and the digest of the file
+CREATE TABLE IF NOT EXISTS documents (
+    id SERIAL PRIMARY KEY,
+    verification_code VARCHAR(19) NOT NULL UNIQUE, -- XXXX-XXXX-XXXX-XXXX, printed on every page
+    type VARCHAR(30) NOT NULL CHECK (type IN ('STATEMENT', 'OWNERSHIP_CERTIFICATE')),
+    account_id INTEGER NOT NULL REFERENCES accounts(id),
+    iban VARCHAR(34) NOT NULL,
+    holder_name VARCHAR(255) NOT NULL,
+    period_from DATE, -- Statements only
+    period_to DATE,
+    sha256 CHAR(64) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_documents_account_id ON documents (account_id);
@@ -0,0 +1,42 @@
+-- SEPA credit transfers to IBANs of other banks. The client is debited when the payment is created, and the
+-- amount waits in the SEPA clearing account until the clearing house settles or rejects it
+CREATE TABLE IF NOT EXISTS outbound_payments (
+    id SERIAL PRIMARY KEY,
+    account_id INTEGER NOT NULL REFERENCES accounts(id),
+    transaction_id INTEGER NOT NULL UNIQUE REFERENCES transactions(id), -- Debit of the client
+    settlement_transaction_id INTEGER REFERENCES transactions(id), -- Clearing to settlement account, once settled
+    refund_transaction_id INTEGER REFERENCES transactions(id), -- Reversal of the debit, once rejected
+    end_to_end_id VARCHAR(35) NOT NULL UNIQUE,
+    debtor_iban VARCHAR(34) NOT NULL,
+    debtor_name VARCHAR(140) NOT NULL,
+    creditor_iban VARCHAR(34) NOT NULL,
+    creditor_name VARCHAR(70) NOT NULL,
+    amount DECIMAL(15,2) NOT NULL CHECK (amount > 0),
+    currency CHAR(3) NOT NULL REFERENCES currencies(code),
+    remittance_information VARCHAR(140),
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'SUBMITTED', 'SETTLED', 'REJECTED')),
+    batch_message_id VARCHAR(35), -- MsgId of the pain.001 batch
+    status_reason VARCHAR(35), -- ISO reason code of a rejection
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbound_payments_account_id ON outbound_payments (account_id);
+CREATE INDEX IF NOT EXISTS idx_outbound_payments_pending ON outbound_payments (id) WHERE status = 'PENDING';
+CREATE INDEX IF NOT EXISTS idx_outbound_payments_batch ON outbound_payments (batch_message_id);
+
+-- SEPA transfers can have their own fees
+ALTER TABLE fee_schedules DROP CONSTRAINT IF EXISTS fee_schedules_transaction_type_check;
+ALTER TABLE fee_schedules ADD CONSTRAINT fee_schedules_transaction_type_check
+    CHECK (transaction_type IN ('ADD', 'WITHDRAWAL', 'TRANSFER', 'SEPA_TRANSFER'));
+
+-- Payments submitted and not settled yet, and the funds of the bank at the clearing house. SEPA is in euros only
+INSERT INTO accounts (account_number, internal_code, currency) VALUES
+    ('INTERNAL-SEPA-CLEARING-EUR', 'SEPA_CLEARING', 'EUR'),
+    ('INTERNAL-SEPA-SETTLEMENT-EUR', 'SEPA_SETTLEMENT', 'EUR')
+ON CONFLICT (account_number) DO NOTHING;
+
+INSERT INTO account_balances (account_id, balance)
+SELECT a.id, 0 FROM accounts a
+WHERE a.internal_code IS NOT NULL
+  AND NOT EXISTS (SELECT 1 FROM account_balances ab WHERE ab.account_id = a.id);
@@ -0,0 +1,31 @@
+-- SEPA credit transfers received from other banks (pacs.008 and camt.054 files). The settlement account is
+-- debited when a file is read, and the creditor credited, or the suspense account when it cannot take it
+CREATE TABLE IF NOT EXISTS inbound_payments (
+    id SERIAL PRIMARY KEY,
+    message_id VARCHAR(35) NOT NULL, -- MsgId of the file
+    message_type VARCHAR(10) NOT NULL CHECK (message_type IN ('pacs.008', 'camt.054')),
+    sequence INTEGER NOT NULL, -- Position in the file, so a file read twice is posted once
+    end_to_end_id VARCHAR(35) NOT NULL,
+    reference VARCHAR(35), -- TxId (pacs.008) or AcctSvcrRef (camt.054)
+    debtor_iban VARCHAR(34),
+    debtor_name VARCHAR(140),
+    creditor_iban VARCHAR(34) NOT NULL,
+    creditor_name VARCHAR(140),
+    amount DECIMAL(15,2) NOT NULL CHECK (amount > 0),
+    currency CHAR(3) NOT NULL REFERENCES currencies(code),
+    remittance_information VARCHAR(140),
+    status VARCHAR(20) NOT NULL CHECK (status IN ('CREDITED', 'EXCEPTION', 'RESOLVED', 'RETURNED')),
+    exception_reason VARCHAR(30), -- Why it went to suspense
+    account_id INTEGER REFERENCES accounts(id), -- Credited account, once credited or resolved
+    transaction_id INTEGER NOT NULL UNIQUE REFERENCES transactions(id), -- Credit to the account or to suspense
+    resolution_transaction_id INTEGER REFERENCES transactions(id), -- Out of suspense, to the account or returned
+    return_reason VARCHAR(4), -- ISO reason code of a return
+    return_message_id VARCHAR(35), -- MsgId of the pacs.004, once sent
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (message_id, sequence)
+);
+
+CREATE INDEX IF NOT EXISTS idx_inbound_payments_account_id ON inbound_payments (account_id);
+CREATE INDEX IF NOT EXISTS idx_inbound_payments_exceptions ON inbound_payments (id) WHERE status = 'EXCEPTION';
+CREATE INDEX IF NOT EXISTS idx_inbound_payments_returns ON inbound_payments (id) WHERE status = 'RETURNED' AND return_message_id IS NULL;
@@ -0,0 +1,10 @@
+-- Banks of the SEPA countries by their code in the IBAN, to show the BIC and name of the bank of an IBAN.
+-- Loaded from BANK_DIRECTORY_FILE, every load replaces the whole directory
+CREATE TABLE IF NOT EXISTS bank_directory (
+    country_code CHAR(2) NOT NULL,
+    bank_code VARCHAR(16) NOT NULL, -- Bank code of the IBAN, or bank and branch code where the BIC depends on the branch
+    bic VARCHAR(11) NOT NULL,
+    name VARCHAR(140) NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (country_code, bank_code)
+);
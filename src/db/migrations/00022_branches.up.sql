@@ -0,0 +1,14 @@
+-- Branches of the bank. The accounts of a client are opened in their branch, or in BANK_BRANCH_CODE when
+-- the client has none, and their account numbers are taken from the sequence of the branch
+CREATE TABLE IF NOT EXISTS branches (
+    code CHAR(4) PRIMARY KEY CHECK (code ~ '^[0-9]{4}$'),
+    name VARCHAR(100) NOT NULL,
+    last_sequence BIGINT NOT NULL DEFAULT 0 CHECK (last_sequence BETWEEN 0 AND 9999999999), -- Last account number handed out
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- The branch of every account opened before
+INSERT INTO branches (code, name) VALUES ('0600', 'Oficina principal') ON CONFLICT (code) DO NOTHING;
+
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS branch_code CHAR(4) REFERENCES branches(code);
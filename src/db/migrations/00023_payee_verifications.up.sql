@@ -0,0 +1,12 @@
+-- Confirmation of Payee checks done by the clients before a transfer. They are counted to rate limit the
+-- checks of a client, so the endpoint cannot be used to find out the holders of the accounts
+CREATE TABLE IF NOT EXISTS payee_verifications (
+    id SERIAL PRIMARY KEY,
+    client_id INT NOT NULL REFERENCES clients(id),
+    account_number VARCHAR(34) NOT NULL,
+    name VARCHAR(140) NOT NULL,
+    result VARCHAR(20) NOT NULL CHECK (result IN ('MATCH', 'CLOSE_MATCH', 'NO_MATCH', 'NOT_AVAILABLE')),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_payee_verifications_client ON payee_verifications (client_id, created_at);
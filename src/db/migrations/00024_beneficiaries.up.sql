@@ -0,0 +1,20 @@
+-- IBANs saved by the clients to send transfers to. Until cooling_off_until the transfers to a new beneficiary
+-- cannot add up to more than cooling_off_limit. The IBAN of a beneficiary never changes
+CREATE TABLE IF NOT EXISTS beneficiaries (
+    id SERIAL PRIMARY KEY,
+    client_id INT NOT NULL REFERENCES clients(id),
+    nickname VARCHAR(70) NOT NULL,
+    iban VARCHAR(34) NOT NULL,
+    name VARCHAR(70),
+    reference VARCHAR(140),
+    cooling_off_until TIMESTAMP NOT NULL,
+    cooling_off_limit DECIMAL(15,2) NOT NULL CHECK (cooling_off_limit >= 0),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (client_id, iban)
+);
+
+-- The transfers sent to a beneficiary, to add them up during its cooling-off
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS beneficiary_id INT REFERENCES beneficiaries(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_transactions_beneficiary ON transactions (beneficiary_id) WHERE beneficiary_id IS NOT NULL;
@@ -0,0 +1,18 @@
+-- What a ledger entry moves: the amount of its transaction (PRINCIPAL) or the fee charged on top of it (FEE).
+-- Both legs of a fee are FEE, so the statements do not show the fee as a second payment to the counterparty
+ALTER TABLE ledger_entries ADD COLUMN IF NOT EXISTS kind VARCHAR(20) NOT NULL DEFAULT 'PRINCIPAL' CHECK (kind IN ('PRINCIPAL', 'FEE'));
+
+-- The fee legs posted before: the entries on the fee income account and, as the fee is posted after the amount,
+-- the last entry of the source account in the same transaction. Reversals mirror the entries in the same order
+UPDATE ledger_entries le SET kind = 'FEE'
+FROM transactions t
+WHERE t.id = le.transaction_id
+  AND t.fee_amount > 0
+  AND EXISTS (
+    SELECT 1 FROM ledger_entries f JOIN accounts fa ON fa.id = f.account_id
+    WHERE f.transaction_id = t.id AND fa.internal_code = 'FEE_INCOME'
+  )
+  AND (
+    le.account_id IN (SELECT id FROM accounts WHERE internal_code = 'FEE_INCOME')
+    OR le.id = (SELECT MAX(s.id) FROM ledger_entries s WHERE s.transaction_id = t.id AND s.account_id = t.account_id)
+  );
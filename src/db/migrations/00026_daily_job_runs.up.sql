@@ -0,0 +1,16 @@
+-- Last day completed by each daily job (i.e. DEBIT_INTEREST, INTEREST_ACCRUAL). A day is recorded once all its
+-- accounts are done, so the next runs start from the day after, or retry the day when some account failed
+CREATE TABLE IF NOT EXISTS daily_job_runs (
+    job VARCHAR(50) PRIMARY KEY,
+    last_day DATE NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- The days done before, from the charges and accruals recorded
+INSERT INTO daily_job_runs (job, last_day)
+SELECT 'DEBIT_INTEREST', MAX(charge_date) FROM overdraft_interest_charges HAVING MAX(charge_date) IS NOT NULL
+ON CONFLICT (job) DO NOTHING;
+
+INSERT INTO daily_job_runs (job, last_day)
+SELECT 'INTEREST_ACCRUAL', MAX(accrual_date) FROM interest_accruals HAVING MAX(accrual_date) IS NOT NULL
+ON CONFLICT (job) DO NOTHING;
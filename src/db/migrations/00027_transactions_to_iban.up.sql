@@ -0,0 +1,10 @@
+-- IBAN, in electronic format, the transfers were sent to: to_account_number or, between accounts of the bank, the
+-- account_number of the destination. It adds up the transfers to the IBAN of a beneficiary during its cooling-off
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS to_iban VARCHAR(34);
+
+UPDATE transactions t
+SET to_iban = COALESCE(UPPER(REPLACE(t.to_account_number, ' ', '')), d.account_number)
+FROM accounts d
+WHERE d.id = t.to_account_id AND t.type IN ('TRANSFER', 'SEPA_TRANSFER');
+
+CREATE INDEX IF NOT EXISTS idx_transactions_to_iban ON transactions (to_iban, created_at) WHERE to_iban IS NOT NULL;
@@ -0,0 +1,52 @@
+package appRedis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// IdempotencyRecord is the cached response of a completed idempotent request
+type IdempotencyRecord struct {
+	RequestHash    string `json:"request_hash"`
+	ResponseStatus int    `json:"response_status"`
+	ResponseBody   string `json:"response_body"`
+}
+
+// GenerateIdempotencyKey creates a Redis key for an idempotency key of a client.
+func GenerateIdempotencyKey(clientId int, idempotencyKey string) string {
+	return fmt.Sprintf("idempotency:%d:%s", clientId, idempotencyKey)
+}
+
+// SetIdempotencyRecord stores the response of a request. The record expires along with the key.
+func SetIdempotencyRecord(ctx context.Context, rdb *redis.Client, clientId int, idempotencyKey string, record IdempotencyRecord, ttl time.Duration) error {
+	key := GenerateIdempotencyKey(clientId, idempotencyKey)
+	value, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal idempotency record for key %s: %w", key, err)
+	}
+	if err := rdb.Set(ctx, key, value, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to save idempotency record for key %s: %w", key, err)
+	}
+	return nil
+}
+
+// GetIdempotencyRecord retrieves the response of a request. A nil record means a cache miss.
+func GetIdempotencyRecord(ctx context.Context, rdb *redis.Client, clientId int, idempotencyKey string) (*IdempotencyRecord, error) {
+	key := GenerateIdempotencyKey(clientId, idempotencyKey)
+	res, err := rdb.Get(ctx, key).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get idempotency record for key %s: %w", key, err)
+	}
+	var record IdempotencyRecord
+	if err := json.Unmarshal([]byte(res), &record); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal idempotency record for key %s: %w", key, err)
+	}
+	return &record, nil
+}
@@ -10,6 +10,11 @@ type AccountEntity struct {
     ID           int       `json:"id" db:"id"`
     ClientID     int       `json:"client_id" db:"client_id"`
     AccountNumber string    `json:"account_number" db:"account_number"`
+    Currency     string    `json:"currency" db:"currency"` // ISO 4217, EUR by default
+    Product      string    `json:"product" db:"product"` // STANDARD by default
+    Status       string    `json:"status" db:"status"` // ACTIVE, FROZEN, DORMANT, CLOSED
+    StatusReason sql.NullString `json:"status_reason" db:"status_reason"`
+    StatusChangedAt sql.NullTime `json:"status_changed_at" db:"status_changed_at"`
     CreatedAt    time.Time `json:"created_at" db:"created_at"`
     UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
 }
@@ -1,13 +1,48 @@
 package accountentity
 
 import (
+    "src/domain/money"
     "time"
 )
 
 // AccountBalanceMV represents the account_balances_mv materialized view in the database.
 type AccountBalanceMV struct {
     AccountID int       `json:"account_id" db:"account_id"`
-    Balance   float64   `json:"balance" db:"balance"`
+    Balance   money.Money `json:"balance" db:"balance"`
     CreatedAt time.Time `json:"created_at" db:"created_at"`
     UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
-}
\ No newline at end of file
+}
+
+// AccountBalance represents a row of the account_balances table.
+//   - Balance: the ledger balance, the sum of the posted ledger entries.
+//   - HeldAmount: funds reserved by authorized holds.
+//   - OverdraftLimit: the arranged overdraft in force today, zero without one.
+type AccountBalance struct {
+    AccountID  int         `json:"account_id" db:"account_id"`
+    Balance    money.Money `json:"balance" db:"balance"`
+    HeldAmount money.Money `json:"held_amount" db:"held_amount"`
+    Internal   bool        `json:"internal"` // Bank owned account, its balance can be negative
+    Currency   string      `json:"currency"`
+    MinorUnit  int         `json:"minor_unit"` // Decimal places of the currency
+    Status     string      `json:"status"` // Status of the account
+    OverdraftLimit money.Money `json:"overdraft_limit"`
+}
+
+// Available is the balance that can still be spent
+func (b AccountBalance) Available() money.Money {
+    return b.Balance.Sub(b.HeldAmount)
+}
+
+// Spendable is the available balance plus the arranged overdraft
+func (b AccountBalance) Spendable() money.Money {
+    return b.Available().Add(b.OverdraftLimit)
+}
+
+// OverdraftHeadroom is the part of the arranged overdraft not used yet
+func (b AccountBalance) OverdraftHeadroom() money.Money {
+    headroom := money.Min(b.OverdraftLimit, b.Spendable())
+    if headroom.IsNegative() {
+        return money.Zero
+    }
+    return headroom
+}
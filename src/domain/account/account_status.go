@@ -0,0 +1,49 @@
+package accountentity
+
+import (
+	"database/sql"
+	"slices"
+	"time"
+)
+
+// Account statuses
+const (
+	StatusActive  string = "ACTIVE"
+	StatusFrozen  string = "FROZEN"  // Blocked by the bank (i.e. compromised): no money in or out
+	StatusDormant string = "DORMANT" // Unused for a long time: it can receive money, not send it
+	StatusClosed  string = "CLOSED"  // Final: no money in or out
+)
+
+// transitions lists the statuses each status can move to
+var transitions = map[string][]string{
+	StatusActive:  {StatusFrozen, StatusDormant, StatusClosed},
+	StatusFrozen:  {StatusActive, StatusClosed},
+	StatusDormant: {StatusActive, StatusFrozen, StatusClosed},
+	StatusClosed:  {},
+}
+
+// CanTransition tells whether an account can move from one status to the other
+func CanTransition(from string, to string) bool {
+	return slices.Contains(transitions[from], to)
+}
+
+// CanSend tells whether money can leave an account in the given status
+func CanSend(status string) bool {
+	return status == StatusActive
+}
+
+// CanReceive tells whether money can be credited to an account in the given status
+func CanReceive(status string) bool {
+	return status == StatusActive || status == StatusDormant
+}
+
+// AccountStatusChangeEntity represents the account_status_changes table in the database
+type AccountStatusChangeEntity struct {
+	ID                      int           `json:"id" db:"id"`
+	AccountID               int           `json:"account_id" db:"account_id"`
+	FromStatus              string        `json:"from_status" db:"from_status"`
+	ToStatus                string        `json:"to_status" db:"to_status"`
+	Reason                  string        `json:"reason" db:"reason"`
+	SettlementTransactionID sql.NullInt32 `json:"settlement_transaction_id" db:"settlement_transaction_id"` // Closing only
+	CreatedAt               time.Time     `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,18 @@
+package accountentity
+
+import (
+	"src/domain/money"
+	"time"
+)
+
+// BalanceHistoryMaxDays bounds the days of a balance history request
+const BalanceHistoryMaxDays = 366
+
+// BalanceSnapshotEntity represents the balance_snapshots table: the end of day balance of an account,
+// credits minus debits of the ledger entries created before the next day
+type BalanceSnapshotEntity struct {
+	AccountID    int         `json:"account_id" db:"account_id"`
+	SnapshotDate time.Time   `json:"snapshot_date" db:"snapshot_date"`
+	Balance      money.Money `json:"balance" db:"balance"`
+	CreatedAt    time.Time   `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,18 @@
+package accountentity
+
+// Codes of the bank owned accounts seeded by the 00009, 00012, 00014, 00015, 00016 and 00019 migrations (accounts.internal_code).
+// There is one account per code and currency: the suspense and SEPA accounts are in euros only.
+//
+// Every account follows the same convention: the balance is credits minus debits. The cash in vault
+// is debited on every ADD, so its balance is the negative of the cash the bank holds, and the sum of
+// the balances of all the accounts is zero.
+const (
+	InternalCashInVault     string = "CASH_IN_VAULT"    // Counterpart of ADD and WITHDRAWAL
+	InternalSuspense        string = "SUSPENSE"         // Funds waiting to be assigned to an account
+	InternalFeeIncome       string = "FEE_INCOME"       // Fees charged to the clients
+	InternalFxPosition      string = "FX_POSITION"      // Counterpart of each leg of the transfers between currencies
+	InternalInterestIncome  string = "INTEREST_INCOME"  // Overdraft interest charged to the clients
+	InternalInterestExpense string = "INTEREST_EXPENSE" // Interest paid to the clients
+	InternalSepaClearing    string = "SEPA_CLEARING"    // SEPA transfers debited and not settled yet
+	InternalSepaSettlement  string = "SEPA_SETTLEMENT"  // Funds of the bank at the clearing house
+)
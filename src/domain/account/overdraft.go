@@ -0,0 +1,74 @@
+package accountentity
+
+import (
+	"database/sql"
+	"errors"
+	"math/big"
+	"regexp"
+	"src/domain/money"
+	"strings"
+	"time"
+)
+
+// DebitInterestDaysInYear is the day count of the debit interest (ACT/365): every day is charged 1/365 of the yearly rate
+const DebitInterestDaysInYear = 365
+
+var ErrInvalidInterestRate = errors.New("interest rate must be a non negative decimal with up to 6 decimals")
+
+// NUMERIC(9,6)
+var interestRatePattern = regexp.MustCompile(`^\d{1,3}(\.\d{1,6})?$`)
+
+// OverdraftEntity represents the overdraft_facilities table: the arranged overdraft of an account
+type OverdraftEntity struct {
+	ID           int          `json:"id" db:"id"`
+	AccountID    int          `json:"account_id" db:"account_id"`
+	Limit        money.Money  `json:"limit" db:"limit_amount"`          // How far below zero the available balance can go
+	InterestRate string       `json:"interest_rate" db:"interest_rate"` // Yearly debit interest rate, 0.095 is 9.5%
+	ValidFrom    time.Time    `json:"valid_from" db:"valid_from"`       // First day of the facility
+	ValidTo      sql.NullTime `json:"valid_to" db:"valid_to"`           // Last day of the facility, open ended when null
+	CreatedAt    time.Time    `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time    `json:"updated_at" db:"updated_at"`
+}
+
+// IsValidOn tells whether the facility applies on the given calendar day
+func (o OverdraftEntity) IsValidOn(day time.Time) bool {
+	if day.Before(o.ValidFrom) {
+		return false
+	}
+	return !o.ValidTo.Valid || !day.After(o.ValidTo.Time)
+}
+
+// OverdraftInterestChargeEntity represents the overdraft_interest_charges table: the debit interest of one day
+type OverdraftInterestChargeEntity struct {
+	ID            int           `json:"id" db:"id"`
+	AccountID     int           `json:"account_id" db:"account_id"`
+	ChargeDate    time.Time     `json:"charge_date" db:"charge_date"`
+	Balance       money.Money   `json:"balance" db:"balance"` // End of day balance
+	InterestRate  string        `json:"interest_rate" db:"interest_rate"`
+	Amount        money.Money   `json:"amount" db:"amount"`
+	TransactionID sql.NullInt32 `json:"transaction_id" db:"transaction_id"` // Null when the interest rounds to zero
+	CreatedAt     time.Time     `json:"created_at" db:"created_at"`
+}
+
+// ParseInterestRate parses a yearly interest rate. It must fit the NUMERIC(9,6) column.
+func ParseInterestRate(rate string) (*big.Rat, error) {
+	rate = strings.TrimSpace(rate)
+	if !interestRatePattern.MatchString(rate) {
+		return nil, ErrInvalidInterestRate
+	}
+	value, ok := new(big.Rat).SetString(rate)
+	if !ok {
+		return nil, ErrInvalidInterestRate
+	}
+	return value, nil
+}
+
+// DailyDebitInterest is the interest of one day on a negative balance, rounded half to even to the minor unit
+// of the currency. Positive balances are not charged.
+func DailyDebitInterest(balance money.Money, yearlyRate *big.Rat, minorUnit int) money.Money {
+	if !balance.IsNegative() {
+		return money.Zero
+	}
+	daily := new(big.Rat).Quo(yearlyRate, big.NewRat(DebitInterestDaysInYear, 1))
+	return money.FromRatTo(new(big.Rat).Mul(balance.Neg().Rat(), daily), minorUnit, money.RoundHalfEven)
+}
@@ -0,0 +1,11 @@
+package accountentity
+
+// DefaultProduct is the product of the accounts opened without one
+const DefaultProduct string = "STANDARD"
+
+// ProductEntity represents the products table: the kind of account (current, business, savings).
+// The fees charged to an account depend on its product.
+type ProductEntity struct {
+	Code string `json:"code" db:"code"`
+	Name string `json:"name" db:"name"`
+}
@@ -0,0 +1,49 @@
+package bankentity
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidCountry  = errors.New("country must be an ISO 3166 alpha-2 code")
+	ErrInvalidBankCode = errors.New("bank code must have 1 to 16 letters or digits")
+	ErrInvalidBic      = errors.New("BIC must have 8 or 11 characters: 4 letters of the bank, 2 of the country and 2 or 5 letters or digits")
+	ErrInvalidName     = errors.New("bank name must have 1 to 140 characters")
+)
+
+var (
+	countryPattern  = regexp.MustCompile(`^[A-Z]{2}$`)
+	bankCodePattern = regexp.MustCompile(`^[A-Z0-9]{1,16}$`)
+	bicPattern      = regexp.MustCompile(`^[A-Z]{6}[A-Z0-9]{2}([A-Z0-9]{3})?$`)
+)
+
+// BankEntity represents the bank_directory table: a bank identified by the bank code of its IBANs
+type BankEntity struct {
+	CountryCode string    `json:"country_code" db:"country_code"`
+	BankCode    string    `json:"bank_code" db:"bank_code"` // Bank code, or bank and branch code, of the IBAN
+	Bic         string    `json:"bic" db:"bic"`
+	Name        string    `json:"name" db:"name"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Normalize uppercases the codes and trims the name, and checks them
+func (b *BankEntity) Normalize() error {
+	b.CountryCode = strings.ToUpper(strings.TrimSpace(b.CountryCode))
+	b.BankCode = strings.ToUpper(strings.Join(strings.Fields(b.BankCode), ""))
+	b.Bic = strings.ToUpper(strings.TrimSpace(b.Bic))
+	b.Name = strings.TrimSpace(b.Name)
+	switch {
+	case !countryPattern.MatchString(b.CountryCode):
+		return ErrInvalidCountry
+	case !bankCodePattern.MatchString(b.BankCode):
+		return ErrInvalidBankCode
+	case !bicPattern.MatchString(b.Bic):
+		return ErrInvalidBic
+	case b.Name == "" || len([]rune(b.Name)) > 140:
+		return ErrInvalidName
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package beneficiaryentity
+
+import (
+	"database/sql"
+	"src/domain/money"
+	"time"
+)
+
+// Limits of the fields of a beneficiary. The name is the creditor of the SEPA transfers, as long as the pain.001 allows.
+const (
+	MaxNicknameLength  = 70
+	MaxNameLength      = 70
+	MaxReferenceLength = 140
+)
+
+// Cooling-off of the beneficiaries when BENEFICIARY_COOLING_OFF and BENEFICIARY_COOLING_OFF_LIMIT are not set
+const DefaultCoolingOff = 24 * time.Hour
+
+var DefaultCoolingOffLimit = money.MustParse("300.00")
+
+// BeneficiaryEntity represents the beneficiaries table: an IBAN saved by a client to send transfers to.
+// Until CoolingOffUntil the transfers to the new beneficiary cannot add up to more than CoolingOffLimit.
+// The IBAN cannot change, another beneficiary has to be added, with its own cooling-off.
+type BeneficiaryEntity struct {
+	ID              int            `json:"id" db:"id"`
+	ClientID        int            `json:"client_id" db:"client_id"`
+	Nickname        string         `json:"nickname" db:"nickname"`
+	Iban            string         `json:"iban" db:"iban"`           // Electronic format
+	Name            sql.NullString `json:"name" db:"name"`           // Holder of the IBAN, the creditor of the SEPA transfers
+	Reference       sql.NullString `json:"reference" db:"reference"` // Default remittance information of the transfers
+	CoolingOffUntil time.Time      `json:"cooling_off_until" db:"cooling_off_until"`
+	CoolingOffLimit money.Money    `json:"cooling_off_limit" db:"cooling_off_limit"`
+	CreatedAt       time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at" db:"updated_at"`
+}
+
+// IsCoolingOff tells whether the transfers to the beneficiary are still capped
+func (b BeneficiaryEntity) IsCoolingOff(now time.Time) bool {
+	return now.Before(b.CoolingOffUntil)
+}
@@ -0,0 +1,53 @@
+package branchentity
+
+import (
+	"errors"
+	"regexp"
+	"time"
+)
+
+// Codes of the IBANs of the bank when BANK_CODE and BANK_BRANCH_CODE are not set
+const (
+	DefaultBankCode   string = "0182"
+	DefaultBranchCode string = "0600"
+)
+
+// Digits of the account number of a Spanish IBAN, and how many numbers a branch can hand out
+const (
+	AccountNumberDigits         = 10
+	AccountNumberCapacity int64 = 9_999_999_999 // 0000000001 to 9999999999
+)
+
+var ErrInvalidCode = errors.New("bank and branch codes must have 4 digits")
+
+var codePattern = regexp.MustCompile(`^[0-9]{4}$`)
+
+func IsValidCode(code string) bool {
+	return codePattern.MatchString(code)
+}
+
+// BranchEntity represents the branches table. The account numbers of a branch are taken in order from
+// its sequence, so two accounts can never get the same one.
+type BranchEntity struct {
+	Code         string    `json:"code" db:"code"`
+	Name         string    `json:"name" db:"name"`
+	LastSequence int64     `json:"last_sequence" db:"last_sequence"` // Last account number handed out, 0 for none
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// BranchUsage is a branch with the accounts opened in it
+type BranchUsage struct {
+	BranchEntity
+	Accounts int `json:"accounts"` // Client accounts with the bank and branch code of the branch
+}
+
+// Available is how many account numbers the branch can still hand out
+func (u BranchUsage) Available() int64 {
+	return AccountNumberCapacity - u.LastSequence
+}
+
+// Usage is the share of the account numbers of the branch handed out, from 0 to 1
+func (u BranchUsage) Usage() float64 {
+	return float64(u.LastSequence) / float64(AccountNumberCapacity)
+}
@@ -25,6 +25,7 @@ type ClientEntity struct {
     CreatedAt     time.Time      `db:"created_at" json:"created_at"`
     UpdatedAt     time.Time      `db:"updated_at" json:"updated_at"`
     KcUserId      sql.NullInt64  `db:"kc_user_id"`
+    BranchCode    sql.NullString `db:"branch_code" json:"branch_code,omitempty"`
 }
 
 func ScanClientEntity(r *sql.Rows, client *ClientEntity) error {
@@ -0,0 +1,76 @@
+package currencyentity
+
+import (
+	"errors"
+	"math/big"
+	"regexp"
+	"src/domain/money"
+	"strings"
+	"time"
+)
+
+// Default is the currency of the accounts opened without one, and of every account created before currencies existed
+const Default string = "EUR"
+
+var (
+	ErrInvalidCode = errors.New("currency must be an ISO 4217 code")
+	ErrInvalidRate = errors.New("exchange rate must be a positive decimal with at most 10 integer and 10 fractional digits")
+)
+
+var codePattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// ratePattern matches the NUMERIC(20,10) column of exchange_rates
+var ratePattern = regexp.MustCompile(`^\d{1,10}(\.\d{1,10})?$`)
+
+// CurrencyEntity represents the currencies table in the database
+type CurrencyEntity struct {
+	Code      string `json:"code" db:"code"` // ISO 4217
+	Name      string `json:"name" db:"name"`
+	MinorUnit int    `json:"minor_unit" db:"minor_unit"` // Decimal places: 2 for EUR, 0 for JPY
+}
+
+// ExchangeRateEntity represents the exchange_rates table in the database.
+// One unit of Base buys Rate units of Quote.
+type ExchangeRateEntity struct {
+	Base      string    `json:"base" db:"base_currency"`
+	Quote     string    `json:"quote" db:"quote_currency"`
+	Rate      string    `json:"rate" db:"rate"` // Exact decimal, i.e. "1.0850000000"
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// NormalizeCode upper cases a currency code and checks its format
+func NormalizeCode(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if !codePattern.MatchString(code) {
+		return "", ErrInvalidCode
+	}
+	return code, nil
+}
+
+// ParseRate parses an exchange rate. It must fit the NUMERIC(20,10) column and be positive.
+func ParseRate(rate string) (*big.Rat, error) {
+	rate = strings.TrimSpace(rate)
+	if !ratePattern.MatchString(rate) {
+		return nil, ErrInvalidRate
+	}
+	value, ok := new(big.Rat).SetString(rate)
+	if !ok || value.Sign() <= 0 {
+		return nil, ErrInvalidRate
+	}
+	return value, nil
+}
+
+// RateValue returns the rate as an exact rational
+func (e ExchangeRateEntity) RateValue() (*big.Rat, error) {
+	return ParseRate(e.Rate)
+}
+
+// HasScale tells whether the amount has no more decimals than the currency allows
+func (c CurrencyEntity) HasScale(amount money.Money) bool {
+	return amount.RoundTo(c.MinorUnit, money.RoundDown).Equal(amount)
+}
+
+// Convert converts an amount with the given rate, rounded half to even to the minor unit of the quote currency
+func Convert(amount money.Money, rate *big.Rat, quoteMinorUnit int) money.Money {
+	return money.FromRatTo(new(big.Rat).Mul(amount.Rat(), rate), quoteMinorUnit, money.RoundHalfEven)
+}
@@ -0,0 +1,93 @@
+package documententity
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// Types of the issued documents
+const (
+	TypeStatement            string = "STATEMENT"
+	TypeOwnershipCertificate string = "OWNERSHIP_CERTIFICATE"
+)
+
+// DefaultIssuer is the name of the bank on the documents when BANK_NAME is not set
+const DefaultIssuer = "Banking Ledger"
+
+const (
+	verificationCodeAlphabet  string = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789" // No 0/O nor 1/I, they are read out loud
+	verificationCodeGroups    int    = 4
+	verificationCodeGroupSize int    = 4
+)
+
+// DocumentEntity represents the documents table: a PDF handed to a client. The document itself is not
+// stored, only what is needed to tell whether a copy is genuine.
+type DocumentEntity struct {
+	ID               int          `json:"id" db:"id"`
+	VerificationCode string       `json:"verification_code" db:"verification_code"` // Printed on every page
+	Type             string       `json:"type" db:"type"`                           // STATEMENT, OWNERSHIP_CERTIFICATE
+	AccountID        int          `json:"account_id" db:"account_id"`
+	Iban             string       `json:"iban" db:"iban"`
+	HolderName       string       `json:"holder_name" db:"holder_name"`
+	PeriodFrom       sql.NullTime `json:"period_from" db:"period_from"` // First day of a statement
+	PeriodTo         sql.NullTime `json:"period_to" db:"period_to"`     // Last day of a statement
+	Sha256           string       `json:"sha256" db:"sha256"`           // Hex digest of the PDF
+	CreatedAt        time.Time    `json:"created_at" db:"created_at"`
+}
+
+// NewVerificationCode returns a random code such as 7KQM-X2PA-HD9T-RW4C: 16 characters of 32 symbols, 80 bits
+func NewVerificationCode() (string, error) {
+	random := make([]byte, verificationCodeGroups*verificationCodeGroupSize)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	var code strings.Builder
+	for i, b := range random {
+		if i > 0 && i%verificationCodeGroupSize == 0 {
+			code.WriteByte('-')
+		}
+		// 256 is a multiple of 32, so every symbol is equally likely
+		code.WriteByte(verificationCodeAlphabet[int(b)%len(verificationCodeAlphabet)])
+	}
+	return code.String(), nil
+}
+
+// NormalizeVerificationCode accepts the code as typed: lowercase, with spaces or without the dashes
+func NormalizeVerificationCode(code string) string {
+	compact := strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(code))
+	var normalized strings.Builder
+	for i, r := range compact {
+		if i > 0 && i%verificationCodeGroupSize == 0 {
+			normalized.WriteByte('-')
+		}
+		normalized.WriteRune(r)
+	}
+	return normalized.String()
+}
+
+// MaskIban hides the IBAN but the country, the check digits and the last 4 digits: ES91****************7890
+func MaskIban(iban string) string {
+	if len(iban) <= 8 {
+		return iban
+	}
+	return iban[:4] + strings.Repeat("*", len(iban)-8) + iban[len(iban)-4:]
+}
+
+// FormatIban groups the IBAN in blocks of 4, as it is printed: ES91 0182 0600 1112 3456 7890
+func FormatIban(iban string) string {
+	var formatted strings.Builder
+	for i, r := range iban {
+		if i > 0 && i%4 == 0 {
+			formatted.WriteByte(' ')
+		}
+		formatted.WriteRune(r)
+	}
+	return formatted.String()
+}
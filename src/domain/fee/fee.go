@@ -0,0 +1,99 @@
+package feeentity
+
+import (
+	"database/sql"
+	"errors"
+	"math/big"
+	"regexp"
+	"src/domain/money"
+	"strings"
+	"time"
+)
+
+// Components of a fee
+const (
+	KindFixed      string = "FIXED"      // Flat amount per transaction
+	KindPercentage string = "PERCENTAGE" // Share of the amount of the transaction
+)
+
+// Transaction types a fee can be charged on
+var TransactionTypes = []string{"ADD", "WITHDRAWAL", "TRANSFER", "SEPA_TRANSFER"}
+
+var ErrInvalidPercentage = errors.New("percentage must be a decimal between 0 and 1 with up to 6 decimals")
+
+// NUMERIC(7,6)
+var percentagePattern = regexp.MustCompile(`^[01](\.\d{1,6})?$`)
+
+// FeeScheduleEntity represents the fee_schedules table: the fee of a transaction type for the accounts
+// of a product in a currency. A schedule without product applies to the products without their own one.
+type FeeScheduleEntity struct {
+	ID                  int             `json:"id" db:"id"`
+	TransactionType     string          `json:"transaction_type" db:"transaction_type"` // ADD, WITHDRAWAL, TRANSFER, SEPA_TRANSFER
+	Product             sql.NullString  `json:"product" db:"product"`                   // Every product when null
+	Currency            string          `json:"currency" db:"currency"`                 // Currency of the source account, and of the fee
+	FixedAmount         money.Money     `json:"fixed_amount" db:"fixed_amount"`
+	Percentage          string          `json:"percentage" db:"percentage"`                       // Share of the amount, 0.01 is 1%
+	MinPercentageAmount money.NullMoney `json:"min_percentage_amount" db:"min_percentage_amount"` // Floor of the percentage component
+	MaxPercentageAmount money.NullMoney `json:"max_percentage_amount" db:"max_percentage_amount"` // Cap of the percentage component
+	CreatedAt           time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt           time.Time       `json:"updated_at" db:"updated_at"`
+}
+
+// FeeEntity represents the transaction_fees table: a component of the fee charged on a transaction
+type FeeEntity struct {
+	ID            int           `json:"id" db:"id"`
+	TransactionID int           `json:"transaction_id" db:"transaction_id"`
+	FeeScheduleID sql.NullInt32 `json:"fee_schedule_id" db:"fee_schedule_id"` // Null once the schedule is deleted
+	Kind          string        `json:"kind" db:"kind"`                       // FIXED, PERCENTAGE
+	Amount        money.Money   `json:"amount" db:"amount"`
+	CreatedAt     time.Time     `json:"created_at" db:"created_at"`
+}
+
+// ParsePercentage parses the percentage of a fee. It must fit the NUMERIC(7,6) column and be up to 1.
+func ParsePercentage(percentage string) (*big.Rat, error) {
+	percentage = strings.TrimSpace(percentage)
+	if !percentagePattern.MatchString(percentage) {
+		return nil, ErrInvalidPercentage
+	}
+	value, ok := new(big.Rat).SetString(percentage)
+	if !ok || value.Cmp(big.NewRat(1, 1)) > 0 {
+		return nil, ErrInvalidPercentage
+	}
+	return value, nil
+}
+
+// Compute returns the components of the fee of an amount, leaving out the zero ones. The percentage
+// component is rounded half to even to the minor unit of the currency and then bounded by the min and max amounts.
+func (s FeeScheduleEntity) Compute(amount money.Money, minorUnit int) ([]FeeEntity, error) {
+	percentage, err := ParsePercentage(s.Percentage)
+	if err != nil {
+		return nil, err
+	}
+	scheduleID := sql.NullInt32{Int32: int32(s.ID), Valid: true}
+	fees := make([]FeeEntity, 0, 2)
+	if s.FixedAmount.IsPositive() {
+		fees = append(fees, FeeEntity{FeeScheduleID: scheduleID, Kind: KindFixed, Amount: s.FixedAmount})
+	}
+	if percentage.Sign() > 0 {
+		variable := money.FromRatTo(new(big.Rat).Mul(amount.Rat(), percentage), minorUnit, money.RoundHalfEven)
+		if s.MinPercentageAmount.Valid && variable.LessThan(s.MinPercentageAmount.Money) {
+			variable = s.MinPercentageAmount.Money
+		}
+		if s.MaxPercentageAmount.Valid && variable.GreaterThan(s.MaxPercentageAmount.Money) {
+			variable = s.MaxPercentageAmount.Money
+		}
+		if variable.IsPositive() {
+			fees = append(fees, FeeEntity{FeeScheduleID: scheduleID, Kind: KindPercentage, Amount: variable})
+		}
+	}
+	return fees, nil
+}
+
+// Total is the sum of the components of a fee
+func Total(fees []FeeEntity) money.Money {
+	total := money.Zero
+	for _, fee := range fees {
+		total = total.Add(fee.Amount)
+	}
+	return total
+}
@@ -0,0 +1,38 @@
+package holdentity
+
+import (
+	"database/sql"
+	"src/domain/money"
+	"time"
+)
+
+// Hold statuses
+const (
+	StatusAuthorized string = "AUTHORIZED"
+	StatusCaptured   string = "CAPTURED"
+	StatusVoided     string = "VOIDED"
+	StatusExpired    string = "EXPIRED"
+)
+
+// HoldEntity represents the holds table in the database.
+// An AUTHORIZED hold reduces the available balance of the account without posting ledger entries.
+type HoldEntity struct {
+	ID             int            `json:"id" db:"id"`
+	AccountID      int            `json:"account_id" db:"account_id"`
+	ToAccountID    sql.NullInt32  `json:"to_account_id" db:"to_account_id"` // Nullable
+	Amount         money.Money    `json:"amount" db:"amount"`
+	CapturedAmount money.Money    `json:"captured_amount" db:"captured_amount"`
+	Status         string         `json:"status" db:"status"` // AUTHORIZED, CAPTURED, VOIDED, EXPIRED
+	Reference      sql.NullString `json:"reference" db:"reference"`
+	ExpiresAt      time.Time      `json:"expires_at" db:"expires_at"`
+	CreatedAt      time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at" db:"updated_at"`
+}
+
+func (h HoldEntity) IsAuthorized() bool {
+	return h.Status == StatusAuthorized
+}
+
+func (h HoldEntity) IsExpired() bool {
+	return !time.Now().Before(h.ExpiresAt)
+}
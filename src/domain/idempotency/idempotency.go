@@ -0,0 +1,23 @@
+package idempotency_entity
+
+import (
+	"database/sql"
+	"time"
+)
+
+// IdempotencyKeyEntity represents the idempotency_keys table in the database.
+// A row without ResponseStatus is a key whose request is still being processed.
+type IdempotencyKeyEntity struct {
+	ID             int            `json:"id" db:"id"`
+	ClientID       int            `json:"client_id" db:"client_id"`
+	IdempotencyKey string         `json:"idempotency_key" db:"idempotency_key"`
+	RequestHash    string         `json:"request_hash" db:"request_hash"`
+	ResponseStatus sql.NullInt32  `json:"response_status" db:"response_status"`
+	ResponseBody   sql.NullString `json:"response_body" db:"response_body"`
+	CreatedAt      time.Time      `json:"created_at" db:"created_at"`
+	ExpiresAt      time.Time      `json:"expires_at" db:"expires_at"`
+}
+
+func (e IdempotencyKeyEntity) IsCompleted() bool {
+	return e.ResponseStatus.Valid
+}
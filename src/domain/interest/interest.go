@@ -0,0 +1,89 @@
+package interestentity
+
+import (
+	"database/sql"
+	"errors"
+	"math/big"
+	"src/domain/money"
+	"time"
+)
+
+// Day count conventions: the share of the yearly rate earned each day
+const (
+	DayCountACT365 string = "ACT/365" // 1/365 of the rate every day, leap years included
+	DayCountACT360 string = "ACT/360" // 1/360 of the rate every day, so a year earns slightly more than the rate
+)
+
+var DayCounts = []string{DayCountACT365, DayCountACT360}
+
+// AccrualScale is the number of decimals of the daily accruals. They are only rounded to the
+// minor unit of the currency when capitalised, so the rounding error is not repeated every day.
+const AccrualScale = 10
+
+var ErrInvalidDayCount = errors.New("day_count must be ACT/365 or ACT/360")
+
+// InterestRateEntity represents the interest_rates table: the yearly credit interest of the accounts of a
+// product in a currency, from effective_from until the next rate of the same product and currency.
+type InterestRateEntity struct {
+	ID            int       `json:"id" db:"id"`
+	Product       string    `json:"product" db:"product"`
+	Currency      string    `json:"currency" db:"currency"`
+	Rate          string    `json:"rate" db:"rate"`           // Yearly rate, 0.015 is 1.5%. Zero stops the interest
+	DayCount      string    `json:"day_count" db:"day_count"` // ACT/365, ACT/360
+	EffectiveFrom time.Time `json:"effective_from" db:"effective_from"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+}
+
+// InterestAccrualEntity represents the interest_accruals table: the interest earned by an account on one day
+type InterestAccrualEntity struct {
+	ID               int           `json:"id" db:"id"`
+	AccountID        int           `json:"account_id" db:"account_id"`
+	AccrualDate      time.Time     `json:"accrual_date" db:"accrual_date"`
+	Balance          money.Money   `json:"balance" db:"balance"` // End of day balance
+	InterestRate     string        `json:"interest_rate" db:"interest_rate"`
+	DayCount         string        `json:"day_count" db:"day_count"`
+	Amount           string        `json:"amount" db:"amount"`                       // Not rounded, AccrualScale decimals
+	CapitalisationID sql.NullInt32 `json:"capitalisation_id" db:"capitalisation_id"` // Null until it is paid
+	CreatedAt        time.Time     `json:"created_at" db:"created_at"`
+}
+
+// InterestCapitalisationEntity represents the interest_capitalisations table: the accruals of an account
+// paid at the end of a month
+type InterestCapitalisationEntity struct {
+	ID            int           `json:"id" db:"id"`
+	AccountID     int           `json:"account_id" db:"account_id"`
+	PeriodStart   time.Time     `json:"period_start" db:"period_start"`     // First day of the month
+	Amount        money.Money   `json:"amount" db:"amount"`                 // Sum of the accruals, rounded half to even
+	TransactionID sql.NullInt32 `json:"transaction_id" db:"transaction_id"` // Null when the interest rounds to zero
+	CreatedAt     time.Time     `json:"created_at" db:"created_at"`
+}
+
+// DaysInYear is the denominator of the day count convention
+func DaysInYear(dayCount string) (int64, error) {
+	switch dayCount {
+	case DayCountACT365:
+		return 365, nil
+	case DayCountACT360:
+		return 360, nil
+	}
+	return 0, ErrInvalidDayCount
+}
+
+// DailyInterest is the interest earned by a positive balance in one day, not rounded.
+// Balances at or below zero earn nothing, the overdraft interest charges them.
+func DailyInterest(balance money.Money, yearlyRate *big.Rat, dayCount string) (*big.Rat, error) {
+	days, err := DaysInYear(dayCount)
+	if err != nil {
+		return nil, err
+	}
+	if !balance.IsPositive() {
+		return new(big.Rat), nil
+	}
+	daily := new(big.Rat).Quo(yearlyRate, big.NewRat(days, 1))
+	return daily.Mul(daily, balance.Rat()), nil
+}
+
+// MonthStart is the first day of the month of the given day, the period of a capitalisation
+func MonthStart(day time.Time) time.Time {
+	return time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, day.Location())
+}
@@ -0,0 +1,41 @@
+package ledgerentity
+
+import (
+	"database/sql"
+	"src/domain/money"
+	"time"
+)
+
+// Directions of an activity entry
+const (
+	DirectionIn  string = "IN"  // CREDIT entry: money received
+	DirectionOut string = "OUT" // DEBIT entry: money sent
+)
+
+// ActivityEntryEntity is a ledger entry of an account joined to its transaction and the other side of it
+type ActivityEntryEntity struct {
+	EntryID               int            `json:"entry_id" db:"entry_id"`
+	TransactionID         int            `json:"transaction_id" db:"transaction_id"`
+	TransactionType       string         `json:"transaction_type" db:"transaction_type"` // ADD, WITHDRAWAL, TRANSFER, REVERSAL
+	Direction             string         `json:"direction" db:"direction"`               // IN, OUT
+	Kind                  string         `json:"kind" db:"kind"`                         // PRINCIPAL, FEE
+	Amount                money.Money    `json:"amount" db:"amount"`
+	BalanceAfter          money.Money    `json:"balance_after" db:"balance_after"`
+	CounterpartyAccountID sql.NullInt32  `json:"counterparty_account_id" db:"counterparty_account_id"` // Nullable: cash movements and fees against internal accounts
+	CounterpartyIban      sql.NullString `json:"counterparty_iban" db:"counterparty_iban"`
+	CounterpartyName      sql.NullString `json:"counterparty_name" db:"counterparty_name"`
+	CreatedAt             time.Time      `json:"created_at" db:"created_at"`
+}
+
+// IsFee tells whether the entry is the fee of its transaction, or its refund
+func (e ActivityEntryEntity) IsFee() bool {
+	return e.Kind == KindFee
+}
+
+// DirectionOf returns the direction of a ledger entry type
+func DirectionOf(ledgerType string) string {
+	if ledgerType == "CREDIT" {
+		return DirectionIn
+	}
+	return DirectionOut
+}
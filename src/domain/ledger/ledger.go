@@ -2,17 +2,25 @@ package ledgerentity
 
 import (
 	"database/sql"
+	"src/domain/money"
 	transaction_entity "src/domain/transaction"
 	"time"
 )
 
+// Kinds of ledger entry
+const (
+	KindPrincipal string = "PRINCIPAL" // The amount of the transaction
+	KindFee       string = "FEE"       // The fee charged on top of it, against the fee income account
+)
+
 // LedgerEntryEntity represents the ledger_entries table in the database.
 type LedgerEntryEntity struct {
 	ID            int       `json:"id" db:"id"`
 	TransactionID int       `json:"transaction_id" db:"transaction_id"`
 	AccountID     int       `json:"account_id" db:"account_id"`
 	Type          string    `json:"type" db:"type"`
-	Amount        float64   `json:"amount" db:"amount"`
+	Kind          string    `json:"kind" db:"kind"` // PRINCIPAL, FEE
+	Amount        money.Money `json:"amount" db:"amount"`
 	CreatedAt     time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
 }
@@ -21,7 +29,24 @@ type LedgerTransaction struct {
 	Transaction transaction_entity.TransactionEntity
 	AccountID   int
 	LedgerType  string // CREDIT / DEBIT
+	Amount      money.Money // Optional, the transaction amount is used when it is zero
+	Kind        string // Optional, PRINCIPAL when empty
+}
 
+// EntryAmount is the amount of the ledger entry
+func (l LedgerTransaction) EntryAmount() money.Money {
+	if l.Amount.IsZero() {
+		return l.Transaction.Amount
+	}
+	return l.Amount
+}
+
+// EntryKind is the kind of the ledger entry
+func (l LedgerTransaction) EntryKind() string {
+	if l.Kind == "" {
+		return KindPrincipal
+	}
+	return l.Kind
 }
 
 func ScanLedgerEntryEntity(r *sql.Rows, ledgerEntry *LedgerEntryEntity) error {
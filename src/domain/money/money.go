@@ -0,0 +1,387 @@
+package money
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Scale is the number of fractional digits of every amount, as the DECIMAL(15,2) columns
+const Scale = 2
+
+// maxDigits keeps the minor units inside an int64
+const maxDigits = 18
+
+var scaleFactor = big.NewInt(100)
+
+var (
+	ErrInvalidAmount = errors.New("invalid monetary amount")
+	ErrScale         = fmt.Errorf("monetary amount has more than %d decimal places", Scale)
+	ErrOverflow      = errors.New("monetary amount is out of range")
+)
+
+// RoundingMode tells how an amount with more than Scale decimals is brought back to Scale
+type RoundingMode int
+
+const (
+	// RoundHalfEven rounds to the nearest cent, ties to the even one (banker's rounding)
+	RoundHalfEven RoundingMode = iota
+	// RoundHalfUp rounds to the nearest cent, ties away from zero
+	RoundHalfUp
+	// RoundDown truncates towards zero
+	RoundDown
+	// RoundUp rounds away from zero
+	RoundUp
+)
+
+// Money is an exact decimal amount with two decimal places.
+// It is stored as minor units (cents), so additions and comparisons never drift as float64 does.
+//
+//   - Postgres: it is scanned from and written to NUMERIC columns as text.
+//   - JSON: it is serialised as a string ("12.50"). Strings and numbers are accepted when decoding.
+type Money struct {
+	units int64
+}
+
+var Zero = Money{}
+
+// FromMinorUnits builds an amount from cents: FromMinorUnits(1250) is 12.50
+func FromMinorUnits(units int64) Money {
+	return Money{units: units}
+}
+
+// FromInt builds an amount without decimals: FromInt(12) is 12.00
+func FromInt(value int64) Money {
+	return Money{units: value * 100}
+}
+
+// Parse reads a decimal string like "-12.5" or "1000.25".
+// More than two decimals are only accepted when the extra digits are zeros ("12.500").
+func Parse(value string) (Money, error) {
+	return parse(value, nil)
+}
+
+// ParseRounded reads a decimal string with any number of decimals, rounding it with mode.
+func ParseRounded(value string, mode RoundingMode) (Money, error) {
+	return parse(value, &mode)
+}
+
+// MustParse is Parse for constants and tests. It panics on error.
+func MustParse(value string) Money {
+	m, err := Parse(value)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+func parse(value string, mode *RoundingMode) (Money, error) {
+	s := strings.TrimSpace(value)
+	if s == "" {
+		return Zero, ErrInvalidAmount
+	}
+	negative := false
+	switch s[0] {
+	case '-':
+		negative = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+	integerPart, fractionalPart, _ := strings.Cut(s, ".")
+	if integerPart == "" && fractionalPart == "" {
+		return Zero, ErrInvalidAmount
+	}
+	if !isDigits(integerPart) || !isDigits(fractionalPart) {
+		return Zero, ErrInvalidAmount
+	}
+	integerPart = strings.TrimLeft(integerPart, "0")
+	if len(integerPart)+Scale > maxDigits {
+		return Zero, ErrOverflow
+	}
+
+	kept := fractionalPart
+	extra := ""
+	if len(fractionalPart) > Scale {
+		kept = fractionalPart[:Scale]
+		extra = fractionalPart[Scale:]
+	}
+	kept = kept + strings.Repeat("0", Scale-len(kept))
+
+	units, err := strconv.ParseInt(integerPart+kept, 10, 64)
+	if err != nil {
+		return Zero, ErrOverflow
+	}
+
+	if strings.Trim(extra, "0") != "" {
+		if mode == nil {
+			return Zero, ErrScale
+		}
+		units = roundDiscarded(units, extra, *mode)
+	}
+	if negative {
+		units = -units
+	}
+	return Money{units: units}, nil
+}
+
+// roundDiscarded applies the rounding mode to the (positive) units, given the discarded digits
+func roundDiscarded(units int64, discarded string, mode RoundingMode) int64 {
+	switch mode {
+	case RoundDown:
+		return units
+	case RoundUp:
+		return units + 1
+	}
+	first := discarded[0]
+	rest := strings.Trim(discarded[1:], "0")
+	switch {
+	case first > '5', first == '5' && rest != "":
+		return units + 1
+	case first == '5' && (mode == RoundHalfUp || units%2 != 0):
+		return units + 1
+	}
+	return units
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// MinorUnits returns the amount in cents
+func (m Money) MinorUnits() int64 {
+	return m.units
+}
+
+func (m Money) Add(other Money) Money {
+	return Money{units: m.units + other.units}
+}
+
+func (m Money) Sub(other Money) Money {
+	return Money{units: m.units - other.units}
+}
+
+func (m Money) Neg() Money {
+	return Money{units: -m.units}
+}
+
+func (m Money) Abs() Money {
+	if m.units < 0 {
+		return m.Neg()
+	}
+	return m
+}
+
+// Cmp returns -1, 0 or +1 when m is lower, equal or greater than other
+func (m Money) Cmp(other Money) int {
+	switch {
+	case m.units < other.units:
+		return -1
+	case m.units > other.units:
+		return 1
+	}
+	return 0
+}
+
+func (m Money) Equal(other Money) bool {
+	return m.units == other.units
+}
+
+func (m Money) LessThan(other Money) bool {
+	return m.units < other.units
+}
+
+func (m Money) GreaterThan(other Money) bool {
+	return m.units > other.units
+}
+
+func (m Money) IsZero() bool {
+	return m.units == 0
+}
+
+func (m Money) IsPositive() bool {
+	return m.units > 0
+}
+
+func (m Money) IsNegative() bool {
+	return m.units < 0
+}
+
+// Min returns the lowest of both amounts
+func Min(a, b Money) Money {
+	if a.LessThan(b) {
+		return a
+	}
+	return b
+}
+
+// MulRat multiplies the amount by an exact rational (rates, percentages) and rounds the result to cents
+func (m Money) MulRat(factor *big.Rat, mode RoundingMode) Money {
+	product := new(big.Rat).Mul(new(big.Rat).SetInt64(m.units), factor)
+	return Money{units: roundRat(product, mode)}
+}
+
+// Rat returns the exact amount as a rational number
+func (m Money) Rat() *big.Rat {
+	return new(big.Rat).SetFrac(big.NewInt(m.units), scaleFactor)
+}
+
+// FromRat rounds an exact rational amount to cents
+func FromRat(amount *big.Rat, mode RoundingMode) Money {
+	units := new(big.Rat).Mul(amount, new(big.Rat).SetInt(scaleFactor))
+	return Money{units: roundRat(units, mode)}
+}
+
+// FromRatTo rounds an exact rational amount to the given decimal places (up to Scale), i.e. 0 for JPY.
+// It rounds once, unlike FromRat followed by RoundTo.
+func FromRatTo(amount *big.Rat, decimals int, mode RoundingMode) Money {
+	decimals = min(max(decimals, 0), Scale)
+	step := int64(1)
+	for i := decimals; i < Scale; i++ {
+		step *= 10
+	}
+	units := new(big.Rat).Mul(amount, new(big.Rat).SetFrac64(scaleFactor.Int64(), step))
+	return Money{units: roundRat(units, mode) * step}
+}
+
+// roundRat rounds a rational number of minor units to an integer
+func roundRat(value *big.Rat, mode RoundingMode) int64 {
+	num := new(big.Int).Set(value.Num())
+	den := value.Denom()
+	negative := num.Sign() < 0
+	num.Abs(num)
+	quotient, remainder := new(big.Int).QuoRem(num, den, new(big.Int))
+	if remainder.Sign() != 0 {
+		twice := new(big.Int).Mul(remainder, big.NewInt(2))
+		half := twice.Cmp(den)
+		switch mode {
+		case RoundUp:
+			quotient.Add(quotient, big.NewInt(1))
+		case RoundHalfUp:
+			if half >= 0 {
+				quotient.Add(quotient, big.NewInt(1))
+			}
+		case RoundHalfEven:
+			if half > 0 || (half == 0 && quotient.Bit(0) == 1) {
+				quotient.Add(quotient, big.NewInt(1))
+			}
+		}
+	}
+	if negative {
+		quotient.Neg(quotient)
+	}
+	return quotient.Int64()
+}
+
+// RoundTo rounds the amount to less decimal places (i.e. 0 for currencies without minor units)
+func (m Money) RoundTo(decimals int, mode RoundingMode) Money {
+	if decimals >= Scale {
+		return m
+	}
+	step := int64(1)
+	for i := decimals; i < Scale; i++ {
+		step *= 10
+	}
+	value := new(big.Rat).SetFrac64(m.units, step)
+	return Money{units: roundRat(value, mode) * step}
+}
+
+// String formats the amount with two decimals: "-12.50"
+func (m Money) String() string {
+	units := m.units
+	sign := ""
+	if units < 0 {
+		sign = "-"
+	}
+	abs := new(big.Int).Abs(big.NewInt(units)).String()
+	if len(abs) <= Scale {
+		abs = strings.Repeat("0", Scale-len(abs)+1) + abs
+	}
+	return fmt.Sprintf("%s%s.%s", sign, abs[:len(abs)-Scale], abs[len(abs)-Scale:])
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.String())
+}
+
+// UnmarshalJSON accepts both "12.50" and 12.50. Amounts with more than two decimals are rejected.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	raw := strings.TrimSpace(string(data))
+	if raw == "null" {
+		return nil
+	}
+	if strings.HasPrefix(raw, `"`) {
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return err
+		}
+	}
+	parsed, err := Parse(raw)
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, raw)
+	}
+	*m = parsed
+	return nil
+}
+
+// Scan implements sql.Scanner for NUMERIC columns
+func (m *Money) Scan(src any) error {
+	switch value := src.(type) {
+	case nil:
+		*m = Zero
+		return nil
+	case []byte:
+		parsed, err := Parse(string(value))
+		if err != nil {
+			return err
+		}
+		*m = parsed
+		return nil
+	case string:
+		parsed, err := Parse(value)
+		if err != nil {
+			return err
+		}
+		*m = parsed
+		return nil
+	case int64:
+		*m = FromInt(value)
+		return nil
+	}
+	return fmt.Errorf("cannot scan %T into money.Money", src)
+}
+
+// Value implements driver.Valuer. The amount is sent as text, so Postgres reads the exact NUMERIC.
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+// NullMoney is a nullable amount, as sql.NullInt32 for integers
+type NullMoney struct {
+	Money Money
+	Valid bool
+}
+
+func (n *NullMoney) Scan(src any) error {
+	if src == nil {
+		n.Money, n.Valid = Zero, false
+		return nil
+	}
+	n.Valid = true
+	return n.Money.Scan(src)
+}
+
+func (n NullMoney) Value() (driver.Value, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+	return n.Money.Value()
+}
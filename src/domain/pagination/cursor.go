@@ -0,0 +1,50 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor is a keyset position on (created_at, id). Before tells whether the items wanted are
+// the ones preceding the position (prev_cursor) or following it (next_cursor), in the order of the listing.
+type Cursor struct {
+	CreatedAt time.Time
+	ID        int
+	Before    bool
+}
+
+// Encode returns the opaque token sent to the clients
+func (c Cursor) Encode() string {
+	direction := "n"
+	if c.Before {
+		direction = "p"
+	}
+	raw := direction + "|" + c.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + strconv.Itoa(c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeCursor parses a token returned by Encode
+func DecodeCursor(token string) (Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	parts := strings.Split(string(raw), "|")
+	if len(parts) != 3 || (parts[0] != "n" && parts[0] != "p") {
+		return Cursor{}, ErrInvalidCursor
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, parts[1])
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	id, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	return Cursor{CreatedAt: createdAt, ID: id, Before: parts[0] == "p"}, nil
+}
@@ -4,4 +4,6 @@ type Pagination[T any] struct {
 	LastPage int `json:"last_page"`
 	Count	int	`json:"count"`
 	Items []T	`json:"items"`
+	NextCursor *string `json:"next_cursor,omitempty"` // Opaque token of the following items, if any
+	PrevCursor *string `json:"prev_cursor,omitempty"` // Opaque token of the preceding items, if any
 }
\ No newline at end of file
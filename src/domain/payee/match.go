@@ -0,0 +1,179 @@
+package payeeentity
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Holder is the name of the holder of an account. Spanish holders have two surnames; the second one is
+// often left out, so a name with the first surname alone is a match.
+type Holder struct {
+	Name     string
+	Surname1 string
+	Surname2 string
+}
+
+// particles of the compound surnames ("de la Torre"), ignored when comparing
+var particles = map[string]bool{
+	"de": true, "del": true, "la": true, "las": true, "los": true, "y": true, "i": true,
+}
+
+var accents = strings.NewReplacer(
+	"á", "a", "à", "a", "ä", "a", "â", "a",
+	"é", "e", "è", "e", "ë", "e", "ê", "e",
+	"í", "i", "ì", "i", "ï", "i", "î", "i",
+	"ó", "o", "ò", "o", "ö", "o", "ô", "o",
+	"ú", "u", "ù", "u", "ü", "u", "û", "u",
+	"ñ", "n", "ç", "c",
+)
+
+// nameTokens lower cases the name, removes the accents and the particles, and splits it in words.
+// Hyphens, dots, commas and apostrophes separate words.
+func nameTokens(name string) []string {
+	name = accents.Replace(strings.ToLower(name))
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	tokens := make([]string, 0, len(words))
+	for _, word := range words {
+		if !particles[word] {
+			tokens = append(tokens, word)
+		}
+	}
+	return tokens
+}
+
+// Parts of the name of the holder
+const (
+	partName = iota
+	partSurname1
+	partSurname2
+)
+
+type holderToken struct {
+	value string
+	part  int
+	first bool // First word of the given name
+	used  bool
+}
+
+// MatchName compares the name typed by the payer with the holder of the account. The order of the words,
+// the case, the accents and the particles do not matter.
+//
+//   - MATCH: every word is a word of the holder, with the first given name and the first surname.
+//   - CLOSE_MATCH: a typo, an initial, a missing first surname or one extra word; the given name and a
+//     surname must still be there.
+//   - NO_MATCH: anything else.
+func MatchName(typed string, holder Holder) string {
+	typedTokens := nameTokens(typed)
+	if len(typedTokens) == 0 {
+		return ResultNoMatch
+	}
+	var holderTokens []*holderToken
+	for part, value := range []string{holder.Name, holder.Surname1, holder.Surname2} {
+		for i, token := range nameTokens(value) {
+			holderTokens = append(holderTokens, &holderToken{value: token, part: part, first: part == partName && i == 0})
+		}
+	}
+
+	// exact words first, so a typo cannot take the word another one matches exactly
+	var exact, fuzzy, unmatched int
+	covered := map[int]bool{}
+	firstNameExact := false
+	pending := make([]string, 0, len(typedTokens))
+	for _, token := range typedTokens {
+		if h := findToken(holderTokens, func(h *holderToken) bool { return h.value == token }); h != nil {
+			exact++
+			covered[h.part] = true
+			firstNameExact = firstNameExact || h.first
+			continue
+		}
+		pending = append(pending, token)
+	}
+	for _, token := range pending {
+		h := findToken(holderTokens, func(h *holderToken) bool {
+			if len([]rune(token)) == 1 {
+				return strings.HasPrefix(h.value, token)
+			}
+			return levenshtein(token, h.value) <= allowedTypos(h.value)
+		})
+		if h == nil {
+			unmatched++
+			continue
+		}
+		fuzzy++
+		covered[h.part] = true
+	}
+
+	if fuzzy == 0 && unmatched == 0 && firstNameExact && covered[partSurname1] {
+		return ResultMatch
+	}
+	if unmatched <= 1 && exact+fuzzy >= 2 && covered[partName] && (covered[partSurname1] || covered[partSurname2]) {
+		return ResultCloseMatch
+	}
+	return ResultNoMatch
+}
+
+func findToken(tokens []*holderToken, matches func(h *holderToken) bool) *holderToken {
+	for _, h := range tokens {
+		if !h.used && matches(h) {
+			h.used = true
+			return h
+		}
+	}
+	return nil
+}
+
+// allowedTypos is the edit distance accepted for a word: none for the short ones, where a typo is another name
+func allowedTypos(word string) int {
+	switch length := len([]rune(word)); {
+	case length <= 3:
+		return 0
+	case length <= 6:
+		return 1
+	default:
+		return 2
+	}
+}
+
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
+
+// MaskName returns the name of the holder with the first letter of every word, "J*** P**** G*****",
+// so a close match can be confirmed without disclosing the holder
+func MaskName(holder Holder) string {
+	full := strings.Join(strings.Fields(strings.Join([]string{holder.Name, holder.Surname1, holder.Surname2}, " ")), " ")
+	var masked strings.Builder
+	wordStart := true
+	for _, r := range full {
+		switch {
+		case !unicode.IsLetter(r):
+			masked.WriteRune(r)
+			wordStart = true
+		case wordStart:
+			masked.WriteRune(r)
+			wordStart = false
+		default:
+			masked.WriteRune('*')
+		}
+	}
+	return masked.String()
+}
@@ -0,0 +1,36 @@
+package payeeentity
+
+import (
+	"time"
+)
+
+// Results of a Confirmation of Payee check
+const (
+	ResultMatch        string = "MATCH"
+	ResultCloseMatch   string = "CLOSE_MATCH"
+	ResultNoMatch      string = "NO_MATCH"
+	ResultNotAvailable string = "NOT_AVAILABLE" // The account is not an account of the bank, its holder cannot be checked
+)
+
+// PayeeVerificationEntity represents the payee_verifications table: every check done by a client,
+// kept to enforce the rate limits and to trace who looked up which account
+type PayeeVerificationEntity struct {
+	ID            int       `json:"id" db:"id"`
+	ClientID      int       `json:"client_id" db:"client_id"`
+	AccountNumber string    `json:"account_number" db:"account_number"`
+	Name          string    `json:"name" db:"name"`
+	Result        string    `json:"result" db:"result"` // MATCH, CLOSE_MATCH, NO_MATCH, NOT_AVAILABLE
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+}
+
+// RateLimit allows Max checks per client within Window
+type RateLimit struct {
+	Max    int
+	Window time.Duration
+}
+
+// Default limits of the checks of a client, PAYEE_VERIFICATIONS_PER_HOUR and PAYEE_VERIFICATIONS_PER_DAY
+const (
+	DefaultVerificationsPerHour = 10
+	DefaultVerificationsPerDay  = 50
+)
@@ -0,0 +1,105 @@
+package paymententity
+
+import (
+	"database/sql"
+	"slices"
+	"src/domain/money"
+	"time"
+)
+
+// Statuses of an inbound payment
+const (
+	InboundStatusCredited  string = "CREDITED"  // Posted to the account of the creditor
+	InboundStatusException string = "EXCEPTION" // Posted to the suspense account, waiting for bank staff
+	InboundStatusResolved  string = "RESOLVED"  // Moved from suspense to the right account
+	InboundStatusReturned  string = "RETURNED"  // Sent back to the bank of the debtor from suspense
+)
+
+// Why an inbound payment went to suspense
+const (
+	ExceptionAccountNotFound  string = "ACCOUNT_NOT_FOUND"  // No client account with the creditor IBAN
+	ExceptionAccountNotActive string = "ACCOUNT_NOT_ACTIVE" // The account is closed or frozen
+)
+
+// Messages an inbound payment can come in
+const (
+	MessagePacs008 string = "pacs.008" // FI to FI customer credit transfer
+	MessageCamt054 string = "camt.054" // Debit/credit notification
+)
+
+// ISO 20022 return reasons (pacs.004) accepted when an inbound payment is returned
+var ReturnReasonCodes = []string{
+	"AC01", // Incorrect account number
+	"AC04", // Closed account number
+	"AC06", // Blocked account
+	"AG01", // Transaction forbidden
+	"AM05", // Duplication
+	"BE04", // Missing creditor address
+	"MD07", // End customer deceased
+	"MS02", // Not specified reason, customer generated
+	"MS03", // Not specified reason, agent generated
+}
+
+func IsValidReturnReason(code string) bool {
+	return slices.Contains(ReturnReasonCodes, code)
+}
+
+// DefaultReturnReason is the reason sent back when bank staff returns an exception without one
+func DefaultReturnReason(exceptionReason string) string {
+	if exceptionReason == ExceptionAccountNotActive {
+		return "AC04"
+	}
+	return "AC01"
+}
+
+// InboundPaymentEntity represents the inbound_payments table: a credit transfer received from another bank.
+// The settlement account is debited when it is read, and the creditor credited, or the suspense account when
+// the creditor cannot take it.
+type InboundPaymentEntity struct {
+	ID                    int            `json:"id" db:"id"`
+	MessageID             string         `json:"message_id" db:"message_id"`     // MsgId of the file
+	MessageType           string         `json:"message_type" db:"message_type"` // pacs.008, camt.054
+	Sequence              int            `json:"sequence" db:"sequence"`         // Position in the file, from 1
+	EndToEndID            string         `json:"end_to_end_id" db:"end_to_end_id"`
+	Reference             sql.NullString `json:"reference" db:"reference"` // TxId (pacs.008) or AcctSvcrRef (camt.054)
+	DebtorIban            sql.NullString `json:"debtor_iban" db:"debtor_iban"`
+	DebtorName            sql.NullString `json:"debtor_name" db:"debtor_name"`
+	CreditorIban          string         `json:"creditor_iban" db:"creditor_iban"`
+	CreditorName          sql.NullString `json:"creditor_name" db:"creditor_name"`
+	Amount                money.Money    `json:"amount" db:"amount"`
+	Currency              string         `json:"currency" db:"currency"`
+	RemittanceInformation sql.NullString `json:"remittance_information" db:"remittance_information"`
+	Status                string         `json:"status" db:"status"`                     // CREDITED, EXCEPTION, RESOLVED, RETURNED
+	ExceptionReason       sql.NullString `json:"exception_reason" db:"exception_reason"` // ACCOUNT_NOT_FOUND, ACCOUNT_NOT_ACTIVE
+	AccountID             sql.NullInt32  `json:"account_id" db:"account_id"`             // Credited account, once credited or resolved
+	TransactionID         int            `json:"transaction_id" db:"transaction_id"`     // Credit to the account or to suspense
+	ResolutionID          sql.NullInt32  `json:"resolution_transaction_id" db:"resolution_transaction_id"`
+	ReturnReason          sql.NullString `json:"return_reason" db:"return_reason"`         // ISO reason code of a return, i.e. AC01
+	ReturnMessageID       sql.NullString `json:"return_message_id" db:"return_message_id"` // MsgId of the pacs.004, once sent
+	CreatedAt             time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt             time.Time      `json:"updated_at" db:"updated_at"`
+}
+
+// InboundFile is a pacs.008 or camt.054 message received from the clearing house
+type InboundFile struct {
+	Name        string // Where it was read from, i.e. the file name
+	MessageID   string
+	MessageType string
+	Payments    []InboundPaymentEntity
+}
+
+// ReturnBatch is a pacs.004 message: the inbound payments sent back together
+type ReturnBatch struct {
+	MessageID string
+	CreatedAt time.Time
+	Payments  []InboundPaymentEntity
+}
+
+// Total is the amount returned by the batch
+func (b ReturnBatch) Total() money.Money {
+	total := money.Zero
+	for _, payment := range b.Payments {
+		total = total.Add(payment.Amount)
+	}
+	return total
+}
@@ -0,0 +1,99 @@
+package paymententity
+
+import (
+	"database/sql"
+	"src/domain/money"
+	"time"
+)
+
+// Statuses of an outbound payment
+const (
+	StatusPending   string = "PENDING"   // Debited, waiting for the next batch
+	StatusSubmitted string = "SUBMITTED" // Sent to the clearing house in a pain.001 batch
+	StatusSettled   string = "SETTLED"   // Accepted and settled (ACSC)
+	StatusRejected  string = "REJECTED"  // Rejected (RJCT), the client is refunded
+)
+
+// SEPA credit transfers are in euros only
+const SepaCurrency = "EUR"
+
+// Text limits of the pain.001 fields
+const (
+	MaxCreditorNameLength = 70
+	MaxRemittanceLength   = 140
+	MaxIdentifierLength   = 35 // MsgId, PmtInfId, EndToEndId
+)
+
+// OutboundPaymentEntity represents the outbound_payments table: a SEPA credit transfer to an IBAN of another bank.
+// The client is debited when it is created, and the amount waits in the SEPA clearing account until the
+// clearing house settles or rejects it.
+type OutboundPaymentEntity struct {
+	ID                    int            `json:"id" db:"id"`
+	AccountID             int            `json:"account_id" db:"account_id"`
+	TransactionID         int            `json:"transaction_id" db:"transaction_id"`                       // Debit of the client
+	SettlementID          sql.NullInt32  `json:"settlement_transaction_id" db:"settlement_transaction_id"` // Clearing to settlement account, once settled
+	RefundID              sql.NullInt32  `json:"refund_transaction_id" db:"refund_transaction_id"`         // Reversal of the debit, once rejected
+	EndToEndID            string         `json:"end_to_end_id" db:"end_to_end_id"`
+	DebtorIban            string         `json:"debtor_iban" db:"debtor_iban"`
+	DebtorName            string         `json:"debtor_name" db:"debtor_name"`
+	CreditorIban          string         `json:"creditor_iban" db:"creditor_iban"`
+	CreditorName          string         `json:"creditor_name" db:"creditor_name"`
+	Amount                money.Money    `json:"amount" db:"amount"`
+	Currency              string         `json:"currency" db:"currency"`
+	RemittanceInformation sql.NullString `json:"remittance_information" db:"remittance_information"`
+	Status                string         `json:"status" db:"status"`                     // PENDING, SUBMITTED, SETTLED, REJECTED
+	BatchMessageID        sql.NullString `json:"batch_message_id" db:"batch_message_id"` // MsgId of the pain.001
+	StatusReason          sql.NullString `json:"status_reason" db:"status_reason"`       // ISO reason code of a rejection, i.e. AC01
+	CreatedAt             time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt             time.Time      `json:"updated_at" db:"updated_at"`
+}
+
+// PaymentBatch is a pain.001 message: the payments submitted together
+type PaymentBatch struct {
+	MessageID string
+	CreatedAt time.Time
+	Payments  []OutboundPaymentEntity
+}
+
+// Total is the control sum of the batch
+func (b PaymentBatch) Total() money.Money {
+	total := money.Zero
+	for _, payment := range b.Payments {
+		total = total.Add(payment.Amount)
+	}
+	return total
+}
+
+// Transaction statuses of a pain.002 status report. The others (ACCP, ACTC, PDNG...) leave the payment submitted
+const (
+	ReportSettled         string = "ACSC" // Settled on the debtor side: the payment is final
+	ReportSettledCreditor string = "ACCC" // Settled on the creditor side
+	ReportRejected        string = "RJCT"
+)
+
+// PaymentStatus is the status of one payment in a pain.002 status report
+type PaymentStatus struct {
+	EndToEndID string
+	Status     string // ACSC, RJCT, ...
+	Reason     string // ISO reason code of a rejection
+}
+
+// IsSettled tells whether the status makes the payment final
+func (s PaymentStatus) IsSettled() bool {
+	return s.Status == ReportSettled || s.Status == ReportSettledCreditor
+}
+
+func (s PaymentStatus) IsRejected() bool {
+	return s.Status == ReportRejected
+}
+
+// StatusReport is a pain.002 message about a batch
+type StatusReport struct {
+	Name              string // Where it was read from, i.e. the file name
+	MessageID         string
+	OriginalMessageID string
+	// GroupStatus applies to the payments of the batch without a status of their own
+	GroupStatus string
+	GroupReason string
+	Payments    []PaymentStatus
+}
@@ -0,0 +1,59 @@
+package reconciliationentity
+
+import (
+	"src/domain/money"
+	"time"
+)
+
+// BalanceDiscrepancy is an account whose stored balance is not the sum of its ledger entries
+type BalanceDiscrepancy struct {
+	AccountID     int         `json:"account_id"`
+	LedgerBalance money.Money `json:"ledger_balance"` // Credits minus debits of ledger_entries
+	StoredBalance money.Money `json:"stored_balance"` // account_balances.balance
+	Difference    money.Money `json:"difference"`     // StoredBalance - LedgerBalance
+}
+
+// HeldAmountDiscrepancy is an account whose held amount is not the sum of its authorized holds
+type HeldAmountDiscrepancy struct {
+	AccountID        int         `json:"account_id"`
+	AuthorizedHolds  money.Money `json:"authorized_holds"`
+	StoredHeldAmount money.Money `json:"stored_held_amount"` // account_balances.held_amount
+}
+
+// UnbalancedTransaction is a transaction whose debits are not equal to its credits in a currency
+type UnbalancedTransaction struct {
+	TransactionID int         `json:"transaction_id"`
+	Type          string      `json:"type"`
+	Currency      string      `json:"currency"`
+	Debits        money.Money `json:"debits"`
+	Credits       money.Money `json:"credits"`
+}
+
+// InternalAccountBalance is the balance of a bank owned account
+type InternalAccountBalance struct {
+	Code      string      `json:"code"`
+	Currency  string      `json:"currency"`
+	AccountID int         `json:"account_id"`
+	Balance   money.Money `json:"balance"`
+}
+
+// Report is the outcome of a reconciliation run. All the figures come from the same database snapshot.
+type Report struct {
+	GeneratedAt               time.Time                `json:"generated_at"`
+	AccountsChecked           int                      `json:"accounts_checked"`
+	TransactionsChecked       int                      `json:"transactions_checked"`
+	LedgerTotal               money.Money              `json:"ledger_total"` // Credits minus debits of every entry. Zero in a double-entry ledger
+	InternalAccounts          []InternalAccountBalance `json:"internal_accounts"`
+	BalanceDiscrepancies      []BalanceDiscrepancy     `json:"balance_discrepancies"`
+	HeldAmountDiscrepancies   []HeldAmountDiscrepancy  `json:"held_amount_discrepancies"`
+	UnbalancedTransactions    []UnbalancedTransaction  `json:"unbalanced_transactions"`
+	MaterializedViewRefreshed bool                     `json:"materialized_view_refreshed"`
+}
+
+// IsClean tells if no discrepancy has been found
+func (r Report) IsClean() bool {
+	return r.LedgerTotal.IsZero() &&
+		len(r.BalanceDiscrepancies) == 0 &&
+		len(r.HeldAmountDiscrepancies) == 0 &&
+		len(r.UnbalancedTransactions) == 0
+}
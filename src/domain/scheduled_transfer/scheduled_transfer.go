@@ -0,0 +1,115 @@
+package scheduledtransferentity
+
+import (
+	"database/sql"
+	"slices"
+	"src/domain/money"
+	"time"
+)
+
+// Frequencies of a standing order
+const (
+	FrequencyDaily      string = "DAILY"
+	FrequencyWeekly     string = "WEEKLY"
+	FrequencyMonthly    string = "MONTHLY"      // Same day of the month as the start date, or the last day of shorter months
+	FrequencyEndOfMonth string = "END_OF_MONTH" // Last day of every month
+)
+
+var Frequencies = []string{FrequencyDaily, FrequencyWeekly, FrequencyMonthly, FrequencyEndOfMonth}
+
+// Standing order statuses
+const (
+	StatusActive    string = "ACTIVE"
+	StatusFinished  string = "FINISHED" // The end date has been reached
+	StatusCancelled string = "CANCELLED"
+)
+
+// Run statuses
+const (
+	RunSucceeded string = "SUCCEEDED"
+	RunFailed    string = "FAILED"
+)
+
+func IsValidFrequency(frequency string) bool {
+	return slices.Contains(Frequencies, frequency)
+}
+
+// ScheduledTransferEntity represents the scheduled_transfers table in the database.
+// Dates are calendar days at 00:00 UTC.
+type ScheduledTransferEntity struct {
+	ID              int            `json:"id" db:"id"`
+	AccountID       int            `json:"account_id" db:"account_id"`
+	ToAccountID     int            `json:"to_account_id" db:"to_account_id"`
+	ToAccountNumber string         `json:"to_account_number" db:"to_account_number"`
+	Amount          money.Money    `json:"amount" db:"amount"`
+	Frequency       string         `json:"frequency" db:"frequency"` // DAILY, WEEKLY, MONTHLY, END_OF_MONTH
+	StartDate       time.Time      `json:"start_date" db:"start_date"`
+	EndDate         sql.NullTime   `json:"end_date" db:"end_date"`           // Nullable: no end
+	NextRunDate     sql.NullTime   `json:"next_run_date" db:"next_run_date"` // Nullable: finished or cancelled
+	Status          string         `json:"status" db:"status"`               // ACTIVE, FINISHED, CANCELLED
+	Reference       sql.NullString `json:"reference" db:"reference"`
+	CreatedAt       time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at" db:"updated_at"`
+}
+
+// ScheduledTransferRunEntity represents the scheduled_transfer_runs table in the database
+type ScheduledTransferRunEntity struct {
+	ID                  int            `json:"id" db:"id"`
+	ScheduledTransferID int            `json:"scheduled_transfer_id" db:"scheduled_transfer_id"`
+	RunDate             time.Time      `json:"run_date" db:"run_date"`
+	Status              string         `json:"status" db:"status"` // SUCCEEDED, FAILED
+	FailureReason       sql.NullString `json:"failure_reason" db:"failure_reason"`
+	TransactionID       sql.NullInt32  `json:"transaction_id" db:"transaction_id"`
+	CreatedAt           time.Time      `json:"created_at" db:"created_at"`
+}
+
+// Date truncates t to its calendar day in UTC
+func Date(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Today is the current calendar day in UTC
+func Today() time.Time {
+	return Date(time.Now().UTC())
+}
+
+// endOfMonth returns the last day of the month of t
+func endOfMonth(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, time.UTC)
+}
+
+// addMonthsClamped moves t n months keeping the given day, or the last day of shorter months
+func addMonthsClamped(t time.Time, n int, day int) time.Time {
+	firstOfMonth := time.Date(t.Year(), t.Month()+time.Month(n), 1, 0, 0, 0, 0, time.UTC)
+	last := endOfMonth(firstOfMonth).Day()
+	return time.Date(firstOfMonth.Year(), firstOfMonth.Month(), min(day, last), 0, 0, 0, 0, time.UTC)
+}
+
+// FirstRunDate is the first execution date on or after the start date
+func (s ScheduledTransferEntity) FirstRunDate() time.Time {
+	start := Date(s.StartDate)
+	if s.Frequency == FrequencyEndOfMonth {
+		return endOfMonth(start)
+	}
+	return start
+}
+
+// RunDateAfter returns the execution date that follows runDate
+func (s ScheduledTransferEntity) RunDateAfter(runDate time.Time) time.Time {
+	runDate = Date(runDate)
+	switch s.Frequency {
+	case FrequencyDaily:
+		return runDate.AddDate(0, 0, 1)
+	case FrequencyWeekly:
+		return runDate.AddDate(0, 0, 7)
+	case FrequencyEndOfMonth:
+		return endOfMonth(addMonthsClamped(runDate, 1, 1))
+	}
+	// MONTHLY: 31 January, 28 February, 31 March...
+	return addMonthsClamped(runDate, 1, Date(s.StartDate).Day())
+}
+
+// IsWithinEndDate tells if runDate is not after the end date of the order
+func (s ScheduledTransferEntity) IsWithinEndDate(runDate time.Time) bool {
+	return !s.EndDate.Valid || !Date(runDate).After(Date(s.EndDate.Time))
+}
@@ -0,0 +1,29 @@
+package statemententity
+
+import (
+	"src/domain/money"
+	"time"
+)
+
+// Formats of the statements
+const (
+	FormatCSV     string = "csv"
+	FormatOFX     string = "ofx"     // OFX 2.2, XML
+	FormatCamt053 string = "camt053" // ISO 20022 camt.053.001.02, bank to customer statement
+)
+
+var Formats = []string{FormatCSV, FormatOFX, FormatCamt053}
+
+// StatementEntity is the header of the statement of an account between two instants. The movements
+// are the ledger entries created between From (included) and To (excluded).
+type StatementEntity struct {
+	AccountID      int
+	Iban           string
+	Currency       string
+	OwnerName      string // Empty for internal accounts
+	From           time.Time
+	To             time.Time
+	OpeningBalance money.Money // Balance at From
+	ClosingBalance money.Money // Balance at To
+	CreatedAt      time.Time
+}
@@ -0,0 +1,35 @@
+package transaction_entity
+
+import (
+	"slices"
+	"src/domain/money"
+	"time"
+)
+
+// Sort orders of the transaction history, on (created_at, id)
+const (
+	SortAsc  string = "asc"
+	SortDesc string = "desc"
+)
+
+var Types = []string{"ADD", "WITHDRAWAL", "TRANSFER", ReversalType, DebitInterestType, CreditInterestType, SepaTransferType}
+
+func IsValidType(transactionType string) bool {
+	return slices.Contains(Types, transactionType)
+}
+
+// TransactionFilter narrows the transaction history of an account. Zero values do not filter.
+type TransactionFilter struct {
+	From             *time.Time   // created_at >= From
+	To               *time.Time   // created_at < To
+	Type             string       // ADD, WITHDRAWAL, TRANSFER, REVERSAL, DEBIT_INTEREST
+	MinAmount        *money.Money // Inclusive
+	MaxAmount        *money.Money // Inclusive
+	CounterpartyIban string       // to_account_number of the transfers
+	Sort             string       // asc, desc (default)
+}
+
+// IsAscending tells whether the oldest transactions come first
+func (f TransactionFilter) IsAscending() bool {
+	return f.Sort == SortAsc
+}
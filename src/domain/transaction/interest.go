@@ -0,0 +1,9 @@
+package transaction_entity
+
+// DebitInterestType is the overdraft interest charged by the bank. It debits the account and
+// credits the interest income account of its currency.
+const DebitInterestType string = "DEBIT_INTEREST"
+
+// CreditInterestType is the interest paid by the bank on the positive balances, once a month. It debits
+// the interest expense account of the currency and credits the account.
+const CreditInterestType string = "CREDIT_INTEREST"
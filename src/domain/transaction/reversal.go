@@ -0,0 +1,30 @@
+package transaction_entity
+
+import "slices"
+
+const ReversalType string = "REVERSAL"
+
+// Reason codes of a reversal
+const (
+	ReversalReasonDuplicate       string = "DUPLICATE"
+	ReversalReasonFraud           string = "FRAUD"
+	ReversalReasonCustomerRequest string = "CUSTOMER_REQUEST"
+	ReversalReasonTechnicalError  string = "TECHNICAL_ERROR"
+	ReversalReasonWrongAmount     string = "WRONG_AMOUNT"
+)
+
+var ReversalReasonCodes = []string{
+	ReversalReasonDuplicate,
+	ReversalReasonFraud,
+	ReversalReasonCustomerRequest,
+	ReversalReasonTechnicalError,
+	ReversalReasonWrongAmount,
+}
+
+// ReversalReasonPaymentRejected refunds a SEPA transfer rejected by the clearing house. It is set by the
+// bank only, so it is not one of the ReversalReasonCodes
+const ReversalReasonPaymentRejected string = "PAYMENT_REJECTED"
+
+func IsValidReversalReasonCode(code string) bool {
+	return slices.Contains(ReversalReasonCodes, code)
+}
@@ -0,0 +1,16 @@
+package transaction_entity
+
+// SepaTransferType is a credit transfer to an IBAN of another bank. It debits the account and credits
+// the SEPA clearing account until the clearing house settles the payment.
+const SepaTransferType string = "SEPA_TRANSFER"
+
+// SepaSettlementType moves a settled payment from the SEPA clearing account to the settlement account
+const SepaSettlementType string = "SEPA_SETTLEMENT"
+
+// SepaCreditType is a credit transfer received from another bank: the settlement account is debited and the
+// beneficiary credited, or the suspense account when the beneficiary cannot take it. Releasing a credit from
+// suspense to the right account is a SEPA_CREDIT as well.
+const SepaCreditType string = "SEPA_CREDIT"
+
+// SepaReturnType sends a received credit back to the bank of the debtor, from the suspense account
+const SepaReturnType string = "SEPA_RETURN"
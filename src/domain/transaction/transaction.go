@@ -3,18 +3,29 @@ package transaction_entity // Adjust package name as needed (e.g., internal/mode
 import (
     "time"
 	"database/sql"
+	feeentity "src/domain/fee"
+	"src/domain/money"
 )
 
 // Transaction represents the transactions table in the database.
 type TransactionEntity struct {
     ID           int       `json:"id" db:"id"`
     AccountID    int       `json:"account_id" db:"account_id"`
-    Type         string    `json:"type" db:"type"`   // ADD, WITHDRAWAL, TRANSFER
-    Amount       float64   `json:"amount" db:"amount"`
+    Type         string    `json:"type" db:"type"`   // ADD, WITHDRAWAL, TRANSFER, REVERSAL
+    Amount       money.Money `json:"amount" db:"amount"`
     ToAccountID  sql.NullInt32      `json:"to_account_id" db:"to_account_id"` // Nullable
     CreatedAt    time.Time `json:"created_at" db:"created_at"`
     UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
     ToAccountNumber sql.NullString `json:"to_account_number" db:"to_account_number"`
+    ReversalOf   sql.NullInt32  `json:"reversal_of" db:"reversal_of"` // Transaction reversed by this one
+    ReasonCode   sql.NullString `json:"reason_code" db:"reason_code"`
+    HoldID       sql.NullInt32  `json:"hold_id" db:"hold_id"` // Hold captured by this transaction
+    FxRate       sql.NullString  `json:"fx_rate" db:"fx_rate"` // Transfers between currencies: units of the destination currency per unit of the source one
+    ToAmount     money.NullMoney `json:"to_amount" db:"to_amount"` // Transfers between currencies: amount credited in the destination currency
+    FeeAmount    money.Money     `json:"fee_amount" db:"fee_amount"` // Fee charged to the account on top of the amount
+    Fees         []feeentity.FeeEntity `json:"fees" db:"-"` // Components of the fee. Only loaded when the transaction is posted
+    BeneficiaryID sql.NullInt32 `json:"beneficiary_id" db:"beneficiary_id"` // Saved beneficiary the transfer was sent to
+    ToIban       sql.NullString `json:"to_iban" db:"to_iban"` // IBAN the transfer was sent to, in electronic format. Set when it is posted
 }
 
 
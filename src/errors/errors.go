@@ -3,7 +3,10 @@ package app_errors
 import (
 	"fmt"
 	"github.com/gin-gonic/gin"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 )
 
 /**
@@ -38,6 +41,10 @@ func (e *ErrInternalServer) Error() string {
 	return "Internal Server Error"
 }
 
+func (e *ErrInternalServer) Unwrap() error {
+	return e.Reason
+}
+
 func (e *ErrInternalServer) JsonError(c *gin.Context) {
 	c.JSON(http.StatusInternalServerError, gin.H{"error": e.Error()})
 }
@@ -67,6 +74,19 @@ func (e *ErrNotEnoughFunds) JsonError(c *gin.Context) {
 	c.JSON(http.StatusConflict, gin.H{"error": e.Error(), "message": e.Message})
 }
 
+// ErrAccountNotActive is returned when the status of an account (frozen, dormant, closed) does not allow the operation
+type ErrAccountNotActive struct {
+	Message string
+}
+
+func (e *ErrAccountNotActive) Error() string {
+	return "account is not active"
+}
+
+func (e *ErrAccountNotActive) JsonError(c *gin.Context) {
+	c.JSON(http.StatusConflict, gin.H{"error": e.Error(), "message": e.Message})
+}
+
 type ErrUnauthorized struct {
 	Reason  error
 	Message string
@@ -134,3 +154,33 @@ func (e *ErrVerifyToken) JsonError(c *gin.Context) {
 }
 
 
+
+type ErrConflict struct {
+	Reason  error
+	Message string
+}
+
+func (e *ErrConflict) Error() string {
+	return "conflict"
+}
+
+func (e *ErrConflict) JsonError(c *gin.Context) {
+	c.JSON(http.StatusConflict, gin.H{"error": e.Error(), "message": e.Message})
+}
+
+// ErrTooManyRequests is returned when a client goes over a rate limit. RetryAfter is sent in the Retry-After header
+type ErrTooManyRequests struct {
+	Message    string
+	RetryAfter time.Duration
+}
+
+func (e *ErrTooManyRequests) Error() string {
+	return "too many requests"
+}
+
+func (e *ErrTooManyRequests) JsonError(c *gin.Context) {
+	if e.RetryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(e.RetryAfter.Seconds()))))
+	}
+	c.JSON(http.StatusTooManyRequests, gin.H{"error": e.Error(), "message": e.Message})
+}
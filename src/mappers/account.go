@@ -34,7 +34,7 @@ func ToAccountEntity(account accountdto.AccountDto) (accountentity.AccountEntity
 	return entity, nil
 }
 
-func ToAccountDTO(entity accountentity.AccountEntity, balance *float64) accountdto.AccountDto {
+func ToAccountDTO(entity accountentity.AccountEntity, balance *accountentity.AccountBalance) accountdto.AccountDto {
 	var dto accountdto.AccountDto
 
 	// Formatear CreatedAt
@@ -47,10 +47,42 @@ func ToAccountDTO(entity accountentity.AccountEntity, balance *float64) accountd
 	// Asignar campos directos
 	dto.ID = entity.ID
 	dto.AccountNumber = entity.AccountNumber
+	dto.Currency = entity.Currency
+	dto.Product = entity.Product
 	dto.ClientID = entity.ClientID
+	dto.Status = entity.Status
+	if entity.StatusReason.Valid {
+		dto.StatusReason = &entity.StatusReason.String
+	}
 	if balance != nil {
-		dto.Balance = *balance
+		dto.Balance = balance.Balance
+		dto.AvailableBalance = balance.Available()
+		dto.OverdraftLimit = balance.OverdraftLimit
+		dto.OverdraftHeadroom = balance.OverdraftHeadroom()
 	}
 
 	return dto
 }
+
+func ToAccountStatusChangeDto(entity accountentity.AccountStatusChangeEntity) accountdto.AccountStatusChangeDto {
+	dto := accountdto.AccountStatusChangeDto{
+		ID:          entity.ID,
+		AccountID:   entity.AccountID,
+		FromStatus:  entity.FromStatus,
+		ToStatus:    entity.ToStatus,
+		Reason:      entity.Reason,
+		CreatedDate: entity.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+	if entity.SettlementTransactionID.Valid {
+		id := int(entity.SettlementTransactionID.Int32)
+		dto.SettlementTransactionID = &id
+	}
+	return dto
+}
+
+func ToBalancePointDto(entity accountentity.BalanceSnapshotEntity) accountdto.BalancePointDto {
+	return accountdto.BalancePointDto{
+		Date:    entity.SnapshotDate.Format(time.DateOnly),
+		Balance: entity.Balance,
+	}
+}
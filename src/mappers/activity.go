@@ -0,0 +1,40 @@
+package mappers
+
+import (
+	dto "src/api/dto"
+	ledgerentity "src/domain/ledger"
+	pagination "src/domain/pagination"
+)
+
+func ToActivityEntryDto(entity ledgerentity.ActivityEntryEntity) dto.ActivityEntryDto {
+	entry := dto.ActivityEntryDto{
+		EntryID:         entity.EntryID,
+		TransactionID:   entity.TransactionID,
+		TransactionType: entity.TransactionType,
+		Direction:       entity.Direction,
+		Kind:            entity.Kind,
+		Amount:          entity.Amount,
+		BalanceAfter:    entity.BalanceAfter,
+		CreatedAt:       entity.CreatedAt,
+	}
+	if entity.CounterpartyIban.Valid {
+		entry.CounterpartyIban = &entity.CounterpartyIban.String
+	}
+	if entity.CounterpartyName.Valid {
+		entry.CounterpartyName = &entity.CounterpartyName.String
+	}
+	return entry
+}
+
+func ToPaginationActivityEntryDto(activity pagination.Pagination[ledgerentity.ActivityEntryEntity]) pagination.Pagination[dto.ActivityEntryDto] {
+	items := make([]dto.ActivityEntryDto, 0, len(activity.Items))
+	for _, entity := range activity.Items {
+		items = append(items, ToActivityEntryDto(entity))
+	}
+	return pagination.Pagination[dto.ActivityEntryDto]{
+		Page:     activity.Page,
+		LastPage: activity.LastPage,
+		Count:    activity.Count,
+		Items:    items,
+	}
+}
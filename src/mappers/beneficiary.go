@@ -0,0 +1,28 @@
+package mappers
+
+import (
+	dto "src/api/dto"
+	beneficiaryentity "src/domain/beneficiary"
+	"time"
+)
+
+func ToBeneficiaryDto(entity beneficiaryentity.BeneficiaryEntity) dto.BeneficiaryDto {
+	beneficiary := dto.BeneficiaryDto{
+		ID:              entity.ID,
+		ClientID:        entity.ClientID,
+		Nickname:        entity.Nickname,
+		Iban:            entity.Iban,
+		CoolingOff:      entity.IsCoolingOff(time.Now()),
+		CoolingOffUntil: entity.CoolingOffUntil,
+		CoolingOffLimit: entity.CoolingOffLimit,
+		CreatedAt:       entity.CreatedAt,
+		UpdatedAt:       entity.UpdatedAt,
+	}
+	if entity.Name.Valid {
+		beneficiary.Name = &entity.Name.String
+	}
+	if entity.Reference.Valid {
+		beneficiary.Reference = &entity.Reference.String
+	}
+	return beneficiary
+}
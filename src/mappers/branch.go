@@ -0,0 +1,20 @@
+package mappers
+
+import (
+	dto "src/api/dto"
+	branchentity "src/domain/branch"
+)
+
+func ToBranchDto(entity branchentity.BranchUsage) dto.BranchDto {
+	return dto.BranchDto{
+		Code:      entity.Code,
+		Name:      entity.Name,
+		Accounts:  entity.Accounts,
+		Allocated: entity.LastSequence,
+		Capacity:  branchentity.AccountNumberCapacity,
+		Available: entity.Available(),
+		Usage:     entity.Usage(),
+		CreatedAt: entity.CreatedAt,
+		UpdatedAt: entity.UpdatedAt,
+	}
+}
@@ -48,6 +48,10 @@ func ToClientEntity(client clientdto.CreateClientRequest) (cliententity.ClientEn
 		entity.State = sql.NullString{Valid: false}
 	}
 
+	if client.BranchCode != "" {
+		entity.BranchCode = sql.NullString{String: client.BranchCode, Valid: true}
+	}
+
 	// Asignar ID (en un caso real, esto probablemente se generaría en la base de datos
 	// después de la inserción, o sería un UUID generado aquí si tu DB lo soporta).
 	// Para este ejemplo, lo inicializamos en 0.
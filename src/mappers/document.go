@@ -0,0 +1,28 @@
+package mappers
+
+import (
+	dto "src/api/dto"
+	documententity "src/domain/document"
+	"time"
+)
+
+func ToDocumentVerificationDto(entity documententity.DocumentEntity) dto.DocumentVerificationDto {
+	verification := dto.DocumentVerificationDto{
+		Valid:            true,
+		VerificationCode: entity.VerificationCode,
+		Type:             entity.Type,
+		Iban:             documententity.MaskIban(entity.Iban),
+		HolderName:       entity.HolderName,
+		Sha256:           entity.Sha256,
+		IssuedAt:         entity.CreatedAt,
+	}
+	if entity.PeriodFrom.Valid {
+		from := entity.PeriodFrom.Time.Format(time.DateOnly)
+		verification.PeriodFrom = &from
+	}
+	if entity.PeriodTo.Valid {
+		to := entity.PeriodTo.Time.Format(time.DateOnly)
+		verification.PeriodTo = &to
+	}
+	return verification
+}
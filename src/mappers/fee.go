@@ -0,0 +1,37 @@
+package mappers
+
+import (
+	dto "src/api/dto"
+	accountentity "src/domain/account"
+	feeentity "src/domain/fee"
+)
+
+func ToProductDto(entity accountentity.ProductEntity) dto.ProductDto {
+	return dto.ProductDto{Code: entity.Code, Name: entity.Name}
+}
+
+func ToFeeDto(entity feeentity.FeeEntity) dto.FeeDto {
+	return dto.FeeDto{Kind: entity.Kind, Amount: entity.Amount}
+}
+
+func ToFeeScheduleDto(entity feeentity.FeeScheduleEntity) dto.FeeScheduleDto {
+	schedule := dto.FeeScheduleDto{
+		ID:              entity.ID,
+		TransactionType: entity.TransactionType,
+		Currency:        entity.Currency,
+		FixedAmount:     entity.FixedAmount,
+		Percentage:      entity.Percentage,
+		CreatedAt:       entity.CreatedAt,
+		UpdatedAt:       entity.UpdatedAt,
+	}
+	if entity.Product.Valid {
+		schedule.Product = &entity.Product.String
+	}
+	if entity.MinPercentageAmount.Valid {
+		schedule.MinPercentageAmount = &entity.MinPercentageAmount.Money
+	}
+	if entity.MaxPercentageAmount.Valid {
+		schedule.MaxPercentageAmount = &entity.MaxPercentageAmount.Money
+	}
+	return schedule
+}
@@ -0,0 +1,27 @@
+package mappers
+
+import (
+	dto "src/api/dto"
+	holdentity "src/domain/hold"
+)
+
+func ToHoldDto(entity holdentity.HoldEntity) dto.HoldDto {
+	hold := dto.HoldDto{
+		ID:             entity.ID,
+		AccountID:      entity.AccountID,
+		Amount:         entity.Amount,
+		CapturedAmount: entity.CapturedAmount,
+		Status:         entity.Status,
+		ExpiresAt:      entity.ExpiresAt,
+		CreatedAt:      entity.CreatedAt,
+		UpdatedAt:      entity.UpdatedAt,
+	}
+	if entity.ToAccountID.Valid {
+		toAccountID := int(entity.ToAccountID.Int32)
+		hold.ToAccountID = &toAccountID
+	}
+	if entity.Reference.Valid {
+		hold.Reference = &entity.Reference.String
+	}
+	return hold
+}
@@ -0,0 +1,29 @@
+package mappers
+
+import (
+	dto "src/api/dto"
+	interestentity "src/domain/interest"
+	"time"
+)
+
+func ToInterestRateDto(entity interestentity.InterestRateEntity) dto.InterestRateDto {
+	return dto.InterestRateDto{
+		ID:            entity.ID,
+		Product:       entity.Product,
+		Currency:      entity.Currency,
+		Rate:          entity.Rate,
+		DayCount:      entity.DayCount,
+		EffectiveFrom: entity.EffectiveFrom.Format(time.DateOnly),
+		CreatedAt:     entity.CreatedAt,
+	}
+}
+
+func ToInterestAccrualDto(entity interestentity.InterestAccrualEntity) dto.InterestAccrualDto {
+	return dto.InterestAccrualDto{
+		AccrualDate:  entity.AccrualDate.Format(time.DateOnly),
+		Balance:      entity.Balance,
+		InterestRate: entity.InterestRate,
+		DayCount:     entity.DayCount,
+		Amount:       entity.Amount,
+	}
+}
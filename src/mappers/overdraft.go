@@ -0,0 +1,23 @@
+package mappers
+
+import (
+	dto "src/api/dto"
+	accountentity "src/domain/account"
+	"time"
+)
+
+func ToOverdraftDto(entity accountentity.OverdraftEntity) dto.OverdraftDto {
+	overdraft := dto.OverdraftDto{
+		AccountID:    entity.AccountID,
+		Limit:        entity.Limit,
+		InterestRate: entity.InterestRate,
+		ValidFrom:    entity.ValidFrom.Format(time.DateOnly),
+		CreatedAt:    entity.CreatedAt,
+		UpdatedAt:    entity.UpdatedAt,
+	}
+	if entity.ValidTo.Valid {
+		validTo := entity.ValidTo.Time.Format(time.DateOnly)
+		overdraft.ValidTo = &validTo
+	}
+	return overdraft
+}
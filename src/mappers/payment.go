@@ -0,0 +1,76 @@
+package mappers
+
+import (
+	dto "src/api/dto"
+	paymententity "src/domain/payment"
+)
+
+func ToOutboundPaymentDto(entity paymententity.OutboundPaymentEntity) dto.OutboundPaymentDto {
+	payment := dto.OutboundPaymentDto{
+		ID:            entity.ID,
+		AccountID:     entity.AccountID,
+		TransactionID: entity.TransactionID,
+		EndToEndID:    entity.EndToEndID,
+		CreditorIban:  entity.CreditorIban,
+		CreditorName:  entity.CreditorName,
+		Amount:        entity.Amount,
+		Currency:      entity.Currency,
+		Status:        entity.Status,
+		CreatedAt:     entity.CreatedAt,
+		UpdatedAt:     entity.UpdatedAt,
+	}
+	if entity.RefundID.Valid {
+		refundID := int(entity.RefundID.Int32)
+		payment.RefundTransactionID = &refundID
+	}
+	if entity.RemittanceInformation.Valid {
+		payment.RemittanceInformation = &entity.RemittanceInformation.String
+	}
+	if entity.StatusReason.Valid {
+		payment.StatusReason = &entity.StatusReason.String
+	}
+	return payment
+}
+
+func ToInboundPaymentDto(entity paymententity.InboundPaymentEntity) dto.InboundPaymentDto {
+	payment := dto.InboundPaymentDto{
+		ID:            entity.ID,
+		MessageID:     entity.MessageID,
+		MessageType:   entity.MessageType,
+		EndToEndID:    entity.EndToEndID,
+		CreditorIban:  entity.CreditorIban,
+		Amount:        entity.Amount,
+		Currency:      entity.Currency,
+		Status:        entity.Status,
+		TransactionID: entity.TransactionID,
+		CreatedAt:     entity.CreatedAt,
+		UpdatedAt:     entity.UpdatedAt,
+	}
+	if entity.DebtorIban.Valid {
+		payment.DebtorIban = &entity.DebtorIban.String
+	}
+	if entity.DebtorName.Valid {
+		payment.DebtorName = &entity.DebtorName.String
+	}
+	if entity.CreditorName.Valid {
+		payment.CreditorName = &entity.CreditorName.String
+	}
+	if entity.RemittanceInformation.Valid {
+		payment.RemittanceInformation = &entity.RemittanceInformation.String
+	}
+	if entity.ExceptionReason.Valid {
+		payment.ExceptionReason = &entity.ExceptionReason.String
+	}
+	if entity.AccountID.Valid {
+		accountID := int(entity.AccountID.Int32)
+		payment.AccountID = &accountID
+	}
+	if entity.ResolutionID.Valid {
+		resolutionID := int(entity.ResolutionID.Int32)
+		payment.ResolutionTransactionID = &resolutionID
+	}
+	if entity.ReturnReason.Valid {
+		payment.ReturnReason = &entity.ReturnReason.String
+	}
+	return payment
+}
@@ -0,0 +1,50 @@
+package mappers
+
+import (
+	dto "src/api/dto"
+	scheduledtransferentity "src/domain/scheduled_transfer"
+	"time"
+)
+
+func ToStandingOrderDto(entity scheduledtransferentity.ScheduledTransferEntity) dto.StandingOrderDto {
+	standingOrder := dto.StandingOrderDto{
+		ID:              entity.ID,
+		AccountID:       entity.AccountID,
+		ToAccountNumber: entity.ToAccountNumber,
+		Amount:          entity.Amount,
+		Frequency:       entity.Frequency,
+		StartDate:       entity.StartDate.Format(time.DateOnly),
+		Status:          entity.Status,
+		CreatedAt:       entity.CreatedAt,
+		UpdatedAt:       entity.UpdatedAt,
+	}
+	if entity.EndDate.Valid {
+		endDate := entity.EndDate.Time.Format(time.DateOnly)
+		standingOrder.EndDate = &endDate
+	}
+	if entity.NextRunDate.Valid {
+		nextRunDate := entity.NextRunDate.Time.Format(time.DateOnly)
+		standingOrder.NextRunDate = &nextRunDate
+	}
+	if entity.Reference.Valid {
+		standingOrder.Reference = &entity.Reference.String
+	}
+	return standingOrder
+}
+
+func ToStandingOrderRunDto(entity scheduledtransferentity.ScheduledTransferRunEntity) dto.StandingOrderRunDto {
+	run := dto.StandingOrderRunDto{
+		ID:        entity.ID,
+		RunDate:   entity.RunDate.Format(time.DateOnly),
+		Status:    entity.Status,
+		CreatedAt: entity.CreatedAt,
+	}
+	if entity.FailureReason.Valid {
+		run.FailureReason = &entity.FailureReason.String
+	}
+	if entity.TransactionID.Valid {
+		transactionID := int(entity.TransactionID.Int32)
+		run.TransactionID = &transactionID
+	}
+	return run
+}
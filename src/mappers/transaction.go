@@ -24,6 +24,8 @@ func ToPaginationTransactionDto(transactionPagination pagination.Pagination[tran
 		LastPage: transactionPagination.LastPage,
 		Count: transactionPagination.Count,
 		Items: itemsDto,
+		NextCursor: transactionPagination.NextCursor,
+		PrevCursor: transactionPagination.PrevCursor,
 	}
 
 	return transactionPaginationDto, nil
@@ -56,6 +58,27 @@ func ToTransactionDto(entity transaction_entity.TransactionEntity) (dto.Transact
 	
 		transaction.ToAccountNumber = &entity.ToAccountNumber.String
 	}
+	if entity.ReversalOf.Valid {
+		reversalOf := int(entity.ReversalOf.Int32)
+		transaction.ReversalOf = &reversalOf
+	}
+	if entity.ReasonCode.Valid {
+		transaction.ReasonCode = &entity.ReasonCode.String
+	}
+	if entity.HoldID.Valid {
+		holdID := int(entity.HoldID.Int32)
+		transaction.HoldID = &holdID
+	}
+	if entity.FxRate.Valid {
+		transaction.FxRate = &entity.FxRate.String
+	}
+	if entity.ToAmount.Valid {
+		transaction.ToAmount = &entity.ToAmount.Money
+	}
+	transaction.FeeAmount = entity.FeeAmount
+	for _, fee := range entity.Fees {
+		transaction.Fees = append(transaction.Fees, ToFeeDto(fee))
+	}
 
 	
 	return transaction, nil
@@ -6,6 +6,8 @@ import (
 	"go.uber.org/zap"
 	"fmt"
 	accountentity "src/domain/account"
+	currencyentity "src/domain/currency"
+	"src/domain/money"
 	errors "src/errors"
 
 )
@@ -19,6 +21,24 @@ type AccountRepository interface {
 	createAccountBalance(ctx context.Context, tx *sql.Tx, account *accountentity.AccountEntity) errors.AppError
 }
 
+// Explicit column list, so new columns do not break the positional scans
+const accountColumns = `id, client_id, account_number, created_at, updated_at, currency, status, status_reason, status_changed_at, product`
+
+func scanAccount(row rowScanner, account *accountentity.AccountEntity) error {
+	return row.Scan(
+		&account.ID,
+		&account.ClientID,
+		&account.AccountNumber,
+		&account.CreatedAt,
+		&account.UpdatedAt,
+		&account.Currency,
+		&account.Status,
+		&account.StatusReason,
+		&account.StatusChangedAt,
+		&account.Product,
+	)
+}
+
 type accountRepository struct {
 	db     *sql.DB
 	logger *zap.Logger
@@ -33,8 +53,9 @@ func NewAccountRepository(db *sql.DB, logger *zap.Logger) AccountRepository {
 
 func (r *accountRepository) FetchAccountIdByAccountNumber(ctx context.Context, iban string)(*int, errors.AppError ){
 	fmt.Println("ACCOUNT NUMBER ", iban)
+	// internal accounts cannot be the destination of client transactions
 	query := `
-		SELECT id from accounts where account_number = $1
+		SELECT id from accounts where account_number = $1 AND internal_code IS NULL
 	`
 	
 
@@ -54,7 +75,7 @@ func (r *accountRepository) FetchAccountIdByAccountNumber(ctx context.Context, i
 
 func (r *accountRepository) FetchAccountsByClient(ctx context.Context, clientID int) ([]accountentity.AccountEntity, errors.AppError) {
 	query := `
-	 SELECT * FROM accounts where client_id = $1
+	 SELECT `+accountColumns+` FROM accounts where client_id = $1
 	`
 
 	sqlRows, err := r.db.QueryContext(ctx, query, clientID)
@@ -68,13 +89,7 @@ func (r *accountRepository) FetchAccountsByClient(ctx context.Context, clientID
 
 	for sqlRows.Next() {
 		var account accountentity.AccountEntity = accountentity.AccountEntity{}
-		scanError := sqlRows.Scan(
-			&account.ID,
-			&account.ClientID,
-			&account.AccountNumber,
-			&account.CreatedAt,
-			&account.UpdatedAt,
-		)
+		scanError := scanAccount(sqlRows, &account)
 		if scanError != nil {
 			r.logger.Error("Error occurred while scanning account: " + err.Error())
 			return nil, &errors.ErrInternalServer{Reason: err}
@@ -96,17 +111,11 @@ func (r *accountRepository) FetchAccountsByClient(ctx context.Context, clientID
 
 func (r *accountRepository) FetchAccountById(ctx context.Context, ID int) (accountentity.AccountEntity, errors.AppError) {
 	query := `
-	 SELECT * FROM accounts where id = $1
+	 SELECT `+accountColumns+` FROM accounts where id = $1
 	`
 	var account accountentity.AccountEntity = accountentity.AccountEntity{}
 	sqlRow := r.db.QueryRowContext(ctx, query, ID)
-	err := sqlRow.Scan(
-		&account.ID,
-		&account.ClientID,
-		&account.AccountNumber,
-		&account.CreatedAt,
-		&account.UpdatedAt,
-	)
+	err := scanAccount(sqlRow, &account)
 	if err == sql.ErrNoRows {
 		r.logger.Error("No account found " + fmt.Sprint(ID))
 		return accountentity.AccountEntity{}, &errors.ErrNotFound{Entity: "Account"}
@@ -124,7 +133,7 @@ func (r *accountRepository) createAccountBalance(ctx context.Context, tx *sql.Tx
 	INSERT INTO account_balances (
             account_id, balance
         ) VALUES ($1, $2)`
-	initBalance := 0.0
+	initBalance := money.Zero
 	_, err := tx.ExecContext(ctx, query, account.ID, initBalance)
 	if err != nil {
 		errString := fmt.Sprintf("Error inserting new account_balance (ACCOUNT_ID: %d). %s",account.ID,err.Error())
@@ -140,15 +149,23 @@ func (r *accountRepository) InsertAccountTx(ctx context.Context, tx *sql.Tx,acco
 	
 	query := `
 	INSERT INTO accounts (
-            client_id, account_number
-        ) VALUES ($1, $2) 
-		RETURNING id,created_at, updated_at`
+            client_id, account_number, currency, product
+        ) VALUES ($1, $2, $3, $4) 
+		RETURNING id,created_at, updated_at, status`
 
+	if account.Currency == "" {
+		account.Currency = currencyentity.Default
+	}
+	if account.Product == "" {
+		account.Product = accountentity.DefaultProduct
+	}
 	// Execute the query and scan the returned values into the client struct
 	err := tx.QueryRowContext(ctx, query,
 		account.ClientID,
 		account.AccountNumber,
-	).Scan(&account.ID, &account.CreatedAt, &account.UpdatedAt)
+		account.Currency,
+		account.Product,
+	).Scan(&account.ID, &account.CreatedAt, &account.UpdatedAt, &account.Status)
 
 	if err != nil {
 		r.logger.Error("Error occurred inserting account: " + err.Error() + " .ClientID: " + fmt.Sprint(account.ClientID))
@@ -168,10 +185,16 @@ func (r *accountRepository) InsertAccount(ctx context.Context, account *accounte
 	
 	query := `
 	INSERT INTO accounts (
-            client_id, account_number
-        ) VALUES ($1, $2) 
-		RETURNING id,created_at, updated_at`
+            client_id, account_number, currency, product
+        ) VALUES ($1, $2, $3, $4) 
+		RETURNING id,created_at, updated_at, status`
 
+	if account.Currency == "" {
+		account.Currency = currencyentity.Default
+	}
+	if account.Product == "" {
+		account.Product = accountentity.DefaultProduct
+	}
 	// Execute the query and scan the returned values into the client struct
 	tx, txError := r.db.BeginTx(ctx,&sql.TxOptions{ReadOnly: false})
 	if txError != nil {
@@ -180,7 +203,9 @@ func (r *accountRepository) InsertAccount(ctx context.Context, account *accounte
 	err := tx.QueryRowContext(ctx, query,
 		account.ClientID,
 		account.AccountNumber,
-	).Scan(&account.ID, &account.CreatedAt, &account.UpdatedAt)
+		account.Currency,
+		account.Product,
+	).Scan(&account.ID, &account.CreatedAt, &account.UpdatedAt, &account.Status)
 
 	if err != nil {
 		r.logger.Error("Error occurred inserting account: " + err.Error() + " .ClientID: " + fmt.Sprint(account.ClientID))
@@ -192,5 +217,9 @@ func (r *accountRepository) InsertAccount(ctx context.Context, account *accounte
 		tx.Rollback()
 		return &errors.ErrInternalServer{Reason: err}
 	}
+	if err = tx.Commit(); err != nil {
+		r.logger.Error("Error occurred committing account: " + err.Error() + " .ClientID: " + fmt.Sprint(account.ClientID))
+		return &errors.ErrInternalServer{Reason: err}
+	}
 	return nil
 }
@@ -0,0 +1,249 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	accountentity "src/domain/account"
+	interestentity "src/domain/interest"
+	scheduledtransferentity "src/domain/scheduled_transfer"
+	transaction_entity "src/domain/transaction"
+	errors "src/errors"
+
+	"go.uber.org/zap"
+)
+
+type AccountStatusRepository interface {
+	ChangeStatusTx(ctx context.Context, accountID int, fromStatus, toStatus, reason string) (accountentity.AccountEntity, errors.AppError)
+	CloseTx(ctx context.Context, accountID int, reason string, settlementIban *string) (accountentity.AccountEntity, *transaction_entity.TransactionEntity, errors.AppError)
+	FetchStatusChanges(ctx context.Context, accountID int) ([]accountentity.AccountStatusChangeEntity, errors.AppError)
+}
+
+type accountStatusRepository struct {
+	db                    *sql.DB
+	logger                *zap.Logger
+	transactionRepository TransactionRepository
+	interestRepository    InterestRepository
+}
+
+// The transaction repository locks the balances and posts the settlement transfer of the closings,
+// the interest repository pays the interest accrued by the closed accounts
+func NewAccountStatusRepository(
+	db *sql.DB,
+	logger *zap.Logger,
+	transactionRepository TransactionRepository,
+	interestRepository InterestRepository,
+) AccountStatusRepository {
+	if db == nil {
+		panic("db cannot be nil")
+	}
+	return &accountStatusRepository{
+		db:                    db,
+		logger:                logger,
+		transactionRepository: transactionRepository,
+		interestRepository:    interestRepository,
+	}
+}
+
+// lockAccount locks the balance (and share locks the row) of a client account, so no
+// transaction can post against it while its status changes
+func (r *accountStatusRepository) lockAccount(ctx context.Context, tx *sql.Tx, accountID int) (accountentity.AccountBalance, errors.AppError) {
+	balances, err := r.transactionRepository.LockAccountBalances(ctx, tx, accountID)
+	if err != nil {
+		return accountentity.AccountBalance{}, err
+	}
+	balance := balances[accountID]
+	if balance.Internal {
+		return accountentity.AccountBalance{}, &errors.ErrNotFound{Entity: "Account"}
+	}
+	return balance, nil
+}
+
+// updateStatus moves the account to the new status and records the change
+func (r *accountStatusRepository) updateStatus(
+	ctx context.Context,
+	tx *sql.Tx,
+	accountID int,
+	fromStatus, toStatus, reason string,
+	settlementTransactionID sql.NullInt32,
+) (accountentity.AccountEntity, errors.AppError) {
+	var account accountentity.AccountEntity
+	query := `
+	UPDATE accounts SET status = $1, status_reason = $2, status_changed_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+	WHERE id = $3
+	RETURNING ` + accountColumns
+	if err := scanAccount(tx.QueryRowContext(ctx, query, toStatus, reason, accountID), &account); err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while updating status of account %d: %s", accountID, err.Error()))
+		return accountentity.AccountEntity{}, &errors.ErrInternalServer{Reason: err}
+	}
+
+	query = `
+	INSERT INTO account_status_changes (
+            account_id, from_status, to_status, reason, settlement_transaction_id
+        ) VALUES ($1, $2, $3, $4, $5)`
+	if _, err := tx.ExecContext(ctx, query, accountID, fromStatus, toStatus, reason, settlementTransactionID); err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while recording status change of account %d: %s", accountID, err.Error()))
+		return accountentity.AccountEntity{}, &errors.ErrInternalServer{Reason: err}
+	}
+	return account, nil
+}
+
+/**
+* Database transaction to freeze, unfreeze, make dormant or reactivate an account
+* 1. Lock the balance of the account, so no posting is in flight
+* 2. Check the current status is fromStatus (any status when empty) and the transition is allowed
+* 3. Update the status and record the change
+*
+* Accounts are closed through CloseTx, which settles the balance first.
+ */
+func (r *accountStatusRepository) ChangeStatusTx(ctx context.Context, accountID int, fromStatus, toStatus, reason string) (accountentity.AccountEntity, errors.AppError) {
+	if toStatus == accountentity.StatusClosed {
+		return accountentity.AccountEntity{}, &errors.ErrBadRequest{Message: "accounts must be closed through the close operation"}
+	}
+	var account accountentity.AccountEntity
+	appErr := r.transactionRepository.RunInTx(ctx, func(tx *sql.Tx) errors.AppError {
+		balance, err := r.lockAccount(ctx, tx, accountID)
+		if err != nil {
+			return err
+		}
+		if (fromStatus != "" && balance.Status != fromStatus) || !accountentity.CanTransition(balance.Status, toStatus) {
+			return &errors.ErrConflict{Message: fmt.Sprintf("account %d is %s, it cannot become %s", accountID, balance.Status, toStatus)}
+		}
+		account, err = r.updateStatus(ctx, tx, accountID, balance.Status, toStatus, reason, sql.NullInt32{})
+		return err
+	})
+	return account, appErr
+}
+
+/**
+* Database transaction to close an account
+* 1. Lock the balance of the account. There cannot be authorized holds nor a negative balance
+* 2. Pay the interest accrued and not capitalised yet, as the capitalisation of the current month
+* 3. A positive balance is transferred in full to the settlement IBAN, which must be an account of the bank
+* 4. Cancel the active standing orders of the account
+* 5. Mark the account as CLOSED and record the change with the settlement transaction
+*
+* A zero balance, once the interest is paid, needs no settlement IBAN.
+ */
+func (r *accountStatusRepository) CloseTx(ctx context.Context, accountID int, reason string, settlementIban *string) (accountentity.AccountEntity, *transaction_entity.TransactionEntity, errors.AppError) {
+	var account accountentity.AccountEntity
+	var settlement *transaction_entity.TransactionEntity
+	appErr := r.transactionRepository.RunInTx(ctx, func(tx *sql.Tx) errors.AppError {
+		settlement = nil
+		balance, err := r.lockAccount(ctx, tx, accountID)
+		if err != nil {
+			return err
+		}
+		if !accountentity.CanTransition(balance.Status, accountentity.StatusClosed) {
+			return &errors.ErrConflict{Message: fmt.Sprintf("account %d is already %s", accountID, balance.Status)}
+		}
+		if !balance.HeldAmount.IsZero() {
+			return &errors.ErrConflict{Message: fmt.Sprintf("account %d has %s units held, capture or void its holds first", accountID, balance.HeldAmount)}
+		}
+		if balance.Balance.IsNegative() {
+			return &errors.ErrConflict{Message: fmt.Sprintf("account %d has a negative balance of %s", accountID, balance.Balance)}
+		}
+
+		// the capitalisation of the month never runs for a closed account
+		monthStart := interestentity.MonthStart(scheduledtransferentity.Today())
+		paid, err := r.interestRepository.CapitaliseAccount(ctx, tx, accountID, monthStart)
+		if err != nil {
+			return err
+		}
+		if paid {
+			paidBalance, err := r.transactionRepository.FetchAccountBalance(ctx, tx, accountID)
+			if err != nil {
+				return err
+			}
+			balance.Balance = paidBalance.Balance
+		}
+
+		settlementTransactionID := sql.NullInt32{}
+		if balance.Balance.IsPositive() {
+			if settlementIban == nil {
+				return &errors.ErrConflict{Message: fmt.Sprintf("account %d has a balance of %s, a settlement IBAN is required to close it", accountID, balance.Balance)}
+			}
+			toAccountID, err := r.fetchSettlementAccountId(ctx, tx, *settlementIban)
+			if err != nil {
+				return err
+			}
+			if toAccountID == accountID {
+				return &errors.ErrBadRequest{Message: "the settlement IBAN cannot be the closed account"}
+			}
+			settlement = &transaction_entity.TransactionEntity{
+				AccountID:       accountID,
+				ToAccountID:     sql.NullInt32{Int32: int32(toAccountID), Valid: true},
+				ToAccountNumber: sql.NullString{String: *settlementIban, Valid: true},
+				Type:            "TRANSFER",
+				Amount:          balance.Balance,
+			}
+			if err := r.transactionRepository.InsertSettlementLedger(ctx, tx, settlement); err != nil {
+				return err
+			}
+			settlementTransactionID = sql.NullInt32{Int32: int32(settlement.ID), Valid: true}
+		}
+
+		query := `
+		UPDATE scheduled_transfers SET status = $1, next_run_date = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE account_id = $2 AND status = $3`
+		if _, err := tx.ExecContext(ctx, query, scheduledtransferentity.StatusCancelled, accountID, scheduledtransferentity.StatusActive); err != nil {
+			r.logger.Error(fmt.Sprintf("Error occurred while cancelling standing orders of account %d: %s", accountID, err.Error()))
+			return &errors.ErrInternalServer{Reason: err}
+		}
+
+		account, err = r.updateStatus(ctx, tx, accountID, balance.Status, accountentity.StatusClosed, reason, settlementTransactionID)
+		return err
+	})
+	return account, settlement, appErr
+}
+
+// fetchSettlementAccountId resolves the IBAN the balance of a closed account is transferred to
+func (r *accountStatusRepository) fetchSettlementAccountId(ctx context.Context, tx *sql.Tx, iban string) (int, errors.AppError) {
+	var id int
+	query := `SELECT id FROM accounts WHERE account_number = $1 AND internal_code IS NULL`
+	err := tx.QueryRowContext(ctx, query, iban).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, &errors.ErrBadRequest{Message: fmt.Sprintf("settlement IBAN %s is not an account of the bank", iban)}
+	}
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while fetching settlement account %s: %s", iban, err.Error()))
+		return 0, &errors.ErrInternalServer{Reason: err}
+	}
+	return id, nil
+}
+
+func (r *accountStatusRepository) FetchStatusChanges(ctx context.Context, accountID int) ([]accountentity.AccountStatusChangeEntity, errors.AppError) {
+	query := `
+	SELECT id, account_id, from_status, to_status, reason, settlement_transaction_id, created_at
+	FROM account_status_changes
+	WHERE account_id = $1
+	ORDER BY id`
+	rows, err := r.db.QueryContext(ctx, query, accountID)
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while fetching status changes of account %d: %s", accountID, err.Error()))
+		return nil, &errors.ErrInternalServer{Reason: err}
+	}
+	defer rows.Close()
+
+	changes := []accountentity.AccountStatusChangeEntity{}
+	for rows.Next() {
+		var change accountentity.AccountStatusChangeEntity
+		if err := rows.Scan(
+			&change.ID,
+			&change.AccountID,
+			&change.FromStatus,
+			&change.ToStatus,
+			&change.Reason,
+			&change.SettlementTransactionID,
+			&change.CreatedAt,
+		); err != nil {
+			r.logger.Error(fmt.Sprintf("Error occurred while scanning status changes of account %d: %s", accountID, err.Error()))
+			return nil, &errors.ErrInternalServer{Reason: err}
+		}
+		changes = append(changes, change)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, &errors.ErrInternalServer{Reason: err}
+	}
+	return changes, nil
+}
@@ -0,0 +1,168 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	accountentity "src/domain/account"
+	"src/domain/money"
+	errors "src/errors"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type BalanceRepository interface {
+	RunInSnapshot(ctx context.Context, fn func(tx *sql.Tx) errors.AppError) errors.AppError
+	FetchBalanceAt(ctx context.Context, tx *sql.Tx, accountID int, at time.Time) (money.Money, errors.AppError)
+	FetchBalanceHistory(ctx context.Context, accountID int, from, to time.Time) ([]accountentity.BalanceSnapshotEntity, errors.AppError)
+	SnapshotBalances(ctx context.Context, until time.Time) (int, errors.AppError)
+}
+
+type balanceRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewBalanceRepository(db *sql.DB, logger *zap.Logger) BalanceRepository {
+	if db == nil {
+		panic("db cannot be nil")
+	}
+	return &balanceRepository{db: db, logger: logger}
+}
+
+// RunInSnapshot runs fn in a read only Tx that sees the ledger as it was when it started, so several
+// balances and the entries between them are consistent with each other
+func (r *balanceRepository) RunInSnapshot(ctx context.Context, fn func(tx *sql.Tx) errors.AppError) errors.AppError {
+	return runInTx(ctx, r.db, r.logger, &sql.TxOptions{
+		ReadOnly:  true,
+		Isolation: sql.LevelRepeatableRead,
+	}, fn)
+}
+
+// FetchBalanceAt returns the balance of the account with the ledger entries created before at. It starts
+// from the last end of day snapshot before at, so only the entries of the days after it are added up.
+// The Tx is optional.
+func (r *balanceRepository) FetchBalanceAt(ctx context.Context, tx *sql.Tx, accountID int, at time.Time) (money.Money, errors.AppError) {
+	queryRow := r.db.QueryRowContext
+	if tx != nil {
+		queryRow = tx.QueryRowContext
+	}
+	var exists bool
+	if err := queryRow(ctx, `SELECT EXISTS (SELECT 1 FROM accounts WHERE id = $1)`, accountID).Scan(&exists); err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while fetching account %d: %s", accountID, err.Error()))
+		return money.Zero, &errors.ErrInternalServer{Reason: err}
+	}
+	if !exists {
+		return money.Zero, &errors.ErrNotFound{Entity: "Account"}
+	}
+
+	query := `
+	WITH snapshot AS (
+		SELECT (snapshot_date + 1)::timestamp AS taken_until, balance
+		FROM balance_snapshots
+		WHERE account_id = $1 AND (snapshot_date + 1)::timestamp <= $2
+		ORDER BY snapshot_date DESC
+		LIMIT 1
+	)
+	SELECT COALESCE((SELECT balance FROM snapshot), 0)
+		+ COALESCE(SUM(CASE WHEN UPPER(le.type) = 'CREDIT' THEN le.amount ELSE -le.amount END), 0)
+	FROM ledger_entries le
+	WHERE le.account_id = $1
+	  AND le.created_at < $2
+	  AND le.created_at >= COALESCE((SELECT taken_until FROM snapshot), '-infinity'::timestamp)`
+	var balance money.Money
+	if err := queryRow(ctx, query, accountID, at).Scan(&balance); err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while computing the balance of account %d at %s: %s", accountID, at, err.Error()))
+		return money.Zero, &errors.ErrInternalServer{Reason: err}
+	}
+	return balance, nil
+}
+
+// FetchBalanceHistory returns the end of day balances of the account between from and to, both included,
+// oldest first. Days after the last snapshot are not returned.
+func (r *balanceRepository) FetchBalanceHistory(ctx context.Context, accountID int, from, to time.Time) ([]accountentity.BalanceSnapshotEntity, errors.AppError) {
+	query := `
+	SELECT account_id, snapshot_date, balance, created_at
+	FROM balance_snapshots
+	WHERE account_id = $1 AND snapshot_date >= $2 AND snapshot_date <= $3
+	ORDER BY snapshot_date`
+	rows, err := r.db.QueryContext(ctx, query, accountID, from, to)
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while fetching the balance history of account %d: %s", accountID, err.Error()))
+		return nil, &errors.ErrInternalServer{Reason: err}
+	}
+	defer rows.Close()
+	snapshots := make([]accountentity.BalanceSnapshotEntity, 0)
+	for rows.Next() {
+		var snapshot accountentity.BalanceSnapshotEntity
+		if err := rows.Scan(&snapshot.AccountID, &snapshot.SnapshotDate, &snapshot.Balance, &snapshot.CreatedAt); err != nil {
+			r.logger.Error("Error occurred while scanning balance snapshot: " + err.Error())
+			return nil, &errors.ErrInternalServer{Reason: err}
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, &errors.ErrInternalServer{Reason: err}
+	}
+	return snapshots, nil
+}
+
+// SnapshotBalances takes the end of day snapshot of every account for each day after the last one taken,
+// or from the first day of the ledger, up to until. It returns how many days were taken. Each day is built
+// from the snapshot of the day before plus the entries of the day, so days are never skipped.
+func (r *balanceRepository) SnapshotBalances(ctx context.Context, until time.Time) (int, errors.AppError) {
+	var next sql.NullTime
+	query := `
+	SELECT COALESCE(
+		(SELECT MAX(snapshot_date) + 1 FROM balance_snapshots),
+		(SELECT MIN(created_at)::date FROM ledger_entries)
+	)`
+	if err := r.db.QueryRowContext(ctx, query).Scan(&next); err != nil {
+		r.logger.Error("Error occurred while fetching the next balance snapshot day: " + err.Error())
+		return 0, &errors.ErrInternalServer{Reason: err}
+	}
+	if !next.Valid {
+		return 0, nil
+	}
+
+	taken := 0
+	for day := next.Time; !day.After(until); day = day.AddDate(0, 0, 1) {
+		if err := r.snapshotDay(ctx, day); err != nil {
+			return taken, err
+		}
+		taken++
+	}
+	return taken, nil
+}
+
+// snapshotDay inserts the snapshot of the day of the accounts that existed, or had entries, by its end.
+// The entries backfilled before their account was created (i.e. the vault ones) are counted too. The
+// snapshots already taken (i.e. by another worker) are kept.
+func (r *balanceRepository) snapshotDay(ctx context.Context, day time.Time) errors.AppError {
+	query := `
+	INSERT INTO balance_snapshots (account_id, snapshot_date, balance)
+	SELECT a.id, $1::date,
+		COALESCE(previous.balance, 0) + COALESCE((
+			SELECT SUM(CASE WHEN UPPER(le.type) = 'CREDIT' THEN le.amount ELSE -le.amount END)
+			FROM ledger_entries le
+			WHERE le.account_id = a.id
+			  AND le.created_at >= COALESCE((previous.snapshot_date + 1)::timestamp, '-infinity'::timestamp)
+			  AND le.created_at < ($1::date + 1)::timestamp
+		), 0)
+	FROM accounts a
+	LEFT JOIN LATERAL (
+		SELECT snapshot_date, balance FROM balance_snapshots bs
+		WHERE bs.account_id = a.id AND bs.snapshot_date < $1::date
+		ORDER BY bs.snapshot_date DESC
+		LIMIT 1
+	) previous ON TRUE
+	WHERE a.created_at < ($1::date + 1)::timestamp
+	   OR EXISTS (SELECT 1 FROM ledger_entries le WHERE le.account_id = a.id AND le.created_at < ($1::date + 1)::timestamp)
+	ON CONFLICT (account_id, snapshot_date) DO NOTHING`
+	if _, err := r.db.ExecContext(ctx, query, day); err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while taking the balance snapshot of %s: %s", day.Format(time.DateOnly), err.Error()))
+		return &errors.ErrInternalServer{Reason: err}
+	}
+	return nil
+}
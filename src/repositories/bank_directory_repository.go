@@ -0,0 +1,86 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	bankentity "src/domain/bank"
+	errors "src/errors"
+
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+type BankDirectoryRepository interface {
+	FetchBank(ctx context.Context, countryCode string, bankCodes ...string) (bankentity.BankEntity, errors.AppError)
+	CountBanks(ctx context.Context) (int, errors.AppError)
+	ReplaceBankDirectory(ctx context.Context, banks []bankentity.BankEntity) errors.AppError
+}
+
+type bankDirectoryRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewBankDirectoryRepository(db *sql.DB, logger *zap.Logger) BankDirectoryRepository {
+	if db == nil {
+		panic("db cannot be nil")
+	}
+	return &bankDirectoryRepository{db: db, logger: logger}
+}
+
+// FetchBank returns the bank of the country with the longest of the given codes, so a bank and branch code
+// listed on its own is preferred to the bank code
+func (r *bankDirectoryRepository) FetchBank(ctx context.Context, countryCode string, bankCodes ...string) (bankentity.BankEntity, errors.AppError) {
+	var bank bankentity.BankEntity
+	query := `
+	SELECT country_code, bank_code, bic, name, updated_at FROM bank_directory
+	WHERE country_code = $1 AND bank_code = ANY($2)
+	ORDER BY length(bank_code) DESC
+	LIMIT 1`
+	err := r.db.QueryRowContext(ctx, query, countryCode, pq.Array(bankCodes)).
+		Scan(&bank.CountryCode, &bank.BankCode, &bank.Bic, &bank.Name, &bank.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return bankentity.BankEntity{}, &errors.ErrNotFound{Entity: "Bank", Reason: err}
+	}
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while fetching bank %s %v: %s", countryCode, bankCodes, err.Error()))
+		return bankentity.BankEntity{}, &errors.ErrInternalServer{Reason: err}
+	}
+	return bank, nil
+}
+
+func (r *bankDirectoryRepository) CountBanks(ctx context.Context) (int, errors.AppError) {
+	var count int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM bank_directory`).Scan(&count); err != nil {
+		r.logger.Error("Error occurred while counting banks: " + err.Error())
+		return 0, &errors.ErrInternalServer{Reason: err}
+	}
+	return count, nil
+}
+
+// ReplaceBankDirectory removes every bank and inserts the given ones, in one database transaction: the
+// lookups see either the old directory or the new one. A bank listed twice keeps its last line.
+func (r *bankDirectoryRepository) ReplaceBankDirectory(ctx context.Context, banks []bankentity.BankEntity) errors.AppError {
+	return runInTx(ctx, r.db, r.logger, nil, func(tx *sql.Tx) errors.AppError {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM bank_directory`); err != nil {
+			r.logger.Error("Error occurred while clearing the bank directory: " + err.Error())
+			return &errors.ErrInternalServer{Reason: err}
+		}
+		stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO bank_directory (country_code, bank_code, bic, name, updated_at)
+		VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP)
+		ON CONFLICT (country_code, bank_code) DO UPDATE SET bic = EXCLUDED.bic, name = EXCLUDED.name`)
+		if err != nil {
+			return &errors.ErrInternalServer{Reason: err}
+		}
+		defer stmt.Close()
+		for _, bank := range banks {
+			if _, err := stmt.ExecContext(ctx, bank.CountryCode, bank.BankCode, bank.Bic, bank.Name); err != nil {
+				r.logger.Error(fmt.Sprintf("Error occurred while storing bank %s %s: %s", bank.CountryCode, bank.BankCode, err.Error()))
+				return &errors.ErrInternalServer{Reason: err}
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,155 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	beneficiaryentity "src/domain/beneficiary"
+	errors "src/errors"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type BeneficiaryRepository interface {
+	InsertBeneficiary(ctx context.Context, beneficiary *beneficiaryentity.BeneficiaryEntity) errors.AppError
+	FetchBeneficiaryById(ctx context.Context, ID int) (beneficiaryentity.BeneficiaryEntity, errors.AppError)
+	FetchBeneficiariesByClient(ctx context.Context, clientID int) ([]beneficiaryentity.BeneficiaryEntity, errors.AppError)
+	UpdateBeneficiary(ctx context.Context, beneficiary *beneficiaryentity.BeneficiaryEntity) errors.AppError
+	DeleteBeneficiary(ctx context.Context, ID int) errors.AppError
+}
+
+type beneficiaryRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewBeneficiaryRepository(db *sql.DB, logger *zap.Logger) BeneficiaryRepository {
+	if db == nil {
+		panic("db cannot be nil")
+	}
+	return &beneficiaryRepository{db: db, logger: logger}
+}
+
+const beneficiaryColumns = `id, client_id, nickname, iban, name, reference, cooling_off_until, cooling_off_limit, created_at, updated_at`
+
+func scanBeneficiary(row rowScanner, entity *beneficiaryentity.BeneficiaryEntity) error {
+	return row.Scan(
+		&entity.ID,
+		&entity.ClientID,
+		&entity.Nickname,
+		&entity.Iban,
+		&entity.Name,
+		&entity.Reference,
+		&entity.CoolingOffUntil,
+		&entity.CoolingOffLimit,
+		&entity.CreatedAt,
+		&entity.UpdatedAt,
+	)
+}
+
+// InsertBeneficiary returns ErrConflict when the client already has a beneficiary with the IBAN
+func (r *beneficiaryRepository) InsertBeneficiary(ctx context.Context, beneficiary *beneficiaryentity.BeneficiaryEntity) errors.AppError {
+	query := `
+	INSERT INTO beneficiaries (client_id, nickname, iban, name, reference, cooling_off_until, cooling_off_limit)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+	ON CONFLICT (client_id, iban) DO NOTHING
+	RETURNING ` + beneficiaryColumns
+	err := scanBeneficiary(r.db.QueryRowContext(ctx, query,
+		beneficiary.ClientID,
+		beneficiary.Nickname,
+		beneficiary.Iban,
+		beneficiary.Name,
+		beneficiary.Reference,
+		beneficiary.CoolingOffUntil,
+		beneficiary.CoolingOffLimit,
+	), beneficiary)
+	if err == sql.ErrNoRows {
+		return &errors.ErrConflict{Message: fmt.Sprintf("%s is already a beneficiary of the client", beneficiary.Iban)}
+	}
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while inserting a beneficiary of client %d: %s", beneficiary.ClientID, err.Error()))
+		return &errors.ErrInternalServer{Reason: err}
+	}
+	return nil
+}
+
+func (r *beneficiaryRepository) FetchBeneficiaryById(ctx context.Context, ID int) (beneficiaryentity.BeneficiaryEntity, errors.AppError) {
+	var beneficiary beneficiaryentity.BeneficiaryEntity
+	err := scanBeneficiary(r.db.QueryRowContext(ctx, `SELECT `+beneficiaryColumns+` FROM beneficiaries WHERE id = $1`, ID), &beneficiary)
+	if err == sql.ErrNoRows {
+		return beneficiaryentity.BeneficiaryEntity{}, &errors.ErrNotFound{Entity: "Beneficiary", Reason: err}
+	}
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while fetching beneficiary %d: %s", ID, err.Error()))
+		return beneficiaryentity.BeneficiaryEntity{}, &errors.ErrInternalServer{Reason: err}
+	}
+	return beneficiary, nil
+}
+
+func (r *beneficiaryRepository) FetchBeneficiariesByClient(ctx context.Context, clientID int) ([]beneficiaryentity.BeneficiaryEntity, errors.AppError) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+beneficiaryColumns+` FROM beneficiaries WHERE client_id = $1 ORDER BY nickname, id`, clientID)
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while fetching the beneficiaries of client %d: %s", clientID, err.Error()))
+		return nil, &errors.ErrInternalServer{Reason: err}
+	}
+	defer rows.Close()
+	beneficiaries := make([]beneficiaryentity.BeneficiaryEntity, 0)
+	for rows.Next() {
+		var beneficiary beneficiaryentity.BeneficiaryEntity
+		if err := scanBeneficiary(rows, &beneficiary); err != nil {
+			r.logger.Error("Error occurred while scanning beneficiary: " + err.Error())
+			return nil, &errors.ErrInternalServer{Reason: err}
+		}
+		beneficiaries = append(beneficiaries, beneficiary)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, &errors.ErrInternalServer{Reason: err}
+	}
+	return beneficiaries, nil
+}
+
+// UpdateBeneficiary changes the nickname, the name and the reference. The IBAN and the cooling-off are kept.
+func (r *beneficiaryRepository) UpdateBeneficiary(ctx context.Context, beneficiary *beneficiaryentity.BeneficiaryEntity) errors.AppError {
+	query := `
+	UPDATE beneficiaries SET nickname = $2, name = $3, reference = $4, updated_at = CURRENT_TIMESTAMP
+	WHERE id = $1
+	RETURNING ` + beneficiaryColumns
+	err := scanBeneficiary(r.db.QueryRowContext(ctx, query,
+		beneficiary.ID,
+		beneficiary.Nickname,
+		beneficiary.Name,
+		beneficiary.Reference,
+	), beneficiary)
+	if err == sql.ErrNoRows {
+		return &errors.ErrNotFound{Entity: "Beneficiary", Reason: err}
+	}
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while updating beneficiary %d: %s", beneficiary.ID, err.Error()))
+		return &errors.ErrInternalServer{Reason: err}
+	}
+	return nil
+}
+
+// DeleteBeneficiary removes the beneficiary. The transfers sent to it are kept, without it. A beneficiary in its
+// cooling-off cannot be removed, otherwise adding it again would start another cooling-off with the whole limit.
+func (r *beneficiaryRepository) DeleteBeneficiary(ctx context.Context, ID int) errors.AppError {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM beneficiaries WHERE id = $1 AND cooling_off_until <= CURRENT_TIMESTAMP`, ID)
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while deleting beneficiary %d: %s", ID, err.Error()))
+		return &errors.ErrInternalServer{Reason: err}
+	}
+	if affected, _ := result.RowsAffected(); affected > 0 {
+		return nil
+	}
+	var coolingOffUntil time.Time
+	err = r.db.QueryRowContext(ctx, `SELECT cooling_off_until FROM beneficiaries WHERE id = $1`, ID).Scan(&coolingOffUntil)
+	if err == sql.ErrNoRows {
+		return &errors.ErrNotFound{Entity: "Beneficiary", Reason: err}
+	}
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while fetching beneficiary %d: %s", ID, err.Error()))
+		return &errors.ErrInternalServer{Reason: err}
+	}
+	return &errors.ErrConflict{Message: fmt.Sprintf("beneficiary %d cannot be removed during its cooling-off, until %s", ID, coolingOffUntil.Format(time.DateTime))}
+}
@@ -0,0 +1,156 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	branchentity "src/domain/branch"
+	errors "src/errors"
+
+	"go.uber.org/zap"
+)
+
+type BranchRepository interface {
+	FetchBranches(ctx context.Context, bankCode string) ([]branchentity.BranchUsage, errors.AppError)
+	FetchBranch(ctx context.Context, code string) (branchentity.BranchEntity, errors.AppError)
+	UpsertBranch(ctx context.Context, branch *branchentity.BranchEntity) errors.AppError
+	FetchClientBranch(ctx context.Context, tx *sql.Tx, clientID int) (sql.NullString, errors.AppError)
+	SetClientBranch(ctx context.Context, clientID int, code string) errors.AppError
+	NextAccountSequence(ctx context.Context, tx *sql.Tx, code string) (int64, errors.AppError)
+	AccountNumberExists(ctx context.Context, tx *sql.Tx, accountNumber string) (bool, errors.AppError)
+}
+
+type branchRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewBranchRepository(db *sql.DB, logger *zap.Logger) BranchRepository {
+	if db == nil {
+		panic("db cannot be nil")
+	}
+	return &branchRepository{db: db, logger: logger}
+}
+
+const branchColumns = `code, name, last_sequence, created_at, updated_at`
+
+func scanBranch(row rowScanner, branch *branchentity.BranchEntity, extra ...any) error {
+	return row.Scan(append([]any{&branch.Code, &branch.Name, &branch.LastSequence, &branch.CreatedAt, &branch.UpdatedAt}, extra...)...)
+}
+
+// FetchBranches returns every branch with the client accounts whose IBAN has the bank code and its branch code
+func (r *branchRepository) FetchBranches(ctx context.Context, bankCode string) ([]branchentity.BranchUsage, errors.AppError) {
+	query := `
+	SELECT ` + branchColumns + `,
+		(SELECT COUNT(*) FROM accounts a
+		 WHERE a.internal_code IS NULL AND a.account_number LIKE 'ES__' || $1 || b.code || '%')
+	FROM branches b
+	ORDER BY code`
+	rows, err := r.db.QueryContext(ctx, query, bankCode)
+	if err != nil {
+		r.logger.Error("Error occurred while fetching branches: " + err.Error())
+		return nil, &errors.ErrInternalServer{Reason: err}
+	}
+	defer rows.Close()
+	branches := make([]branchentity.BranchUsage, 0)
+	for rows.Next() {
+		var branch branchentity.BranchUsage
+		if err := scanBranch(rows, &branch.BranchEntity, &branch.Accounts); err != nil {
+			r.logger.Error("Error occurred while scanning branch: " + err.Error())
+			return nil, &errors.ErrInternalServer{Reason: err}
+		}
+		branches = append(branches, branch)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, &errors.ErrInternalServer{Reason: err}
+	}
+	return branches, nil
+}
+
+func (r *branchRepository) FetchBranch(ctx context.Context, code string) (branchentity.BranchEntity, errors.AppError) {
+	var branch branchentity.BranchEntity
+	err := scanBranch(r.db.QueryRowContext(ctx, `SELECT `+branchColumns+` FROM branches WHERE code = $1`, code), &branch)
+	if err == sql.ErrNoRows {
+		return branchentity.BranchEntity{}, &errors.ErrNotFound{Entity: "Branch", Reason: err}
+	}
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while fetching branch %s: %s", code, err.Error()))
+		return branchentity.BranchEntity{}, &errors.ErrInternalServer{Reason: err}
+	}
+	return branch, nil
+}
+
+// UpsertBranch creates the branch, or renames it. Its sequence is kept.
+func (r *branchRepository) UpsertBranch(ctx context.Context, branch *branchentity.BranchEntity) errors.AppError {
+	query := `
+	INSERT INTO branches (code, name) VALUES ($1, $2)
+	ON CONFLICT (code) DO UPDATE SET name = EXCLUDED.name, updated_at = CURRENT_TIMESTAMP
+	RETURNING ` + branchColumns
+	if err := scanBranch(r.db.QueryRowContext(ctx, query, branch.Code, branch.Name), branch); err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while storing branch %s: %s", branch.Code, err.Error()))
+		return &errors.ErrInternalServer{Reason: err}
+	}
+	return nil
+}
+
+// FetchClientBranch returns the branch of the client, not valid when the client has none
+func (r *branchRepository) FetchClientBranch(ctx context.Context, tx *sql.Tx, clientID int) (sql.NullString, errors.AppError) {
+	var code sql.NullString
+	err := tx.QueryRowContext(ctx, `SELECT branch_code FROM clients WHERE id = $1`, clientID).Scan(&code)
+	if err == sql.ErrNoRows {
+		return sql.NullString{}, &errors.ErrNotFound{Entity: "Client", Reason: err}
+	}
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while fetching the branch of client %d: %s", clientID, err.Error()))
+		return sql.NullString{}, &errors.ErrInternalServer{Reason: err}
+	}
+	return code, nil
+}
+
+// SetClientBranch moves the client to the branch. The accounts opened before keep their IBAN.
+func (r *branchRepository) SetClientBranch(ctx context.Context, clientID int, code string) errors.AppError {
+	if _, appErr := r.FetchBranch(ctx, code); appErr != nil {
+		return appErr
+	}
+	result, err := r.db.ExecContext(ctx, `UPDATE clients SET branch_code = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $1`, clientID, code)
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while setting the branch of client %d: %s", clientID, err.Error()))
+		return &errors.ErrInternalServer{Reason: err}
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return &errors.ErrNotFound{Entity: "Client", Reason: sql.ErrNoRows}
+	}
+	return nil
+}
+
+// NextAccountSequence hands out the next account number of the branch. The branch stays locked until tx ends,
+// so the accounts of a branch are opened one at a time and a rolled back account gives its number back.
+func (r *branchRepository) NextAccountSequence(ctx context.Context, tx *sql.Tx, code string) (int64, errors.AppError) {
+	var sequence int64
+	query := `
+	UPDATE branches SET last_sequence = last_sequence + 1, updated_at = CURRENT_TIMESTAMP
+	WHERE code = $1 AND last_sequence < $2
+	RETURNING last_sequence`
+	err := tx.QueryRowContext(ctx, query, code, branchentity.AccountNumberCapacity).Scan(&sequence)
+	if err == sql.ErrNoRows {
+		if _, appErr := r.FetchBranch(ctx, code); appErr != nil {
+			return 0, appErr
+		}
+		return 0, &errors.ErrConflict{Message: fmt.Sprintf("branch %s has no account numbers left", code)}
+	}
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while taking an account number of branch %s: %s", code, err.Error()))
+		return 0, &errors.ErrInternalServer{Reason: err}
+	}
+	return sequence, nil
+}
+
+func (r *branchRepository) AccountNumberExists(ctx context.Context, tx *sql.Tx, accountNumber string) (bool, errors.AppError) {
+	var exists bool
+	err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM accounts WHERE account_number = $1)`, accountNumber).Scan(&exists)
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while checking account number %s: %s", accountNumber, err.Error()))
+		return false, &errors.ErrInternalServer{Reason: err}
+	}
+	return exists, nil
+}
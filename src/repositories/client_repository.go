@@ -18,6 +18,34 @@ type ClientRepository interface {
 	GetTx() (*sql.Tx, errors.AppError)
 }
 
+// Explicit column list, so new columns do not break the positional scans
+const clientColumns = `id, name, surname1, surname2, email, identification, nationality, date_of_birth, sex, address, city,
+	province, state, zip_code, telephone, created_at, updated_at, kc_user_id, branch_code`
+
+func scanClient(row rowScanner, client *cliententity.ClientEntity) error {
+	return row.Scan(
+		&client.ID,
+		&client.Name,
+		&client.Surname1,
+		&client.Surname2,
+		&client.Email,
+		&client.Identification,
+		&client.Nationality,
+		&client.DateOfBirth,
+		&client.Sex,
+		&client.Address,
+		&client.City,
+		&client.Province,
+		&client.State,
+		&client.ZipCode,
+		&client.Telephone,
+		&client.CreatedAt,
+		&client.UpdatedAt,
+		&client.KcUserId,
+		&client.BranchCode,
+	)
+}
+
 type clientRepository struct {
 	db     *sql.DB
 	logger *zap.Logger
@@ -42,28 +70,11 @@ func (r *clientRepository) GetTx() (*sql.Tx, errors.AppError) {
 
 func (r *clientRepository) FetchClient(ctx context.Context, identification string) (cliententity.ClientEntity, errors.AppError) {
 	query := `
-	 SELECT * FROM clients where identification = $1
+	 SELECT `+clientColumns+` FROM clients where identification = $1
 	`
 	var client cliententity.ClientEntity = cliententity.ClientEntity{}
 	sqlRow := r.db.QueryRowContext(ctx, query, identification)
-	err := sqlRow.Scan(&client.ID,
-		&client.Name,
-		&client.Surname1,
-		&client.Surname2,
-		&client.Email,
-		&client.Identification,
-		&client.Nationality,
-		&client.DateOfBirth,
-		&client.Sex,
-		&client.Address,
-		&client.City,
-		&client.Province,
-		&client.State,
-		&client.ZipCode,
-		&client.Telephone,
-		&client.CreatedAt,
-		&client.UpdatedAt, 
-		&client.KcUserId)
+	err := scanClient(sqlRow, &client)
 	if err == sql.ErrNoRows {
 		r.logger.Error("No client found for " + identification)
 		return cliententity.ClientEntity{}, &errors.ErrNotFound{Reason: err, Entity: "Client"}
@@ -79,28 +90,11 @@ func (r *clientRepository) FetchClient(ctx context.Context, identification strin
 
 func (r *clientRepository) FetchClientById(ctx context.Context, ID int) (cliententity.ClientEntity, errors.AppError) {
 	query := `
-	 SELECT * FROM clients where id = $1
+	 SELECT `+clientColumns+` FROM clients where id = $1
 	`
 	var client cliententity.ClientEntity = cliententity.ClientEntity{}
 	sqlRow := r.db.QueryRowContext(ctx, query, ID)
-	err := sqlRow.Scan(&client.ID,
-		&client.Name,
-		&client.Surname1,
-		&client.Surname2,
-		&client.Email,
-		&client.Identification,
-		&client.Nationality,
-		&client.DateOfBirth,
-		&client.Sex,
-		&client.Address,
-		&client.City,
-		&client.Province,
-		&client.State,
-		&client.ZipCode,
-		&client.Telephone,
-		&client.CreatedAt,
-		&client.UpdatedAt, 
-		&client.KcUserId)
+	err := scanClient(sqlRow, &client)
 	if err == sql.ErrNoRows {
 		r.logger.Error("No client found " + fmt.Sprint(ID))
 		return cliententity.ClientEntity{}, &errors.ErrNotFound{Entity: "Client", Reason: err}
@@ -115,28 +109,11 @@ func (r *clientRepository) FetchClientById(ctx context.Context, ID int) (cliente
 
 func (r *clientRepository) FetchClientByIdentification(ctx context.Context, identification string) (cliententity.ClientEntity, errors.AppError) {
 	query := `
-	 SELECT * FROM clients where identification = $1
+	 SELECT `+clientColumns+` FROM clients where identification = $1
 	`
 	var client cliententity.ClientEntity = cliententity.ClientEntity{}
 	sqlRow := r.db.QueryRowContext(ctx, query, identification)
-	err := sqlRow.Scan(&client.ID,
-		&client.Name,
-		&client.Surname1,
-		&client.Surname2,
-		&client.Email,
-		&client.Identification,
-		&client.Nationality,
-		&client.DateOfBirth,
-		&client.Sex,
-		&client.Address,
-		&client.City,
-		&client.Province,
-		&client.State,
-		&client.ZipCode,
-		&client.Telephone,
-		&client.CreatedAt,
-		&client.UpdatedAt, 
-		&client.KcUserId)
+	err := scanClient(sqlRow, &client)
 	if err == sql.ErrNoRows {
 		r.logger.Error("No client found " + fmt.Sprint(identification))
 		return cliententity.ClientEntity{}, &errors.ErrNotFound{Entity: "Client", Reason: err}
@@ -154,8 +131,8 @@ func (r *clientRepository) InsertClient(ctx context.Context, client *cliententit
         INSERT INTO clients (
             name, surname1, surname2, email, identification, nationality, 
             date_of_birth, sex, address, city, province, state, 
-            zip_code, telephone
-        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+            zip_code, telephone, branch_code
+        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
         RETURNING id, created_at, updated_at`
 
 	// Execute the query and scan the returned values into the client struct
@@ -174,6 +151,7 @@ func (r *clientRepository) InsertClient(ctx context.Context, client *cliententit
 		client.State,
 		client.ZipCode,
 		client.Telephone,
+		client.BranchCode,
 	).Scan(&client.ID, &client.CreatedAt, &client.UpdatedAt)
 
 	if err != nil {
@@ -189,8 +167,8 @@ func (r *clientRepository) InsertClientTx(ctx context.Context, tx *sql.Tx, clien
         INSERT INTO clients (
             name, surname1, surname2, email, identification, nationality, 
             date_of_birth, sex, address, city, province, state, 
-            zip_code, telephone
-        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+            zip_code, telephone, branch_code
+        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
         RETURNING id, created_at, updated_at`
 
 	// Execute the query and scan the returned values into the client struct
@@ -209,6 +187,7 @@ func (r *clientRepository) InsertClientTx(ctx context.Context, tx *sql.Tx, clien
 		client.State,
 		client.ZipCode,
 		client.Telephone,
+		client.BranchCode,
 	).Scan(&client.ID, &client.CreatedAt, &client.UpdatedAt)
 
 	if err != nil {
@@ -0,0 +1,139 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	currencyentity "src/domain/currency"
+	errors "src/errors"
+
+	"go.uber.org/zap"
+)
+
+type CurrencyRepository interface {
+	FetchCurrencies(ctx context.Context) ([]currencyentity.CurrencyEntity, errors.AppError)
+	FetchCurrency(ctx context.Context, code string) (currencyentity.CurrencyEntity, errors.AppError)
+	FetchExchangeRates(ctx context.Context) ([]currencyentity.ExchangeRateEntity, errors.AppError)
+	UpsertExchangeRates(ctx context.Context, rates []currencyentity.ExchangeRateEntity) errors.AppError
+}
+
+type currencyRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewCurrencyRepository(db *sql.DB, logger *zap.Logger) CurrencyRepository {
+	if db == nil {
+		panic("db cannot be nil")
+	}
+	return &currencyRepository{db: db, logger: logger}
+}
+
+func (r *currencyRepository) FetchCurrencies(ctx context.Context) ([]currencyentity.CurrencyEntity, errors.AppError) {
+	rows, err := r.db.QueryContext(ctx, `SELECT code, name, minor_unit FROM currencies ORDER BY code`)
+	if err != nil {
+		r.logger.Error("Error occurred while fetching currencies: " + err.Error())
+		return nil, &errors.ErrInternalServer{Reason: err}
+	}
+	defer rows.Close()
+	currencies := make([]currencyentity.CurrencyEntity, 0)
+	for rows.Next() {
+		var currency currencyentity.CurrencyEntity
+		if err := rows.Scan(&currency.Code, &currency.Name, &currency.MinorUnit); err != nil {
+			r.logger.Error("Error occurred while scanning currency: " + err.Error())
+			return nil, &errors.ErrInternalServer{Reason: err}
+		}
+		currencies = append(currencies, currency)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, &errors.ErrInternalServer{Reason: err}
+	}
+	return currencies, nil
+}
+
+func (r *currencyRepository) FetchCurrency(ctx context.Context, code string) (currencyentity.CurrencyEntity, errors.AppError) {
+	var currency currencyentity.CurrencyEntity
+	err := r.db.QueryRowContext(ctx, `SELECT code, name, minor_unit FROM currencies WHERE code = $1`, code).
+		Scan(&currency.Code, &currency.Name, &currency.MinorUnit)
+	if err == sql.ErrNoRows {
+		return currencyentity.CurrencyEntity{}, &errors.ErrNotFound{Entity: "Currency", Reason: err}
+	}
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while fetching currency %s: %s", code, err.Error()))
+		return currencyentity.CurrencyEntity{}, &errors.ErrInternalServer{Reason: err}
+	}
+	return currency, nil
+}
+
+func (r *currencyRepository) FetchExchangeRates(ctx context.Context) ([]currencyentity.ExchangeRateEntity, errors.AppError) {
+	query := `SELECT base_currency, quote_currency, rate, updated_at FROM exchange_rates ORDER BY base_currency, quote_currency`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		r.logger.Error("Error occurred while fetching exchange rates: " + err.Error())
+		return nil, &errors.ErrInternalServer{Reason: err}
+	}
+	defer rows.Close()
+	rates := make([]currencyentity.ExchangeRateEntity, 0)
+	for rows.Next() {
+		var rate currencyentity.ExchangeRateEntity
+		if err := rows.Scan(&rate.Base, &rate.Quote, &rate.Rate, &rate.UpdatedAt); err != nil {
+			r.logger.Error("Error occurred while scanning exchange rate: " + err.Error())
+			return nil, &errors.ErrInternalServer{Reason: err}
+		}
+		rates = append(rates, rate)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, &errors.ErrInternalServer{Reason: err}
+	}
+	return rates, nil
+}
+
+// UpsertExchangeRates stores the rates, replacing the previous rate of each pair. Either all of them are stored or none.
+func (r *currencyRepository) UpsertExchangeRates(ctx context.Context, rates []currencyentity.ExchangeRateEntity) errors.AppError {
+	currencies, appErr := r.FetchCurrencies(ctx)
+	if appErr != nil {
+		return appErr
+	}
+	known := make(map[string]bool, len(currencies))
+	for _, currency := range currencies {
+		known[currency.Code] = true
+	}
+	for _, rate := range rates {
+		if !known[rate.Base] || !known[rate.Quote] {
+			return &errors.ErrBadRequest{Message: fmt.Sprintf("unknown currency in the %s/%s exchange rate", rate.Base, rate.Quote)}
+		}
+		if rate.Base == rate.Quote {
+			return &errors.ErrBadRequest{Message: fmt.Sprintf("the %s/%s exchange rate must be between two currencies", rate.Base, rate.Quote)}
+		}
+		if _, err := rate.RateValue(); err != nil {
+			return &errors.ErrBadRequest{Message: fmt.Sprintf("%s/%s: %s", rate.Base, rate.Quote, err.Error())}
+		}
+	}
+
+	query := `
+	INSERT INTO exchange_rates (base_currency, quote_currency, rate, updated_at)
+	VALUES ($1, $2, $3, CURRENT_TIMESTAMP)
+	ON CONFLICT (base_currency, quote_currency) DO UPDATE SET rate = EXCLUDED.rate, updated_at = EXCLUDED.updated_at`
+	return runInTx(ctx, r.db, r.logger, &sql.TxOptions{Isolation: sql.LevelReadCommitted}, func(tx *sql.Tx) errors.AppError {
+		for _, rate := range rates {
+			if _, err := tx.ExecContext(ctx, query, rate.Base, rate.Quote, rate.Rate); err != nil {
+				r.logger.Error(fmt.Sprintf("Error occurred while storing the %s/%s exchange rate: %s", rate.Base, rate.Quote, err.Error()))
+				return &errors.ErrInternalServer{Reason: err}
+			}
+		}
+		return nil
+	})
+}
+
+// queryExchangeRate reads the rate of base to quote inside tx. When only the opposite pair is stored,
+// it is returned with inverse set. sql.ErrNoRows is returned when neither pair is stored.
+func queryExchangeRate(ctx context.Context, tx *sql.Tx, base, quote string) (rate string, inverse bool, err error) {
+	query := `
+	SELECT rate, base_currency <> $1
+	FROM exchange_rates
+	WHERE (base_currency = $1 AND quote_currency = $2) OR (base_currency = $2 AND quote_currency = $1)
+	ORDER BY base_currency <> $1
+	LIMIT 1`
+	err = tx.QueryRowContext(ctx, query, base, quote).Scan(&rate, &inverse)
+	return rate, inverse, err
+}
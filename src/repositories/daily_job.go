@@ -0,0 +1,60 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	errors "src/errors"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Daily jobs, as recorded in daily_job_runs
+const (
+	dailyJobDebitInterest   = "DEBIT_INTEREST"
+	dailyJobInterestAccrual = "INTEREST_ACCRUAL"
+)
+
+// Days a daily job catches up in one run at most. The days after are left for the next runs
+const maxCatchUpDays = 31
+
+/**
+* runDailyJob runs the job for every day after the last one it completed up to until, one day at a time,
+* and returns what the days returned added up. Without a day completed yet, only until is run.
+* A day is recorded as completed only when runDay succeeds, so a failed day is run again by the next run,
+* and runDay must skip what the failed run already did. At most maxCatchUpDays days are run each time.
+ */
+func runDailyJob(ctx context.Context, db *sql.DB, logger *zap.Logger, job string, until time.Time, runDay func(day time.Time) (int, errors.AppError)) (int, errors.AppError) {
+	var lastDay time.Time
+	day := until
+	err := db.QueryRowContext(ctx, `SELECT last_day FROM daily_job_runs WHERE job = $1`, job).Scan(&lastDay)
+	if err != nil && err != sql.ErrNoRows {
+		logger.Error(fmt.Sprintf("Error occurred while fetching the last day of job %s: %s", job, err.Error()))
+		return 0, &errors.ErrInternalServer{Reason: err}
+	}
+	if err == nil {
+		day = lastDay.AddDate(0, 0, 1)
+	}
+	if last := day.AddDate(0, 0, maxCatchUpDays-1); until.After(last) {
+		logger.Warn(fmt.Sprintf("Job %s is behind since %s, catching up until %s", job, day.Format(time.DateOnly), last.Format(time.DateOnly)))
+		until = last
+	}
+
+	total := 0
+	for ; !day.After(until); day = day.AddDate(0, 0, 1) {
+		count, appErr := runDay(day)
+		total += count
+		if appErr != nil {
+			return total, appErr
+		}
+		query := `
+		INSERT INTO daily_job_runs (job, last_day) VALUES ($1, $2)
+		ON CONFLICT (job) DO UPDATE SET last_day = GREATEST(daily_job_runs.last_day, EXCLUDED.last_day), updated_at = CURRENT_TIMESTAMP`
+		if _, err := db.ExecContext(ctx, query, job, day); err != nil {
+			logger.Error(fmt.Sprintf("Error occurred while recording day %s of job %s: %s", day.Format(time.DateOnly), job, err.Error()))
+			return total, &errors.ErrInternalServer{Reason: err}
+		}
+	}
+	return total, nil
+}
@@ -0,0 +1,82 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	documententity "src/domain/document"
+	errors "src/errors"
+
+	"go.uber.org/zap"
+)
+
+type DocumentRepository interface {
+	InsertDocument(ctx context.Context, document *documententity.DocumentEntity) errors.AppError
+	FetchDocumentByCode(ctx context.Context, code string) (documententity.DocumentEntity, errors.AppError)
+}
+
+type documentRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewDocumentRepository(db *sql.DB, logger *zap.Logger) DocumentRepository {
+	if db == nil {
+		panic("db cannot be nil")
+	}
+	return &documentRepository{db: db, logger: logger}
+}
+
+const documentColumns = `id, verification_code, type, account_id, iban, holder_name, period_from, period_to, sha256, created_at`
+
+func scanDocument(row rowScanner, entity *documententity.DocumentEntity) error {
+	return row.Scan(
+		&entity.ID,
+		&entity.VerificationCode,
+		&entity.Type,
+		&entity.AccountID,
+		&entity.Iban,
+		&entity.HolderName,
+		&entity.PeriodFrom,
+		&entity.PeriodTo,
+		&entity.Sha256,
+		&entity.CreatedAt,
+	)
+}
+
+// InsertDocument records an issued document, so its verification code can be checked
+func (r *documentRepository) InsertDocument(ctx context.Context, document *documententity.DocumentEntity) errors.AppError {
+	query := `
+	INSERT INTO documents (verification_code, type, account_id, iban, holder_name, period_from, period_to, sha256)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	RETURNING ` + documentColumns
+	err := scanDocument(r.db.QueryRowContext(ctx, query,
+		document.VerificationCode,
+		document.Type,
+		document.AccountID,
+		document.Iban,
+		document.HolderName,
+		document.PeriodFrom,
+		document.PeriodTo,
+		document.Sha256,
+	), document)
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while recording %s document of account %d: %s", document.Type, document.AccountID, err.Error()))
+		return &errors.ErrInternalServer{Reason: err}
+	}
+	return nil
+}
+
+func (r *documentRepository) FetchDocumentByCode(ctx context.Context, code string) (documententity.DocumentEntity, errors.AppError) {
+	var document documententity.DocumentEntity
+	query := `SELECT ` + documentColumns + ` FROM documents WHERE verification_code = $1`
+	err := scanDocument(r.db.QueryRowContext(ctx, query, code), &document)
+	if err == sql.ErrNoRows {
+		return documententity.DocumentEntity{}, &errors.ErrNotFound{Entity: "Document", Reason: err}
+	}
+	if err != nil {
+		r.logger.Error("Error occurred while fetching document: " + err.Error())
+		return documententity.DocumentEntity{}, &errors.ErrInternalServer{Reason: err}
+	}
+	return document, nil
+}
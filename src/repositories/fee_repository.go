@@ -0,0 +1,167 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	accountentity "src/domain/account"
+	feeentity "src/domain/fee"
+	errors "src/errors"
+
+	"go.uber.org/zap"
+)
+
+type FeeRepository interface {
+	FetchProducts(ctx context.Context) ([]accountentity.ProductEntity, errors.AppError)
+	FetchProduct(ctx context.Context, code string) (accountentity.ProductEntity, errors.AppError)
+	FetchFeeSchedules(ctx context.Context) ([]feeentity.FeeScheduleEntity, errors.AppError)
+	UpsertFeeSchedule(ctx context.Context, schedule *feeentity.FeeScheduleEntity) errors.AppError
+	DeleteFeeSchedule(ctx context.Context, ID int) errors.AppError
+}
+
+type feeRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewFeeRepository(db *sql.DB, logger *zap.Logger) FeeRepository {
+	if db == nil {
+		panic("db cannot be nil")
+	}
+	return &feeRepository{db: db, logger: logger}
+}
+
+const feeScheduleColumns = `id, transaction_type, product, currency, fixed_amount, percentage, min_percentage_amount, max_percentage_amount, created_at, updated_at`
+
+func scanFeeSchedule(row rowScanner, entity *feeentity.FeeScheduleEntity) error {
+	return row.Scan(
+		&entity.ID,
+		&entity.TransactionType,
+		&entity.Product,
+		&entity.Currency,
+		&entity.FixedAmount,
+		&entity.Percentage,
+		&entity.MinPercentageAmount,
+		&entity.MaxPercentageAmount,
+		&entity.CreatedAt,
+		&entity.UpdatedAt,
+	)
+}
+
+func (r *feeRepository) FetchProducts(ctx context.Context) ([]accountentity.ProductEntity, errors.AppError) {
+	rows, err := r.db.QueryContext(ctx, `SELECT code, name FROM products ORDER BY code`)
+	if err != nil {
+		r.logger.Error("Error occurred while fetching products: " + err.Error())
+		return nil, &errors.ErrInternalServer{Reason: err}
+	}
+	defer rows.Close()
+	products := make([]accountentity.ProductEntity, 0)
+	for rows.Next() {
+		var product accountentity.ProductEntity
+		if err := rows.Scan(&product.Code, &product.Name); err != nil {
+			r.logger.Error("Error occurred while scanning product: " + err.Error())
+			return nil, &errors.ErrInternalServer{Reason: err}
+		}
+		products = append(products, product)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, &errors.ErrInternalServer{Reason: err}
+	}
+	return products, nil
+}
+
+func (r *feeRepository) FetchProduct(ctx context.Context, code string) (accountentity.ProductEntity, errors.AppError) {
+	var product accountentity.ProductEntity
+	err := r.db.QueryRowContext(ctx, `SELECT code, name FROM products WHERE code = $1`, code).Scan(&product.Code, &product.Name)
+	if err == sql.ErrNoRows {
+		return accountentity.ProductEntity{}, &errors.ErrNotFound{Entity: "Product", Reason: err}
+	}
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while fetching product %s: %s", code, err.Error()))
+		return accountentity.ProductEntity{}, &errors.ErrInternalServer{Reason: err}
+	}
+	return product, nil
+}
+
+func (r *feeRepository) FetchFeeSchedules(ctx context.Context) ([]feeentity.FeeScheduleEntity, errors.AppError) {
+	query := `SELECT ` + feeScheduleColumns + ` FROM fee_schedules ORDER BY transaction_type, currency, product NULLS FIRST`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		r.logger.Error("Error occurred while fetching fee schedules: " + err.Error())
+		return nil, &errors.ErrInternalServer{Reason: err}
+	}
+	defer rows.Close()
+	schedules := make([]feeentity.FeeScheduleEntity, 0)
+	for rows.Next() {
+		var schedule feeentity.FeeScheduleEntity
+		if err := scanFeeSchedule(rows, &schedule); err != nil {
+			r.logger.Error("Error occurred while scanning fee schedule: " + err.Error())
+			return nil, &errors.ErrInternalServer{Reason: err}
+		}
+		schedules = append(schedules, schedule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, &errors.ErrInternalServer{Reason: err}
+	}
+	return schedules, nil
+}
+
+// UpsertFeeSchedule stores the fee of a transaction type, product and currency, replacing the previous one
+func (r *feeRepository) UpsertFeeSchedule(ctx context.Context, schedule *feeentity.FeeScheduleEntity) errors.AppError {
+	query := `
+	INSERT INTO fee_schedules (
+            transaction_type, product, currency, fixed_amount, percentage, min_percentage_amount, max_percentage_amount
+        ) VALUES ($1, $2, $3, $4, $5, $6, $7)
+	ON CONFLICT (transaction_type, COALESCE(product, ''), currency) DO UPDATE SET
+		fixed_amount = EXCLUDED.fixed_amount,
+		percentage = EXCLUDED.percentage,
+		min_percentage_amount = EXCLUDED.min_percentage_amount,
+		max_percentage_amount = EXCLUDED.max_percentage_amount,
+		updated_at = CURRENT_TIMESTAMP
+	RETURNING ` + feeScheduleColumns
+	err := scanFeeSchedule(r.db.QueryRowContext(ctx, query,
+		schedule.TransactionType,
+		schedule.Product,
+		schedule.Currency,
+		schedule.FixedAmount,
+		schedule.Percentage,
+		schedule.MinPercentageAmount,
+		schedule.MaxPercentageAmount,
+	), schedule)
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while storing the %s fee schedule: %s", schedule.TransactionType, err.Error()))
+		return &errors.ErrInternalServer{Reason: err}
+	}
+	return nil
+}
+
+// DeleteFeeSchedule stops charging the fee. The fees already charged keep their amounts.
+func (r *feeRepository) DeleteFeeSchedule(ctx context.Context, ID int) errors.AppError {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM fee_schedules WHERE id = $1`, ID)
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while deleting fee schedule %d: %s", ID, err.Error()))
+		return &errors.ErrInternalServer{Reason: err}
+	}
+	if deleted, _ := result.RowsAffected(); deleted == 0 {
+		return &errors.ErrNotFound{Entity: "Fee schedule"}
+	}
+	return nil
+}
+
+// queryFeeSchedule finds the fee schedule of a transaction of the account: the one of its product if
+// there is one, otherwise the one for every product. Both in the currency of the account.
+func queryFeeSchedule(ctx context.Context, tx *sql.Tx, accountID int, transactionType string) (feeentity.FeeScheduleEntity, error) {
+	var schedule feeentity.FeeScheduleEntity
+	query := `
+	SELECT fs.id, fs.transaction_type, fs.product, fs.currency, fs.fixed_amount, fs.percentage,
+		fs.min_percentage_amount, fs.max_percentage_amount, fs.created_at, fs.updated_at
+	FROM fee_schedules fs
+	JOIN accounts a ON a.id = $1
+	WHERE fs.transaction_type = $2
+	  AND fs.currency = a.currency
+	  AND (fs.product IS NULL OR fs.product = a.product)
+	ORDER BY fs.product IS NULL
+	LIMIT 1`
+	err := scanFeeSchedule(tx.QueryRowContext(ctx, query, accountID, transactionType), &schedule)
+	return schedule, err
+}
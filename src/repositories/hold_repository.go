@@ -0,0 +1,308 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	accountentity "src/domain/account"
+	holdentity "src/domain/hold"
+	"src/domain/money"
+	transaction_entity "src/domain/transaction"
+	errors "src/errors"
+
+	"go.uber.org/zap"
+)
+
+type HoldRepository interface {
+	FetchHoldById(ctx context.Context, ID int) (holdentity.HoldEntity, errors.AppError)
+	AuthorizeTx(ctx context.Context, hold *holdentity.HoldEntity) errors.AppError
+	CaptureTx(ctx context.Context, ID int, amount *money.Money) (holdentity.HoldEntity, transaction_entity.TransactionEntity, errors.AppError)
+	VoidTx(ctx context.Context, ID int) (holdentity.HoldEntity, errors.AppError)
+	ExpireHolds(ctx context.Context) (int, errors.AppError)
+}
+
+type holdRepository struct {
+	db                    *sql.DB
+	logger                *zap.Logger
+	transactionRepository TransactionRepository
+}
+
+// The transaction repository posts the ledger entries of the captures
+func NewHoldRepository(db *sql.DB, logger *zap.Logger, transactionRepository TransactionRepository) HoldRepository {
+	if db == nil {
+		panic("db cannot be nil")
+	}
+	return &holdRepository{db: db, logger: logger, transactionRepository: transactionRepository}
+}
+
+const holdColumns = `id, account_id, to_account_id, amount, captured_amount, status, reference, expires_at, created_at, updated_at`
+
+func scanHold(row rowScanner, entity *holdentity.HoldEntity) error {
+	return row.Scan(
+		&entity.ID,
+		&entity.AccountID,
+		&entity.ToAccountID,
+		&entity.Amount,
+		&entity.CapturedAmount,
+		&entity.Status,
+		&entity.Reference,
+		&entity.ExpiresAt,
+		&entity.CreatedAt,
+		&entity.UpdatedAt,
+	)
+}
+
+func (r *holdRepository) FetchHoldById(ctx context.Context, ID int) (holdentity.HoldEntity, errors.AppError) {
+	return r.fetchHold(ctx, r.db.QueryRowContext(ctx, `SELECT `+holdColumns+` FROM holds WHERE id = $1`, ID), ID)
+}
+
+// lockHold reads the hold with a row lock, so it cannot be captured, voided or expired twice
+func (r *holdRepository) lockHold(ctx context.Context, tx *sql.Tx, ID int) (holdentity.HoldEntity, errors.AppError) {
+	return r.fetchHold(ctx, tx.QueryRowContext(ctx, `SELECT `+holdColumns+` FROM holds WHERE id = $1 FOR UPDATE`, ID), ID)
+}
+
+func (r *holdRepository) fetchHold(ctx context.Context, row *sql.Row, ID int) (holdentity.HoldEntity, errors.AppError) {
+	var hold holdentity.HoldEntity
+	err := scanHold(row, &hold)
+	if err == sql.ErrNoRows {
+		return holdentity.HoldEntity{}, &errors.ErrNotFound{Entity: "Hold", Reason: err}
+	}
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while fetching hold %d: %s", ID, err.Error()))
+		return holdentity.HoldEntity{}, &errors.ErrInternalServer{Reason: err}
+	}
+	return hold, nil
+}
+
+// updateHeldAmount adds delta (negative to release funds) to the held amount of the account
+func (r *holdRepository) updateHeldAmount(ctx context.Context, tx *sql.Tx, accountID int, delta money.Money) errors.AppError {
+	query := `UPDATE account_balances SET held_amount = held_amount + $1 WHERE account_id = $2`
+	_, err := tx.ExecContext(ctx, query, delta, accountID)
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while updating held amount of account %d: %s", accountID, err.Error()))
+		return &errors.ErrInternalServer{Reason: err}
+	}
+	return nil
+}
+
+func (r *holdRepository) updateStatus(ctx context.Context, tx *sql.Tx, hold *holdentity.HoldEntity) errors.AppError {
+	query := `
+	UPDATE holds SET status = $1, captured_amount = $2, updated_at = CURRENT_TIMESTAMP
+	WHERE id = $3
+	RETURNING updated_at`
+	err := tx.QueryRowContext(ctx, query, hold.Status, hold.CapturedAmount, hold.ID).Scan(&hold.UpdatedAt)
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while updating hold %d: %s", hold.ID, err.Error()))
+		return &errors.ErrInternalServer{Reason: err}
+	}
+	return nil
+}
+
+/**
+* Database transaction to reserve funds
+* 1. Lock the balance of the account
+* 2. Check the account is active and the available balance (balance - held_amount) plus the overdraft covers the hold
+* 3. Insert the hold and increase the held amount of the account
+*
+* No ledger entries are posted until the hold is captured.
+ */
+func (r *holdRepository) AuthorizeTx(ctx context.Context, hold *holdentity.HoldEntity) errors.AppError {
+	return r.transactionRepository.RunInTx(ctx, func(tx *sql.Tx) errors.AppError {
+		balances, err := r.transactionRepository.LockAccountBalances(ctx, tx, hold.AccountID)
+		if err != nil {
+			return err
+		}
+		if status := balances[hold.AccountID].Status; !accountentity.CanSend(status) {
+			return &errors.ErrAccountNotActive{Message: fmt.Sprintf("account %d is %s", hold.AccountID, status)}
+		}
+		available := balances[hold.AccountID].Spendable()
+		if available.LessThan(hold.Amount) {
+			errStr := fmt.Sprintf(
+				"Not enough funds. Account %d has %s monetary units available. Tried to hold %s units",
+				hold.AccountID,
+				available,
+				hold.Amount,
+			)
+			r.logger.Info(errStr)
+			return &errors.ErrNotEnoughFunds{Message: errStr}
+		}
+
+		hold.Status = holdentity.StatusAuthorized
+		hold.CapturedAmount = money.Zero
+		query := `
+		INSERT INTO holds (
+	            account_id, to_account_id, amount, status, reference, expires_at
+	        ) VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, updated_at`
+		queryErr := tx.QueryRowContext(ctx, query,
+			hold.AccountID,
+			hold.ToAccountID,
+			hold.Amount,
+			hold.Status,
+			hold.Reference,
+			hold.ExpiresAt,
+		).Scan(&hold.ID, &hold.CreatedAt, &hold.UpdatedAt)
+		if queryErr != nil {
+			r.logger.Error(fmt.Sprintf("Error occurred while inserting hold for account %d: %s", hold.AccountID, queryErr.Error()))
+			return &errors.ErrInternalServer{Reason: queryErr}
+		}
+		return r.updateHeldAmount(ctx, tx, hold.AccountID, hold.Amount)
+	})
+}
+
+/**
+* Database transaction to capture a hold, fully or partially
+* 1. Lock the hold. It must be AUTHORIZED and not expired
+* 2. Lock the balances of every account the capture is posted to, by ascending id as any other posting
+* 3. Release the whole held amount
+* 4. Post the captured amount as a TRANSFER to the merchant account, or a WITHDRAWAL without it
+* 5. Mark the hold as CAPTURED. The amount not captured goes back to the available balance
+*
+* A nil amount captures the whole hold.
+ */
+func (r *holdRepository) CaptureTx(ctx context.Context, ID int, amount *money.Money) (holdentity.HoldEntity, transaction_entity.TransactionEntity, errors.AppError) {
+	var hold holdentity.HoldEntity
+	var transaction transaction_entity.TransactionEntity
+	appErr := r.transactionRepository.RunInTx(ctx, func(tx *sql.Tx) errors.AppError {
+		var err errors.AppError
+		hold, err = r.lockHold(ctx, tx, ID)
+		if err != nil {
+			return err
+		}
+		if err := r.checkAuthorized(hold); err != nil {
+			return err
+		}
+		captureAmount := hold.Amount
+		if amount != nil {
+			captureAmount = *amount
+		}
+		if !captureAmount.IsPositive() || captureAmount.GreaterThan(hold.Amount) {
+			return &errors.ErrBadRequest{Message: fmt.Sprintf("capture amount %s must be positive and up to the held amount %s", captureAmount, hold.Amount)}
+		}
+
+		transaction = transaction_entity.TransactionEntity{
+			AccountID:   hold.AccountID,
+			ToAccountID: hold.ToAccountID,
+			Type:        "WITHDRAWAL",
+			Amount:      captureAmount,
+			HoldID:      sql.NullInt32{Int32: int32(hold.ID), Valid: true},
+		}
+		if hold.ToAccountID.Valid {
+			transaction.Type = "TRANSFER"
+		}
+		if _, err := r.transactionRepository.LockTransactionAccounts(ctx, tx, &transaction); err != nil {
+			return err
+		}
+		// the funds of the hold are released first, so the posting below can spend them
+		if err := r.updateHeldAmount(ctx, tx, hold.AccountID, hold.Amount.Neg()); err != nil {
+			return err
+		}
+		if err := r.transactionRepository.InsertTransactionLedger(ctx, tx, &transaction); err != nil {
+			return err
+		}
+
+		hold.Status = holdentity.StatusCaptured
+		hold.CapturedAmount = captureAmount
+		return r.updateStatus(ctx, tx, &hold)
+	})
+	if appErr != nil {
+		return holdentity.HoldEntity{}, transaction_entity.TransactionEntity{}, appErr
+	}
+	return hold, transaction, nil
+}
+
+// VoidTx cancels an authorized hold and releases its funds
+func (r *holdRepository) VoidTx(ctx context.Context, ID int) (holdentity.HoldEntity, errors.AppError) {
+	var hold holdentity.HoldEntity
+	appErr := r.transactionRepository.RunInTx(ctx, func(tx *sql.Tx) errors.AppError {
+		var err errors.AppError
+		hold, err = r.lockHold(ctx, tx, ID)
+		if err != nil {
+			return err
+		}
+		if err := r.checkAuthorized(hold); err != nil {
+			return err
+		}
+		if _, err := r.transactionRepository.LockAccountBalances(ctx, tx, hold.AccountID); err != nil {
+			return err
+		}
+		return r.release(ctx, tx, &hold, holdentity.StatusVoided)
+	})
+	if appErr != nil {
+		return holdentity.HoldEntity{}, appErr
+	}
+	return hold, nil
+}
+
+// ExpireHolds releases the authorized holds past their expiry date. It returns how many were expired.
+// Holds locked by a capture or a void at the same time are skipped, the next run will see them.
+func (r *holdRepository) ExpireHolds(ctx context.Context) (int, errors.AppError) {
+	expired := 0
+	appErr := r.transactionRepository.RunInTx(ctx, func(tx *sql.Tx) errors.AppError {
+		expired = 0
+		query := `
+		SELECT ` + holdColumns + ` FROM holds
+		WHERE status = $1 AND expires_at <= CURRENT_TIMESTAMP
+		ORDER BY id
+		FOR UPDATE SKIP LOCKED`
+		rows, err := tx.QueryContext(ctx, query, holdentity.StatusAuthorized)
+		if err != nil {
+			r.logger.Error("Error occurred while fetching expired holds: " + err.Error())
+			return &errors.ErrInternalServer{Reason: err}
+		}
+		var holds []holdentity.HoldEntity
+		for rows.Next() {
+			var hold holdentity.HoldEntity
+			if err := scanHold(rows, &hold); err != nil {
+				rows.Close()
+				r.logger.Error("Error occurred while scanning expired hold: " + err.Error())
+				return &errors.ErrInternalServer{Reason: err}
+			}
+			holds = append(holds, hold)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return &errors.ErrInternalServer{Reason: err}
+		}
+
+		// the balances are locked all at once, by ascending account id, and not in the order of the holds
+		accountIDs := make([]int, 0, len(holds))
+		for _, hold := range holds {
+			accountIDs = append(accountIDs, hold.AccountID)
+		}
+		if _, err := r.transactionRepository.LockAccountBalances(ctx, tx, accountIDs...); err != nil {
+			return err
+		}
+		for i := range holds {
+			if err := r.release(ctx, tx, &holds[i], holdentity.StatusExpired); err != nil {
+				return err
+			}
+			expired++
+		}
+		return nil
+	})
+	if appErr != nil {
+		return 0, appErr
+	}
+	return expired, nil
+}
+
+func (r *holdRepository) checkAuthorized(hold holdentity.HoldEntity) errors.AppError {
+	if !hold.IsAuthorized() {
+		return &errors.ErrConflict{Message: fmt.Sprintf("hold %d is %s", hold.ID, hold.Status)}
+	}
+	if hold.IsExpired() {
+		return &errors.ErrConflict{Message: fmt.Sprintf("hold %d has expired", hold.ID)}
+	}
+	return nil
+}
+
+// release gives the held funds back to the available balance and closes the hold with the given status.
+// The balance of the account must be locked already
+func (r *holdRepository) release(ctx context.Context, tx *sql.Tx, hold *holdentity.HoldEntity, status string) errors.AppError {
+	if err := r.updateHeldAmount(ctx, tx, hold.AccountID, hold.Amount.Neg()); err != nil {
+		return err
+	}
+	hold.Status = status
+	return r.updateStatus(ctx, tx, hold)
+}
@@ -0,0 +1,123 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	idempotency_entity "src/domain/idempotency"
+	errors "src/errors"
+
+	"go.uber.org/zap"
+)
+
+type IdempotencyRepository interface {
+	FetchByKey(ctx context.Context, clientID int, key string) (idempotency_entity.IdempotencyKeyEntity, errors.AppError)
+	Reserve(ctx context.Context, entity *idempotency_entity.IdempotencyKeyEntity) (bool, errors.AppError)
+	Complete(ctx context.Context, ID int, status int, body string) errors.AppError
+	Release(ctx context.Context, ID int) errors.AppError
+	DeleteExpired(ctx context.Context) (int64, errors.AppError)
+}
+
+type idempotencyRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewIdempotencyRepository(db *sql.DB, logger *zap.Logger) IdempotencyRepository {
+	if db == nil {
+		panic("db cannot be nil")
+	}
+	return &idempotencyRepository{db: db, logger: logger}
+}
+
+// FetchByKey returns the non expired idempotency key of a client
+func (r *idempotencyRepository) FetchByKey(ctx context.Context, clientID int, key string) (idempotency_entity.IdempotencyKeyEntity, errors.AppError) {
+	query := `
+	 SELECT id, client_id, idempotency_key, request_hash, response_status, response_body, created_at, expires_at
+	 FROM idempotency_keys
+	 WHERE client_id = $1 AND idempotency_key = $2 AND expires_at > CURRENT_TIMESTAMP
+	`
+	var entity idempotency_entity.IdempotencyKeyEntity
+	err := r.db.QueryRowContext(ctx, query, clientID, key).Scan(
+		&entity.ID,
+		&entity.ClientID,
+		&entity.IdempotencyKey,
+		&entity.RequestHash,
+		&entity.ResponseStatus,
+		&entity.ResponseBody,
+		&entity.CreatedAt,
+		&entity.ExpiresAt,
+	)
+	if err == sql.ErrNoRows {
+		return idempotency_entity.IdempotencyKeyEntity{}, &errors.ErrNotFound{Entity: "Idempotency Key", Reason: err}
+	}
+	if err != nil {
+		r.logger.Error("Error occurred while fetching idempotency key: " + err.Error())
+		return idempotency_entity.IdempotencyKeyEntity{}, &errors.ErrInternalServer{Reason: err}
+	}
+	return entity, nil
+}
+
+// Reserve inserts the key without a response. An expired key with the same value is taken over.
+// It returns false when the key is already owned by another (in progress or completed) request.
+func (r *idempotencyRepository) Reserve(ctx context.Context, entity *idempotency_entity.IdempotencyKeyEntity) (bool, errors.AppError) {
+	query := `
+	INSERT INTO idempotency_keys (
+            client_id, idempotency_key, request_hash, expires_at
+        ) VALUES ($1, $2, $3, $4)
+	ON CONFLICT (client_id, idempotency_key) DO UPDATE SET
+		request_hash = EXCLUDED.request_hash,
+		response_status = NULL,
+		response_body = NULL,
+		created_at = CURRENT_TIMESTAMP,
+		expires_at = EXCLUDED.expires_at
+	WHERE idempotency_keys.expires_at <= CURRENT_TIMESTAMP
+	RETURNING id, created_at`
+
+	err := r.db.QueryRowContext(ctx, query,
+		entity.ClientID,
+		entity.IdempotencyKey,
+		entity.RequestHash,
+		entity.ExpiresAt,
+	).Scan(&entity.ID, &entity.CreatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while reserving idempotency key %s: %s", entity.IdempotencyKey, err.Error()))
+		return false, &errors.ErrInternalServer{Reason: err}
+	}
+	return true, nil
+}
+
+func (r *idempotencyRepository) Complete(ctx context.Context, ID int, status int, body string) errors.AppError {
+	query := `UPDATE idempotency_keys SET response_status = $1, response_body = $2 WHERE id = $3`
+	_, err := r.db.ExecContext(ctx, query, status, body, ID)
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while completing idempotency key %d: %s", ID, err.Error()))
+		return &errors.ErrInternalServer{Reason: err}
+	}
+	return nil
+}
+
+// Release deletes a reserved key so the request can be retried with it
+func (r *idempotencyRepository) Release(ctx context.Context, ID int) errors.AppError {
+	query := `DELETE FROM idempotency_keys WHERE id = $1 AND response_status IS NULL`
+	_, err := r.db.ExecContext(ctx, query, ID)
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while releasing idempotency key %d: %s", ID, err.Error()))
+		return &errors.ErrInternalServer{Reason: err}
+	}
+	return nil
+}
+
+func (r *idempotencyRepository) DeleteExpired(ctx context.Context) (int64, errors.AppError) {
+	query := `DELETE FROM idempotency_keys WHERE expires_at <= CURRENT_TIMESTAMP`
+	result, err := r.db.ExecContext(ctx, query)
+	if err != nil {
+		r.logger.Error("Error occurred while deleting expired idempotency keys: " + err.Error())
+		return 0, &errors.ErrInternalServer{Reason: err}
+	}
+	rows, _ := result.RowsAffected()
+	return rows, nil
+}
@@ -0,0 +1,358 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	accountentity "src/domain/account"
+	paymententity "src/domain/payment"
+	transaction_entity "src/domain/transaction"
+	errors "src/errors"
+
+	"go.uber.org/zap"
+)
+
+type InboundPaymentRepository interface {
+	InsertInboundPayment(ctx context.Context, payment *paymententity.InboundPaymentEntity) (bool, errors.AppError)
+	FetchInboundPayment(ctx context.Context, ID int) (paymententity.InboundPaymentEntity, errors.AppError)
+	FetchInboundPayments(ctx context.Context, status string) ([]paymententity.InboundPaymentEntity, errors.AppError)
+	ResolveInboundPayment(ctx context.Context, ID int, accountNumber string) (paymententity.InboundPaymentEntity, errors.AppError)
+	ReturnInboundPayment(ctx context.Context, ID int, reasonCode string) (paymententity.InboundPaymentEntity, errors.AppError)
+	ClaimPendingReturns(ctx context.Context, messageID string, limit int) ([]paymententity.InboundPaymentEntity, errors.AppError)
+	ReleaseReturns(ctx context.Context, messageID string) errors.AppError
+}
+
+type inboundPaymentRepository struct {
+	db                    *sql.DB
+	logger                *zap.Logger
+	transactionRepository TransactionRepository
+}
+
+// The transaction repository posts the credits, resolutions and returns of the payments
+func NewInboundPaymentRepository(db *sql.DB, logger *zap.Logger, transactionRepository TransactionRepository) InboundPaymentRepository {
+	if db == nil {
+		panic("db cannot be nil")
+	}
+	return &inboundPaymentRepository{db: db, logger: logger, transactionRepository: transactionRepository}
+}
+
+const inboundPaymentColumns = `id, message_id, message_type, sequence, end_to_end_id, reference, debtor_iban, debtor_name,
+	creditor_iban, creditor_name, amount, currency, remittance_information, status, exception_reason, account_id,
+	transaction_id, resolution_transaction_id, return_reason, return_message_id, created_at, updated_at`
+
+func scanInboundPayment(row rowScanner, entity *paymententity.InboundPaymentEntity) error {
+	return row.Scan(
+		&entity.ID,
+		&entity.MessageID,
+		&entity.MessageType,
+		&entity.Sequence,
+		&entity.EndToEndID,
+		&entity.Reference,
+		&entity.DebtorIban,
+		&entity.DebtorName,
+		&entity.CreditorIban,
+		&entity.CreditorName,
+		&entity.Amount,
+		&entity.Currency,
+		&entity.RemittanceInformation,
+		&entity.Status,
+		&entity.ExceptionReason,
+		&entity.AccountID,
+		&entity.TransactionID,
+		&entity.ResolutionID,
+		&entity.ReturnReason,
+		&entity.ReturnMessageID,
+		&entity.CreatedAt,
+		&entity.UpdatedAt,
+	)
+}
+
+func (r *inboundPaymentRepository) queryInboundPayments(ctx context.Context, query string, args ...any) ([]paymententity.InboundPaymentEntity, errors.AppError) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("Error occurred while fetching inbound payments: " + err.Error())
+		return nil, &errors.ErrInternalServer{Reason: err}
+	}
+	defer rows.Close()
+	payments := make([]paymententity.InboundPaymentEntity, 0)
+	for rows.Next() {
+		var payment paymententity.InboundPaymentEntity
+		if err := scanInboundPayment(rows, &payment); err != nil {
+			r.logger.Error("Error occurred while scanning inbound payment: " + err.Error())
+			return nil, &errors.ErrInternalServer{Reason: err}
+		}
+		payments = append(payments, payment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, &errors.ErrInternalServer{Reason: err}
+	}
+	return payments, nil
+}
+
+/**
+* Database transaction to post a credit transfer received from another bank
+* 1. Skip it when the same position of the same file was posted already (i.e. a file read twice)
+* 2. Find the client account with the creditor IBAN, and lock it so its status cannot change meanwhile
+* 3. Post the SEPA_CREDIT from the settlement account to the account, if it can receive money,
+*    otherwise to the suspense account, as an EXCEPTION for bank staff
+* 4. Record the payment
+*
+* Returns whether the payment was posted now.
+ */
+func (r *inboundPaymentRepository) InsertInboundPayment(ctx context.Context, payment *paymententity.InboundPaymentEntity) (bool, errors.AppError) {
+	posted := false
+	appErr := r.transactionRepository.RunInTx(ctx, func(tx *sql.Tx) errors.AppError {
+		posted = false
+		var exists bool
+		query := `SELECT EXISTS (SELECT 1 FROM inbound_payments WHERE message_id = $1 AND sequence = $2)`
+		if err := tx.QueryRowContext(ctx, query, payment.MessageID, payment.Sequence).Scan(&exists); err != nil {
+			r.logger.Error(fmt.Sprintf("Error occurred while checking inbound payment %s/%d: %s", payment.MessageID, payment.Sequence, err.Error()))
+			return &errors.ErrInternalServer{Reason: err}
+		}
+		if exists {
+			return nil
+		}
+
+		settlementID, appErr := r.transactionRepository.FetchInternalAccountId(ctx, tx, accountentity.InternalSepaSettlement, payment.Currency)
+		if appErr != nil {
+			return appErr
+		}
+		payment.Status = paymententity.InboundStatusCredited
+		payment.ExceptionReason = sql.NullString{}
+		payment.AccountID = sql.NullInt32{}
+		var accountID int
+		var status string
+		query = `SELECT id, status FROM accounts WHERE account_number = $1 AND internal_code IS NULL FOR SHARE`
+		err := tx.QueryRowContext(ctx, query, payment.CreditorIban).Scan(&accountID, &status)
+		switch {
+		case err == sql.ErrNoRows:
+			payment.Status = paymententity.InboundStatusException
+			payment.ExceptionReason = sql.NullString{String: paymententity.ExceptionAccountNotFound, Valid: true}
+		case err != nil:
+			r.logger.Error(fmt.Sprintf("Error occurred while fetching account %s: %s", payment.CreditorIban, err.Error()))
+			return &errors.ErrInternalServer{Reason: err}
+		case !accountentity.CanReceive(status):
+			payment.Status = paymententity.InboundStatusException
+			payment.ExceptionReason = sql.NullString{String: paymententity.ExceptionAccountNotActive, Valid: true}
+		default:
+			payment.AccountID = sql.NullInt32{Int32: int32(accountID), Valid: true}
+		}
+		if payment.Status == paymententity.InboundStatusException {
+			if accountID, appErr = r.transactionRepository.FetchInternalAccountId(ctx, tx, accountentity.InternalSuspense, payment.Currency); appErr != nil {
+				return appErr
+			}
+		}
+
+		credit := transaction_entity.TransactionEntity{
+			AccountID:       settlementID,
+			ToAccountID:     sql.NullInt32{Int32: int32(accountID), Valid: true},
+			ToAccountNumber: payment.DebtorIban,
+			Type:            transaction_entity.SepaCreditType,
+			Amount:          payment.Amount,
+		}
+		if appErr := r.transactionRepository.InsertSettlementLedger(ctx, tx, &credit); appErr != nil {
+			return appErr
+		}
+		payment.TransactionID = credit.ID
+
+		query = `
+		INSERT INTO inbound_payments (
+			message_id, message_type, sequence, end_to_end_id, reference, debtor_iban, debtor_name, creditor_iban,
+			creditor_name, amount, currency, remittance_information, status, exception_reason, account_id, transaction_id
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+		RETURNING ` + inboundPaymentColumns
+		err = scanInboundPayment(tx.QueryRowContext(ctx, query,
+			payment.MessageID,
+			payment.MessageType,
+			payment.Sequence,
+			payment.EndToEndID,
+			payment.Reference,
+			payment.DebtorIban,
+			payment.DebtorName,
+			payment.CreditorIban,
+			payment.CreditorName,
+			payment.Amount,
+			payment.Currency,
+			payment.RemittanceInformation,
+			payment.Status,
+			payment.ExceptionReason,
+			payment.AccountID,
+			payment.TransactionID,
+		), payment)
+		if err != nil {
+			r.logger.Error(fmt.Sprintf("Error occurred while recording inbound payment %s/%d: %s", payment.MessageID, payment.Sequence, err.Error()))
+			return &errors.ErrInternalServer{Reason: err}
+		}
+		posted = true
+		return nil
+	})
+	return posted, appErr
+}
+
+func (r *inboundPaymentRepository) FetchInboundPayment(ctx context.Context, ID int) (paymententity.InboundPaymentEntity, errors.AppError) {
+	var payment paymententity.InboundPaymentEntity
+	query := `SELECT ` + inboundPaymentColumns + ` FROM inbound_payments WHERE id = $1`
+	err := scanInboundPayment(r.db.QueryRowContext(ctx, query, ID), &payment)
+	if err == sql.ErrNoRows {
+		return paymententity.InboundPaymentEntity{}, &errors.ErrNotFound{Entity: "Inbound payment", Reason: err}
+	}
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while fetching inbound payment %d: %s", ID, err.Error()))
+		return paymententity.InboundPaymentEntity{}, &errors.ErrInternalServer{Reason: err}
+	}
+	return payment, nil
+}
+
+// FetchInboundPayments returns the inbound payments with the given status (every one when empty), oldest first
+func (r *inboundPaymentRepository) FetchInboundPayments(ctx context.Context, status string) ([]paymententity.InboundPaymentEntity, errors.AppError) {
+	query := `SELECT ` + inboundPaymentColumns + ` FROM inbound_payments WHERE ($1 = '' OR status = $1) ORDER BY id`
+	return r.queryInboundPayments(ctx, query, status)
+}
+
+// lockException locks an inbound payment that must still be an exception
+func (r *inboundPaymentRepository) lockException(ctx context.Context, tx *sql.Tx, ID int) (paymententity.InboundPaymentEntity, errors.AppError) {
+	var payment paymententity.InboundPaymentEntity
+	query := `SELECT ` + inboundPaymentColumns + ` FROM inbound_payments WHERE id = $1 FOR UPDATE`
+	err := scanInboundPayment(tx.QueryRowContext(ctx, query, ID), &payment)
+	if err == sql.ErrNoRows {
+		return payment, &errors.ErrNotFound{Entity: "Inbound payment", Reason: err}
+	}
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while fetching inbound payment %d: %s", ID, err.Error()))
+		return payment, &errors.ErrInternalServer{Reason: err}
+	}
+	if payment.Status != paymententity.InboundStatusException {
+		return payment, &errors.ErrConflict{Message: fmt.Sprintf("inbound payment %d is %s, only exceptions can be resolved or returned", ID, payment.Status)}
+	}
+	return payment, nil
+}
+
+func (r *inboundPaymentRepository) updateResolution(ctx context.Context, tx *sql.Tx, payment *paymententity.InboundPaymentEntity) errors.AppError {
+	query := `
+	UPDATE inbound_payments
+	SET status = $1, account_id = $2, resolution_transaction_id = $3, return_reason = $4, updated_at = CURRENT_TIMESTAMP
+	WHERE id = $5
+	RETURNING ` + inboundPaymentColumns
+	err := scanInboundPayment(tx.QueryRowContext(ctx, query, payment.Status, payment.AccountID, payment.ResolutionID, payment.ReturnReason, payment.ID), payment)
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while updating inbound payment %d: %s", payment.ID, err.Error()))
+		return &errors.ErrInternalServer{Reason: err}
+	}
+	return nil
+}
+
+// ResolveInboundPayment moves an exception from the suspense account to the client account with the given
+// IBAN, which must be able to receive money
+func (r *inboundPaymentRepository) ResolveInboundPayment(ctx context.Context, ID int, accountNumber string) (paymententity.InboundPaymentEntity, errors.AppError) {
+	var payment paymententity.InboundPaymentEntity
+	appErr := r.transactionRepository.RunInTx(ctx, func(tx *sql.Tx) errors.AppError {
+		var appErr errors.AppError
+		if payment, appErr = r.lockException(ctx, tx, ID); appErr != nil {
+			return appErr
+		}
+		var accountID int
+		query := `SELECT id FROM accounts WHERE account_number = $1 AND internal_code IS NULL`
+		err := tx.QueryRowContext(ctx, query, accountNumber).Scan(&accountID)
+		if err == sql.ErrNoRows {
+			return &errors.ErrNotFound{Entity: "Account", Reason: err}
+		}
+		if err != nil {
+			r.logger.Error(fmt.Sprintf("Error occurred while fetching account %s: %s", accountNumber, err.Error()))
+			return &errors.ErrInternalServer{Reason: err}
+		}
+		suspenseID, appErr := r.transactionRepository.FetchInternalAccountId(ctx, tx, accountentity.InternalSuspense, payment.Currency)
+		if appErr != nil {
+			return appErr
+		}
+		release := transaction_entity.TransactionEntity{
+			AccountID:       suspenseID,
+			ToAccountID:     sql.NullInt32{Int32: int32(accountID), Valid: true},
+			ToAccountNumber: payment.DebtorIban,
+			Type:            transaction_entity.SepaCreditType,
+			Amount:          payment.Amount,
+		}
+		if appErr := r.transactionRepository.InsertSettlementLedger(ctx, tx, &release); appErr != nil {
+			return appErr
+		}
+		payment.Status = paymententity.InboundStatusResolved
+		payment.AccountID = sql.NullInt32{Int32: int32(accountID), Valid: true}
+		payment.ResolutionID = sql.NullInt32{Int32: int32(release.ID), Valid: true}
+		return r.updateResolution(ctx, tx, &payment)
+	})
+	return payment, appErr
+}
+
+// ReturnInboundPayment sends an exception back to the bank of the debtor: the amount leaves the suspense account
+// for the settlement account, and the return waits for the next pacs.004 batch
+func (r *inboundPaymentRepository) ReturnInboundPayment(ctx context.Context, ID int, reasonCode string) (paymententity.InboundPaymentEntity, errors.AppError) {
+	var payment paymententity.InboundPaymentEntity
+	appErr := r.transactionRepository.RunInTx(ctx, func(tx *sql.Tx) errors.AppError {
+		var appErr errors.AppError
+		if payment, appErr = r.lockException(ctx, tx, ID); appErr != nil {
+			return appErr
+		}
+		if reasonCode == "" {
+			reasonCode = paymententity.DefaultReturnReason(payment.ExceptionReason.String)
+		}
+		if !paymententity.IsValidReturnReason(reasonCode) {
+			return &errors.ErrBadRequest{Message: fmt.Sprintf("reason_code must be one of %v", paymententity.ReturnReasonCodes)}
+		}
+		suspenseID, appErr := r.transactionRepository.FetchInternalAccountId(ctx, tx, accountentity.InternalSuspense, payment.Currency)
+		if appErr != nil {
+			return appErr
+		}
+		settlementID, appErr := r.transactionRepository.FetchInternalAccountId(ctx, tx, accountentity.InternalSepaSettlement, payment.Currency)
+		if appErr != nil {
+			return appErr
+		}
+		refund := transaction_entity.TransactionEntity{
+			AccountID:       suspenseID,
+			ToAccountID:     sql.NullInt32{Int32: int32(settlementID), Valid: true},
+			ToAccountNumber: payment.DebtorIban,
+			Type:            transaction_entity.SepaReturnType,
+			Amount:          payment.Amount,
+			ReasonCode:      sql.NullString{String: reasonCode, Valid: true},
+		}
+		if appErr := r.transactionRepository.InsertSettlementLedger(ctx, tx, &refund); appErr != nil {
+			return appErr
+		}
+		payment.Status = paymententity.InboundStatusReturned
+		payment.ResolutionID = sql.NullInt32{Int32: int32(refund.ID), Valid: true}
+		payment.ReturnReason = sql.NullString{String: reasonCode, Valid: true}
+		return r.updateResolution(ctx, tx, &payment)
+	})
+	return payment, appErr
+}
+
+// ClaimPendingReturns assigns up to limit returned payments not sent yet, oldest first, to the pacs.004
+// with the given MsgId and returns them. Returns claimed by another worker at the same time are skipped.
+func (r *inboundPaymentRepository) ClaimPendingReturns(ctx context.Context, messageID string, limit int) ([]paymententity.InboundPaymentEntity, errors.AppError) {
+	query := `
+	UPDATE inbound_payments SET return_message_id = $1, updated_at = CURRENT_TIMESTAMP
+	WHERE id IN (
+		SELECT id FROM inbound_payments
+		WHERE status = $2 AND return_message_id IS NULL
+		ORDER BY id
+		LIMIT $3
+		FOR UPDATE SKIP LOCKED
+	)
+	RETURNING ` + inboundPaymentColumns
+	payments, appErr := r.queryInboundPayments(ctx, query, messageID, paymententity.InboundStatusReturned, limit)
+	if appErr != nil {
+		return nil, appErr
+	}
+	// RETURNING keeps no order
+	slices.SortFunc(payments, func(a, b paymententity.InboundPaymentEntity) int { return a.ID - b.ID })
+	return payments, nil
+}
+
+// ReleaseReturns puts back the returns of a pacs.004 that could not be sent, for the next one
+func (r *inboundPaymentRepository) ReleaseReturns(ctx context.Context, messageID string) errors.AppError {
+	query := `UPDATE inbound_payments SET return_message_id = NULL, updated_at = CURRENT_TIMESTAMP WHERE return_message_id = $1`
+	if _, err := r.db.ExecContext(ctx, query, messageID); err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while releasing returns %s: %s", messageID, err.Error()))
+		return &errors.ErrInternalServer{Reason: err}
+	}
+	return nil
+}
@@ -0,0 +1,405 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math/big"
+	accountentity "src/domain/account"
+	interestentity "src/domain/interest"
+	"src/domain/money"
+	transaction_entity "src/domain/transaction"
+	errors "src/errors"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type InterestRepository interface {
+	FetchInterestRates(ctx context.Context) ([]interestentity.InterestRateEntity, errors.AppError)
+	UpsertInterestRate(ctx context.Context, rate *interestentity.InterestRateEntity) errors.AppError
+	DeleteInterestRate(ctx context.Context, ID int, today time.Time) errors.AppError
+	FetchPendingAccruals(ctx context.Context, accountID int) ([]interestentity.InterestAccrualEntity, errors.AppError)
+	AccrueInterest(ctx context.Context, day time.Time) (int, errors.AppError)
+	AccrueInterestUntil(ctx context.Context, until time.Time) (int, errors.AppError)
+	CapitaliseInterest(ctx context.Context, periodStart time.Time) (int, errors.AppError)
+	CapitaliseAccount(ctx context.Context, tx *sql.Tx, accountID int, periodStart time.Time) (bool, errors.AppError)
+}
+
+type interestRepository struct {
+	db                    *sql.DB
+	logger                *zap.Logger
+	transactionRepository TransactionRepository
+}
+
+// The transaction repository posts the credit interest
+func NewInterestRepository(db *sql.DB, logger *zap.Logger, transactionRepository TransactionRepository) InterestRepository {
+	if db == nil {
+		panic("db cannot be nil")
+	}
+	return &interestRepository{db: db, logger: logger, transactionRepository: transactionRepository}
+}
+
+const interestRateColumns = `id, product, currency, rate, day_count, effective_from, created_at`
+
+func scanInterestRate(row rowScanner, entity *interestentity.InterestRateEntity) error {
+	return row.Scan(
+		&entity.ID,
+		&entity.Product,
+		&entity.Currency,
+		&entity.Rate,
+		&entity.DayCount,
+		&entity.EffectiveFrom,
+		&entity.CreatedAt,
+	)
+}
+
+const interestAccrualColumns = `id, account_id, accrual_date, balance, interest_rate, day_count, amount, capitalisation_id, created_at`
+
+func scanInterestAccrual(row rowScanner, entity *interestentity.InterestAccrualEntity) error {
+	return row.Scan(
+		&entity.ID,
+		&entity.AccountID,
+		&entity.AccrualDate,
+		&entity.Balance,
+		&entity.InterestRate,
+		&entity.DayCount,
+		&entity.Amount,
+		&entity.CapitalisationID,
+		&entity.CreatedAt,
+	)
+}
+
+func (r *interestRepository) FetchInterestRates(ctx context.Context) ([]interestentity.InterestRateEntity, errors.AppError) {
+	query := `SELECT ` + interestRateColumns + ` FROM interest_rates ORDER BY product, currency, effective_from`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		r.logger.Error("Error occurred while fetching interest rates: " + err.Error())
+		return nil, &errors.ErrInternalServer{Reason: err}
+	}
+	defer rows.Close()
+	rates := make([]interestentity.InterestRateEntity, 0)
+	for rows.Next() {
+		var rate interestentity.InterestRateEntity
+		if err := scanInterestRate(rows, &rate); err != nil {
+			r.logger.Error("Error occurred while scanning interest rate: " + err.Error())
+			return nil, &errors.ErrInternalServer{Reason: err}
+		}
+		rates = append(rates, rate)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, &errors.ErrInternalServer{Reason: err}
+	}
+	return rates, nil
+}
+
+// UpsertInterestRate stores the rate of a product and currency from a day, replacing the one set for that same day
+func (r *interestRepository) UpsertInterestRate(ctx context.Context, rate *interestentity.InterestRateEntity) errors.AppError {
+	query := `
+	INSERT INTO interest_rates (product, currency, rate, day_count, effective_from)
+	VALUES ($1, $2, $3, $4, $5)
+	ON CONFLICT (product, currency, effective_from) DO UPDATE SET
+		rate = EXCLUDED.rate,
+		day_count = EXCLUDED.day_count
+	RETURNING ` + interestRateColumns
+	err := scanInterestRate(r.db.QueryRowContext(ctx, query,
+		rate.Product,
+		rate.Currency,
+		rate.Rate,
+		rate.DayCount,
+		rate.EffectiveFrom,
+	), rate)
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while storing the %s %s interest rate: %s", rate.Product, rate.Currency, err.Error()))
+		return &errors.ErrInternalServer{Reason: err}
+	}
+	return nil
+}
+
+// DeleteInterestRate deletes a rate that is not in force yet. The rates already applied are kept, as the
+// accruals are computed with them: a new rate replaces them from its effective date.
+func (r *interestRepository) DeleteInterestRate(ctx context.Context, ID int, today time.Time) errors.AppError {
+	var effectiveFrom time.Time
+	err := r.db.QueryRowContext(ctx, `SELECT effective_from FROM interest_rates WHERE id = $1`, ID).Scan(&effectiveFrom)
+	if err == sql.ErrNoRows {
+		return &errors.ErrNotFound{Entity: "Interest rate", Reason: err}
+	}
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while fetching interest rate %d: %s", ID, err.Error()))
+		return &errors.ErrInternalServer{Reason: err}
+	}
+	if !effectiveFrom.After(today) {
+		return &errors.ErrConflict{Message: "the interest rate is already in force, set a new rate instead"}
+	}
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM interest_rates WHERE id = $1 AND effective_from > $2`, ID, today); err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while deleting interest rate %d: %s", ID, err.Error()))
+		return &errors.ErrInternalServer{Reason: err}
+	}
+	return nil
+}
+
+// FetchPendingAccruals returns the accruals of the account that are not capitalised yet, oldest first
+func (r *interestRepository) FetchPendingAccruals(ctx context.Context, accountID int) ([]interestentity.InterestAccrualEntity, errors.AppError) {
+	query := `SELECT ` + interestAccrualColumns + ` FROM interest_accruals
+	WHERE account_id = $1 AND capitalisation_id IS NULL
+	ORDER BY accrual_date`
+	rows, err := r.db.QueryContext(ctx, query, accountID)
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while fetching interest accruals of account %d: %s", accountID, err.Error()))
+		return nil, &errors.ErrInternalServer{Reason: err}
+	}
+	defer rows.Close()
+	accruals := make([]interestentity.InterestAccrualEntity, 0)
+	for rows.Next() {
+		var accrual interestentity.InterestAccrualEntity
+		if err := scanInterestAccrual(rows, &accrual); err != nil {
+			r.logger.Error("Error occurred while scanning interest accrual: " + err.Error())
+			return nil, &errors.ErrInternalServer{Reason: err}
+		}
+		accruals = append(accruals, accrual)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, &errors.ErrInternalServer{Reason: err}
+	}
+	return accruals, nil
+}
+
+// AccrueInterest records the interest earned on the given day by every client account with a positive end of day
+// balance and a rate in force that day for its product and currency. It returns how many accounts accrued.
+// Accounts that already accrued the day are skipped, so running it twice for the same day accrues nothing.
+func (r *interestRepository) AccrueInterest(ctx context.Context, day time.Time) (int, errors.AppError) {
+	query := `
+	WITH end_of_day AS (
+		SELECT le.account_id, SUM(CASE WHEN UPPER(le.type) = 'CREDIT' THEN le.amount ELSE -le.amount END) AS balance
+		FROM ledger_entries le
+		WHERE le.created_at < $1
+		GROUP BY le.account_id
+	)
+	SELECT a.id, e.balance, ir.rate, ir.day_count
+	FROM end_of_day e
+	JOIN accounts a ON a.id = e.account_id
+	JOIN LATERAL (
+		SELECT rate, day_count FROM interest_rates
+		WHERE product = a.product AND currency = a.currency AND effective_from <= $2
+		ORDER BY effective_from DESC
+		LIMIT 1
+	) ir ON TRUE
+	WHERE a.internal_code IS NULL
+	  AND a.status <> $3
+	  AND e.balance > 0
+	  AND ir.rate > 0
+	  AND NOT EXISTS (
+		SELECT 1 FROM interest_accruals ia WHERE ia.account_id = a.id AND ia.accrual_date = $2
+	  )
+	ORDER BY a.id`
+	rows, err := r.db.QueryContext(ctx, query, day.AddDate(0, 0, 1), day, accountentity.StatusClosed)
+	if err != nil {
+		r.logger.Error("Error occurred while fetching the accounts earning interest: " + err.Error())
+		return 0, &errors.ErrInternalServer{Reason: err}
+	}
+	accruals := []interestentity.InterestAccrualEntity{}
+	for rows.Next() {
+		accrual := interestentity.InterestAccrualEntity{AccrualDate: day}
+		if err := rows.Scan(&accrual.AccountID, &accrual.Balance, &accrual.InterestRate, &accrual.DayCount); err != nil {
+			rows.Close()
+			return 0, &errors.ErrInternalServer{Reason: err}
+		}
+		accruals = append(accruals, accrual)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, &errors.ErrInternalServer{Reason: err}
+	}
+
+	// an account that fails does not stop the others, the first error is returned once all are done
+	accrued := 0
+	var failed errors.AppError
+	for _, accrual := range accruals {
+		inserted, appErr := r.insertAccrual(ctx, accrual)
+		if appErr != nil {
+			r.logger.Error(fmt.Sprintf("Interest of account %d on %s could not be accrued: %s", accrual.AccountID, day.Format(time.DateOnly), appErr.Error()))
+			if failed == nil {
+				failed = appErr
+			}
+			continue
+		}
+		if inserted {
+			accrued++
+		}
+	}
+	return accrued, failed
+}
+
+// insertAccrual computes the interest of the accrual and records it, unless the account already accrued the day
+func (r *interestRepository) insertAccrual(ctx context.Context, accrual interestentity.InterestAccrualEntity) (bool, errors.AppError) {
+	rate, err := accountentity.ParseInterestRate(accrual.InterestRate)
+	if err != nil {
+		return false, &errors.ErrInternalServer{Reason: err}
+	}
+	amount, err := interestentity.DailyInterest(accrual.Balance, rate, accrual.DayCount)
+	if err != nil {
+		return false, &errors.ErrInternalServer{Reason: err}
+	}
+	accrual.Amount = amount.FloatString(interestentity.AccrualScale)
+
+	query := `
+	INSERT INTO interest_accruals (account_id, accrual_date, balance, interest_rate, day_count, amount)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	ON CONFLICT (account_id, accrual_date) DO NOTHING`
+	result, err := r.db.ExecContext(ctx, query, accrual.AccountID, accrual.AccrualDate, accrual.Balance, accrual.InterestRate, accrual.DayCount, accrual.Amount)
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while recording the interest of account %d: %s", accrual.AccountID, err.Error()))
+		return false, &errors.ErrInternalServer{Reason: err}
+	}
+	inserted, _ := result.RowsAffected()
+	return inserted > 0, nil
+}
+
+// AccrueInterestUntil accrues the interest of every day from the one after the last day completed up to until,
+// one day at a time, and returns how many accruals were recorded. A day with an account that could not accrue is
+// accrued again by the next run, which skips the accounts that accrued already. See runDailyJob.
+func (r *interestRepository) AccrueInterestUntil(ctx context.Context, until time.Time) (int, errors.AppError) {
+	return runDailyJob(ctx, r.db, r.logger, dailyJobInterestAccrual, until, func(day time.Time) (int, errors.AppError) {
+		return r.AccrueInterest(ctx, day)
+	})
+}
+
+// CapitaliseInterest pays the accruals of every account up to the end of the month starting on periodStart, the
+// ones of earlier months not paid yet included, and returns how many accounts were paid. Closed accounts are
+// skipped. Accounts already capitalised for the month are skipped too, so running it twice pays nothing.
+func (r *interestRepository) CapitaliseInterest(ctx context.Context, periodStart time.Time) (int, errors.AppError) {
+	periodEnd := periodStart.AddDate(0, 1, 0)
+	query := `
+	SELECT DISTINCT ia.account_id
+	FROM interest_accruals ia
+	JOIN accounts a ON a.id = ia.account_id
+	WHERE ia.capitalisation_id IS NULL
+	  AND ia.accrual_date < $1
+	  AND a.status <> $2
+	ORDER BY ia.account_id`
+	rows, err := r.db.QueryContext(ctx, query, periodEnd, accountentity.StatusClosed)
+	if err != nil {
+		r.logger.Error("Error occurred while fetching the accounts with interest to pay: " + err.Error())
+		return 0, &errors.ErrInternalServer{Reason: err}
+	}
+	accountIDs := []int{}
+	for rows.Next() {
+		var accountID int
+		if err := rows.Scan(&accountID); err != nil {
+			rows.Close()
+			return 0, &errors.ErrInternalServer{Reason: err}
+		}
+		accountIDs = append(accountIDs, accountID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, &errors.ErrInternalServer{Reason: err}
+	}
+
+	capitalised := 0
+	for _, accountID := range accountIDs {
+		posted, appErr := r.capitaliseAccount(ctx, accountID, periodStart)
+		if appErr != nil {
+			return capitalised, appErr
+		}
+		if posted {
+			capitalised++
+		}
+	}
+	return capitalised, nil
+}
+
+// capitaliseAccount capitalises the interest of one account and month in its own Tx. See CapitaliseAccount
+func (r *interestRepository) capitaliseAccount(ctx context.Context, accountID int, periodStart time.Time) (bool, errors.AppError) {
+	posted := false
+	appErr := r.transactionRepository.RunInTx(ctx, func(tx *sql.Tx) errors.AppError {
+		var err errors.AppError
+		posted, err = r.CapitaliseAccount(ctx, tx, accountID, periodStart)
+		return err
+	})
+	return posted, appErr
+}
+
+/**
+* Capitalises the interest of one account and month inside the given Tx, and tells whether it was posted
+* 1. Record the capitalisation. One already recorded for the month (i.e. by another worker) ends here
+* 2. Link the pending accruals up to the end of the month to it, adding up their amounts
+* 3. Round the sum half to even to the minor unit of the currency
+* 4. Post it as a CREDIT_INTEREST transaction from the interest expense account
+ */
+func (r *interestRepository) CapitaliseAccount(ctx context.Context, tx *sql.Tx, accountID int, periodStart time.Time) (bool, errors.AppError) {
+	capitalisation := interestentity.InterestCapitalisationEntity{AccountID: accountID, PeriodStart: periodStart}
+	query := `
+	INSERT INTO interest_capitalisations (account_id, period_start)
+	VALUES ($1, $2)
+	ON CONFLICT (account_id, period_start) DO NOTHING
+	RETURNING id, created_at`
+	err := tx.QueryRowContext(ctx, query, accountID, periodStart).Scan(&capitalisation.ID, &capitalisation.CreatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while recording the capitalisation of account %d: %s", accountID, err.Error()))
+		return false, &errors.ErrInternalServer{Reason: err}
+	}
+
+	query = `
+	UPDATE interest_accruals SET capitalisation_id = $1
+	WHERE account_id = $2 AND capitalisation_id IS NULL AND accrual_date < $3
+	RETURNING amount`
+	rows, err := tx.QueryContext(ctx, query, capitalisation.ID, accountID, periodStart.AddDate(0, 1, 0))
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while capitalising the accruals of account %d: %s", accountID, err.Error()))
+		return false, &errors.ErrInternalServer{Reason: err}
+	}
+	total := new(big.Rat)
+	for rows.Next() {
+		var amount string
+		if err := rows.Scan(&amount); err != nil {
+			rows.Close()
+			return false, &errors.ErrInternalServer{Reason: err}
+		}
+		value, ok := new(big.Rat).SetString(amount)
+		if !ok {
+			rows.Close()
+			return false, &errors.ErrInternalServer{Reason: fmt.Errorf("invalid interest accrual amount %q", amount)}
+		}
+		total.Add(total, value)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return false, &errors.ErrInternalServer{Reason: err}
+	}
+
+	balance, appErr := r.transactionRepository.FetchAccountBalance(ctx, tx, accountID)
+	if appErr != nil {
+		return false, appErr
+	}
+	capitalisation.Amount = money.FromRatTo(total, balance.MinorUnit, money.RoundHalfEven)
+	if !capitalisation.Amount.IsPositive() {
+		return false, r.updateCapitalisation(ctx, tx, capitalisation)
+	}
+
+	transaction := transaction_entity.TransactionEntity{
+		AccountID: accountID,
+		Type:      transaction_entity.CreditInterestType,
+		Amount:    capitalisation.Amount,
+	}
+	if appErr := r.transactionRepository.InsertPayoutLedger(ctx, tx, &transaction, accountentity.InternalInterestExpense); appErr != nil {
+		return false, appErr
+	}
+	capitalisation.TransactionID = sql.NullInt32{Int32: int32(transaction.ID), Valid: true}
+	if appErr := r.updateCapitalisation(ctx, tx, capitalisation); appErr != nil {
+		return false, appErr
+	}
+	return true, nil
+}
+
+func (r *interestRepository) updateCapitalisation(ctx context.Context, tx *sql.Tx, capitalisation interestentity.InterestCapitalisationEntity) errors.AppError {
+	query := `UPDATE interest_capitalisations SET amount = $1, transaction_id = $2 WHERE id = $3`
+	if _, err := tx.ExecContext(ctx, query, capitalisation.Amount, capitalisation.TransactionID, capitalisation.ID); err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while updating the capitalisation of account %d: %s", capitalisation.AccountID, err.Error()))
+		return &errors.ErrInternalServer{Reason: err}
+	}
+	return nil
+}
@@ -0,0 +1,250 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math/big"
+	accountentity "src/domain/account"
+	transaction_entity "src/domain/transaction"
+	errors "src/errors"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type OverdraftRepository interface {
+	FetchOverdraft(ctx context.Context, accountID int) (accountentity.OverdraftEntity, errors.AppError)
+	UpsertOverdraft(ctx context.Context, overdraft *accountentity.OverdraftEntity) errors.AppError
+	DeleteOverdraft(ctx context.Context, accountID int) errors.AppError
+	ChargeDebitInterest(ctx context.Context, day time.Time, unarrangedRate *big.Rat) (int, errors.AppError)
+	ChargeDebitInterestUntil(ctx context.Context, until time.Time, unarrangedRate *big.Rat) (int, errors.AppError)
+}
+
+type overdraftRepository struct {
+	db                    *sql.DB
+	logger                *zap.Logger
+	transactionRepository TransactionRepository
+}
+
+// The transaction repository posts the debit interest
+func NewOverdraftRepository(db *sql.DB, logger *zap.Logger, transactionRepository TransactionRepository) OverdraftRepository {
+	if db == nil {
+		panic("db cannot be nil")
+	}
+	return &overdraftRepository{db: db, logger: logger, transactionRepository: transactionRepository}
+}
+
+const overdraftColumns = `id, account_id, limit_amount, interest_rate, valid_from, valid_to, created_at, updated_at`
+
+func scanOverdraft(row rowScanner, entity *accountentity.OverdraftEntity) error {
+	return row.Scan(
+		&entity.ID,
+		&entity.AccountID,
+		&entity.Limit,
+		&entity.InterestRate,
+		&entity.ValidFrom,
+		&entity.ValidTo,
+		&entity.CreatedAt,
+		&entity.UpdatedAt,
+	)
+}
+
+func (r *overdraftRepository) FetchOverdraft(ctx context.Context, accountID int) (accountentity.OverdraftEntity, errors.AppError) {
+	var overdraft accountentity.OverdraftEntity
+	query := `SELECT ` + overdraftColumns + ` FROM overdraft_facilities WHERE account_id = $1`
+	err := scanOverdraft(r.db.QueryRowContext(ctx, query, accountID), &overdraft)
+	if err == sql.ErrNoRows {
+		return accountentity.OverdraftEntity{}, &errors.ErrNotFound{Entity: "Overdraft", Reason: err}
+	}
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while fetching overdraft of account %d: %s", accountID, err.Error()))
+		return accountentity.OverdraftEntity{}, &errors.ErrInternalServer{Reason: err}
+	}
+	return overdraft, nil
+}
+
+// UpsertOverdraft sets the overdraft of a client account, replacing the previous one
+func (r *overdraftRepository) UpsertOverdraft(ctx context.Context, overdraft *accountentity.OverdraftEntity) errors.AppError {
+	query := `
+	INSERT INTO overdraft_facilities (account_id, limit_amount, interest_rate, valid_from, valid_to)
+	SELECT a.id, $2, $3, $4, $5 FROM accounts a WHERE a.id = $1 AND a.internal_code IS NULL
+	ON CONFLICT (account_id) DO UPDATE SET
+		limit_amount = EXCLUDED.limit_amount,
+		interest_rate = EXCLUDED.interest_rate,
+		valid_from = EXCLUDED.valid_from,
+		valid_to = EXCLUDED.valid_to,
+		updated_at = CURRENT_TIMESTAMP
+	RETURNING ` + overdraftColumns
+	err := scanOverdraft(r.db.QueryRowContext(ctx, query,
+		overdraft.AccountID,
+		overdraft.Limit,
+		overdraft.InterestRate,
+		overdraft.ValidFrom,
+		overdraft.ValidTo,
+	), overdraft)
+	if err == sql.ErrNoRows {
+		return &errors.ErrNotFound{Entity: "Account", Reason: err}
+	}
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while setting overdraft of account %d: %s", overdraft.AccountID, err.Error()))
+		return &errors.ErrInternalServer{Reason: err}
+	}
+	return nil
+}
+
+// DeleteOverdraft removes the overdraft of the account. A balance already below zero stays there,
+// it just cannot go further.
+func (r *overdraftRepository) DeleteOverdraft(ctx context.Context, accountID int) errors.AppError {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM overdraft_facilities WHERE account_id = $1`, accountID)
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while deleting overdraft of account %d: %s", accountID, err.Error()))
+		return &errors.ErrInternalServer{Reason: err}
+	}
+	if deleted, _ := result.RowsAffected(); deleted == 0 {
+		return &errors.ErrNotFound{Entity: "Overdraft"}
+	}
+	return nil
+}
+
+// ChargeDebitInterest charges the interest of the given day on every client account whose end of day
+// balance was below zero, and returns how many accounts were charged. The rate is the one of the overdraft
+// in force that day, or unarrangedRate without one. Accounts already charged for the day are skipped, so
+// running it twice for the same day charges nothing.
+func (r *overdraftRepository) ChargeDebitInterest(ctx context.Context, day time.Time, unarrangedRate *big.Rat) (int, errors.AppError) {
+	endOfDay := day.AddDate(0, 0, 1)
+	query := `
+	SELECT le.account_id
+	FROM ledger_entries le
+	JOIN accounts a ON a.id = le.account_id
+	WHERE a.internal_code IS NULL
+	  AND le.created_at < $1
+	  AND NOT EXISTS (
+		SELECT 1 FROM overdraft_interest_charges oc WHERE oc.account_id = le.account_id AND oc.charge_date = $2
+	  )
+	GROUP BY le.account_id
+	HAVING SUM(CASE WHEN UPPER(le.type) = 'CREDIT' THEN le.amount ELSE -le.amount END) < 0
+	ORDER BY le.account_id`
+	rows, err := r.db.QueryContext(ctx, query, endOfDay, day)
+	if err != nil {
+		r.logger.Error("Error occurred while fetching overdrawn accounts: " + err.Error())
+		return 0, &errors.ErrInternalServer{Reason: err}
+	}
+	accountIDs := []int{}
+	for rows.Next() {
+		var accountID int
+		if err := rows.Scan(&accountID); err != nil {
+			rows.Close()
+			return 0, &errors.ErrInternalServer{Reason: err}
+		}
+		accountIDs = append(accountIDs, accountID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, &errors.ErrInternalServer{Reason: err}
+	}
+
+	// an account that fails does not stop the others, the first error is returned once all are done
+	charged := 0
+	var failed errors.AppError
+	for _, accountID := range accountIDs {
+		posted, appErr := r.chargeAccount(ctx, accountID, day, unarrangedRate)
+		if appErr != nil {
+			r.logger.Error(fmt.Sprintf("Debit interest of account %d on %s could not be charged: %s", accountID, day.Format(time.DateOnly), appErr.Error()))
+			if failed == nil {
+				failed = appErr
+			}
+			continue
+		}
+		if posted {
+			charged++
+		}
+	}
+	return charged, failed
+}
+
+// ChargeDebitInterestUntil charges the debit interest of every day from the one after the last day completed up
+// to until, one day at a time, and returns how many charges were posted. A day with an account that could not be
+// charged is charged again by the next run, which skips the accounts already charged. See runDailyJob.
+func (r *overdraftRepository) ChargeDebitInterestUntil(ctx context.Context, until time.Time, unarrangedRate *big.Rat) (int, errors.AppError) {
+	return runDailyJob(ctx, r.db, r.logger, dailyJobDebitInterest, until, func(day time.Time) (int, errors.AppError) {
+		return r.ChargeDebitInterest(ctx, day, unarrangedRate)
+	})
+}
+
+/**
+* Database transaction to charge the debit interest of one account and day
+* 1. Compute the end of day balance from the ledger entries
+* 2. Record the charge. A charge already recorded for the day (i.e. by another worker) ends the Tx
+* 3. Post the interest, rounded to the minor unit, as a DEBIT_INTEREST transaction against the interest income account
+ */
+func (r *overdraftRepository) chargeAccount(ctx context.Context, accountID int, day time.Time, unarrangedRate *big.Rat) (bool, errors.AppError) {
+	posted := false
+	appErr := r.transactionRepository.RunInTx(ctx, func(tx *sql.Tx) errors.AppError {
+		posted = false
+		charge := accountentity.OverdraftInterestChargeEntity{AccountID: accountID, ChargeDate: day}
+		query := `
+		SELECT COALESCE(SUM(CASE WHEN UPPER(type) = 'CREDIT' THEN amount ELSE -amount END), 0)
+		FROM ledger_entries
+		WHERE account_id = $1 AND created_at < $2`
+		if err := tx.QueryRowContext(ctx, query, accountID, day.AddDate(0, 0, 1)).Scan(&charge.Balance); err != nil {
+			r.logger.Error(fmt.Sprintf("Error occurred while computing end of day balance of account %d: %s", accountID, err.Error()))
+			return &errors.ErrInternalServer{Reason: err}
+		}
+
+		rate := unarrangedRate
+		query = `
+		SELECT interest_rate FROM overdraft_facilities
+		WHERE account_id = $1 AND valid_from <= $2 AND (valid_to IS NULL OR valid_to >= $2)`
+		err := tx.QueryRowContext(ctx, query, accountID, day).Scan(&charge.InterestRate)
+		if err != nil && err != sql.ErrNoRows {
+			r.logger.Error(fmt.Sprintf("Error occurred while fetching overdraft rate of account %d: %s", accountID, err.Error()))
+			return &errors.ErrInternalServer{Reason: err}
+		}
+		if err == nil {
+			if rate, err = accountentity.ParseInterestRate(charge.InterestRate); err != nil {
+				return &errors.ErrInternalServer{Reason: err}
+			}
+		}
+		charge.InterestRate = rate.FloatString(6)
+
+		balance, appErr := r.transactionRepository.FetchAccountBalance(ctx, tx, accountID)
+		if appErr != nil {
+			return appErr
+		}
+		charge.Amount = accountentity.DailyDebitInterest(charge.Balance, rate, balance.MinorUnit)
+
+		query = `
+		INSERT INTO overdraft_interest_charges (account_id, charge_date, balance, interest_rate, amount)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (account_id, charge_date) DO NOTHING
+		RETURNING id, created_at`
+		err = tx.QueryRowContext(ctx, query, accountID, day, charge.Balance, charge.InterestRate, charge.Amount).Scan(&charge.ID, &charge.CreatedAt)
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			r.logger.Error(fmt.Sprintf("Error occurred while recording debit interest of account %d: %s", accountID, err.Error()))
+			return &errors.ErrInternalServer{Reason: err}
+		}
+		if !charge.Amount.IsPositive() {
+			return nil
+		}
+
+		transaction := transaction_entity.TransactionEntity{
+			AccountID: accountID,
+			Type:      transaction_entity.DebitInterestType,
+			Amount:    charge.Amount,
+		}
+		if appErr := r.transactionRepository.InsertChargeLedger(ctx, tx, &transaction, accountentity.InternalInterestIncome); appErr != nil {
+			return appErr
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE overdraft_interest_charges SET transaction_id = $1 WHERE id = $2`, transaction.ID, charge.ID); err != nil {
+			r.logger.Error(fmt.Sprintf("Error occurred while linking debit interest of account %d: %s", accountID, err.Error()))
+			return &errors.ErrInternalServer{Reason: err}
+		}
+		posted = true
+		return nil
+	})
+	return posted, appErr
+}
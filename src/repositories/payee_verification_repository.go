@@ -0,0 +1,83 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	payeeentity "src/domain/payee"
+	errors "src/errors"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type PayeeVerificationRepository interface {
+	InsertVerification(ctx context.Context, verification *payeeentity.PayeeVerificationEntity, limits []payeeentity.RateLimit) errors.AppError
+}
+
+type payeeVerificationRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewPayeeVerificationRepository(db *sql.DB, logger *zap.Logger) PayeeVerificationRepository {
+	if db == nil {
+		panic("db cannot be nil")
+	}
+	return &payeeVerificationRepository{db: db, logger: logger}
+}
+
+// InsertVerification records the check when the client is within every limit, and returns ErrTooManyRequests
+// otherwise. The client row is locked, so concurrent checks of a client are counted one at a time.
+func (r *payeeVerificationRepository) InsertVerification(ctx context.Context, verification *payeeentity.PayeeVerificationEntity, limits []payeeentity.RateLimit) errors.AppError {
+	return runInTx(ctx, r.db, r.logger, &sql.TxOptions{Isolation: sql.LevelReadCommitted}, func(tx *sql.Tx) errors.AppError {
+		var clientID int
+		err := tx.QueryRowContext(ctx, `SELECT id FROM clients WHERE id = $1 FOR UPDATE`, verification.ClientID).Scan(&clientID)
+		if err == sql.ErrNoRows {
+			return &errors.ErrNotFound{Entity: "Client", Reason: err}
+		}
+		if err != nil {
+			r.logger.Error(fmt.Sprintf("Error occurred while locking client %d: %s", verification.ClientID, err.Error()))
+			return &errors.ErrInternalServer{Reason: err}
+		}
+		for _, limit := range limits {
+			if limit.Max <= 0 {
+				continue
+			}
+			// the Max-th latest check within the window: the limit is reached until it leaves the window
+			query := `
+			SELECT EXTRACT(EPOCH FROM created_at + make_interval(secs => $2) - CURRENT_TIMESTAMP)
+			FROM payee_verifications
+			WHERE client_id = $1 AND created_at > CURRENT_TIMESTAMP - make_interval(secs => $2)
+			ORDER BY created_at DESC
+			OFFSET $3 LIMIT 1`
+			var retryAfter float64
+			err := tx.QueryRowContext(ctx, query, verification.ClientID, limit.Window.Seconds(), limit.Max-1).Scan(&retryAfter)
+			if err == nil {
+				return &errors.ErrTooManyRequests{
+					Message:    fmt.Sprintf("no more than %d payee verifications every %s", limit.Max, limit.Window),
+					RetryAfter: time.Duration(retryAfter * float64(time.Second)),
+				}
+			}
+			if err != sql.ErrNoRows {
+				r.logger.Error(fmt.Sprintf("Error occurred while counting the payee verifications of client %d: %s", verification.ClientID, err.Error()))
+				return &errors.ErrInternalServer{Reason: err}
+			}
+		}
+		query := `
+		INSERT INTO payee_verifications (client_id, account_number, name, result)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at`
+		err = tx.QueryRowContext(ctx, query,
+			verification.ClientID,
+			verification.AccountNumber,
+			verification.Name,
+			verification.Result,
+		).Scan(&verification.ID, &verification.CreatedAt)
+		if err != nil {
+			r.logger.Error(fmt.Sprintf("Error occurred while inserting the payee verification of client %d: %s", verification.ClientID, err.Error()))
+			return &errors.ErrInternalServer{Reason: err}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,306 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	accountentity "src/domain/account"
+	paymententity "src/domain/payment"
+	transaction_entity "src/domain/transaction"
+	errors "src/errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type PaymentRepository interface {
+	InsertOutboundPayment(ctx context.Context, payment *paymententity.OutboundPaymentEntity, transaction *transaction_entity.TransactionEntity) errors.AppError
+	FetchOutboundPaymentsByAccount(ctx context.Context, accountID int) ([]paymententity.OutboundPaymentEntity, errors.AppError)
+	FetchOutboundPaymentsByBatch(ctx context.Context, messageID string) ([]paymententity.OutboundPaymentEntity, errors.AppError)
+	ClaimPendingPayments(ctx context.Context, messageID string, limit int, submit func(payments []paymententity.OutboundPaymentEntity) error) ([]paymententity.OutboundPaymentEntity, errors.AppError)
+	ApplyPaymentStatus(ctx context.Context, status paymententity.PaymentStatus) (bool, errors.AppError)
+}
+
+type paymentRepository struct {
+	db                    *sql.DB
+	logger                *zap.Logger
+	transactionRepository TransactionRepository
+}
+
+// The transaction repository posts the debits, settlements and refunds of the payments
+func NewPaymentRepository(db *sql.DB, logger *zap.Logger, transactionRepository TransactionRepository) PaymentRepository {
+	if db == nil {
+		panic("db cannot be nil")
+	}
+	return &paymentRepository{db: db, logger: logger, transactionRepository: transactionRepository}
+}
+
+const outboundPaymentColumns = `id, account_id, transaction_id, settlement_transaction_id, refund_transaction_id, end_to_end_id,
+	debtor_iban, debtor_name, creditor_iban, creditor_name, amount, currency, remittance_information, status,
+	batch_message_id, status_reason, created_at, updated_at`
+
+func scanOutboundPayment(row rowScanner, entity *paymententity.OutboundPaymentEntity) error {
+	return row.Scan(
+		&entity.ID,
+		&entity.AccountID,
+		&entity.TransactionID,
+		&entity.SettlementID,
+		&entity.RefundID,
+		&entity.EndToEndID,
+		&entity.DebtorIban,
+		&entity.DebtorName,
+		&entity.CreditorIban,
+		&entity.CreditorName,
+		&entity.Amount,
+		&entity.Currency,
+		&entity.RemittanceInformation,
+		&entity.Status,
+		&entity.BatchMessageID,
+		&entity.StatusReason,
+		&entity.CreatedAt,
+		&entity.UpdatedAt,
+	)
+}
+
+func (r *paymentRepository) queryOutboundPayments(ctx context.Context, query string, args ...any) ([]paymententity.OutboundPaymentEntity, errors.AppError) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("Error occurred while fetching outbound payments: " + err.Error())
+		return nil, &errors.ErrInternalServer{Reason: err}
+	}
+	return r.scanOutboundPayments(rows)
+}
+
+func (r *paymentRepository) scanOutboundPayments(rows *sql.Rows) ([]paymententity.OutboundPaymentEntity, errors.AppError) {
+	defer rows.Close()
+	payments := make([]paymententity.OutboundPaymentEntity, 0)
+	for rows.Next() {
+		var payment paymententity.OutboundPaymentEntity
+		if err := scanOutboundPayment(rows, &payment); err != nil {
+			r.logger.Error("Error occurred while scanning outbound payment: " + err.Error())
+			return nil, &errors.ErrInternalServer{Reason: err}
+		}
+		payments = append(payments, payment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, &errors.ErrInternalServer{Reason: err}
+	}
+	return payments, nil
+}
+
+/**
+* Database transaction to send a SEPA credit transfer
+* 1. Load the account: a client account in euros. Its IBAN and holder are the debtor of the payment
+* 2. Post the SEPA_TRANSFER: the account is debited (plus its fee) and the SEPA clearing account credited
+* 3. Queue the payment as PENDING, with a new end to end id, for the next pain.001 batch
+*
+* The BeneficiaryID of the given transaction, if any, is kept. The transfer counts towards the cooling-off of the
+* beneficiary with the creditor IBAN, whether or not it was sent by beneficiary_id
+ */
+func (r *paymentRepository) InsertOutboundPayment(
+	ctx context.Context,
+	payment *paymententity.OutboundPaymentEntity,
+	transaction *transaction_entity.TransactionEntity,
+) errors.AppError {
+	return r.transactionRepository.RunInTx(ctx, func(tx *sql.Tx) errors.AppError {
+		query := `
+		SELECT a.account_number, a.currency, c.name || ' ' || c.surname1 || COALESCE(' ' || c.surname2, '')
+		FROM accounts a
+		JOIN clients c ON c.id = a.client_id
+		WHERE a.id = $1 AND a.internal_code IS NULL`
+		err := tx.QueryRowContext(ctx, query, payment.AccountID).Scan(&payment.DebtorIban, &payment.Currency, &payment.DebtorName)
+		if err == sql.ErrNoRows {
+			return &errors.ErrNotFound{Entity: "Account", Reason: err}
+		}
+		if err != nil {
+			r.logger.Error(fmt.Sprintf("Error occurred while fetching account %d: %s", payment.AccountID, err.Error()))
+			return &errors.ErrInternalServer{Reason: err}
+		}
+		if payment.Currency != paymententity.SepaCurrency {
+			return &errors.ErrBadRequest{Message: "transfers to other banks are SEPA transfers, in euros only"}
+		}
+		clearingID, appErr := r.transactionRepository.FetchInternalAccountId(ctx, tx, accountentity.InternalSepaClearing, paymententity.SepaCurrency)
+		if appErr != nil {
+			return appErr
+		}
+
+		*transaction = transaction_entity.TransactionEntity{
+			AccountID:       payment.AccountID,
+			ToAccountID:     sql.NullInt32{Int32: int32(clearingID), Valid: true},
+			ToAccountNumber: sql.NullString{String: payment.CreditorIban, Valid: true},
+			Type:            transaction_entity.SepaTransferType,
+			Amount:          payment.Amount,
+			BeneficiaryID:   transaction.BeneficiaryID,
+		}
+		if appErr := r.transactionRepository.InsertTransactionLedger(ctx, tx, transaction); appErr != nil {
+			return appErr
+		}
+
+		payment.TransactionID = transaction.ID
+		payment.EndToEndID = strings.ReplaceAll(uuid.NewString(), "-", "")
+		payment.Status = paymententity.StatusPending
+		query = `
+		INSERT INTO outbound_payments (
+			account_id, transaction_id, end_to_end_id, debtor_iban, debtor_name, creditor_iban, creditor_name,
+			amount, currency, remittance_information, status
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING ` + outboundPaymentColumns
+		err = scanOutboundPayment(tx.QueryRowContext(ctx, query,
+			payment.AccountID,
+			payment.TransactionID,
+			payment.EndToEndID,
+			payment.DebtorIban,
+			payment.DebtorName,
+			payment.CreditorIban,
+			payment.CreditorName,
+			payment.Amount,
+			payment.Currency,
+			payment.RemittanceInformation,
+			payment.Status,
+		), payment)
+		if err != nil {
+			r.logger.Error(fmt.Sprintf("Error occurred while queuing outbound payment of account %d: %s", payment.AccountID, err.Error()))
+			return &errors.ErrInternalServer{Reason: err}
+		}
+		return nil
+	})
+}
+
+// FetchOutboundPaymentsByAccount returns the SEPA transfers of the account, newest first
+func (r *paymentRepository) FetchOutboundPaymentsByAccount(ctx context.Context, accountID int) ([]paymententity.OutboundPaymentEntity, errors.AppError) {
+	query := `SELECT ` + outboundPaymentColumns + ` FROM outbound_payments WHERE account_id = $1 ORDER BY id DESC`
+	return r.queryOutboundPayments(ctx, query, accountID)
+}
+
+// FetchOutboundPaymentsByBatch returns the payments submitted in the pain.001 with the given MsgId
+func (r *paymentRepository) FetchOutboundPaymentsByBatch(ctx context.Context, messageID string) ([]paymententity.OutboundPaymentEntity, errors.AppError) {
+	query := `SELECT ` + outboundPaymentColumns + ` FROM outbound_payments WHERE batch_message_id = $1 ORDER BY id`
+	return r.queryOutboundPayments(ctx, query, messageID)
+}
+
+/**
+* Database transaction to claim the pending payments for a pain.001 batch
+* 1. Mark up to limit pending payments, oldest first, as SUBMITTED in the batch with the given MsgId.
+*    Payments claimed by another worker at the same time are skipped
+* 2. Submit the batch. The claim is committed only once it is sent, so a batch that fails, or a process
+*    that dies before the batch is written, leaves the payments PENDING for the next one
+*
+* No batch is submitted when there are no pending payments.
+ */
+func (r *paymentRepository) ClaimPendingPayments(ctx context.Context, messageID string, limit int, submit func(payments []paymententity.OutboundPaymentEntity) error) ([]paymententity.OutboundPaymentEntity, errors.AppError) {
+	var payments []paymententity.OutboundPaymentEntity
+	appErr := r.transactionRepository.RunInTx(ctx, func(tx *sql.Tx) errors.AppError {
+		query := `
+		UPDATE outbound_payments SET status = $1, batch_message_id = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE id IN (
+			SELECT id FROM outbound_payments
+			WHERE status = $3
+			ORDER BY id
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + outboundPaymentColumns
+		rows, err := tx.QueryContext(ctx, query, paymententity.StatusSubmitted, messageID, paymententity.StatusPending, limit)
+		if err != nil {
+			r.logger.Error(fmt.Sprintf("Error occurred while claiming the payments of batch %s: %s", messageID, err.Error()))
+			return &errors.ErrInternalServer{Reason: err}
+		}
+		claimed, appErr := r.scanOutboundPayments(rows)
+		if appErr != nil {
+			return appErr
+		}
+		if len(claimed) == 0 {
+			payments = claimed
+			return nil
+		}
+		// RETURNING keeps no order
+		slices.SortFunc(claimed, func(a, b paymententity.OutboundPaymentEntity) int { return a.ID - b.ID })
+		if err := submit(claimed); err != nil {
+			r.logger.Error(fmt.Sprintf("Batch %s could not be submitted: %s", messageID, err.Error()))
+			return &errors.ErrInternalServer{Reason: err}
+		}
+		payments = claimed
+		return nil
+	})
+	if appErr != nil {
+		return nil, appErr
+	}
+	return payments, nil
+}
+
+/**
+* Database transaction to apply the status reported by the clearing house to a submitted payment
+* 1. Lock the payment. A payment no longer SUBMITTED (i.e. a report read twice) is left as it is
+* 2. Settled: the amount moves from the SEPA clearing account to the settlement account
+* 3. Rejected: the debit of the client, fee included, is reversed
+* 4. Any other status (accepted, pending) keeps the payment submitted
+*
+* Returns whether the payment reached a final status.
+ */
+func (r *paymentRepository) ApplyPaymentStatus(ctx context.Context, status paymententity.PaymentStatus) (bool, errors.AppError) {
+	if !status.IsSettled() && !status.IsRejected() {
+		return false, nil
+	}
+	applied := false
+	appErr := r.transactionRepository.RunInTx(ctx, func(tx *sql.Tx) errors.AppError {
+		applied = false
+		var payment paymententity.OutboundPaymentEntity
+		query := `SELECT ` + outboundPaymentColumns + ` FROM outbound_payments WHERE end_to_end_id = $1 FOR UPDATE`
+		err := scanOutboundPayment(tx.QueryRowContext(ctx, query, status.EndToEndID), &payment)
+		if err == sql.ErrNoRows {
+			return &errors.ErrNotFound{Entity: "Outbound payment", Reason: err}
+		}
+		if err != nil {
+			r.logger.Error(fmt.Sprintf("Error occurred while fetching outbound payment %s: %s", status.EndToEndID, err.Error()))
+			return &errors.ErrInternalServer{Reason: err}
+		}
+		if payment.Status != paymententity.StatusSubmitted {
+			return nil
+		}
+
+		if status.IsSettled() {
+			clearingID, appErr := r.transactionRepository.FetchInternalAccountId(ctx, tx, accountentity.InternalSepaClearing, payment.Currency)
+			if appErr != nil {
+				return appErr
+			}
+			settlementID, appErr := r.transactionRepository.FetchInternalAccountId(ctx, tx, accountentity.InternalSepaSettlement, payment.Currency)
+			if appErr != nil {
+				return appErr
+			}
+			settlement := transaction_entity.TransactionEntity{
+				AccountID:       clearingID,
+				ToAccountID:     sql.NullInt32{Int32: int32(settlementID), Valid: true},
+				ToAccountNumber: sql.NullString{String: payment.CreditorIban, Valid: true},
+				Type:            transaction_entity.SepaSettlementType,
+				Amount:          payment.Amount,
+			}
+			if appErr := r.transactionRepository.InsertSettlementLedger(ctx, tx, &settlement); appErr != nil {
+				return appErr
+			}
+			payment.Status = paymententity.StatusSettled
+			payment.SettlementID = sql.NullInt32{Int32: int32(settlement.ID), Valid: true}
+		} else {
+			refund, appErr := r.transactionRepository.InsertReversalLedger(ctx, tx, payment.TransactionID, nil, transaction_entity.ReversalReasonPaymentRejected)
+			if appErr != nil {
+				return appErr
+			}
+			payment.Status = paymententity.StatusRejected
+			payment.RefundID = sql.NullInt32{Int32: int32(refund.ID), Valid: true}
+			payment.StatusReason = sql.NullString{String: status.Reason, Valid: status.Reason != ""}
+		}
+
+		query = `
+		UPDATE outbound_payments
+		SET status = $1, settlement_transaction_id = $2, refund_transaction_id = $3, status_reason = $4, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $5`
+		if _, err := tx.ExecContext(ctx, query, payment.Status, payment.SettlementID, payment.RefundID, payment.StatusReason, payment.ID); err != nil {
+			r.logger.Error(fmt.Sprintf("Error occurred while updating outbound payment %d: %s", payment.ID, err.Error()))
+			return &errors.ErrInternalServer{Reason: err}
+		}
+		applied = true
+		return nil
+	})
+	return applied, appErr
+}
@@ -0,0 +1,223 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"src/domain/money"
+	reconciliationentity "src/domain/reconciliation"
+	errors "src/errors"
+
+	"go.uber.org/zap"
+)
+
+type ReconciliationRepository interface {
+	RunInSnapshot(ctx context.Context, fn func(tx *sql.Tx) errors.AppError) errors.AppError
+	CountAccounts(ctx context.Context, tx *sql.Tx) (int, errors.AppError)
+	CountTransactions(ctx context.Context, tx *sql.Tx) (int, errors.AppError)
+	FetchBalanceDiscrepancies(ctx context.Context, tx *sql.Tx) ([]reconciliationentity.BalanceDiscrepancy, errors.AppError)
+	FetchHeldAmountDiscrepancies(ctx context.Context, tx *sql.Tx) ([]reconciliationentity.HeldAmountDiscrepancy, errors.AppError)
+	FetchUnbalancedTransactions(ctx context.Context, tx *sql.Tx) ([]reconciliationentity.UnbalancedTransaction, errors.AppError)
+	FetchLedgerTotal(ctx context.Context, tx *sql.Tx) (money.Money, errors.AppError)
+	FetchInternalAccountBalances(ctx context.Context, tx *sql.Tx) ([]reconciliationentity.InternalAccountBalance, errors.AppError)
+	RefreshBalancesMaterializedView(ctx context.Context) errors.AppError
+}
+
+type reconciliationRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewReconciliationRepository(db *sql.DB, logger *zap.Logger) ReconciliationRepository {
+	if db == nil {
+		panic("db cannot be nil")
+	}
+	return &reconciliationRepository{db: db, logger: logger}
+}
+
+// RunInSnapshot runs fn in a read only REPEATABLE READ transaction, so every query sees the same data
+func (r *reconciliationRepository) RunInSnapshot(ctx context.Context, fn func(tx *sql.Tx) errors.AppError) errors.AppError {
+	return runInTx(ctx, r.db, r.logger, &sql.TxOptions{
+		ReadOnly:  true,
+		Isolation: sql.LevelRepeatableRead,
+	}, fn)
+}
+
+func (r *reconciliationRepository) CountAccounts(ctx context.Context, tx *sql.Tx) (int, errors.AppError) {
+	return r.count(ctx, tx, `SELECT count(id) FROM accounts`)
+}
+
+func (r *reconciliationRepository) CountTransactions(ctx context.Context, tx *sql.Tx) (int, errors.AppError) {
+	return r.count(ctx, tx, `SELECT count(id) FROM transactions`)
+}
+
+func (r *reconciliationRepository) count(ctx context.Context, tx *sql.Tx, query string) (int, errors.AppError) {
+	var total int
+	if err := tx.QueryRowContext(ctx, query).Scan(&total); err != nil {
+		r.logger.Error("Error occurred while counting rows for the reconciliation: " + err.Error())
+		return 0, &errors.ErrInternalServer{Reason: err}
+	}
+	return total, nil
+}
+
+// FetchBalanceDiscrepancies recomputes every balance from ledger_entries and compares it with account_balances.
+// Accounts with ledger entries and no balance row are reported with a stored balance of 0.
+func (r *reconciliationRepository) FetchBalanceDiscrepancies(ctx context.Context, tx *sql.Tx) ([]reconciliationentity.BalanceDiscrepancy, errors.AppError) {
+	query := `
+	WITH ledger AS (
+		SELECT account_id, SUM(CASE WHEN UPPER(type) = 'CREDIT' THEN amount ELSE -amount END) AS balance
+		FROM ledger_entries
+		GROUP BY account_id
+	)
+	SELECT
+		COALESCE(ab.account_id, ledger.account_id),
+		COALESCE(ledger.balance, 0),
+		COALESCE(ab.balance, 0)
+	FROM account_balances ab
+	FULL OUTER JOIN ledger ON ledger.account_id = ab.account_id
+	WHERE COALESCE(ledger.balance, 0) <> COALESCE(ab.balance, 0)
+	ORDER BY 1`
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		r.logger.Error("Error occurred while recomputing balances: " + err.Error())
+		return nil, &errors.ErrInternalServer{Reason: err}
+	}
+	defer rows.Close()
+	discrepancies := make([]reconciliationentity.BalanceDiscrepancy, 0)
+	for rows.Next() {
+		var discrepancy reconciliationentity.BalanceDiscrepancy
+		if err := rows.Scan(&discrepancy.AccountID, &discrepancy.LedgerBalance, &discrepancy.StoredBalance); err != nil {
+			r.logger.Error("Error occurred while scanning balance discrepancy: " + err.Error())
+			return nil, &errors.ErrInternalServer{Reason: err}
+		}
+		discrepancy.Difference = discrepancy.StoredBalance.Sub(discrepancy.LedgerBalance)
+		discrepancies = append(discrepancies, discrepancy)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, &errors.ErrInternalServer{Reason: err}
+	}
+	return discrepancies, nil
+}
+
+// FetchHeldAmountDiscrepancies compares account_balances.held_amount with the authorized holds of each account
+func (r *reconciliationRepository) FetchHeldAmountDiscrepancies(ctx context.Context, tx *sql.Tx) ([]reconciliationentity.HeldAmountDiscrepancy, errors.AppError) {
+	query := `
+	WITH authorized AS (
+		SELECT account_id, SUM(amount) AS amount
+		FROM holds
+		WHERE status = 'AUTHORIZED'
+		GROUP BY account_id
+	)
+	SELECT ab.account_id, COALESCE(authorized.amount, 0), ab.held_amount
+	FROM account_balances ab
+	LEFT JOIN authorized ON authorized.account_id = ab.account_id
+	WHERE COALESCE(authorized.amount, 0) <> ab.held_amount
+	ORDER BY ab.account_id`
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		r.logger.Error("Error occurred while recomputing held amounts: " + err.Error())
+		return nil, &errors.ErrInternalServer{Reason: err}
+	}
+	defer rows.Close()
+	discrepancies := make([]reconciliationentity.HeldAmountDiscrepancy, 0)
+	for rows.Next() {
+		var discrepancy reconciliationentity.HeldAmountDiscrepancy
+		if err := rows.Scan(&discrepancy.AccountID, &discrepancy.AuthorizedHolds, &discrepancy.StoredHeldAmount); err != nil {
+			r.logger.Error("Error occurred while scanning held amount discrepancy: " + err.Error())
+			return nil, &errors.ErrInternalServer{Reason: err}
+		}
+		discrepancies = append(discrepancies, discrepancy)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, &errors.ErrInternalServer{Reason: err}
+	}
+	return discrepancies, nil
+}
+
+// FetchUnbalancedTransactions returns the transactions whose debits are not equal to their credits.
+// Transfers between currencies post entries in both, so the check is done per currency.
+func (r *reconciliationRepository) FetchUnbalancedTransactions(ctx context.Context, tx *sql.Tx) ([]reconciliationentity.UnbalancedTransaction, errors.AppError) {
+	query := `
+	SELECT
+		t.id,
+		t.type,
+		COALESCE(a.currency, ''),
+		COALESCE(SUM(CASE WHEN UPPER(le.type) = 'DEBIT' THEN le.amount END), 0) AS debits,
+		COALESCE(SUM(CASE WHEN UPPER(le.type) = 'CREDIT' THEN le.amount END), 0) AS credits
+	FROM transactions t
+	LEFT JOIN ledger_entries le ON le.transaction_id = t.id
+	LEFT JOIN accounts a ON a.id = le.account_id
+	GROUP BY t.id, t.type, a.currency
+	HAVING COALESCE(SUM(CASE WHEN UPPER(le.type) = 'DEBIT' THEN le.amount END), 0)
+		<> COALESCE(SUM(CASE WHEN UPPER(le.type) = 'CREDIT' THEN le.amount END), 0)
+	ORDER BY t.id, 3`
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		r.logger.Error("Error occurred while checking debits and credits: " + err.Error())
+		return nil, &errors.ErrInternalServer{Reason: err}
+	}
+	defer rows.Close()
+	transactions := make([]reconciliationentity.UnbalancedTransaction, 0)
+	for rows.Next() {
+		var transaction reconciliationentity.UnbalancedTransaction
+		if err := rows.Scan(&transaction.TransactionID, &transaction.Type, &transaction.Currency, &transaction.Debits, &transaction.Credits); err != nil {
+			r.logger.Error("Error occurred while scanning unbalanced transaction: " + err.Error())
+			return nil, &errors.ErrInternalServer{Reason: err}
+		}
+		transactions = append(transactions, transaction)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, &errors.ErrInternalServer{Reason: err}
+	}
+	return transactions, nil
+}
+
+// FetchLedgerTotal sums the credits minus the debits of every ledger entry. Every transaction posts
+// balanced entries against client or internal accounts, so anything but zero means money created or lost.
+// Each currency is balanced on its own (see FetchUnbalancedTransactions), so the total is zero as well.
+func (r *reconciliationRepository) FetchLedgerTotal(ctx context.Context, tx *sql.Tx) (money.Money, errors.AppError) {
+	query := `SELECT COALESCE(SUM(CASE WHEN UPPER(type) = 'CREDIT' THEN amount ELSE -amount END), 0) FROM ledger_entries`
+	var total money.Money
+	if err := tx.QueryRowContext(ctx, query).Scan(&total); err != nil {
+		r.logger.Error("Error occurred while summing the ledger: " + err.Error())
+		return money.Zero, &errors.ErrInternalServer{Reason: err}
+	}
+	return total, nil
+}
+
+func (r *reconciliationRepository) FetchInternalAccountBalances(ctx context.Context, tx *sql.Tx) ([]reconciliationentity.InternalAccountBalance, errors.AppError) {
+	query := `
+	SELECT a.internal_code, a.currency, a.id, COALESCE(ab.balance, 0)
+	FROM accounts a
+	LEFT JOIN account_balances ab ON ab.account_id = a.id
+	WHERE a.internal_code IS NOT NULL
+	ORDER BY a.internal_code, a.currency`
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		r.logger.Error("Error occurred while fetching internal account balances: " + err.Error())
+		return nil, &errors.ErrInternalServer{Reason: err}
+	}
+	defer rows.Close()
+	balances := make([]reconciliationentity.InternalAccountBalance, 0)
+	for rows.Next() {
+		var balance reconciliationentity.InternalAccountBalance
+		if err := rows.Scan(&balance.Code, &balance.Currency, &balance.AccountID, &balance.Balance); err != nil {
+			r.logger.Error("Error occurred while scanning internal account balance: " + err.Error())
+			return nil, &errors.ErrInternalServer{Reason: err}
+		}
+		balances = append(balances, balance)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, &errors.ErrInternalServer{Reason: err}
+	}
+	return balances, nil
+}
+
+// RefreshBalancesMaterializedView rebuilds account_balances_mv without blocking its readers
+func (r *reconciliationRepository) RefreshBalancesMaterializedView(ctx context.Context) errors.AppError {
+	_, err := r.db.ExecContext(ctx, `REFRESH MATERIALIZED VIEW CONCURRENTLY account_balances_mv`)
+	if err != nil {
+		r.logger.Error("Error occurred while refreshing account_balances_mv: " + err.Error())
+		return &errors.ErrInternalServer{Reason: err}
+	}
+	return nil
+}
@@ -10,4 +10,21 @@ type RepositoryWrapper struct {
 	ClientRepository ClientRepository
 	TransactionRepository TransactionRepository
 	RegistryAccountOtpRepository RegistryAccountOtpRepository
+	IdempotencyRepository IdempotencyRepository
+	HoldRepository HoldRepository
+	ScheduledTransferRepository ScheduledTransferRepository
+	ReconciliationRepository ReconciliationRepository
+	CurrencyRepository CurrencyRepository
+	AccountStatusRepository AccountStatusRepository
+	OverdraftRepository OverdraftRepository
+	FeeRepository FeeRepository
+	InterestRepository InterestRepository
+	BalanceRepository BalanceRepository
+	DocumentRepository DocumentRepository
+	PaymentRepository PaymentRepository
+	InboundPaymentRepository InboundPaymentRepository
+	BankDirectoryRepository BankDirectoryRepository
+	BranchRepository BranchRepository
+	PayeeVerificationRepository PayeeVerificationRepository
+	BeneficiaryRepository BeneficiaryRepository
 }
\ No newline at end of file
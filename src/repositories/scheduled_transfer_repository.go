@@ -0,0 +1,383 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	goerrors "errors"
+	"fmt"
+	scheduledtransferentity "src/domain/scheduled_transfer"
+	transaction_entity "src/domain/transaction"
+	errors "src/errors"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type ScheduledTransferRepository interface {
+	InsertScheduledTransfer(ctx context.Context, scheduledTransfer *scheduledtransferentity.ScheduledTransferEntity) errors.AppError
+	FetchScheduledTransferById(ctx context.Context, ID int) (scheduledtransferentity.ScheduledTransferEntity, errors.AppError)
+	FetchScheduledTransfersByAccount(ctx context.Context, accountID int) ([]scheduledtransferentity.ScheduledTransferEntity, errors.AppError)
+	UpdateScheduledTransfer(ctx context.Context, scheduledTransfer *scheduledtransferentity.ScheduledTransferEntity) errors.AppError
+	CancelScheduledTransfer(ctx context.Context, ID int) (scheduledtransferentity.ScheduledTransferEntity, errors.AppError)
+	FetchRuns(ctx context.Context, scheduledTransferID int) ([]scheduledtransferentity.ScheduledTransferRunEntity, errors.AppError)
+	ExecuteDue(ctx context.Context, today time.Time) (int, errors.AppError)
+}
+
+type scheduledTransferRepository struct {
+	db                    *sql.DB
+	logger                *zap.Logger
+	transactionRepository TransactionRepository
+}
+
+// The transaction repository posts the transfers of the executed orders
+func NewScheduledTransferRepository(db *sql.DB, logger *zap.Logger, transactionRepository TransactionRepository) ScheduledTransferRepository {
+	if db == nil {
+		panic("db cannot be nil")
+	}
+	return &scheduledTransferRepository{db: db, logger: logger, transactionRepository: transactionRepository}
+}
+
+const scheduledTransferColumns = `id, account_id, to_account_id, to_account_number, amount, frequency, start_date, end_date, next_run_date, status, reference, created_at, updated_at`
+
+func scanScheduledTransfer(row rowScanner, entity *scheduledtransferentity.ScheduledTransferEntity) error {
+	return row.Scan(
+		&entity.ID,
+		&entity.AccountID,
+		&entity.ToAccountID,
+		&entity.ToAccountNumber,
+		&entity.Amount,
+		&entity.Frequency,
+		&entity.StartDate,
+		&entity.EndDate,
+		&entity.NextRunDate,
+		&entity.Status,
+		&entity.Reference,
+		&entity.CreatedAt,
+		&entity.UpdatedAt,
+	)
+}
+
+func (r *scheduledTransferRepository) InsertScheduledTransfer(ctx context.Context, scheduledTransfer *scheduledtransferentity.ScheduledTransferEntity) errors.AppError {
+	query := `
+	INSERT INTO scheduled_transfers (
+            account_id, to_account_id, to_account_number, amount, frequency, start_date, end_date, next_run_date, status, reference
+        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	RETURNING id, created_at, updated_at`
+	err := r.db.QueryRowContext(ctx, query,
+		scheduledTransfer.AccountID,
+		scheduledTransfer.ToAccountID,
+		scheduledTransfer.ToAccountNumber,
+		scheduledTransfer.Amount,
+		scheduledTransfer.Frequency,
+		scheduledTransfer.StartDate,
+		scheduledTransfer.EndDate,
+		scheduledTransfer.NextRunDate,
+		scheduledTransfer.Status,
+		scheduledTransfer.Reference,
+	).Scan(&scheduledTransfer.ID, &scheduledTransfer.CreatedAt, &scheduledTransfer.UpdatedAt)
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while inserting scheduled transfer of account %d: %s", scheduledTransfer.AccountID, err.Error()))
+		return &errors.ErrInternalServer{Reason: err}
+	}
+	return nil
+}
+
+func (r *scheduledTransferRepository) FetchScheduledTransferById(ctx context.Context, ID int) (scheduledtransferentity.ScheduledTransferEntity, errors.AppError) {
+	query := `SELECT ` + scheduledTransferColumns + ` FROM scheduled_transfers WHERE id = $1`
+	var entity scheduledtransferentity.ScheduledTransferEntity
+	err := scanScheduledTransfer(r.db.QueryRowContext(ctx, query, ID), &entity)
+	if err == sql.ErrNoRows {
+		return scheduledtransferentity.ScheduledTransferEntity{}, &errors.ErrNotFound{Entity: "Standing order", Reason: err}
+	}
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while fetching scheduled transfer %d: %s", ID, err.Error()))
+		return scheduledtransferentity.ScheduledTransferEntity{}, &errors.ErrInternalServer{Reason: err}
+	}
+	return entity, nil
+}
+
+func (r *scheduledTransferRepository) FetchScheduledTransfersByAccount(ctx context.Context, accountID int) ([]scheduledtransferentity.ScheduledTransferEntity, errors.AppError) {
+	query := `SELECT ` + scheduledTransferColumns + ` FROM scheduled_transfers WHERE account_id = $1 ORDER BY id`
+	rows, err := r.db.QueryContext(ctx, query, accountID)
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while fetching scheduled transfers of account %d: %s", accountID, err.Error()))
+		return nil, &errors.ErrInternalServer{Reason: err}
+	}
+	defer rows.Close()
+	scheduledTransfers := make([]scheduledtransferentity.ScheduledTransferEntity, 0)
+	for rows.Next() {
+		var entity scheduledtransferentity.ScheduledTransferEntity
+		if err := scanScheduledTransfer(rows, &entity); err != nil {
+			r.logger.Error("Error occurred while scanning scheduled transfer: " + err.Error())
+			return nil, &errors.ErrInternalServer{Reason: err}
+		}
+		scheduledTransfers = append(scheduledTransfers, entity)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, &errors.ErrInternalServer{Reason: err}
+	}
+	return scheduledTransfers, nil
+}
+
+// UpdateScheduledTransfer saves the editable fields of an active order: amount, end date, next run date and reference
+func (r *scheduledTransferRepository) UpdateScheduledTransfer(ctx context.Context, scheduledTransfer *scheduledtransferentity.ScheduledTransferEntity) errors.AppError {
+	query := `
+	UPDATE scheduled_transfers SET
+		amount = $1, end_date = $2, next_run_date = $3, status = $4, reference = $5, updated_at = CURRENT_TIMESTAMP
+	WHERE id = $6 AND status = $7
+	RETURNING updated_at`
+	err := r.db.QueryRowContext(ctx, query,
+		scheduledTransfer.Amount,
+		scheduledTransfer.EndDate,
+		scheduledTransfer.NextRunDate,
+		scheduledTransfer.Status,
+		scheduledTransfer.Reference,
+		scheduledTransfer.ID,
+		scheduledtransferentity.StatusActive,
+	).Scan(&scheduledTransfer.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return &errors.ErrConflict{Message: fmt.Sprintf("standing order %d is not active", scheduledTransfer.ID)}
+	}
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while updating scheduled transfer %d: %s", scheduledTransfer.ID, err.Error()))
+		return &errors.ErrInternalServer{Reason: err}
+	}
+	return nil
+}
+
+func (r *scheduledTransferRepository) CancelScheduledTransfer(ctx context.Context, ID int) (scheduledtransferentity.ScheduledTransferEntity, errors.AppError) {
+	query := `
+	UPDATE scheduled_transfers SET status = $1, next_run_date = NULL, updated_at = CURRENT_TIMESTAMP
+	WHERE id = $2 AND status = $3
+	RETURNING ` + scheduledTransferColumns
+	var entity scheduledtransferentity.ScheduledTransferEntity
+	err := scanScheduledTransfer(r.db.QueryRowContext(ctx, query, scheduledtransferentity.StatusCancelled, ID, scheduledtransferentity.StatusActive), &entity)
+	if err == sql.ErrNoRows {
+		return scheduledtransferentity.ScheduledTransferEntity{}, &errors.ErrConflict{Message: fmt.Sprintf("standing order %d is not active", ID)}
+	}
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while cancelling scheduled transfer %d: %s", ID, err.Error()))
+		return scheduledtransferentity.ScheduledTransferEntity{}, &errors.ErrInternalServer{Reason: err}
+	}
+	return entity, nil
+}
+
+func (r *scheduledTransferRepository) FetchRuns(ctx context.Context, scheduledTransferID int) ([]scheduledtransferentity.ScheduledTransferRunEntity, errors.AppError) {
+	query := `
+	SELECT id, scheduled_transfer_id, run_date, status, failure_reason, transaction_id, created_at
+	FROM scheduled_transfer_runs
+	WHERE scheduled_transfer_id = $1
+	ORDER BY run_date DESC`
+	rows, err := r.db.QueryContext(ctx, query, scheduledTransferID)
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while fetching runs of scheduled transfer %d: %s", scheduledTransferID, err.Error()))
+		return nil, &errors.ErrInternalServer{Reason: err}
+	}
+	defer rows.Close()
+	runs := make([]scheduledtransferentity.ScheduledTransferRunEntity, 0)
+	for rows.Next() {
+		var run scheduledtransferentity.ScheduledTransferRunEntity
+		err := rows.Scan(&run.ID, &run.ScheduledTransferID, &run.RunDate, &run.Status, &run.FailureReason, &run.TransactionID, &run.CreatedAt)
+		if err != nil {
+			r.logger.Error("Error occurred while scanning scheduled transfer run: " + err.Error())
+			return nil, &errors.ErrInternalServer{Reason: err}
+		}
+		runs = append(runs, run)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, &errors.ErrInternalServer{Reason: err}
+	}
+	return runs, nil
+}
+
+// ExecuteDue executes every occurrence due on or before today, oldest first, and returns how many were executed.
+// Occurrences missed while the scheduler was stopped are executed one by one. An occurrence that cannot be
+// executed because of an internal error is recorded as FAILED and its order moved to the next run date, so it
+// does not block the orders after it; the first of those errors is returned once every due order has been run.
+func (r *scheduledTransferRepository) ExecuteDue(ctx context.Context, today time.Time) (int, errors.AppError) {
+	executed := 0
+	var firstErr errors.AppError
+	for {
+		order, found, err := r.executeNext(ctx, scheduledtransferentity.Date(today))
+		if err != nil && order.ID == 0 {
+			return executed, err
+		}
+		if err != nil {
+			if recordErr := r.recordFailure(ctx, order, err); recordErr != nil {
+				// not even the failure could be recorded: stop instead of picking the same order again
+				return executed, recordErr
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if !found {
+			return executed, firstErr
+		}
+		executed++
+	}
+}
+
+/**
+* Database transaction to execute one occurrence of a standing order
+* 1. Lock the oldest due order. Orders locked by another worker are skipped
+* 2. Post the transfer through InsertTransactionLedger, inside a savepoint
+* 3. Record the run: SUCCEEDED with the transaction or FAILED with the reason (i.e. not enough funds)
+* 4. Move the order to its next run date, or finish it after the end date
+*
+* The run and the transfer are committed together, so a restart never executes an occurrence twice.
+* The locked order is returned also when the Tx fails, so its failure can be recorded.
+ */
+func (r *scheduledTransferRepository) executeNext(ctx context.Context, today time.Time) (scheduledtransferentity.ScheduledTransferEntity, bool, errors.AppError) {
+	found := false
+	var order scheduledtransferentity.ScheduledTransferEntity
+	appErr := r.transactionRepository.RunInTx(ctx, func(tx *sql.Tx) errors.AppError {
+		found = false
+		order = scheduledtransferentity.ScheduledTransferEntity{}
+		query := `
+		SELECT ` + scheduledTransferColumns + ` FROM scheduled_transfers
+		WHERE status = $1 AND next_run_date <= $2
+		ORDER BY next_run_date, id
+		LIMIT 1
+		FOR UPDATE SKIP LOCKED`
+		err := scanScheduledTransfer(tx.QueryRowContext(ctx, query, scheduledtransferentity.StatusActive, today), &order)
+		if err == sql.ErrNoRows {
+			order = scheduledtransferentity.ScheduledTransferEntity{}
+			return nil
+		}
+		if err != nil {
+			order = scheduledtransferentity.ScheduledTransferEntity{}
+			r.logger.Error("Error occurred while fetching due scheduled transfers: " + err.Error())
+			return &errors.ErrInternalServer{Reason: err}
+		}
+		found = true
+		runDate := scheduledtransferentity.Date(order.NextRunDate.Time)
+
+		run := scheduledtransferentity.ScheduledTransferRunEntity{
+			ScheduledTransferID: order.ID,
+			RunDate:             runDate,
+			Status:              scheduledtransferentity.RunSucceeded,
+		}
+		if appErr := r.postTransfer(ctx, tx, order, &run); appErr != nil {
+			return appErr
+		}
+		if appErr := r.insertRun(ctx, tx, &run); appErr != nil {
+			return appErr
+		}
+		return r.advance(ctx, tx, &order, runDate)
+	})
+	return order, found, appErr
+}
+
+/**
+* Database transaction to record an occurrence that could not be executed because of an internal error
+* 1. Lock the order again. It is skipped if it is no longer due on the same date (i.e. another worker ran it)
+* 2. Record the run as FAILED with the error as the reason
+* 3. Move the order to its next run date, or finish it after the end date
+ */
+func (r *scheduledTransferRepository) recordFailure(ctx context.Context, order scheduledtransferentity.ScheduledTransferEntity, cause errors.AppError) errors.AppError {
+	runDate := scheduledtransferentity.Date(order.NextRunDate.Time)
+	detail := cause.Error()
+	if reason := goerrors.Unwrap(cause); reason != nil {
+		detail = reason.Error()
+	}
+	r.logger.Error(fmt.Sprintf("Standing order %d could not be executed on %s: %s", order.ID, runDate.Format(time.DateOnly), detail))
+	return r.transactionRepository.RunInTx(ctx, func(tx *sql.Tx) errors.AppError {
+		query := `
+		SELECT ` + scheduledTransferColumns + ` FROM scheduled_transfers
+		WHERE id = $1 AND status = $2 AND next_run_date = $3
+		FOR UPDATE`
+		var locked scheduledtransferentity.ScheduledTransferEntity
+		err := scanScheduledTransfer(tx.QueryRowContext(ctx, query, order.ID, scheduledtransferentity.StatusActive, runDate), &locked)
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			r.logger.Error(fmt.Sprintf("Error occurred while fetching scheduled transfer %d: %s", order.ID, err.Error()))
+			return &errors.ErrInternalServer{Reason: err}
+		}
+		reason := []rune("internal error: " + detail)
+		if len(reason) > 255 {
+			reason = reason[:255]
+		}
+		run := scheduledtransferentity.ScheduledTransferRunEntity{
+			ScheduledTransferID: locked.ID,
+			RunDate:             runDate,
+			Status:              scheduledtransferentity.RunFailed,
+			FailureReason:       sql.NullString{String: string(reason), Valid: true},
+		}
+		if appErr := r.insertRun(ctx, tx, &run); appErr != nil {
+			return appErr
+		}
+		return r.advance(ctx, tx, &locked, runDate)
+	})
+}
+
+// postTransfer posts the transfer of the order. Business failures are recorded in the run,
+// internal errors are returned so the whole database transaction is rolled back and retried later.
+func (r *scheduledTransferRepository) postTransfer(ctx context.Context, tx *sql.Tx, order scheduledtransferentity.ScheduledTransferEntity, run *scheduledtransferentity.ScheduledTransferRunEntity) errors.AppError {
+	if _, err := tx.ExecContext(ctx, `SAVEPOINT scheduled_transfer`); err != nil {
+		return &errors.ErrInternalServer{Reason: err}
+	}
+	transaction := transaction_entity.TransactionEntity{
+		AccountID:       order.AccountID,
+		ToAccountID:     sql.NullInt32{Int32: int32(order.ToAccountID), Valid: true},
+		ToAccountNumber: sql.NullString{String: order.ToAccountNumber, Valid: true},
+		Type:            "TRANSFER",
+		Amount:          order.Amount,
+	}
+	appErr := r.transactionRepository.InsertTransactionLedger(ctx, tx, &transaction)
+	if appErr == nil {
+		run.TransactionID = sql.NullInt32{Int32: int32(transaction.ID), Valid: true}
+		return nil
+	}
+	var internalErr *errors.ErrInternalServer
+	if goerrors.As(appErr, &internalErr) {
+		return appErr
+	}
+	if _, err := tx.ExecContext(ctx, `ROLLBACK TO SAVEPOINT scheduled_transfer`); err != nil {
+		return &errors.ErrInternalServer{Reason: err}
+	}
+	r.logger.Info(fmt.Sprintf("Standing order %d failed on %s: %s", order.ID, run.RunDate.Format(time.DateOnly), appErr.Error()))
+	run.Status = scheduledtransferentity.RunFailed
+	run.FailureReason = sql.NullString{String: appErr.Error(), Valid: true}
+	return nil
+}
+
+func (r *scheduledTransferRepository) insertRun(ctx context.Context, tx *sql.Tx, run *scheduledtransferentity.ScheduledTransferRunEntity) errors.AppError {
+	query := `
+	INSERT INTO scheduled_transfer_runs (
+            scheduled_transfer_id, run_date, status, failure_reason, transaction_id
+        ) VALUES ($1, $2, $3, $4, $5)
+	RETURNING id, created_at`
+	err := tx.QueryRowContext(ctx, query,
+		run.ScheduledTransferID,
+		run.RunDate,
+		run.Status,
+		run.FailureReason,
+		run.TransactionID,
+	).Scan(&run.ID, &run.CreatedAt)
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while recording run of scheduled transfer %d: %s", run.ScheduledTransferID, err.Error()))
+		return &errors.ErrInternalServer{Reason: err}
+	}
+	return nil
+}
+
+// advance moves the order to the run date after runDate. The order is finished when it is past the end date.
+func (r *scheduledTransferRepository) advance(ctx context.Context, tx *sql.Tx, order *scheduledtransferentity.ScheduledTransferEntity, runDate time.Time) errors.AppError {
+	next := order.RunDateAfter(runDate)
+	if order.IsWithinEndDate(next) {
+		order.NextRunDate = sql.NullTime{Time: next, Valid: true}
+	} else {
+		order.NextRunDate = sql.NullTime{}
+		order.Status = scheduledtransferentity.StatusFinished
+	}
+	query := `UPDATE scheduled_transfers SET next_run_date = $1, status = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $3`
+	if _, err := tx.ExecContext(ctx, query, order.NextRunDate, order.Status, order.ID); err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while moving scheduled transfer %d to its next run: %s", order.ID, err.Error()))
+		return &errors.ErrInternalServer{Reason: err}
+	}
+	return nil
+}
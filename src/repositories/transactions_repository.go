@@ -5,28 +5,82 @@ import (
 	"database/sql"
 	"fmt"
 	"go.uber.org/zap"
+	"math/big"
+	accountentity "src/domain/account"
+	currencyentity "src/domain/currency"
+	feeentity "src/domain/fee"
 	ledgerentity "src/domain/ledger"
+	"src/domain/money"
 	pagination "src/domain/pagination"
 	transaction_entity "src/domain/transaction"
 	errors "src/errors"
+	"src/utils"
 	validators "src/validators"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 )
 
 type TransactionRepository interface {
 	// FetchTransactionById(ctx context.Context, ID int) (transaction_entity.TransactionEntity, error)
 	// FetchTransactionsByAccount(ctx context.Context, accountID int) ([]transaction_entity.TransactionEntity, error)
-	FetchAccountBalance(ctx context.Context, tx *sql.Tx, accountID int) (*float64, errors.AppError)
+	FetchAccountBalance(ctx context.Context, tx *sql.Tx, accountID int) (*accountentity.AccountBalance, errors.AppError)
 	updateAccountBalance(ctx context.Context, tx *sql.Tx, ledgerTransaction ledgerentity.LedgerTransaction) errors.AppError
 	InsertTransaction(ctx context.Context, tx *sql.Tx, transaction *transaction_entity.TransactionEntity) errors.AppError
 	InsertLedgerEntry(ctx context.Context, tx *sql.Tx, ledgerTransaction *ledgerentity.LedgerTransaction) errors.AppError
 	InsertTransactionLedgerTx(ctx context.Context, transaction *transaction_entity.TransactionEntity) errors.AppError
-	GetTransactions(ctx context.Context, accountId, page, count int) (pagination.Pagination[transaction_entity.TransactionEntity], errors.AppError)
+	InsertTransactionLedger(ctx context.Context, tx *sql.Tx, transaction *transaction_entity.TransactionEntity) errors.AppError
+	InsertSettlementLedger(ctx context.Context, tx *sql.Tx, transaction *transaction_entity.TransactionEntity) errors.AppError
+	InsertChargeLedger(ctx context.Context, tx *sql.Tx, transaction *transaction_entity.TransactionEntity, internalCode string) errors.AppError
+	InsertPayoutLedger(ctx context.Context, tx *sql.Tx, transaction *transaction_entity.TransactionEntity, internalCode string) errors.AppError
+	RunInTx(ctx context.Context, fn func(tx *sql.Tx) errors.AppError) errors.AppError
+	LockAccountBalances(ctx context.Context, tx *sql.Tx, accountIDs ...int) (map[int]accountentity.AccountBalance, errors.AppError)
+	LockTransactionAccounts(ctx context.Context, tx *sql.Tx, transaction *transaction_entity.TransactionEntity) (map[int]accountentity.AccountBalance, errors.AppError)
+	FetchInternalAccountId(ctx context.Context, tx *sql.Tx, code string, currency string) (int, errors.AppError)
+	GetTransactions(ctx context.Context, accountID int, filter transaction_entity.TransactionFilter, page, count int) (pagination.Pagination[transaction_entity.TransactionEntity], errors.AppError)
+	ListTransactions(ctx context.Context, accountID int, filter transaction_entity.TransactionFilter, cursor *pagination.Cursor, count int) (pagination.Pagination[transaction_entity.TransactionEntity], errors.AppError)
+	GetAccountActivity(ctx context.Context, accountID, page, count int) (pagination.Pagination[ledgerentity.ActivityEntryEntity], errors.AppError)
+	StreamAccountActivity(ctx context.Context, tx *sql.Tx, accountID int, from, to time.Time, openingBalance money.Money, fn func(entry ledgerentity.ActivityEntryEntity) error) errors.AppError
+	FetchTransactionById(ctx context.Context, tx *sql.Tx, ID int) (transaction_entity.TransactionEntity, errors.AppError)
+	FetchLedgerEntriesByTransaction(ctx context.Context, tx *sql.Tx, transactionID int) ([]ledgerentity.LedgerEntryEntity, errors.AppError)
+	InsertReversalLedgerTx(ctx context.Context, originalID int, amount *money.Money, reasonCode string) (transaction_entity.TransactionEntity, errors.AppError)
+	InsertReversalLedger(ctx context.Context, tx *sql.Tx, originalID int, amount *money.Money, reasonCode string) (transaction_entity.TransactionEntity, errors.AppError)
+}
+
+// Explicit column list, so new columns do not break the positional scans
+const transactionColumns = `id, account_id, type, amount, to_account_id, created_at, updated_at, to_account_number, reversal_of, reason_code, hold_id, fx_rate, to_amount, fee_amount, beneficiary_id, to_iban`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanTransaction(row rowScanner, entity *transaction_entity.TransactionEntity) error {
+	return row.Scan(
+		&entity.ID,
+		&entity.AccountID,
+		&entity.Type,
+		&entity.Amount,
+		&entity.ToAccountID,
+		&entity.CreatedAt,
+		&entity.UpdatedAt,
+		&entity.ToAccountNumber,
+		&entity.ReversalOf,
+		&entity.ReasonCode,
+		&entity.HoldID,
+		&entity.FxRate,
+		&entity.ToAmount,
+		&entity.FeeAmount,
+		&entity.BeneficiaryID,
+		&entity.ToIban,
+	)
 }
 
 type transactionRepository struct {
 	db     *sql.DB
 	logger *zap.Logger
+	// internal account code and currency -> account id. Internal accounts are seeded by migration and never change
+	internalAccounts sync.Map
 }
 
 func NewTransactionRepository(db *sql.DB, logger *zap.Logger) TransactionRepository {
@@ -40,8 +94,8 @@ func NewTransactionRepository(db *sql.DB, logger *zap.Logger) TransactionReposit
 func (r *transactionRepository) InsertTransaction(ctx context.Context, tx *sql.Tx, transaction *transaction_entity.TransactionEntity) errors.AppError {
 	query := `
         INSERT INTO transactions (
-            account_id, to_account_id, amount, type, to_account_number
-        ) VALUES ($1, $2, $3, $4, $5)
+            account_id, to_account_id, amount, type, to_account_number, reversal_of, reason_code, hold_id, fx_rate, to_amount, fee_amount, beneficiary_id, to_iban
+        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
         RETURNING id, created_at, updated_at`
 
 	// Execute the query and scan the returned values into the client struct
@@ -52,6 +106,14 @@ func (r *transactionRepository) InsertTransaction(ctx context.Context, tx *sql.T
 		transaction.Amount,
 		transaction.Type,
 		transaction.ToAccountNumber,
+		transaction.ReversalOf,
+		transaction.ReasonCode,
+		transaction.HoldID,
+		transaction.FxRate,
+		transaction.ToAmount,
+		transaction.FeeAmount,
+		transaction.BeneficiaryID,
+		transaction.ToIban,
 	).Scan(&transaction.ID, &transaction.CreatedAt, &transaction.UpdatedAt)
 
 	if err != nil {
@@ -67,8 +129,8 @@ func (r *transactionRepository) InsertTransaction(ctx context.Context, tx *sql.T
 func (r *transactionRepository) InsertLedgerEntry(ctx context.Context, tx *sql.Tx, ledgerTransaction *ledgerentity.LedgerTransaction) errors.AppError {
 	query := `
         INSERT INTO ledger_entries (
-            transaction_id, account_id, type, amount
-        ) VALUES ($1, $2, $3, $4)`
+            transaction_id, account_id, type, amount, kind
+        ) VALUES ($1, $2, $3, $4, $5)`
 
 	transaction := ledgerTransaction.Transaction
 	// Execute the query and scan the returned values into the client struct
@@ -76,7 +138,8 @@ func (r *transactionRepository) InsertLedgerEntry(ctx context.Context, tx *sql.T
 		transaction.ID,
 		ledgerTransaction.AccountID,
 		ledgerTransaction.LedgerType,
-		transaction.Amount,
+		ledgerTransaction.EntryAmount(),
+		ledgerTransaction.EntryKind(),
 	)
 
 	if err != nil {
@@ -109,95 +172,486 @@ func (r *transactionRepository) InsertLedgerEntry(ctx context.Context, tx *sql.T
 /**
 * Database transaction to move funds: ADD, WITHDRAWAL OR TRANSFER
 * 1. Initialize database transaction (Tx)
-* 2. Check balances if TransactionType is WITHDRAWAL OR TRANSFER
-* 3. Insert the transaction —Money exchange— into the database
-* 4. Insert the LedgerEntry and update the balance for the source account
-* 5. Insert the LedgerEntry and update the balance of the destination account
+* 2. Lock the balances of the involved accounts (lowest account id first, so it cannot deadlock)
+* 3. Compute the fee of the transaction from the fee schedule of the product of the source account
+* 4. Check the status of the accounts and, for WITHDRAWAL OR TRANSFER, the available balance plus the arranged overdraft
+*    against the amount plus the fee
+* 5. Insert the transaction —Money exchange— and its fees into the database
+* 6. Insert the LedgerEntry and update the balance for the source account
+* 7. Insert the opposite LedgerEntry and update the balance of the counterpart account:
+*    the destination account of a TRANSFER, the internal cash in vault account of an ADD or a WITHDRAWAL
+* 8. Debit the fee from the source account and credit it to the fee income account of its currency
 *
+* A TRANSFER between accounts in different currencies is converted with the stored exchange rate, which is
+* recorded on the transaction with the converted amount. Two more entries post the FX legs against the
+* internal FX position accounts, so the entries of every currency are balanced.
 *
+* Serialization failures and deadlocks roll back the whole Tx, which is retried.
  */
 
 func (r *transactionRepository) InsertTransactionLedgerTx(ctx context.Context, transaction *transaction_entity.TransactionEntity) errors.AppError {
-	tx, txErr := r.db.BeginTx(ctx, &sql.TxOptions{
-		ReadOnly:  false,
-		Isolation: 0,
+	return r.RunInTx(ctx, func(tx *sql.Tx) errors.AppError {
+		return r.InsertTransactionLedger(ctx, tx, transaction)
 	})
+}
 
-	if txErr != nil {
-		errStr := fmt.Sprintf("Error occurred while beginning transaction ledger: %s ", txErr.Error())
-		r.logger.Error(errStr)
-		return &errors.ErrInternalServer{Reason: txErr}
+func (r *transactionRepository) RunInTx(ctx context.Context, fn func(tx *sql.Tx) errors.AppError) errors.AppError {
+	return runInTx(ctx, r.db, r.logger, &sql.TxOptions{
+		ReadOnly:  false,
+		Isolation: sql.LevelReadCommitted,
+	}, fn)
+}
+
+// InsertTransactionLedger moves the funds inside the given Tx. See InsertTransactionLedgerTx
+func (r *transactionRepository) InsertTransactionLedger(ctx context.Context, tx *sql.Tx, transaction *transaction_entity.TransactionEntity) errors.AppError {
+	return r.insertTransactionLedger(ctx, tx, transaction, false)
+}
+
+// InsertSettlementLedger moves the funds inside the given Tx without checking the status of the source
+// account nor charging fees. It posts the final transfer of an account being closed, which may be frozen,
+// and the settlement of the SEPA transfers between the clearing and settlement accounts.
+func (r *transactionRepository) InsertSettlementLedger(ctx context.Context, tx *sql.Tx, transaction *transaction_entity.TransactionEntity) errors.AppError {
+	return r.insertTransactionLedger(ctx, tx, transaction, true)
+}
+
+// InsertChargeLedger posts a charge of the bank (i.e. debit interest) inside the given Tx: the account is
+// debited and the internal account with the given code, in the currency of the account, is credited.
+// Charges are contractual, so neither the balance nor the status of the account are checked.
+func (r *transactionRepository) InsertChargeLedger(ctx context.Context, tx *sql.Tx, transaction *transaction_entity.TransactionEntity, internalCode string) errors.AppError {
+	return r.insertInternalLedger(ctx, tx, transaction, internalCode, "DEBIT")
+}
+
+// InsertPayoutLedger posts a payment of the bank (i.e. credit interest) inside the given Tx: the internal account
+// with the given code, in the currency of the account, is debited and the account is credited. As charges,
+// payouts are contractual and the status of the account is not checked.
+func (r *transactionRepository) InsertPayoutLedger(ctx context.Context, tx *sql.Tx, transaction *transaction_entity.TransactionEntity, internalCode string) errors.AppError {
+	return r.insertInternalLedger(ctx, tx, transaction, internalCode, "CREDIT")
+}
+
+/**
+* checkCoolingOff sets the IBAN the transfer is sent to and refuses the transfer when that IBAN is a beneficiary of
+* the client in its cooling-off and, with the ones sent since the beneficiary was added, the transfers of the client
+* to that IBAN go over its limit. Transfers count whether or not they were sent by beneficiary_id; the ones sent
+* before the beneficiary was added do not. A beneficiary cannot be removed during its cooling-off, so removing and
+* adding it again does not start over. The beneficiary is locked, so concurrent transfers to it are added up one at a time. Amounts are added up as they are, in the
+* currency of each account; reversed transfers still count.
+ */
+func (r *transactionRepository) checkCoolingOff(ctx context.Context, tx *sql.Tx, transaction *transaction_entity.TransactionEntity) errors.AppError {
+	if transaction.ToAccountNumber.Valid {
+		transaction.ToIban = sql.NullString{String: utils.NormalizeIban(transaction.ToAccountNumber.String), Valid: true}
+	} else {
+		err := tx.QueryRowContext(ctx, `SELECT account_number FROM accounts WHERE id = $1`, transaction.ToAccountID).Scan(&transaction.ToIban)
+		if err != nil {
+			r.logger.Error(fmt.Sprintf("Error occurred while fetching the IBAN of account %d: %s", transaction.ToAccountID.Int32, err.Error()))
+			return &errors.ErrInternalServer{Reason: err}
+		}
 	}
 
-	// we always starts with the account triggering the transaction
-	ledgerType := ""
+	var beneficiaryID, clientID int
+	var coolingOffUntil time.Time
+	var limit money.Money
+	query := `
+	SELECT b.id, b.client_id, b.cooling_off_until, b.cooling_off_limit
+	FROM beneficiaries b
+	JOIN accounts a ON a.client_id = b.client_id
+	WHERE a.id = $1 AND b.iban = $2 AND b.cooling_off_until > CURRENT_TIMESTAMP
+	FOR UPDATE OF b`
+	err := tx.QueryRowContext(ctx, query, transaction.AccountID, transaction.ToIban).Scan(&beneficiaryID, &clientID, &coolingOffUntil, &limit)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while fetching the beneficiary of transaction to %s: %s", transaction.ToIban.String, err.Error()))
+		return &errors.ErrInternalServer{Reason: err}
+	}
+	var sent money.Money
+	query = `
+	SELECT COALESCE(SUM(t.amount), 0)
+	FROM transactions t
+	JOIN accounts a ON a.id = t.account_id
+	WHERE t.to_iban = $1
+		AND t.created_at >= (SELECT created_at FROM beneficiaries WHERE id = $2)
+		AND a.client_id = $3`
+	err = tx.QueryRowContext(ctx, query, transaction.ToIban, beneficiaryID, clientID).Scan(&sent)
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while adding up the transfers of client %d to %s: %s", clientID, transaction.ToIban.String, err.Error()))
+		return &errors.ErrInternalServer{Reason: err}
+	}
+	if sent.Add(transaction.Amount).GreaterThan(limit) {
+		left := limit.Sub(sent)
+		if left.IsNegative() {
+			left = money.Zero
+		}
+		return &errors.ErrConflict{Message: fmt.Sprintf("beneficiary %s was added recently: until %s the transfers to it cannot add up to more than %s, %s left",
+			transaction.ToIban.String, coolingOffUntil.Format(time.DateTime), limit, left)}
+	}
+	return nil
+}
 
-	balance, err := r.FetchAccountBalance(ctx, tx, transaction.AccountID)
+// insertInternalLedger posts the transaction between the account, on the given side, and an internal account
+func (r *transactionRepository) insertInternalLedger(ctx context.Context, tx *sql.Tx, transaction *transaction_entity.TransactionEntity, internalCode string, ledgerType string) errors.AppError {
+	currency, err := r.fetchAccountCurrency(ctx, tx, transaction.AccountID)
 	if err != nil {
-		tx.Rollback()
 		return err
 	}
+	internalID, err := r.FetchInternalAccountId(ctx, tx, internalCode, currency)
+	if err != nil {
+		return err
+	}
+	balances, err := r.LockAccountBalances(ctx, tx, transaction.AccountID, internalID)
+	if err != nil {
+		return err
+	}
+	source := balances[transaction.AccountID]
+	if !(currencyentity.CurrencyEntity{Code: source.Currency, MinorUnit: source.MinorUnit}).HasScale(transaction.Amount) {
+		return &errors.ErrBadRequest{Message: fmt.Sprintf("%s amounts cannot have more than %d decimals", source.Currency, source.MinorUnit)}
+	}
+	if err := r.InsertTransaction(ctx, tx, transaction); err != nil {
+		return err
+	}
+	internalLedgerType := "CREDIT"
+	if ledgerType == "CREDIT" {
+		internalLedgerType = "DEBIT"
+	}
+	entries := []ledgerentity.LedgerTransaction{
+		{Transaction: *transaction, AccountID: transaction.AccountID, LedgerType: ledgerType},
+		{Transaction: *transaction, AccountID: internalID, LedgerType: internalLedgerType},
+	}
+	for i := range entries {
+		if err := r.InsertLedgerEntry(ctx, tx, &entries[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ledgerAccounts are the accounts a posting moves funds between
+type ledgerAccounts struct {
+	currency            string
+	counterpartID       int
+	counterpartCurrency string
+	fxSourceID          int
+	fxCounterpartID     int
+	feeSchedule         *feeentity.FeeScheduleEntity
+	feeIncomeID         int
+	ids                 []int
+}
+
+// resolveLedgerAccounts finds the accounts the transaction is posted to: the source, the destination (or the cash
+// in vault), the FX position accounts when the currencies differ and the fee income account when a fee applies
+func (r *transactionRepository) resolveLedgerAccounts(ctx context.Context, tx *sql.Tx, transaction *transaction_entity.TransactionEntity, settlement bool) (ledgerAccounts, errors.AppError) {
+	var accounts ledgerAccounts
+	var err errors.AppError
+	transactionType := strings.ToUpper(transaction.Type)
+	if accounts.currency, err = r.fetchAccountCurrency(ctx, tx, transaction.AccountID); err != nil {
+		return accounts, err
+	}
+	accounts.counterpartID = int(transaction.ToAccountID.Int32)
+	accounts.counterpartCurrency = accounts.currency
+	if transaction.ToAccountID.Valid {
+		if accounts.counterpartCurrency, err = r.fetchAccountCurrency(ctx, tx, accounts.counterpartID); err != nil {
+			return accounts, err
+		}
+	} else {
+		// the cash comes from (or goes to) outside of the ledger
+		if accounts.counterpartID, err = r.FetchInternalAccountId(ctx, tx, accountentity.InternalCashInVault, accounts.currency); err != nil {
+			return accounts, err
+		}
+	}
+
+	// a transfer between currencies goes through the FX position account of each currency
+	accounts.ids = []int{transaction.AccountID, accounts.counterpartID}
+	if accounts.currency != accounts.counterpartCurrency {
+		if accounts.fxSourceID, err = r.FetchInternalAccountId(ctx, tx, accountentity.InternalFxPosition, accounts.currency); err != nil {
+			return accounts, err
+		}
+		if accounts.fxCounterpartID, err = r.FetchInternalAccountId(ctx, tx, accountentity.InternalFxPosition, accounts.counterpartCurrency); err != nil {
+			return accounts, err
+		}
+		accounts.ids = append(accounts.ids, accounts.fxSourceID, accounts.fxCounterpartID)
+	}
 
-	err = validators.ValidateTransactionBalance(*transaction, *balance, r.logger)
+	// the fee is paid by the source account, to the fee income account of its currency
+	if !settlement {
+		schedule, queryErr := queryFeeSchedule(ctx, tx, transaction.AccountID, transactionType)
+		if queryErr != nil && queryErr != sql.ErrNoRows {
+			r.logger.Error(fmt.Sprintf("Error occurred while fetching the %s fee schedule of account %d: %s", transactionType, transaction.AccountID, queryErr.Error()))
+			return accounts, &errors.ErrInternalServer{Reason: queryErr}
+		}
+		if queryErr == nil {
+			accounts.feeSchedule = &schedule
+			if accounts.feeIncomeID, err = r.FetchInternalAccountId(ctx, tx, accountentity.InternalFeeIncome, accounts.currency); err != nil {
+				return accounts, err
+			}
+			accounts.ids = append(accounts.ids, accounts.feeIncomeID)
+		}
+	}
+	return accounts, nil
+}
 
+// LockTransactionAccounts locks, by ascending id, the balances of every account InsertTransactionLedger would post
+// the transaction to. Callers that change a balance before posting (i.e. releasing a hold) lock them first, so the
+// lock order stays the same as for any other posting.
+func (r *transactionRepository) LockTransactionAccounts(ctx context.Context, tx *sql.Tx, transaction *transaction_entity.TransactionEntity) (map[int]accountentity.AccountBalance, errors.AppError) {
+	if !transaction.ToAccountID.Valid && strings.ToUpper(transaction.Type) == "TRANSFER" {
+		return nil, &errors.ErrBadRequest{Message: "TRANSFER type needs a destination account"}
+	}
+	accounts, err := r.resolveLedgerAccounts(ctx, tx, transaction, false)
 	if err != nil {
-		tx.Rollback()
-		return err
+		return nil, err
 	}
+	return r.LockAccountBalances(ctx, tx, accounts.ids...)
+}
 
-	err = r.InsertTransaction(ctx, tx, transaction)
+func (r *transactionRepository) insertTransactionLedger(ctx context.Context, tx *sql.Tx, transaction *transaction_entity.TransactionEntity, settlement bool) errors.AppError {
+	transactionType := strings.ToUpper(transaction.Type)
+	if !transaction.ToAccountID.Valid && transactionType == "TRANSFER" {
+		return &errors.ErrBadRequest{Message: "TRANSFER type needs a destination account"}
+	}
+	accounts, err := r.resolveLedgerAccounts(ctx, tx, transaction, settlement)
+	if err != nil {
+		return err
+	}
+	currency, counterpartID, counterpartCurrency := accounts.currency, accounts.counterpartID, accounts.counterpartCurrency
+	isFx := currency != counterpartCurrency
+	fxSourceID, fxCounterpartID := accounts.fxSourceID, accounts.fxCounterpartID
+	feeSchedule, feeIncomeID := accounts.feeSchedule, accounts.feeIncomeID
 
+	balances, err := r.LockAccountBalances(ctx, tx, accounts.ids...)
 	if err != nil {
-		tx.Rollback()
 		return err
 	}
+	source := balances[transaction.AccountID]
+	if !(currencyentity.CurrencyEntity{Code: source.Currency, MinorUnit: source.MinorUnit}).HasScale(transaction.Amount) {
+		return &errors.ErrBadRequest{Message: fmt.Sprintf("%s amounts cannot have more than %d decimals", source.Currency, source.MinorUnit)}
+	}
+	// an ADD credits the source account, the rest debit it
+	if transactionType == "ADD" && !accountentity.CanReceive(source.Status) ||
+		transactionType != "ADD" && !settlement && !accountentity.CanSend(source.Status) {
+		return &errors.ErrAccountNotActive{Message: fmt.Sprintf("account %d is %s", transaction.AccountID, source.Status)}
+	}
+	if transaction.ToAccountID.Valid && !accountentity.CanReceive(balances[counterpartID].Status) {
+		return &errors.ErrAccountNotActive{Message: fmt.Sprintf("destination account %d is %s", counterpartID, balances[counterpartID].Status)}
+	}
+	if !settlement && (transaction.ToAccountNumber.Valid || transaction.ToAccountID.Valid) {
+		if err := r.checkCoolingOff(ctx, tx, transaction); err != nil {
+			return err
+		}
+	}
 
-	if strings.ToUpper(transaction.Type) != "ADD" {
-		ledgerType = "DEBIT"
-	} else {
-		ledgerType = "CREDIT"
+	transaction.Fees = nil
+	transaction.FeeAmount = money.Zero
+	if feeSchedule != nil {
+		fees, computeErr := feeSchedule.Compute(transaction.Amount, source.MinorUnit)
+		if computeErr != nil {
+			r.logger.Error(fmt.Sprintf("Fee schedule %d is not valid: %s", feeSchedule.ID, computeErr.Error()))
+			return &errors.ErrInternalServer{Reason: computeErr}
+		}
+		transaction.Fees = fees
+		transaction.FeeAmount = feeentity.Total(fees)
+	}
+
+	// funds reserved by holds cannot be spent, the arranged overdraft can. The fee is spent on top of the amount,
+	// the fee of an ADD is taken from the deposit. Internal accounts (i.e. settling SEPA credits) can go below zero
+	if !source.Internal {
+		err = validators.ValidateTransactionBalance(*transaction, source.Spendable(), r.logger)
+		if err != nil {
+			return err
+		}
+	}
+	if transactionType == "ADD" && transaction.FeeAmount.GreaterThan(transaction.Amount.Add(source.Spendable())) {
+		return &errors.ErrNotEnoughFunds{Message: fmt.Sprintf("Not enough funds. The fee of %s units exceeds the deposit", transaction.FeeAmount)}
+	}
+
+	counterpartAmount := transaction.Amount
+	if isFx {
+		rate, err := r.appliedExchangeRate(ctx, tx, currency, counterpartCurrency)
+		if err != nil {
+			return err
+		}
+		counterpartAmount = currencyentity.Convert(transaction.Amount, rate, balances[counterpartID].MinorUnit)
+		if !counterpartAmount.IsPositive() {
+			return &errors.ErrBadRequest{Message: fmt.Sprintf("%s %s is too small to be converted to %s", transaction.Amount, currency, counterpartCurrency)}
+		}
+		transaction.FxRate = sql.NullString{String: rate.FloatString(10), Valid: true}
+		transaction.ToAmount = money.NullMoney{Money: counterpartAmount, Valid: true}
 	}
 
+	err = r.InsertTransaction(ctx, tx, transaction)
+	if err != nil {
+		return err
+	}
+	if err := r.insertTransactionFees(ctx, tx, transaction); err != nil {
+		return err
+	}
+
+	// we always starts with the account triggering the transaction
+	ledgerType := "DEBIT"
+	counterpartLedgerType := "CREDIT"
+	if transactionType == "ADD" {
+		ledgerType, counterpartLedgerType = counterpartLedgerType, ledgerType
+	}
 	transactionLedger := ledgerentity.LedgerTransaction{
 		Transaction: *transaction,
 		LedgerType:  ledgerType,
 		AccountID:   transaction.AccountID,
 	}
-
 	err = r.InsertLedgerEntry(ctx, tx, &transactionLedger)
 	if err != nil {
-		tx.Rollback()
 		return err
 	}
 
-	if !transaction.ToAccountID.Valid {
-		warning := fmt.Sprintf(
-			"No attached ToAccountId in transaction %d. Tx finishing without errors for account_id %d",
-			transaction.ID,
-			transaction.AccountID,
-		)
-		r.logger.Warn(warning)
-		tx.Commit()
-		return nil
+	if isFx {
+		// the source currency is bought by the bank and the destination currency sold, each leg balanced in its currency
+		legs := []ledgerentity.LedgerTransaction{
+			{Transaction: *transaction, AccountID: fxSourceID, LedgerType: counterpartLedgerType, Amount: transaction.Amount},
+			{Transaction: *transaction, AccountID: fxCounterpartID, LedgerType: ledgerType, Amount: counterpartAmount},
+		}
+		for i := range legs {
+			if err := r.InsertLedgerEntry(ctx, tx, &legs[i]); err != nil {
+				return err
+			}
+		}
 	}
-	// If there's a valid ToAccountId, another ledger entry has to be inserted
 
-	if ledgerType == "CREDIT" {
-		ledgerType = "DEBIT"
+	transactionLedger.AccountID = counterpartID
+	transactionLedger.LedgerType = counterpartLedgerType
+	transactionLedger.Amount = counterpartAmount
+	if err := r.InsertLedgerEntry(ctx, tx, &transactionLedger); err != nil {
+		return err
+	}
+
+	if transaction.FeeAmount.IsPositive() {
+		feeEntries := []ledgerentity.LedgerTransaction{
+			{Transaction: *transaction, AccountID: transaction.AccountID, LedgerType: "DEBIT", Amount: transaction.FeeAmount, Kind: ledgerentity.KindFee},
+			{Transaction: *transaction, AccountID: feeIncomeID, LedgerType: "CREDIT", Amount: transaction.FeeAmount, Kind: ledgerentity.KindFee},
+		}
+		for i := range feeEntries {
+			if err := r.InsertLedgerEntry(ctx, tx, &feeEntries[i]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// insertTransactionFees records the components of the fee of a posted transaction
+func (r *transactionRepository) insertTransactionFees(ctx context.Context, tx *sql.Tx, transaction *transaction_entity.TransactionEntity) errors.AppError {
+	query := `
+	INSERT INTO transaction_fees (transaction_id, fee_schedule_id, kind, amount)
+	VALUES ($1, $2, $3, $4)
+	RETURNING id, created_at`
+	for i := range transaction.Fees {
+		fee := &transaction.Fees[i]
+		fee.TransactionID = transaction.ID
+		err := tx.QueryRowContext(ctx, query, fee.TransactionID, fee.FeeScheduleID, fee.Kind, fee.Amount).Scan(&fee.ID, &fee.CreatedAt)
+		if err != nil {
+			r.logger.Error(fmt.Sprintf("Error occurred while inserting fee of transaction %d: %s", transaction.ID, err.Error()))
+			return &errors.ErrInternalServer{Reason: err}
+		}
+	}
+	return nil
+}
+
+// FetchInternalAccountId returns the id of the bank owned account with the given code (accountentity.Internal*) and currency
+func (r *transactionRepository) FetchInternalAccountId(ctx context.Context, tx *sql.Tx, code string, currency string) (int, errors.AppError) {
+	key := code + "/" + currency
+	if id, ok := r.internalAccounts.Load(key); ok {
+		return id.(int), nil
+	}
+	query := `SELECT id from accounts where internal_code = $1 and currency = $2`
+	var id int
+	var err error
+	if tx == nil {
+		err = r.db.QueryRowContext(ctx, query, code, currency).Scan(&id)
 	} else {
-		ledgerType = "CREDIT"
+		err = tx.QueryRowContext(ctx, query, code, currency).Scan(&id)
+	}
+	if err == sql.ErrNoRows {
+		r.logger.Error(fmt.Sprintf("Internal account %s in %s not found. Are the migrations applied?", code, currency))
+		return 0, &errors.ErrInternalServer{Reason: err}
 	}
-	transactionLedger.AccountID = int(transaction.ToAccountID.Int32)
-	transactionLedger.LedgerType = ledgerType
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while fetching internal account %s in %s: %s", code, currency, err.Error()))
+		return 0, &errors.ErrInternalServer{Reason: err}
+	}
+	r.internalAccounts.Store(key, id)
+	return id, nil
+}
 
-	err = r.InsertLedgerEntry(ctx, tx, &transactionLedger)
+// fetchAccountCurrency returns the currency of an account. It never changes, so the row is not locked.
+func (r *transactionRepository) fetchAccountCurrency(ctx context.Context, tx *sql.Tx, accountID int) (string, errors.AppError) {
+	var currency string
+	err := tx.QueryRowContext(ctx, `SELECT currency from accounts where id = $1`, accountID).Scan(&currency)
+	if err == sql.ErrNoRows {
+		return "", &errors.ErrNotFound{Entity: "Account", Reason: err}
+	}
 	if err != nil {
-		tx.Rollback()
-		return err
+		r.logger.Error(fmt.Sprintf("Error occurred while fetching currency of account %d: %s", accountID, err.Error()))
+		return "", &errors.ErrInternalServer{Reason: err}
 	}
-	tx.Commit()
-	return nil
+	return currency, nil
+}
+
+// appliedExchangeRate is the rate of a transfer from base to quote. When only the quote to base rate
+// is stored, its inverse is rounded to the 10 decimals of transactions.fx_rate, so the recorded rate is the applied one.
+func (r *transactionRepository) appliedExchangeRate(ctx context.Context, tx *sql.Tx, base, quote string) (*big.Rat, errors.AppError) {
+	stored, inverse, err := queryExchangeRate(ctx, tx, base, quote)
+	if err == sql.ErrNoRows {
+		return nil, &errors.ErrBadRequest{Message: fmt.Sprintf("there is no exchange rate from %s to %s", base, quote)}
+	}
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while fetching the %s/%s exchange rate: %s", base, quote, err.Error()))
+		return nil, &errors.ErrInternalServer{Reason: err}
+	}
+	rate, err := currencyentity.ParseRate(stored)
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("Stored %s/%s exchange rate %s is not valid", base, quote, stored))
+		return nil, &errors.ErrInternalServer{Reason: err}
+	}
+	if inverse {
+		rate, _ = new(big.Rat).SetString(new(big.Rat).Inv(rate).FloatString(10))
+		if rate.Sign() <= 0 {
+			return nil, &errors.ErrBadRequest{Message: fmt.Sprintf("the %s/%s exchange rate is too small", base, quote)}
+		}
+	}
+	return rate, nil
+}
 
+// LockAccountBalances locks the balance rows of the accounts until the Tx finishes.
+// Rows are always locked by ascending account id, so two transactions over the same
+// accounts wait for each other instead of deadlocking. The account rows are share locked,
+// so their status cannot change until the Tx finishes.
+func (r *transactionRepository) LockAccountBalances(ctx context.Context, tx *sql.Tx, accountIDs ...int) (map[int]accountentity.AccountBalance, errors.AppError) {
+	ids := slices.Clone(accountIDs)
+	slices.Sort(ids)
+	ids = slices.Compact(ids)
+
+	query := `
+	SELECT ab.account_id, ab.balance, ab.held_amount, a.internal_code IS NOT NULL, a.currency, c.minor_unit, a.status,
+		COALESCE(o.limit_amount, 0)
+	FROM account_balances ab
+	JOIN accounts a ON a.id = ab.account_id
+	JOIN currencies c ON c.code = a.currency
+	LEFT JOIN overdraft_facilities o ON o.account_id = a.id
+		AND o.valid_from <= CURRENT_DATE AND (o.valid_to IS NULL OR o.valid_to >= CURRENT_DATE)
+	WHERE ab.account_id = $1
+	FOR UPDATE OF ab FOR SHARE OF a`
+	balances := make(map[int]accountentity.AccountBalance, len(ids))
+	for _, accountID := range ids {
+		var balance accountentity.AccountBalance
+		err := tx.QueryRowContext(ctx, query, accountID).Scan(&balance.AccountID, &balance.Balance, &balance.HeldAmount, &balance.Internal, &balance.Currency, &balance.MinorUnit, &balance.Status, &balance.OverdraftLimit)
+		if err == sql.ErrNoRows {
+			r.logger.Error(fmt.Sprintf("No account balance found. ACCOUNT_ID: %d", accountID))
+			return nil, &errors.ErrNotFound{Entity: "Account", Reason: err}
+		}
+		if err != nil {
+			r.logger.Error(fmt.Sprintf("Error occurred while locking account balance. ACCOUNT_ID: %d. %s", accountID, err.Error()))
+			return nil, &errors.ErrInternalServer{Reason: err}
+		}
+		balances[accountID] = balance
+	}
+	return balances, nil
 }
 
 func (r *transactionRepository) updateAccountBalance(
@@ -216,12 +670,12 @@ func (r *transactionRepository) updateAccountBalance(
 		query = `UPDATE account_balances SET balance = balance - $1 where account_id = $2`
 	}
 
-	_, err := tx.ExecContext(ctx, query, ledgerTransaction.Transaction.Amount, ledgerTransaction.AccountID)
+	_, err := tx.ExecContext(ctx, query, ledgerTransaction.EntryAmount(), ledgerTransaction.AccountID)
 	if err != nil {
 		errStr := fmt.Sprintf(
-			"Error occurred in Txn while updating account balance. ACTION: %s, AMOUNT: %f, ACCOUNT_ID: %d",
+			"Error occurred in Txn while updating account balance. ACTION: %s, AMOUNT: %s, ACCOUNT_ID: %d",
 			ledgerTransaction.LedgerType,
-			ledgerTransaction.Transaction.Amount,
+			ledgerTransaction.EntryAmount(),
 			ledgerTransaction.AccountID,
 		)
 		r.logger.Error(errStr)
@@ -232,11 +686,19 @@ func (r *transactionRepository) updateAccountBalance(
 
 }
 
-func (r *transactionRepository) FetchAccountBalance(ctx context.Context, tx *sql.Tx, accountID int) (*float64, errors.AppError) {
-	query := `SELECT balance from account_balances where account_id = $1`
-	var balance *float64
+func (r *transactionRepository) FetchAccountBalance(ctx context.Context, tx *sql.Tx, accountID int) (*accountentity.AccountBalance, errors.AppError) {
+	query := `
+	SELECT ab.account_id, ab.balance, ab.held_amount, a.internal_code IS NOT NULL, a.currency, c.minor_unit, a.status,
+		COALESCE(o.limit_amount, 0)
+	FROM account_balances ab
+	JOIN accounts a ON a.id = ab.account_id
+	JOIN currencies c ON c.code = a.currency
+	LEFT JOIN overdraft_facilities o ON o.account_id = a.id
+		AND o.valid_from <= CURRENT_DATE AND (o.valid_to IS NULL OR o.valid_to >= CURRENT_DATE)
+	WHERE ab.account_id = $1`
+	balance := &accountentity.AccountBalance{}
 	if tx == nil {
-		err := r.db.QueryRowContext(ctx, query, accountID).Scan(&balance)
+		err := r.db.QueryRowContext(ctx, query, accountID).Scan(&balance.AccountID, &balance.Balance, &balance.HeldAmount, &balance.Internal, &balance.Currency, &balance.MinorUnit, &balance.Status, &balance.OverdraftLimit)
 		if err != nil {
 			errStr := fmt.Sprintf(
 				"Error occurred while fetching account balance. ACCOUNT_ID: %v",
@@ -247,7 +709,7 @@ func (r *transactionRepository) FetchAccountBalance(ctx context.Context, tx *sql
 
 		}
 	} else {
-		err := tx.QueryRowContext(ctx, query, accountID).Scan(&balance)
+		err := tx.QueryRowContext(ctx, query, accountID).Scan(&balance.AccountID, &balance.Balance, &balance.HeldAmount, &balance.Internal, &balance.Currency, &balance.MinorUnit, &balance.Status, &balance.OverdraftLimit)
 		if err != nil {
 			errStr := fmt.Sprintf(
 				"Error occurred while fetching account balance. ACCOUNT_ID: %v",
@@ -261,53 +723,91 @@ func (r *transactionRepository) FetchAccountBalance(ctx context.Context, tx *sql
 
 }
 
-func (r *transactionRepository) GetTransactions(ctx context.Context, accountID, page, count int) (pagination.Pagination[transaction_entity.TransactionEntity], errors.AppError) {
-	queryTotal := `SELECT count(id) from transactions where account_id = $1`
+// transactionFilterClause builds the WHERE clause of the transaction history of an account and its arguments
+func transactionFilterClause(accountID int, filter transaction_entity.TransactionFilter) (string, []any) {
+	conditions := []string{"account_id = $1"}
+	args := []any{accountID}
+	add := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if filter.From != nil {
+		add("created_at >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		add("created_at < $%d", *filter.To)
+	}
+	if filter.Type != "" {
+		add("type = $%d", filter.Type)
+	}
+	if filter.MinAmount != nil {
+		add("amount >= $%d", *filter.MinAmount)
+	}
+	if filter.MaxAmount != nil {
+		add("amount <= $%d", *filter.MaxAmount)
+	}
+	if filter.CounterpartyIban != "" {
+		add("to_account_number = $%d", filter.CounterpartyIban)
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
+func (r *transactionRepository) queryTransactions(ctx context.Context, query string, args ...any) ([]transaction_entity.TransactionEntity, errors.AppError) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("Error %s", err.Error()))
+		return nil, &errors.ErrInternalServer{Reason: err}
+	}
+	defer rows.Close()
+	transactions := make([]transaction_entity.TransactionEntity, 0)
+	for rows.Next() {
+		var entity transaction_entity.TransactionEntity
+		if err := scanTransaction(rows, &entity); err != nil {
+			r.logger.Error(fmt.Sprintf("Error %s", err.Error()))
+			return nil, &errors.ErrInternalServer{Reason: err}
+		}
+		transactions = append(transactions, entity)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, &errors.ErrInternalServer{Reason: err}
+	}
+	return transactions, nil
+}
+
+// GetTransactions pages the transactions of an account with OFFSET. Kept for compatibility, ListTransactions
+// is faster on large accounts and stable while new transactions arrive.
+func (r *transactionRepository) GetTransactions(ctx context.Context, accountID int, filter transaction_entity.TransactionFilter, page, count int) (pagination.Pagination[transaction_entity.TransactionEntity], errors.AppError) {
 	if page < 1 || count < 1 {
 
 		return pagination.Pagination[transaction_entity.TransactionEntity]{}, &errors.ErrBadRequest{}
 	}
+	where, args := transactionFilterClause(accountID, filter)
 
-	var totalRows *int
-	err := r.db.QueryRowContext(ctx, queryTotal, accountID).Scan(&totalRows)
+	var totalRows int
+	err := r.db.QueryRowContext(ctx, `SELECT count(id) from transactions where `+where, args...).Scan(&totalRows)
 	if err != nil {
 		r.logger.Error(fmt.Sprintf("Error %s", err.Error()))
 		return pagination.Pagination[transaction_entity.TransactionEntity]{}, &errors.ErrInternalServer{}
 	}
-	lastPage := *totalRows / count
-	if *totalRows%count != 0 {
+	lastPage := totalRows / count
+	if totalRows%count != 0 {
 		lastPage++
 	}
 	offset := 0
 	if page > 1 {
 		offset = count * (page - 1)
 	}
-	query := `
-	 SELECT * from transactions where account_id = $1 order by created_at desc limit $2 offset $3 
-	`
-	var transactions []transaction_entity.TransactionEntity = make([]transaction_entity.TransactionEntity, 0)
-	rows, err := r.db.QueryContext(ctx, query, accountID, count, offset)
-	if err != nil {
-		r.logger.Error(fmt.Sprintf("Error %s", err.Error()))
-		return pagination.Pagination[transaction_entity.TransactionEntity]{}, &errors.ErrInternalServer{}
+	order := "desc"
+	if filter.IsAscending() {
+		order = "asc"
 	}
-	for rows.Next() {
-		var entity transaction_entity.TransactionEntity
-		err := rows.Scan(
-			&entity.ID,
-			&entity.AccountID,
-			&entity.Type,
-			&entity.Amount,
-			&entity.ToAccountID,
-			&entity.CreatedAt,
-			&entity.UpdatedAt,
-			&entity.ToAccountNumber,
-		)
-		transactions = append(transactions, entity)
-		if err != nil {
-			r.logger.Error(fmt.Sprintf("Error %s", err.Error()))
-			return pagination.Pagination[transaction_entity.TransactionEntity]{}, &errors.ErrInternalServer{}
-		}
+	query := fmt.Sprintf(
+		`SELECT `+transactionColumns+` from transactions where %s order by created_at %s, id %s limit $%d offset $%d`,
+		where, order, order, len(args)+1, len(args)+2,
+	)
+	transactions, appErr := r.queryTransactions(ctx, query, append(args, count, offset)...)
+	if appErr != nil {
+		return pagination.Pagination[transaction_entity.TransactionEntity]{}, appErr
 	}
 
 	nrTransactions := len(transactions)
@@ -324,3 +824,572 @@ func (r *transactionRepository) GetTransactions(ctx context.Context, accountID,
 
 	return pagination, nil
 }
+
+// ListTransactions pages the transactions of an account with a keyset on (created_at, id).
+// A nil cursor returns the first page. The page following the last item is returned in NextCursor
+// and the one preceding the first item in PrevCursor, when there are more transactions that way.
+func (r *transactionRepository) ListTransactions(ctx context.Context, accountID int, filter transaction_entity.TransactionFilter, cursor *pagination.Cursor, count int) (pagination.Pagination[transaction_entity.TransactionEntity], errors.AppError) {
+	if count < 1 {
+		return pagination.Pagination[transaction_entity.TransactionEntity]{}, &errors.ErrBadRequest{Message: "count must be positive"}
+	}
+	where, args := transactionFilterClause(accountID, filter)
+
+	// the previous page is read backwards from the cursor and turned around afterwards
+	backwards := cursor != nil && cursor.Before
+	ascending := filter.IsAscending() != backwards
+	order, comparison := "desc", "<"
+	if ascending {
+		order, comparison = "asc", ">"
+	}
+	if cursor != nil {
+		args = append(args, cursor.CreatedAt, cursor.ID)
+		where += fmt.Sprintf(" AND (created_at, id) %s ($%d, $%d)", comparison, len(args)-1, len(args))
+	}
+	// one more row tells whether there is another page
+	args = append(args, count+1)
+	query := fmt.Sprintf(
+		`SELECT `+transactionColumns+` from transactions where %s order by created_at %s, id %s limit $%d`,
+		where, order, order, len(args),
+	)
+	transactions, appErr := r.queryTransactions(ctx, query, args...)
+	if appErr != nil {
+		return pagination.Pagination[transaction_entity.TransactionEntity]{}, appErr
+	}
+	hasMore := len(transactions) > count
+	if hasMore {
+		transactions = transactions[:count]
+	}
+	if backwards {
+		slices.Reverse(transactions)
+	}
+
+	result := pagination.Pagination[transaction_entity.TransactionEntity]{
+		Count: len(transactions),
+		Items: transactions,
+	}
+	encode := func(transaction transaction_entity.TransactionEntity, before bool) *string {
+		token := pagination.Cursor{CreatedAt: transaction.CreatedAt, ID: transaction.ID, Before: before}.Encode()
+		return &token
+	}
+	if len(transactions) == 0 {
+		// nothing past the cursor: going back from it is still possible
+		if cursor != nil {
+			token := pagination.Cursor{CreatedAt: cursor.CreatedAt, ID: cursor.ID, Before: !cursor.Before}.Encode()
+			if backwards {
+				result.NextCursor = &token
+			} else {
+				result.PrevCursor = &token
+			}
+		}
+		return result, nil
+	}
+	first, last := transactions[0], transactions[len(transactions)-1]
+	if hasMore || backwards {
+		result.NextCursor = encode(last, false)
+	}
+	if (hasMore && backwards) || (cursor != nil && !backwards) {
+		result.PrevCursor = encode(first, true)
+	}
+	return result, nil
+}
+
+// StreamAccountActivity calls fn with every ledger entry of the account created between from (included) and
+// to (excluded), in posting order, as the rows are read: the entries are never held in memory together. The
+// running balance starts from openingBalance, the balance at from. An error of fn stops the stream.
+func (r *transactionRepository) StreamAccountActivity(ctx context.Context, tx *sql.Tx, accountID int, from, to time.Time, openingBalance money.Money, fn func(entry ledgerentity.ActivityEntryEntity) error) errors.AppError {
+	query := `
+	WITH entries AS (
+		SELECT
+			id,
+			transaction_id,
+			UPPER(type) AS type,
+			kind,
+			amount,
+			created_at,
+			SUM(CASE WHEN UPPER(type) = 'CREDIT' THEN amount ELSE -amount END) OVER (ORDER BY id) AS movement
+		FROM ledger_entries
+		WHERE account_id = $1 AND created_at >= $2 AND created_at < $3
+	)
+	SELECT
+		e.id,
+		e.transaction_id,
+		t.type,
+		e.type,
+		e.kind,
+		e.amount,
+		e.movement,
+		ca.id,
+		COALESCE(ca.account_number, op.creditor_iban, ip.debtor_iban),
+		COALESCE(cc.name || ' ' || cc.surname1 || COALESCE(' ' || cc.surname2, ''), op.creditor_name, ip.debtor_name),
+		e.created_at
+	FROM entries e
+	JOIN transactions t ON t.id = e.transaction_id
+	LEFT JOIN LATERAL (
+		SELECT o.account_id FROM ledger_entries o
+		JOIN accounts oa ON oa.id = o.account_id
+		WHERE o.transaction_id = e.transaction_id AND o.account_id <> $1 AND o.kind = e.kind
+		ORDER BY oa.internal_code IS NOT NULL, o.id
+		LIMIT 1
+	) other ON true
+	LEFT JOIN accounts ca ON ca.id = other.account_id AND ca.internal_code IS NULL
+	LEFT JOIN clients cc ON cc.id = ca.client_id
+	LEFT JOIN outbound_payments op ON op.transaction_id = e.transaction_id AND e.kind = 'PRINCIPAL'
+	LEFT JOIN inbound_payments ip ON e.transaction_id IN (ip.transaction_id, ip.resolution_transaction_id) AND e.kind = 'PRINCIPAL'
+	ORDER BY e.id`
+	var rows *sql.Rows
+	var err error
+	if tx == nil {
+		rows, err = r.db.QueryContext(ctx, query, accountID, from, to)
+	} else {
+		rows, err = tx.QueryContext(ctx, query, accountID, from, to)
+	}
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while streaming activity of account %d: %s", accountID, err.Error()))
+		return &errors.ErrInternalServer{Reason: err}
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var entry ledgerentity.ActivityEntryEntity
+		var ledgerType string
+		var movement money.Money
+		err := rows.Scan(
+			&entry.EntryID,
+			&entry.TransactionID,
+			&entry.TransactionType,
+			&ledgerType,
+			&entry.Kind,
+			&entry.Amount,
+			&movement,
+			&entry.CounterpartyAccountID,
+			&entry.CounterpartyIban,
+			&entry.CounterpartyName,
+			&entry.CreatedAt,
+		)
+		if err != nil {
+			r.logger.Error("Error occurred while scanning activity entry: " + err.Error())
+			return &errors.ErrInternalServer{Reason: err}
+		}
+		entry.Direction = ledgerentity.DirectionOf(ledgerType)
+		entry.BalanceAfter = openingBalance.Add(movement)
+		if err := fn(entry); err != nil {
+			return &errors.ErrInternalServer{Reason: err}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return &errors.ErrInternalServer{Reason: err}
+	}
+	return nil
+}
+
+// GetAccountActivity pages the ledger entries of an account, newest first. Unlike GetTransactions it
+// includes the incoming transfers. The running balance is computed over every entry of the account
+// in posting order, and the counterparty is the other client account of the transaction, if any, the
+// creditor of a SEPA transfer or the debtor of a SEPA credit. The other side of a fee entry is the fee
+// income account, so fees have no counterparty.
+func (r *transactionRepository) GetAccountActivity(ctx context.Context, accountID, page, count int) (pagination.Pagination[ledgerentity.ActivityEntryEntity], errors.AppError) {
+	if page < 1 || count < 1 {
+		return pagination.Pagination[ledgerentity.ActivityEntryEntity]{}, &errors.ErrBadRequest{Message: "page and count must be positive"}
+	}
+
+	var totalRows int
+	err := r.db.QueryRowContext(ctx, `SELECT count(id) FROM ledger_entries WHERE account_id = $1`, accountID).Scan(&totalRows)
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while counting ledger entries of account %d: %s", accountID, err.Error()))
+		return pagination.Pagination[ledgerentity.ActivityEntryEntity]{}, &errors.ErrInternalServer{Reason: err}
+	}
+	lastPage := totalRows / count
+	if totalRows%count != 0 {
+		lastPage++
+	}
+
+	query := `
+	WITH entries AS (
+		SELECT
+			id,
+			transaction_id,
+			UPPER(type) AS type,
+			kind,
+			amount,
+			created_at,
+			SUM(CASE WHEN UPPER(type) = 'CREDIT' THEN amount ELSE -amount END) OVER (ORDER BY id) AS balance_after
+		FROM ledger_entries
+		WHERE account_id = $1
+	)
+	SELECT
+		e.id,
+		e.transaction_id,
+		t.type,
+		e.type,
+		e.kind,
+		e.amount,
+		e.balance_after,
+		ca.id,
+		COALESCE(ca.account_number, op.creditor_iban, ip.debtor_iban),
+		COALESCE(cc.name || ' ' || cc.surname1 || COALESCE(' ' || cc.surname2, ''), op.creditor_name, ip.debtor_name),
+		e.created_at
+	FROM entries e
+	JOIN transactions t ON t.id = e.transaction_id
+	LEFT JOIN LATERAL (
+		SELECT o.account_id FROM ledger_entries o
+		JOIN accounts oa ON oa.id = o.account_id
+		WHERE o.transaction_id = e.transaction_id AND o.account_id <> $1 AND o.kind = e.kind
+		ORDER BY oa.internal_code IS NOT NULL, o.id
+		LIMIT 1
+	) other ON true
+	LEFT JOIN accounts ca ON ca.id = other.account_id AND ca.internal_code IS NULL
+	LEFT JOIN clients cc ON cc.id = ca.client_id
+	LEFT JOIN outbound_payments op ON op.transaction_id = e.transaction_id AND e.kind = 'PRINCIPAL'
+	LEFT JOIN inbound_payments ip ON e.transaction_id IN (ip.transaction_id, ip.resolution_transaction_id) AND e.kind = 'PRINCIPAL'
+	ORDER BY e.id DESC
+	LIMIT $2 OFFSET $3`
+	rows, err := r.db.QueryContext(ctx, query, accountID, count, count*(page-1))
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while fetching activity of account %d: %s", accountID, err.Error()))
+		return pagination.Pagination[ledgerentity.ActivityEntryEntity]{}, &errors.ErrInternalServer{Reason: err}
+	}
+	defer rows.Close()
+	entries := make([]ledgerentity.ActivityEntryEntity, 0)
+	for rows.Next() {
+		var entry ledgerentity.ActivityEntryEntity
+		var ledgerType string
+		err := rows.Scan(
+			&entry.EntryID,
+			&entry.TransactionID,
+			&entry.TransactionType,
+			&ledgerType,
+			&entry.Kind,
+			&entry.Amount,
+			&entry.BalanceAfter,
+			&entry.CounterpartyAccountID,
+			&entry.CounterpartyIban,
+			&entry.CounterpartyName,
+			&entry.CreatedAt,
+		)
+		if err != nil {
+			r.logger.Error("Error occurred while scanning activity entry: " + err.Error())
+			return pagination.Pagination[ledgerentity.ActivityEntryEntity]{}, &errors.ErrInternalServer{Reason: err}
+		}
+		entry.Direction = ledgerentity.DirectionOf(ledgerType)
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return pagination.Pagination[ledgerentity.ActivityEntryEntity]{}, &errors.ErrInternalServer{Reason: err}
+	}
+
+	return pagination.Pagination[ledgerentity.ActivityEntryEntity]{
+		Page:     page,
+		LastPage: lastPage,
+		Count:    len(entries),
+		Items:    entries,
+	}, nil
+}
+
+// FetchTransactionById fetches a transaction. Inside a Tx, the row is locked until the Tx finishes.
+func (r *transactionRepository) FetchTransactionById(ctx context.Context, tx *sql.Tx, ID int) (transaction_entity.TransactionEntity, errors.AppError) {
+	var entity transaction_entity.TransactionEntity
+	var err error
+	if tx == nil {
+		query := `SELECT ` + transactionColumns + ` from transactions where id = $1`
+		err = scanTransaction(r.db.QueryRowContext(ctx, query, ID), &entity)
+	} else {
+		query := `SELECT ` + transactionColumns + ` from transactions where id = $1 FOR UPDATE`
+		err = scanTransaction(tx.QueryRowContext(ctx, query, ID), &entity)
+	}
+	if err == sql.ErrNoRows {
+		r.logger.Error("No transaction found " + fmt.Sprint(ID))
+		return transaction_entity.TransactionEntity{}, &errors.ErrNotFound{Entity: "Transaction", Reason: err}
+	}
+	if err != nil {
+		r.logger.Error("Error occurred: " + err.Error())
+		return transaction_entity.TransactionEntity{}, &errors.ErrInternalServer{Reason: err}
+	}
+	return entity, nil
+}
+
+func (r *transactionRepository) FetchLedgerEntriesByTransaction(ctx context.Context, tx *sql.Tx, transactionID int) ([]ledgerentity.LedgerEntryEntity, errors.AppError) {
+	query := `
+	 SELECT id, transaction_id, account_id, type, kind, amount, created_at, updated_at
+	 FROM ledger_entries WHERE transaction_id = $1 ORDER BY id
+	`
+	var rows *sql.Rows
+	var err error
+	if tx == nil {
+		rows, err = r.db.QueryContext(ctx, query, transactionID)
+	} else {
+		rows, err = tx.QueryContext(ctx, query, transactionID)
+	}
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while fetching ledger entries of transaction %d: %s", transactionID, err.Error()))
+		return nil, &errors.ErrInternalServer{Reason: err}
+	}
+	defer rows.Close()
+	entries := make([]ledgerentity.LedgerEntryEntity, 0)
+	for rows.Next() {
+		var entry ledgerentity.LedgerEntryEntity
+		err := rows.Scan(
+			&entry.ID,
+			&entry.TransactionID,
+			&entry.AccountID,
+			&entry.Type,
+			&entry.Kind,
+			&entry.Amount,
+			&entry.CreatedAt,
+			&entry.UpdatedAt,
+		)
+		if err != nil {
+			r.logger.Error("Error occurred while scanning ledger entry: " + err.Error())
+			return nil, &errors.ErrInternalServer{Reason: err}
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, &errors.ErrInternalServer{Reason: err}
+	}
+	return entries, nil
+}
+
+/**
+* Database transaction to reverse a posted transaction
+* 1. Lock the original transaction, so concurrent reversals of it are serialized
+* 2. Reject reversals of reversals, of SEPA transfers not rejected by the clearing house and amounts beyond what is left to reverse
+* 3. Lock the balances of the accounts of the original ledger entries
+* 4. Insert the REVERSAL transaction linked to the original one
+* 5. Mirror every ledger entry (CREDIT <-> DEBIT) and update the balances
+*
+* amount is optional, everything left to reverse is reversed when it is nil.
+ */
+func (r *transactionRepository) InsertReversalLedgerTx(
+	ctx context.Context,
+	originalID int,
+	amount *money.Money,
+	reasonCode string,
+) (transaction_entity.TransactionEntity, errors.AppError) {
+	var reversal transaction_entity.TransactionEntity
+	err := r.RunInTx(ctx, func(tx *sql.Tx) errors.AppError {
+		var err errors.AppError
+		reversal, err = r.insertReversalLedger(ctx, tx, originalID, amount, reasonCode)
+		return err
+	})
+	return reversal, err
+}
+
+// InsertReversalLedger reverses the transaction inside the given Tx. See InsertReversalLedgerTx
+func (r *transactionRepository) InsertReversalLedger(
+	ctx context.Context,
+	tx *sql.Tx,
+	originalID int,
+	amount *money.Money,
+	reasonCode string,
+) (transaction_entity.TransactionEntity, errors.AppError) {
+	return r.insertReversalLedger(ctx, tx, originalID, amount, reasonCode)
+}
+
+func (r *transactionRepository) insertReversalLedger(
+	ctx context.Context,
+	tx *sql.Tx,
+	originalID int,
+	amount *money.Money,
+	reasonCode string,
+) (transaction_entity.TransactionEntity, errors.AppError) {
+	original, err := r.FetchTransactionById(ctx, tx, originalID)
+	if err != nil {
+		return transaction_entity.TransactionEntity{}, err
+	}
+	if original.Type == transaction_entity.ReversalType {
+		return transaction_entity.TransactionEntity{}, &errors.ErrConflict{Message: "a reversal cannot be reversed"}
+	}
+	// the amount of a SEPA transfer may be on its way to the other bank: it is refunded when the payment is rejected
+	if original.Type == transaction_entity.SepaTransferType && reasonCode != transaction_entity.ReversalReasonPaymentRejected {
+		return transaction_entity.TransactionEntity{}, &errors.ErrConflict{Message: "a SEPA transfer is refunded when the clearing house rejects it"}
+	}
+
+	var reversed, reversedToAmount, reversedFee money.Money
+	query := `SELECT COALESCE(SUM(amount), 0), COALESCE(SUM(to_amount), 0), COALESCE(SUM(fee_amount), 0) from transactions where reversal_of = $1`
+	if err := tx.QueryRowContext(ctx, query, original.ID).Scan(&reversed, &reversedToAmount, &reversedFee); err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while fetching reversed amount of transaction %d: %s", original.ID, err.Error()))
+		return transaction_entity.TransactionEntity{}, &errors.ErrInternalServer{Reason: err}
+	}
+	remaining := original.Amount.Sub(reversed)
+	if !remaining.IsPositive() {
+		return transaction_entity.TransactionEntity{}, &errors.ErrConflict{Message: fmt.Sprintf("transaction %d is already reversed", original.ID)}
+	}
+	reversalAmount := remaining
+	if amount != nil {
+		reversalAmount = *amount
+	}
+	if !reversalAmount.IsPositive() {
+		return transaction_entity.TransactionEntity{}, &errors.ErrBadRequest{Message: "amount must be positive"}
+	}
+	if reversalAmount.GreaterThan(remaining) {
+		return transaction_entity.TransactionEntity{}, &errors.ErrConflict{
+			Message: fmt.Sprintf("amount %s exceeds the %s left to reverse of transaction %d", reversalAmount, remaining, original.ID),
+		}
+	}
+
+	entries, err := r.FetchLedgerEntriesByTransaction(ctx, tx, original.ID)
+	if err != nil {
+		return transaction_entity.TransactionEntity{}, err
+	}
+	accountIDs := make([]int, 0, len(entries))
+	for _, entry := range entries {
+		accountIDs = append(accountIDs, entry.AccountID)
+	}
+	balances, err := r.LockAccountBalances(ctx, tx, accountIDs...)
+	if err != nil {
+		return transaction_entity.TransactionEntity{}, err
+	}
+
+	// the balance of a closed account must stay at zero. Frozen and dormant accounts can be corrected
+	for accountID, balance := range balances {
+		if balance.Status == accountentity.StatusClosed {
+			return transaction_entity.TransactionEntity{}, &errors.ErrAccountNotActive{Message: fmt.Sprintf("account %d is %s", accountID, balance.Status)}
+		}
+	}
+	source := balances[original.AccountID]
+	if !(currencyentity.CurrencyEntity{Code: source.Currency, MinorUnit: source.MinorUnit}).HasScale(reversalAmount) {
+		return transaction_entity.TransactionEntity{}, &errors.ErrBadRequest{Message: fmt.Sprintf("%s amounts cannot have more than %d decimals", source.Currency, source.MinorUnit)}
+	}
+
+	// entries are mirrored proportionally, so a partial reversal reverses the same share of each one.
+	// The shares are rounded, so the last reversal posts what is left instead and the reversals add up to the original
+	share := new(big.Rat).SetFrac(big.NewInt(reversalAmount.MinorUnits()), big.NewInt(original.Amount.MinorUnits()))
+	last := reversalAmount.Equal(remaining)
+
+	reversal := transaction_entity.TransactionEntity{
+		AccountID:       original.AccountID,
+		ToAccountID:     original.ToAccountID,
+		ToAccountNumber: original.ToAccountNumber,
+		Type:            transaction_entity.ReversalType,
+		Amount:          reversalAmount,
+		ReversalOf:      sql.NullInt32{Int32: int32(original.ID), Valid: true},
+		ReasonCode:      sql.NullString{String: reasonCode, Valid: true},
+		FxRate:          original.FxRate,
+	}
+	if original.ToAmount.Valid {
+		// the destination amount is reversed in the same share, as its ledger entries below
+		toAmount := money.FromRatTo(new(big.Rat).Mul(original.ToAmount.Money.Rat(), share), balances[int(original.ToAccountID.Int32)].MinorUnit, money.RoundHalfEven)
+		if last {
+			toAmount = original.ToAmount.Money.Sub(reversedToAmount)
+		}
+		reversal.ToAmount = money.NullMoney{Money: toAmount, Valid: true}
+	}
+	// the fee entries are mirrored too, so the fee is refunded in the same share
+	reversal.FeeAmount = money.FromRatTo(new(big.Rat).Mul(original.FeeAmount.Rat(), share), source.MinorUnit, money.RoundHalfEven)
+	if last {
+		reversal.FeeAmount = original.FeeAmount.Sub(reversedFee)
+	}
+	if err := r.InsertTransaction(ctx, tx, &reversal); err != nil {
+		return transaction_entity.TransactionEntity{}, err
+	}
+
+	var left map[reversedEntryKey]money.Money
+	var lastEntry map[reversedEntryKey]int
+	if last {
+		left, lastEntry, err = r.entriesLeftToReverse(ctx, tx, original.ID, entries)
+		if err != nil {
+			return transaction_entity.TransactionEntity{}, err
+		}
+	}
+
+	for i, entry := range entries {
+		ledgerType := "CREDIT"
+		if strings.ToUpper(entry.Type) == "CREDIT" {
+			ledgerType = "DEBIT"
+		}
+		balance := balances[entry.AccountID]
+		entryAmount := money.FromRatTo(new(big.Rat).Mul(entry.Amount.Rat(), share), balance.MinorUnit, money.RoundHalfEven)
+		if last {
+			// the last entry of an account and type takes what is left of all of them
+			key := reversedEntryKey{AccountID: entry.AccountID, Type: ledgerType, Kind: entry.Kind}
+			if lastEntry[key] == i {
+				entryAmount = left[key]
+			}
+			left[key] = left[key].Sub(entryAmount)
+		}
+		if entryAmount.IsZero() {
+			continue
+		}
+		// internal accounts, such as the cash in vault, can go below zero. Client accounts down to their overdraft limit
+		if ledgerType == "DEBIT" && !balance.Internal && balance.Spendable().LessThan(entryAmount) {
+			errStr := fmt.Sprintf(
+				"Not enough funds to reverse transaction %d. Account %d has %s monetary units. Tried to reverse %s units",
+				original.ID,
+				entry.AccountID,
+				balance.Spendable(),
+				entryAmount,
+			)
+			r.logger.Error(errStr)
+			return transaction_entity.TransactionEntity{}, &errors.ErrNotEnoughFunds{Message: errStr}
+		}
+		ledgerTransaction := ledgerentity.LedgerTransaction{
+			Transaction: reversal,
+			AccountID:   entry.AccountID,
+			LedgerType:  ledgerType,
+			Amount:      entryAmount,
+			Kind:        entry.Kind,
+		}
+		if err := r.InsertLedgerEntry(ctx, tx, &ledgerTransaction); err != nil {
+			return transaction_entity.TransactionEntity{}, err
+		}
+		if ledgerType == "DEBIT" {
+			balance.Balance = balance.Balance.Sub(entryAmount)
+		} else {
+			balance.Balance = balance.Balance.Add(entryAmount)
+		}
+		balances[entry.AccountID] = balance
+	}
+	return reversal, nil
+}
+
+// reversedEntryKey groups the ledger entries of the reversals by account, (mirrored) type and kind
+type reversedEntryKey struct {
+	AccountID int
+	Type      string
+	Kind      string
+}
+
+// entriesLeftToReverse returns, by account, mirrored type and kind, the amount of the original entries that the previous
+// reversals have not reversed yet, and the index of the last original entry of each group
+func (r *transactionRepository) entriesLeftToReverse(
+	ctx context.Context,
+	tx *sql.Tx,
+	originalID int,
+	entries []ledgerentity.LedgerEntryEntity,
+) (map[reversedEntryKey]money.Money, map[reversedEntryKey]int, errors.AppError) {
+	left := make(map[reversedEntryKey]money.Money)
+	lastEntry := make(map[reversedEntryKey]int)
+	for i, entry := range entries {
+		key := reversedEntryKey{AccountID: entry.AccountID, Type: "CREDIT", Kind: entry.Kind}
+		if strings.ToUpper(entry.Type) == "CREDIT" {
+			key.Type = "DEBIT"
+		}
+		left[key] = left[key].Add(entry.Amount)
+		lastEntry[key] = i
+	}
+
+	query := `
+	 SELECT le.account_id, UPPER(le.type), le.kind, SUM(le.amount)
+	 FROM ledger_entries le JOIN transactions t ON t.id = le.transaction_id
+	 WHERE t.reversal_of = $1
+	 GROUP BY le.account_id, UPPER(le.type), le.kind
+	`
+	rows, err := tx.QueryContext(ctx, query, originalID)
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("Error occurred while fetching reversed entries of transaction %d: %s", originalID, err.Error()))
+		return nil, nil, &errors.ErrInternalServer{Reason: err}
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var key reversedEntryKey
+		var reversed money.Money
+		if err := rows.Scan(&key.AccountID, &key.Type, &key.Kind, &reversed); err != nil {
+			r.logger.Error("Error occurred while scanning reversed entries: " + err.Error())
+			return nil, nil, &errors.ErrInternalServer{Reason: err}
+		}
+		left[key] = left[key].Sub(reversed)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, &errors.ErrInternalServer{Reason: err}
+	}
+	return left, lastEntry, nil
+}
@@ -0,0 +1,63 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	goerrors "errors"
+	"fmt"
+	errors "src/errors"
+	"time"
+
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+// Postgres error codes that are safe to retry: the whole database transaction is rolled back
+const (
+	serializationFailureCode pq.ErrorCode = "40001"
+	deadlockDetectedCode     pq.ErrorCode = "40P01"
+)
+
+const maxTxAttempts = 3
+
+// runInTx executes fn inside a database transaction. It commits when fn succeeds and
+// rolls back otherwise. Serialization failures and deadlocks are retried with a short backoff.
+func runInTx(ctx context.Context, db *sql.DB, logger *zap.Logger, options *sql.TxOptions, fn func(tx *sql.Tx) errors.AppError) errors.AppError {
+	var appErr errors.AppError
+	for attempt := 1; attempt <= maxTxAttempts; attempt++ {
+		appErr = runInTxOnce(ctx, db, options, fn)
+		if appErr == nil || !isRetryableTxError(appErr) {
+			return appErr
+		}
+		logger.Warn(fmt.Sprintf("Retrying database transaction (attempt %d of %d): %s", attempt, maxTxAttempts, appErr.Error()))
+		select {
+		case <-ctx.Done():
+			return &errors.ErrInternalServer{Reason: ctx.Err()}
+		case <-time.After(time.Duration(attempt*attempt) * 10 * time.Millisecond):
+		}
+	}
+	return appErr
+}
+
+func runInTxOnce(ctx context.Context, db *sql.DB, options *sql.TxOptions, fn func(tx *sql.Tx) errors.AppError) errors.AppError {
+	tx, err := db.BeginTx(ctx, options)
+	if err != nil {
+		return &errors.ErrInternalServer{Reason: err}
+	}
+	if appErr := fn(tx); appErr != nil {
+		tx.Rollback()
+		return appErr
+	}
+	if err := tx.Commit(); err != nil {
+		return &errors.ErrInternalServer{Reason: err}
+	}
+	return nil
+}
+
+func isRetryableTxError(err error) bool {
+	var pqErr *pq.Error
+	if !goerrors.As(err, &pqErr) {
+		return false
+	}
+	return pqErr.Code == serializationFailureCode || pqErr.Code == deadlockDetectedCode
+}
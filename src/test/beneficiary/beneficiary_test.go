@@ -0,0 +1,39 @@
+package beneficiary_test
+
+import (
+	"src/api/handlers"
+	beneficiaryentity "src/domain/beneficiary"
+	"src/domain/money"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsCoolingOff(t *testing.T) {
+	now := time.Now()
+	beneficiary := beneficiaryentity.BeneficiaryEntity{CoolingOffUntil: now.Add(time.Hour)}
+	assert.True(t, beneficiary.IsCoolingOff(now))
+	assert.False(t, beneficiary.IsCoolingOff(now.Add(time.Hour)))
+}
+
+func TestBeneficiaryCoolingOffFromEnv(t *testing.T) {
+	t.Setenv("BENEFICIARY_COOLING_OFF", "")
+	t.Setenv("BENEFICIARY_COOLING_OFF_LIMIT", "")
+	coolingOff, limit := handlers.BeneficiaryCoolingOffFromEnv()
+	assert.Equal(t, beneficiaryentity.DefaultCoolingOff, coolingOff)
+	assert.Equal(t, beneficiaryentity.DefaultCoolingOffLimit, limit)
+
+	t.Setenv("BENEFICIARY_COOLING_OFF", "48h")
+	t.Setenv("BENEFICIARY_COOLING_OFF_LIMIT", "1000.00")
+	coolingOff, limit = handlers.BeneficiaryCoolingOffFromEnv()
+	assert.Equal(t, 48*time.Hour, coolingOff)
+	assert.Equal(t, money.MustParse("1000.00"), limit)
+
+	// no cooling-off at all
+	t.Setenv("BENEFICIARY_COOLING_OFF", "0s")
+	t.Setenv("BENEFICIARY_COOLING_OFF_LIMIT", "-5.00")
+	coolingOff, limit = handlers.BeneficiaryCoolingOffFromEnv()
+	assert.Zero(t, coolingOff)
+	assert.Equal(t, beneficiaryentity.DefaultCoolingOffLimit, limit)
+}
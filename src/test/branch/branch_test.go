@@ -0,0 +1,50 @@
+package branch_test
+
+import (
+	services "src/api/service"
+	branchentity "src/domain/branch"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsValidCode(t *testing.T) {
+	assert.True(t, branchentity.IsValidCode("0600"))
+	assert.False(t, branchentity.IsValidCode("600"))
+	assert.False(t, branchentity.IsValidCode("06001"))
+	assert.False(t, branchentity.IsValidCode("06A0"))
+}
+
+func TestBranchUsage(t *testing.T) {
+	usage := branchentity.BranchUsage{BranchEntity: branchentity.BranchEntity{LastSequence: 0}}
+	assert.Equal(t, branchentity.AccountNumberCapacity, usage.Available())
+	assert.Zero(t, usage.Usage())
+
+	usage.LastSequence = 999_999_999
+	assert.Equal(t, int64(9_000_000_000), usage.Available())
+	assert.InDelta(t, 0.1, usage.Usage(), 1e-9)
+
+	usage.LastSequence = branchentity.AccountNumberCapacity
+	assert.Zero(t, usage.Available())
+	assert.Equal(t, 1.0, usage.Usage())
+}
+
+func TestBankCodes(t *testing.T) {
+	t.Setenv("BANK_CODE", "")
+	t.Setenv("BANK_BRANCH_CODE", "")
+	bankCode, branchCode, err := services.BankCodes()
+	assert.Nil(t, err)
+	assert.Equal(t, branchentity.DefaultBankCode, bankCode)
+	assert.Equal(t, branchentity.DefaultBranchCode, branchCode)
+
+	t.Setenv("BANK_CODE", "2100")
+	t.Setenv("BANK_BRANCH_CODE", "0418")
+	bankCode, branchCode, err = services.BankCodes()
+	assert.Nil(t, err)
+	assert.Equal(t, "2100", bankCode)
+	assert.Equal(t, "0418", branchCode)
+
+	t.Setenv("BANK_BRANCH_CODE", "418")
+	_, _, err = services.BankCodes()
+	assert.ErrorIs(t, err, branchentity.ErrInvalidCode)
+}
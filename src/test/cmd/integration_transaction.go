@@ -6,6 +6,7 @@ import (
 	"fmt"
 	_ "github.com/lib/pq"
 	cliententity "src/domain/client"
+	"src/domain/money"
 	"src/test/utils"
 	insert_entities "src/test/utils"
 )
@@ -64,7 +65,7 @@ func main() {
 
 	joeAddsMoneyTransaction := insert_entities.CreateTransaction(accountJoe.ClientID, sql.NullInt32{
 		
-	}, 2000.65, "ingreso")
+	}, money.MustParse("2000.65"), "ingreso")
 	joeAddsMoneyTransaction.AccountID = accountJoe.ID
 	txErr := insert_entities.AccountTransactionTx(ctx, db, &joeAddsMoneyTransaction, accountJoe.ClientID, "CREDITO", "")
 	if txErr != nil {
@@ -77,7 +78,7 @@ func main() {
 			Int32: int32(accountJhon.ID),
 			Valid: true,
 			}, 
-		1000, 
+		money.MustParse("1000"), 
 		"TRANSFERENCIA",
 	)
 	txErr = insert_entities.AccountTransactionTx(ctx, db, &joeTransfersMoneyTransactionToJhon, accountJoe.ClientID, "DEBITO", "CREDITO")
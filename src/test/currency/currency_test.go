@@ -0,0 +1,90 @@
+package currency_test
+
+import (
+	"database/sql"
+	"math/big"
+	services "src/api/service"
+	currencyentity "src/domain/currency"
+	"src/domain/money"
+	transaction_entity "src/domain/transaction"
+	"src/mappers"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRate(t *testing.T) {
+	rate, err := currencyentity.ParseRate(" 1.0850 ")
+	assert.NoError(t, err)
+	assert.Equal(t, "1.0850000000", rate.FloatString(10))
+
+	for _, invalid := range []string{"", "0", "-1.2", "1,08", "1e3", "1.12345678901", "12345678901"} {
+		_, err := currencyentity.ParseRate(invalid)
+		assert.ErrorIs(t, err, currencyentity.ErrInvalidRate, invalid)
+	}
+}
+
+func TestNormalizeCode(t *testing.T) {
+	code, err := currencyentity.NormalizeCode(" usd")
+	assert.NoError(t, err)
+	assert.Equal(t, "USD", code)
+
+	_, err = currencyentity.NormalizeCode("EURO")
+	assert.ErrorIs(t, err, currencyentity.ErrInvalidCode)
+}
+
+func TestConvertRoundsOnceToTheQuoteMinorUnit(t *testing.T) {
+	rate, _ := new(big.Rat).SetString("156.25")
+	assert.Equal(t, "1562.00", currencyentity.Convert(money.MustParse("10.00"), rate, 0).String())
+	assert.Equal(t, "1562.52", currencyentity.Convert(money.MustParse("10.00"), big.NewRat(1562515, 10000), 2).String())
+
+	// 0.4951 rounds to 0 yen, not to 0.50 and then to 0 or 1
+	assert.Equal(t, "0.00", money.FromRatTo(big.NewRat(4951, 10000), 0, money.RoundHalfEven).String())
+	assert.Equal(t, "2.00", money.FromRatTo(big.NewRat(5, 2), 0, money.RoundHalfEven).String())
+}
+
+func TestHasScale(t *testing.T) {
+	yen := currencyentity.CurrencyEntity{Code: "JPY", MinorUnit: 0}
+	assert.True(t, yen.HasScale(money.MustParse("1500")))
+	assert.False(t, yen.HasScale(money.MustParse("1500.50")))
+	assert.True(t, currencyentity.CurrencyEntity{Code: "EUR", MinorUnit: 2}.HasScale(money.MustParse("0.01")))
+}
+
+// The response of a transfer between currencies has the rate applied and the amount credited
+func TestTransactionDtoFx(t *testing.T) {
+	transaction := transaction_entity.TransactionEntity{
+		ID:       1,
+		Type:     "TRANSFER",
+		Amount:   money.MustParse("10.00"),
+		FxRate:   sql.NullString{String: "156.2500000000", Valid: true},
+		ToAmount: money.NullMoney{Money: money.MustParse("1562.00"), Valid: true},
+	}
+	transactionDto, err := mappers.ToTransactionDto(transaction)
+	assert.NoError(t, err)
+	if assert.NotNil(t, transactionDto.FxRate) {
+		assert.Equal(t, "156.2500000000", *transactionDto.FxRate)
+	}
+	if assert.NotNil(t, transactionDto.ToAmount) {
+		assert.Equal(t, "1562.00", transactionDto.ToAmount.String())
+	}
+
+	transactionDto, err = mappers.ToTransactionDto(transaction_entity.TransactionEntity{ID: 2, Type: "TRANSFER", Amount: money.MustParse("10.00")})
+	assert.NoError(t, err)
+	assert.Nil(t, transactionDto.FxRate)
+	assert.Nil(t, transactionDto.ToAmount)
+}
+
+func TestParseExchangeRatesCSV(t *testing.T) {
+	rates, err := parse("base,quote,rate\n# ECB reference rates\nEUR,USD,1.0850\nEUR, GBP, 0.8560\n")
+	assert.NoError(t, err)
+	assert.Len(t, rates, 2)
+	assert.Equal(t, currencyentity.ExchangeRateEntity{Base: "EUR", Quote: "GBP", Rate: "0.8560"}, rates[1])
+
+	_, err = parse("EUR,USD\n")
+	assert.Error(t, err)
+}
+
+func parse(content string) ([]currencyentity.ExchangeRateEntity, error) {
+	return services.ParseExchangeRatesCSV(strings.NewReader(content))
+}
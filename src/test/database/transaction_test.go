@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"fmt"
 	cliententity "src/domain/client"
+	"src/domain/money"
 	"src/test/utils"
 	insert_entities "src/test/utils"
 	"testing"
@@ -107,12 +108,12 @@ func TestDatabaseConnection(t *testing.T) {
 	fmt.Println("Account ID Jhon: " + fmt.Sprintf("%d", accountJhon.ID))
 	fmt.Println("Account ID Joe: " + fmt.Sprintf("%d", accountJoe.ID))
 	toAccountId := sql.NullInt32{}
-	joeAddsMoneyTransaction := insert_entities.CreateTransaction(accountJoe.ID,toAccountId,2000.65, "ingreso")
+	joeAddsMoneyTransaction := insert_entities.CreateTransaction(accountJoe.ID,toAccountId,money.MustParse("2000.65"), "ingreso")
 	txErr := insert_entities.AccountTransactionTx(ctx,db, &joeAddsMoneyTransaction, accountJoe.ClientID, "CREDITO", "")
 	assert.NoError(t, txErr)
 	
 	toAccountIdJhon := sql.NullInt32{Int32: int32(accountJhon.ID),Valid: true}
-	joeTransfersMoneyTransactionToJhon := insert_entities.CreateTransaction(accountJoe.ID, toAccountIdJhon, 1000, "TRANSFERENCIA")
+	joeTransfersMoneyTransactionToJhon := insert_entities.CreateTransaction(accountJoe.ID, toAccountIdJhon, money.MustParse("1000"), "TRANSFERENCIA")
 	txErr = insert_entities.AccountTransactionTx(ctx,db, &joeTransfersMoneyTransactionToJhon, accountJoe.ClientID, "DEBITO", "CREDITO")
 	assert.NoError(t, txErr)
 
@@ -0,0 +1,102 @@
+package document_test
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	documententity "src/domain/document"
+	"src/utils/pdf"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerificationCode(t *testing.T) {
+	pattern := regexp.MustCompile(`^[A-HJ-NP-Z2-9]{4}(-[A-HJ-NP-Z2-9]{4}){3}$`)
+	seen := map[string]bool{}
+	for i := 0; i < 100; i++ {
+		code, err := documententity.NewVerificationCode()
+		assert.NoError(t, err)
+		assert.Regexp(t, pattern, code)
+		assert.False(t, seen[code])
+		seen[code] = true
+	}
+}
+
+func TestNormalizeVerificationCode(t *testing.T) {
+	assert.Equal(t, "7KQM-X2PA-HD9T-RW4C", documententity.NormalizeVerificationCode("7KQM-X2PA-HD9T-RW4C"))
+	assert.Equal(t, "7KQM-X2PA-HD9T-RW4C", documententity.NormalizeVerificationCode("7kqmx2pahd9trw4c"))
+	assert.Equal(t, "7KQM-X2PA-HD9T-RW4C", documententity.NormalizeVerificationCode(" 7kqm x2pa-hd9t rw4c"))
+}
+
+func TestIbanFormatting(t *testing.T) {
+	assert.Equal(t, "ES91 0182 0600 1112 3456 7890", documententity.FormatIban("ES9101820600111234567890"))
+	assert.Equal(t, "ES91****************7890", documententity.MaskIban("ES9101820600111234567890"))
+}
+
+// The cross reference table must point at every object, or readers have to repair the file
+func assertValidPdf(t *testing.T, content []byte) {
+	assert.True(t, bytes.HasPrefix(content, []byte("%PDF-1.4\n")))
+	assert.True(t, bytes.HasSuffix(content, []byte("%%EOF\n")))
+
+	startxref := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(content)
+	assert.NotNil(t, startxref)
+	xref, _ := strconv.Atoi(string(startxref[1]))
+	assert.True(t, bytes.HasPrefix(content[xref:], []byte("xref\n")))
+
+	lines := strings.Split(string(content[xref:]), "\n")
+	var count int
+	fmt.Sscanf(lines[1], "0 %d", &count)
+	for object := 1; object < count; object++ {
+		offset, _ := strconv.Atoi(lines[2+object][:10])
+		assert.True(t, bytes.HasPrefix(content[offset:], []byte(fmt.Sprintf("%d 0 obj\n", object))), "object %d", object)
+	}
+
+	// the stream lengths match their content
+	for _, match := range regexp.MustCompile(`(?s)<< /Length (\d+) >>\nstream\n(.*?)endstream`).FindAllSubmatch(content, -1) {
+		length, _ := strconv.Atoi(string(match[1]))
+		assert.Equal(t, length, len(match[2]))
+	}
+}
+
+func TestPdf(t *testing.T) {
+	document := pdf.New("Account statement")
+	document.Footer = "verification code 7KQM-X2PA-HD9T-RW4C"
+	document.Text(16, true, "Account statement")
+	document.Text(10, false, "Holder: José Núñez (Peña) 10 €")
+	content := document.Bytes()
+
+	assertValidPdf(t, content)
+	assert.Contains(t, string(content), "/Count 1")
+	assert.Contains(t, string(content), "(Holder: Jos\xe9 N\xfa\xf1ez \\(Pe\xf1a\\) 10 \x80) Tj")
+	assert.Contains(t, string(content), "(verification code 7KQM-X2PA-HD9T-RW4C) Tj")
+	assert.Contains(t, string(content), "(Page 1 of 1) Tj")
+}
+
+func TestPdfPageBreak(t *testing.T) {
+	document := pdf.New("Account statement")
+	headers := 0
+	document.OnNewPage = func(d *pdf.Document) {
+		headers++
+		d.Row(8, pdf.Cell{Text: "Date", Bold: true})
+	}
+	for i := 0; i < 200; i++ {
+		document.Row(8, pdf.Cell{Text: strconv.Itoa(i)}, pdf.Cell{X: 400, Width: 95, Text: "1234.56", AlignRight: true})
+	}
+	content := document.Bytes()
+
+	assertValidPdf(t, content)
+	pages := regexp.MustCompile(`/Count (\d+)`).FindSubmatch(content)
+	assert.Equal(t, strconv.Itoa(headers+1), string(pages[1]))
+	assert.Greater(t, headers, 0)
+	assert.Contains(t, string(content), fmt.Sprintf("(Page %d of %d) Tj", headers+1, headers+1))
+}
+
+func TestPdfTextFits(t *testing.T) {
+	assert.InDelta(t, 27.8, pdf.TextWidth("00000", 10), 0.001)
+	document := pdf.New("Certificate")
+	document.Row(10, pdf.Cell{Width: 50, Text: "A very long counterparty name that does not fit"})
+	assert.Contains(t, string(document.Bytes()), "...) Tj")
+}
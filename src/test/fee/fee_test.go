@@ -0,0 +1,100 @@
+package fee_test
+
+import (
+	feeentity "src/domain/fee"
+	"src/domain/money"
+	transaction_entity "src/domain/transaction"
+	"src/mappers"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePercentage(t *testing.T) {
+	percentage, err := feeentity.ParsePercentage(" 0.0025 ")
+	assert.NoError(t, err)
+	assert.Equal(t, "0.002500", percentage.FloatString(6))
+
+	for _, valid := range []string{"0", "1", "1.000000"} {
+		_, err := feeentity.ParsePercentage(valid)
+		assert.NoError(t, err, valid)
+	}
+	for _, invalid := range []string{"", "-0.01", "0,01", "0.0000001", "1.5", "2"} {
+		_, err := feeentity.ParsePercentage(invalid)
+		assert.ErrorIs(t, err, feeentity.ErrInvalidPercentage, invalid)
+	}
+}
+
+func TestComputeFixedAndPercentage(t *testing.T) {
+	schedule := feeentity.FeeScheduleEntity{ID: 7, FixedAmount: money.MustParse("0.50"), Percentage: "0.01"}
+	fees, err := schedule.Compute(money.MustParse("120.00"), 2)
+	assert.NoError(t, err)
+	assert.Len(t, fees, 2)
+	assert.Equal(t, feeentity.KindFixed, fees[0].Kind)
+	assert.Equal(t, "0.50", fees[0].Amount.String())
+	assert.Equal(t, feeentity.KindPercentage, fees[1].Kind)
+	assert.Equal(t, "1.20", fees[1].Amount.String())
+	assert.Equal(t, int32(7), fees[1].FeeScheduleID.Int32)
+	assert.Equal(t, "1.70", feeentity.Total(fees).String())
+}
+
+func TestComputeRoundsHalfToEven(t *testing.T) {
+	schedule := feeentity.FeeScheduleEntity{Percentage: "0.001"}
+	// 0.125 and 0.135
+	fees, err := schedule.Compute(money.MustParse("125.00"), 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "0.12", feeentity.Total(fees).String())
+	fees, err = schedule.Compute(money.MustParse("135.00"), 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "0.14", feeentity.Total(fees).String())
+}
+
+func TestComputeBoundsThePercentage(t *testing.T) {
+	schedule := feeentity.FeeScheduleEntity{
+		Percentage:          "0.002",
+		MinPercentageAmount: money.NullMoney{Money: money.MustParse("1.00"), Valid: true},
+		MaxPercentageAmount: money.NullMoney{Money: money.MustParse("5.00"), Valid: true},
+	}
+	fees, _ := schedule.Compute(money.MustParse("100.00"), 2)
+	assert.Equal(t, "1.00", feeentity.Total(fees).String())
+	fees, _ = schedule.Compute(money.MustParse("1000.00"), 2)
+	assert.Equal(t, "2.00", feeentity.Total(fees).String())
+	fees, _ = schedule.Compute(money.MustParse("10000.00"), 2)
+	assert.Equal(t, "5.00", feeentity.Total(fees).String())
+}
+
+func TestComputeLeavesOutZeroComponents(t *testing.T) {
+	schedule := feeentity.FeeScheduleEntity{FixedAmount: money.MustParse("0.30"), Percentage: "0"}
+	fees, err := schedule.Compute(money.MustParse("50.00"), 2)
+	assert.NoError(t, err)
+	assert.Len(t, fees, 1)
+
+	fees, err = feeentity.FeeScheduleEntity{Percentage: "0"}.Compute(money.MustParse("50.00"), 2)
+	assert.NoError(t, err)
+	assert.Empty(t, fees)
+	assert.True(t, feeentity.Total(fees).IsZero())
+
+	_, err = feeentity.FeeScheduleEntity{Percentage: "abc"}.Compute(money.MustParse("50.00"), 2)
+	assert.ErrorIs(t, err, feeentity.ErrInvalidPercentage)
+}
+
+// The response of a performed transaction has its fee and the components of it
+func TestTransactionDtoFees(t *testing.T) {
+	transaction := transaction_entity.TransactionEntity{
+		ID:        1,
+		Type:      "TRANSFER",
+		Amount:    money.MustParse("100.00"),
+		FeeAmount: money.MustParse("0.75"),
+		Fees: []feeentity.FeeEntity{
+			{Kind: feeentity.KindFixed, Amount: money.MustParse("0.50")},
+			{Kind: feeentity.KindPercentage, Amount: money.MustParse("0.25")},
+		},
+	}
+	transactionDto, err := mappers.ToTransactionDto(transaction)
+	assert.NoError(t, err)
+	assert.Equal(t, "0.75", transactionDto.FeeAmount.String())
+	if assert.Len(t, transactionDto.Fees, 2) {
+		assert.Equal(t, feeentity.KindFixed, transactionDto.Fees[0].Kind)
+		assert.Equal(t, "0.25", transactionDto.Fees[1].Amount.String())
+	}
+}
@@ -0,0 +1,132 @@
+package iban_test
+
+import (
+	"context"
+	services "src/api/service"
+	bankentity "src/domain/bank"
+	app_errors "src/errors"
+	app_logger "src/logger"
+	"src/utils"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// bankDirectory keeps the banks in memory
+type bankDirectory struct {
+	banks []bankentity.BankEntity
+}
+
+func (d *bankDirectory) FetchBank(ctx context.Context, countryCode string, bankCodes ...string) (bankentity.BankEntity, app_errors.AppError) {
+	for _, code := range bankCodes {
+		for _, bank := range d.banks {
+			if bank.CountryCode == countryCode && bank.BankCode == code {
+				return bank, nil
+			}
+		}
+	}
+	return bankentity.BankEntity{}, &app_errors.ErrNotFound{Entity: "Bank"}
+}
+
+func (d *bankDirectory) CountBanks(ctx context.Context) (int, app_errors.AppError) {
+	return len(d.banks), nil
+}
+
+func (d *bankDirectory) ReplaceBankDirectory(ctx context.Context, banks []bankentity.BankEntity) app_errors.AppError {
+	d.banks = banks
+	return nil
+}
+
+const directory = `country,bank_code,bic,name
+# Spain
+ES, 2100, CAIXESBBXXX, CaixaBank
+gb,NWBK601613,nwbkgb2lxxx,"National Westminster Bank, Bishopsgate"
+GB,NWBK,NWBKGB2L,National Westminster Bank
+`
+
+func TestParseBankDirectoryCSV(t *testing.T) {
+	banks, err := services.ParseBankDirectoryCSV(strings.NewReader(directory))
+	assert.NoError(t, err)
+	assert.Len(t, banks, 3)
+	assert.Equal(t, "2100", banks[0].BankCode)
+	assert.Equal(t, "National Westminster Bank, Bishopsgate", banks[1].Name)
+
+	_, err = services.ParseBankDirectoryCSV(strings.NewReader("ES,2100,CAIXESBBXXX\n"))
+	assert.Error(t, err)
+}
+
+func TestNormalizeBank(t *testing.T) {
+	bank := bankentity.BankEntity{CountryCode: "gb", BankCode: "NWBK 6016 13", Bic: "nwbkgb2lxxx", Name: " NatWest "}
+	assert.NoError(t, bank.Normalize())
+	assert.Equal(t, bankentity.BankEntity{CountryCode: "GB", BankCode: "NWBK601613", Bic: "NWBKGB2LXXX", Name: "NatWest"}, bank)
+
+	for _, invalid := range []bankentity.BankEntity{
+		{CountryCode: "ESP", BankCode: "2100", Bic: "CAIXESBBXXX", Name: "CaixaBank"},
+		{CountryCode: "ES", BankCode: "21-00", Bic: "CAIXESBBXXX", Name: "CaixaBank"},
+		{CountryCode: "ES", BankCode: "2100", Bic: "CAIXESBBXX", Name: "CaixaBank"},
+		{CountryCode: "ES", BankCode: "2100", Bic: "CAIXESBBXXX", Name: " "},
+	} {
+		assert.Error(t, invalid.Normalize(), invalid)
+	}
+}
+
+func TestLookupIban(t *testing.T) {
+	ctx := context.Background()
+	service := services.NewBankDirectoryService(&bankDirectory{}, app_logger.GetLogger())
+	banks, err := services.ParseBankDirectoryCSV(strings.NewReader(directory))
+	assert.NoError(t, err)
+	count, appErr := service.ReplaceBankDirectory(ctx, banks)
+	assert.Nil(t, appErr)
+	assert.Equal(t, 3, count)
+
+	result, appErr := service.LookupIban(ctx, "es91 2100 0418 4502 0005 1332")
+	assert.Nil(t, appErr)
+	assert.True(t, result.Valid)
+	assert.Nil(t, result.Reason)
+	assert.Equal(t, "ES9121000418450200051332", result.ElectronicFormat)
+	assert.Equal(t, "ES91 2100 0418 4502 0005 1332", result.PrintFormat)
+	assert.Equal(t, "2100", result.BankCode)
+	assert.Equal(t, "0418", result.BranchCode)
+	assert.Equal(t, "0200051332", result.AccountNumber)
+	assert.Equal(t, "CAIXESBBXXX", *result.Bic)
+	assert.Equal(t, "CaixaBank", *result.BankName)
+
+	// the bank and branch is preferred to the bank alone
+	result, appErr = service.LookupIban(ctx, "GB29NWBK60161331926819")
+	assert.Nil(t, appErr)
+	assert.Equal(t, "NWBKGB2LXXX", *result.Bic)
+
+	// a valid IBAN of a bank not listed
+	result, appErr = service.LookupIban(ctx, "DE89370400440532013000")
+	assert.Nil(t, appErr)
+	assert.True(t, result.Valid)
+	assert.Nil(t, result.Bic)
+
+	for iban, reason := range map[string]string{
+		"ES912100041845020005133":  utils.IbanReasonLength,
+		"ES9021000418450200051332": utils.IbanReasonCheckDigits,
+		"US64SVBKUS6S3300958879":   utils.IbanReasonCountry,
+		"GB29NWBK6016133192681A":   utils.IbanReasonFormat,
+	} {
+		result, appErr = service.LookupIban(ctx, iban)
+		assert.Nil(t, appErr)
+		assert.False(t, result.Valid, iban)
+		if assert.NotNil(t, result.Reason, iban) {
+			assert.Equal(t, reason, *result.Reason, iban)
+		}
+		assert.NotNil(t, result.Message)
+		assert.Empty(t, result.BankCode)
+	}
+
+	_, appErr = service.ReplaceBankDirectory(ctx, nil)
+	assert.IsType(t, &app_errors.ErrBadRequest{}, appErr)
+	_, appErr = service.ReplaceBankDirectory(ctx, []bankentity.BankEntity{{CountryCode: "ES", BankCode: "2100", Bic: "X", Name: "CaixaBank"}})
+	assert.IsType(t, &app_errors.ErrBadRequest{}, appErr)
+}
+
+func TestPrintIban(t *testing.T) {
+	assert.Equal(t, "NO93 8601 1117 947", utils.PrintIban("no9386011117947"))
+	assert.Equal(t, "BE68 5390 0754 7034", utils.PrintIban("BE68 5390 0754 7034"))
+	assert.Equal(t, "", utils.PrintIban(""))
+}
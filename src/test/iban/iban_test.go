@@ -0,0 +1,158 @@
+package iban_test
+
+import (
+	"src/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Examples of the SWIFT IBAN registry
+var validIbans = []string{
+	"AT611904300234573201",
+	"BE68539007547034",
+	"CH9300762011623852957",
+	"CZ6508000000192000145399",
+	"DE89370400440532013000",
+	"ES9121000418450200051332",
+	"FR1420041010050500013M02606",
+	"GB29NWBK60161331926819",
+	"HU42117730161111101800000000",
+	"IT60X0542811101000000123456",
+	"MC5811222000010123456789030",
+	"NL91ABNA0417164300",
+	"NO9386011117947",
+	"PL61109010140000071219812874",
+	"PT50000201231234567890154",
+	"SI56263300012039086",
+	"SK3112000000198742637541",
+	"SM86U0322509800000000270100",
+}
+
+func TestVerifyRegistryExamples(t *testing.T) {
+	handler := utils.IbanHandler{}
+	for _, iban := range validIbans {
+		_, err := handler.Parse(iban)
+		assert.NoError(t, err, iban)
+		assert.True(t, handler.Verify(iban), iban)
+	}
+}
+
+func TestParseComponents(t *testing.T) {
+	handler := utils.IbanHandler{}
+
+	parsed, err := handler.Parse("es91 2100 0418 4502 0005 1332")
+	assert.NoError(t, err)
+	assert.Equal(t, utils.ParsedIban{
+		CountryCode:         "ES",
+		CheckDigits:         "91",
+		Bban:                "21000418450200051332",
+		BankCode:            "2100",
+		BranchCode:          "0418",
+		NationalCheckDigits: "45",
+		AccountNumber:       "0200051332",
+	}, parsed)
+	assert.Equal(t, "ES9121000418450200051332", parsed.String())
+
+	parsed, err = handler.Parse("FR1420041010050500013M02606")
+	assert.NoError(t, err)
+	assert.Equal(t, "20041", parsed.BankCode)
+	assert.Equal(t, "01005", parsed.BranchCode)
+	assert.Equal(t, "0500013M026", parsed.AccountNumber)
+	assert.Equal(t, "06", parsed.NationalCheckDigits)
+
+	parsed, err = handler.Parse("IT60X0542811101000000123456")
+	assert.NoError(t, err)
+	assert.Equal(t, "X", parsed.NationalCheckDigits)
+	assert.Equal(t, "05428", parsed.BankCode)
+	assert.Equal(t, "11101", parsed.BranchCode)
+	assert.Equal(t, "000000123456", parsed.AccountNumber)
+
+	parsed, err = handler.Parse("DE89370400440532013000")
+	assert.NoError(t, err)
+	assert.Equal(t, "37040044", parsed.BankCode)
+	assert.Empty(t, parsed.BranchCode)
+	assert.Empty(t, parsed.NationalCheckDigits)
+	assert.Equal(t, "0532013000", parsed.AccountNumber)
+}
+
+func TestParseInvalid(t *testing.T) {
+	handler := utils.IbanHandler{}
+	for iban, reason := range map[string]string{
+		"":                            "too short",
+		"US64SVBKUS6S3300958879":      "country US is not supported",
+		"ES912100041845020005133":     "ES IBANs have 24 characters, not 23",
+		"ES91210004184502000513A2":    "structure",
+		"ES9021000418450200051332":    "check digits are not valid",
+		"GB29NWBK6016133192681A":      "structure",
+		"NL91ABNA0417164301":          "check digits are not valid",
+		"XX00":                        "not supported",
+		"ESAB21000418450200051332":    "check digits must be numeric",
+		"FR7630006000011234567890188": "check digits are not valid",
+	} {
+		_, err := handler.Parse(iban)
+		if assert.Error(t, err, iban) {
+			assert.Contains(t, err.Error(), reason, iban)
+			assert.IsType(t, &utils.IbanError{}, err)
+		}
+		assert.False(t, handler.Verify(iban), iban)
+	}
+}
+
+// IBANs with valid MOD 97-10 check digits but wrong national check digits
+func TestParseNationalCheckDigits(t *testing.T) {
+	handler := utils.IbanHandler{}
+	for country, bban := range map[string]string{
+		"ES": "21000418460200051332",
+		"BE": "539007547035",
+		"FR": "20041010050500013M02607",
+		"IT": "Y0542811101000000123456",
+		"NO": "86011117948",
+		"PT": "000201231234567890155",
+		"SI": "263300012039087",
+		"CZ": "08000000192000145398",
+		"HU": "117730161111101800000001",
+	} {
+		iban := withCheckDigits(country, bban)
+		_, err := handler.Parse(iban)
+		if assert.Error(t, err, iban) {
+			assert.Equal(t, "national check digits are not valid", err.Error(), iban)
+		}
+	}
+}
+
+// withCheckDigits builds the IBAN of a BBAN with valid MOD 97-10 check digits
+func withCheckDigits(country string, bban string) string {
+	for digits := 2; digits <= 98; digits++ {
+		iban := country + string(rune('0'+digits/10)) + string(rune('0'+digits%10)) + bban
+		if mod97Valid(iban) {
+			return iban
+		}
+	}
+	return ""
+}
+
+func mod97Valid(iban string) bool {
+	rearranged := iban[4:] + iban[:4]
+	remainder := 0
+	for _, char := range rearranged {
+		if char >= 'A' && char <= 'Z' {
+			remainder = (remainder*100 + int(char-'A') + 10) % 97
+		} else {
+			remainder = (remainder*10 + int(char-'0')) % 97
+		}
+	}
+	return remainder == 1
+}
+
+func TestComputeIban(t *testing.T) {
+	handler := utils.IbanHandler{}
+	bban := utils.Bban{BankCode: "0182", BranchCode: "0600", AccountNumber: "1234567890"}
+	bban.DomesticCheckDigits = handler.DomesticCheckDigits(bban.BankCode, bban.BranchCode, bban.AccountNumber)
+	iban, err := handler.ComputeIban(bban, "ES")
+	assert.NoError(t, err)
+	assert.True(t, handler.Verify(iban))
+
+	_, err = handler.ComputeIban(bban, "FR")
+	assert.Error(t, err)
+}
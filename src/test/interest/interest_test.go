@@ -0,0 +1,49 @@
+package interest_test
+
+import (
+	"math/big"
+	interestentity "src/domain/interest"
+	"src/domain/money"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDaysInYear(t *testing.T) {
+	days, err := interestentity.DaysInYear(interestentity.DayCountACT365)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(365), days)
+	days, err = interestentity.DaysInYear(interestentity.DayCountACT360)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(360), days)
+	_, err = interestentity.DaysInYear("30/360")
+	assert.ErrorIs(t, err, interestentity.ErrInvalidDayCount)
+}
+
+func TestDailyInterest(t *testing.T) {
+	rate := big.NewRat(365, 10000) // 3.65%
+	interest, err := interestentity.DailyInterest(money.MustParse("1000.00"), rate, interestentity.DayCountACT365)
+	assert.NoError(t, err)
+	assert.Equal(t, "0.1000000000", interest.FloatString(interestentity.AccrualScale))
+
+	// the same rate earns more every day with ACT/360
+	interest, err = interestentity.DailyInterest(money.MustParse("1000.00"), rate, interestentity.DayCountACT360)
+	assert.NoError(t, err)
+	assert.Equal(t, "0.1013888889", interest.FloatString(interestentity.AccrualScale))
+
+	for _, balance := range []string{"0.00", "-1000.00"} {
+		interest, err = interestentity.DailyInterest(money.MustParse(balance), rate, interestentity.DayCountACT365)
+		assert.NoError(t, err)
+		assert.Zero(t, interest.Sign(), balance)
+	}
+
+	_, err = interestentity.DailyInterest(money.MustParse("1000.00"), rate, "")
+	assert.ErrorIs(t, err, interestentity.ErrInvalidDayCount)
+}
+
+func TestMonthStart(t *testing.T) {
+	day := time.Date(2026, 2, 17, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC), interestentity.MonthStart(day))
+	assert.Equal(t, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), interestentity.MonthStart(day).AddDate(0, -1, 0))
+}
@@ -0,0 +1,105 @@
+package money_test
+
+import (
+	"encoding/json"
+	"math/big"
+	"src/domain/money"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	cases := map[string]string{
+		"0":        "0.00",
+		"12":       "12.00",
+		"12.5":     "12.50",
+		"-12.05":   "-12.05",
+		".75":      "0.75",
+		"000100.1": "100.10",
+		"12.500":   "12.50",
+	}
+	for input, expected := range cases {
+		amount, err := money.Parse(input)
+		assert.NoError(t, err, input)
+		assert.Equal(t, expected, amount.String(), input)
+	}
+
+	for _, input := range []string{"", "-", ".", "1,5", "1e3", "abc", "12.345"} {
+		_, err := money.Parse(input)
+		assert.Error(t, err, input)
+	}
+	_, err := money.Parse("12.345")
+	assert.ErrorIs(t, err, money.ErrScale)
+}
+
+func TestParseRounded(t *testing.T) {
+	cases := []struct {
+		input    string
+		mode     money.RoundingMode
+		expected string
+	}{
+		{"2.345", money.RoundHalfEven, "2.34"},
+		{"2.355", money.RoundHalfEven, "2.36"},
+		{"2.3451", money.RoundHalfEven, "2.35"},
+		{"2.345", money.RoundHalfUp, "2.35"},
+		{"-2.345", money.RoundHalfUp, "-2.35"},
+		{"2.349", money.RoundDown, "2.34"},
+		{"2.341", money.RoundUp, "2.35"},
+	}
+	for _, c := range cases {
+		amount, err := money.ParseRounded(c.input, c.mode)
+		assert.NoError(t, err)
+		assert.Equal(t, c.expected, amount.String(), c.input)
+	}
+}
+
+// 0.1 + 0.2 drifts with float64, it must not with money
+func TestArithmeticIsExact(t *testing.T) {
+	total := money.Zero
+	for i := 0; i < 1000; i++ {
+		total = total.Add(money.MustParse("0.10"))
+	}
+	assert.Equal(t, "100.00", total.String())
+	assert.True(t, money.MustParse("0.30").Equal(money.MustParse("0.10").Add(money.MustParse("0.20"))))
+	assert.Equal(t, "-0.05", money.MustParse("0.10").Sub(money.MustParse("0.15")).String())
+}
+
+func TestMulRat(t *testing.T) {
+	// 1.5% of 10.10 = 0.1515
+	fee := money.MustParse("10.10").MulRat(big.NewRat(15, 1000), money.RoundHalfEven)
+	assert.Equal(t, "0.15", fee.String())
+	// 1/3 of 100.00
+	third := money.MustParse("100").MulRat(big.NewRat(1, 3), money.RoundDown)
+	assert.Equal(t, "33.33", third.String())
+	assert.Equal(t, "1234.00", money.MustParse("1234.50").RoundTo(0, money.RoundHalfEven).String())
+}
+
+func TestJSON(t *testing.T) {
+	type body struct {
+		Amount money.Money `json:"amount"`
+	}
+	encoded, err := json.Marshal(body{Amount: money.MustParse("1000.2")})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"amount":"1000.20"}`, string(encoded))
+
+	var decoded body
+	assert.NoError(t, json.Unmarshal([]byte(`{"amount":"12.34"}`), &decoded))
+	assert.Equal(t, "12.34", decoded.Amount.String())
+	assert.NoError(t, json.Unmarshal([]byte(`{"amount":12.3}`), &decoded))
+	assert.Equal(t, "12.30", decoded.Amount.String())
+	assert.Error(t, json.Unmarshal([]byte(`{"amount":12.345}`), &decoded))
+}
+
+func TestScanAndValue(t *testing.T) {
+	var amount money.Money
+	assert.NoError(t, amount.Scan([]byte("2000.65")))
+	assert.Equal(t, int64(200065), amount.MinorUnits())
+	value, err := amount.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "2000.65", value)
+
+	var nullable money.NullMoney
+	assert.NoError(t, nullable.Scan(nil))
+	assert.False(t, nullable.Valid)
+}
@@ -0,0 +1,62 @@
+package overdraft_test
+
+import (
+	"database/sql"
+	"math/big"
+	accountentity "src/domain/account"
+	"src/domain/money"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseInterestRate(t *testing.T) {
+	rate, err := accountentity.ParseInterestRate(" 0.095 ")
+	assert.NoError(t, err)
+	assert.Equal(t, "0.095000", rate.FloatString(6))
+
+	_, err = accountentity.ParseInterestRate("0")
+	assert.NoError(t, err)
+
+	for _, invalid := range []string{"", "-0.1", "9,5", "0.1234567", "1000"} {
+		_, err := accountentity.ParseInterestRate(invalid)
+		assert.ErrorIs(t, err, accountentity.ErrInvalidInterestRate, invalid)
+	}
+}
+
+func TestDailyDebitInterest(t *testing.T) {
+	rate := big.NewRat(73, 1000) // 7.3%, 0.02% a day
+	assert.Equal(t, "0.20", accountentity.DailyDebitInterest(money.MustParse("-1000.00"), rate, 2).String())
+	// 0.025 rounded half to even
+	assert.Equal(t, "0.02", accountentity.DailyDebitInterest(money.MustParse("-125.00"), rate, 2).String())
+	assert.Equal(t, "0.00", accountentity.DailyDebitInterest(money.MustParse("-1000.00"), rate, 0).String())
+	assert.True(t, accountentity.DailyDebitInterest(money.MustParse("1000.00"), rate, 2).IsZero())
+}
+
+func TestOverdraftValidity(t *testing.T) {
+	overdraft := accountentity.OverdraftEntity{
+		ValidFrom: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+		ValidTo:   sql.NullTime{Time: time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC), Valid: true},
+	}
+	assert.False(t, overdraft.IsValidOn(time.Date(2026, 2, 28, 0, 0, 0, 0, time.UTC)))
+	assert.True(t, overdraft.IsValidOn(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)))
+	assert.True(t, overdraft.IsValidOn(time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC)))
+	assert.False(t, overdraft.IsValidOn(time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)))
+
+	overdraft.ValidTo = sql.NullTime{}
+	assert.True(t, overdraft.IsValidOn(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestOverdraftHeadroom(t *testing.T) {
+	balance := accountentity.AccountBalance{Balance: money.MustParse("50.00"), OverdraftLimit: money.MustParse("200.00")}
+	assert.Equal(t, "250.00", balance.Spendable().String())
+	assert.Equal(t, "200.00", balance.OverdraftHeadroom().String())
+
+	balance.Balance = money.MustParse("-150.00")
+	assert.Equal(t, "50.00", balance.OverdraftHeadroom().String())
+
+	// below the limit, i.e. after the limit was lowered
+	balance.Balance = money.MustParse("-300.00")
+	assert.True(t, balance.OverdraftHeadroom().IsZero())
+}
@@ -0,0 +1,28 @@
+package pagination_test
+
+import (
+	"src/domain/pagination"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	createdAt := time.Date(2025, 3, 31, 10, 15, 30, 123456000, time.UTC)
+	for _, before := range []bool{false, true} {
+		cursor := pagination.Cursor{CreatedAt: createdAt, ID: 42, Before: before}
+		decoded, err := pagination.DecodeCursor(cursor.Encode())
+		assert.NoError(t, err)
+		assert.True(t, createdAt.Equal(decoded.CreatedAt))
+		assert.Equal(t, 42, decoded.ID)
+		assert.Equal(t, before, decoded.Before)
+	}
+}
+
+func TestDecodeCursorRejectsForgedTokens(t *testing.T) {
+	for _, token := range []string{"", "not base64!", "eHx5fHo", "bnwyMDI1LTAzLTMxVDEwOjE1OjMwWnxhYmM"} {
+		_, err := pagination.DecodeCursor(token)
+		assert.ErrorIs(t, err, pagination.ErrInvalidCursor, token)
+	}
+}
@@ -0,0 +1,49 @@
+package payee_test
+
+import (
+	payeeentity "src/domain/payee"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var juan = payeeentity.Holder{Name: "Juan", Surname1: "Pérez", Surname2: "García"}
+
+func TestMatchName(t *testing.T) {
+	cases := []struct {
+		typed  string
+		holder payeeentity.Holder
+		result string
+	}{
+		// both surnames, the first one alone, any order, case or accents
+		{"Juan Pérez García", juan, payeeentity.ResultMatch},
+		{"juan perez", juan, payeeentity.ResultMatch},
+		{"PÉREZ GARCÍA, JUAN", juan, payeeentity.ResultMatch},
+		{"María José de la Torre", payeeentity.Holder{Name: "María José", Surname1: "de la Torre"}, payeeentity.ResultMatch},
+		{"María Torre-Núñez", payeeentity.Holder{Name: "María", Surname1: "Torre", Surname2: "Núñez"}, payeeentity.ResultMatch},
+		// typos, initials, the second surname alone, a second given name alone, one extra word
+		{"Juan Peres Garcia", juan, payeeentity.ResultCloseMatch},
+		{"J. Pérez García", juan, payeeentity.ResultCloseMatch},
+		{"Juan García", juan, payeeentity.ResultCloseMatch},
+		{"José Martín", payeeentity.Holder{Name: "María José", Surname1: "Martín", Surname2: "Sanz"}, payeeentity.ResultCloseMatch},
+		{"Juan Pérez García López", juan, payeeentity.ResultCloseMatch},
+		// another person, surnames without a name, a name alone
+		{"Pedro Pérez García", payeeentity.Holder{Name: "Pablo", Surname1: "Sánchez", Surname2: "Ruiz"}, payeeentity.ResultNoMatch},
+		{"Pérez García", juan, payeeentity.ResultNoMatch},
+		{"Juan", juan, payeeentity.ResultNoMatch},
+		{"Ana Pérez García", juan, payeeentity.ResultNoMatch},
+		{"", juan, payeeentity.ResultNoMatch},
+		// short words need to be exact
+		{"Ana Gil", payeeentity.Holder{Name: "Ana", Surname1: "Gil"}, payeeentity.ResultMatch},
+		{"Ana Gal", payeeentity.Holder{Name: "Ana", Surname1: "Gil"}, payeeentity.ResultNoMatch},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.result, payeeentity.MatchName(c.typed, c.holder), c.typed)
+	}
+}
+
+func TestMaskName(t *testing.T) {
+	assert.Equal(t, "J*** P**** G*****", payeeentity.MaskName(juan))
+	assert.Equal(t, "M**** J*** d* l* T****", payeeentity.MaskName(payeeentity.Holder{Name: "María José", Surname1: "de la Torre"}))
+	assert.Equal(t, "A** T****-N****", payeeentity.MaskName(payeeentity.Holder{Name: "Ana", Surname1: "Torre-Núñez"}))
+}
@@ -0,0 +1,236 @@
+package payment_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	services "src/api/service"
+	"src/domain/money"
+	paymententity "src/domain/payment"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const creditTransfer = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:pacs.008.001.02">
+  <FIToFICstmrCdtTrf>
+    <GrpHdr><MsgId>IN-1</MsgId><NbOfTxs>2</NbOfTxs></GrpHdr>
+    <CdtTrfTxInf>
+      <PmtId><EndToEndId>e2e-in-1</EndToEndId><TxId>TX-1</TxId></PmtId>
+      <IntrBkSttlmAmt Ccy="EUR">120.50</IntrBkSttlmAmt>
+      <Dbtr><Nm>Luis Pérez</Nm></Dbtr>
+      <DbtrAcct><Id><IBAN>ES79 2100 0813 6101 2345 6789</IBAN></Id></DbtrAcct>
+      <Cdtr><Nm>Ana García López</Nm></Cdtr>
+      <CdtrAcct><Id><IBAN>ES9101820600111234567890</IBAN></Id></CdtrAcct>
+      <RmtInf><Ustrd>Factura</Ustrd><Ustrd>2026/17</Ustrd></RmtInf>
+    </CdtTrfTxInf>
+    <CdtTrfTxInf>
+      <PmtId><TxId>TX-2</TxId></PmtId>
+      <IntrBkSttlmAmt Ccy="EUR">3</IntrBkSttlmAmt>
+      <CdtrAcct><Id><IBAN>es1201820600140000000001</IBAN></Id></CdtrAcct>
+    </CdtTrfTxInf>
+  </FIToFICstmrCdtTrf>
+</Document>`
+
+func TestParsePacs008(t *testing.T) {
+	file, err := services.ParsePacs008([]byte(creditTransfer))
+	assert.NoError(t, err)
+	assert.Equal(t, "IN-1", file.MessageID)
+	assert.Equal(t, paymententity.MessagePacs008, file.MessageType)
+	assert.Len(t, file.Payments, 2)
+
+	first := file.Payments[0]
+	assert.Equal(t, 1, first.Sequence)
+	assert.Equal(t, "e2e-in-1", first.EndToEndID)
+	assert.Equal(t, "TX-1", first.Reference.String)
+	assert.Equal(t, "ES7921000813610123456789", first.DebtorIban.String)
+	assert.Equal(t, "Luis Pérez", first.DebtorName.String)
+	assert.Equal(t, "ES9101820600111234567890", first.CreditorIban)
+	assert.Equal(t, "120.50", first.Amount.String())
+	assert.Equal(t, "EUR", first.Currency)
+	assert.Equal(t, "Factura 2026/17", first.RemittanceInformation.String)
+
+	second := file.Payments[1]
+	assert.Equal(t, 2, second.Sequence)
+	assert.Equal(t, "NOTPROVIDED", second.EndToEndID)
+	assert.Equal(t, "ES1201820600140000000001", second.CreditorIban)
+	assert.Equal(t, "3.00", second.Amount.String())
+	assert.False(t, second.DebtorIban.Valid)
+	assert.False(t, second.RemittanceInformation.Valid)
+}
+
+func TestParsePacs008Invalid(t *testing.T) {
+	for _, transaction := range []string{
+		`<IntrBkSttlmAmt Ccy="USD">10.00</IntrBkSttlmAmt><CdtrAcct><Id><IBAN>ES9101820600111234567890</IBAN></Id></CdtrAcct>`,
+		`<IntrBkSttlmAmt Ccy="EUR">-10.00</IntrBkSttlmAmt><CdtrAcct><Id><IBAN>ES9101820600111234567890</IBAN></Id></CdtrAcct>`,
+		`<IntrBkSttlmAmt Ccy="EUR">10.001</IntrBkSttlmAmt><CdtrAcct><Id><IBAN>ES9101820600111234567890</IBAN></Id></CdtrAcct>`,
+		`<IntrBkSttlmAmt Ccy="EUR">10.00</IntrBkSttlmAmt>`,
+	} {
+		_, err := services.ParsePacs008([]byte(`<Document><FIToFICstmrCdtTrf><GrpHdr><MsgId>IN-2</MsgId></GrpHdr>
+			<CdtTrfTxInf>` + transaction + `</CdtTrfTxInf></FIToFICstmrCdtTrf></Document>`))
+		assert.Error(t, err, transaction)
+	}
+	_, err := services.ParsePacs008([]byte(`<Document><FIToFICstmrCdtTrf/></Document>`))
+	assert.Error(t, err)
+}
+
+const creditNotification = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.054.001.02">
+  <BkToCstmrDbtCdtNtfctn>
+    <GrpHdr><MsgId>NTF-1</MsgId></GrpHdr>
+    <Ntfctn>
+      <Acct><Id><IBAN>ES9101820600111234567890</IBAN></Id></Acct>
+      <Ntry>
+        <Amt Ccy="EUR">40.00</Amt><CdtDbtInd>CRDT</CdtDbtInd><AcctSvcrRef>REF-1</AcctSvcrRef>
+        <NtryDtls>
+          <TxDtls>
+            <Refs><EndToEndId>e2e-n-1</EndToEndId></Refs>
+            <AmtDtls><TxAmt><Amt Ccy="EUR">25.00</Amt></TxAmt></AmtDtls>
+            <RltdPties><Dbtr><Nm>Marta Ruiz</Nm></Dbtr></RltdPties>
+          </TxDtls>
+          <TxDtls>
+            <Refs><EndToEndId>e2e-n-2</EndToEndId><TxId>TX-9</TxId></Refs>
+            <Amt Ccy="EUR">15.00</Amt>
+            <RltdPties><CdtrAcct><Id><IBAN>ES1201820600140000000001</IBAN></Id></CdtrAcct></RltdPties>
+          </TxDtls>
+        </NtryDtls>
+      </Ntry>
+      <Ntry><Amt Ccy="EUR">99.00</Amt><CdtDbtInd>DBIT</CdtDbtInd></Ntry>
+      <Ntry><Amt Ccy="EUR">7.00</Amt><CdtDbtInd>CRDT</CdtDbtInd><AcctSvcrRef>REF-3</AcctSvcrRef></Ntry>
+    </Ntfctn>
+  </BkToCstmrDbtCdtNtfctn>
+</Document>`
+
+func TestParseCamt054(t *testing.T) {
+	file, err := services.ParseCamt054([]byte(creditNotification))
+	assert.NoError(t, err)
+	assert.Equal(t, "NTF-1", file.MessageID)
+	assert.Equal(t, paymententity.MessageCamt054, file.MessageType)
+	// the debit entry is left out
+	assert.Len(t, file.Payments, 3)
+
+	assert.Equal(t, "e2e-n-1", file.Payments[0].EndToEndID)
+	assert.Equal(t, "25.00", file.Payments[0].Amount.String())
+	assert.Equal(t, "ES9101820600111234567890", file.Payments[0].CreditorIban) // account of the notification
+	assert.Equal(t, "Marta Ruiz", file.Payments[0].DebtorName.String)
+
+	assert.Equal(t, "15.00", file.Payments[1].Amount.String())
+	assert.Equal(t, "ES1201820600140000000001", file.Payments[1].CreditorIban)
+	assert.Equal(t, "TX-9", file.Payments[1].Reference.String)
+
+	// an entry without details is a single credit
+	assert.Equal(t, 3, file.Payments[2].Sequence)
+	assert.Equal(t, "7.00", file.Payments[2].Amount.String())
+	assert.Equal(t, "REF-3", file.Payments[2].Reference.String)
+	assert.Equal(t, "NOTPROVIDED", file.Payments[2].EndToEndID)
+}
+
+type pacs004 struct {
+	GroupHeader struct {
+		MessageID   string `xml:"MsgId"`
+		NumberOfTxs int    `xml:"NbOfTxs"`
+		Total       string `xml:"TtlRtrdIntrBkSttlmAmt"`
+	} `xml:"PmtRtr>GrpHdr"`
+	Transactions []struct {
+		OriginalMessageID   string `xml:"OrgnlGrpInf>OrgnlMsgId"`
+		OriginalMessageType string `xml:"OrgnlGrpInf>OrgnlMsgNmId"`
+		OriginalEndToEndID  string `xml:"OrgnlEndToEndId"`
+		OriginalTxID        string `xml:"OrgnlTxId"`
+		Amount              string `xml:"RtrdIntrBkSttlmAmt"`
+		Reason              string `xml:"RtrRsnInf>Rsn>Cd"`
+		DebtorIban          string `xml:"OrgnlTxRef>DbtrAcct>Id>IBAN"`
+		CreditorIban        string `xml:"OrgnlTxRef>CdtrAcct>Id>IBAN"`
+	} `xml:"PmtRtr>TxInf"`
+}
+
+func TestWritePacs004(t *testing.T) {
+	returns := paymententity.ReturnBatch{
+		MessageID: "RET-1",
+		CreatedAt: time.Date(2026, 5, 6, 9, 0, 0, 0, time.UTC),
+		Payments: []paymententity.InboundPaymentEntity{
+			{
+				ID:           7,
+				MessageID:    "IN-1",
+				MessageType:  paymententity.MessagePacs008,
+				EndToEndID:   "e2e-in-1",
+				Reference:    sql.NullString{String: "TX-1", Valid: true},
+				DebtorIban:   sql.NullString{String: "ES7921000813610123456789", Valid: true},
+				CreditorIban: "ES0000000000000000000000",
+				Amount:       money.MustParse("120.50"),
+				Currency:     "EUR",
+				ReturnReason: sql.NullString{String: "AC01", Valid: true},
+			},
+			{
+				ID:           8,
+				MessageID:    "NTF-1",
+				MessageType:  paymententity.MessageCamt054,
+				EndToEndID:   "NOTPROVIDED",
+				CreditorIban: "ES9101820600111234567890",
+				Amount:       money.MustParse("7.00"),
+				Currency:     "EUR",
+				ReturnReason: sql.NullString{String: "AC04", Valid: true},
+			},
+		},
+	}
+	var buffer bytes.Buffer
+	assert.NoError(t, services.WritePacs004(&buffer, returns, "LEDGESMMXXX"))
+	assert.Contains(t, buffer.String(), `xmlns="urn:iso:std:iso:20022:tech:xsd:pacs.004.001.02"`)
+
+	var document pacs004
+	assert.NoError(t, xml.Unmarshal(buffer.Bytes(), &document))
+	assert.Equal(t, "RET-1", document.GroupHeader.MessageID)
+	assert.Equal(t, 2, document.GroupHeader.NumberOfTxs)
+	assert.Equal(t, "127.50", document.GroupHeader.Total)
+	assert.Len(t, document.Transactions, 2)
+	assert.Equal(t, "IN-1", document.Transactions[0].OriginalMessageID)
+	assert.Equal(t, "pacs.008", document.Transactions[0].OriginalMessageType)
+	assert.Equal(t, "e2e-in-1", document.Transactions[0].OriginalEndToEndID)
+	assert.Equal(t, "TX-1", document.Transactions[0].OriginalTxID)
+	assert.Equal(t, "120.50", document.Transactions[0].Amount)
+	assert.Equal(t, "AC01", document.Transactions[0].Reason)
+	assert.Equal(t, "ES7921000813610123456789", document.Transactions[0].DebtorIban)
+	assert.Equal(t, "AC04", document.Transactions[1].Reason)
+	assert.Empty(t, document.Transactions[1].DebtorIban)
+	assert.Equal(t, "ES9101820600111234567890", document.Transactions[1].CreditorIban)
+}
+
+// Status reports and inbound credits share the inbox: each kind of file is read by its own fetch only
+func TestFileClearingGatewayInbound(t *testing.T) {
+	dir := t.TempDir()
+	outbox, inbox := filepath.Join(dir, "out"), filepath.Join(dir, "in")
+	gateway, err := services.NewFileClearingGateway(outbox, inbox, "Banking Ledger", "LEDGESMMXXX")
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	assert.NoError(t, os.WriteFile(filepath.Join(inbox, "a.xml"), []byte(statusReport), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(inbox, "b.xml"), []byte(creditNotification), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(inbox, "c.xml"), []byte(creditTransfer), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(inbox, "d.xml"), []byte(`<Document><FIToFICstmrCdtTrf/></Document>`), 0o644))
+
+	files, err := gateway.FetchInboundFiles(ctx)
+	assert.Error(t, err)
+	assert.Len(t, files, 2)
+	assert.Equal(t, "b.xml", files[0].Name)
+	assert.Equal(t, "NTF-1", files[0].MessageID)
+	assert.Equal(t, "c.xml", files[1].Name)
+	assert.FileExists(t, filepath.Join(inbox, "failed", "d.xml"))
+	assert.FileExists(t, filepath.Join(inbox, "a.xml"))
+
+	reports, err := gateway.FetchStatusReports(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, reports, 1)
+
+	assert.NoError(t, gateway.AcknowledgeInboundFile(ctx, files[0]))
+	assert.FileExists(t, filepath.Join(inbox, "processed", "b.xml"))
+
+	returns := paymententity.ReturnBatch{MessageID: "RET-1", CreatedAt: time.Now(), Payments: []paymententity.InboundPaymentEntity{
+		{MessageID: "IN-1", EndToEndID: "e2e", CreditorIban: "ES9101820600111234567890", Amount: money.MustParse("1.00"), Currency: "EUR"},
+	}}
+	assert.NoError(t, gateway.SubmitReturns(ctx, returns))
+	assert.FileExists(t, filepath.Join(outbox, "pacs004-RET-1.xml"))
+}
@@ -0,0 +1,209 @@
+package payment_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	services "src/api/service"
+	"src/domain/money"
+	paymententity "src/domain/payment"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var batch = paymententity.PaymentBatch{
+	MessageID: "0f4a2c9e8b7d4e0a9c1b2d3e4f5a6b7c",
+	CreatedAt: time.Date(2026, 5, 4, 10, 15, 0, 0, time.UTC),
+	Payments: []paymententity.OutboundPaymentEntity{
+		{
+			EndToEndID:            "e2e1",
+			DebtorIban:            "ES9101820600111234567890",
+			DebtorName:            "Ana García López",
+			CreditorIban:          "ES7921000813610123456789",
+			CreditorName:          "Luis Pérez & Hijos",
+			Amount:                money.MustParse("50.00"),
+			Currency:              "EUR",
+			RemittanceInformation: sql.NullString{String: "Factura 2026/17", Valid: true},
+		},
+		{
+			EndToEndID:   "e2e2",
+			DebtorIban:   "ES1201820600140000000001",
+			DebtorName:   "Juan Martín",
+			CreditorIban: "ES7921000813610123456789",
+			CreditorName: "Luis Pérez & Hijos",
+			Amount:       money.MustParse("10.25"),
+			Currency:     "EUR",
+		},
+		{
+			EndToEndID:   "e2e3",
+			DebtorIban:   "ES9101820600111234567890",
+			DebtorName:   "Ana García López",
+			CreditorIban: "ES6000491500051234567892",
+			CreditorName: "Marta Ruiz",
+			Amount:       money.MustParse("0.75"),
+			Currency:     "EUR",
+		},
+	},
+}
+
+type pain001 struct {
+	GroupHeader struct {
+		MessageID       string `xml:"MsgId"`
+		NumberOfTxs     int    `xml:"NbOfTxs"`
+		ControlSum      string `xml:"CtrlSum"`
+		InitiatingParty string `xml:"InitgPty>Nm"`
+	} `xml:"CstmrCdtTrfInitn>GrpHdr"`
+	PaymentInformation []struct {
+		ID           string `xml:"PmtInfId"`
+		NumberOfTxs  int    `xml:"NbOfTxs"`
+		ControlSum   string `xml:"CtrlSum"`
+		ServiceLevel string `xml:"PmtTpInf>SvcLvl>Cd"`
+		DebtorName   string `xml:"Dbtr>Nm"`
+		DebtorIban   string `xml:"DbtrAcct>Id>IBAN"`
+		DebtorBic    string `xml:"DbtrAgt>FinInstnId>BIC"`
+		ChargeBearer string `xml:"ChrgBr"`
+		Transactions []struct {
+			EndToEndID   string `xml:"PmtId>EndToEndId"`
+			Amount       string `xml:"Amt>InstdAmt"`
+			CreditorName string `xml:"Cdtr>Nm"`
+			CreditorIban string `xml:"CdtrAcct>Id>IBAN"`
+			Remittance   string `xml:"RmtInf>Ustrd"`
+		} `xml:"CdtTrfTxInf"`
+	} `xml:"CstmrCdtTrfInitn>PmtInf"`
+}
+
+func TestWritePain001(t *testing.T) {
+	var buffer bytes.Buffer
+	assert.NoError(t, services.WritePain001(&buffer, batch, "Banking Ledger", "LEDGESMMXXX"))
+	assert.Contains(t, buffer.String(), `xmlns="urn:iso:std:iso:20022:tech:xsd:pain.001.001.03"`)
+
+	var document pain001
+	assert.NoError(t, xml.Unmarshal(buffer.Bytes(), &document))
+	assert.Equal(t, batch.MessageID, document.GroupHeader.MessageID)
+	assert.Equal(t, 3, document.GroupHeader.NumberOfTxs)
+	assert.Equal(t, "61.00", document.GroupHeader.ControlSum)
+	assert.Equal(t, "Banking Ledger", document.GroupHeader.InitiatingParty)
+
+	// one block per debtor account, in the order of the batch
+	assert.Len(t, document.PaymentInformation, 2)
+	first := document.PaymentInformation[0]
+	assert.Equal(t, batch.MessageID+"-1", first.ID)
+	assert.Equal(t, 2, first.NumberOfTxs)
+	assert.Equal(t, "50.75", first.ControlSum)
+	assert.Equal(t, "SEPA", first.ServiceLevel)
+	assert.Equal(t, "SLEV", first.ChargeBearer)
+	assert.Equal(t, "Ana García López", first.DebtorName)
+	assert.Equal(t, "ES9101820600111234567890", first.DebtorIban)
+	assert.Equal(t, "LEDGESMMXXX", first.DebtorBic)
+	assert.Equal(t, "e2e1", first.Transactions[0].EndToEndID)
+	assert.Equal(t, "50.00", first.Transactions[0].Amount)
+	assert.Equal(t, "Luis Pérez & Hijos", first.Transactions[0].CreditorName)
+	assert.Equal(t, "Factura 2026/17", first.Transactions[0].Remittance)
+	assert.Equal(t, "e2e3", first.Transactions[1].EndToEndID)
+	assert.Empty(t, first.Transactions[1].Remittance)
+	assert.Equal(t, "ES1201820600140000000001", document.PaymentInformation[1].DebtorIban)
+}
+
+func TestWritePain001WithoutBic(t *testing.T) {
+	var buffer bytes.Buffer
+	assert.NoError(t, services.WritePain001(&buffer, batch, "Banking Ledger", ""))
+	assert.Contains(t, buffer.String(), "<Id>NOTPROVIDED</Id>")
+	assert.NotContains(t, buffer.String(), "<BIC>")
+}
+
+const statusReport = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:pain.002.001.03">
+  <CstmrPmtStsRpt>
+    <GrpHdr><MsgId>STS-1</MsgId><CreDtTm>2026-05-05T08:00:00Z</CreDtTm></GrpHdr>
+    <OrgnlGrpInfAndSts>
+      <OrgnlMsgId>0f4a2c9e8b7d4e0a9c1b2d3e4f5a6b7c</OrgnlMsgId>
+      <OrgnlMsgNmId>pain.001.001.03</OrgnlMsgNmId>
+      <GrpSts>PART</GrpSts>
+    </OrgnlGrpInfAndSts>
+    <OrgnlPmtInfAndSts>
+      <OrgnlPmtInfId>0f4a2c9e8b7d4e0a9c1b2d3e4f5a6b7c-1</OrgnlPmtInfId>
+      <TxInfAndSts>
+        <OrgnlEndToEndId>e2e1</OrgnlEndToEndId>
+        <TxSts>ACSC</TxSts>
+      </TxInfAndSts>
+      <TxInfAndSts>
+        <OrgnlEndToEndId>e2e3</OrgnlEndToEndId>
+        <TxSts>RJCT</TxSts>
+        <StsRsnInf><Rsn><Cd>AC01</Cd></Rsn></StsRsnInf>
+      </TxInfAndSts>
+    </OrgnlPmtInfAndSts>
+    <OrgnlPmtInfAndSts>
+      <OrgnlPmtInfId>0f4a2c9e8b7d4e0a9c1b2d3e4f5a6b7c-2</OrgnlPmtInfId>
+      <PmtInfSts>RJCT</PmtInfSts>
+      <StsRsnInf><Rsn><Cd>AM04</Cd></Rsn></StsRsnInf>
+      <TxInfAndSts>
+        <OrgnlEndToEndId>e2e2</OrgnlEndToEndId>
+      </TxInfAndSts>
+    </OrgnlPmtInfAndSts>
+  </CstmrPmtStsRpt>
+</Document>`
+
+func TestParsePain002(t *testing.T) {
+	report, err := services.ParsePain002([]byte(statusReport))
+	assert.NoError(t, err)
+	assert.Equal(t, "STS-1", report.MessageID)
+	assert.Equal(t, batch.MessageID, report.OriginalMessageID)
+	assert.Equal(t, "PART", report.GroupStatus)
+	assert.Equal(t, []paymententity.PaymentStatus{
+		{EndToEndID: "e2e1", Status: "ACSC"},
+		{EndToEndID: "e2e3", Status: "RJCT", Reason: "AC01"},
+		{EndToEndID: "e2e2", Status: "RJCT", Reason: "AM04"}, // status and reason of its block
+	}, report.Payments)
+	assert.True(t, report.Payments[0].IsSettled())
+	assert.True(t, report.Payments[1].IsRejected())
+}
+
+func TestParsePain002GroupRejection(t *testing.T) {
+	report, err := services.ParsePain002([]byte(`<Document><CstmrPmtStsRpt><GrpHdr><MsgId>STS-2</MsgId></GrpHdr>
+		<OrgnlGrpInfAndSts><OrgnlMsgId>B1</OrgnlMsgId><GrpSts>RJCT</GrpSts>
+		<StsRsnInf><Rsn><Cd>FF01</Cd></Rsn></StsRsnInf></OrgnlGrpInfAndSts></CstmrPmtStsRpt></Document>`))
+	assert.NoError(t, err)
+	assert.Equal(t, "RJCT", report.GroupStatus)
+	assert.Equal(t, "FF01", report.GroupReason)
+	assert.Empty(t, report.Payments)
+}
+
+func TestParsePain002Invalid(t *testing.T) {
+	_, err := services.ParsePain002([]byte(`<Document><CstmrCdtTrfInitn/></Document>`))
+	assert.Error(t, err)
+	_, err = services.ParsePain002([]byte(`not xml`))
+	assert.Error(t, err)
+}
+
+func TestFileClearingGateway(t *testing.T) {
+	dir := t.TempDir()
+	outbox, inbox := filepath.Join(dir, "out"), filepath.Join(dir, "in")
+	gateway, err := services.NewFileClearingGateway(outbox, inbox, "Banking Ledger", "")
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	assert.NoError(t, gateway.SubmitBatch(ctx, batch))
+	files, _ := os.ReadDir(outbox)
+	assert.Len(t, files, 1)
+	assert.Equal(t, "pain001-"+batch.MessageID+".xml", files[0].Name())
+	assert.Error(t, gateway.SubmitBatch(ctx, paymententity.PaymentBatch{MessageID: "empty"}))
+
+	assert.NoError(t, os.WriteFile(filepath.Join(inbox, "a.xml"), []byte(statusReport), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(inbox, "b.xml"), []byte("garbage"), 0o644))
+	reports, err := gateway.FetchStatusReports(ctx)
+	assert.Error(t, err)
+	assert.Len(t, reports, 1)
+	assert.Equal(t, "a.xml", reports[0].Name)
+	assert.FileExists(t, filepath.Join(inbox, "failed", "b.xml"))
+
+	assert.NoError(t, gateway.AcknowledgeStatusReport(ctx, reports[0]))
+	assert.FileExists(t, filepath.Join(inbox, "processed", "a.xml"))
+	reports, err = gateway.FetchStatusReports(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, reports)
+}
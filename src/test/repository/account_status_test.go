@@ -0,0 +1,134 @@
+package repository_Test
+
+import (
+	"context"
+	"database/sql"
+	accountentity "src/domain/account"
+	"src/domain/money"
+	errors "src/errors"
+	app_logger "src/logger"
+	"src/repositories"
+	"src/test/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// A frozen account can neither send nor receive, a dormant one can only receive
+func TestAccountStatusEnforcement(t *testing.T) {
+	ctx := context.Background()
+	db := startLedgerDatabase(t, ctx)
+	logger := app_logger.GetLogger()
+
+	clientRepository := repositories.NewClientRepository(db, logger)
+	accountRepository := repositories.NewAccountRepository(db, logger)
+	transactionRepository := repositories.NewTransactionRepository(db, logger)
+	accountStatusRepository := repositories.NewAccountStatusRepository(db, logger, transactionRepository, repositories.NewInterestRepository(db, logger, transactionRepository))
+
+	client := utils.CreateClientTest(1, "Jhon", "jhon@test.es")
+	assert.NoError(t, clientRepository.InsertClient(ctx, &client))
+	account := utils.CreateAccount(client.ID)
+	other := utils.CreateAccount(client.ID)
+	assert.NoError(t, accountRepository.InsertAccount(ctx, &account))
+	assert.NoError(t, accountRepository.InsertAccount(ctx, &other))
+	assert.Equal(t, accountentity.StatusActive, account.Status)
+
+	deposit := utils.CreateTransaction(account.ID, sql.NullInt32{}, money.MustParse("100.00"), "ADD")
+	assert.Nil(t, transactionRepository.InsertTransactionLedgerTx(ctx, &deposit))
+
+	frozen, err := accountStatusRepository.ChangeStatusTx(ctx, account.ID, "", accountentity.StatusFrozen, "suspected fraud")
+	assert.Nil(t, err)
+	assert.Equal(t, accountentity.StatusFrozen, frozen.Status)
+	assert.Equal(t, "suspected fraud", frozen.StatusReason.String)
+
+	// the frozen account as source and as destination
+	outgoing := utils.CreateTransaction(account.ID, sql.NullInt32{Int32: int32(other.ID), Valid: true}, money.MustParse("10.00"), "TRANSFER")
+	err = transactionRepository.InsertTransactionLedgerTx(ctx, &outgoing)
+	assert.IsType(t, &errors.ErrAccountNotActive{}, err)
+	incoming := utils.CreateTransaction(other.ID, sql.NullInt32{Int32: int32(account.ID), Valid: true}, money.MustParse("10.00"), "TRANSFER")
+	err = transactionRepository.InsertTransactionLedgerTx(ctx, &incoming)
+	assert.IsType(t, &errors.ErrAccountNotActive{}, err)
+
+	// only a frozen account can be unfrozen
+	_, err = accountStatusRepository.ChangeStatusTx(ctx, other.ID, accountentity.StatusFrozen, accountentity.StatusActive, "unfreeze")
+	assert.IsType(t, &errors.ErrConflict{}, err)
+	_, err = accountStatusRepository.ChangeStatusTx(ctx, account.ID, accountentity.StatusFrozen, accountentity.StatusActive, "cleared")
+	assert.Nil(t, err)
+
+	_, err = accountStatusRepository.ChangeStatusTx(ctx, account.ID, "", accountentity.StatusDormant, "unused")
+	assert.Nil(t, err)
+	outgoing = utils.CreateTransaction(account.ID, sql.NullInt32{Int32: int32(other.ID), Valid: true}, money.MustParse("10.00"), "TRANSFER")
+	err = transactionRepository.InsertTransactionLedgerTx(ctx, &outgoing)
+	assert.IsType(t, &errors.ErrAccountNotActive{}, err)
+	deposit = utils.CreateTransaction(account.ID, sql.NullInt32{}, money.MustParse("5.00"), "ADD")
+	assert.Nil(t, transactionRepository.InsertTransactionLedgerTx(ctx, &deposit))
+
+	balance, err := transactionRepository.FetchAccountBalance(ctx, nil, account.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, "105.00", balance.Balance.String())
+
+	changes, err := accountStatusRepository.FetchStatusChanges(ctx, account.ID)
+	assert.Nil(t, err)
+	assert.Len(t, changes, 3)
+}
+
+// Closing needs a settlement IBAN unless the balance is zero. The settlement moves the whole balance
+func TestCloseAccount(t *testing.T) {
+	ctx := context.Background()
+	db := startLedgerDatabase(t, ctx)
+	logger := app_logger.GetLogger()
+
+	clientRepository := repositories.NewClientRepository(db, logger)
+	accountRepository := repositories.NewAccountRepository(db, logger)
+	transactionRepository := repositories.NewTransactionRepository(db, logger)
+	accountStatusRepository := repositories.NewAccountStatusRepository(db, logger, transactionRepository, repositories.NewInterestRepository(db, logger, transactionRepository))
+
+	client := utils.CreateClientTest(1, "Jhon", "jhon@test.es")
+	assert.NoError(t, clientRepository.InsertClient(ctx, &client))
+	account := utils.CreateAccount(client.ID)
+	settlementAccount := utils.CreateAccount(client.ID)
+	empty := utils.CreateAccount(client.ID)
+	assert.NoError(t, accountRepository.InsertAccount(ctx, &account))
+	assert.NoError(t, accountRepository.InsertAccount(ctx, &settlementAccount))
+	assert.NoError(t, accountRepository.InsertAccount(ctx, &empty))
+
+	deposit := utils.CreateTransaction(account.ID, sql.NullInt32{}, money.MustParse("42.50"), "ADD")
+	assert.Nil(t, transactionRepository.InsertTransactionLedgerTx(ctx, &deposit))
+
+	_, _, err := accountStatusRepository.CloseTx(ctx, account.ID, "customer request", nil)
+	assert.IsType(t, &errors.ErrConflict{}, err)
+
+	closed, settlement, err := accountStatusRepository.CloseTx(ctx, empty.ID, "customer request", nil)
+	assert.Nil(t, err)
+	assert.Nil(t, settlement)
+	assert.Equal(t, accountentity.StatusClosed, closed.Status)
+
+	// it can be closed while frozen
+	_, err = accountStatusRepository.ChangeStatusTx(ctx, account.ID, "", accountentity.StatusFrozen, "customer deceased")
+	assert.Nil(t, err)
+	closed, settlement, err = accountStatusRepository.CloseTx(ctx, account.ID, "customer request", &settlementAccount.AccountNumber)
+	assert.Nil(t, err)
+	assert.Equal(t, accountentity.StatusClosed, closed.Status)
+	if assert.NotNil(t, settlement) {
+		assert.Equal(t, "42.50", settlement.Amount.String())
+	}
+
+	balance, err := transactionRepository.FetchAccountBalance(ctx, nil, account.ID)
+	assert.Nil(t, err)
+	assert.True(t, balance.Balance.IsZero())
+	balance, err = transactionRepository.FetchAccountBalance(ctx, nil, settlementAccount.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, "42.50", balance.Balance.String())
+
+	// closed is final
+	_, err = accountStatusRepository.ChangeStatusTx(ctx, account.ID, "", accountentity.StatusActive, "reopen")
+	assert.IsType(t, &errors.ErrConflict{}, err)
+	deposit = utils.CreateTransaction(account.ID, sql.NullInt32{}, money.MustParse("1.00"), "ADD")
+	assert.IsType(t, &errors.ErrAccountNotActive{}, transactionRepository.InsertTransactionLedgerTx(ctx, &deposit))
+
+	changes, err := accountStatusRepository.FetchStatusChanges(ctx, account.ID)
+	assert.Nil(t, err)
+	if assert.Len(t, changes, 2) {
+		assert.Equal(t, int32(settlement.ID), changes[1].SettlementTransactionID.Int32)
+	}
+}
@@ -0,0 +1,125 @@
+package repository_Test
+
+import (
+	"context"
+	"database/sql"
+	feeentity "src/domain/fee"
+	ledgerentity "src/domain/ledger"
+	"src/domain/money"
+	app_logger "src/logger"
+	"src/repositories"
+	"src/test/utils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// The recipient of a transfer sees it in its activity, with the sender as counterparty and the running balance
+func TestAccountActivityIncludesIncomingTransfers(t *testing.T) {
+	ctx := context.Background()
+	db := startLedgerDatabase(t, ctx)
+	logger := app_logger.GetLogger()
+
+	clientRepository := repositories.NewClientRepository(db, logger)
+	accountRepository := repositories.NewAccountRepository(db, logger)
+	transactionRepository := repositories.NewTransactionRepository(db, logger)
+
+	jhon := utils.CreateClientTest(1, "Jhon", "jhon@test.es")
+	landlord := utils.CreateClientTest(2, "Landlord", "landlord@test.es")
+	assert.NoError(t, clientRepository.InsertClient(ctx, &jhon))
+	assert.NoError(t, clientRepository.InsertClient(ctx, &landlord))
+	account := utils.CreateAccount(jhon.ID)
+	toAccount := utils.CreateAccount(landlord.ID)
+	assert.NoError(t, accountRepository.InsertAccount(ctx, &account))
+	assert.NoError(t, accountRepository.InsertAccount(ctx, &toAccount))
+
+	deposit := utils.CreateTransaction(toAccount.ID, sql.NullInt32{}, money.MustParse("10.00"), "ADD")
+	assert.NoError(t, transactionRepository.InsertTransactionLedgerTx(ctx, &deposit))
+	deposit = utils.CreateTransaction(account.ID, sql.NullInt32{}, money.MustParse("100.00"), "ADD")
+	assert.NoError(t, transactionRepository.InsertTransactionLedgerTx(ctx, &deposit))
+	transfer := utils.CreateTransaction(account.ID, sql.NullInt32{Int32: int32(toAccount.ID), Valid: true}, money.MustParse("30.00"), "TRANSFER")
+	assert.NoError(t, transactionRepository.InsertTransactionLedgerTx(ctx, &transfer))
+
+	activity, err := transactionRepository.GetAccountActivity(ctx, toAccount.ID, 1, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, activity.LastPage)
+	assert.Len(t, activity.Items, 1)
+	incoming := activity.Items[0]
+	assert.Equal(t, transfer.ID, incoming.TransactionID)
+	assert.Equal(t, ledgerentity.DirectionIn, incoming.Direction)
+	assert.Equal(t, "30.00", incoming.Amount.String())
+	assert.Equal(t, "40.00", incoming.BalanceAfter.String())
+	assert.Equal(t, account.AccountNumber, incoming.CounterpartyIban.String)
+	assert.Contains(t, incoming.CounterpartyName.String, jhon.Name)
+
+	activity, err = transactionRepository.GetAccountActivity(ctx, account.ID, 1, 10)
+	assert.Nil(t, err)
+	assert.Len(t, activity.Items, 2)
+	assert.Equal(t, ledgerentity.DirectionOut, activity.Items[0].Direction)
+	assert.Equal(t, "70.00", activity.Items[0].BalanceAfter.String())
+	// deposits are posted against the internal vault account, which is not shown as counterparty
+	assert.Equal(t, "ADD", activity.Items[1].TransactionType)
+	assert.False(t, activity.Items[1].CounterpartyIban.Valid)
+}
+
+// The fee of a transfer is a separate entry against the fee income account, not a second payment to the recipient
+func TestAccountActivityTransferFee(t *testing.T) {
+	ctx := context.Background()
+	db := startLedgerDatabase(t, ctx)
+	logger := app_logger.GetLogger()
+
+	clientRepository := repositories.NewClientRepository(db, logger)
+	accountRepository := repositories.NewAccountRepository(db, logger)
+	transactionRepository := repositories.NewTransactionRepository(db, logger)
+	feeRepository := repositories.NewFeeRepository(db, logger)
+
+	jhon := utils.CreateClientTest(1, "Jhon", "jhon@test.es")
+	landlord := utils.CreateClientTest(2, "Landlord", "landlord@test.es")
+	assert.NoError(t, clientRepository.InsertClient(ctx, &jhon))
+	assert.NoError(t, clientRepository.InsertClient(ctx, &landlord))
+	account := utils.CreateAccount(jhon.ID)
+	toAccount := utils.CreateAccount(landlord.ID)
+	assert.NoError(t, accountRepository.InsertAccount(ctx, &account))
+	assert.NoError(t, accountRepository.InsertAccount(ctx, &toAccount))
+	schedule := feeentity.FeeScheduleEntity{TransactionType: "TRANSFER", Currency: "EUR", FixedAmount: money.MustParse("0.50"), Percentage: "0"}
+	assert.Nil(t, feeRepository.UpsertFeeSchedule(ctx, &schedule))
+
+	deposit := utils.CreateTransaction(account.ID, sql.NullInt32{}, money.MustParse("100.00"), "ADD")
+	assert.NoError(t, transactionRepository.InsertTransactionLedgerTx(ctx, &deposit))
+	transfer := utils.CreateTransaction(account.ID, sql.NullInt32{Int32: int32(toAccount.ID), Valid: true}, money.MustParse("30.00"), "TRANSFER")
+	assert.NoError(t, transactionRepository.InsertTransactionLedgerTx(ctx, &transfer))
+
+	activity, err := transactionRepository.GetAccountActivity(ctx, account.ID, 1, 10)
+	assert.Nil(t, err)
+	assert.Len(t, activity.Items, 3)
+	fee, payment := activity.Items[0], activity.Items[1]
+	assert.Equal(t, transfer.ID, fee.TransactionID)
+	assert.True(t, fee.IsFee())
+	assert.Equal(t, ledgerentity.DirectionOut, fee.Direction)
+	assert.Equal(t, "0.50", fee.Amount.String())
+	assert.Equal(t, "69.50", fee.BalanceAfter.String())
+	assert.False(t, fee.CounterpartyAccountID.Valid)
+	assert.False(t, fee.CounterpartyIban.Valid)
+	assert.False(t, fee.CounterpartyName.Valid)
+	assert.Equal(t, ledgerentity.KindPrincipal, payment.Kind)
+	assert.Equal(t, "30.00", payment.Amount.String())
+	assert.Equal(t, toAccount.AccountNumber, payment.CounterpartyIban.String)
+
+	// the statements read the same entries
+	var streamed []ledgerentity.ActivityEntryEntity
+	assert.Nil(t, transactionRepository.StreamAccountActivity(ctx, nil, account.ID, time.Now().UTC().Add(-time.Hour), time.Now().UTC().Add(time.Hour), money.Zero,
+		func(entry ledgerentity.ActivityEntryEntity) error {
+			streamed = append(streamed, entry)
+			return nil
+		}))
+	assert.Len(t, streamed, 3)
+	assert.True(t, streamed[2].IsFee())
+	assert.False(t, streamed[2].CounterpartyIban.Valid)
+
+	// the recipient does not see the fee
+	activity, err = transactionRepository.GetAccountActivity(ctx, toAccount.ID, 1, 10)
+	assert.Nil(t, err)
+	assert.Len(t, activity.Items, 1)
+	assert.Equal(t, "30.00", activity.Items[0].Amount.String())
+}
@@ -0,0 +1,84 @@
+package repository_Test
+
+import (
+	"context"
+	"database/sql"
+	"src/domain/money"
+	scheduledtransferentity "src/domain/scheduled_transfer"
+	errors "src/errors"
+	app_logger "src/logger"
+	"src/repositories"
+	"src/test/utils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// The end of day snapshots cover every day from the first entry of the ledger, and the point in time
+// balance, which starts from them, matches the entries created before the instant
+func TestBalanceHistory(t *testing.T) {
+	ctx := context.Background()
+	db := startLedgerDatabase(t, ctx)
+	logger := app_logger.GetLogger()
+
+	clientRepository := repositories.NewClientRepository(db, logger)
+	accountRepository := repositories.NewAccountRepository(db, logger)
+	transactionRepository := repositories.NewTransactionRepository(db, logger)
+	balanceRepository := repositories.NewBalanceRepository(db, logger)
+
+	client := utils.CreateClientTest(1, "Jhon", "jhon@test.es")
+	assert.NoError(t, clientRepository.InsertClient(ctx, &client))
+	account := utils.CreateAccount(client.ID)
+	assert.NoError(t, accountRepository.InsertAccount(ctx, &account))
+
+	// entries of three days ago, yesterday and today
+	backdate := func(transactionID int, days int) {
+		_, err := db.ExecContext(ctx, `UPDATE ledger_entries SET created_at = created_at - make_interval(days => $1) WHERE transaction_id = $2`, days, transactionID)
+		assert.NoError(t, err)
+	}
+	_, err := db.ExecContext(ctx, `UPDATE accounts SET created_at = created_at - interval '3 days' WHERE id = $1`, account.ID)
+	assert.NoError(t, err)
+	deposit := utils.CreateTransaction(account.ID, sql.NullInt32{}, money.MustParse("100.00"), "ADD")
+	assert.Nil(t, transactionRepository.InsertTransactionLedgerTx(ctx, &deposit))
+	backdate(deposit.ID, 3)
+	withdrawal := utils.CreateTransaction(account.ID, sql.NullInt32{}, money.MustParse("30.00"), "WITHDRAWAL")
+	assert.Nil(t, transactionRepository.InsertTransactionLedgerTx(ctx, &withdrawal))
+	backdate(withdrawal.ID, 1)
+	deposit = utils.CreateTransaction(account.ID, sql.NullInt32{}, money.MustParse("5.00"), "ADD")
+	assert.Nil(t, transactionRepository.InsertTransactionLedgerTx(ctx, &deposit))
+
+	today := scheduledtransferentity.Today()
+	taken, appErr := balanceRepository.SnapshotBalances(ctx, today.AddDate(0, 0, -1))
+	assert.Nil(t, appErr)
+	assert.Equal(t, 3, taken)
+	taken, appErr = balanceRepository.SnapshotBalances(ctx, today.AddDate(0, 0, -1))
+	assert.Nil(t, appErr)
+	assert.Equal(t, 0, taken)
+
+	history, appErr := balanceRepository.FetchBalanceHistory(ctx, account.ID, today.AddDate(0, 0, -10), today)
+	assert.Nil(t, appErr)
+	assert.Len(t, history, 3)
+	expected := []string{"100.00", "100.00", "70.00"}
+	for i, snapshot := range history {
+		assert.Equal(t, today.AddDate(0, 0, i-3).Format(time.DateOnly), snapshot.SnapshotDate.Format(time.DateOnly))
+		assert.Equal(t, expected[i], snapshot.Balance.String())
+	}
+
+	for _, point := range []struct {
+		at      time.Time
+		balance string
+	}{
+		{today.AddDate(0, 0, -4), "0.00"},
+		{today.AddDate(0, 0, -1), "100.00"},
+		{today, "70.00"},
+		{time.Now().UTC().Add(time.Minute), "75.00"},
+	} {
+		balance, appErr := balanceRepository.FetchBalanceAt(ctx, nil, account.ID, point.at)
+		assert.Nil(t, appErr)
+		assert.Equal(t, point.balance, balance.String(), point.at)
+	}
+
+	_, appErr = balanceRepository.FetchBalanceAt(ctx, nil, account.ID+1000, today)
+	assert.IsType(t, &errors.ErrNotFound{}, appErr)
+}
@@ -0,0 +1,50 @@
+package repository_Test
+
+import (
+	"context"
+	bankentity "src/domain/bank"
+	errors "src/errors"
+	app_logger "src/logger"
+	"src/repositories"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Every load replaces the whole directory, and the longest bank code listed wins
+func TestBankDirectory(t *testing.T) {
+	ctx := context.Background()
+	db := startLedgerDatabase(t, ctx)
+	bankDirectoryRepository := repositories.NewBankDirectoryRepository(db, app_logger.GetLogger())
+
+	assert.Nil(t, bankDirectoryRepository.ReplaceBankDirectory(ctx, []bankentity.BankEntity{
+		{CountryCode: "ES", BankCode: "2100", Bic: "CAIXESBBXXX", Name: "CaixaBank"},
+		{CountryCode: "GB", BankCode: "NWBK", Bic: "NWBKGB2L", Name: "National Westminster Bank"},
+		{CountryCode: "GB", BankCode: "NWBK601613", Bic: "NWBKGB2LXXX", Name: "National Westminster Bank, Bishopsgate"},
+	}))
+	count, err := bankDirectoryRepository.CountBanks(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, count)
+
+	bank, err := bankDirectoryRepository.FetchBank(ctx, "GB", "NWBK601613", "NWBK")
+	assert.Nil(t, err)
+	assert.Equal(t, "NWBKGB2LXXX", bank.Bic)
+	bank, err = bankDirectoryRepository.FetchBank(ctx, "GB", "NWBK400515", "NWBK")
+	assert.Nil(t, err)
+	assert.Equal(t, "NWBKGB2L", bank.Bic)
+	_, err = bankDirectoryRepository.FetchBank(ctx, "FR", "2100")
+	assert.IsType(t, &errors.ErrNotFound{}, err)
+
+	assert.Nil(t, bankDirectoryRepository.ReplaceBankDirectory(ctx, []bankentity.BankEntity{
+		{CountryCode: "ES", BankCode: "2100", Bic: "CAIXESBB", Name: "CaixaBank"},
+		{CountryCode: "ES", BankCode: "2100", Bic: "CAIXESBBXXX", Name: "CaixaBank, S.A."},
+	}))
+	count, err = bankDirectoryRepository.CountBanks(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, count)
+	bank, err = bankDirectoryRepository.FetchBank(ctx, "ES", "21000418", "2100")
+	assert.Nil(t, err)
+	assert.Equal(t, "CaixaBank, S.A.", bank.Name)
+	_, err = bankDirectoryRepository.FetchBank(ctx, "GB", "NWBK")
+	assert.IsType(t, &errors.ErrNotFound{}, err)
+}
@@ -0,0 +1,144 @@
+package repository_Test
+
+import (
+	"context"
+	"database/sql"
+	beneficiaryentity "src/domain/beneficiary"
+	"src/domain/money"
+	paymententity "src/domain/payment"
+	transaction_entity "src/domain/transaction"
+	errors "src/errors"
+	app_logger "src/logger"
+	"src/repositories"
+	"src/test/utils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// The transfers to a beneficiary in its cooling-off cannot add up to more than its limit, afterwards they are not capped
+func TestBeneficiaryCoolingOff(t *testing.T) {
+	ctx := context.Background()
+	db := startLedgerDatabase(t, ctx)
+	logger := app_logger.GetLogger()
+	clientRepository := repositories.NewClientRepository(db, logger)
+	accountRepository := repositories.NewAccountRepository(db, logger)
+	transactionRepository := repositories.NewTransactionRepository(db, logger)
+	beneficiaryRepository := repositories.NewBeneficiaryRepository(db, logger)
+
+	client := utils.CreateClientTest(1, "Jhon", "jhon@test.es")
+	assert.NoError(t, clientRepository.InsertClient(ctx, &client))
+	account := utils.CreateAccount(client.ID)
+	assert.NoError(t, accountRepository.InsertAccount(ctx, &account))
+	toAccount := utils.CreateAccount(client.ID)
+	assert.NoError(t, accountRepository.InsertAccount(ctx, &toAccount))
+	deposit := utils.CreateTransaction(account.ID, sql.NullInt32{}, money.MustParse("500.00"), "ADD")
+	assert.NoError(t, transactionRepository.InsertTransactionLedgerTx(ctx, &deposit))
+
+	beneficiary := beneficiaryentity.BeneficiaryEntity{
+		ClientID:        client.ID,
+		Nickname:        "Savings",
+		Iban:            toAccount.AccountNumber,
+		CoolingOffUntil: time.Now().Add(time.Hour),
+		CoolingOffLimit: money.MustParse("100.00"),
+	}
+	assert.Nil(t, beneficiaryRepository.InsertBeneficiary(ctx, &beneficiary))
+	duplicate := beneficiary
+	assert.IsType(t, &errors.ErrConflict{}, beneficiaryRepository.InsertBeneficiary(ctx, &duplicate))
+
+	transfer := func(amount string) errors.AppError {
+		transaction := utils.CreateTransaction(account.ID, sql.NullInt32{Int32: int32(toAccount.ID), Valid: true}, money.MustParse(amount), "TRANSFER")
+		transaction.BeneficiaryID = sql.NullInt32{Int32: int32(beneficiary.ID), Valid: true}
+		return transactionRepository.InsertTransactionLedgerTx(ctx, &transaction)
+	}
+	assert.Nil(t, transfer("60.00"))
+	assert.Nil(t, transfer("40.00"))
+	assert.IsType(t, &errors.ErrConflict{}, transfer("0.01"))
+
+	_, err := db.ExecContext(ctx, `UPDATE beneficiaries SET cooling_off_until = CURRENT_TIMESTAMP - INTERVAL '1 minute' WHERE id = $1`, beneficiary.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, transfer("200.00"))
+
+	beneficiary.Nickname = "Holidays"
+	beneficiary.Reference = sql.NullString{String: "Trip", Valid: true}
+	assert.Nil(t, beneficiaryRepository.UpdateBeneficiary(ctx, &beneficiary))
+	beneficiaries, appErr := beneficiaryRepository.FetchBeneficiariesByClient(ctx, client.ID)
+	assert.Nil(t, appErr)
+	assert.Len(t, beneficiaries, 1)
+	assert.Equal(t, "Holidays", beneficiaries[0].Nickname)
+	assert.Equal(t, money.MustParse("100.00"), beneficiaries[0].CoolingOffLimit)
+
+	// the transfers are kept without the beneficiary
+	assert.Nil(t, beneficiaryRepository.DeleteBeneficiary(ctx, beneficiary.ID))
+	_, appErr = beneficiaryRepository.FetchBeneficiaryById(ctx, beneficiary.ID)
+	assert.IsType(t, &errors.ErrNotFound{}, appErr)
+	var transfers int
+	assert.NoError(t, db.QueryRowContext(ctx, `SELECT COUNT(*) FROM transactions WHERE type = 'TRANSFER' AND beneficiary_id IS NULL`).Scan(&transfers))
+	assert.Equal(t, 3, transfers)
+}
+
+// The cooling-off follows the IBAN, not the beneficiary: transfers to the IBAN without beneficiary_id are capped too,
+// the ones sent before the beneficiary was added are not counted, and the beneficiary cannot be removed and added again
+func TestBeneficiaryCoolingOffByIban(t *testing.T) {
+	ctx := context.Background()
+	db := startLedgerDatabase(t, ctx)
+	logger := app_logger.GetLogger()
+	clientRepository := repositories.NewClientRepository(db, logger)
+	accountRepository := repositories.NewAccountRepository(db, logger)
+	transactionRepository := repositories.NewTransactionRepository(db, logger)
+	paymentRepository := repositories.NewPaymentRepository(db, logger, transactionRepository)
+	beneficiaryRepository := repositories.NewBeneficiaryRepository(db, logger)
+
+	client := utils.CreateClientTest(1, "Jhon", "jhon@test.es")
+	assert.NoError(t, clientRepository.InsertClient(ctx, &client))
+	account := utils.CreateAccount(client.ID)
+	assert.NoError(t, accountRepository.InsertAccount(ctx, &account))
+	toAccount := utils.CreateAccount(client.ID)
+	assert.NoError(t, accountRepository.InsertAccount(ctx, &toAccount))
+	deposit := utils.CreateTransaction(account.ID, sql.NullInt32{}, money.MustParse("1000.00"), "ADD")
+	assert.NoError(t, transactionRepository.InsertTransactionLedgerTx(ctx, &deposit))
+
+	addBeneficiary := func(iban string) beneficiaryentity.BeneficiaryEntity {
+		beneficiary := beneficiaryentity.BeneficiaryEntity{
+			ClientID:        client.ID,
+			Nickname:        "Landlord",
+			Iban:            iban,
+			CoolingOffUntil: time.Now().Add(time.Hour),
+			CoolingOffLimit: money.MustParse("100.00"),
+		}
+		assert.Nil(t, beneficiaryRepository.InsertBeneficiary(ctx, &beneficiary))
+		return beneficiary
+	}
+
+	// an account of the bank, by to_account_number
+	addBeneficiary(toAccount.AccountNumber)
+	transfer := func(amount string) errors.AppError {
+		transaction := utils.CreateTransaction(account.ID, sql.NullInt32{Int32: int32(toAccount.ID), Valid: true}, money.MustParse(amount), "TRANSFER")
+		return transactionRepository.InsertTransactionLedgerTx(ctx, &transaction)
+	}
+	assert.Nil(t, transfer("70.00"))
+	assert.IsType(t, &errors.ErrConflict{}, transfer("30.01"))
+	assert.Nil(t, transfer("30.00"))
+
+	// another bank, by IBAN, paid before it was added
+	iban := "ES7921000813610123456789"
+	sepaTransfer := func(amount string) errors.AppError {
+		payment := paymententity.OutboundPaymentEntity{AccountID: account.ID, CreditorIban: iban, CreditorName: "Luis", Amount: money.MustParse(amount)}
+		var transaction transaction_entity.TransactionEntity
+		return paymentRepository.InsertOutboundPayment(ctx, &payment, &transaction)
+	}
+	assert.Nil(t, sepaTransfer("250.00"))
+	external := addBeneficiary(iban)
+	assert.Nil(t, sepaTransfer("80.00"))
+	assert.IsType(t, &errors.ErrConflict{}, beneficiaryRepository.DeleteBeneficiary(ctx, external.ID))
+	assert.IsType(t, &errors.ErrConflict{}, sepaTransfer("20.01"))
+	assert.Nil(t, sepaTransfer("20.00"))
+
+	// once the cooling-off is over the beneficiary can be removed, and without it there is no cap
+	_, err := db.ExecContext(ctx, `UPDATE beneficiaries SET cooling_off_until = CURRENT_TIMESTAMP - INTERVAL '1 minute' WHERE client_id = $1`, client.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, beneficiaryRepository.DeleteBeneficiary(ctx, external.ID))
+	assert.Nil(t, sepaTransfer("100.00"))
+	assert.IsType(t, &errors.ErrNotFound{}, beneficiaryRepository.DeleteBeneficiary(ctx, external.ID))
+}
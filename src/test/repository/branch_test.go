@@ -0,0 +1,112 @@
+package repository_Test
+
+import (
+	"context"
+	"fmt"
+	services "src/api/service"
+	branchentity "src/domain/branch"
+	errors "src/errors"
+	app_logger "src/logger"
+	"src/repositories"
+	"src/test/utils"
+	appUtils "src/utils"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func branchIban(branchCode string, sequence int64) string {
+	handler := appUtils.IbanHandler{}
+	accNumber := fmt.Sprintf("%010d", sequence)
+	iban, _ := handler.ComputeIban(appUtils.Bban{
+		BankCode:            branchentity.DefaultBankCode,
+		BranchCode:          branchCode,
+		DomesticCheckDigits: handler.DomesticCheckDigits(branchentity.DefaultBankCode, branchCode, accNumber),
+		AccountNumber:       accNumber,
+	}, "ES")
+	return iban
+}
+
+// Accounts are numbered from the sequence of the client's branch, skipping the numbers already taken,
+// and concurrent openings never get the same number
+func TestAccountNumberAllocation(t *testing.T) {
+	ctx := context.Background()
+	db := startLedgerDatabase(t, ctx)
+	logger := app_logger.GetLogger()
+	wrapper := repositories.RepositoryWrapper{
+		ClientRepository:      repositories.NewClientRepository(db, logger),
+		AccountRepository:     repositories.NewAccountRepository(db, logger),
+		TransactionRepository: repositories.NewTransactionRepository(db, logger),
+		CurrencyRepository:    repositories.NewCurrencyRepository(db, logger),
+		FeeRepository:         repositories.NewFeeRepository(db, logger),
+		BranchRepository:      repositories.NewBranchRepository(db, logger),
+	}
+	accountService := services.NewAccountService(wrapper)
+
+	client := utils.CreateClientTest(1, "Jhon", "jhon@test.es")
+	assert.NoError(t, wrapper.ClientRepository.InsertClient(ctx, &client))
+	// an account opened with a random number before the sequence
+	taken := utils.CreateAccount(client.ID)
+	taken.AccountNumber = branchIban(branchentity.DefaultBranchCode, 1)
+	assert.NoError(t, wrapper.AccountRepository.InsertAccount(ctx, &taken))
+
+	account, err := accountService.CreateAccount(client.ID, "", "")
+	assert.Nil(t, err)
+	assert.Equal(t, branchIban(branchentity.DefaultBranchCode, 2), account.AccountNumber)
+
+	assert.Nil(t, wrapper.BranchRepository.UpsertBranch(ctx, &branchentity.BranchEntity{Code: "0601", Name: "Oficina norte"}))
+	assert.IsType(t, &errors.ErrNotFound{}, wrapper.BranchRepository.SetClientBranch(ctx, client.ID, "0999"))
+	assert.Nil(t, wrapper.BranchRepository.SetClientBranch(ctx, client.ID, "0601"))
+
+	const workers = 20
+	numbers := make(chan string, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			account, err := accountService.CreateAccount(client.ID, "", "")
+			if assert.Nil(t, err) {
+				numbers <- account.AccountNumber
+			}
+		}()
+	}
+	wg.Wait()
+	close(numbers)
+	unique := map[string]bool{}
+	for number := range numbers {
+		unique[number] = true
+	}
+	assert.Len(t, unique, workers)
+	for sequence := int64(1); sequence <= workers; sequence++ {
+		assert.True(t, unique[branchIban("0601", sequence)], "sequence %d was not allocated", sequence)
+	}
+
+	branches, err := wrapper.BranchRepository.FetchBranches(ctx, branchentity.DefaultBankCode)
+	assert.Nil(t, err)
+	assert.Len(t, branches, 2)
+	assert.Equal(t, "0600", branches[0].Code)
+	assert.Equal(t, 2, branches[0].Accounts)
+	assert.Equal(t, int64(2), branches[0].LastSequence)
+	assert.Equal(t, "0601", branches[1].Code)
+	assert.Equal(t, workers, branches[1].Accounts)
+	assert.Equal(t, branchentity.AccountNumberCapacity-workers, branches[1].Available())
+}
+
+// A branch with every number handed out cannot open accounts
+func TestExhaustedBranch(t *testing.T) {
+	ctx := context.Background()
+	db := startLedgerDatabase(t, ctx)
+	branchRepository := repositories.NewBranchRepository(db, app_logger.GetLogger())
+
+	_, err := db.ExecContext(ctx, `UPDATE branches SET last_sequence = $1 WHERE code = '0600'`, branchentity.AccountNumberCapacity)
+	assert.NoError(t, err)
+	tx, err := db.BeginTx(ctx, nil)
+	assert.NoError(t, err)
+	defer tx.Rollback()
+	_, appErr := branchRepository.NextAccountSequence(ctx, tx, "0600")
+	assert.IsType(t, &errors.ErrConflict{}, appErr)
+	_, appErr = branchRepository.NextAccountSequence(ctx, tx, "0999")
+	assert.IsType(t, &errors.ErrNotFound{}, appErr)
+}
@@ -0,0 +1,186 @@
+package repository_Test
+
+import (
+	"context"
+	"database/sql"
+	"src/domain/money"
+	app_logger "src/logger"
+	"src/repositories"
+	"src/test/utils"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+// startLedgerDatabase runs a Postgres container with the ledger schema
+func startLedgerDatabase(t *testing.T, ctx context.Context) *sql.DB {
+	pgContainer, err := postgres.RunContainer(ctx,
+		testcontainers.WithImage("postgres:17-alpine"),
+		postgres.WithDatabase("testdb"),
+		postgres.WithUsername("user"),
+		postgres.WithPassword("password"),
+		postgres.WithInitScripts(
+			"../../db/migrations/00001_tables.up.sql",
+			"../../db/migrations/00003_transaction_add_field.up.sql",
+			"../../db/migrations/00005_transaction_reversals.up.sql",
+			"../../db/migrations/00006_holds.up.sql",
+			"../../db/migrations/00007_scheduled_transfers.up.sql",
+			"../../db/migrations/00008_account_balances_mv_fix.up.sql",
+			"../../db/migrations/00009_internal_accounts.up.sql",
+			"../../db/migrations/00010_ledger_entries_account_index.up.sql",
+			"../../db/migrations/00011_transactions_keyset_index.up.sql",
+			"../../db/migrations/00012_currencies.up.sql",
+			"../../db/migrations/00013_account_status.up.sql",
+			"../../db/migrations/00014_overdrafts.up.sql",
+			"../../db/migrations/00015_fees.up.sql",
+			"../../db/migrations/00016_interest_accruals.up.sql",
+			"../../db/migrations/00017_balance_snapshots.up.sql",
+			"../../db/migrations/00018_documents.up.sql",
+			"../../db/migrations/00019_outbound_payments.up.sql",
+			"../../db/migrations/00020_inbound_payments.up.sql",
+			"../../db/migrations/00021_bank_directory.up.sql",
+			"../../db/migrations/00022_branches.up.sql",
+			"../../db/migrations/00023_payee_verifications.up.sql",
+			"../../db/migrations/00024_beneficiaries.up.sql",
+			"../../db/migrations/00025_ledger_entry_kind.up.sql",
+			"../../db/migrations/00026_daily_job_runs.up.sql",
+			"../../db/migrations/00027_transactions_to_iban.up.sql",
+		),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(5*time.Second)),
+	)
+	if err != nil {
+		t.Fatalf("failed to start container: %s", err)
+	}
+	t.Cleanup(func() {
+		if err := pgContainer.Terminate(ctx); err != nil {
+			t.Fatalf("failed to terminate container: %s", err)
+		}
+	})
+
+	connStr, err := pgContainer.ConnectionString(ctx, "sslmode=disable")
+	if err != nil {
+		t.Fatalf("failed to get connection string: %s", err)
+	}
+	db, err := sql.Open("postgres", connStr)
+	if err != nil {
+		t.Fatalf("failed to open database: %s", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	db.SetMaxOpenConns(50)
+	assert.NoError(t, db.PingContext(ctx), "failed to ping database")
+	return db
+}
+
+// Many goroutines withdraw from the same account at once. Without row locks several of them
+// read the same balance, pass the validation and overdraw the account.
+func TestConcurrentWithdrawalsNeverOverdraw(t *testing.T) {
+	ctx := context.Background()
+	db := startLedgerDatabase(t, ctx)
+	logger := app_logger.GetLogger()
+
+	clientRepository := repositories.NewClientRepository(db, logger)
+	accountRepository := repositories.NewAccountRepository(db, logger)
+	transactionRepository := repositories.NewTransactionRepository(db, logger)
+
+	client := utils.CreateClientTest(1, "Jhon", "jhon@test.es")
+	assert.NoError(t, clientRepository.InsertClient(ctx, &client))
+	account := utils.CreateAccount(client.ID)
+	assert.NoError(t, accountRepository.InsertAccount(ctx, &account))
+
+	deposit := utils.CreateTransaction(account.ID, sql.NullInt32{}, money.MustParse("100.00"), "ADD")
+	assert.NoError(t, transactionRepository.InsertTransactionLedgerTx(ctx, &deposit))
+
+	const workers = 50
+	var succeeded, rejected atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			withdrawal := utils.CreateTransaction(account.ID, sql.NullInt32{}, money.MustParse("10.00"), "WITHDRAWAL")
+			if err := transactionRepository.InsertTransactionLedgerTx(ctx, &withdrawal); err != nil {
+				rejected.Add(1)
+				return
+			}
+			succeeded.Add(1)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(10), succeeded.Load())
+	assert.Equal(t, int32(workers-10), rejected.Load())
+
+	balance, err := transactionRepository.FetchAccountBalance(ctx, nil, account.ID)
+	assert.Nil(t, err)
+	assert.True(t, balance.Balance.IsZero(), "balance must be 0.00, got %s", balance.Balance)
+
+	var ledgerBalance money.Money
+	err2 := db.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(CASE WHEN type = 'CREDIT' THEN amount ELSE -amount END), 0)
+		FROM ledger_entries WHERE account_id = $1`, account.ID).Scan(&ledgerBalance)
+	assert.NoError(t, err2)
+	assert.True(t, ledgerBalance.Equal(balance.Balance), "ledger %s != balance %s", ledgerBalance, balance.Balance)
+}
+
+// Transfers in both directions between the same two accounts lock the rows in the same
+// order, so they never deadlock and the total amount of money is preserved.
+func TestConcurrentTransfersInBothDirections(t *testing.T) {
+	ctx := context.Background()
+	db := startLedgerDatabase(t, ctx)
+	logger := app_logger.GetLogger()
+
+	clientRepository := repositories.NewClientRepository(db, logger)
+	accountRepository := repositories.NewAccountRepository(db, logger)
+	transactionRepository := repositories.NewTransactionRepository(db, logger)
+
+	jhon := utils.CreateClientTest(1, "Jhon", "jhon@test.es")
+	joe := utils.CreateClientTest(2, "Joe", "joe@insertion.com")
+	assert.NoError(t, clientRepository.InsertClient(ctx, &jhon))
+	assert.NoError(t, clientRepository.InsertClient(ctx, &joe))
+	accountJhon := utils.CreateAccount(jhon.ID)
+	accountJoe := utils.CreateAccount(joe.ID)
+	assert.NoError(t, accountRepository.InsertAccount(ctx, &accountJhon))
+	assert.NoError(t, accountRepository.InsertAccount(ctx, &accountJoe))
+
+	for _, accountID := range []int{accountJhon.ID, accountJoe.ID} {
+		deposit := utils.CreateTransaction(accountID, sql.NullInt32{}, money.MustParse("50.00"), "ADD")
+		assert.NoError(t, transactionRepository.InsertTransactionLedgerTx(ctx, &deposit))
+	}
+
+	const transfersPerDirection = 40
+	var wg sync.WaitGroup
+	var failed atomic.Int32
+	transfer := func(from, to int) {
+		defer wg.Done()
+		transaction := utils.CreateTransaction(from, sql.NullInt32{Int32: int32(to), Valid: true}, money.MustParse("5.00"), "TRANSFER")
+		if err := transactionRepository.InsertTransactionLedgerTx(ctx, &transaction); err != nil {
+			failed.Add(1)
+		}
+	}
+	for i := 0; i < transfersPerDirection; i++ {
+		wg.Add(2)
+		go transfer(accountJhon.ID, accountJoe.ID)
+		go transfer(accountJoe.ID, accountJhon.ID)
+	}
+	wg.Wait()
+
+	balanceJhon, err := transactionRepository.FetchAccountBalance(ctx, nil, accountJhon.ID)
+	assert.Nil(t, err)
+	balanceJoe, err := transactionRepository.FetchAccountBalance(ctx, nil, accountJoe.ID)
+	assert.Nil(t, err)
+
+	assert.False(t, balanceJhon.Balance.IsNegative())
+	assert.False(t, balanceJoe.Balance.IsNegative())
+	assert.Equal(t, "100.00", balanceJhon.Balance.Add(balanceJoe.Balance).String())
+	t.Logf("%d transfers rejected for lack of funds", failed.Load())
+}
@@ -0,0 +1,74 @@
+package repository_Test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	services "src/api/service"
+	documententity "src/domain/document"
+	"src/domain/money"
+	errors "src/errors"
+	app_logger "src/logger"
+	"src/repositories"
+	"src/test/utils"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Every issued document is recorded with the digest of the PDF, and its code finds it back
+func TestIssueDocuments(t *testing.T) {
+	ctx := context.Background()
+	db := startLedgerDatabase(t, ctx)
+	logger := app_logger.GetLogger()
+
+	clientRepository := repositories.NewClientRepository(db, logger)
+	accountRepository := repositories.NewAccountRepository(db, logger)
+	transactionRepository := repositories.NewTransactionRepository(db, logger)
+	documentRepository := repositories.NewDocumentRepository(db, logger)
+	wrapper := repositories.RepositoryWrapper{
+		ClientRepository:      clientRepository,
+		AccountRepository:     accountRepository,
+		TransactionRepository: transactionRepository,
+		BalanceRepository:     repositories.NewBalanceRepository(db, logger),
+		DocumentRepository:    documentRepository,
+	}
+	documentService := services.NewDocumentService(wrapper, services.NewStatementService(wrapper, logger), logger)
+
+	client := utils.CreateClientTest(1, "Jhon", "jhon@test.es")
+	assert.NoError(t, clientRepository.InsertClient(ctx, &client))
+	account := utils.CreateAccount(client.ID)
+	assert.NoError(t, accountRepository.InsertAccount(ctx, &account))
+	deposit := utils.CreateTransaction(account.ID, sql.NullInt32{}, money.MustParse("100.00"), "ADD")
+	assert.Nil(t, transactionRepository.InsertTransactionLedgerTx(ctx, &deposit))
+
+	content, certificate, appErr := documentService.IssueOwnershipCertificate(ctx, account.ID)
+	assert.Nil(t, appErr)
+	assert.True(t, bytes.HasPrefix(content, []byte("%PDF-")))
+	assert.Contains(t, string(content), documententity.FormatIban(account.AccountNumber))
+	assert.Contains(t, string(content), certificate.VerificationCode)
+	assert.Equal(t, documententity.TypeOwnershipCertificate, certificate.Type)
+	assert.True(t, strings.HasPrefix(certificate.HolderName, "Jhon"))
+
+	content, statement, appErr := documentService.IssueStatement(ctx, account.ID, time.Now().UTC())
+	assert.Nil(t, appErr)
+	assert.Contains(t, string(content), "(100.00 EUR) Tj")
+	assert.NotEqual(t, certificate.VerificationCode, statement.VerificationCode)
+
+	found, appErr := documentRepository.FetchDocumentByCode(ctx, statement.VerificationCode)
+	assert.Nil(t, appErr)
+	digest := sha256.Sum256(content)
+	assert.Equal(t, hex.EncodeToString(digest[:]), found.Sha256)
+	assert.Equal(t, documententity.TypeStatement, found.Type)
+	assert.Equal(t, account.AccountNumber, found.Iban)
+	assert.True(t, found.PeriodFrom.Valid)
+
+	_, _, appErr = documentService.IssueStatement(ctx, account.ID, time.Now().UTC().AddDate(0, 1, 0))
+	assert.IsType(t, &errors.ErrBadRequest{}, appErr)
+	_, appErr = documentRepository.FetchDocumentByCode(ctx, "AAAA-AAAA-AAAA-AAAA")
+	assert.IsType(t, &errors.ErrNotFound{}, appErr)
+}
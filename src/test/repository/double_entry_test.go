@@ -0,0 +1,66 @@
+package repository_Test
+
+import (
+	"context"
+	"database/sql"
+	accountentity "src/domain/account"
+	currencyentity "src/domain/currency"
+	"src/domain/money"
+	transaction_entity "src/domain/transaction"
+	app_logger "src/logger"
+	"src/repositories"
+	"src/test/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ADD and WITHDRAWAL post their counterpart against the cash in vault account, so every
+// transaction is balanced and the whole ledger sums to zero, reversals included.
+func TestAddAndWithdrawalAreDoubleEntry(t *testing.T) {
+	ctx := context.Background()
+	db := startLedgerDatabase(t, ctx)
+	logger := app_logger.GetLogger()
+
+	clientRepository := repositories.NewClientRepository(db, logger)
+	accountRepository := repositories.NewAccountRepository(db, logger)
+	transactionRepository := repositories.NewTransactionRepository(db, logger)
+
+	client := utils.CreateClientTest(1, "Jhon", "jhon@test.es")
+	assert.NoError(t, clientRepository.InsertClient(ctx, &client))
+	account := utils.CreateAccount(client.ID)
+	assert.NoError(t, accountRepository.InsertAccount(ctx, &account))
+
+	deposit := utils.CreateTransaction(account.ID, sql.NullInt32{}, money.MustParse("100.00"), "ADD")
+	assert.NoError(t, transactionRepository.InsertTransactionLedgerTx(ctx, &deposit))
+	withdrawal := utils.CreateTransaction(account.ID, sql.NullInt32{}, money.MustParse("40.00"), "WITHDRAWAL")
+	assert.NoError(t, transactionRepository.InsertTransactionLedgerTx(ctx, &withdrawal))
+
+	vaultID, err := transactionRepository.FetchInternalAccountId(ctx, nil, accountentity.InternalCashInVault, currencyentity.Default)
+	assert.Nil(t, err)
+	vault, err := transactionRepository.FetchAccountBalance(ctx, nil, vaultID)
+	assert.Nil(t, err)
+	assert.True(t, vault.Internal)
+	assert.Equal(t, "-60.00", vault.Balance.String())
+
+	entries, err := transactionRepository.FetchLedgerEntriesByTransaction(ctx, nil, deposit.ID)
+	assert.Nil(t, err)
+	assert.Len(t, entries, 2)
+
+	// the vault is debited below zero by the reversal of the withdrawal
+	_, err = transactionRepository.InsertReversalLedgerTx(ctx, withdrawal.ID, nil, transaction_entity.ReversalReasonTechnicalError)
+	assert.Nil(t, err)
+	balance, err := transactionRepository.FetchAccountBalance(ctx, nil, account.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, "100.00", balance.Balance.String())
+
+	var total money.Money
+	assert.NoError(t, db.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(CASE WHEN type = 'CREDIT' THEN amount ELSE -amount END), 0)
+		FROM ledger_entries`).Scan(&total))
+	assert.True(t, total.IsZero(), "ledger total must be 0.00, got %s", total)
+
+	// clients cannot send money to internal accounts
+	_, err = accountRepository.FetchAccountIdByAccountNumber(ctx, "INTERNAL-FEE-INCOME")
+	assert.NotNil(t, err)
+}
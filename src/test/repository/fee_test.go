@@ -0,0 +1,95 @@
+package repository_Test
+
+import (
+	"context"
+	"database/sql"
+	accountentity "src/domain/account"
+	feeentity "src/domain/fee"
+	"src/domain/money"
+	errors "src/errors"
+	app_logger "src/logger"
+	"src/repositories"
+	"src/test/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// The fee of the product of the source account is paid on top of the amount to the fee income account,
+// and the schedule of the product overrides the one for every product
+func TestFees(t *testing.T) {
+	ctx := context.Background()
+	db := startLedgerDatabase(t, ctx)
+	logger := app_logger.GetLogger()
+
+	clientRepository := repositories.NewClientRepository(db, logger)
+	accountRepository := repositories.NewAccountRepository(db, logger)
+	transactionRepository := repositories.NewTransactionRepository(db, logger)
+	feeRepository := repositories.NewFeeRepository(db, logger)
+	reconciliationRepository := repositories.NewReconciliationRepository(db, logger)
+
+	client := utils.CreateClientTest(1, "Jhon", "jhon@test.es")
+	assert.NoError(t, clientRepository.InsertClient(ctx, &client))
+	standard := utils.CreateAccount(client.ID)
+	assert.NoError(t, accountRepository.InsertAccount(ctx, &standard))
+	business := utils.CreateAccount(client.ID)
+	business.Product = "BUSINESS"
+	assert.NoError(t, accountRepository.InsertAccount(ctx, &business))
+
+	generic := feeentity.FeeScheduleEntity{
+		TransactionType:     "WITHDRAWAL",
+		Currency:            "EUR",
+		FixedAmount:         money.MustParse("0.50"),
+		Percentage:          "0.01",
+		MaxPercentageAmount: money.NullMoney{Money: money.MustParse("2.00"), Valid: true},
+	}
+	assert.Nil(t, feeRepository.UpsertFeeSchedule(ctx, &generic))
+	businessSchedule := feeentity.FeeScheduleEntity{
+		TransactionType: "WITHDRAWAL",
+		Product:         sql.NullString{String: "BUSINESS", Valid: true},
+		Currency:        "EUR",
+		FixedAmount:     money.MustParse("0.10"),
+		Percentage:      "0",
+	}
+	assert.Nil(t, feeRepository.UpsertFeeSchedule(ctx, &businessSchedule))
+	schedules, err := feeRepository.FetchFeeSchedules(ctx)
+	assert.Nil(t, err)
+	assert.Len(t, schedules, 2)
+
+	for _, account := range []accountentity.AccountEntity{standard, business} {
+		deposit := utils.CreateTransaction(account.ID, sql.NullInt32{}, money.MustParse("100.00"), "ADD")
+		assert.Nil(t, transactionRepository.InsertTransactionLedgerTx(ctx, &deposit))
+		assert.True(t, deposit.FeeAmount.IsZero())
+	}
+
+	// 0.50 + 1% of 50.00
+	withdrawal := utils.CreateTransaction(standard.ID, sql.NullInt32{}, money.MustParse("50.00"), "WITHDRAWAL")
+	assert.Nil(t, transactionRepository.InsertTransactionLedgerTx(ctx, &withdrawal))
+	assert.Equal(t, "1.00", withdrawal.FeeAmount.String())
+	assert.Len(t, withdrawal.Fees, 2)
+	withdrawal = utils.CreateTransaction(business.ID, sql.NullInt32{}, money.MustParse("50.00"), "WITHDRAWAL")
+	assert.Nil(t, transactionRepository.InsertTransactionLedgerTx(ctx, &withdrawal))
+	assert.Equal(t, "0.10", withdrawal.FeeAmount.String())
+
+	// 49.00 left: the fee does not fit
+	withdrawal = utils.CreateTransaction(standard.ID, sql.NullInt32{}, money.MustParse("49.00"), "WITHDRAWAL")
+	assert.IsType(t, &errors.ErrNotEnoughFunds{}, transactionRepository.InsertTransactionLedgerTx(ctx, &withdrawal))
+
+	balance, err := transactionRepository.FetchAccountBalance(ctx, nil, standard.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, "49.00", balance.Balance.String())
+	incomeID, err := transactionRepository.FetchInternalAccountId(ctx, nil, accountentity.InternalFeeIncome, "EUR")
+	assert.Nil(t, err)
+	income, err := transactionRepository.FetchAccountBalance(ctx, nil, incomeID)
+	assert.Nil(t, err)
+	assert.Equal(t, "1.10", income.Balance.String())
+
+	assert.Nil(t, feeRepository.DeleteFeeSchedule(ctx, businessSchedule.ID))
+	assert.IsType(t, &errors.ErrNotFound{}, feeRepository.DeleteFeeSchedule(ctx, businessSchedule.ID))
+
+	assert.Nil(t, reconciliationRepository.RunInSnapshot(ctx, func(tx *sql.Tx) errors.AppError {
+		unbalanced, err := reconciliationRepository.FetchUnbalancedTransactions(ctx, tx)
+		assert.Empty(t, unbalanced)
+		return err
+	}))
+}
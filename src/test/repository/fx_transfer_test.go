@@ -0,0 +1,82 @@
+package repository_Test
+
+import (
+	"context"
+	"database/sql"
+	accountentity "src/domain/account"
+	currencyentity "src/domain/currency"
+	"src/domain/money"
+	transaction_entity "src/domain/transaction"
+	errors "src/errors"
+	app_logger "src/logger"
+	"src/repositories"
+	"src/test/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// A EUR to JPY transfer is converted with the inverse of the stored JPY/EUR rate, the rate and the
+// converted amount are recorded, and each currency is balanced through its FX position account
+func TestTransferBetweenCurrencies(t *testing.T) {
+	ctx := context.Background()
+	db := startLedgerDatabase(t, ctx)
+	logger := app_logger.GetLogger()
+
+	clientRepository := repositories.NewClientRepository(db, logger)
+	accountRepository := repositories.NewAccountRepository(db, logger)
+	transactionRepository := repositories.NewTransactionRepository(db, logger)
+	currencyRepository := repositories.NewCurrencyRepository(db, logger)
+	reconciliationRepository := repositories.NewReconciliationRepository(db, logger)
+
+	jhon := utils.CreateClientTest(1, "Jhon", "jhon@test.es")
+	hiro := utils.CreateClientTest(2, "Hiro", "hiro@test.jp")
+	assert.NoError(t, clientRepository.InsertClient(ctx, &jhon))
+	assert.NoError(t, clientRepository.InsertClient(ctx, &hiro))
+	account := utils.CreateAccount(jhon.ID)
+	yenAccount := utils.CreateAccount(hiro.ID)
+	yenAccount.Currency = "JPY"
+	assert.NoError(t, accountRepository.InsertAccount(ctx, &account))
+	assert.NoError(t, accountRepository.InsertAccount(ctx, &yenAccount))
+	assert.Equal(t, currencyentity.Default, account.Currency)
+
+	deposit := utils.CreateTransaction(account.ID, sql.NullInt32{}, money.MustParse("100.00"), "ADD")
+	assert.NoError(t, transactionRepository.InsertTransactionLedgerTx(ctx, &deposit))
+
+	transfer := utils.CreateTransaction(account.ID, sql.NullInt32{Int32: int32(yenAccount.ID), Valid: true}, money.MustParse("10.00"), "TRANSFER")
+	err := transactionRepository.InsertTransactionLedgerTx(ctx, &transfer)
+	assert.NotNil(t, err, "no exchange rate stored yet")
+
+	assert.Nil(t, currencyRepository.UpsertExchangeRates(ctx, []currencyentity.ExchangeRateEntity{{Base: "JPY", Quote: "EUR", Rate: "0.0064"}}))
+	transfer = utils.CreateTransaction(account.ID, sql.NullInt32{Int32: int32(yenAccount.ID), Valid: true}, money.MustParse("10.00"), "TRANSFER")
+	assert.Nil(t, transactionRepository.InsertTransactionLedgerTx(ctx, &transfer))
+	assert.Equal(t, "156.2500000000", transfer.FxRate.String)
+	// 10.00 * 156.25 = 1562.50 rounded half to even to whole yen
+	assert.Equal(t, "1562.00", transfer.ToAmount.Money.String())
+
+	yenBalance, err := transactionRepository.FetchAccountBalance(ctx, nil, yenAccount.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, "1562.00", yenBalance.Balance.String())
+	assert.Equal(t, "JPY", yenBalance.Currency)
+	fxEuroID, err := transactionRepository.FetchInternalAccountId(ctx, nil, accountentity.InternalFxPosition, "EUR")
+	assert.Nil(t, err)
+	fxEuro, err := transactionRepository.FetchAccountBalance(ctx, nil, fxEuroID)
+	assert.Nil(t, err)
+	assert.Equal(t, "10.00", fxEuro.Balance.String())
+
+	// yen amounts have no decimals
+	invalid := utils.CreateTransaction(yenAccount.ID, sql.NullInt32{}, money.MustParse("1.50"), "WITHDRAWAL")
+	assert.NotNil(t, transactionRepository.InsertTransactionLedgerTx(ctx, &invalid))
+
+	_, err = transactionRepository.InsertReversalLedgerTx(ctx, transfer.ID, nil, transaction_entity.ReversalReasonCustomerRequest)
+	assert.Nil(t, err)
+	yenBalance, err = transactionRepository.FetchAccountBalance(ctx, nil, yenAccount.ID)
+	assert.Nil(t, err)
+	assert.True(t, yenBalance.Balance.IsZero())
+
+	assert.Nil(t, reconciliationRepository.RunInSnapshot(ctx, func(tx *sql.Tx) errors.AppError {
+		unbalanced, err := reconciliationRepository.FetchUnbalancedTransactions(ctx, tx)
+		assert.Empty(t, unbalanced)
+		return err
+	}))
+}
@@ -0,0 +1,195 @@
+package repository_Test
+
+import (
+	"context"
+	"database/sql"
+	holdentity "src/domain/hold"
+	"src/domain/money"
+	app_logger "src/logger"
+	"src/repositories"
+	"src/test/utils"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// An authorization only reduces the available balance. A partial capture posts a normal transfer
+// and gives the rest of the hold back.
+func TestHoldAuthorizeAndPartialCapture(t *testing.T) {
+	ctx := context.Background()
+	db := startLedgerDatabase(t, ctx)
+	logger := app_logger.GetLogger()
+
+	clientRepository := repositories.NewClientRepository(db, logger)
+	accountRepository := repositories.NewAccountRepository(db, logger)
+	transactionRepository := repositories.NewTransactionRepository(db, logger)
+	holdRepository := repositories.NewHoldRepository(db, logger, transactionRepository)
+
+	jhon := utils.CreateClientTest(1, "Jhon", "jhon@test.es")
+	shop := utils.CreateClientTest(2, "Shop", "shop@test.es")
+	assert.NoError(t, clientRepository.InsertClient(ctx, &jhon))
+	assert.NoError(t, clientRepository.InsertClient(ctx, &shop))
+	account := utils.CreateAccount(jhon.ID)
+	merchant := utils.CreateAccount(shop.ID)
+	assert.NoError(t, accountRepository.InsertAccount(ctx, &account))
+	assert.NoError(t, accountRepository.InsertAccount(ctx, &merchant))
+
+	deposit := utils.CreateTransaction(account.ID, sql.NullInt32{}, money.MustParse("100.00"), "ADD")
+	assert.NoError(t, transactionRepository.InsertTransactionLedgerTx(ctx, &deposit))
+
+	hold := holdentity.HoldEntity{
+		AccountID:   account.ID,
+		ToAccountID: sql.NullInt32{Int32: int32(merchant.ID), Valid: true},
+		Amount:      money.MustParse("80.00"),
+		ExpiresAt:   time.Now().Add(time.Hour),
+	}
+	assert.Nil(t, holdRepository.AuthorizeTx(ctx, &hold))
+
+	balance, err := transactionRepository.FetchAccountBalance(ctx, nil, account.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, "100.00", balance.Balance.String())
+	assert.Equal(t, "20.00", balance.Available().String())
+
+	// the held funds cannot be spent
+	withdrawal := utils.CreateTransaction(account.ID, sql.NullInt32{}, money.MustParse("30.00"), "WITHDRAWAL")
+	assert.NotNil(t, transactionRepository.InsertTransactionLedgerTx(ctx, &withdrawal))
+	second := holdentity.HoldEntity{AccountID: account.ID, Amount: money.MustParse("30.00"), ExpiresAt: time.Now().Add(time.Hour)}
+	assert.NotNil(t, holdRepository.AuthorizeTx(ctx, &second))
+
+	captureAmount := money.MustParse("50.00")
+	captured, transaction, err := holdRepository.CaptureTx(ctx, hold.ID, &captureAmount)
+	assert.Nil(t, err)
+	assert.Equal(t, holdentity.StatusCaptured, captured.Status)
+	assert.Equal(t, "50.00", captured.CapturedAmount.String())
+	assert.Equal(t, "TRANSFER", transaction.Type)
+	assert.Equal(t, int32(hold.ID), transaction.HoldID.Int32)
+
+	balance, err = transactionRepository.FetchAccountBalance(ctx, nil, account.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, "50.00", balance.Balance.String())
+	assert.Equal(t, "50.00", balance.Available().String())
+	merchantBalance, err := transactionRepository.FetchAccountBalance(ctx, nil, merchant.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, "50.00", merchantBalance.Balance.String())
+
+	// a captured hold cannot be captured nor voided again
+	_, _, err = holdRepository.CaptureTx(ctx, hold.ID, nil)
+	assert.NotNil(t, err)
+	_, err = holdRepository.VoidTx(ctx, hold.ID)
+	assert.NotNil(t, err)
+}
+
+// Voided and expired holds release their funds without posting ledger entries
+func TestHoldVoidAndExpiry(t *testing.T) {
+	ctx := context.Background()
+	db := startLedgerDatabase(t, ctx)
+	logger := app_logger.GetLogger()
+
+	clientRepository := repositories.NewClientRepository(db, logger)
+	accountRepository := repositories.NewAccountRepository(db, logger)
+	transactionRepository := repositories.NewTransactionRepository(db, logger)
+	holdRepository := repositories.NewHoldRepository(db, logger, transactionRepository)
+
+	client := utils.CreateClientTest(1, "Jhon", "jhon@test.es")
+	assert.NoError(t, clientRepository.InsertClient(ctx, &client))
+	account := utils.CreateAccount(client.ID)
+	assert.NoError(t, accountRepository.InsertAccount(ctx, &account))
+	deposit := utils.CreateTransaction(account.ID, sql.NullInt32{}, money.MustParse("100.00"), "ADD")
+	assert.NoError(t, transactionRepository.InsertTransactionLedgerTx(ctx, &deposit))
+
+	voided := holdentity.HoldEntity{AccountID: account.ID, Amount: money.MustParse("40.00"), ExpiresAt: time.Now().Add(time.Hour)}
+	expiring := holdentity.HoldEntity{AccountID: account.ID, Amount: money.MustParse("60.00"), ExpiresAt: time.Now().Add(time.Second)}
+	assert.Nil(t, holdRepository.AuthorizeTx(ctx, &voided))
+	assert.Nil(t, holdRepository.AuthorizeTx(ctx, &expiring))
+
+	balance, err := transactionRepository.FetchAccountBalance(ctx, nil, account.ID)
+	assert.Nil(t, err)
+	assert.True(t, balance.Available().IsZero())
+
+	hold, err := holdRepository.VoidTx(ctx, voided.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, holdentity.StatusVoided, hold.Status)
+
+	time.Sleep(2 * time.Second)
+	expired, err := holdRepository.ExpireHolds(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, expired)
+	_, _, err = holdRepository.CaptureTx(ctx, expiring.ID, nil)
+	assert.NotNil(t, err)
+
+	balance, err = transactionRepository.FetchAccountBalance(ctx, nil, account.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, "100.00", balance.Balance.String())
+	assert.Equal(t, "100.00", balance.Available().String())
+}
+
+// Captures lock the balances of the payer and the merchant in the same order as the transfers between them, so
+// capturing while the merchant pays the client back never deadlocks and the total amount of money is preserved
+func TestConcurrentCapturesAndTransfers(t *testing.T) {
+	ctx := context.Background()
+	db := startLedgerDatabase(t, ctx)
+	logger := app_logger.GetLogger()
+
+	clientRepository := repositories.NewClientRepository(db, logger)
+	accountRepository := repositories.NewAccountRepository(db, logger)
+	transactionRepository := repositories.NewTransactionRepository(db, logger)
+	holdRepository := repositories.NewHoldRepository(db, logger, transactionRepository)
+
+	shop := utils.CreateClientTest(1, "Shop", "shop@test.es")
+	jhon := utils.CreateClientTest(2, "Jhon", "jhon@test.es")
+	assert.NoError(t, clientRepository.InsertClient(ctx, &shop))
+	assert.NoError(t, clientRepository.InsertClient(ctx, &jhon))
+	// the merchant account has the lower id, so releasing the hold first would lock the balances out of order
+	merchant := utils.CreateAccount(shop.ID)
+	account := utils.CreateAccount(jhon.ID)
+	assert.NoError(t, accountRepository.InsertAccount(ctx, &merchant))
+	assert.NoError(t, accountRepository.InsertAccount(ctx, &account))
+	for _, accountID := range []int{account.ID, merchant.ID} {
+		deposit := utils.CreateTransaction(accountID, sql.NullInt32{}, money.MustParse("100.00"), "ADD")
+		assert.NoError(t, transactionRepository.InsertTransactionLedgerTx(ctx, &deposit))
+	}
+
+	const captures = 20
+	holds := make([]holdentity.HoldEntity, captures)
+	for i := range holds {
+		holds[i] = holdentity.HoldEntity{
+			AccountID:   account.ID,
+			ToAccountID: sql.NullInt32{Int32: int32(merchant.ID), Valid: true},
+			Amount:      money.MustParse("5.00"),
+			ExpiresAt:   time.Now().Add(time.Hour),
+		}
+		assert.Nil(t, holdRepository.AuthorizeTx(ctx, &holds[i]))
+	}
+
+	var wg sync.WaitGroup
+	var failed atomic.Int32
+	for i := range holds {
+		wg.Add(2)
+		go func(holdID int) {
+			defer wg.Done()
+			if _, _, err := holdRepository.CaptureTx(ctx, holdID, nil); err != nil {
+				failed.Add(1)
+			}
+		}(holds[i].ID)
+		go func() {
+			defer wg.Done()
+			refund := utils.CreateTransaction(merchant.ID, sql.NullInt32{Int32: int32(account.ID), Valid: true}, money.MustParse("1.00"), "TRANSFER")
+			if err := transactionRepository.InsertTransactionLedgerTx(ctx, &refund); err != nil {
+				failed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(0), failed.Load())
+
+	balance, err := transactionRepository.FetchAccountBalance(ctx, nil, account.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, "20.00", balance.Balance.String())
+	assert.True(t, balance.HeldAmount.IsZero())
+	merchantBalance, err := transactionRepository.FetchAccountBalance(ctx, nil, merchant.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, "180.00", merchantBalance.Balance.String())
+}
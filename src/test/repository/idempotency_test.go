@@ -0,0 +1,128 @@
+package repository_Test
+
+import (
+	"context"
+	"net/http"
+	services "src/api/service"
+	"src/domain/money"
+	errors "src/errors"
+	app_logger "src/logger"
+	"src/repositories"
+	"src/test/utils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type idempotentRequest struct {
+	Amount      money.Money `json:"amount"`
+	ToAccountId int         `json:"to_account_id"`
+}
+
+// A key is reserved by the first request, replays of the same request get its response
+// and any other use of the key is a conflict
+func TestIdempotencyKeyReplayAndConflicts(t *testing.T) {
+	ctx := context.Background()
+	db := startLedgerDatabase(t, ctx)
+	logger := app_logger.GetLogger()
+	clientRepository := repositories.NewClientRepository(db, logger)
+	service := services.NewIdempotencyService(repositories.NewIdempotencyRepository(db, logger), nil, time.Hour, logger)
+
+	client := utils.CreateClientTest(1, "Jhon", "jhon@test.es")
+	assert.NoError(t, clientRepository.InsertClient(ctx, &client))
+	request := idempotentRequest{Amount: money.MustParse("10.00"), ToAccountId: 2}
+
+	entity, stored, appErr := service.Begin(ctx, client.ID, "key-1", request)
+	assert.Nil(t, appErr)
+	assert.Nil(t, stored)
+	assert.NotZero(t, entity.ID)
+
+	// the first request has not finished yet
+	_, _, appErr = service.Begin(ctx, client.ID, "key-1", request)
+	assert.IsType(t, &errors.ErrConflict{}, appErr)
+
+	assert.Nil(t, service.Complete(ctx, entity, http.StatusOK, []byte(`{"id":1}`)))
+	_, stored, appErr = service.Begin(ctx, client.ID, "key-1", request)
+	assert.Nil(t, appErr)
+	assert.Equal(t, &services.IdempotentResponse{Status: http.StatusOK, Body: `{"id":1}`}, stored)
+
+	other := idempotentRequest{Amount: money.MustParse("20.00"), ToAccountId: 2}
+	_, _, appErr = service.Begin(ctx, client.ID, "key-1", other)
+	assert.IsType(t, &errors.ErrConflict{}, appErr)
+
+	// keys are per client
+	otherClient := utils.CreateClientTest(2, "Jane", "jane@test.es")
+	assert.NoError(t, clientRepository.InsertClient(ctx, &otherClient))
+	_, stored, appErr = service.Begin(ctx, otherClient.ID, "key-1", other)
+	assert.Nil(t, appErr)
+	assert.Nil(t, stored)
+}
+
+// A failed request releases its key and an expired key is taken over, in both cases
+// the key can be used again even with another request
+func TestIdempotencyKeyReleasedAndExpired(t *testing.T) {
+	ctx := context.Background()
+	db := startLedgerDatabase(t, ctx)
+	logger := app_logger.GetLogger()
+	clientRepository := repositories.NewClientRepository(db, logger)
+	idempotencyRepository := repositories.NewIdempotencyRepository(db, logger)
+	service := services.NewIdempotencyService(idempotencyRepository, nil, time.Hour, logger)
+
+	client := utils.CreateClientTest(1, "Jhon", "jhon@test.es")
+	assert.NoError(t, clientRepository.InsertClient(ctx, &client))
+	request := idempotentRequest{Amount: money.MustParse("10.00"), ToAccountId: 2}
+	retry := idempotentRequest{Amount: money.MustParse("15.00"), ToAccountId: 2}
+
+	entity, _, appErr := service.Begin(ctx, client.ID, "key-1", request)
+	assert.Nil(t, appErr)
+	assert.Nil(t, service.Release(ctx, entity))
+	_, appErr = idempotencyRepository.FetchByKey(ctx, client.ID, "key-1")
+	assert.IsType(t, &errors.ErrNotFound{}, appErr)
+	retried, stored, appErr := service.Begin(ctx, client.ID, "key-1", retry)
+	assert.Nil(t, appErr)
+	assert.Nil(t, stored)
+	assert.NotZero(t, retried.ID)
+
+	// a completed key is not released
+	assert.Nil(t, service.Complete(ctx, retried, http.StatusOK, []byte(`{"id":1}`)))
+	assert.Nil(t, service.Release(ctx, retried))
+	_, stored, appErr = service.Begin(ctx, client.ID, "key-1", retry)
+	assert.Nil(t, appErr)
+	assert.NotNil(t, stored)
+
+	expiring := services.NewIdempotencyService(idempotencyRepository, nil, -time.Minute, logger)
+	_, _, appErr = expiring.Begin(ctx, client.ID, "key-2", request)
+	assert.Nil(t, appErr)
+	takenOver, stored, appErr := service.Begin(ctx, client.ID, "key-2", retry)
+	assert.Nil(t, appErr)
+	assert.Nil(t, stored)
+	current, appErr := idempotencyRepository.FetchByKey(ctx, client.ID, "key-2")
+	assert.Nil(t, appErr)
+	assert.Equal(t, takenOver.ID, current.ID)
+	assert.False(t, current.IsCompleted())
+}
+
+// The response is stored once the request is performed, even if the client has gone away meanwhile,
+// so the key does not stay in progress
+func TestIdempotencyKeyCompletedAfterCancel(t *testing.T) {
+	ctx := context.Background()
+	db := startLedgerDatabase(t, ctx)
+	logger := app_logger.GetLogger()
+	clientRepository := repositories.NewClientRepository(db, logger)
+	service := services.NewIdempotencyService(repositories.NewIdempotencyRepository(db, logger), nil, time.Hour, logger)
+
+	client := utils.CreateClientTest(1, "Jhon", "jhon@test.es")
+	assert.NoError(t, clientRepository.InsertClient(ctx, &client))
+	request := idempotentRequest{Amount: money.MustParse("10.00"), ToAccountId: 2}
+
+	requestCtx, cancel := context.WithCancel(ctx)
+	entity, _, appErr := service.Begin(requestCtx, client.ID, "key-1", request)
+	assert.Nil(t, appErr)
+	cancel()
+	assert.Nil(t, service.Complete(requestCtx, entity, http.StatusOK, []byte(`{"id":1}`)))
+
+	_, stored, appErr := service.Begin(ctx, client.ID, "key-1", request)
+	assert.Nil(t, appErr)
+	assert.Equal(t, &services.IdempotentResponse{Status: http.StatusOK, Body: `{"id":1}`}, stored)
+}
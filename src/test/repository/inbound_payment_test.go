@@ -0,0 +1,140 @@
+package repository_Test
+
+import (
+	"context"
+	"database/sql"
+	accountentity "src/domain/account"
+	"src/domain/money"
+	paymententity "src/domain/payment"
+	errors "src/errors"
+	app_logger "src/logger"
+	"src/repositories"
+	"src/test/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// A SEPA credit moves from the settlement account to the creditor. Credits to an unknown or closed account
+// wait in suspense until bank staff resolves or returns them.
+func TestInboundPayments(t *testing.T) {
+	ctx := context.Background()
+	db := startLedgerDatabase(t, ctx)
+	logger := app_logger.GetLogger()
+
+	clientRepository := repositories.NewClientRepository(db, logger)
+	accountRepository := repositories.NewAccountRepository(db, logger)
+	transactionRepository := repositories.NewTransactionRepository(db, logger)
+	accountStatusRepository := repositories.NewAccountStatusRepository(db, logger, transactionRepository, repositories.NewInterestRepository(db, logger, transactionRepository))
+	inboundPaymentRepository := repositories.NewInboundPaymentRepository(db, logger, transactionRepository)
+	reconciliationRepository := repositories.NewReconciliationRepository(db, logger)
+
+	client := utils.CreateClientTest(1, "Jhon", "jhon@test.es")
+	assert.NoError(t, clientRepository.InsertClient(ctx, &client))
+	account := utils.CreateAccount(client.ID)
+	assert.NoError(t, accountRepository.InsertAccount(ctx, &account))
+	closed := utils.CreateAccount(client.ID)
+	assert.NoError(t, accountRepository.InsertAccount(ctx, &closed))
+	_, _, err := accountStatusRepository.CloseTx(ctx, closed.ID, "customer request", nil)
+	assert.Nil(t, err)
+
+	suspenseID, err := transactionRepository.FetchInternalAccountId(ctx, nil, accountentity.InternalSuspense, "EUR")
+	assert.Nil(t, err)
+	settlementID, err := transactionRepository.FetchInternalAccountId(ctx, nil, accountentity.InternalSepaSettlement, "EUR")
+	assert.Nil(t, err)
+	balanceOf := func(accountID int) string {
+		balance, err := transactionRepository.FetchAccountBalance(ctx, nil, accountID)
+		assert.Nil(t, err)
+		return balance.Balance.String()
+	}
+
+	newPayment := func(sequence int, creditorIban string, amount string) paymententity.InboundPaymentEntity {
+		return paymententity.InboundPaymentEntity{
+			MessageID:    "IN-1",
+			MessageType:  paymententity.MessagePacs008,
+			Sequence:     sequence,
+			EndToEndID:   "e2e",
+			DebtorIban:   sql.NullString{String: "ES7921000813610123456789", Valid: true},
+			DebtorName:   sql.NullString{String: "Luis Pérez", Valid: true},
+			CreditorIban: creditorIban,
+			Amount:       money.MustParse(amount),
+			Currency:     "EUR",
+		}
+	}
+	credited := newPayment(1, account.AccountNumber, "100.00")
+	posted, err := inboundPaymentRepository.InsertInboundPayment(ctx, &credited)
+	assert.Nil(t, err)
+	assert.True(t, posted)
+	assert.Equal(t, paymententity.InboundStatusCredited, credited.Status)
+	assert.Equal(t, int32(account.ID), credited.AccountID.Int32)
+	assert.Equal(t, "100.00", balanceOf(account.ID))
+	assert.Equal(t, "-100.00", balanceOf(settlementID))
+
+	// the same credit read again is skipped
+	again := newPayment(1, account.AccountNumber, "100.00")
+	posted, err = inboundPaymentRepository.InsertInboundPayment(ctx, &again)
+	assert.Nil(t, err)
+	assert.False(t, posted)
+	assert.Equal(t, "100.00", balanceOf(account.ID))
+
+	unknown := newPayment(2, "ES0000000000000000000000", "30.00")
+	_, err = inboundPaymentRepository.InsertInboundPayment(ctx, &unknown)
+	assert.Nil(t, err)
+	assert.Equal(t, paymententity.InboundStatusException, unknown.Status)
+	assert.Equal(t, paymententity.ExceptionAccountNotFound, unknown.ExceptionReason.String)
+	toClosed := newPayment(3, closed.AccountNumber, "20.00")
+	_, err = inboundPaymentRepository.InsertInboundPayment(ctx, &toClosed)
+	assert.Nil(t, err)
+	assert.Equal(t, paymententity.InboundStatusException, toClosed.Status)
+	assert.Equal(t, paymententity.ExceptionAccountNotActive, toClosed.ExceptionReason.String)
+	assert.Equal(t, "50.00", balanceOf(suspenseID))
+	assert.Equal(t, "0.00", balanceOf(closed.ID))
+
+	exceptions, err := inboundPaymentRepository.FetchInboundPayments(ctx, paymententity.InboundStatusException)
+	assert.Nil(t, err)
+	assert.Len(t, exceptions, 2)
+	all, err := inboundPaymentRepository.FetchInboundPayments(ctx, "")
+	assert.Nil(t, err)
+	assert.Len(t, all, 3)
+
+	_, err = inboundPaymentRepository.ResolveInboundPayment(ctx, credited.ID, account.AccountNumber)
+	assert.IsType(t, &errors.ErrConflict{}, err)
+	_, err = inboundPaymentRepository.ResolveInboundPayment(ctx, unknown.ID, closed.AccountNumber)
+	assert.IsType(t, &errors.ErrAccountNotActive{}, err)
+	resolved, err := inboundPaymentRepository.ResolveInboundPayment(ctx, unknown.ID, account.AccountNumber)
+	assert.Nil(t, err)
+	assert.Equal(t, paymententity.InboundStatusResolved, resolved.Status)
+	assert.True(t, resolved.ResolutionID.Valid)
+	assert.Equal(t, "130.00", balanceOf(account.ID))
+	assert.Equal(t, "20.00", balanceOf(suspenseID))
+
+	_, err = inboundPaymentRepository.ReturnInboundPayment(ctx, toClosed.ID, "XX99")
+	assert.IsType(t, &errors.ErrBadRequest{}, err)
+	returned, err := inboundPaymentRepository.ReturnInboundPayment(ctx, toClosed.ID, "")
+	assert.Nil(t, err)
+	assert.Equal(t, paymententity.InboundStatusReturned, returned.Status)
+	assert.Equal(t, "AC04", returned.ReturnReason.String)
+	assert.Equal(t, "0.00", balanceOf(suspenseID))
+	assert.Equal(t, "-130.00", balanceOf(settlementID))
+	_, err = inboundPaymentRepository.ReturnInboundPayment(ctx, toClosed.ID, "")
+	assert.IsType(t, &errors.ErrConflict{}, err)
+
+	// returns go out once; a released batch goes back to the queue
+	returns, err := inboundPaymentRepository.ClaimPendingReturns(ctx, "RET-1", 10)
+	assert.Nil(t, err)
+	assert.Len(t, returns, 1)
+	assert.Equal(t, toClosed.ID, returns[0].ID)
+	assert.Nil(t, inboundPaymentRepository.ReleaseReturns(ctx, "RET-1"))
+	returns, err = inboundPaymentRepository.ClaimPendingReturns(ctx, "RET-2", 10)
+	assert.Nil(t, err)
+	assert.Len(t, returns, 1)
+	returns, err = inboundPaymentRepository.ClaimPendingReturns(ctx, "RET-3", 10)
+	assert.Nil(t, err)
+	assert.Empty(t, returns)
+
+	assert.Nil(t, reconciliationRepository.RunInSnapshot(ctx, func(tx *sql.Tx) errors.AppError {
+		unbalanced, err := reconciliationRepository.FetchUnbalancedTransactions(ctx, tx)
+		assert.Empty(t, unbalanced)
+		return err
+	}))
+}
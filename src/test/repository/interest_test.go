@@ -0,0 +1,229 @@
+package repository_Test
+
+import (
+	"context"
+	"database/sql"
+	accountentity "src/domain/account"
+	interestentity "src/domain/interest"
+	"src/domain/money"
+	scheduledtransferentity "src/domain/scheduled_transfer"
+	transaction_entity "src/domain/transaction"
+	errors "src/errors"
+	app_logger "src/logger"
+	"src/repositories"
+	"src/test/utils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Savings accounts accrue the interest of the rate in force each day, so a rate changed in the middle of the month
+// applies from its effective date, and the month is paid once from the interest expense account
+func TestInterestAccrual(t *testing.T) {
+	ctx := context.Background()
+	db := startLedgerDatabase(t, ctx)
+	logger := app_logger.GetLogger()
+
+	clientRepository := repositories.NewClientRepository(db, logger)
+	accountRepository := repositories.NewAccountRepository(db, logger)
+	transactionRepository := repositories.NewTransactionRepository(db, logger)
+	interestRepository := repositories.NewInterestRepository(db, logger, transactionRepository)
+	reconciliationRepository := repositories.NewReconciliationRepository(db, logger)
+
+	client := utils.CreateClientTest(1, "Jhon", "jhon@test.es")
+	assert.NoError(t, clientRepository.InsertClient(ctx, &client))
+	savings := utils.CreateAccount(client.ID)
+	savings.Product = "SAVINGS"
+	assert.NoError(t, accountRepository.InsertAccount(ctx, &savings))
+	current := utils.CreateAccount(client.ID)
+	assert.NoError(t, accountRepository.InsertAccount(ctx, &current))
+	for _, account := range []accountentity.AccountEntity{savings, current} {
+		deposit := utils.CreateTransaction(account.ID, sql.NullInt32{}, money.MustParse("1000.00"), "ADD")
+		assert.Nil(t, transactionRepository.InsertTransactionLedgerTx(ctx, &deposit))
+	}
+
+	today := scheduledtransferentity.Today()
+	tomorrow := today.AddDate(0, 0, 1)
+	current365 := interestentity.InterestRateEntity{
+		Product:       "SAVINGS",
+		Currency:      "EUR",
+		Rate:          "0.0365",
+		DayCount:      interestentity.DayCountACT365,
+		EffectiveFrom: today.AddDate(0, 0, -10),
+	}
+	assert.Nil(t, interestRepository.UpsertInterestRate(ctx, &current365))
+	next360 := interestentity.InterestRateEntity{
+		Product:       "SAVINGS",
+		Currency:      "EUR",
+		Rate:          "0.073",
+		DayCount:      interestentity.DayCountACT360,
+		EffectiveFrom: tomorrow,
+	}
+	assert.Nil(t, interestRepository.UpsertInterestRate(ctx, &next360))
+
+	// 1000.00 * 3.65% / 365 = 0.10
+	accrued, err := interestRepository.AccrueInterest(ctx, today)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, accrued)
+	accrued, err = interestRepository.AccrueInterest(ctx, today)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, accrued)
+	// 1000.00 * 7.3% / 360 = 0.2027777778
+	accrued, err = interestRepository.AccrueInterest(ctx, tomorrow)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, accrued)
+
+	accruals, err := interestRepository.FetchPendingAccruals(ctx, savings.ID)
+	assert.Nil(t, err)
+	assert.Len(t, accruals, 2)
+	assert.Equal(t, "0.1000000000", accruals[0].Amount)
+	assert.Equal(t, "0.2027777778", accruals[1].Amount)
+	assert.Equal(t, interestentity.DayCountACT360, accruals[1].DayCount)
+
+	assert.IsType(t, &errors.ErrConflict{}, interestRepository.DeleteInterestRate(ctx, current365.ID, today))
+	assert.Nil(t, interestRepository.DeleteInterestRate(ctx, next360.ID, today))
+
+	// 0.3027777778 paid as 0.30
+	capitalised, err := interestRepository.CapitaliseInterest(ctx, interestentity.MonthStart(tomorrow))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, capitalised)
+	capitalised, err = interestRepository.CapitaliseInterest(ctx, interestentity.MonthStart(tomorrow))
+	assert.Nil(t, err)
+	assert.Equal(t, 0, capitalised)
+
+	accruals, err = interestRepository.FetchPendingAccruals(ctx, savings.ID)
+	assert.Nil(t, err)
+	assert.Empty(t, accruals)
+	balance, err := transactionRepository.FetchAccountBalance(ctx, nil, savings.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, "1000.30", balance.Balance.String())
+	balance, err = transactionRepository.FetchAccountBalance(ctx, nil, current.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, "1000.00", balance.Balance.String())
+	expenseID, err := transactionRepository.FetchInternalAccountId(ctx, nil, accountentity.InternalInterestExpense, "EUR")
+	assert.Nil(t, err)
+	expense, err := transactionRepository.FetchAccountBalance(ctx, nil, expenseID)
+	assert.Nil(t, err)
+	assert.Equal(t, "-0.30", expense.Balance.String())
+
+	history, err := transactionRepository.GetTransactions(ctx, savings.ID, transaction_entity.TransactionFilter{Type: transaction_entity.CreditInterestType}, 1, 10)
+	assert.Nil(t, err)
+	assert.Len(t, history.Items, 1)
+
+	assert.Nil(t, reconciliationRepository.RunInSnapshot(ctx, func(tx *sql.Tx) errors.AppError {
+		unbalanced, err := reconciliationRepository.FetchUnbalancedTransactions(ctx, tx)
+		assert.Empty(t, unbalanced)
+		return err
+	}))
+}
+
+// The days after the last one completed are accrued one at a time, so the days missed while the service was down are not lost
+func TestInterestAccrualCatchUp(t *testing.T) {
+	ctx := context.Background()
+	db := startLedgerDatabase(t, ctx)
+	logger := app_logger.GetLogger()
+
+	clientRepository := repositories.NewClientRepository(db, logger)
+	accountRepository := repositories.NewAccountRepository(db, logger)
+	transactionRepository := repositories.NewTransactionRepository(db, logger)
+	interestRepository := repositories.NewInterestRepository(db, logger, transactionRepository)
+
+	client := utils.CreateClientTest(1, "Jhon", "jhon@test.es")
+	assert.NoError(t, clientRepository.InsertClient(ctx, &client))
+	savings := utils.CreateAccount(client.ID)
+	savings.Product = "SAVINGS"
+	assert.NoError(t, accountRepository.InsertAccount(ctx, &savings))
+	deposit := utils.CreateTransaction(savings.ID, sql.NullInt32{}, money.MustParse("1000.00"), "ADD")
+	assert.Nil(t, transactionRepository.InsertTransactionLedgerTx(ctx, &deposit))
+
+	today := scheduledtransferentity.Today()
+	// without a rate nothing accrues, the day is done all the same
+	accrued, err := interestRepository.AccrueInterestUntil(ctx, today.AddDate(0, 0, -1))
+	assert.Nil(t, err)
+	assert.Equal(t, 0, accrued)
+	var lastDay time.Time
+	assert.NoError(t, db.QueryRowContext(ctx, `SELECT last_day FROM daily_job_runs WHERE job = 'INTEREST_ACCRUAL'`).Scan(&lastDay))
+	assert.Equal(t, today.AddDate(0, 0, -1).Format(time.DateOnly), lastDay.Format(time.DateOnly))
+
+	rate := interestentity.InterestRateEntity{
+		Product:       "SAVINGS",
+		Currency:      "EUR",
+		Rate:          "0.0365",
+		DayCount:      interestentity.DayCountACT365,
+		EffectiveFrom: today.AddDate(0, 0, -10),
+	}
+	assert.Nil(t, interestRepository.UpsertInterestRate(ctx, &rate))
+
+	accrued, err = interestRepository.AccrueInterestUntil(ctx, today)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, accrued)
+	accrued, err = interestRepository.AccrueInterestUntil(ctx, today.AddDate(0, 0, 3))
+	assert.Nil(t, err)
+	assert.Equal(t, 3, accrued)
+	accrued, err = interestRepository.AccrueInterestUntil(ctx, today.AddDate(0, 0, 3))
+	assert.Nil(t, err)
+	assert.Equal(t, 0, accrued)
+
+	accruals, err := interestRepository.FetchPendingAccruals(ctx, savings.ID)
+	assert.Nil(t, err)
+	assert.Len(t, accruals, 4)
+	for i, accrual := range accruals {
+		assert.Equal(t, today.AddDate(0, 0, i).Format(time.DateOnly), accrual.AccrualDate.Format(time.DateOnly))
+	}
+}
+
+// Closing an account pays the interest accrued since the last capitalisation before the balance is settled
+func TestCloseAccountPaysPendingInterest(t *testing.T) {
+	ctx := context.Background()
+	db := startLedgerDatabase(t, ctx)
+	logger := app_logger.GetLogger()
+
+	clientRepository := repositories.NewClientRepository(db, logger)
+	accountRepository := repositories.NewAccountRepository(db, logger)
+	transactionRepository := repositories.NewTransactionRepository(db, logger)
+	interestRepository := repositories.NewInterestRepository(db, logger, transactionRepository)
+	accountStatusRepository := repositories.NewAccountStatusRepository(db, logger, transactionRepository, interestRepository)
+
+	client := utils.CreateClientTest(1, "Jhon", "jhon@test.es")
+	assert.NoError(t, clientRepository.InsertClient(ctx, &client))
+	savings := utils.CreateAccount(client.ID)
+	savings.Product = "SAVINGS"
+	assert.NoError(t, accountRepository.InsertAccount(ctx, &savings))
+	settlementAccount := utils.CreateAccount(client.ID)
+	assert.NoError(t, accountRepository.InsertAccount(ctx, &settlementAccount))
+	deposit := utils.CreateTransaction(savings.ID, sql.NullInt32{}, money.MustParse("1000.00"), "ADD")
+	assert.Nil(t, transactionRepository.InsertTransactionLedgerTx(ctx, &deposit))
+
+	today := scheduledtransferentity.Today()
+	rate := interestentity.InterestRateEntity{
+		Product:       "SAVINGS",
+		Currency:      "EUR",
+		Rate:          "0.0365",
+		DayCount:      interestentity.DayCountACT365,
+		EffectiveFrom: today.AddDate(0, 0, -10),
+	}
+	assert.Nil(t, interestRepository.UpsertInterestRate(ctx, &rate))
+	// 1000.00 * 3.65% / 365 = 0.10
+	accrued, err := interestRepository.AccrueInterest(ctx, today)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, accrued)
+
+	_, settlement, err := accountStatusRepository.CloseTx(ctx, savings.ID, "customer request", &settlementAccount.AccountNumber)
+	assert.Nil(t, err)
+	if assert.NotNil(t, settlement) {
+		assert.Equal(t, "1000.10", settlement.Amount.String())
+	}
+
+	accruals, err := interestRepository.FetchPendingAccruals(ctx, savings.ID)
+	assert.Nil(t, err)
+	assert.Empty(t, accruals)
+	balance, err := transactionRepository.FetchAccountBalance(ctx, nil, savings.ID)
+	assert.Nil(t, err)
+	assert.True(t, balance.Balance.IsZero())
+	expenseID, err := transactionRepository.FetchInternalAccountId(ctx, nil, accountentity.InternalInterestExpense, "EUR")
+	assert.Nil(t, err)
+	expense, err := transactionRepository.FetchAccountBalance(ctx, nil, expenseID)
+	assert.Nil(t, err)
+	assert.Equal(t, "-0.10", expense.Balance.String())
+}
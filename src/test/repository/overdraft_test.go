@@ -0,0 +1,113 @@
+package repository_Test
+
+import (
+	"context"
+	"database/sql"
+	"math/big"
+	accountentity "src/domain/account"
+	"src/domain/money"
+	scheduledtransferentity "src/domain/scheduled_transfer"
+	transaction_entity "src/domain/transaction"
+	errors "src/errors"
+	app_logger "src/logger"
+	"src/repositories"
+	"src/test/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Withdrawals can go below zero down to the limit of the overdraft in force, and the debit interest
+// of a day is posted once against the interest income account
+func TestOverdraft(t *testing.T) {
+	ctx := context.Background()
+	db := startLedgerDatabase(t, ctx)
+	logger := app_logger.GetLogger()
+
+	clientRepository := repositories.NewClientRepository(db, logger)
+	accountRepository := repositories.NewAccountRepository(db, logger)
+	transactionRepository := repositories.NewTransactionRepository(db, logger)
+	overdraftRepository := repositories.NewOverdraftRepository(db, logger, transactionRepository)
+	reconciliationRepository := repositories.NewReconciliationRepository(db, logger)
+
+	client := utils.CreateClientTest(1, "Jhon", "jhon@test.es")
+	assert.NoError(t, clientRepository.InsertClient(ctx, &client))
+	account := utils.CreateAccount(client.ID)
+	assert.NoError(t, accountRepository.InsertAccount(ctx, &account))
+
+	deposit := utils.CreateTransaction(account.ID, sql.NullInt32{}, money.MustParse("100.00"), "ADD")
+	assert.Nil(t, transactionRepository.InsertTransactionLedgerTx(ctx, &deposit))
+
+	today := scheduledtransferentity.Today()
+	// nobody was overdrawn yesterday, the day is done all the same
+	charged, err := overdraftRepository.ChargeDebitInterestUntil(ctx, today.AddDate(0, 0, -1), new(big.Rat))
+	assert.Nil(t, err)
+	assert.Equal(t, 0, charged)
+
+	// an overdraft that is not in force yet
+	overdraft := accountentity.OverdraftEntity{
+		AccountID:    account.ID,
+		Limit:        money.MustParse("500.00"),
+		InterestRate: "0.073",
+		ValidFrom:    today.AddDate(0, 0, 1),
+	}
+	assert.Nil(t, overdraftRepository.UpsertOverdraft(ctx, &overdraft))
+	withdrawal := utils.CreateTransaction(account.ID, sql.NullInt32{}, money.MustParse("600.00"), "WITHDRAWAL")
+	assert.IsType(t, &errors.ErrNotEnoughFunds{}, transactionRepository.InsertTransactionLedgerTx(ctx, &withdrawal))
+
+	overdraft.ValidFrom = today
+	assert.Nil(t, overdraftRepository.UpsertOverdraft(ctx, &overdraft))
+	withdrawal = utils.CreateTransaction(account.ID, sql.NullInt32{}, money.MustParse("600.00"), "WITHDRAWAL")
+	assert.Nil(t, transactionRepository.InsertTransactionLedgerTx(ctx, &withdrawal))
+	withdrawal = utils.CreateTransaction(account.ID, sql.NullInt32{}, money.MustParse("0.01"), "WITHDRAWAL")
+	assert.IsType(t, &errors.ErrNotEnoughFunds{}, transactionRepository.InsertTransactionLedgerTx(ctx, &withdrawal))
+
+	balance, err := transactionRepository.FetchAccountBalance(ctx, nil, account.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, "-500.00", balance.Balance.String())
+	assert.Equal(t, "500.00", balance.OverdraftLimit.String())
+	assert.True(t, balance.OverdraftHeadroom().IsZero())
+
+	// 500.00 * 7.3% / 365 = 0.10
+	charged, err = overdraftRepository.ChargeDebitInterest(ctx, today, new(big.Rat))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, charged)
+	charged, err = overdraftRepository.ChargeDebitInterest(ctx, today, new(big.Rat))
+	assert.Nil(t, err)
+	assert.Equal(t, 0, charged)
+
+	balance, err = transactionRepository.FetchAccountBalance(ctx, nil, account.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, "-500.10", balance.Balance.String())
+	incomeID, err := transactionRepository.FetchInternalAccountId(ctx, nil, accountentity.InternalInterestIncome, "EUR")
+	assert.Nil(t, err)
+	income, err := transactionRepository.FetchAccountBalance(ctx, nil, incomeID)
+	assert.Nil(t, err)
+	assert.Equal(t, "0.10", income.Balance.String())
+
+	history, err := transactionRepository.GetTransactions(ctx, account.ID, transaction_entity.TransactionFilter{Type: transaction_entity.DebitInterestType}, 1, 10)
+	assert.Nil(t, err)
+	assert.Len(t, history.Items, 1)
+
+	// the days after the last one completed are caught up, one at a time, today charged already
+	charged, err = overdraftRepository.ChargeDebitInterestUntil(ctx, today.AddDate(0, 0, 2), new(big.Rat))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, charged)
+	charged, err = overdraftRepository.ChargeDebitInterestUntil(ctx, today.AddDate(0, 0, 2), new(big.Rat))
+	assert.Nil(t, err)
+	assert.Equal(t, 0, charged)
+	balance, err = transactionRepository.FetchAccountBalance(ctx, nil, account.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, "-500.30", balance.Balance.String())
+
+	assert.Nil(t, overdraftRepository.DeleteOverdraft(ctx, account.ID))
+	balance, err = transactionRepository.FetchAccountBalance(ctx, nil, account.ID)
+	assert.Nil(t, err)
+	assert.True(t, balance.OverdraftLimit.IsZero())
+
+	assert.Nil(t, reconciliationRepository.RunInSnapshot(ctx, func(tx *sql.Tx) errors.AppError {
+		unbalanced, err := reconciliationRepository.FetchUnbalancedTransactions(ctx, tx)
+		assert.Empty(t, unbalanced)
+		return err
+	}))
+}
@@ -0,0 +1,86 @@
+package repository_Test
+
+import (
+	"context"
+	services "src/api/service"
+	payeeentity "src/domain/payee"
+	errors "src/errors"
+	app_logger "src/logger"
+	"src/repositories"
+	"src/test/utils"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// The holder of an account of the bank is checked, other banks cannot be, and every check counts
+// towards the limit of the client even when they are sent at once
+func TestPayeeVerification(t *testing.T) {
+	ctx := context.Background()
+	db := startLedgerDatabase(t, ctx)
+	logger := app_logger.GetLogger()
+	wrapper := repositories.RepositoryWrapper{
+		ClientRepository:            repositories.NewClientRepository(db, logger),
+		AccountRepository:           repositories.NewAccountRepository(db, logger),
+		PayeeVerificationRepository: repositories.NewPayeeVerificationRepository(db, logger),
+	}
+	payeeService := services.NewPayeeService(wrapper, []payeeentity.RateLimit{
+		{Max: 5, Window: time.Hour},
+		{Max: 100, Window: 24 * time.Hour},
+	}, logger)
+
+	payer := utils.CreateClientTest(1, "Jhon", "jhon@test.es")
+	assert.NoError(t, wrapper.ClientRepository.InsertClient(ctx, &payer))
+	payee := utils.CreateClientTest(2, "Juan", "juan@test.es")
+	assert.NoError(t, wrapper.ClientRepository.InsertClient(ctx, &payee))
+	account := utils.CreateAccount(payee.ID)
+	account.AccountNumber = "ES9121000418450200051332"
+	assert.NoError(t, wrapper.AccountRepository.InsertAccount(ctx, &account))
+
+	result, err := payeeService.VerifyPayee(ctx, payer.ID, "es91 2100 0418 4502 0005 1332", "Juan Doe Smith")
+	assert.Nil(t, err)
+	assert.Equal(t, payeeentity.ResultMatch, result.Result)
+	assert.Nil(t, result.SuggestedName)
+
+	result, err = payeeService.VerifyPayee(ctx, payer.ID, "ES9121000418450200051332", "Juan Doe Smyth")
+	assert.Nil(t, err)
+	assert.Equal(t, payeeentity.ResultCloseMatch, result.Result)
+	assert.Equal(t, "J*** D** S****", *result.SuggestedName)
+
+	result, err = payeeService.VerifyPayee(ctx, payer.ID, "DE89370400440532013000", "Juan Doe")
+	assert.Nil(t, err)
+	assert.Equal(t, payeeentity.ResultNotAvailable, result.Result)
+
+	// an invalid IBAN is not counted
+	_, err = payeeService.VerifyPayee(ctx, payer.ID, "ES9121000418450200051333", "Juan Doe")
+	assert.IsType(t, &errors.ErrBadRequest{}, err)
+
+	var succeeded, limited atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := payeeService.VerifyPayee(ctx, payer.ID, "ES9121000418450200051332", "Pedro Doe")
+			if err == nil {
+				succeeded.Add(1)
+				return
+			}
+			if tooMany, ok := err.(*errors.ErrTooManyRequests); assert.True(t, ok, err.Error()) {
+				assert.True(t, tooMany.RetryAfter > 59*time.Minute && tooMany.RetryAfter <= time.Hour, tooMany.RetryAfter)
+				limited.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(2), succeeded.Load())
+	assert.Equal(t, int32(8), limited.Load())
+
+	// the limits are per client
+	result, err = payeeService.VerifyPayee(ctx, payee.ID, "ES9121000418450200051332", "Juan Doe")
+	assert.Nil(t, err)
+	assert.Equal(t, payeeentity.ResultMatch, result.Result)
+}
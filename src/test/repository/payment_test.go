@@ -0,0 +1,140 @@
+package repository_Test
+
+import (
+	"context"
+	"database/sql"
+	goerrors "errors"
+	accountentity "src/domain/account"
+	"src/domain/money"
+	paymententity "src/domain/payment"
+	transaction_entity "src/domain/transaction"
+	errors "src/errors"
+	app_logger "src/logger"
+	"src/repositories"
+	"src/test/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// A SEPA transfer debits the client into the clearing account. Once settled the amount moves to the
+// settlement account, and once rejected the client gets it back.
+func TestOutboundPayments(t *testing.T) {
+	ctx := context.Background()
+	db := startLedgerDatabase(t, ctx)
+	logger := app_logger.GetLogger()
+
+	clientRepository := repositories.NewClientRepository(db, logger)
+	accountRepository := repositories.NewAccountRepository(db, logger)
+	transactionRepository := repositories.NewTransactionRepository(db, logger)
+	paymentRepository := repositories.NewPaymentRepository(db, logger, transactionRepository)
+	reconciliationRepository := repositories.NewReconciliationRepository(db, logger)
+
+	client := utils.CreateClientTest(1, "Jhon", "jhon@test.es")
+	assert.NoError(t, clientRepository.InsertClient(ctx, &client))
+	account := utils.CreateAccount(client.ID)
+	assert.NoError(t, accountRepository.InsertAccount(ctx, &account))
+	deposit := utils.CreateTransaction(account.ID, sql.NullInt32{}, money.MustParse("100.00"), "ADD")
+	assert.Nil(t, transactionRepository.InsertTransactionLedgerTx(ctx, &deposit))
+
+	clearingID, err := transactionRepository.FetchInternalAccountId(ctx, nil, accountentity.InternalSepaClearing, "EUR")
+	assert.Nil(t, err)
+	settlementID, err := transactionRepository.FetchInternalAccountId(ctx, nil, accountentity.InternalSepaSettlement, "EUR")
+	assert.Nil(t, err)
+	balanceOf := func(accountID int) string {
+		balance, err := transactionRepository.FetchAccountBalance(ctx, nil, accountID)
+		assert.Nil(t, err)
+		return balance.Balance.String()
+	}
+
+	newPayment := func(amount string) (paymententity.OutboundPaymentEntity, transaction_entity.TransactionEntity) {
+		payment := paymententity.OutboundPaymentEntity{
+			AccountID:    account.ID,
+			CreditorIban: "ES7921000813610123456789",
+			CreditorName: "Luis Pérez",
+			Amount:       money.MustParse(amount),
+		}
+		var transaction transaction_entity.TransactionEntity
+		assert.Nil(t, paymentRepository.InsertOutboundPayment(ctx, &payment, &transaction))
+		return payment, transaction
+	}
+	settled, debit := newPayment("30.00")
+	rejected, _ := newPayment("20.00")
+	assert.Equal(t, paymententity.StatusPending, settled.Status)
+	assert.Equal(t, "Jhon Doe Smith", settled.DebtorName)
+	assert.Equal(t, account.AccountNumber, settled.DebtorIban)
+	assert.Equal(t, transaction_entity.SepaTransferType, debit.Type)
+	assert.Equal(t, "50.00", balanceOf(account.ID))
+	assert.Equal(t, "50.00", balanceOf(clearingID))
+
+	var transaction transaction_entity.TransactionEntity
+	tooMuch := paymententity.OutboundPaymentEntity{AccountID: account.ID, CreditorIban: settled.CreditorIban, CreditorName: "Luis", Amount: money.MustParse("50.01")}
+	assert.IsType(t, &errors.ErrNotEnoughFunds{}, paymentRepository.InsertOutboundPayment(ctx, &tooMuch, &transaction))
+
+	// the debit is refunded by the clearing house only
+	_, err = transactionRepository.InsertReversalLedgerTx(ctx, debit.ID, nil, transaction_entity.ReversalReasonCustomerRequest)
+	assert.IsType(t, &errors.ErrConflict{}, err)
+
+	submit := func(payments []paymententity.OutboundPaymentEntity) error { return nil }
+	submitted, err := paymentRepository.ClaimPendingPayments(ctx, "BATCH-1", 10, submit)
+	assert.Nil(t, err)
+	assert.Len(t, submitted, 2)
+	assert.Equal(t, settled.ID, submitted[0].ID)
+	assert.Equal(t, paymententity.StatusSubmitted, submitted[0].Status)
+	none, err := paymentRepository.ClaimPendingPayments(ctx, "BATCH-2", 10, submit)
+	assert.Nil(t, err)
+	assert.Empty(t, none)
+
+	// accepted but not settled yet
+	final, err := paymentRepository.ApplyPaymentStatus(ctx, paymententity.PaymentStatus{EndToEndID: settled.EndToEndID, Status: "ACCP"})
+	assert.Nil(t, err)
+	assert.False(t, final)
+
+	final, err = paymentRepository.ApplyPaymentStatus(ctx, paymententity.PaymentStatus{EndToEndID: settled.EndToEndID, Status: paymententity.ReportSettled})
+	assert.Nil(t, err)
+	assert.True(t, final)
+	final, err = paymentRepository.ApplyPaymentStatus(ctx, paymententity.PaymentStatus{EndToEndID: settled.EndToEndID, Status: paymententity.ReportRejected})
+	assert.Nil(t, err)
+	assert.False(t, final)
+	assert.Equal(t, "20.00", balanceOf(clearingID))
+	assert.Equal(t, "30.00", balanceOf(settlementID))
+
+	final, err = paymentRepository.ApplyPaymentStatus(ctx, paymententity.PaymentStatus{EndToEndID: rejected.EndToEndID, Status: paymententity.ReportRejected, Reason: "AC01"})
+	assert.Nil(t, err)
+	assert.True(t, final)
+	assert.Equal(t, "70.00", balanceOf(account.ID))
+	assert.Equal(t, "0.00", balanceOf(clearingID))
+
+	_, err = paymentRepository.ApplyPaymentStatus(ctx, paymententity.PaymentStatus{EndToEndID: "unknown", Status: paymententity.ReportSettled})
+	assert.IsType(t, &errors.ErrNotFound{}, err)
+
+	payments, err := paymentRepository.FetchOutboundPaymentsByAccount(ctx, account.ID)
+	assert.Nil(t, err)
+	assert.Len(t, payments, 2)
+	assert.Equal(t, paymententity.StatusRejected, payments[0].Status)
+	assert.Equal(t, "AC01", payments[0].StatusReason.String)
+	assert.True(t, payments[0].RefundID.Valid)
+	assert.Equal(t, paymententity.StatusSettled, payments[1].Status)
+	assert.True(t, payments[1].SettlementID.Valid)
+
+	// the payments of a batch that cannot be submitted stay pending
+	pending, _ := newPayment("5.00")
+	_, err = paymentRepository.ClaimPendingPayments(ctx, "BATCH-3", 10, func(payments []paymententity.OutboundPaymentEntity) error {
+		return goerrors.New("outbox not writable")
+	})
+	assert.IsType(t, &errors.ErrInternalServer{}, err)
+	payments, err = paymentRepository.FetchOutboundPaymentsByBatch(ctx, "BATCH-3")
+	assert.Nil(t, err)
+	assert.Empty(t, payments)
+	submitted, err = paymentRepository.ClaimPendingPayments(ctx, "BATCH-4", 10, submit)
+	assert.Nil(t, err)
+	assert.Len(t, submitted, 1)
+	assert.Equal(t, pending.ID, submitted[0].ID)
+	assert.Equal(t, "BATCH-4", submitted[0].BatchMessageID.String)
+
+	assert.Nil(t, reconciliationRepository.RunInSnapshot(ctx, func(tx *sql.Tx) errors.AppError {
+		unbalanced, err := reconciliationRepository.FetchUnbalancedTransactions(ctx, tx)
+		assert.Empty(t, unbalanced)
+		return err
+	}))
+}
@@ -0,0 +1,89 @@
+package repository_Test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"slices"
+	services "src/api/service"
+	accountentity "src/domain/account"
+	"src/domain/money"
+	reconciliationentity "src/domain/reconciliation"
+	app_logger "src/logger"
+	"src/repositories"
+	"src/test/utils"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// A consistent ledger produces a clean report. Tampering with account_balances and with the
+// entries of a transfer is reported, and the materialized view is refreshed from the ledger.
+func TestReconciliationReportsDiscrepancies(t *testing.T) {
+	ctx := context.Background()
+	db := startLedgerDatabase(t, ctx)
+	logger := app_logger.GetLogger()
+
+	clientRepository := repositories.NewClientRepository(db, logger)
+	accountRepository := repositories.NewAccountRepository(db, logger)
+	transactionRepository := repositories.NewTransactionRepository(db, logger)
+	reconciliationService := services.NewReconciliationService(repositories.NewReconciliationRepository(db, logger), logger)
+
+	jhon := utils.CreateClientTest(1, "Jhon", "jhon@test.es")
+	joe := utils.CreateClientTest(2, "Joe", "joe@test.es")
+	assert.NoError(t, clientRepository.InsertClient(ctx, &jhon))
+	assert.NoError(t, clientRepository.InsertClient(ctx, &joe))
+	accountJhon := utils.CreateAccount(jhon.ID)
+	accountJoe := utils.CreateAccount(joe.ID)
+	assert.NoError(t, accountRepository.InsertAccount(ctx, &accountJhon))
+	assert.NoError(t, accountRepository.InsertAccount(ctx, &accountJoe))
+
+	deposit := utils.CreateTransaction(accountJhon.ID, sql.NullInt32{}, money.MustParse("100.00"), "ADD")
+	assert.NoError(t, transactionRepository.InsertTransactionLedgerTx(ctx, &deposit))
+	transfer := utils.CreateTransaction(accountJhon.ID, sql.NullInt32{Int32: int32(accountJoe.ID), Valid: true}, money.MustParse("30.00"), "TRANSFER")
+	assert.NoError(t, transactionRepository.InsertTransactionLedgerTx(ctx, &transfer))
+
+	report, err := reconciliationService.Reconcile(ctx, false)
+	assert.Nil(t, err)
+	assert.True(t, report.IsClean(), "unexpected discrepancies: %+v", report)
+	assert.Equal(t, 2, report.TransactionsChecked)
+	assert.True(t, report.LedgerTotal.IsZero())
+	vault := slices.IndexFunc(report.InternalAccounts, func(balance reconciliationentity.InternalAccountBalance) bool {
+		return balance.Code == accountentity.InternalCashInVault && balance.Currency == "EUR"
+	})
+	if assert.NotEqual(t, -1, vault) {
+		assert.Equal(t, "-100.00", report.InternalAccounts[vault].Balance.String())
+	}
+
+	_, sqlErr := db.ExecContext(ctx, `UPDATE account_balances SET balance = balance + 5 WHERE account_id = $1`, accountJoe.ID)
+	assert.NoError(t, sqlErr)
+	_, sqlErr = db.ExecContext(ctx, `UPDATE ledger_entries SET amount = 29 WHERE transaction_id = $1 AND type = 'CREDIT'`, transfer.ID)
+	assert.NoError(t, sqlErr)
+
+	report, err = reconciliationService.Reconcile(ctx, true)
+	assert.Nil(t, err)
+	assert.False(t, report.IsClean())
+	assert.True(t, report.MaterializedViewRefreshed)
+	if assert.Len(t, report.BalanceDiscrepancies, 1) {
+		assert.Equal(t, accountJoe.ID, report.BalanceDiscrepancies[0].AccountID)
+		assert.Equal(t, "29.00", report.BalanceDiscrepancies[0].LedgerBalance.String())
+		assert.Equal(t, "35.00", report.BalanceDiscrepancies[0].StoredBalance.String())
+		assert.Equal(t, "6.00", report.BalanceDiscrepancies[0].Difference.String())
+	}
+	if assert.Len(t, report.UnbalancedTransactions, 1) {
+		assert.Equal(t, transfer.ID, report.UnbalancedTransactions[0].TransactionID)
+	}
+	assert.Equal(t, "1.00", report.LedgerTotal.Neg().String())
+
+	var mvBalance money.Money
+	assert.NoError(t, db.QueryRowContext(ctx, `SELECT balance FROM account_balances_mv WHERE account_id = $1`, accountJhon.ID).Scan(&mvBalance))
+	assert.Equal(t, "70.00", mvBalance.String())
+
+	var csv bytes.Buffer
+	assert.NoError(t, services.WriteReconciliationCSV(&csv, report))
+	lines := strings.Split(strings.TrimSpace(csv.String()), "\n")
+	assert.Equal(t, "kind,id,type,expected,actual,difference", lines[0])
+	// ledger total, balance and unbalanced transaction
+	assert.Len(t, lines, 4)
+}
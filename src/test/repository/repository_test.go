@@ -3,6 +3,7 @@ package repository_Test
 import (
 	"context"
 	"database/sql"
+	"src/domain/money"
 	app_logger "src/logger"
 	"src/repositories"
 	"src/test/utils"
@@ -83,13 +84,13 @@ func TestRepository(t *testing.T) {
 	accountRepository.InsertAccount(ctx, &accountJoe)
 
 	toAccountId := sql.NullInt32{}
-	joeAddsMoneyTransaction := insert_entities.CreateTransaction(accountJoe.ID, toAccountId, 2000.65, "ADD")
+	joeAddsMoneyTransaction := insert_entities.CreateTransaction(accountJoe.ID, toAccountId, money.MustParse("2000.65"), "ADD")
 	txErr := transactionRepository.InsertTransactionLedgerTx(ctx, &joeAddsMoneyTransaction)
 	assert.NoError(t, txErr)
 
 	toAccountIdJhon := sql.NullInt32{Int32: int32(accountJhon.ID), Valid: true}
 
-	joeTransfersMoneyTransactionToJhon := insert_entities.CreateTransaction(accountJoe.ID, toAccountIdJhon, 1000, "TRANSFERENCIA")
+	joeTransfersMoneyTransactionToJhon := insert_entities.CreateTransaction(accountJoe.ID, toAccountIdJhon, money.MustParse("1000"), "TRANSFERENCIA")
 	txErr = transactionRepository.InsertTransactionLedgerTx(ctx, &joeTransfersMoneyTransactionToJhon)
 	assert.NoError(t, txErr)
 
@@ -168,13 +169,13 @@ func TestRepositoryNotEnoughFundsTransaction(t *testing.T) {
 	accountRepository.InsertAccount(ctx, &accountJoe)
 
 	toAccountId := sql.NullInt32{}
-	joeAddsMoneyTransaction := insert_entities.CreateTransaction(accountJoe.ID, toAccountId, 900.65, "ADD")
+	joeAddsMoneyTransaction := insert_entities.CreateTransaction(accountJoe.ID, toAccountId, money.MustParse("900.65"), "ADD")
 	txErr := transactionRepository.InsertTransactionLedgerTx(ctx, &joeAddsMoneyTransaction)
 	assert.NoError(t, txErr)
 
 	toAccountIdJhon := sql.NullInt32{Int32: int32(accountJhon.ID), Valid: true}
 
-	joeTransfersMoneyTransactionToJhon := insert_entities.CreateTransaction(accountJoe.ID, toAccountIdJhon, 1000, "TRANSFER")
+	joeTransfersMoneyTransactionToJhon := insert_entities.CreateTransaction(accountJoe.ID, toAccountIdJhon, money.MustParse("1000"), "TRANSFER")
 	txErr = transactionRepository.InsertTransactionLedgerTx(ctx, &joeTransfersMoneyTransactionToJhon)
 	assert.Error(t, txErr)
 
@@ -251,13 +252,13 @@ func TestRepositoryNotEnoughFundsWithdrawal(t *testing.T) {
 	accountRepository.InsertAccount(ctx, &accountJoe)
 
 	toAccountId := sql.NullInt32{}
-	joeAddsMoneyTransaction := insert_entities.CreateTransaction(accountJoe.ID, toAccountId, 900.65, "ADD")
+	joeAddsMoneyTransaction := insert_entities.CreateTransaction(accountJoe.ID, toAccountId, money.MustParse("900.65"), "ADD")
 	txErr := transactionRepository.InsertTransactionLedgerTx(ctx, &joeAddsMoneyTransaction)
 	assert.NoError(t, txErr)
 
 	toAccountIdJhon := sql.NullInt32{Int32: int32(accountJhon.ID), Valid: true}
 
-	joeWithdrawMoney := insert_entities.CreateTransaction(accountJoe.ID, toAccountIdJhon, 1000, "WITHDRAWAL")
+	joeWithdrawMoney := insert_entities.CreateTransaction(accountJoe.ID, toAccountIdJhon, money.MustParse("1000"), "WITHDRAWAL")
 	txErr = transactionRepository.InsertTransactionLedgerTx(ctx, &joeWithdrawMoney)
 	assert.Error(t, txErr)
 
@@ -0,0 +1,80 @@
+package repository_Test
+
+import (
+	"context"
+	"database/sql"
+	accountentity "src/domain/account"
+	feeentity "src/domain/fee"
+	"src/domain/money"
+	transaction_entity "src/domain/transaction"
+	errors "src/errors"
+	app_logger "src/logger"
+	"src/repositories"
+	"src/test/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Partial reversals cannot go beyond the amount of the original transaction and, whatever the rounding
+// of their shares, they add up to it once everything is reversed
+func TestPartialReversals(t *testing.T) {
+	ctx := context.Background()
+	db := startLedgerDatabase(t, ctx)
+	logger := app_logger.GetLogger()
+
+	clientRepository := repositories.NewClientRepository(db, logger)
+	accountRepository := repositories.NewAccountRepository(db, logger)
+	transactionRepository := repositories.NewTransactionRepository(db, logger)
+	feeRepository := repositories.NewFeeRepository(db, logger)
+
+	client := utils.CreateClientTest(1, "Jhon", "jhon@test.es")
+	assert.NoError(t, clientRepository.InsertClient(ctx, &client))
+	account := utils.CreateAccount(client.ID)
+	toAccount := utils.CreateAccount(client.ID)
+	assert.NoError(t, accountRepository.InsertAccount(ctx, &account))
+	assert.NoError(t, accountRepository.InsertAccount(ctx, &toAccount))
+	schedule := feeentity.FeeScheduleEntity{TransactionType: "TRANSFER", Currency: "EUR", FixedAmount: money.MustParse("0.01"), Percentage: "0"}
+	assert.Nil(t, feeRepository.UpsertFeeSchedule(ctx, &schedule))
+
+	deposit := utils.CreateTransaction(account.ID, sql.NullInt32{}, money.MustParse("100.00"), "ADD")
+	assert.Nil(t, transactionRepository.InsertTransactionLedgerTx(ctx, &deposit))
+	transfer := utils.CreateTransaction(account.ID, sql.NullInt32{Int32: int32(toAccount.ID), Valid: true}, money.MustParse("30.00"), "TRANSFER")
+	assert.Nil(t, transactionRepository.InsertTransactionLedgerTx(ctx, &transfer))
+	assert.Equal(t, "0.01", transfer.FeeAmount.String())
+
+	balanceOf := func(accountID int) string {
+		balance, err := transactionRepository.FetchAccountBalance(ctx, nil, accountID)
+		assert.Nil(t, err)
+		return balance.Balance.String()
+	}
+	incomeID, err := transactionRepository.FetchInternalAccountId(ctx, nil, accountentity.InternalFeeIncome, "EUR")
+	assert.Nil(t, err)
+
+	third := money.MustParse("10.00")
+	reversal, err := transactionRepository.InsertReversalLedgerTx(ctx, transfer.ID, &third, transaction_entity.ReversalReasonWrongAmount)
+	assert.Nil(t, err)
+	assert.True(t, reversal.FeeAmount.IsZero())
+	assert.Equal(t, "80.00", balanceOf(account.ID))
+	assert.Equal(t, "20.00", balanceOf(toAccount.ID))
+
+	// 20.00 are left to reverse
+	beyond := money.MustParse("25.00")
+	_, err = transactionRepository.InsertReversalLedgerTx(ctx, transfer.ID, &beyond, transaction_entity.ReversalReasonWrongAmount)
+	assert.IsType(t, &errors.ErrConflict{}, err)
+	_, err = transactionRepository.InsertReversalLedgerTx(ctx, reversal.ID, nil, transaction_entity.ReversalReasonWrongAmount)
+	assert.IsType(t, &errors.ErrConflict{}, err)
+
+	_, err = transactionRepository.InsertReversalLedgerTx(ctx, transfer.ID, &third, transaction_entity.ReversalReasonWrongAmount)
+	assert.Nil(t, err)
+	// the last reversal refunds the fee that the rounded shares did not
+	reversal, err = transactionRepository.InsertReversalLedgerTx(ctx, transfer.ID, &third, transaction_entity.ReversalReasonWrongAmount)
+	assert.Nil(t, err)
+	assert.Equal(t, "0.01", reversal.FeeAmount.String())
+	assert.Equal(t, "100.00", balanceOf(account.ID))
+	assert.Equal(t, "0.00", balanceOf(toAccount.ID))
+	assert.Equal(t, "0.00", balanceOf(incomeID))
+
+	_, err = transactionRepository.InsertReversalLedgerTx(ctx, transfer.ID, nil, transaction_entity.ReversalReasonWrongAmount)
+	assert.IsType(t, &errors.ErrConflict{}, err)
+}
@@ -0,0 +1,169 @@
+package repository_Test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	accountentity "src/domain/account"
+	"src/domain/money"
+	scheduledtransferentity "src/domain/scheduled_transfer"
+	app_logger "src/logger"
+	"src/repositories"
+	"src/test/utils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// A daily order started three days ago executes the missed runs once. Running the scheduler
+// again (i.e. after a restart) executes nothing, and the run without funds is recorded as FAILED.
+func TestStandingOrderRunsAreIdempotent(t *testing.T) {
+	ctx := context.Background()
+	db := startLedgerDatabase(t, ctx)
+	logger := app_logger.GetLogger()
+
+	clientRepository := repositories.NewClientRepository(db, logger)
+	accountRepository := repositories.NewAccountRepository(db, logger)
+	transactionRepository := repositories.NewTransactionRepository(db, logger)
+	scheduledTransferRepository := repositories.NewScheduledTransferRepository(db, logger, transactionRepository)
+
+	jhon := utils.CreateClientTest(1, "Jhon", "jhon@test.es")
+	landlord := utils.CreateClientTest(2, "Landlord", "landlord@test.es")
+	assert.NoError(t, clientRepository.InsertClient(ctx, &jhon))
+	assert.NoError(t, clientRepository.InsertClient(ctx, &landlord))
+	account := utils.CreateAccount(jhon.ID)
+	toAccount := utils.CreateAccount(landlord.ID)
+	assert.NoError(t, accountRepository.InsertAccount(ctx, &account))
+	assert.NoError(t, accountRepository.InsertAccount(ctx, &toAccount))
+
+	deposit := utils.CreateTransaction(account.ID, sql.NullInt32{}, money.MustParse("50.00"), "ADD")
+	assert.NoError(t, transactionRepository.InsertTransactionLedgerTx(ctx, &deposit))
+
+	today := scheduledtransferentity.Today()
+	order := scheduledtransferentity.ScheduledTransferEntity{
+		AccountID:       account.ID,
+		ToAccountID:     toAccount.ID,
+		ToAccountNumber: toAccount.AccountNumber,
+		Amount:          money.MustParse("20.00"),
+		Frequency:       scheduledtransferentity.FrequencyDaily,
+		StartDate:       today.AddDate(0, 0, -2),
+		EndDate:         sql.NullTime{Time: today, Valid: true},
+		Status:          scheduledtransferentity.StatusActive,
+	}
+	order.NextRunDate = sql.NullTime{Time: order.FirstRunDate(), Valid: true}
+	assert.Nil(t, scheduledTransferRepository.InsertScheduledTransfer(ctx, &order))
+
+	executed, err := scheduledTransferRepository.ExecuteDue(ctx, today)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, executed)
+
+	executed, err = scheduledTransferRepository.ExecuteDue(ctx, today)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, executed)
+
+	runs, err := scheduledTransferRepository.FetchRuns(ctx, order.ID)
+	assert.Nil(t, err)
+	assert.Len(t, runs, 3)
+	// newest first: 50.00 only covers the first two runs
+	assert.Equal(t, scheduledtransferentity.RunFailed, runs[0].Status)
+	assert.Equal(t, "not enough funds", runs[0].FailureReason.String)
+	assert.False(t, runs[0].TransactionID.Valid)
+	assert.Equal(t, scheduledtransferentity.RunSucceeded, runs[2].Status)
+	assert.True(t, runs[2].TransactionID.Valid)
+	assert.Equal(t, today.AddDate(0, 0, -2).Format(time.DateOnly), runs[2].RunDate.Format(time.DateOnly))
+
+	stored, err := scheduledTransferRepository.FetchScheduledTransferById(ctx, order.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, scheduledtransferentity.StatusFinished, stored.Status)
+	assert.False(t, stored.NextRunDate.Valid)
+
+	balance, err := transactionRepository.FetchAccountBalance(ctx, nil, account.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, "10.00", balance.Balance.String())
+	toBalance, err := transactionRepository.FetchAccountBalance(ctx, nil, toAccount.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, "40.00", toBalance.Balance.String())
+}
+
+// An order whose transfer fails with an internal error does not block the orders due after it: the run is recorded
+// as FAILED, the order moves to its next run date and the error is returned once every due order has been run.
+func TestStandingOrderInternalErrorDoesNotBlockOthers(t *testing.T) {
+	ctx := context.Background()
+	db := startLedgerDatabase(t, ctx)
+	logger := app_logger.GetLogger()
+
+	clientRepository := repositories.NewClientRepository(db, logger)
+	accountRepository := repositories.NewAccountRepository(db, logger)
+	transactionRepository := repositories.NewTransactionRepository(db, logger)
+	scheduledTransferRepository := repositories.NewScheduledTransferRepository(db, logger, transactionRepository)
+
+	jhon := utils.CreateClientTest(1, "Jhon", "jhon@test.es")
+	landlord := utils.CreateClientTest(2, "Landlord", "landlord@test.es")
+	assert.NoError(t, clientRepository.InsertClient(ctx, &jhon))
+	assert.NoError(t, clientRepository.InsertClient(ctx, &landlord))
+	broken := utils.CreateAccount(jhon.ID)
+	account := utils.CreateAccount(jhon.ID)
+	toAccount := utils.CreateAccount(landlord.ID)
+	for _, a := range []*accountentity.AccountEntity{&broken, &account, &toAccount} {
+		assert.NoError(t, accountRepository.InsertAccount(ctx, a))
+		deposit := utils.CreateTransaction(a.ID, sql.NullInt32{}, money.MustParse("50.00"), "ADD")
+		assert.NoError(t, transactionRepository.InsertTransactionLedgerTx(ctx, &deposit))
+	}
+
+	// every transaction of the broken account fails in the database
+	_, err := db.ExecContext(ctx, `
+	CREATE FUNCTION fail_transaction() RETURNS trigger AS $$
+	BEGIN
+		RAISE EXCEPTION 'ledger unavailable';
+	END
+	$$ LANGUAGE plpgsql`)
+	assert.NoError(t, err)
+	_, err = db.ExecContext(ctx, fmt.Sprintf(`
+	CREATE TRIGGER fail_transaction BEFORE INSERT ON transactions
+	FOR EACH ROW WHEN (NEW.account_id = %d) EXECUTE FUNCTION fail_transaction()`, broken.ID))
+	assert.NoError(t, err)
+
+	today := scheduledtransferentity.Today()
+	newOrder := func(accountID int, start time.Time) scheduledtransferentity.ScheduledTransferEntity {
+		order := scheduledtransferentity.ScheduledTransferEntity{
+			AccountID:       accountID,
+			ToAccountID:     toAccount.ID,
+			ToAccountNumber: toAccount.AccountNumber,
+			Amount:          money.MustParse("10.00"),
+			Frequency:       scheduledtransferentity.FrequencyDaily,
+			StartDate:       start,
+			EndDate:         sql.NullTime{Time: today, Valid: true},
+			Status:          scheduledtransferentity.StatusActive,
+		}
+		order.NextRunDate = sql.NullTime{Time: order.FirstRunDate(), Valid: true}
+		assert.Nil(t, scheduledTransferRepository.InsertScheduledTransfer(ctx, &order))
+		return order
+	}
+	failing := newOrder(broken.ID, today.AddDate(0, 0, -1))
+	order := newOrder(account.ID, today)
+
+	executed, appErr := scheduledTransferRepository.ExecuteDue(ctx, today)
+	assert.NotNil(t, appErr)
+	assert.Equal(t, 1, executed)
+
+	runs, appErr := scheduledTransferRepository.FetchRuns(ctx, failing.ID)
+	assert.Nil(t, appErr)
+	assert.Len(t, runs, 2)
+	for _, run := range runs {
+		assert.Equal(t, scheduledtransferentity.RunFailed, run.Status)
+		assert.Contains(t, run.FailureReason.String, "ledger unavailable")
+	}
+	stored, appErr := scheduledTransferRepository.FetchScheduledTransferById(ctx, failing.ID)
+	assert.Nil(t, appErr)
+	assert.Equal(t, scheduledtransferentity.StatusFinished, stored.Status)
+
+	runs, appErr = scheduledTransferRepository.FetchRuns(ctx, order.ID)
+	assert.Nil(t, appErr)
+	assert.Len(t, runs, 1)
+	assert.Equal(t, scheduledtransferentity.RunSucceeded, runs[0].Status)
+
+	executed, appErr = scheduledTransferRepository.ExecuteDue(ctx, today)
+	assert.Nil(t, appErr)
+	assert.Equal(t, 0, executed)
+}
@@ -0,0 +1,76 @@
+package repository_Test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	services "src/api/service"
+	"src/domain/money"
+	statemententity "src/domain/statement"
+	app_logger "src/logger"
+	"src/repositories"
+	"src/test/utils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// The statement has the entries of the period only, and its closing balance is the opening balance plus them
+func TestStatement(t *testing.T) {
+	ctx := context.Background()
+	db := startLedgerDatabase(t, ctx)
+	logger := app_logger.GetLogger()
+
+	clientRepository := repositories.NewClientRepository(db, logger)
+	accountRepository := repositories.NewAccountRepository(db, logger)
+	transactionRepository := repositories.NewTransactionRepository(db, logger)
+	wrapper := repositories.RepositoryWrapper{
+		ClientRepository:      clientRepository,
+		AccountRepository:     accountRepository,
+		TransactionRepository: transactionRepository,
+		BalanceRepository:     repositories.NewBalanceRepository(db, logger),
+	}
+	statementService := services.NewStatementService(wrapper, logger)
+
+	client := utils.CreateClientTest(1, "Jhon", "jhon@test.es")
+	assert.NoError(t, clientRepository.InsertClient(ctx, &client))
+	account := utils.CreateAccount(client.ID)
+	assert.NoError(t, accountRepository.InsertAccount(ctx, &account))
+	client2 := utils.CreateClientTest(2, "Ana", "ana@test.es")
+	assert.NoError(t, clientRepository.InsertClient(ctx, &client2))
+	account2 := utils.CreateAccount(client2.ID)
+	assert.NoError(t, accountRepository.InsertAccount(ctx, &account2))
+
+	// a deposit before the period, then a deposit and a transfer inside it
+	deposit := utils.CreateTransaction(account.ID, sql.NullInt32{}, money.MustParse("100.00"), "ADD")
+	assert.Nil(t, transactionRepository.InsertTransactionLedgerTx(ctx, &deposit))
+	_, err := db.ExecContext(ctx, `UPDATE ledger_entries SET created_at = created_at - interval '2 days' WHERE transaction_id = $1`, deposit.ID)
+	assert.NoError(t, err)
+	from := time.Now().UTC().Add(-time.Hour)
+	deposit = utils.CreateTransaction(account.ID, sql.NullInt32{}, money.MustParse("20.00"), "ADD")
+	assert.Nil(t, transactionRepository.InsertTransactionLedgerTx(ctx, &deposit))
+	transfer := utils.CreateTransaction(account.ID, sql.NullInt32{Int32: int32(account2.ID), Valid: true}, money.MustParse("45.00"), "TRANSFER")
+	assert.Nil(t, transactionRepository.InsertTransactionLedgerTx(ctx, &transfer))
+	to := time.Now().UTC().Add(time.Minute)
+
+	var buffer bytes.Buffer
+	var header statemententity.StatementEntity
+	appErr := statementService.WriteStatement(ctx, account.ID, from, to, func(statement statemententity.StatementEntity) (services.StatementWriter, error) {
+		header = statement
+		return services.NewStatementWriter(statemententity.FormatCSV, &buffer)
+	})
+	assert.Nil(t, appErr)
+	assert.Equal(t, "100.00", header.OpeningBalance.String())
+	assert.Equal(t, "75.00", header.ClosingBalance.String())
+	assert.Equal(t, account.AccountNumber, header.Iban)
+
+	records, err := csv.NewReader(&buffer).ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, records, 5)
+	assert.Equal(t, []string{"ADD", "IN", "20.00", "120.00"}, []string{records[2][3], records[2][4], records[2][5], records[2][9]})
+	assert.Equal(t, []string{"TRANSFER", "OUT", "-45.00", "75.00"}, []string{records[3][3], records[3][4], records[3][5], records[3][9]})
+	assert.Equal(t, account2.AccountNumber, records[3][7])
+	assert.Equal(t, "75.00", records[4][9])
+}
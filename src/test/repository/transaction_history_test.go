@@ -0,0 +1,92 @@
+package repository_Test
+
+import (
+	"context"
+	"database/sql"
+	"src/domain/money"
+	"src/domain/pagination"
+	transaction_entity "src/domain/transaction"
+	app_logger "src/logger"
+	"src/repositories"
+	"src/test/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func transactionIds(items []transaction_entity.TransactionEntity) []int {
+	ids := make([]int, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ID)
+	}
+	return ids
+}
+
+// Walking the history forwards with next_cursor and back with prev_cursor returns the same pages,
+// and a transaction inserted meanwhile does not shift them
+func TestTransactionHistoryCursorPagination(t *testing.T) {
+	ctx := context.Background()
+	db := startLedgerDatabase(t, ctx)
+	logger := app_logger.GetLogger()
+
+	clientRepository := repositories.NewClientRepository(db, logger)
+	accountRepository := repositories.NewAccountRepository(db, logger)
+	transactionRepository := repositories.NewTransactionRepository(db, logger)
+
+	client := utils.CreateClientTest(1, "Jhon", "jhon@test.es")
+	assert.NoError(t, clientRepository.InsertClient(ctx, &client))
+	account := utils.CreateAccount(client.ID)
+	assert.NoError(t, accountRepository.InsertAccount(ctx, &account))
+
+	var ids []int
+	for _, amount := range []string{"10.00", "20.00", "30.00", "40.00", "50.00"} {
+		deposit := utils.CreateTransaction(account.ID, sql.NullInt32{}, money.MustParse(amount), "ADD")
+		assert.NoError(t, transactionRepository.InsertTransactionLedgerTx(ctx, &deposit))
+		ids = append(ids, deposit.ID)
+	}
+	filter := transaction_entity.TransactionFilter{Sort: transaction_entity.SortDesc}
+
+	first, err := transactionRepository.ListTransactions(ctx, account.ID, filter, nil, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{ids[4], ids[3]}, transactionIds(first.Items))
+	assert.Nil(t, first.PrevCursor)
+	assert.NotNil(t, first.NextCursor)
+
+	newer := utils.CreateTransaction(account.ID, sql.NullInt32{}, money.MustParse("60.00"), "ADD")
+	assert.NoError(t, transactionRepository.InsertTransactionLedgerTx(ctx, &newer))
+
+	cursor, decodeErr := pagination.DecodeCursor(*first.NextCursor)
+	assert.NoError(t, decodeErr)
+	second, err := transactionRepository.ListTransactions(ctx, account.ID, filter, &cursor, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{ids[2], ids[1]}, transactionIds(second.Items))
+
+	cursor, _ = pagination.DecodeCursor(*second.NextCursor)
+	last, err := transactionRepository.ListTransactions(ctx, account.ID, filter, &cursor, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{ids[0]}, transactionIds(last.Items))
+	assert.Nil(t, last.NextCursor)
+
+	cursor, _ = pagination.DecodeCursor(*last.PrevCursor)
+	back, err := transactionRepository.ListTransactions(ctx, account.ID, filter, &cursor, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{ids[2], ids[1]}, transactionIds(back.Items))
+
+	cursor, _ = pagination.DecodeCursor(*back.PrevCursor)
+	back, err = transactionRepository.ListTransactions(ctx, account.ID, filter, &cursor, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{ids[4], ids[3]}, transactionIds(back.Items))
+	// the transaction inserted after the first page is before it
+	assert.NotNil(t, back.PrevCursor)
+
+	minAmount, maxAmount := money.MustParse("20.00"), money.MustParse("40.00")
+	filtered, err := transactionRepository.GetTransactions(ctx, account.ID, transaction_entity.TransactionFilter{
+		Type:      "ADD",
+		MinAmount: &minAmount,
+		MaxAmount: &maxAmount,
+		Sort:      transaction_entity.SortAsc,
+	}, 1, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{ids[1], ids[2], ids[3]}, transactionIds(filtered.Items))
+	assert.Equal(t, 1, filtered.LastPage)
+}
@@ -0,0 +1,67 @@
+package scheduledtransfer_test
+
+import (
+	"database/sql"
+	scheduledtransferentity "src/domain/scheduled_transfer"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func date(value string) time.Time {
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// runDates returns the first n run dates of the order
+func runDates(order scheduledtransferentity.ScheduledTransferEntity, n int) []string {
+	dates := make([]string, 0, n)
+	runDate := order.FirstRunDate()
+	for len(dates) < n {
+		dates = append(dates, runDate.Format(time.DateOnly))
+		runDate = order.RunDateAfter(runDate)
+	}
+	return dates
+}
+
+func TestRunDates(t *testing.T) {
+	cases := map[string]struct {
+		frequency string
+		start     string
+		expected  []string
+	}{
+		"daily":                {scheduledtransferentity.FrequencyDaily, "2024-02-28", []string{"2024-02-28", "2024-02-29", "2024-03-01"}},
+		"weekly":               {scheduledtransferentity.FrequencyWeekly, "2024-12-25", []string{"2024-12-25", "2025-01-01", "2025-01-08"}},
+		"monthly":              {scheduledtransferentity.FrequencyMonthly, "2024-01-15", []string{"2024-01-15", "2024-02-15", "2024-03-15"}},
+		"monthly short months": {scheduledtransferentity.FrequencyMonthly, "2024-01-31", []string{"2024-01-31", "2024-02-29", "2024-03-31", "2024-04-30"}},
+		"end of month":         {scheduledtransferentity.FrequencyEndOfMonth, "2023-01-10", []string{"2023-01-31", "2023-02-28", "2023-03-31", "2023-04-30"}},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			order := scheduledtransferentity.ScheduledTransferEntity{Frequency: c.frequency, StartDate: date(c.start)}
+			assert.Equal(t, c.expected, runDates(order, len(c.expected)))
+		})
+	}
+}
+
+func TestIsWithinEndDate(t *testing.T) {
+	order := scheduledtransferentity.ScheduledTransferEntity{
+		Frequency: scheduledtransferentity.FrequencyMonthly,
+		StartDate: date("2024-01-31"),
+		EndDate:   sql.NullTime{Time: date("2024-03-31"), Valid: true},
+	}
+	assert.True(t, order.IsWithinEndDate(date("2024-03-31")))
+	assert.False(t, order.IsWithinEndDate(date("2024-04-30")))
+
+	order.EndDate = sql.NullTime{}
+	assert.True(t, order.IsWithinEndDate(date("2099-12-31")))
+}
+
+func TestIsValidFrequency(t *testing.T) {
+	assert.True(t, scheduledtransferentity.IsValidFrequency("END_OF_MONTH"))
+	assert.False(t, scheduledtransferentity.IsValidFrequency("YEARLY"))
+}
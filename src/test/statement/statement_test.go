@@ -0,0 +1,208 @@
+package statement_test
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"encoding/xml"
+	services "src/api/service"
+	ledgerentity "src/domain/ledger"
+	"src/domain/money"
+	statemententity "src/domain/statement"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var statement = statemententity.StatementEntity{
+	AccountID:      7,
+	Iban:           "ES9101820600111234567890",
+	Currency:       "EUR",
+	OwnerName:      "Ana García López",
+	From:           time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+	To:             time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC),
+	OpeningBalance: money.MustParse("100.00"),
+	ClosingBalance: money.MustParse("75.50"),
+	CreatedAt:      time.Date(2026, 4, 2, 9, 30, 0, 0, time.UTC),
+}
+
+var entries = []ledgerentity.ActivityEntryEntity{
+	{
+		EntryID:         11,
+		TransactionID:   5,
+		TransactionType: "ADD",
+		Direction:       ledgerentity.DirectionIn,
+		Kind:            ledgerentity.KindPrincipal,
+		Amount:          money.MustParse("26.00"),
+		BalanceAfter:    money.MustParse("126.00"),
+		CreatedAt:       time.Date(2026, 3, 3, 10, 0, 0, 0, time.UTC),
+	},
+	{
+		EntryID:          14,
+		TransactionID:    6,
+		TransactionType:  "TRANSFER",
+		Direction:        ledgerentity.DirectionOut,
+		Kind:             ledgerentity.KindPrincipal,
+		Amount:           money.MustParse("50.00"),
+		BalanceAfter:     money.MustParse("76.00"),
+		CounterpartyIban: sql.NullString{String: "ES7921000813610123456789", Valid: true},
+		CounterpartyName: sql.NullString{String: "Luis Pérez <Martín>", Valid: true},
+		CreatedAt:        time.Date(2026, 3, 20, 18, 45, 0, 0, time.UTC),
+	},
+	// the fee of the transfer has no counterparty
+	{
+		EntryID:         16,
+		TransactionID:   6,
+		TransactionType: "TRANSFER",
+		Direction:       ledgerentity.DirectionOut,
+		Kind:            ledgerentity.KindFee,
+		Amount:          money.MustParse("0.50"),
+		BalanceAfter:    money.MustParse("75.50"),
+		CreatedAt:       time.Date(2026, 3, 20, 18, 45, 0, 0, time.UTC),
+	},
+}
+
+func writeStatement(t *testing.T, format string) string {
+	var buffer bytes.Buffer
+	writer, err := services.NewStatementWriter(format, &buffer)
+	assert.NoError(t, err)
+	assert.NoError(t, writer.WriteHeader(statement))
+	for _, entry := range entries {
+		assert.NoError(t, writer.WriteEntry(entry))
+	}
+	assert.NoError(t, writer.Close())
+	return buffer.String()
+}
+
+func TestUnsupportedFormat(t *testing.T) {
+	_, err := services.NewStatementWriter("pdf", &bytes.Buffer{})
+	assert.Error(t, err)
+}
+
+func TestCSVStatement(t *testing.T) {
+	records, err := csv.NewReader(strings.NewReader(writeStatement(t, statemententity.FormatCSV))).ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, records, 6)
+	assert.Equal(t, "date", records[0][0])
+	assert.Equal(t, []string{"2026-03-01T00:00:00Z", "", "", "OPENING_BALANCE", "", "", "", "EUR", "", "", "100.00"}, records[1])
+	assert.Equal(t, []string{"2026-03-03T10:00:00Z", "11", "5", "ADD", "PRINCIPAL", "IN", "26.00", "EUR", "", "", "126.00"}, records[2])
+	assert.Equal(t, []string{
+		"2026-03-20T18:45:00Z", "14", "6", "TRANSFER", "PRINCIPAL", "OUT", "-50.00", "EUR",
+		"ES7921000813610123456789", "Luis Pérez <Martín>", "76.00",
+	}, records[3])
+	assert.Equal(t, []string{"2026-03-20T18:45:00Z", "16", "6", "TRANSFER", "FEE", "OUT", "-0.50", "EUR", "", "", "75.50"}, records[4])
+	assert.Equal(t, "CLOSING_BALANCE", records[5][3])
+	assert.Equal(t, "75.50", records[5][10])
+}
+
+func TestOFXStatement(t *testing.T) {
+	output := writeStatement(t, statemententity.FormatOFX)
+	assert.Contains(t, output, `<?OFX OFXHEADER="200" VERSION="220"`)
+
+	var ofx struct {
+		Statement struct {
+			Currency string `xml:"CURDEF"`
+			Account  struct {
+				BankID    string `xml:"BANKID"`
+				AccountID string `xml:"ACCTID"`
+			} `xml:"BANKACCTFROM"`
+			Transactions []struct {
+				Type      string `xml:"TRNTYPE"`
+				Posted    string `xml:"DTPOSTED"`
+				Amount    string `xml:"TRNAMT"`
+				FitID     string `xml:"FITID"`
+				Name      string `xml:"NAME"`
+				ToAccount string `xml:"BANKACCTTO>ACCTID"`
+			} `xml:"BANKTRANLIST>STMTTRN"`
+			LedgerBalance  string `xml:"LEDGERBAL>BALAMT"`
+			OpeningBalance string `xml:"BALLIST>BAL>VALUE"`
+		} `xml:"BANKMSGSRSV1>STMTTRNRS>STMTRS"`
+	}
+	assert.NoError(t, xml.Unmarshal([]byte(output), &ofx))
+	assert.Equal(t, "EUR", ofx.Statement.Currency)
+	assert.Equal(t, "0182", ofx.Statement.Account.BankID)
+	assert.Equal(t, statement.Iban, ofx.Statement.Account.AccountID)
+	assert.Len(t, ofx.Statement.Transactions, 3)
+	assert.Equal(t, "DEP", ofx.Statement.Transactions[0].Type)
+	assert.Equal(t, "20260303100000.000[0:GMT]", ofx.Statement.Transactions[0].Posted)
+	assert.Equal(t, "XFER", ofx.Statement.Transactions[1].Type)
+	assert.Equal(t, "-50.00", ofx.Statement.Transactions[1].Amount)
+	assert.Equal(t, "14", ofx.Statement.Transactions[1].FitID)
+	assert.Equal(t, "Luis Pérez <Martín>", ofx.Statement.Transactions[1].Name)
+	assert.Equal(t, "ES7921000813610123456789", ofx.Statement.Transactions[1].ToAccount)
+	assert.Equal(t, "FEE", ofx.Statement.Transactions[2].Type)
+	assert.Equal(t, "-0.50", ofx.Statement.Transactions[2].Amount)
+	assert.Empty(t, ofx.Statement.Transactions[2].ToAccount)
+	assert.Equal(t, "75.50", ofx.Statement.LedgerBalance)
+	assert.Equal(t, "100.00", ofx.Statement.OpeningBalance)
+}
+
+func TestCamt053Statement(t *testing.T) {
+	output := writeStatement(t, statemententity.FormatCamt053)
+
+	type amount struct {
+		Value    string `xml:",chardata"`
+		Currency string `xml:"Ccy,attr"`
+	}
+	var document struct {
+		XMLName   xml.Name
+		Statement struct {
+			Iban     string `xml:"Acct>Id>IBAN"`
+			Owner    string `xml:"Acct>Ownr>Nm"`
+			Balances []struct {
+				Code      string `xml:"Tp>CdOrPrtry>Cd"`
+				Amount    amount `xml:"Amt"`
+				Indicator string `xml:"CdtDbtInd"`
+			} `xml:"Bal"`
+			Entries []struct {
+				Reference    string `xml:"NtryRef"`
+				Amount       amount `xml:"Amt"`
+				Indicator    string `xml:"CdtDbtInd"`
+				Status       string `xml:"Sts"`
+				BookingDate  string `xml:"BookgDt>DtTm"`
+				Code         string `xml:"BkTxCd>Prtry>Cd"`
+				CreditorName string `xml:"NtryDtls>TxDtls>RltdPties>Cdtr>Nm"`
+				CreditorIban string `xml:"NtryDtls>TxDtls>RltdPties>CdtrAcct>Id>IBAN"`
+			} `xml:"Ntry"`
+		} `xml:"BkToCstmrStmt>Stmt"`
+	}
+	assert.NoError(t, xml.Unmarshal([]byte(output), &document))
+	assert.Equal(t, "urn:iso:std:iso:20022:tech:xsd:camt.053.001.02", document.XMLName.Space)
+	assert.Equal(t, statement.Iban, document.Statement.Iban)
+	assert.Equal(t, "Ana García López", document.Statement.Owner)
+	assert.Len(t, document.Statement.Balances, 2)
+	assert.Equal(t, "OPBD", document.Statement.Balances[0].Code)
+	assert.Equal(t, amount{Value: "100.00", Currency: "EUR"}, document.Statement.Balances[0].Amount)
+	assert.Equal(t, "CLBD", document.Statement.Balances[1].Code)
+	assert.Equal(t, "CRDT", document.Statement.Balances[1].Indicator)
+
+	assert.Len(t, document.Statement.Entries, 3)
+	assert.Equal(t, "CRDT", document.Statement.Entries[0].Indicator)
+	assert.Equal(t, "BOOK", document.Statement.Entries[0].Status)
+	assert.Equal(t, "2026-03-03T10:00:00Z", document.Statement.Entries[0].BookingDate)
+	assert.Equal(t, "DBIT", document.Statement.Entries[1].Indicator)
+	assert.Equal(t, amount{Value: "50.00", Currency: "EUR"}, document.Statement.Entries[1].Amount)
+	assert.Equal(t, "TRANSFER", document.Statement.Entries[1].Code)
+	assert.Equal(t, "Luis Pérez <Martín>", document.Statement.Entries[1].CreditorName)
+	assert.Equal(t, "ES7921000813610123456789", document.Statement.Entries[1].CreditorIban)
+	assert.Equal(t, "TRANSFER FEE", document.Statement.Entries[2].Code)
+	assert.Empty(t, document.Statement.Entries[2].CreditorIban)
+}
+
+func TestNegativeClosingBalanceIsDebit(t *testing.T) {
+	overdrawn := statement
+	overdrawn.ClosingBalance = money.MustParse("-20.00")
+	var buffer bytes.Buffer
+	writer, _ := services.NewStatementWriter(statemententity.FormatCamt053, &buffer)
+	assert.NoError(t, writer.WriteHeader(overdrawn))
+	assert.NoError(t, writer.Close())
+	assert.Contains(t, buffer.String(), `<Amt Ccy="EUR">20.00</Amt>`)
+	assert.Contains(t, buffer.String(), `<CdtDbtInd>DBIT</CdtDbtInd>`)
+}
+
+func TestStatementFileName(t *testing.T) {
+	assert.Equal(t, "statement-ES9101820600111234567890-20260301-20260331.ofx", services.StatementFileName(statement, statemententity.FormatOFX))
+	assert.Equal(t, "statement-ES9101820600111234567890-20260301-20260331.xml", services.StatementFileName(statement, statemententity.FormatCamt053))
+}
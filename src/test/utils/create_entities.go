@@ -5,6 +5,7 @@ import (
 	accountentity "src/domain/account"
 	cliententity "src/domain/client"
 	ledgerentity "src/domain/ledger"
+	"src/domain/money"
 	transaction_entity "src/domain/transaction"
 	"time"
 
@@ -42,7 +43,7 @@ func CreateAccount(clientId int) accountentity.AccountEntity {
 	}
 }
 
-func CreateTransaction(accountID int, toAccountID sql.NullInt32, amount float64, typ string) transaction_entity.TransactionEntity {
+func CreateTransaction(accountID int, toAccountID sql.NullInt32, amount money.Money, typ string) transaction_entity.TransactionEntity {
 	return transaction_entity.TransactionEntity{
 		AccountID:   accountID,
 		Type:        typ,
@@ -54,7 +55,7 @@ func CreateTransaction(accountID int, toAccountID sql.NullInt32, amount float64,
 }
 
 // InitializeLedgerEntry creates a new LedgerEntry instance.
-func InitializeLedgerEntry(transactionID, accountID int, entryType string, amount float64) ledgerentity.LedgerEntryEntity {
+func InitializeLedgerEntry(transactionID, accountID int, entryType string, amount money.Money) ledgerentity.LedgerEntryEntity {
 	return ledgerentity.LedgerEntryEntity{
 		TransactionID: transactionID,
 		AccountID:     accountID,
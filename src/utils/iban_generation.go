@@ -60,7 +60,8 @@ func (i *IbanHandler) GenerateAccountNumber(length int) string {
 
 // Domestic Check Digits (CC):
 // Calculated using weighted sums:
-// For BBBBGGGG: Weights [1, 2, 4, 8, 5, 10, 9, 7], modulo 11.
+// For BBBBGGGG: Weights [4, 8, 5, 10, 9, 7, 3, 6], modulo 11 (the ten weights below,
+// as if BBBBGGGG had two leading zeros).
 
 // For AAAAAAAAAA: Weights [1, 2, 4, 8, 5, 10, 9, 7, 3, 6], modulo 11.
 
@@ -72,7 +73,7 @@ func (i *IbanHandler) DomesticCheckDigits(bankDigits, branchDigits, accountNr st
 	bankDigitsWithBranchDigits := fmt.Sprintf("%s%s", bankDigits, branchDigits)
 	// fmt.Println(tag + " BBBBGGGG: ", bankDigitsWithBranchDigits)
 
-	weights := []int{1, 2, 4, 8, 5, 10, 9, 7}
+	weights := []int{4, 8, 5, 10, 9, 7, 3, 6}
 
 	sum := 0
 	for i, weight := range weights {
@@ -191,42 +192,16 @@ func (i *IbanHandler) ComputeIban(bban Bban, countryCode string) (string, error)
         DomesticCheckDigits: bban.DomesticCheckDigits,
         AccountNumber: bban.AccountNumber,
     }.String()
-	if len(ibanStr) != 24 {
+	if length := IbanLength(countryCode); len(ibanStr) != length {
 		return "", fmt.Errorf("generated IBAN has invalid length: %d", len(ibanStr))
 	}
 	return ibanStr, nil
 
 }
 
-//
-// ESkk BBBB GGGG CC AAAAAAAAAA
+// Verify validates an IBAN of any country of the registry (see Parse): length, structure,
+// MOD 97-10 check digits and national check digits.
 func (i *IbanHandler) Verify(iban string) bool {
-	if len(iban) < 24 {
-		return false
-	}
-	bankDigits := iban[4:8]
-	branchDigits := iban[8:12]
-	accountNr := iban[14:]
-	ibanControlDigits := iban[12:14]
-
-	controlDigits := i.DomesticCheckDigits(bankDigits, branchDigits, accountNr)
-	if  controlDigits != ibanControlDigits {
-		fmt.Printf("\nBank digits:%s\tBranch Digits:%s\tAccountNr:%s\n", bankDigits, branchDigits, accountNr)
-		fmt.Printf("Computed ControlDigits %s : TestControlDigits %s\n", controlDigits, ibanControlDigits)
-		return false
-	}
-	country := iban[0:2]
-	bban := iban[4:]
-	placeholder := "00"
-	// BBBBGGGGccAAAAAAAAAAES00
-	bbanCountry := fmt.Sprintf("%s%s%s", bban, country, placeholder)
-	bbanNumeric := i.numericBban(bbanCountry)
-	checkDigits, err := i.mod_97_10(bbanNumeric)
-	if err != nil {
-        
-		return false
-	}
-	testControlDigits := iban[2:4]
-	return checkDigits == testControlDigits
-
+	_, err := i.Parse(iban)
+	return err == nil
 }
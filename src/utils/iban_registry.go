@@ -0,0 +1,318 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ibanFormat is the entry of a country in the IBAN registry
+type ibanFormat struct {
+	// BBAN structure in the notation of the SWIFT registry: 4!n digits, 4!a upper case letters, 4!c alphanumeric
+	Structure string
+	// What each BBAN position is: b bank code, s branch code, x national check digits, a account number
+	Layout string
+	// National check digits, for the countries where every bank uses the same algorithm
+	Check func(bban string) bool
+}
+
+// IBAN formats of the SEPA countries, from the SWIFT IBAN registry
+var ibanRegistry = map[string]ibanFormat{
+	"AD": {"4!n4!n12!c", "bbbbssssaaaaaaaaaaaa", nil},
+	"AT": {"5!n11!n", "bbbbbaaaaaaaaaaa", nil},
+	"BE": {"3!n7!n2!n", "bbbaaaaaaaxx", belgianCheck},
+	"BG": {"4!a4!n2!n8!c", "bbbbssssaaaaaaaaaa", nil},
+	"CH": {"5!n12!c", "bbbbbaaaaaaaaaaaa", nil},
+	"CY": {"3!n5!n16!c", "bbbsssssaaaaaaaaaaaaaaaa", nil},
+	"CZ": {"4!n6!n10!n", "bbbbaaaaaaaaaaaaaaaa", czechCheck},
+	"DE": {"8!n10!n", "bbbbbbbbaaaaaaaaaa", nil},
+	"DK": {"4!n9!n1!n", "bbbbaaaaaaaaaa", nil},
+	"EE": {"2!n14!n", "bbaaaaaaaaaaaaaa", nil},
+	"ES": {"4!n4!n1!n1!n10!n", "bbbbssssxxaaaaaaaaaa", spanishCheck},
+	"FI": {"3!n11!n", "bbbaaaaaaaaaaa", nil},
+	"FR": {"5!n5!n11!c2!n", "bbbbbsssssaaaaaaaaaaaxx", ribCheck},
+	"GB": {"4!a6!n8!n", "bbbbssssssaaaaaaaa", nil},
+	"GI": {"4!a15!c", "bbbbaaaaaaaaaaaaaaa", nil},
+	"GR": {"3!n4!n16!c", "bbbssssaaaaaaaaaaaaaaaa", nil},
+	"HR": {"7!n10!n", "bbbbbbbaaaaaaaaaa", nil},
+	"HU": {"3!n4!n1!n15!n1!n", "bbbssssxaaaaaaaaaaaaaaax", hungarianCheck},
+	"IE": {"4!a6!n8!n", "bbbbssssssaaaaaaaa", nil},
+	"IS": {"4!n2!n6!n10!n", "bbbbaaaaaaaaaaaaaaaaaa", nil},
+	"IT": {"1!a5!n5!n12!c", "xbbbbbsssssaaaaaaaaaaaa", cinCheck},
+	"LI": {"5!n12!c", "bbbbbaaaaaaaaaaaa", nil},
+	"LT": {"5!n11!n", "bbbbbaaaaaaaaaaa", nil},
+	"LU": {"3!n13!c", "bbbaaaaaaaaaaaaa", nil},
+	"LV": {"4!a13!c", "bbbbaaaaaaaaaaaaa", nil},
+	"MC": {"5!n5!n11!c2!n", "bbbbbsssssaaaaaaaaaaaxx", ribCheck},
+	"MT": {"4!a5!n18!c", "bbbbsssssaaaaaaaaaaaaaaaaaa", nil},
+	"NL": {"4!a10!n", "bbbbaaaaaaaaaa", nil},
+	"NO": {"4!n6!n1!n", "bbbbaaaaaax", norwegianCheck},
+	"PL": {"8!n16!n", "bbbbbbbbaaaaaaaaaaaaaaaa", nil},
+	"PT": {"4!n4!n11!n2!n", "bbbbssssaaaaaaaaaaaxx", iso7064Check},
+	"RO": {"4!a16!c", "bbbbaaaaaaaaaaaaaaaa", nil},
+	"SE": {"3!n16!n1!n", "bbbaaaaaaaaaaaaaaaaa", nil},
+	"SI": {"5!n8!n2!n", "bbbbbaaaaaaaaxx", iso7064Check},
+	"SK": {"4!n6!n10!n", "bbbbaaaaaaaaaaaaaaaa", czechCheck},
+	"SM": {"1!a5!n5!n12!c", "xbbbbbsssssaaaaaaaaaaaa", cinCheck},
+	"VA": {"3!n15!n", "bbbaaaaaaaaaaaaaaa", nil},
+}
+
+// Why an IBAN is not valid
+const (
+	IbanReasonCountry             = "UNSUPPORTED_COUNTRY"
+	IbanReasonLength              = "LENGTH"
+	IbanReasonFormat              = "FORMAT"
+	IbanReasonCheckDigits         = "CHECK_DIGITS"
+	IbanReasonNationalCheckDigits = "NATIONAL_CHECK_DIGITS"
+)
+
+// IbanError is the error of Parse, with the reason of the failure
+type IbanError struct {
+	Reason  string
+	Message string
+}
+
+func (e *IbanError) Error() string {
+	return e.Message
+}
+
+func ibanError(reason string, format string, args ...any) error {
+	return &IbanError{Reason: reason, Message: fmt.Sprintf(format, args...)}
+}
+
+// ParsedIban is an IBAN split in the components of its country
+type ParsedIban struct {
+	CountryCode         string
+	CheckDigits         string
+	Bban                string
+	BankCode            string
+	BranchCode          string // Empty in the countries without one
+	NationalCheckDigits string // Empty in the countries without them
+	AccountNumber       string
+}
+
+func (p ParsedIban) String() string {
+	return p.CountryCode + p.CheckDigits + p.Bban
+}
+
+// NormalizeIban is the electronic format of an IBAN: no spaces, upper case
+func NormalizeIban(iban string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(iban), ""))
+}
+
+// PrintIban is the paper format of an IBAN: groups of four characters separated by spaces
+func PrintIban(iban string) string {
+	iban = NormalizeIban(iban)
+	groups := make([]string, 0, (len(iban)+3)/4)
+	for len(iban) > 4 {
+		groups = append(groups, iban[:4])
+		iban = iban[4:]
+	}
+	return strings.Join(append(groups, iban), " ")
+}
+
+// IsSupportedIbanCountry reports whether the IBANs of the country are in the registry
+func IsSupportedIbanCountry(countryCode string) bool {
+	_, ok := ibanRegistry[countryCode]
+	return ok
+}
+
+// IbanLength is the length of the IBANs of the country, 0 when it is not in the registry
+func IbanLength(countryCode string) int {
+	format, ok := ibanRegistry[countryCode]
+	if !ok {
+		return 0
+	}
+	return 4 + len(format.Layout)
+}
+
+/**
+* Parse validates an IBAN of a SEPA country and splits it in its components. It checks, in order, the
+* country, the length, the BBAN structure of the country, the MOD 97-10 check digits and the national
+* check digits when the country has them. Spaces are ignored and letters uppercased, so the printed
+* form (ES91 0182 0600 ...) is accepted. The error is an *IbanError.
+ */
+func (i *IbanHandler) Parse(iban string) (ParsedIban, error) {
+	iban = NormalizeIban(iban)
+	if len(iban) < 4 {
+		return ParsedIban{}, ibanError(IbanReasonLength, "IBAN is too short")
+	}
+	country := iban[0:2]
+	format, ok := ibanRegistry[country]
+	if !ok {
+		return ParsedIban{}, ibanError(IbanReasonCountry, "country %s is not supported", country)
+	}
+	if length := IbanLength(country); len(iban) != length {
+		return ParsedIban{}, ibanError(IbanReasonLength, "%s IBANs have %d characters, not %d", country, length, len(iban))
+	}
+	if !isDigits(iban[2:4]) {
+		return ParsedIban{}, ibanError(IbanReasonFormat, "check digits must be numeric")
+	}
+	bban := iban[4:]
+	if !matchesStructure(bban, format.Structure) {
+		return ParsedIban{}, ibanError(IbanReasonFormat, "BBAN does not match the %s structure %s", country, format.Structure)
+	}
+	checkDigits, err := i.mod_97_10(i.numericBban(bban + country + "00"))
+	if err != nil || checkDigits != iban[2:4] {
+		return ParsedIban{}, ibanError(IbanReasonCheckDigits, "check digits are not valid")
+	}
+	if format.Check != nil && !format.Check(bban) {
+		return ParsedIban{}, ibanError(IbanReasonNationalCheckDigits, "national check digits are not valid")
+	}
+
+	parsed := ParsedIban{CountryCode: country, CheckDigits: iban[2:4], Bban: bban}
+	for position, component := range format.Layout {
+		switch component {
+		case 'b':
+			parsed.BankCode += string(bban[position])
+		case 's':
+			parsed.BranchCode += string(bban[position])
+		case 'x':
+			parsed.NationalCheckDigits += string(bban[position])
+		default:
+			parsed.AccountNumber += string(bban[position])
+		}
+	}
+	return parsed, nil
+}
+
+// matchesStructure checks the characters of the BBAN against a structure of the registry, i.e. 4!n4!n2!n10!n
+func matchesStructure(bban string, structure string) bool {
+	position := 0
+	for structure != "" {
+		mark := strings.IndexByte(structure, '!')
+		if mark < 0 || mark+1 >= len(structure) {
+			return false
+		}
+		length, err := strconv.Atoi(structure[:mark])
+		if err != nil || position+length > len(bban) {
+			return false
+		}
+		for _, char := range bban[position : position+length] {
+			digit, letter := char >= '0' && char <= '9', char >= 'A' && char <= 'Z'
+			switch structure[mark+1] {
+			case 'n':
+				if !digit {
+					return false
+				}
+			case 'a':
+				if !letter {
+					return false
+				}
+			default:
+				if !digit && !letter {
+					return false
+				}
+			}
+		}
+		position += length
+		structure = structure[mark+2:]
+	}
+	return position == len(bban)
+}
+
+func isDigits(text string) bool {
+	for _, char := range text {
+		if char < '0' || char > '9' {
+			return false
+		}
+	}
+	return text != ""
+}
+
+// mod97 is the remainder of a number of any length written in decimal digits
+func mod97(digits string) int {
+	remainder := 0
+	for _, char := range digits {
+		remainder = (remainder*10 + int(char-'0')) % 97
+	}
+	return remainder
+}
+
+// weightedSum multiplies each digit by its weight, the weights starting over when they run out
+func weightedSum(digits string, weights []int) int {
+	sum := 0
+	for position, char := range digits {
+		sum += int(char-'0') * weights[position%len(weights)]
+	}
+	return sum
+}
+
+// Spain: two digits modulo 11, one for the bank and branch (BBBBGGGG), one for the account
+func spanishCheck(bban string) bool {
+	return (&IbanHandler{}).DomesticCheckDigits(bban[0:4], bban[4:8], bban[10:20]) == bban[8:10]
+}
+
+// Belgium: the first 10 digits modulo 97, 97 when the remainder is 0
+func belgianCheck(bban string) bool {
+	check := mod97(bban[:10])
+	if check == 0 {
+		check = 97
+	}
+	return fmt.Sprintf("%02d", check) == bban[10:]
+}
+
+// France and Monaco: the RIB key, 97 minus (89 bank + 15 branch + 3 account) modulo 97. The letters of the
+// account count as digits: A and J are 1, B, K and S are 2 and so on.
+func ribCheck(bban string) bool {
+	account := []byte(bban[10:21])
+	for position, char := range account {
+		if char >= 'A' && char <= 'Z' {
+			value := int(char-'A')%9 + 1
+			if char >= 'S' {
+				value = int(char-'S') + 2
+			}
+			account[position] = byte('0' + value)
+		}
+	}
+	key := 97 - (89*mod97(bban[0:5])+15*mod97(bban[5:10])+3*mod97(string(account)))%97
+	return fmt.Sprintf("%02d", key) == bban[21:23]
+}
+
+// Values of the characters in the odd positions for the Italian CIN, 0 to 9 as A to J
+var cinOddValues = []int{1, 0, 5, 7, 9, 13, 15, 17, 19, 21, 2, 4, 18, 20, 11, 3, 6, 8, 12, 14, 16, 10, 22, 25, 24, 23}
+
+// Italy and San Marino: the CIN letter, a sum modulo 26 of bank, branch and account, the characters
+// in odd positions converted with a table
+func cinCheck(bban string) bool {
+	sum := 0
+	for position, char := range bban[1:] {
+		value := int(char - 'A')
+		if char >= '0' && char <= '9' {
+			value = int(char - '0')
+		}
+		if position%2 == 0 {
+			value = cinOddValues[value]
+		}
+		sum += value
+	}
+	return bban[0] == byte('A'+sum%26)
+}
+
+// Czech Republic and Slovakia: the account prefix and the account number are multiples of 11 once weighted
+func czechCheck(bban string) bool {
+	return weightedSum(bban[4:10], []int{10, 5, 8, 4, 2, 1})%11 == 0 &&
+		weightedSum(bban[10:20], []int{6, 3, 7, 9, 10, 5, 8, 4, 2, 1})%11 == 0
+}
+
+// Hungary: the bank and branch with their check digit, and the account with its own, are multiples of 10
+// once weighted 9, 7, 3, 1
+func hungarianCheck(bban string) bool {
+	weights := []int{9, 7, 3, 1}
+	return weightedSum(bban[0:8], weights)%10 == 0 && weightedSum(bban[8:24], weights)%10 == 0
+}
+
+// Norway: the last digit is 11 minus the weighted sum of the others modulo 11, 0 when that is 11
+func norwegianCheck(bban string) bool {
+	remainder := weightedSum(bban[:10], []int{5, 4, 3, 2, 7, 6, 5, 4, 3, 2}) % 11
+	if remainder == 1 {
+		return false
+	}
+	return (11-remainder)%11 == int(bban[10]-'0')
+}
+
+// Portugal and Slovenia: ISO 7064 MOD 97-10 over the rest of the BBAN, as the IBAN check digits
+func iso7064Check(bban string) bool {
+	digits := bban[:len(bban)-2]
+	return fmt.Sprintf("%02d", 98-mod97(digits+"00")) == bban[len(bban)-2:]
+}
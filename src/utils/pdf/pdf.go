@@ -0,0 +1,254 @@
+// Package pdf writes simple text documents as PDF 1.4 without external tools: A4 pages, the standard
+// Helvetica fonts (no embedding) and horizontal rules. Text is laid out top to bottom with a cursor,
+// and a new page is started when it runs out of space.
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// A4 in points, 1/72 inch
+const (
+	PageWidth  = 595.28
+	PageHeight = 841.89
+	Margin     = 50.0
+	footerSize = 8.0
+)
+
+// Cell is a piece of text of a row, at X from the left margin. Right aligned cells end at X + Width.
+type Cell struct {
+	X          float64
+	Width      float64
+	Text       string
+	Bold       bool
+	AlignRight bool
+}
+
+// Document is a PDF being laid out. Every page is kept in memory until WriteTo.
+type Document struct {
+	Title     string
+	Author    string
+	CreatedAt time.Time
+	// Footer is printed at the bottom of every page, next to the page number
+	Footer string
+	// OnNewPage is called after a page break, i.e. to repeat the header of a table
+	OnNewPage func(d *Document)
+
+	pages []*bytes.Buffer
+	y     float64 // baseline of the next line, from the bottom of the page
+}
+
+func New(title string) *Document {
+	d := &Document{Title: title, CreatedAt: time.Now().UTC()}
+	d.newPage()
+	return d
+}
+
+func (d *Document) page() *bytes.Buffer {
+	return d.pages[len(d.pages)-1]
+}
+
+func (d *Document) newPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+	d.y = PageHeight - Margin
+}
+
+// Ensure starts a new page when there are less than height points left above the footer
+func (d *Document) Ensure(height float64) {
+	if d.y-height >= Margin+2*footerSize {
+		return
+	}
+	d.newPage()
+	if d.OnNewPage != nil {
+		d.OnNewPage(d)
+	}
+}
+
+// Space moves the cursor down
+func (d *Document) Space(height float64) {
+	d.y -= height
+}
+
+// Text writes a line at the left margin. Long regular text is wrapped at the right margin.
+func (d *Document) Text(size float64, bold bool, text string) {
+	lines := []string{text}
+	if !bold {
+		lines = wrap(text, size, PageWidth-2*Margin)
+	}
+	for _, line := range lines {
+		d.Row(size, Cell{Text: line, Bold: bold})
+	}
+}
+
+// Row writes the cells on one line
+func (d *Document) Row(size float64, cells ...Cell) {
+	d.Ensure(size * 1.5)
+	d.y -= size * 1.2
+	for _, cell := range cells {
+		x := Margin + cell.X
+		text := cell.Text
+		if cell.Width > 0 {
+			text = truncate(text, size, cell.Width)
+		}
+		if cell.AlignRight {
+			x += cell.Width - TextWidth(text, size)
+		}
+		d.text(d.page(), x, d.y, size, cell.Bold, text)
+	}
+	d.y -= size * 0.3
+}
+
+// Rule draws a horizontal line between the margins
+func (d *Document) Rule() {
+	d.Ensure(6)
+	d.y -= 3
+	fmt.Fprintf(d.page(), "0.5 w %.2f %.2f m %.2f %.2f l S\n", Margin, d.y, PageWidth-Margin, d.y)
+	d.y -= 3
+}
+
+func (d *Document) text(page *bytes.Buffer, x, y, size float64, bold bool, text string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(page, "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, y, escape(text))
+}
+
+// WriteTo writes the PDF file. The footer and the page numbers are added here, once the pages are known.
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	var out bytes.Buffer
+	offsets := []int{0}
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets)-1, body)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	const firstPage = 6
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	object(fmt.Sprintf("<< /Title (%s) /Author (%s) /CreationDate (D:%s) >>",
+		escape(d.Title), escape(d.Author), d.CreatedAt.UTC().Format("20060102150405Z")))
+	for i, page := range d.pages {
+		content := bytes.NewBuffer(append([]byte(nil), page.Bytes()...))
+		footer := fmt.Sprintf("Page %d of %d", i+1, len(d.pages))
+		if d.Footer != "" {
+			d.text(content, Margin, Margin, footerSize, false, d.Footer)
+		}
+		d.text(content, PageWidth-Margin-TextWidth(footer, footerSize), Margin, footerSize, false, footer)
+
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			PageWidth, PageHeight, firstPage+2*i+1))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets))
+	for _, offset := range offsets[1:] {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R /Info 5 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets), xref)
+	return out.WriteTo(w)
+}
+
+// Bytes returns the PDF file
+func (d *Document) Bytes() []byte {
+	var out bytes.Buffer
+	d.WriteTo(&out)
+	return out.Bytes()
+}
+
+// winAnsi encodes the text in WinAnsiEncoding, the encoding of the fonts. Latin-1 letters, so
+// the Spanish ones, are kept; the characters it does not have become '?'.
+func winAnsi(text string) []byte {
+	encoded := make([]byte, 0, len(text))
+	for _, r := range text {
+		switch {
+		case r == '€':
+			encoded = append(encoded, 0x80)
+		case r >= 32 && r < 127, r >= 0xA0 && r <= 0xFF:
+			encoded = append(encoded, byte(r))
+		case r == '\t' || r == '\n':
+			encoded = append(encoded, ' ')
+		default:
+			encoded = append(encoded, '?')
+		}
+	}
+	return encoded
+}
+
+// escape encodes the text as the content of a PDF literal string
+func escape(text string) string {
+	var escaped strings.Builder
+	for _, b := range winAnsi(text) {
+		if b == '(' || b == ')' || b == '\\' {
+			escaped.WriteByte('\\')
+		}
+		escaped.WriteByte(b)
+	}
+	return escaped.String()
+}
+
+// Widths of the Helvetica glyphs from space (32) to tilde (126), in 1/1000 of the font size
+var helveticaWidths = [...]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+// TextWidth is the width in points of the text in Helvetica. The accented letters are
+// measured as their base letter would be, 556.
+func TextWidth(text string, size float64) float64 {
+	width := 0
+	for _, b := range winAnsi(text) {
+		if b >= 32 && b <= 126 {
+			width += helveticaWidths[b-32]
+		} else {
+			width += 556
+		}
+	}
+	return float64(width) * size / 1000
+}
+
+// truncate cuts the text with an ellipsis so it fits the width
+func truncate(text string, size, width float64) string {
+	if TextWidth(text, size) <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && TextWidth(string(runes)+"...", size) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "..."
+}
+
+// wrap splits the text in lines that fit the width, at the spaces
+func wrap(text string, size, width float64) []string {
+	lines := []string{}
+	line := ""
+	for _, word := range strings.Fields(text) {
+		candidate := word
+		if line != "" {
+			candidate = line + " " + word
+		}
+		if line != "" && TextWidth(candidate, size) > width {
+			lines = append(lines, line)
+			candidate = word
+		}
+		line = candidate
+	}
+	return append(lines, line)
+}
@@ -3,24 +3,27 @@ package validators
 import (
 	"fmt"
 	"go.uber.org/zap"
+	"src/domain/money"
 	transaction_entity "src/domain/transaction"
 	errors "src/errors"
 	"strings"
 )
 
-func ValidateTransactionBalance(transaction transaction_entity.TransactionEntity, balance float64, logger *zap.Logger) errors.AppError {
+func ValidateTransactionBalance(transaction transaction_entity.TransactionEntity, balance money.Money, logger *zap.Logger) errors.AppError {
 	transactionType := strings.ToUpper(transaction.Type)
 
 	if transactionType == "ADD" {
 		return nil
 	}
-	if balance < transaction.Amount {
+	// the fee is paid on top of the amount
+	total := transaction.Amount.Add(transaction.FeeAmount)
+	if balance.LessThan(total) {
 		errStr := fmt.Sprintf(
 			"Not enough funds. Account %d has %v monetary units. Tried to %s %v units",
 			transaction.AccountID,
 			balance,
 			transactionType,
-			transaction.Amount,
+			total,
 		)
 		logger.Error(errStr)
 		return &errors.ErrNotEnoughFunds{Message: errStr}